@@ -0,0 +1,272 @@
+// cmd/npadmin/main.go
+//
+// Break-glass admin CLI. It talks to MongoDB directly through the same admin
+// service as the HTTP admin API, using the server's environment / .env.
+//
+// Build with:
+//   ./scripts/build.sh ./cmd/npadmin npadmin
+//
+// Usage:
+//   npadmin users    [-q text] [-role user|admin] [-disabled true|false] [-limit n] [-offset n]
+//   npadmin show     <id|email>
+//   npadmin disable  <id|email>
+//   npadmin enable   <id|email>
+//   npadmin sign-out <id|email>
+//   npadmin set-role <id|email> <user|admin>
+//   npadmin reindex
+//
+// Sign-outs issued here revoke refresh tokens at once. WebSocket streams held
+// by a running server close when it next checks the user (see
+// auth.userStatusTTL) or when the stream hits WS_MAX_SESSION_SEC.
+//
+// reindex rebuilds the embedded search index (SEARCH_BACKEND=embedded). The
+// server holds the index open, so stop it first.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"note-pulse/internal/clients/mongo"
+	"note-pulse/internal/clients/searchindex"
+	"note-pulse/internal/config"
+	"note-pulse/internal/services/admin"
+	"note-pulse/internal/services/auth"
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	codeUsage = 2
+	codeError = 1
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// errUsage marks bad command lines
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, err)
+		usage(os.Stderr)
+		os.Exit(codeUsage)
+	default:
+		fmt.Fprintln(os.Stderr, "npadmin:", err)
+		os.Exit(codeError)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `usage:
+  npadmin users    [-q text] [-role user|admin] [-disabled true|false] [-limit n] [-offset n]
+  npadmin show     <id|email>
+  npadmin disable  <id|email>
+  npadmin enable   <id|email>
+  npadmin sign-out <id|email>
+  npadmin set-role <id|email> <user|admin>
+  npadmin reindex
+`)
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	// Logs go to stderr so stdout stays machine readable
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	if _, _, err := mongo.Init(ctx, cfg, log); err != nil {
+		return err
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		_ = mongo.Shutdown(shutdownCtx)
+	}()
+
+	svc, err := newAdminService(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+
+	cmd, rest := args[0], args[1:]
+	switch cmd {
+	case "users":
+		return listUsers(ctx, svc, rest, out)
+	case "show", "disable", "enable", "sign-out":
+		if len(rest) != 1 {
+			return fmt.Errorf("%w: %s takes exactly one user", errUsage, cmd)
+		}
+		return userCommand(ctx, svc, cmd, rest[0], out)
+	case "set-role":
+		if len(rest) != 2 {
+			return fmt.Errorf("%w: set-role takes a user and a role", errUsage)
+		}
+		return setRole(ctx, svc, rest[0], rest[1], out)
+	case "reindex":
+		if len(rest) != 0 {
+			return fmt.Errorf("%w: reindex takes no arguments", errUsage)
+		}
+		return reindex(ctx, cfg, log, out)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+	}
+}
+
+// newAdminService wires the admin service exactly like the server does, minus
+// the WebSocket hub which lives in the server process
+func newAdminService(ctx context.Context, cfg config.Config, log *slog.Logger) (*admin.Service, error) {
+	db := mongo.DB()
+
+	usersRepo, err := mongo.NewUsersRepo(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokensRepo, err := mongo.NewRefreshTokensRepo(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	notesRepo, err := mongo.NewNotesRepo(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	authSvc := auth.NewService(usersRepo, refreshTokensRepo, cfg, log)
+	return admin.NewService(usersRepo, notesRepo, authSvc, log), nil
+}
+
+func listUsers(ctx context.Context, svc *admin.Service, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("users", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	q := fs.String("q", "", "email substring")
+	role := fs.String("role", "", "user or admin")
+	disabled := fs.String("disabled", "", "true or false")
+	limit := fs.Int("limit", 50, "page size")
+	offset := fs.Int("offset", 0, "offset")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	req := admin.ListUsersRequest{Q: *q, Role: *role, Limit: *limit, Offset: *offset}
+	if *disabled != "" {
+		b, err := strconv.ParseBool(*disabled)
+		if err != nil {
+			return fmt.Errorf("%w: -disabled must be true or false", errUsage)
+		}
+		req.Disabled = &b
+	}
+
+	resp, err := svc.ListUsers(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMAIL\tROLE\tDISABLED\tNOTES\tBYTES\tCREATED")
+	for _, u := range resp.Users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%d\t%d\t%s\n",
+			u.ID.Hex(), u.Email, u.EffectiveRole(), u.Disabled(),
+			u.Usage.NoteCount, u.Usage.StorageBytes, u.CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(tw, "\n%d of %d users\n", len(resp.Users), resp.TotalCount)
+	return tw.Flush()
+}
+
+func userCommand(ctx context.Context, svc *admin.Service, cmd, who string, out io.Writer) error {
+	user, err := svc.FindUser(ctx, who)
+	if err != nil {
+		return err
+	}
+
+	// The CLI has no admin identity; the zero ID disables the self-action guard
+	actor := bson.ObjectID{}
+
+	switch cmd {
+	case "disable":
+		user, err = svc.DisableUser(ctx, actor, user.ID)
+	case "enable":
+		user, err = svc.EnableUser(ctx, actor, user.ID)
+	case "sign-out":
+		err = svc.ForceSignOut(ctx, actor, user.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(out, user)
+}
+
+func setRole(ctx context.Context, svc *admin.Service, who, role string, out io.Writer) error {
+	if role != auth.RoleUser && role != auth.RoleAdmin {
+		return fmt.Errorf("%w: role must be %q or %q", errUsage, auth.RoleUser, auth.RoleAdmin)
+	}
+
+	user, err := svc.FindUser(ctx, who)
+	if err != nil {
+		return err
+	}
+	user, err = svc.SetRole(ctx, bson.ObjectID{}, user.ID, role)
+	if err != nil {
+		return err
+	}
+	return printJSON(out, user)
+}
+
+// reindex rebuilds the search index from every note in MongoDB
+func reindex(ctx context.Context, cfg config.Config, log *slog.Logger, out io.Writer) error {
+	idx, err := searchindex.Init(cfg)
+	if err != nil {
+		return fmt.Errorf("open search index: %w", err)
+	}
+	if idx == nil {
+		return fmt.Errorf("SEARCH_BACKEND is %s: %w", cfg.SearchBackend, notes.ErrNoSearchIndex)
+	}
+	defer idx.Close()
+
+	notesRepo, err := mongo.NewNotesRepo(ctx, mongo.DB())
+	if err != nil {
+		return err
+	}
+	// Reindexing changes no notes, so nothing is broadcast
+	svc := notes.NewService(notesRepo, nil, log)
+	svc.SetSearchIndex(idx)
+
+	start := time.Now()
+	n, err := svc.Reindex(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "indexed %d notes in %s\n", n, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
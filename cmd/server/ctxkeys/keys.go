@@ -3,5 +3,7 @@ package ctxkeys
 const (
 	UserIDKey    string = "userID"
 	UserEmailKey string = "userEmail"
+	SessionIDKey string = "sessionID"
+	UserRoleKey  string = "userRole"
 	ParentCtxKey string = "parentCtx"
 )
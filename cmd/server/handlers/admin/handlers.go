@@ -0,0 +1,236 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/admin"
+	"note-pulse/internal/services/auth"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the admin service
+type Service interface {
+	ListUsers(ctx context.Context, req admin.ListUsersRequest) (*admin.ListUsersResponse, error)
+	GetUser(ctx context.Context, id bson.ObjectID) (*admin.UserSummary, error)
+	DisableUser(ctx context.Context, actorID, id bson.ObjectID) (*admin.UserSummary, error)
+	EnableUser(ctx context.Context, actorID, id bson.ObjectID) (*admin.UserSummary, error)
+	ForceSignOut(ctx context.Context, actorID, id bson.ObjectID) error
+	SetRole(ctx context.Context, actorID, id bson.ObjectID, role string) (*admin.UserSummary, error)
+}
+
+// Handlers contains the admin HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new admin handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// targetUser returns the acting admin and the user named in the path
+func targetUser(c *fiber.Ctx, handlerName string) (bson.ObjectID, bson.ObjectID, error) {
+	actorID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, err
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid user ID parameter", "handler", handlerName, ctxkeys.UserIDKey, actorID.Hex(), "error", err)
+		return bson.ObjectID{}, bson.ObjectID{}, httperr.Fail(httperr.ErrInvalidUserID)
+	}
+	return actorID, id, nil
+}
+
+func serviceError(err error, handlerName string, actorID bson.ObjectID) error {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		return handlerutil.NotFoundError(err)
+	case errors.Is(err, admin.ErrSelfAction):
+		return httperr.Fail(httperr.E{Status: 409, Message: err.Error()})
+	}
+	logger.L().Error("admin service failed", "handler", handlerName, ctxkeys.UserIDKey, actorID.Hex(), "error", err)
+	return httperr.Fail(httperr.InternalError(err.Error()))
+}
+
+// ListUsers lists and searches users
+// @Summary List users
+// @Description Search by email substring, role or disabled flag. Each user carries note count and storage usage.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param q query string false "Email substring"
+// @Param role query string false "Role" Enums(user, admin)
+// @Param disabled query bool false "Only disabled (true) or enabled (false) users"
+// @Param limit query int false "Page size (1-200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} admin.ListUsersResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Router /admin/users [get]
+func (h *Handlers) ListUsers(c *fiber.Ctx) error {
+	actorID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req admin.ListUsersRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "ListUsers"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListUsers(c.Context(), req)
+	if err != nil {
+		return serviceError(err, "ListUsers", actorID)
+	}
+	return c.JSON(resp)
+}
+
+// GetUser shows one user
+// @Summary Get user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} admin.UserSummary
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /admin/users/{id} [get]
+func (h *Handlers) GetUser(c *fiber.Ctx) error {
+	actorID, id, err := targetUser(c, "GetUser")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.service.GetUser(c.Context(), id)
+	if err != nil {
+		return serviceError(err, "GetUser", actorID)
+	}
+	return c.JSON(user)
+}
+
+// DisableUser disables an account
+// @Summary Disable user
+// @Description Blocks sign-in, refresh and API access, revokes all sessions and closes live WebSocket streams.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} admin.UserSummary
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /admin/users/{id}/disable [post]
+func (h *Handlers) DisableUser(c *fiber.Ctx) error {
+	actorID, id, err := targetUser(c, "DisableUser")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.service.DisableUser(c.Context(), actorID, id)
+	if err != nil {
+		return serviceError(err, "DisableUser", actorID)
+	}
+	return c.JSON(user)
+}
+
+// EnableUser re-enables an account
+// @Summary Enable user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} admin.UserSummary
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /admin/users/{id}/enable [post]
+func (h *Handlers) EnableUser(c *fiber.Ctx) error {
+	actorID, id, err := targetUser(c, "EnableUser")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.service.EnableUser(c.Context(), actorID, id)
+	if err != nil {
+		return serviceError(err, "EnableUser", actorID)
+	}
+	return c.JSON(user)
+}
+
+// ForceSignOut signs a user out everywhere
+// @Summary Force sign-out
+// @Description Revokes every refresh token of the user and closes live WebSocket streams.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /admin/users/{id}/sign-out [post]
+func (h *Handlers) ForceSignOut(c *fiber.Ctx) error {
+	actorID, id, err := targetUser(c, "ForceSignOut")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.ForceSignOut(c.Context(), actorID, id); err != nil {
+		return serviceError(err, "ForceSignOut", actorID)
+	}
+	return c.SendStatus(204)
+}
+
+// SetRole changes a user's role
+// @Summary Set user role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param request body admin.SetRoleRequest true "New role"
+// @Success 200 {object} admin.UserSummary
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /admin/users/{id}/role [put]
+func (h *Handlers) SetRole(c *fiber.Ctx) error {
+	actorID, id, err := targetUser(c, "SetRole")
+	if err != nil {
+		return err
+	}
+
+	var req admin.SetRoleRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "SetRole"); err != nil {
+		return err
+	}
+
+	user, err := h.service.SetRole(c.Context(), actorID, id, req.Role)
+	if err != nil {
+		return serviceError(err, "SetRole", actorID)
+	}
+	return c.JSON(user)
+}
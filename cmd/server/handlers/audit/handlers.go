@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/audit"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the audit service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID, req audit.ListAuditRequest) (*audit.ListAuditResponse, error)
+	ListAll(ctx context.Context, req audit.ListAuditRequest) (*audit.ListAuditResponse, error)
+}
+
+// Handlers contains the audit HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new audit handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	if errors.Is(err, audit.ErrBadRequest) {
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+	}
+	logger.L().Error("audit service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+	return httperr.Fail(httperr.InternalError(err.Error()))
+}
+
+// List lists the caller's own audit entries
+// @Summary List own audit entries
+// @Description Security and data events of the caller's account, newest first: sign-ups, sign-ins (also failed ones), refreshes, sign-outs, password changes and note creates, updates and deletes. Pass next_before as before for the next page.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param action query string false "Action, e.g. auth.sign_in_failed or note.update"
+// @Param before query string false "Entries older than this entry ID"
+// @Param limit query int false "Page size (1-100)"
+// @Success 200 {object} audit.ListAuditResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Router /audit [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req audit.ListAuditRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "List"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// ListAll lists the audit entries of every account
+// @Summary List audit entries
+// @Description Audit entries of all accounts, newest first, optionally of one user or action.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param user_id query string false "User ID"
+// @Param action query string false "Action, e.g. auth.sign_in_failed or note.update"
+// @Param before query string false "Entries older than this entry ID"
+// @Param limit query int false "Page size (1-100)"
+// @Success 200 {object} audit.ListAuditResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Router /admin/audit [get]
+func (h *Handlers) ListAll(c *fiber.Ctx) error {
+	actorID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req audit.ListAuditRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "ListAll"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListAll(c.Context(), req)
+	if err != nil {
+		return serviceError(c, err, "ListAll", actorID)
+	}
+	return c.JSON(resp)
+}
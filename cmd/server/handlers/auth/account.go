@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// accountError maps account service errors to HTTP errors
+func accountError(err error, handlerName string, userID bson.ObjectID) error {
+	switch {
+	case errors.Is(err, auth.ErrWrongPassword):
+		return httperr.Fail(httperr.E{Status: 403, Message: err.Error()})
+	case errors.Is(err, auth.ErrUserNotFound):
+		return handlerutil.NotFoundError(err)
+	case errors.Is(err, auth.ErrEmailTaken):
+		return httperr.Fail(httperr.E{Status: 409, Message: err.Error()})
+	case errors.Is(err, auth.ErrInvalidEmailToken):
+		return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+	case errors.Is(err, auth.ErrMailNotConfigured):
+		logger.L().Error("account email not sent", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.E{Status: 503, Message: err.Error()})
+	}
+	logger.L().Error("account service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+	return httperr.Fail(httperr.InternalError(err.Error()))
+}
+
+// ChangePassword changes the caller's password
+// @Summary Change password
+// @Description Requires the current password. Every other session is signed out; the calling device stays signed in.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body auth.ChangePasswordRequest true "Change password request"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Router /me/password [post]
+func (h *Handlers) ChangePassword(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req auth.ChangePasswordRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "ChangePassword"); err != nil {
+		return err
+	}
+
+	currentSessionID, _ := c.Locals(ctxkeys.SessionIDKey).(string)
+
+	if err := h.authService.ChangePassword(c.Context(), userID, currentSessionID, req); err != nil {
+		return accountError(err, "ChangePassword", userID)
+	}
+
+	return c.SendStatus(204)
+}
+
+// RequestEmailChange starts an email change
+// @Summary Change email
+// @Description Requires the current password. A confirmation token is sent to the new address; the email changes once it is confirmed.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body auth.ChangeEmailRequest true "Change email request"
+// @Success 202
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Failure 503 {object} httperr.E
+// @Router /me/email [post]
+func (h *Handlers) RequestEmailChange(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req auth.ChangeEmailRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "RequestEmailChange"); err != nil {
+		return err
+	}
+
+	if err := h.authService.RequestEmailChange(c.Context(), userID, req); err != nil {
+		return accountError(err, "RequestEmailChange", userID)
+	}
+
+	return c.SendStatus(202)
+}
+
+// ConfirmEmailChange completes an email change
+// @Summary Confirm email change
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body auth.ConfirmEmailRequest true "Confirm email request"
+// @Success 200 {object} auth.User
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /me/email/confirm [post]
+func (h *Handlers) ConfirmEmailChange(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req auth.ConfirmEmailRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "ConfirmEmailChange"); err != nil {
+		return err
+	}
+
+	user, err := h.authService.ConfirmEmailChange(c.Context(), userID, req)
+	if err != nil {
+		return accountError(err, "ConfirmEmailChange", userID)
+	}
+
+	return c.JSON(user)
+}
+
+// DeleteAccount deletes the caller's account
+// @Summary Delete account
+// @Description Requires the current password. The user is signed out everywhere at once; notes and all other data are purged in the background.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body auth.DeleteAccountRequest true "Delete account request"
+// @Success 202
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Router /me [delete]
+func (h *Handlers) DeleteAccount(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req auth.DeleteAccountRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "DeleteAccount"); err != nil {
+		return err
+	}
+
+	if err := h.authService.DeleteAccount(c.Context(), userID, req); err != nil {
+		return accountError(err, "DeleteAccount", userID)
+	}
+
+	return c.SendStatus(202)
+}
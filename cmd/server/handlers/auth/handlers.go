@@ -23,6 +23,12 @@ type Service interface {
 	Refresh(ctx context.Context, rawRefreshToken string) (*auth.Response, error)
 	SignOut(ctx context.Context, userID bson.ObjectID, rawRefreshToken string) error
 	SignOutAll(ctx context.Context, userID bson.ObjectID) error
+	ListSessions(ctx context.Context, userID bson.ObjectID, currentSessionID string) (*auth.ListSessionsResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) error
+	ChangePassword(ctx context.Context, userID bson.ObjectID, currentSessionID string, req auth.ChangePasswordRequest) error
+	RequestEmailChange(ctx context.Context, userID bson.ObjectID, req auth.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, userID bson.ObjectID, req auth.ConfirmEmailRequest) (*auth.User, error)
+	DeleteAccount(ctx context.Context, userID bson.ObjectID, req auth.DeleteAccountRequest) error
 }
 
 // Handlers contains the auth HTTP handlers
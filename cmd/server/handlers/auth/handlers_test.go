@@ -66,6 +66,42 @@ func (m *MockAuthService) SignOutAll(ctx context.Context, userID bson.ObjectID)
 	return args.Error(0)
 }
 
+func (m *MockAuthService) ListSessions(ctx context.Context, userID bson.ObjectID, currentSessionID string) (*auth.ListSessionsResponse, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.ListSessionsResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ChangePassword(ctx context.Context, userID bson.ObjectID, currentSessionID string, req auth.ChangePasswordRequest) error {
+	args := m.Called(ctx, userID, currentSessionID, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestEmailChange(ctx context.Context, userID bson.ObjectID, req auth.ChangeEmailRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ConfirmEmailChange(ctx context.Context, userID bson.ObjectID, req auth.ConfirmEmailRequest) (*auth.User, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+
+func (m *MockAuthService) DeleteAccount(ctx context.Context, userID bson.ObjectID, req auth.DeleteAccountRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
 // AuthTestSetup contains common test setup data
 type AuthTestSetup struct {
 	MockService *MockAuthService
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ListSessions lists the devices the user is signed in on
+// @Summary List active sessions
+// @Description Every signed-in device with its user agent, IP and refresh times. The caller's own session is flagged as current.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} auth.ListSessionsResponse
+// @Failure 401 {object} httperr.E
+// @Failure 500 {object} httperr.E
+// @Router /sessions [get]
+func (h *Handlers) ListSessions(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	currentSessionID, _ := c.Locals(ctxkeys.SessionIDKey).(string)
+
+	resp, err := h.authService.ListSessions(c.Context(), userID, currentSessionID)
+	if err != nil {
+		logger.L().Error("list sessions service failed", "handler", "ListSessions", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	return c.JSON(resp)
+}
+
+// RevokeSession signs a single device out
+// @Summary Revoke a session
+// @Description Revokes the session's refresh token and closes its live WebSocket connections. Other devices stay signed in.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /sessions/{id} [delete]
+func (h *Handlers) RevokeSession(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid session ID parameter", "handler", "RevokeSession", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.ErrBadRequest)
+	}
+
+	if err := h.authService.RevokeSession(c.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			return handlerutil.NotFoundError(err)
+		}
+		logger.L().Error("revoke session service failed", "handler", "RevokeSession", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	return c.SendStatus(204)
+}
@@ -35,6 +35,16 @@ func InvalidInput(err error) error {
 	})
 }
 
+// TooLarge reports content over a configured size limit
+func TooLarge(err error) error {
+	return Fail(E{Status: 413, Message: err.Error()})
+}
+
+// QuotaExceeded reports a write that would take a user past a plan limit
+func QuotaExceeded(err error) error {
+	return Fail(E{Status: 422, Message: err.Error()})
+}
+
 // InternalError returns an internal server error with the given message
 func InternalError(message string) E {
 	return E{Status: 500, Message: message}
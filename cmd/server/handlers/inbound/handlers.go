@@ -0,0 +1,106 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/inbound"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the inbound mail service
+type Service interface {
+	Address(ctx context.Context, userID bson.ObjectID) (*inbound.AddressResponse, error)
+	Rotate(ctx context.Context, userID bson.ObjectID) (*inbound.AddressResponse, error)
+	Delete(ctx context.Context, userID bson.ObjectID) error
+}
+
+// Handlers contains the inbound address HTTP handlers
+type Handlers struct {
+	service Service
+}
+
+// NewHandlers creates new inbound address handlers
+func NewHandlers(service Service) *Handlers {
+	return &Handlers{service: service}
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	if errors.Is(err, inbound.ErrAddressNotFound) {
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 404, Message: err.Error()})
+	}
+	logger.L().Error("inbound service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+	return httperr.Fail(httperr.InternalError(err.Error()))
+}
+
+// Get returns the caller's inbound address
+// @Summary Get inbound address
+// @Description Mail to this address becomes a note: the subject is its title, the text its body, followed by the names and sizes of the attachments.
+// @Tags inbound
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} inbound.AddressResponse
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /me/inbound-address [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.Address(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Rotate gives the caller a new inbound address
+// @Summary Create inbound address
+// @Description Creates the caller's inbound address, or replaces it: mail to the previous one bounces from then on.
+// @Tags inbound
+// @Produce json
+// @Security Bearer
+// @Success 201 {object} inbound.AddressResponse
+// @Failure 401 {object} httperr.E
+// @Router /me/inbound-address [post]
+func (h *Handlers) Rotate(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.Rotate(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "Rotate", userID)
+	}
+	return c.Status(201).JSON(resp)
+}
+
+// Delete stops the caller's inbound address
+// @Summary Delete inbound address
+// @Tags inbound
+// @Security Bearer
+// @Success 204
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /me/inbound-address [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
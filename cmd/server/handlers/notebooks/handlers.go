@@ -0,0 +1,218 @@
+package notebooks
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notebooks"
+	"note-pulse/internal/services/notes"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the notebooks service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID) (*notebooks.ListNotebooksResponse, error)
+	Create(ctx context.Context, userID bson.ObjectID, req notebooks.CreateNotebookRequest) (*notebooks.Notebook, error)
+	Get(ctx context.Context, userID, notebookID bson.ObjectID) (*notebooks.Notebook, error)
+	Update(ctx context.Context, userID, notebookID bson.ObjectID, req notebooks.UpdateNotebookRequest) (*notebooks.Notebook, error)
+	Delete(ctx context.Context, userID, notebookID bson.ObjectID, req notebooks.DeleteNotebookRequest) error
+}
+
+// Handlers contains the notebooks HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new notebooks handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// notebookID returns the caller and the notebook named by the :id path param
+func notebookID(c *fiber.Ctx, handlerName string) (bson.ObjectID, bson.ObjectID, error) {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, err
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid notebook ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return bson.ObjectID{}, bson.ObjectID{}, httperr.Fail(httperr.ErrBadRequest)
+	}
+	return userID, id, nil
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	var status int
+	switch {
+	case errors.Is(err, notebooks.ErrNotebookNotFound):
+		status = 404
+	case errors.Is(err, notebooks.ErrNotebookExists),
+		errors.Is(err, notebooks.ErrTooManyNotebooks):
+		status = 409
+	case errors.Is(err, notebooks.ErrTooDeep),
+		errors.Is(err, notebooks.ErrNotebookCycle),
+		errors.Is(err, notes.ErrBadRequest):
+		status = 400
+	default:
+		logger.L().Error("notebooks service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	c.Locals("log_level", "info")
+	return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+}
+
+// List lists the caller's notebooks
+// @Summary List notebooks
+// @Description Notebooks come in path order, each before the notebooks nested in it.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} notebooks.ListNotebooksResponse
+// @Failure 401 {object} httperr.E
+// @Router /notebooks [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Create creates a notebook
+// @Summary Create notebook
+// @Description Sibling notebooks have distinct names; notebooks nest up to 8 levels.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body notebooks.CreateNotebookRequest true "Notebook"
+// @Success 201 {object} notebooks.Notebook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /notebooks [post]
+func (h *Handlers) Create(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req notebooks.CreateNotebookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Create"); err != nil {
+		return err
+	}
+
+	nb, err := h.service.Create(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "Create", userID)
+	}
+	return c.Status(201).JSON(nb)
+}
+
+// Get returns a notebook
+// @Summary Get notebook
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Notebook ID"
+// @Success 200 {object} notebooks.Notebook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notebooks/{id} [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, id, err := notebookID(c, "Get")
+	if err != nil {
+		return err
+	}
+
+	nb, err := h.service.Get(c.Context(), userID, id)
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(nb)
+}
+
+// Update renames or moves a notebook
+// @Summary Rename or move notebook
+// @Description A parent_id moves the notebook and everything nested in it; "" makes it top-level.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Notebook ID"
+// @Param request body notebooks.UpdateNotebookRequest true "Fields to change"
+// @Success 200 {object} notebooks.Notebook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /notebooks/{id} [patch]
+func (h *Handlers) Update(c *fiber.Ctx) error {
+	userID, id, err := notebookID(c, "Update")
+	if err != nil {
+		return err
+	}
+
+	var req notebooks.UpdateNotebookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Update"); err != nil {
+		return err
+	}
+
+	nb, err := h.service.Update(c.Context(), userID, id, req)
+	if err != nil {
+		return serviceError(c, err, "Update", userID)
+	}
+	return c.JSON(nb)
+}
+
+// Delete deletes a notebook
+// @Summary Delete notebook
+// @Description Deletes the notebook and every notebook nested in it. Their notes move to the Inbox, or are deleted with notes=delete.
+// @Tags notebooks
+// @Security Bearer
+// @Param id path string true "Notebook ID"
+// @Param notes query string false "What happens to the notes" Enums(inbox, delete)
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notebooks/{id} [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, id, err := notebookID(c, "Delete")
+	if err != nil {
+		return err
+	}
+
+	var req notebooks.DeleteNotebookRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "Delete"); err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID, id, req); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,235 @@
+package notes
+
+import (
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// attachmentPath extracts the user, note and, when withAttachment is set,
+// attachment IDs
+func attachmentPath(c *fiber.Ctx, handlerName string, withAttachment bool) (bson.ObjectID, bson.ObjectID, bson.ObjectID, error) {
+	var attachmentID bson.ObjectID
+
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, attachmentID, err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, handlerName)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, attachmentID, err
+	}
+
+	if withAttachment {
+		attachmentID, err = bson.ObjectIDFromHex(c.Params("attachmentId"))
+		if err != nil {
+			logger.L().Info("invalid attachment ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+			return bson.ObjectID{}, bson.ObjectID{}, attachmentID, httperr.Fail(httperr.ErrBadRequest)
+		}
+	}
+	return userID, noteID, attachmentID, nil
+}
+
+// attachmentError maps attachment failures
+func attachmentError(c *fiber.Ctx, err error, handlerName string, userID, noteID bson.ObjectID) error {
+	status := 0
+	switch {
+	case errors.Is(err, notes.ErrAttachmentNotFound):
+		c.Locals("log_level", "info")
+		return handlerutil.NotFoundError(notes.ErrAttachmentNotFound)
+	case errors.Is(err, notes.ErrBadRequest):
+		status = 400
+	case errors.Is(err, notes.ErrTooManyAttachments):
+		status = 409
+	case errors.Is(err, notes.ErrAttachmentTooLarge):
+		status = 413
+	case errors.Is(err, notes.ErrUnsupportedMedia):
+		status = 415
+	case errors.Is(err, notes.ErrNoBlobStore):
+		status = 501
+	}
+	if status != 0 {
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+	}
+	if werr := workspaceError(c, err); werr != nil {
+		return werr
+	}
+	if qerr := quotaError(c, err); qerr != nil {
+		return qerr
+	}
+	return handlerutil.HandleServiceError(err, handlerName, userID, &noteID, notes.ErrNoteNotFound)
+}
+
+// UploadAttachment handles attaching a file to a note
+// @Summary Upload an attachment
+// @Description Sends the file as the "file" field of a multipart form. Its type is sniffed from the content; images get a thumbnail.
+// @Tags notes
+// @Accept mpfd
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} notes.Attachment
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Failure 413 {object} httperr.E
+// @Failure 415 {object} httperr.E
+// @Failure 422 {object} httperr.E
+// @Router /notes/{id}/attachments [post]
+func (h *Handlers) UploadAttachment(c *fiber.Ctx) error {
+	userID, noteID, _, err := attachmentPath(c, "UploadAttachment", false)
+	if err != nil {
+		return err
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.Locals("log_level", "info")
+		logger.L().Info("missing attachment file", "handler", "UploadAttachment", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.E{Status: 400, Message: "multipart field file is required"})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		logger.L().Error("failed to open uploaded file", "handler", "UploadAttachment", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.ErrInternal)
+	}
+	defer f.Close()
+
+	resp, err := h.service.Upload(c.Context(), userID, noteID, notes.UploadRequest{Name: fh.Filename, Size: fh.Size, Content: f})
+	if err != nil {
+		return attachmentError(c, err, "UploadAttachment", userID, noteID)
+	}
+
+	return c.Status(201).JSON(resp)
+}
+
+// ListAttachments handles listing the attachments of a note
+// @Summary List attachments
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Success 200 {object} notes.AttachmentsResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/attachments [get]
+func (h *Handlers) ListAttachments(c *fiber.Ctx) error {
+	userID, noteID, _, err := attachmentPath(c, "ListAttachments", false)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListAttachments(c.Context(), userID, noteID)
+	if err != nil {
+		return attachmentError(c, err, "ListAttachments", userID, noteID)
+	}
+
+	return c.JSON(resp)
+}
+
+// DownloadAttachment handles downloading an attachment
+// @Summary Download an attachment
+// @Description Images are served inline, anything else as a download. The ETag is the SHA-256 of the content.
+// @Tags notes
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/attachments/{attachmentId} [get]
+func (h *Handlers) DownloadAttachment(c *fiber.Ctx) error {
+	return h.sendAttachment(c, "DownloadAttachment", false)
+}
+
+// AttachmentThumbnail handles downloading the thumbnail of an image attachment
+// @Summary Download an attachment thumbnail
+// @Description A PNG at most 256 pixels on each side. Attachments whose thumbnail field is false have none.
+// @Tags notes
+// @Produce png
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/attachments/{attachmentId}/thumbnail [get]
+func (h *Handlers) AttachmentThumbnail(c *fiber.Ctx) error {
+	return h.sendAttachment(c, "AttachmentThumbnail", true)
+}
+
+func (h *Handlers) sendAttachment(c *fiber.Ctx, handlerName string, thumb bool) error {
+	userID, noteID, attachmentID, err := attachmentPath(c, handlerName, true)
+	if err != nil {
+		return err
+	}
+
+	a, rc, err := h.service.OpenAttachment(c.Context(), userID, noteID, attachmentID, thumb)
+	if err != nil {
+		return attachmentError(c, err, handlerName, userID, noteID)
+	}
+
+	contentType, disposition, size := a.MIME, "attachment", a.Size
+	if strings.HasPrefix(a.MIME, "image/") {
+		disposition = "inline"
+	}
+	etag := a.SHA256
+	if thumb {
+		contentType, disposition, size = "image/png", "inline", -1
+		etag += "-thumb"
+	}
+
+	// The sniffed type is trusted only because browsers are told not to
+	// second-guess it
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": a.Name}))
+	c.Set(fiber.HeaderETag, strconv.Quote(etag))
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	return c.SendStream(rc, int(size))
+}
+
+// DeleteAttachment handles removing an attachment
+// @Summary Delete an attachment
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/attachments/{attachmentId} [delete]
+func (h *Handlers) DeleteAttachment(c *fiber.Ctx) error {
+	userID, noteID, attachmentID, err := attachmentPath(c, "DeleteAttachment", true)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteAttachment(c.Context(), userID, noteID, attachmentID); err != nil {
+		return attachmentError(c, err, "DeleteAttachment", userID, noteID)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,93 @@
+package notes
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Move handles placing a note on a board
+// @Summary Place a note on a board
+// @Description Sets the board, coordinates, size and z-order of a note without changing updated_at. Connected clients get a lightweight moved event; moves of one note are coalesced while a drag lasts.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param request body notes.PositionRequest true "Position"
+// @Success 200 {object} notes.NoteResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/position [patch]
+func (h *Handlers) Move(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, "Move")
+	if err != nil {
+		return err
+	}
+
+	var req notes.PositionRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Move"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Move(c.Context(), userID, noteID, req)
+	if err != nil {
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
+		return handlerutil.HandleServiceError(err, "Move", userID, &noteID, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
+
+// Arrange handles placing many notes at once
+// @Summary Arrange notes on boards
+// @Description Places up to 200 notes of one workspace in a single write. Notes outside the workspace are skipped and left out of the response.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body notes.ArrangeRequest true "Positions"
+// @Success 200 {object} notes.ArrangeResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/arrange [post]
+func (h *Handlers) Arrange(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req notes.ArrangeRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Arrange"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Arrange(c.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, notes.ErrBadRequest) {
+			c.Locals("log_level", "info")
+			return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+		}
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
+		return handlerutil.HandleServiceError(err, "Arrange", userID, nil, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
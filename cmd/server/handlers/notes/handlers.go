@@ -3,6 +3,7 @@ package notes
 import (
 	"context"
 	"errors"
+	"io"
 	"note-pulse/cmd/server/handlers/handlerutil"
 	"note-pulse/cmd/server/handlers/httperr"
 	"note-pulse/internal/services/notes"
@@ -18,6 +19,22 @@ type Service interface {
 	List(ctx context.Context, userID bson.ObjectID, req notes.ListNotesRequest) (*notes.ListNotesResponse, error)
 	Update(ctx context.Context, userID, noteID bson.ObjectID, req notes.UpdateNoteRequest) (*notes.NoteResponse, error)
 	Delete(ctx context.Context, userID, noteID bson.ObjectID) error
+	Related(ctx context.Context, userID, noteID bson.ObjectID, req notes.RelatedNotesRequest) (*notes.RelatedNotesResponse, error)
+	AddItem(ctx context.Context, userID, noteID bson.ObjectID, req notes.AddItemRequest) (*notes.ItemResponse, error)
+	UpdateItem(ctx context.Context, userID, noteID, itemID bson.ObjectID, req notes.UpdateItemRequest) (*notes.ItemResponse, error)
+	DeleteItem(ctx context.Context, userID, noteID, itemID bson.ObjectID) error
+	ReorderItems(ctx context.Context, userID, noteID bson.ObjectID, req notes.ReorderItemsRequest) (*notes.NoteResponse, error)
+	Upload(ctx context.Context, userID, noteID bson.ObjectID, req notes.UploadRequest) (*notes.Attachment, error)
+	ListAttachments(ctx context.Context, userID, noteID bson.ObjectID) (*notes.AttachmentsResponse, error)
+	OpenAttachment(ctx context.Context, userID, noteID, attachmentID bson.ObjectID, thumb bool) (*notes.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, userID, noteID, attachmentID bson.ObjectID) error
+	Move(ctx context.Context, userID, noteID bson.ObjectID, req notes.PositionRequest) (*notes.NoteResponse, error)
+	Arrange(ctx context.Context, userID bson.ObjectID, req notes.ArrangeRequest) (*notes.ArrangeResponse, error)
+	MoveToNotebook(ctx context.Context, userID, noteID bson.ObjectID, req notes.NotebookRequest) (*notes.NoteResponse, error)
+	CopyToNotebook(ctx context.Context, userID, noteID bson.ObjectID, req notes.NotebookRequest) (*notes.NoteResponse, error)
+	Backlinks(ctx context.Context, userID, noteID bson.ObjectID, req notes.BacklinksRequest) (*notes.BacklinksResponse, error)
+	Graph(ctx context.Context, userID bson.ObjectID, req notes.GraphRequest) (*notes.GraphResponse, error)
+	Usage(ctx context.Context, userID bson.ObjectID) (*notes.UsageResponse, error)
 }
 
 // Handlers contains the notes HTTP handlers
@@ -34,6 +51,19 @@ func NewHandlers(service Service, validator *validator.Validate) *Handlers {
 	}
 }
 
+// workspaceError maps workspace access failures, returning nil for any other error
+func workspaceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, notes.ErrWorkspaceNotFound):
+		c.Locals("log_level", "info")
+		return handlerutil.NotFoundError(notes.ErrWorkspaceNotFound)
+	case errors.Is(err, notes.ErrWorkspaceReadOnly):
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 403, Message: notes.ErrWorkspaceReadOnly.Error()})
+	}
+	return nil
+}
+
 // Create handles note creation
 // @Summary Create a new note
 // @Tags notes
@@ -44,6 +74,10 @@ func NewHandlers(service Service, validator *validator.Validate) *Handlers {
 // @Success 201 {object} notes.NoteResponse
 // @Failure 400 {object} httperr.E
 // @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 413 {object} httperr.E
+// @Failure 422 {object} httperr.E
 // @Router /notes [post]
 func (h *Handlers) Create(c *fiber.Ctx) error {
 	userID, err := handlerutil.GetUserID(c)
@@ -58,6 +92,19 @@ func (h *Handlers) Create(c *fiber.Ctx) error {
 
 	resp, err := h.service.Create(c.Context(), userID, req)
 	if err != nil {
+		if errors.Is(err, notes.ErrBadRequest) || errors.Is(err, notes.ErrChecklistFull) {
+			c.Locals("log_level", "info")
+			return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+		}
+		if nerr := notebookError(c, err); nerr != nil {
+			return nerr
+		}
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
+		if qerr := quotaError(c, err); qerr != nil {
+			return qerr
+		}
 		return handlerutil.HandleServiceError(err, "Create", userID, nil, notes.ErrNoteNotFound)
 	}
 
@@ -70,18 +117,28 @@ func (h *Handlers) Create(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param workspace_id query string false "Shared workspace to list; defaults to the personal workspace"
 // @Param limit query int false "Limit (default: 50, max: 100)" minimum(1) maximum(100)
 // @Param cursor query string false "Cursor for pagination. Cannot be used with offset or anchor."
 // @Param anchor query string false "Centre the window on this note id. Cannot be used with offset or cursor."
 // @Param span query int false "How many notes to return (default:limit)" minimum(1) maximum(100)
-// @Param q query string false "Full-text search in title or body"
+// @Param q query string false "Search query: free words and \"phrases\" use the full-text index; title:, body:, color:#RRGGBB, created: and updated: (>2026-01-01, <=2026-02-01T12:00:00Z) filter; -term excludes. Results carry highlights with match offsets and a body snippet"
 // @Param color query string false "Hex color filter (#RRGGBB)"
-// @Param sort query string false "Sort field: created_at|updated_at|title"
+// @Param sort query string false "Sort field: created_at|updated_at|title|relevance|due_at (relevance needs q of 3+ characters and no anchor; due_at lists only notes with a due date)"
 // @Param order query string false "asc|desc (default desc)"
 // @Param offset query int false "Offset for absolute positioning (0-50,000). Cannot be used with cursor or anchor." minimum(0) maximum(50000)
+// @Param similar_to query string false "Rank notes by similarity to this text; implies sort=relevance and cannot be combined with free text in q"
+// @Param due_before query string false "Only notes due before this RFC 3339 time"
+// @Param overdue query bool false "Only notes whose due date has passed"
+// @Param board_id query string false "Only notes placed on this board"
+// @Param viewport query string false "x,y,width,height: only placed notes intersecting this rectangle; without board_id the default board"
+// @Param notebook query string false "Only personal notes of this notebook ID, or of the Inbox with inbox"
+// @Param recursive query bool false "With notebook, also the notes of its nested notebooks"
+// @Param render query string false "html adds rendered_html: Markdown notes rendered, plain ones escaped" Enums(html)
 // @Success 200 {object} notes.ListNotesResponse
 // @Failure 400 {object} httperr.E
 // @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
 // @Failure 416 {object} httperr.E
 // @Router /notes [get]
 func (h *Handlers) List(c *fiber.Ctx) error {
@@ -97,6 +154,11 @@ func (h *Handlers) List(c *fiber.Ctx) error {
 
 	resp, err := h.service.List(c.Context(), userID, req)
 	if err != nil {
+		var qerr *notes.QueryError
+		if errors.As(err, &qerr) {
+			c.Locals("log_level", "info")
+			return httperr.Fail(httperr.E{Status: 400, Message: qerr.Error()})
+		}
 		if errors.Is(err, notes.ErrBadRequest) {
 			c.Locals("log_level", "info")
 			return httperr.Fail(httperr.ErrBadRequest)
@@ -105,6 +167,12 @@ func (h *Handlers) List(c *fiber.Ctx) error {
 			c.Locals("log_level", "info")
 			return httperr.Fail(httperr.ErrRequestedRangeNotSatisfiable)
 		}
+		if nerr := notebookError(c, err); nerr != nil {
+			return nerr
+		}
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
 		return handlerutil.HandleServiceError(err, "List", userID, nil, notes.ErrNoteNotFound)
 	}
 
@@ -122,6 +190,9 @@ func (h *Handlers) List(c *fiber.Ctx) error {
 // @Success 200 {object} notes.NoteResponse
 // @Failure 400 {object} httperr.E
 // @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 413 {object} httperr.E
+// @Failure 422 {object} httperr.E
 // @Router /notes/{id} [patch]
 func (h *Handlers) Update(c *fiber.Ctx) error {
 	userID, err := handlerutil.GetUserID(c)
@@ -141,6 +212,16 @@ func (h *Handlers) Update(c *fiber.Ctx) error {
 
 	resp, err := h.service.Update(c.Context(), userID, noteID, req)
 	if err != nil {
+		if errors.Is(err, notes.ErrBadRequest) {
+			c.Locals("log_level", "info")
+			return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+		}
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
+		if qerr := quotaError(c, err); qerr != nil {
+			return qerr
+		}
 		return handlerutil.HandleServiceError(err, "Update", userID, &noteID, notes.ErrNoteNotFound)
 	}
 
@@ -157,6 +238,7 @@ func (h *Handlers) Update(c *fiber.Ctx) error {
 // @Success 204
 // @Failure 400 {object} httperr.E
 // @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
 // @Router /notes/{id} [delete]
 func (h *Handlers) Delete(c *fiber.Ctx) error {
 	userID, err := handlerutil.GetUserID(c)
@@ -171,8 +253,49 @@ func (h *Handlers) Delete(c *fiber.Ctx) error {
 
 	err = h.service.Delete(c.Context(), userID, noteID)
 	if err != nil {
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
 		return handlerutil.HandleServiceError(err, "Delete", userID, &noteID, notes.ErrNoteNotFound)
 	}
 
 	return c.SendStatus(204)
 }
+
+// Related handles listing the notes most similar to a note
+// @Summary List related notes
+// @Description Notes of the same workspace ranked by the cosine similarity of their embeddings; each note's score is the similarity
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param limit query int false "How many notes to return (default 10)" minimum(1) maximum(50)
+// @Param render query string false "html adds rendered_html to every note" Enums(html)
+// @Success 200 {object} notes.RelatedNotesResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/related [get]
+func (h *Handlers) Related(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, "Related")
+	if err != nil {
+		return err
+	}
+
+	var req notes.RelatedNotesRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "Related"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Related(c.Context(), userID, noteID, req)
+	if err != nil {
+		return handlerutil.HandleServiceError(err, "Related", userID, &noteID, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
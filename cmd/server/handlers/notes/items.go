@@ -0,0 +1,193 @@
+package notes
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// itemPath extracts the user, note and, when withItem is set, item IDs
+func itemPath(c *fiber.Ctx, handlerName string, withItem bool) (bson.ObjectID, bson.ObjectID, bson.ObjectID, error) {
+	var itemID bson.ObjectID
+
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, itemID, err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, handlerName)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, itemID, err
+	}
+
+	if withItem {
+		itemID, err = bson.ObjectIDFromHex(c.Params("itemId"))
+		if err != nil {
+			logger.L().Info("invalid item ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+			return bson.ObjectID{}, bson.ObjectID{}, itemID, httperr.Fail(httperr.ErrBadRequest)
+		}
+	}
+	return userID, noteID, itemID, nil
+}
+
+// itemError maps checklist item failures
+func itemError(c *fiber.Ctx, err error, handlerName string, userID, noteID bson.ObjectID) error {
+	switch {
+	case errors.Is(err, notes.ErrItemNotFound):
+		c.Locals("log_level", "info")
+		return handlerutil.NotFoundError(notes.ErrItemNotFound)
+	case errors.Is(err, notes.ErrBadRequest), errors.Is(err, notes.ErrNotChecklist), errors.Is(err, notes.ErrItemOrder):
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 400, Message: err.Error()})
+	case errors.Is(err, notes.ErrChecklistFull):
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 409, Message: err.Error()})
+	}
+	if werr := workspaceError(c, err); werr != nil {
+		return werr
+	}
+	if qerr := quotaError(c, err); qerr != nil {
+		return qerr
+	}
+	return handlerutil.HandleServiceError(err, handlerName, userID, &noteID, notes.ErrNoteNotFound)
+}
+
+// AddItem handles adding a checklist item
+// @Summary Add a checklist item
+// @Description Appends the item, or inserts it at position. Connected clients get an item_added event.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param request body notes.AddItemRequest true "New item"
+// @Success 201 {object} notes.ItemResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Failure 422 {object} httperr.E
+// @Router /notes/{id}/items [post]
+func (h *Handlers) AddItem(c *fiber.Ctx) error {
+	userID, noteID, _, err := itemPath(c, "AddItem", false)
+	if err != nil {
+		return err
+	}
+
+	var req notes.AddItemRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "AddItem"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.AddItem(c.Context(), userID, noteID, req)
+	if err != nil {
+		return itemError(c, err, "AddItem", userID, noteID)
+	}
+
+	return c.Status(201).JSON(resp)
+}
+
+// UpdateItem handles checklist item updates
+// @Summary Update a checklist item
+// @Description Changes the text or checked state of one item without touching the others. Connected clients get an item_updated event.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param itemId path string true "Item ID"
+// @Param request body notes.UpdateItemRequest true "Item fields"
+// @Success 200 {object} notes.ItemResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 422 {object} httperr.E
+// @Router /notes/{id}/items/{itemId} [patch]
+func (h *Handlers) UpdateItem(c *fiber.Ctx) error {
+	userID, noteID, itemID, err := itemPath(c, "UpdateItem", true)
+	if err != nil {
+		return err
+	}
+
+	var req notes.UpdateItemRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "UpdateItem"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.UpdateItem(c.Context(), userID, noteID, itemID, req)
+	if err != nil {
+		return itemError(c, err, "UpdateItem", userID, noteID)
+	}
+
+	return c.JSON(resp)
+}
+
+// DeleteItem handles checklist item deletion
+// @Summary Delete a checklist item
+// @Description Connected clients get an item_deleted event.
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param itemId path string true "Item ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/items/{itemId} [delete]
+func (h *Handlers) DeleteItem(c *fiber.Ctx) error {
+	userID, noteID, itemID, err := itemPath(c, "DeleteItem", true)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteItem(c.Context(), userID, noteID, itemID); err != nil {
+		return itemError(c, err, "DeleteItem", userID, noteID)
+	}
+
+	return c.SendStatus(204)
+}
+
+// ReorderItems handles checklist reordering
+// @Summary Reorder checklist items
+// @Description item_ids must list every item exactly once. Connected clients get an items_reordered event.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param request body notes.ReorderItemsRequest true "New order"
+// @Success 200 {object} notes.NoteResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/items/order [put]
+func (h *Handlers) ReorderItems(c *fiber.Ctx) error {
+	userID, noteID, _, err := itemPath(c, "ReorderItems", false)
+	if err != nil {
+		return err
+	}
+
+	var req notes.ReorderItemsRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "ReorderItems"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.ReorderItems(c.Context(), userID, noteID, req)
+	if err != nil {
+		return itemError(c, err, "ReorderItems", userID, noteID)
+	}
+
+	return c.JSON(resp)
+}
@@ -0,0 +1,95 @@
+package notes
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// linksError maps a missing link index, returning nil for any other error
+func linksError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, notes.ErrNoLinkIndex) {
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 501, Message: err.Error()})
+	}
+	return workspaceError(c, err)
+}
+
+// Backlinks handles listing the notes that link to a note
+// @Summary List backlinks
+// @Description Notes of the same workspace whose body links to the note with [[title]] or [[id]], newest first
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param limit query int false "How many notes to return (default 50)" minimum(1) maximum(200)
+// @Param render query string false "html adds rendered_html to every note" Enums(html)
+// @Success 200 {object} notes.BacklinksResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/backlinks [get]
+func (h *Handlers) Backlinks(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, "Backlinks")
+	if err != nil {
+		return err
+	}
+
+	var req notes.BacklinksRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "Backlinks"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Backlinks(c.Context(), userID, noteID, req)
+	if err != nil {
+		if lerr := linksError(c, err); lerr != nil {
+			return lerr
+		}
+		return handlerutil.HandleServiceError(err, "Backlinks", userID, &noteID, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
+
+// Graph handles the link graph of a workspace
+// @Summary Get the link graph
+// @Description Every note of a workspace as a node, the links between them as edges, and the links that point at no note
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param workspace_id query string false "Shared workspace; empty is the personal one"
+// @Success 200 {object} notes.GraphResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/graph [get]
+func (h *Handlers) Graph(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req notes.GraphRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "Graph"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Graph(c.Context(), userID, req)
+	if err != nil {
+		if lerr := linksError(c, err); lerr != nil {
+			return lerr
+		}
+		return handlerutil.HandleServiceError(err, "Graph", userID, nil, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
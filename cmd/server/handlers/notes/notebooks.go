@@ -0,0 +1,114 @@
+package notes
+
+import (
+	"errors"
+
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notebookError maps notebook failures, returning nil for any other error
+func notebookError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, notes.ErrNotebookNotFound):
+		c.Locals("log_level", "info")
+		return handlerutil.NotFoundError(notes.ErrNotebookNotFound)
+	case errors.Is(err, notes.ErrPersonalOnly):
+		c.Locals("log_level", "info")
+		return httperr.Fail(httperr.E{Status: 400, Message: notes.ErrPersonalOnly.Error()})
+	}
+	return nil
+}
+
+// MoveToNotebook handles filing a note in a notebook
+// @Summary Move a note to a notebook
+// @Description Files a personal note in one of the caller's notebooks, or in the Inbox with an empty notebook_id. Connected clients get an updated event.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param request body notes.NotebookRequest true "Target notebook"
+// @Success 200 {object} notes.NoteResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /notes/{id}/move [post]
+func (h *Handlers) MoveToNotebook(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, "MoveToNotebook")
+	if err != nil {
+		return err
+	}
+
+	var req notes.NotebookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "MoveToNotebook"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.MoveToNotebook(c.Context(), userID, noteID, req)
+	if err != nil {
+		if nerr := notebookError(c, err); nerr != nil {
+			return nerr
+		}
+		if werr := workspaceError(c, err); werr != nil {
+			return werr
+		}
+		return handlerutil.HandleServiceError(err, "MoveToNotebook", userID, &noteID, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
+
+// CopyToNotebook handles copying a note into a notebook
+// @Summary Copy a note to a notebook
+// @Description Copies a note the caller can read, shared ones included, into one of the caller's notebooks or the Inbox. The copy keeps the content and due date but not reminders, board placement or attachments.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Note ID"
+// @Param request body notes.NotebookRequest true "Target notebook"
+// @Success 201 {object} notes.NoteResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 413 {object} httperr.E
+// @Failure 422 {object} httperr.E
+// @Router /notes/{id}/copy [post]
+func (h *Handlers) CopyToNotebook(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := handlerutil.ExtractNoteID(c, userID, "CopyToNotebook")
+	if err != nil {
+		return err
+	}
+
+	var req notes.NotebookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "CopyToNotebook"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.CopyToNotebook(c.Context(), userID, noteID, req)
+	if err != nil {
+		if nerr := notebookError(c, err); nerr != nil {
+			return nerr
+		}
+		if qerr := quotaError(c, err); qerr != nil {
+			return qerr
+		}
+		return handlerutil.HandleServiceError(err, "CopyToNotebook", userID, &noteID, notes.ErrNoteNotFound)
+	}
+
+	return c.Status(201).JSON(resp)
+}
@@ -0,0 +1,47 @@
+package notes
+
+import (
+	"errors"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/notes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// quotaError maps writes refused by the plan limits, returning nil for any
+// other error
+func quotaError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, notes.ErrNoteTooLarge):
+		c.Locals("log_level", "info")
+		return httperr.TooLarge(err)
+	case errors.Is(err, notes.ErrQuotaExceeded):
+		c.Locals("log_level", "info")
+		return httperr.QuotaExceeded(err)
+	}
+	return nil
+}
+
+// Usage handles reading the caller's usage
+// @Summary Get storage usage
+// @Description Counts the notes the caller wrote, shared workspaces included, and the bytes of their text and uploaded attachments, with the limits they count against. Writes past a limit fail with 422; a title or body over its length limit with 413.
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} notes.UsageResponse
+// @Failure 401 {object} httperr.E
+// @Router /me/usage [get]
+func (h *Handlers) Usage(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.Usage(c.Context(), userID)
+	if err != nil {
+		return handlerutil.HandleServiceError(err, "Usage", userID, nil, notes.ErrNoteNotFound)
+	}
+
+	return c.JSON(resp)
+}
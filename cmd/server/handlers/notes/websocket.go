@@ -9,6 +9,7 @@ import (
 	"note-pulse/cmd/server/ctxkeys"
 	"note-pulse/cmd/server/handlers/httperr"
 	"note-pulse/internal/logger"
+	"note-pulse/internal/services/auth"
 	"note-pulse/internal/services/notes"
 
 	"github.com/gofiber/contrib/websocket"
@@ -33,14 +34,23 @@ const (
 // Hub interface for WebSocket management
 type Hub interface {
 	Subscribe(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID) (*notes.Subscriber, func())
+	SubscribeSession(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID, sessionID string) (*notes.Subscriber, func())
 	Unsubscribe(ctx context.Context, connULID ulid.ULID)
 }
 
+// UserStatusProvider reports whether a user is disabled and whether a
+// session was revoked
+type UserStatusProvider interface {
+	UserStatus(ctx context.Context, userID bson.ObjectID) (auth.UserStatus, error)
+	SessionActive(ctx context.Context, userID bson.ObjectID, sessionID string) (bool, error)
+}
+
 // WebSocketHandlers contains WebSocket-related handlers
 type WebSocketHandlers struct {
 	hub           Hub
 	jwtSecret     string
 	maxSessionSec int
+	userStatus    UserStatusProvider
 }
 
 // NewWebSocketHandlers creates new WebSocket handlers
@@ -52,6 +62,12 @@ func NewWebSocketHandlers(hub Hub, jwtSecret string, maxSessionSec int) *WebSock
 	}
 }
 
+// SetUserStatus makes the upgrade reject disabled or deleted users, and
+// revoked sessions, whose access token has not expired yet
+func (h *WebSocketHandlers) SetUserStatus(p UserStatusProvider) {
+	h.userStatus = p
+}
+
 // WSUpgrade upgrades HTTP connection to WebSocket for notes streaming
 func (h *WebSocketHandlers) WSUpgrade(c *fiber.Ctx) error {
 	if websocket.IsWebSocketUpgrade(c) {
@@ -65,7 +81,7 @@ func (h *WebSocketHandlers) WSUpgrade(c *fiber.Ctx) error {
 			})
 		}
 
-		userID, userEmail, err := h.validateJWT(token)
+		userID, userEmail, sessionID, err := h.validateJWT(token)
 		if err != nil {
 			logger.L().Error("invalid token in websocket upgrade", "handler", "WSUpgrade", "path", c.Path(), "error", err)
 			return httperr.Fail(httperr.E{
@@ -74,9 +90,31 @@ func (h *WebSocketHandlers) WSUpgrade(c *fiber.Ctx) error {
 			})
 		}
 
+		if h.userStatus != nil {
+			st, err := h.userStatus.UserStatus(c.Context(), userID)
+			if err != nil || st.Disabled {
+				logger.L().Warn("websocket upgrade rejected for inactive user", "handler", "WSUpgrade", "user_id", userID.Hex(), "error", err)
+				return httperr.Fail(httperr.E{
+					Status:  401,
+					Message: "Invalid token",
+				})
+			}
+			if sessionID != "" {
+				active, err := h.userStatus.SessionActive(c.Context(), userID, sessionID)
+				if err != nil || !active {
+					logger.L().Warn("websocket upgrade rejected for revoked session", "handler", "WSUpgrade", "user_id", userID.Hex(), "error", err)
+					return httperr.Fail(httperr.E{
+						Status:  401,
+						Message: "Invalid token",
+					})
+				}
+			}
+		}
+
 		// Store user info and context in locals for the WebSocket handler
 		c.Locals(ctxkeys.UserIDKey, userID.Hex())
 		c.Locals(ctxkeys.UserEmailKey, userEmail)
+		c.Locals(ctxkeys.SessionIDKey, sessionID)
 		// Use Fiber's request‑bound context so WSNotesStream gets a *real* context.Context.
 		c.Locals(ctxkeys.ParentCtxKey, c.UserContext())
 
@@ -101,7 +139,7 @@ func (h *WebSocketHandlers) WSNotesStream(c *websocket.Conn) {
 	ctx, cancelCtx := context.WithCancel(parentCtx)
 	defer cancelCtx()
 
-	subscriber, cancel := h.hub.Subscribe(ctx, conn.connULID, conn.userID)
+	subscriber, cancel := h.hub.SubscribeSession(ctx, conn.connULID, conn.userID, conn.sessionID)
 	defer cancel()
 
 	logger.L().Info("WebSocket connection established", "user_id", conn.userID.Hex(), "conn_id", conn.connID)
@@ -122,9 +160,10 @@ func (h *WebSocketHandlers) WSNotesStream(c *websocket.Conn) {
 
 // wsConnection holds connection-specific data
 type wsConnection struct {
-	userID   bson.ObjectID
-	connULID ulid.ULID
-	connID   string
+	userID    bson.ObjectID
+	sessionID string
+	connULID  ulid.ULID
+	connID    string
 }
 
 // initializeConnection validates and sets up the WebSocket connection
@@ -147,13 +186,17 @@ func (h *WebSocketHandlers) initializeConnection(c *websocket.Conn) (*wsConnecti
 		return nil, nil, fmt.Errorf(ctxkeys.ParentCtxKey + " not found")
 	}
 
+	// Tokens issued before session tracking carry no sid; that's fine.
+	sessionID, _ := c.Locals(ctxkeys.SessionIDKey).(string)
+
 	connULID := ulid.MustNew(ulid.Timestamp(time.Now().UTC()), rand.Reader)
 	connID := connULID.String()
 
 	conn := &wsConnection{
-		userID:   userID,
-		connULID: connULID,
-		connID:   connID,
+		userID:    userID,
+		sessionID: sessionID,
+		connULID:  connULID,
+		connID:    connID,
 	}
 
 	return conn, parentCtx, nil
@@ -185,7 +228,12 @@ func (h *WebSocketHandlers) stopSessionTimer(timer *time.Timer) {
 
 // sendCloseMessage sends a close frame to the client
 func (h *WebSocketHandlers) sendCloseMessage(c *websocket.Conn, conn *wsConnection) {
-	err := c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(WSClosePolicyViolation, "session timeout"))
+	h.sendCloseReason(c, conn, "session timeout")
+}
+
+// sendCloseReason sends a policy-violation close frame with the given reason
+func (h *WebSocketHandlers) sendCloseReason(c *websocket.Conn, conn *wsConnection, reason string) {
+	err := c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(WSClosePolicyViolation, reason))
 	if err != nil {
 		logger.L().Error("failed to send close message", "error", err, "user_id", conn.userID.Hex(), "conn_id", conn.connID)
 	}
@@ -229,12 +277,14 @@ func (h *WebSocketHandlers) handleOutgoingMessages(c *websocket.Conn, conn *wsCo
 		select {
 		case event, ok := <-subscriber.Ch:
 			if !ok {
+				h.hangUpIfEvicted(ctx, c, conn)
 				return
 			}
 			if h.sendEvent(c, conn, event) != nil {
 				return
 			}
 		case <-subscriber.Done:
+			h.hangUpIfEvicted(ctx, c, conn)
 			return
 		case <-ctx.Done():
 			return
@@ -242,6 +292,18 @@ func (h *WebSocketHandlers) handleOutgoingMessages(c *websocket.Conn, conn *wsCo
 	}
 }
 
+// hangUpIfEvicted closes the socket when the hub dropped the subscriber while
+// the connection is still alive (its session was revoked), so the client
+// doesn't idle on a stream that will never deliver again.
+func (h *WebSocketHandlers) hangUpIfEvicted(ctx context.Context, c *websocket.Conn, conn *wsConnection) {
+	if ctx.Err() != nil {
+		return // regular teardown
+	}
+	logger.L().Info("WebSocket session revoked", "user_id", conn.userID.Hex(), "conn_id", conn.connID)
+	h.sendCloseReason(c, conn, "session revoked")
+	h.closeConnection(c)
+}
+
 // sendEvent sends an event to the client
 func (h *WebSocketHandlers) sendEvent(c *websocket.Conn, conn *wsConnection, event notes.NoteEvent) error {
 	message := h.buildEventMessage(event)
@@ -259,6 +321,12 @@ func (h *WebSocketHandlers) sendEvent(c *websocket.Conn, conn *wsConnection, eve
 
 // buildEventMessage builds the message payload for an event
 func (h *WebSocketHandlers) buildEventMessage(event notes.NoteEvent) map[string]any {
+	if event.Type == notes.EventViewCounts {
+		return map[string]any{
+			"type":  event.Type,
+			"views": event.Views,
+		}
+	}
 	if event.Type == "deleted" {
 		return map[string]any{
 			"type": event.Type,
@@ -267,10 +335,30 @@ func (h *WebSocketHandlers) buildEventMessage(event notes.NoteEvent) map[string]
 			},
 		}
 	}
-	return map[string]any{
+	if event.Type == notes.EventMoved {
+		note := map[string]any{
+			"id":       event.Note.ID.Hex(),
+			"position": event.Note.Position,
+		}
+		if event.Note.BoardID != "" {
+			note["board_id"] = event.Note.BoardID
+		}
+		return map[string]any{
+			"type": event.Type,
+			"note": note,
+		}
+	}
+	message := map[string]any{
 		"type": event.Type,
 		"note": event.Note,
 	}
+	if event.Item != nil {
+		message["item"] = event.Item
+	}
+	if event.Links != nil {
+		message["links"] = event.Links
+	}
+	return message
 }
 
 // handleIncomingMessages handles messages received from the client
@@ -301,8 +389,9 @@ func (h *WebSocketHandlers) sendPong(c *websocket.Conn, conn *wsConnection) erro
 	return nil
 }
 
-// validateJWT validates the JWT token and extracts user information
-func (h *WebSocketHandlers) validateJWT(tokenString string) (bson.ObjectID, string, error) {
+// validateJWT validates the JWT token and extracts user information.
+// The returned session id is empty for tokens minted without a "sid" claim.
+func (h *WebSocketHandlers) validateJWT(tokenString string) (bson.ObjectID, string, string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		// Validate signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -312,34 +401,36 @@ func (h *WebSocketHandlers) validateJWT(tokenString string) (bson.ObjectID, stri
 	})
 
 	if err != nil {
-		return bson.ObjectID{}, "", err
+		return bson.ObjectID{}, "", "", err
 	}
 
 	if !token.Valid {
-		return bson.ObjectID{}, "", fmt.Errorf("invalid token")
+		return bson.ObjectID{}, "", "", fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return bson.ObjectID{}, "", fmt.Errorf("invalid claims")
+		return bson.ObjectID{}, "", "", fmt.Errorf("invalid claims")
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		return bson.ObjectID{}, "", fmt.Errorf("missing user_id")
+		return bson.ObjectID{}, "", "", fmt.Errorf("missing user_id")
 	}
 
 	userEmail, ok := claims["email"].(string)
 	if !ok {
-		return bson.ObjectID{}, "", fmt.Errorf("missing email")
+		return bson.ObjectID{}, "", "", fmt.Errorf("missing email")
 	}
 
 	userID, err := bson.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		return bson.ObjectID{}, "", fmt.Errorf("invalid user_id: %w", err)
+		return bson.ObjectID{}, "", "", fmt.Errorf("invalid user_id: %w", err)
 	}
 
-	return userID, userEmail, nil
+	sessionID, _ := claims["sid"].(string)
+
+	return userID, userEmail, sessionID, nil
 }
 
 // LogWSConnections logs every WebSocket upgrade attempt.
@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"note-pulse/cmd/server/testutil"
 	"note-pulse/internal/config"
 	"note-pulse/internal/logger"
+	"note-pulse/internal/services/auth"
 	"note-pulse/internal/services/notes"
 
 	"github.com/gofiber/contrib/websocket"
@@ -49,6 +51,45 @@ func TestWSUpgradeTableDriven(t *testing.T) {
 	}
 }
 
+// revokedSessions reports every user as active and the listed sessions as
+// revoked
+type revokedSessions map[string]bool
+
+func (revokedSessions) UserStatus(context.Context, bson.ObjectID) (auth.UserStatus, error) {
+	return auth.UserStatus{Role: auth.RoleUser}, nil
+}
+
+func (r revokedSessions) SessionActive(_ context.Context, _ bson.ObjectID, sessionID string) (bool, error) {
+	return !r[sessionID], nil
+}
+
+func TestWSUpgradeRejectsRevokedSession(t *testing.T) {
+	cfg := DefaultWebSocketTestConfig()
+	app, _, wsHandlers := SetupWebSocketHandlersApp(t, cfg)
+
+	active, revoked := bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	wsHandlers.SetUserStatus(revokedSessions{revoked: true})
+
+	tokenFor := func(sessionID string) *string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": bson.NewObjectID().Hex(),
+			"email":   "test@example.com",
+			"sid":     sessionID,
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(cfg.Secret))
+		require.NoError(t, err)
+		return &token
+	}
+
+	resp, err := app.Test(testutil.CreateWebSocketRequest("/ws", tokenFor(active)))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(testutil.CreateWebSocketRequest("/ws", tokenFor(revoked)))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode, "a revoked session cannot reconnect")
+}
+
 func TestWSUpgradeNonWebSocketRequest(t *testing.T) {
 	cfg := config.Config{
 		LogLevel:  "info",
@@ -238,7 +279,7 @@ func TestValidateJWTTabledriven(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			token := tc.setupToken()
-			parsedUserID, parsedEmail, err := wsHandlers.validateJWT(token)
+			parsedUserID, parsedEmail, _, err := wsHandlers.validateJWT(token)
 
 			if tc.expectError {
 				assert.Error(t, err)
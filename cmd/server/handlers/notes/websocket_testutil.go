@@ -29,10 +29,15 @@ func NewMockHub() *MockHub {
 }
 
 func (m *MockHub) Subscribe(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID) (*notes.Subscriber, func()) {
+	return m.SubscribeSession(ctx, connULID, userID, "")
+}
+
+func (m *MockHub) SubscribeSession(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID, sessionID string) (*notes.Subscriber, func()) {
 	sub := &notes.Subscriber{
-		UserID: userID,
-		Ch:     make(chan notes.NoteEvent, 10),
-		Done:   make(chan struct{}),
+		UserID:    userID,
+		SessionID: sessionID,
+		Ch:        make(chan notes.NoteEvent, 10),
+		Done:      make(chan struct{}),
 	}
 	m.subscribers[connULID] = sub
 	m.subscribeCount++
@@ -0,0 +1,243 @@
+package templates
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/services/templates"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the templates service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID) (*templates.ListTemplatesResponse, error)
+	Create(ctx context.Context, userID bson.ObjectID, req templates.CreateTemplateRequest) (*templates.Template, error)
+	Get(ctx context.Context, userID, templateID bson.ObjectID) (*templates.Template, error)
+	Update(ctx context.Context, userID, templateID bson.ObjectID, req templates.UpdateTemplateRequest) (*templates.Template, error)
+	Delete(ctx context.Context, userID, templateID bson.ObjectID) error
+	Instantiate(ctx context.Context, userID, templateID bson.ObjectID) (*notes.NoteResponse, error)
+}
+
+// Handlers contains the note templates HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new templates handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// templateID returns the caller and the template named by the :id path param
+func templateID(c *fiber.Ctx, handlerName string) (bson.ObjectID, bson.ObjectID, error) {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, err
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid template ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return bson.ObjectID{}, bson.ObjectID{}, httperr.Fail(httperr.ErrBadRequest)
+	}
+	return userID, id, nil
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	var status int
+	switch {
+	case errors.Is(err, templates.ErrTemplateNotFound),
+		errors.Is(err, notes.ErrWorkspaceNotFound):
+		status = 404
+	case errors.Is(err, notes.ErrWorkspaceReadOnly):
+		status = 403
+	case errors.Is(err, templates.ErrTooManyTemplates):
+		status = 409
+	case errors.Is(err, templates.ErrInvalidRule),
+		errors.Is(err, templates.ErrInvalidRecurrence),
+		errors.Is(err, notes.ErrBadRequest):
+		status = 400
+	case errors.Is(err, notes.ErrNoteTooLarge):
+		status = 413
+	case errors.Is(err, notes.ErrQuotaExceeded):
+		status = 422
+	default:
+		logger.L().Error("templates service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	c.Locals("log_level", "info")
+	return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+}
+
+// List lists the caller's templates
+// @Summary List note templates
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} templates.ListTemplatesResponse
+// @Failure 401 {object} httperr.E
+// @Router /templates [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Create saves a template
+// @Summary Create note template
+// @Description Title and body may use {{date}} (2026-01-12) and {{week}} (2026-W03). A recurrence with an RRULE makes a note from the template at each occurrence.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body templates.CreateTemplateRequest true "Template"
+// @Success 201 {object} templates.Template
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /templates [post]
+func (h *Handlers) Create(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req templates.CreateTemplateRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Create"); err != nil {
+		return err
+	}
+
+	t, err := h.service.Create(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "Create", userID)
+	}
+	return c.Status(201).JSON(t)
+}
+
+// Get returns a template
+// @Summary Get note template
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Template ID"
+// @Success 200 {object} templates.Template
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /templates/{id} [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, id, err := templateID(c, "Get")
+	if err != nil {
+		return err
+	}
+
+	t, err := h.service.Get(c.Context(), userID, id)
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(t)
+}
+
+// Update changes a template
+// @Summary Update note template
+// @Description A recurrence replaces the schedule; one with an empty rrule removes it.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Template ID"
+// @Param request body templates.UpdateTemplateRequest true "Fields to change"
+// @Success 200 {object} templates.Template
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /templates/{id} [patch]
+func (h *Handlers) Update(c *fiber.Ctx) error {
+	userID, id, err := templateID(c, "Update")
+	if err != nil {
+		return err
+	}
+
+	var req templates.UpdateTemplateRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Update"); err != nil {
+		return err
+	}
+
+	t, err := h.service.Update(c.Context(), userID, id, req)
+	if err != nil {
+		return serviceError(c, err, "Update", userID)
+	}
+	return c.JSON(t)
+}
+
+// Delete deletes a template
+// @Summary Delete note template
+// @Tags templates
+// @Security Bearer
+// @Param id path string true "Template ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /templates/{id} [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, id, err := templateID(c, "Delete")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID, id); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Instantiate makes a note from a template
+// @Summary Create a note from a template
+// @Description Placeholders are filled with today's date in the template's time zone (UTC without a recurrence).
+// @Tags notes
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Template ID"
+// @Success 201 {object} notes.NoteResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 413 {object} httperr.E
+// @Failure 422 {object} httperr.E
+// @Router /notes/from-template/{id} [post]
+func (h *Handlers) Instantiate(c *fiber.Ctx) error {
+	userID, id, err := templateID(c, "Instantiate")
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.Instantiate(c.Context(), userID, id)
+	if err != nil {
+		return serviceError(c, err, "Instantiate", userID)
+	}
+	return c.Status(201).JSON(resp)
+}
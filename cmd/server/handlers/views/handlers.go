@@ -0,0 +1,256 @@
+package views
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/services/views"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the views service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID) (*views.ListViewsResponse, error)
+	Create(ctx context.Context, userID bson.ObjectID, req views.CreateViewRequest) (*views.View, error)
+	Get(ctx context.Context, userID, viewID bson.ObjectID) (*views.View, error)
+	Update(ctx context.Context, userID, viewID bson.ObjectID, req views.UpdateViewRequest) (*views.View, error)
+	Delete(ctx context.Context, userID, viewID bson.ObjectID) error
+	Notes(ctx context.Context, userID, viewID bson.ObjectID, page notes.ListNotesRequest) (*notes.ListNotesResponse, error)
+}
+
+// Handlers contains the saved views HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new views handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// viewID returns the caller and the view named by the :id path param
+func viewID(c *fiber.Ctx, handlerName string) (bson.ObjectID, bson.ObjectID, error) {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, err
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid view ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return bson.ObjectID{}, bson.ObjectID{}, httperr.Fail(httperr.ErrBadRequest)
+	}
+	return userID, id, nil
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	var qerr *notes.QueryError
+	var status int
+	switch {
+	case errors.As(err, &qerr):
+		status = 400
+	case errors.Is(err, views.ErrViewNotFound),
+		errors.Is(err, notes.ErrWorkspaceNotFound),
+		errors.Is(err, notes.ErrNotebookNotFound),
+		errors.Is(err, notes.ErrNoteNotFound):
+		status = 404
+	case errors.Is(err, views.ErrTooManyViews):
+		status = 409
+	case errors.Is(err, notes.ErrBadRequest),
+		errors.Is(err, notes.ErrPersonalOnly),
+		errors.Is(err, notes.ErrInvalidCursor),
+		errors.Is(err, notes.ErrInvalidLimit):
+		status = 400
+	case errors.Is(err, notes.ErrOffsetBeyondTotal):
+		status = 416
+	default:
+		logger.L().Error("views service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	c.Locals("log_level", "info")
+	return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+}
+
+// List lists the caller's saved views
+// @Summary List saved views
+// @Description Every view carries the number of notes it currently matches.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} views.ListViewsResponse
+// @Failure 401 {object} httperr.E
+// @Router /views [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Create saves a view
+// @Summary Create saved view
+// @Description The request keeps the filters and sort of GET /notes (workspace_id, q, color, sort, order); pagination fields are dropped.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body views.CreateViewRequest true "View"
+// @Success 201 {object} views.View
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /views [post]
+func (h *Handlers) Create(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req views.CreateViewRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Create"); err != nil {
+		return err
+	}
+
+	v, err := h.service.Create(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "Create", userID)
+	}
+	return c.Status(201).JSON(v)
+}
+
+// Get returns a saved view
+// @Summary Get saved view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "View ID"
+// @Success 200 {object} views.View
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /views/{id} [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, id, err := viewID(c, "Get")
+	if err != nil {
+		return err
+	}
+
+	v, err := h.service.Get(c.Context(), userID, id)
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(v)
+}
+
+// Update renames a saved view or replaces its request
+// @Summary Update saved view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "View ID"
+// @Param request body views.UpdateViewRequest true "Fields to change"
+// @Success 200 {object} views.View
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /views/{id} [patch]
+func (h *Handlers) Update(c *fiber.Ctx) error {
+	userID, id, err := viewID(c, "Update")
+	if err != nil {
+		return err
+	}
+
+	var req views.UpdateViewRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Update"); err != nil {
+		return err
+	}
+
+	v, err := h.service.Update(c.Context(), userID, id, req)
+	if err != nil {
+		return serviceError(c, err, "Update", userID)
+	}
+	return c.JSON(v)
+}
+
+// Delete deletes a saved view
+// @Summary Delete saved view
+// @Tags views
+// @Security Bearer
+// @Param id path string true "View ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /views/{id} [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, id, err := viewID(c, "Delete")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID, id); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Notes runs a saved view
+// @Summary List the notes of a saved view
+// @Description Takes the pagination parameters of GET /notes; filters and sort come from the view.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "View ID"
+// @Param limit query int false "Limit (default: 50, max: 100)" minimum(1) maximum(100)
+// @Param cursor query string false "Cursor for pagination. Cannot be used with offset or anchor."
+// @Param anchor query string false "Centre the window on this note id. Cannot be used with offset or cursor."
+// @Param span query int false "How many notes to return (default:limit)" minimum(1) maximum(100)
+// @Param offset query int false "Offset for absolute positioning (0-50,000). Cannot be used with cursor or anchor." minimum(0) maximum(50000)
+// @Param render query string false "html adds rendered_html to every note" Enums(html)
+// @Success 200 {object} notes.ListNotesResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 416 {object} httperr.E
+// @Router /views/{id}/notes [get]
+func (h *Handlers) Notes(c *fiber.Ctx) error {
+	userID, id, err := viewID(c, "Notes")
+	if err != nil {
+		return err
+	}
+
+	var page notes.ListNotesRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &page, h.validator, "Notes"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Notes(c.Context(), userID, id, page)
+	if err != nil {
+		return serviceError(c, err, "Notes", userID)
+	}
+	return c.JSON(resp)
+}
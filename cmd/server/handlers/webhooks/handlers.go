@@ -0,0 +1,276 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/services/webhooks"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the webhooks service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID) (*webhooks.ListWebhooksResponse, error)
+	Create(ctx context.Context, userID bson.ObjectID, req webhooks.CreateWebhookRequest) (*webhooks.Webhook, error)
+	Get(ctx context.Context, userID, webhookID bson.ObjectID) (*webhooks.Webhook, error)
+	Update(ctx context.Context, userID, webhookID bson.ObjectID, req webhooks.UpdateWebhookRequest) (*webhooks.Webhook, error)
+	Delete(ctx context.Context, userID, webhookID bson.ObjectID) error
+	Deliveries(ctx context.Context, userID, webhookID bson.ObjectID, req webhooks.ListDeliveriesRequest) (*webhooks.ListDeliveriesResponse, error)
+	Redeliver(ctx context.Context, userID, webhookID, deliveryID bson.ObjectID) (*webhooks.Delivery, error)
+}
+
+// Handlers contains the webhooks HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new webhooks handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// webhookID returns the caller and the webhook named by the :id path param
+func webhookID(c *fiber.Ctx, handlerName string) (bson.ObjectID, bson.ObjectID, error) {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, bson.ObjectID{}, err
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		logger.L().Info("invalid webhook ID parameter", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return bson.ObjectID{}, bson.ObjectID{}, httperr.Fail(httperr.ErrBadRequest)
+	}
+	return userID, id, nil
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	var status int
+	switch {
+	case errors.Is(err, webhooks.ErrWebhookNotFound),
+		errors.Is(err, webhooks.ErrDeliveryNotFound),
+		errors.Is(err, notes.ErrWorkspaceNotFound):
+		status = 404
+	case errors.Is(err, webhooks.ErrTooManyWebhooks),
+		errors.Is(err, webhooks.ErrWebhookDisabled):
+		status = 409
+	case errors.Is(err, webhooks.ErrInvalidURL),
+		errors.Is(err, notes.ErrBadRequest):
+		status = 400
+	default:
+		logger.L().Error("webhooks service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	c.Locals("log_level", "info")
+	return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+}
+
+// List lists the caller's webhooks
+// @Summary List webhooks
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} webhooks.ListWebhooksResponse
+// @Failure 401 {object} httperr.E
+// @Router /webhooks [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Create subscribes a URL to note events
+// @Summary Create webhook
+// @Description Each note event is POSTed as JSON with X-NotePulse-Event, X-NotePulse-Delivery, X-NotePulse-Timestamp and X-NotePulse-Signature, "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret. Failed deliveries are retried with exponential backoff.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body webhooks.CreateWebhookRequest true "Webhook"
+// @Success 201 {object} webhooks.Webhook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /webhooks [post]
+func (h *Handlers) Create(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req webhooks.CreateWebhookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Create"); err != nil {
+		return err
+	}
+
+	w, err := h.service.Create(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "Create", userID)
+	}
+	return c.Status(201).JSON(w)
+}
+
+// Get returns a webhook
+// @Summary Get webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} webhooks.Webhook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /webhooks/{id} [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, id, err := webhookID(c, "Get")
+	if err != nil {
+		return err
+	}
+
+	w, err := h.service.Get(c.Context(), userID, id)
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(w)
+}
+
+// Update changes a webhook
+// @Summary Update webhook
+// @Description "active": true enables a webhook that was disabled after repeated failures.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param request body webhooks.UpdateWebhookRequest true "Fields to change"
+// @Success 200 {object} webhooks.Webhook
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /webhooks/{id} [patch]
+func (h *Handlers) Update(c *fiber.Ctx) error {
+	userID, id, err := webhookID(c, "Update")
+	if err != nil {
+		return err
+	}
+
+	var req webhooks.UpdateWebhookRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Update"); err != nil {
+		return err
+	}
+
+	w, err := h.service.Update(c.Context(), userID, id, req)
+	if err != nil {
+		return serviceError(c, err, "Update", userID)
+	}
+	return c.JSON(w)
+}
+
+// Delete deletes a webhook
+// @Summary Delete webhook
+// @Description Pending deliveries are dropped with the delivery log.
+// @Tags webhooks
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /webhooks/{id} [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, id, err := webhookID(c, "Delete")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID, id); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Deliveries lists the delivery log of a webhook
+// @Summary List webhook deliveries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Deliveries to return (default 20, max 100)"
+// @Success 200 {object} webhooks.ListDeliveriesResponse
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handlers) Deliveries(c *fiber.Ctx) error {
+	userID, id, err := webhookID(c, "Deliveries")
+	if err != nil {
+		return err
+	}
+
+	var req webhooks.ListDeliveriesRequest
+	if err := handlerutil.ParseAndValidateQuery(c, &req, h.validator, "Deliveries"); err != nil {
+		return err
+	}
+
+	resp, err := h.service.Deliveries(c.Context(), userID, id, req)
+	if err != nil {
+		return serviceError(c, err, "Deliveries", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Redeliver sends a logged delivery again
+// @Summary Redeliver webhook delivery
+// @Description Queues the payload of the delivery again as a new delivery with attempts of its own.
+// @Tags webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} webhooks.Delivery
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handlers) Redeliver(c *fiber.Ctx) error {
+	userID, id, err := webhookID(c, "Redeliver")
+	if err != nil {
+		return err
+	}
+	deliveryID, err := bson.ObjectIDFromHex(c.Params("deliveryId"))
+	if err != nil {
+		logger.L().Info("invalid delivery ID parameter", "handler", "Redeliver", ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.ErrBadRequest)
+	}
+
+	d, err := h.service.Redeliver(c.Context(), userID, id, deliveryID)
+	if err != nil {
+		return serviceError(c, err, "Redeliver", userID)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(d)
+}
@@ -0,0 +1,427 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/handlerutil"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/logger"
+	"note-pulse/internal/services/workspaces"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Service defines the interface for the workspaces service
+type Service interface {
+	List(ctx context.Context, userID bson.ObjectID) (*workspaces.ListWorkspacesResponse, error)
+	Create(ctx context.Context, userID bson.ObjectID, req workspaces.CreateWorkspaceRequest) (*workspaces.Workspace, error)
+	Get(ctx context.Context, userID, workspaceID bson.ObjectID) (*workspaces.Workspace, error)
+	Rename(ctx context.Context, userID, workspaceID bson.ObjectID, req workspaces.RenameWorkspaceRequest) (*workspaces.Workspace, error)
+	Delete(ctx context.Context, userID, workspaceID bson.ObjectID) error
+	ListMembers(ctx context.Context, userID, workspaceID bson.ObjectID) (*workspaces.ListMembersResponse, error)
+	SetMemberRole(ctx context.Context, userID, workspaceID, memberID bson.ObjectID, role string) (*workspaces.Member, error)
+	RemoveMember(ctx context.Context, userID, workspaceID, memberID bson.ObjectID) error
+	Invite(ctx context.Context, userID, workspaceID bson.ObjectID, req workspaces.InviteRequest) (*workspaces.Invitation, error)
+	ListInvitations(ctx context.Context, userID, workspaceID bson.ObjectID) (*workspaces.ListInvitationsResponse, error)
+	RevokeInvitation(ctx context.Context, userID, workspaceID, invitationID bson.ObjectID) error
+	AcceptInvitation(ctx context.Context, userID bson.ObjectID, req workspaces.AcceptInvitationRequest) (*workspaces.Workspace, error)
+}
+
+// Handlers contains the workspaces HTTP handlers
+type Handlers struct {
+	service   Service
+	validator *validator.Validate
+}
+
+// NewHandlers creates new workspaces handlers
+func NewHandlers(service Service, validator *validator.Validate) *Handlers {
+	return &Handlers{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// pathIDs returns the caller and the ObjectIDs named by the given path params
+func pathIDs(c *fiber.Ctx, handlerName string, params ...string) (bson.ObjectID, []bson.ObjectID, error) {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return bson.ObjectID{}, nil, err
+	}
+
+	ids := make([]bson.ObjectID, len(params))
+	for i, param := range params {
+		ids[i], err = bson.ObjectIDFromHex(c.Params(param))
+		if err != nil {
+			logger.L().Info("invalid ID parameter", "handler", handlerName, "param", param, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+			return bson.ObjectID{}, nil, httperr.Fail(httperr.ErrBadRequest)
+		}
+	}
+	return userID, ids, nil
+}
+
+func serviceError(c *fiber.Ctx, err error, handlerName string, userID bson.ObjectID) error {
+	var status int
+	switch {
+	case errors.Is(err, workspaces.ErrWorkspaceNotFound),
+		errors.Is(err, workspaces.ErrMemberNotFound),
+		errors.Is(err, workspaces.ErrInvitationNotFound):
+		status = 404
+	case errors.Is(err, workspaces.ErrForbidden),
+		errors.Is(err, workspaces.ErrInvitationEmail):
+		status = 403
+	case errors.Is(err, workspaces.ErrAlreadyMember),
+		errors.Is(err, workspaces.ErrOwnerRole):
+		status = 409
+	case errors.Is(err, workspaces.ErrPersonalWorkspace):
+		status = 400
+	case errors.Is(err, workspaces.ErrMailNotConfigured):
+		logger.L().Error("workspace invitation not sent", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.E{Status: 503, Message: err.Error()})
+	default:
+		logger.L().Error("workspaces service failed", "handler", handlerName, ctxkeys.UserIDKey, userID.Hex(), "error", err)
+		return httperr.Fail(httperr.InternalError(err.Error()))
+	}
+
+	c.Locals("log_level", "info")
+	return httperr.Fail(httperr.E{Status: status, Message: err.Error()})
+}
+
+// List lists the caller's workspaces
+// @Summary List workspaces
+// @Description The implicit personal workspace comes first; its ID is the caller's user ID.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} workspaces.ListWorkspacesResponse
+// @Failure 401 {object} httperr.E
+// @Router /workspaces [get]
+func (h *Handlers) List(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return serviceError(c, err, "List", userID)
+	}
+	return c.JSON(resp)
+}
+
+// Create creates a shared workspace
+// @Summary Create workspace
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body workspaces.CreateWorkspaceRequest true "Workspace"
+// @Success 201 {object} workspaces.Workspace
+// @Failure 400 {object} httperr.E
+// @Failure 401 {object} httperr.E
+// @Router /workspaces [post]
+func (h *Handlers) Create(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req workspaces.CreateWorkspaceRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Create"); err != nil {
+		return err
+	}
+
+	ws, err := h.service.Create(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "Create", userID)
+	}
+	return c.Status(201).JSON(ws)
+}
+
+// Get shows a workspace
+// @Summary Get workspace
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Success 200 {object} workspaces.Workspace
+// @Failure 400 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id} [get]
+func (h *Handlers) Get(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "Get", "id")
+	if err != nil {
+		return err
+	}
+
+	ws, err := h.service.Get(c.Context(), userID, ids[0])
+	if err != nil {
+		return serviceError(c, err, "Get", userID)
+	}
+	return c.JSON(ws)
+}
+
+// Rename renames a workspace
+// @Summary Rename workspace
+// @Description Requires the admin or owner role.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Param request body workspaces.RenameWorkspaceRequest true "New name"
+// @Success 200 {object} workspaces.Workspace
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id} [patch]
+func (h *Handlers) Rename(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "Rename", "id")
+	if err != nil {
+		return err
+	}
+
+	var req workspaces.RenameWorkspaceRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Rename"); err != nil {
+		return err
+	}
+
+	ws, err := h.service.Rename(c.Context(), userID, ids[0], req)
+	if err != nil {
+		return serviceError(c, err, "Rename", userID)
+	}
+	return c.JSON(ws)
+}
+
+// Delete deletes a workspace with all of its notes
+// @Summary Delete workspace
+// @Description Requires the owner role. Notes, members and invitations are deleted too.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id} [delete]
+func (h *Handlers) Delete(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "Delete", "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(c.Context(), userID, ids[0]); err != nil {
+		return serviceError(c, err, "Delete", userID)
+	}
+	return c.SendStatus(204)
+}
+
+// ListMembers lists the members of a workspace
+// @Summary List workspace members
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Success 200 {object} workspaces.ListMembersResponse
+// @Failure 400 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id}/members [get]
+func (h *Handlers) ListMembers(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "ListMembers", "id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListMembers(c.Context(), userID, ids[0])
+	if err != nil {
+		return serviceError(c, err, "ListMembers", userID)
+	}
+	return c.JSON(resp)
+}
+
+// SetMemberRole changes a member's role
+// @Summary Change member role
+// @Description Requires the admin or owner role. The owner's role cannot be changed.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Param userId path string true "Member user ID"
+// @Param request body workspaces.SetMemberRoleRequest true "New role"
+// @Success 200 {object} workspaces.Member
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /workspaces/{id}/members/{userId} [patch]
+func (h *Handlers) SetMemberRole(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "SetMemberRole", "id", "userId")
+	if err != nil {
+		return err
+	}
+
+	var req workspaces.SetMemberRoleRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "SetMemberRole"); err != nil {
+		return err
+	}
+
+	member, err := h.service.SetMemberRole(c.Context(), userID, ids[0], ids[1], req.Role)
+	if err != nil {
+		return serviceError(c, err, "SetMemberRole", userID)
+	}
+	return c.JSON(member)
+}
+
+// RemoveMember removes a member, or lets the caller leave
+// @Summary Remove member
+// @Description Admins and the owner may remove members; anyone but the owner may remove themselves.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Param userId path string true "Member user ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /workspaces/{id}/members/{userId} [delete]
+func (h *Handlers) RemoveMember(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "RemoveMember", "id", "userId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RemoveMember(c.Context(), userID, ids[0], ids[1]); err != nil {
+		return serviceError(c, err, "RemoveMember", userID)
+	}
+	return c.SendStatus(204)
+}
+
+// Invite emails an invitation to join a workspace
+// @Summary Invite by email
+// @Description Requires the admin or owner role. The link in the email is valid for 7 days.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Param request body workspaces.InviteRequest true "Invitation"
+// @Success 201 {object} workspaces.Invitation
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 500 {object} httperr.E
+// @Failure 503 {object} httperr.E
+// @Router /workspaces/{id}/invitations [post]
+func (h *Handlers) Invite(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "Invite", "id")
+	if err != nil {
+		return err
+	}
+
+	var req workspaces.InviteRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "Invite"); err != nil {
+		return err
+	}
+
+	inv, err := h.service.Invite(c.Context(), userID, ids[0], req)
+	if err != nil {
+		return serviceError(c, err, "Invite", userID)
+	}
+	return c.Status(201).JSON(inv)
+}
+
+// ListInvitations lists pending invitations
+// @Summary List invitations
+// @Description Requires the admin or owner role.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Success 200 {object} workspaces.ListInvitationsResponse
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id}/invitations [get]
+func (h *Handlers) ListInvitations(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "ListInvitations", "id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListInvitations(c.Context(), userID, ids[0])
+	if err != nil {
+		return serviceError(c, err, "ListInvitations", userID)
+	}
+	return c.JSON(resp)
+}
+
+// RevokeInvitation deletes a pending invitation
+// @Summary Revoke invitation
+// @Description Requires the admin or owner role.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Workspace ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 204
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Router /workspaces/{id}/invitations/{invitationId} [delete]
+func (h *Handlers) RevokeInvitation(c *fiber.Ctx) error {
+	userID, ids, err := pathIDs(c, "RevokeInvitation", "id", "invitationId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RevokeInvitation(c.Context(), userID, ids[0], ids[1]); err != nil {
+		return serviceError(c, err, "RevokeInvitation", userID)
+	}
+	return c.SendStatus(204)
+}
+
+// AcceptInvitation joins a workspace
+// @Summary Accept invitation
+// @Description The invitation must have been sent to the caller's current email address.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body workspaces.AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} workspaces.Workspace
+// @Failure 400 {object} httperr.E
+// @Failure 403 {object} httperr.E
+// @Failure 404 {object} httperr.E
+// @Failure 409 {object} httperr.E
+// @Router /workspaces/invitations/accept [post]
+func (h *Handlers) AcceptInvitation(c *fiber.Ctx) error {
+	userID, err := handlerutil.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req workspaces.AcceptInvitationRequest
+	if err := handlerutil.ParseAndValidateBody(c, &req, h.validator, "AcceptInvitation"); err != nil {
+		return err
+	}
+
+	ws, err := h.service.AcceptInvitation(c.Context(), userID, req)
+	if err != nil {
+		return serviceError(c, err, "AcceptInvitation", userID)
+	}
+	return c.JSON(ws)
+}
@@ -84,7 +84,7 @@ func main() {
 	logg.Info("starting NotePulse", "port", cfg.AppPort, "version", version, "commit", commit, "built_at", builtAt)
 
 	// Setup router and start server
-	app := setupRouter(ctx, cfg)
+	app := setupRouter(ctx, cfg, g)
 	portStr := fmt.Sprintf(":%d", cfg.AppPort)
 
 	g.Go(func() error {
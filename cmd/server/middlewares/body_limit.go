@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"io"
+
+	"note-pulse/cmd/server/handlers/httperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errBodyTooLarge   = httperr.E{Status: 413, Message: "request body is too large"}
+	errLengthRequired = httperr.E{Status: 411, Message: "Content-Length is required"}
+	errBodyRead       = httperr.E{Status: 400, Message: "failed to read request body"}
+)
+
+// BodyLimit caps request bodies for an app that streams them. Most routes
+// get limit: their body is read into memory up to it, as Fiber would, and a
+// longer one is refused with 413. The routes matched by streamed get
+// streamedLimit instead and keep their body as a stream for the handler,
+// e.g. a multipart upload that is spooled to disk; their length must be
+// declared up front.
+func BodyLimit(limit int, streamed func(c *fiber.Ctx) bool, streamedLimit int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		length := req.Header.ContentLength()
+
+		if streamed != nil && streamed(c) {
+			switch {
+			case length < 0 && req.IsBodyStream():
+				return refuseBody(c, errLengthRequired)
+			case int64(length) > streamedLimit:
+				return refuseBody(c, errBodyTooLarge)
+			}
+			return c.Next()
+		}
+
+		if length > limit {
+			return refuseBody(c, errBodyTooLarge)
+		}
+		if !req.IsBodyStream() {
+			return c.Next()
+		}
+		// Chunked bodies declare no length, so the cap applies as they are read
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			return refuseBody(c, errBodyRead)
+		}
+		if len(body) > limit {
+			return refuseBody(c, errBodyTooLarge)
+		}
+		req.SetBody(body)
+		return c.Next()
+	}
+}
+
+// refuseBody fails a request whose body was not read to the end. The
+// connection is closed, since the rest of the body would otherwise be taken
+// for the next request.
+func refuseBody(c *fiber.Ctx, e httperr.E) error {
+	c.Locals("log_level", "info")
+	c.Context().SetConnectionClose()
+	return httperr.Fail(e)
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"note-pulse/cmd/server/handlers/httperr"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage:        true,
+		ErrorHandler:                 httperr.Handler,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	isUpload := func(c *fiber.Ctx) bool { return c.Path() == "/upload" }
+	app.Use(BodyLimit(10, isUpload, 100))
+
+	app.Post("/json", func(c *fiber.Ctx) error {
+		return c.SendString(string(c.Body()))
+	})
+	app.Post("/upload", func(c *fiber.Ctx) error {
+		assert.True(t, c.Request().IsBodyStream(), "uploads are left to the handler to stream")
+		b, err := io.ReadAll(c.Request().BodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString(string(b))
+	})
+
+	// app.Test cannot send chunked bodies, so the app serves a real listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	send := func(path string, body io.Reader) (int, string) {
+		resp, err := http.Post("http://"+ln.Addr().String()+path, "text/plain", body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+	// io.MultiReader hides the length, so the request is sent chunked
+	chunked := func(s string) io.Reader { return io.MultiReader(strings.NewReader(s)) }
+
+	status, body := send("/json", strings.NewReader("0123456789"))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0123456789", body)
+	status, _ = send("/json", strings.NewReader("0123456789a"))
+	assert.Equal(t, 413, status)
+	status, body = send("/json", chunked("0123456789"))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0123456789", body)
+	status, _ = send("/json", chunked("0123456789a"))
+	assert.Equal(t, 413, status, "a chunked body is capped as it is read")
+
+	status, body = send("/upload", strings.NewReader(strings.Repeat("x", 100)))
+	assert.Equal(t, 200, status, "the upload route has a limit of its own")
+	assert.Len(t, body, 100)
+	status, _ = send("/upload", strings.NewReader(strings.Repeat("x", 101)))
+	assert.Equal(t, 413, status)
+	status, _ = send("/upload", chunked("x"))
+	assert.Equal(t, 411, status)
+}
@@ -0,0 +1,162 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/idempotency"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader names the client's key for a retryable request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader marks a response replayed from a stored one
+	IdempotencyReplayedHeader = "Idempotency-Replayed"
+
+	maxIdempotencyKeyLen = 255
+)
+
+// IdempotencyKeys claims keys and stores the responses of their requests
+type IdempotencyKeys interface {
+	Begin(ctx context.Context, scope, key, fingerprint string) (*idempotency.Response, error)
+	Complete(ctx context.Context, scope, key string, resp *idempotency.Response)
+	Release(ctx context.Context, scope, key string)
+}
+
+var (
+	errInvalidIdempotencyKey = httperr.E{Status: 400, Message: "Idempotency-Key must be 1 to 255 printable ASCII characters"}
+	errIdempotencyKeyReused  = httperr.E{Status: 422, Message: idempotency.ErrKeyReused.Error()}
+	errIdempotencyInFlight   = httperr.E{Status: 409, Message: idempotency.ErrInFlight.Error()}
+)
+
+// Idempotency runs a POST, PUT, PATCH or DELETE sent with an Idempotency-Key
+// header once per key. The response is stored, and a retry with the same
+// key, method, path and body gets it back with Idempotency-Replayed: true
+// instead of running again. Reusing a key for another request fails with
+// 422, and a retry while the first attempt is still running with 409.
+// Server errors and 429s are not stored, so they can be retried. Keys are
+// per user behind the JWT middleware; see anonymousScope for the others.
+// When the key store cannot be reached the request runs as if no key was
+// sent.
+func Idempotency(keys IdempotencyKeys) fiber.Handler {
+	return idempotent(keys, true)
+}
+
+// IdempotencyStatusOnly is Idempotency for routes whose responses hold
+// credentials, like sign-up: only the status is stored, so a retry gets it
+// with an empty body and has to sign in for its tokens.
+func IdempotencyStatusOnly(keys IdempotencyKeys) fiber.Handler {
+	return idempotent(keys, false)
+}
+
+// idempotent stores the status of each keyed response, and the body and its
+// content type when keepBody is set
+func idempotent(keys IdempotencyKeys, keepBody bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" || !unsafeMethod(c.Method()) {
+			return c.Next()
+		}
+		if !validIdempotencyKey(key) {
+			c.Locals("log_level", "info")
+			return httperr.Fail(errInvalidIdempotencyKey)
+		}
+
+		var scope string
+		if userID, ok := c.Locals(ctxkeys.UserIDKey).(string); ok && userID != "" {
+			scope = userID
+		} else {
+			scope = anonymousScope(c)
+		}
+		fingerprint := idempotency.Fingerprint(c.Method(), c.OriginalURL(), c.Body())
+
+		stored, err := keys.Begin(c.Context(), scope, key, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			c.Locals("log_level", "info")
+			return httperr.Fail(errIdempotencyKeyReused)
+		case errors.Is(err, idempotency.ErrInFlight):
+			c.Locals("log_level", "info")
+			return httperr.Fail(errIdempotencyInFlight)
+		case err != nil:
+			return c.Next()
+		case stored != nil:
+			c.Set(IdempotencyReplayedHeader, "true")
+			if stored.ContentType != "" {
+				c.Set(fiber.HeaderContentType, stored.ContentType)
+			}
+			return c.Status(stored.Status).Send(stored.Body)
+		}
+
+		// Render errors here so the stored response is the one the client sees
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				keys.Release(c.Context(), scope, key)
+				return handlerErr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError || status == fiber.StatusTooManyRequests {
+			keys.Release(c.Context(), scope, key)
+			return nil
+		}
+		resp := &idempotency.Response{Status: status}
+		if keepBody {
+			resp.ContentType = string(c.Response().Header.ContentType())
+			resp.Body = append([]byte(nil), c.Response().Body()...)
+		}
+		keys.Complete(c.Context(), scope, key, resp)
+		return nil
+	}
+}
+
+// anonymousScope keeps the keys of unauthenticated callers apart: per email
+// when the body names one, as a sign-up does, else per client IP. Two
+// clients picking the same key thus never see each other's responses.
+func anonymousScope(c *fiber.Ctx) string {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err == nil && body.Email != "" {
+		return emailScope(body.Email)
+	}
+	return "ip:" + c.IP()
+}
+
+// emailScope is the scope of an email, hashed so that it is not stored
+// with the key
+func emailScope(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "email:" + hex.EncodeToString(sum[:])
+}
+
+// unsafeMethod reports whether method changes state
+func unsafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// validIdempotencyKey reports whether key is short and printable ASCII
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
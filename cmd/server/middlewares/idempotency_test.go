@@ -0,0 +1,220 @@
+package middlewares
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/idempotency"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memKeys struct {
+	mu        sync.Mutex
+	prints    map[string]string
+	responses map[string]*idempotency.Response
+}
+
+func newMemKeys() *memKeys {
+	return &memKeys{prints: map[string]string{}, responses: map[string]*idempotency.Response{}}
+}
+
+func (m *memKeys) Begin(_ context.Context, scope, key, fingerprint string) (*idempotency.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := scope + "/" + key
+	print, ok := m.prints[id]
+	switch {
+	case !ok:
+		m.prints[id] = fingerprint
+		return nil, nil
+	case print != fingerprint:
+		return nil, idempotency.ErrKeyReused
+	case m.responses[id] == nil:
+		return nil, idempotency.ErrInFlight
+	}
+	return m.responses[id], nil
+}
+
+func (m *memKeys) Complete(_ context.Context, scope, key string, resp *idempotency.Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[scope+"/"+key] = resp
+}
+
+func (m *memKeys) Release(_ context.Context, scope, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.prints, scope+"/"+key)
+}
+
+func TestIdempotencyReplaysResponses(t *testing.T) {
+	keys := newMemKeys()
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+
+	runs := 0
+	app.Post("/notes", func(c *fiber.Ctx) error {
+		c.Locals(ctxkeys.UserIDKey, "u1")
+		return c.Next()
+	}, Idempotency(keys), func(c *fiber.Ctx) error {
+		runs++
+		if string(c.Body()) == "fail" {
+			return httperr.ErrInternal
+		}
+		return c.Status(201).JSON(fiber.Map{"run": runs})
+	})
+
+	send := func(key, body string) (int, string, string) {
+		req := httptest.NewRequest("POST", "/notes", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b), resp.Header.Get(IdempotencyReplayedHeader)
+	}
+
+	status, body, replayed := send("k1", "a")
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"run":1}`, body)
+	assert.Empty(t, replayed)
+
+	status, body, replayed = send("k1", "a")
+	assert.Equal(t, 201, status)
+	assert.JSONEq(t, `{"run":1}`, body, "a retry gets the first response")
+	assert.Equal(t, "true", replayed)
+	assert.Equal(t, 1, runs)
+
+	status, _, _ = send("k1", "b")
+	assert.Equal(t, 422, status, "a key reused for another body")
+
+	status, _, _ = send("", "a")
+	assert.Equal(t, 201, status)
+	assert.Equal(t, 2, runs, "requests without a key always run")
+
+	status, _, _ = send("k2", "fail")
+	assert.Equal(t, 500, status)
+	status, _, _ = send("k2", "fail")
+	assert.Equal(t, 500, status)
+	assert.Equal(t, 4, runs, "server errors are not stored")
+
+	status, _, _ = send("ключ", "a")
+	assert.Equal(t, 400, status)
+	status, _, _ = send(strings.Repeat("k", 256), "a")
+	assert.Equal(t, 400, status)
+}
+
+func TestIdempotencyStatusOnlyStoresNoBody(t *testing.T) {
+	keys := newMemKeys()
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+
+	runs := 0
+	app.Post("/sign-up", IdempotencyStatusOnly(keys), func(c *fiber.Ctx) error {
+		runs++
+		return c.Status(201).JSON(fiber.Map{"token": "secret"})
+	})
+
+	send := func() (int, string, string) {
+		req := httptest.NewRequest("POST", "/sign-up", strings.NewReader(`{"email":"a@example.com"}`))
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b), resp.Header.Get(IdempotencyReplayedHeader)
+	}
+
+	status, body, _ := send()
+	assert.Equal(t, 201, status)
+	assert.Contains(t, body, "secret")
+	for _, stored := range keys.responses {
+		assert.Equal(t, 201, stored.Status)
+		assert.Empty(t, stored.Body, "tokens are never stored")
+	}
+
+	status, body, replayed := send()
+	assert.Equal(t, 201, status)
+	assert.Empty(t, body)
+	assert.Equal(t, "true", replayed)
+	assert.Equal(t, 1, runs)
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	keys := newMemKeys()
+	body := `{"email":"a@example.com"}`
+	_, err := keys.Begin(context.Background(), emailScope("a@example.com"), "k1", idempotency.Fingerprint("POST", "/sign-up", []byte(body)))
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+	app.Post("/sign-up", Idempotency(keys), func(c *fiber.Ctx) error {
+		return c.SendStatus(201)
+	})
+
+	req := httptest.NewRequest("POST", "/sign-up", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestIdempotencyScopesAnonymousCallers(t *testing.T) {
+	keys := newMemKeys()
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+
+	runs := 0
+	app.Post("/sign-up", IdempotencyStatusOnly(keys), func(c *fiber.Ctx) error {
+		runs++
+		return c.SendStatus(201)
+	})
+
+	send := func(body string) (int, string) {
+		req := httptest.NewRequest("POST", "/sign-up", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "sign-up-1")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get(IdempotencyReplayedHeader)
+	}
+
+	status, _ := send(`{"email":"ann@example.com","password":"Password123"}`)
+	assert.Equal(t, 201, status)
+	status, replayed := send(`{"email":"bob@example.com","password":"Password456"}`)
+	assert.Equal(t, 201, status, "another client's sign-up under the same key is not a reuse")
+	assert.Empty(t, replayed)
+	assert.Equal(t, 2, runs)
+
+	status, _ = send(`{"email":"ann@example.com","password":"Password789"}`)
+	assert.Equal(t, 422, status, "a key reused by the same client")
+	status, replayed = send(`{"email":"ann@example.com","password":"Password123"}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, "true", replayed)
+	assert.Equal(t, 2, runs)
+
+	for id := range keys.prints {
+		assert.NotContains(t, id, "example.com", "emails are not stored with the keys")
+	}
+}
+
+func TestIdempotencySkipsSafeMethods(t *testing.T) {
+	keys := newMemKeys()
+	app := fiber.New()
+	app.Get("/notes", Idempotency(keys), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+
+	req := httptest.NewRequest("GET", "/notes", nil)
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, keys.prints)
+}
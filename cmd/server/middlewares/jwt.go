@@ -1,24 +1,43 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
+
 	"note-pulse/cmd/server/ctxkeys"
+	"note-pulse/cmd/server/handlers/httperr"
 	"note-pulse/internal/config"
+	"note-pulse/internal/logger"
 	"note-pulse/internal/services/auth"
 
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// UserStatusProvider reports whether a user is disabled, which role they
+// currently have and whether a session was revoked. The auth service
+// implements it with a short-lived cache.
+type UserStatusProvider interface {
+	UserStatus(ctx context.Context, userID bson.ObjectID) (auth.UserStatus, error)
+	SessionActive(ctx context.Context, userID bson.ObjectID, sessionID string) (bool, error)
+}
+
 // JWT returns a configured Fiber middleware that:
 //
 //   - validates the Bearer token signature using cfg.JWTSecret
 //   - makes sure the token carries "user_id" and "email" claims
 //   - stores those values in ctx.Locals(ctxkeys.UserIDKey) / ctx.Locals(ctxkeys.UserEmailKey) so
-//     downstream handlers can trust them.
+//     downstream handlers can trust them; the optional "sid" claim goes to
+//     ctx.Locals(ctxkeys.SessionIDKey).
+//   - when a UserStatusProvider is given, rejects disabled or deleted users
+//     and tokens whose "sid" session was revoked, and stores the current
+//     role in ctx.Locals(ctxkeys.UserRoleKey); otherwise the role comes from
+//     the optional "role" claim.
 //
 // On any problem it bubbles up a 401 via the global httperr handler.
-func JWT(cfg config.Config) fiber.Handler {
+func JWT(cfg config.Config, status ...UserStatusProvider) fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{Key: []byte(cfg.JWTSecret)},
 		SuccessHandler: func(c *fiber.Ctx) error {
@@ -38,6 +57,20 @@ func JWT(cfg config.Config) fiber.Handler {
 
 			c.Locals(ctxkeys.UserIDKey, userID)
 			c.Locals(ctxkeys.UserEmailKey, userEmail)
+			// "sid" is optional: tokens minted before session tracking lack it.
+			sessionID, _ := claims["sid"].(string)
+			if sessionID != "" {
+				c.Locals(ctxkeys.SessionIDKey, sessionID)
+			}
+			if role, ok := claims["role"].(string); ok {
+				c.Locals(ctxkeys.UserRoleKey, role)
+			}
+
+			for _, p := range status {
+				if err := checkUserStatus(c, p, userID, sessionID); err != nil {
+					return err
+				}
+			}
 			return c.Next()
 		},
 
@@ -47,3 +80,50 @@ func JWT(cfg config.Config) fiber.Handler {
 		},
 	})
 }
+
+// checkUserStatus rejects users that were disabled or deleted after their
+// token was issued, and tokens of a revoked session, and refreshes the role
+// from storage
+func checkUserStatus(c *fiber.Ctx, p UserStatusProvider, userIDHex, sessionID string) error {
+	userID, err := bson.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return auth.ErrInvalidTokenMissingUserID
+	}
+
+	st, err := p.UserStatus(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return auth.ErrUnauthorized(err)
+		}
+		logger.L().Error("user status lookup failed", ctxkeys.UserIDKey, userIDHex, "error", err)
+		return httperr.Fail(httperr.ErrInternal)
+	}
+	if st.Disabled {
+		return auth.ErrUnauthorized(auth.ErrAccountDisabled)
+	}
+
+	if sessionID != "" {
+		active, err := p.SessionActive(c.Context(), userID, sessionID)
+		if err != nil {
+			logger.L().Error("session lookup failed", ctxkeys.UserIDKey, userIDHex, "error", err)
+			return httperr.Fail(httperr.ErrInternal)
+		}
+		if !active {
+			return auth.ErrUnauthorized(auth.ErrSessionRevoked)
+		}
+	}
+
+	c.Locals(ctxkeys.UserRoleKey, st.Role)
+	return nil
+}
+
+// RequireRole lets the request through only if the authenticated user has
+// role. It must run after JWT.
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if userRole, _ := c.Locals(ctxkeys.UserRoleKey).(string); userRole != role {
+			return httperr.Fail(httperr.E{Status: 403, Message: "Forbidden"})
+		}
+		return c.Next()
+	}
+}
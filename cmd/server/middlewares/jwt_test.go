@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/cmd/server/testutil"
+	"note-pulse/internal/config"
+	"note-pulse/internal/services/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const jwtTestSecret = "test-secret-with-32-plus-characters"
+
+// stubStatus serves fixed user statuses
+type stubStatus map[bson.ObjectID]auth.UserStatus
+
+func (s stubStatus) UserStatus(_ context.Context, userID bson.ObjectID) (auth.UserStatus, error) {
+	st, ok := s[userID]
+	if !ok {
+		return auth.UserStatus{}, auth.ErrUserNotFound
+	}
+	return st, nil
+}
+
+// revokedSessionHex names the session stubStatus reports as revoked
+var revokedSessionHex = bson.NewObjectID().Hex()
+
+func (s stubStatus) SessionActive(_ context.Context, _ bson.ObjectID, sessionID string) (bool, error) {
+	return sessionID != revokedSessionHex, nil
+}
+
+func newStatusTestApp(status stubStatus) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+	jwtMW := JWT(config.Config{JWTSecret: jwtTestSecret}, status)
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(200) }
+	app.Get("/me", jwtMW, ok)
+	app.Get("/admin", jwtMW, RequireRole(auth.RoleAdmin), ok)
+	return app
+}
+
+func statusOf(t *testing.T, app *fiber.App, path string, userID bson.ObjectID) int {
+	t.Helper()
+	token, err := testutil.CreateTestJWT(userID.Hex(), "u@example.com", []byte(jwtTestSecret), time.Hour)
+	require.NoError(t, err)
+
+	resp, err := app.Test(testutil.CreateAuthenticatedRequest("GET", path, nil, token), -1)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestJWTRejectsInactiveUsers(t *testing.T) {
+	active, disabled, deleted := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	app := newStatusTestApp(stubStatus{
+		active:   {Role: auth.RoleUser},
+		disabled: {Role: auth.RoleUser, Disabled: true},
+	})
+
+	assert.Equal(t, 200, statusOf(t, app, "/me", active))
+	assert.Equal(t, 401, statusOf(t, app, "/me", disabled), "disabled users fail the JWT check")
+	assert.Equal(t, 401, statusOf(t, app, "/me", deleted), "deleted users fail the JWT check")
+}
+
+func TestRequireRole(t *testing.T) {
+	user, admin := bson.NewObjectID(), bson.NewObjectID()
+	app := newStatusTestApp(stubStatus{
+		user:  {Role: auth.RoleUser},
+		admin: {Role: auth.RoleAdmin},
+	})
+
+	assert.Equal(t, 403, statusOf(t, app, "/admin", user))
+	assert.Equal(t, 200, statusOf(t, app, "/admin", admin))
+}
+
+func TestJWTRejectsRevokedSessions(t *testing.T) {
+	user := bson.NewObjectID()
+	app := newStatusTestApp(stubStatus{user: {Role: auth.RoleUser}})
+
+	statusWithSession := func(sessionID string) int {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": user.Hex(),
+			"email":   "u@example.com",
+			"sid":     sessionID,
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(jwtTestSecret))
+		require.NoError(t, err)
+
+		resp, err := app.Test(testutil.CreateAuthenticatedRequest("GET", "/me", nil, token), -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, statusWithSession(bson.NewObjectID().Hex()))
+	assert.Equal(t, 401, statusWithSession(revokedSessionHex), "a revoked session's access token fails the JWT check")
+	assert.Equal(t, 200, statusOf(t, app, "/me", user), "tokens without a session skip the check")
+}
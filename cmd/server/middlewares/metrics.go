@@ -33,8 +33,9 @@ func normalizeStatus(status int) string {
 }
 
 // AttachMetrics gives the supplied Fiber app its **own** Prometheus registry
-// and wires a /metrics endpoint plus request-timing middleware.
-func AttachMetrics(app *fiber.App) {
+// and wires a /metrics endpoint plus request-timing middleware. Extra
+// collectors (e.g. service-level counters) are registered alongside.
+func AttachMetrics(app *fiber.App, collectors ...prometheus.Collector) {
 	reg := prometheus.NewRegistry()
 
 	// collectors
@@ -55,6 +56,7 @@ func AttachMetrics(app *fiber.App) {
 	)
 
 	reg.MustRegister(reqDuration, reqTotal)
+	reg.MustRegister(collectors...)
 
 	app.Use(func(c *fiber.Ctx) error {
 		start := time.Now()
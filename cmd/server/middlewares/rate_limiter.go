@@ -1,46 +1,141 @@
 package middlewares
 
 import (
+	"context"
+	"math"
+	"strconv"
 	"strings"
-	"time"
 
+	"note-pulse/cmd/server/ctxkeys"
 	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/internal/services/ratelimit"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// BuildRateLimiter returns a Fiber handler that does *nothing* when max <= 0
-// so callers don't need to wrap it in an if-statement.
+// Rate limit response headers, after the IETF RateLimit header fields draft
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimits counts requests against rate limit policies
+type RateLimits interface {
+	Allow(ctx context.Context, policy ratelimit.Policy, subject string) ratelimit.Result
+}
+
+// RateLimitKey names the bucket a request is counted in
+type RateLimitKey func(c *fiber.Ctx) string
+
+// KeyByIP counts requests per client IP
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser counts requests per user when the request is authenticated and
+// per client IP otherwise. The user comes from the JWT middleware when it
+// ran first, else from a Bearer token or ?token= query parameter that
+// verifies with jwtSecret; an unverified token is never trusted, so nobody
+// can spend another user's budget.
+func KeyByUser(jwtSecret string) RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		if userID, ok := c.Locals(ctxkeys.UserIDKey).(string); ok && userID != "" {
+			return "user:" + userID
+		}
+		token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok {
+			token = c.Query("token")
+		}
+		if userID := verifiedUserID(token, jwtSecret); userID != "" {
+			return "user:" + userID
+		}
+		return KeyByIP(c)
+	}
+}
+
+// SkipPaths skips requests whose path starts with any of prefixes
+func SkipPaths(prefixes ...string) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(c.Path(), p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SkipReads skips requests that change nothing, and those whose path starts
+// with any of prefixes
+func SkipReads(prefixes ...string) func(c *fiber.Ctx) bool {
+	skipPath := SkipPaths(prefixes...)
+	return func(c *fiber.Ctx) bool {
+		return !unsafeMethod(c.Method()) || skipPath(c)
+	}
+}
+
+// BuildRateLimiter returns a Fiber handler that does *nothing* when
+// policy.Limit <= 0 so callers don't need to wrap it in an if-statement.
 //
-//	max           — requests per Expiration window
-//	expiration    — bucket window
-//	skipPrefixes  — requests whose path *starts* with any of these prefixes
-//	                bypass the limiter (handy for auth-only or health-checks).
-func BuildRateLimiter(max int, expiration time.Duration, skipPrefixes ...string) fiber.Handler {
-	if max <= 0 {
+//	limits — where requests are counted, in memory or shared by replicas
+//	policy — requests per window
+//	key    — the bucket of a request, e.g. KeyByIP or KeyByUser
+//	skip   — requests it returns true for bypass the limiter; may be nil
+//
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy of the tightest limiter a request went through, and a 429
+// also Retry-After.
+func BuildRateLimiter(limits RateLimits, policy ratelimit.Policy, key RateLimitKey, skip func(c *fiber.Ctx) bool) fiber.Handler {
+	if policy.Limit <= 0 {
 		// disabled -> just fall through
 		return func(c *fiber.Ctx) error { return c.Next() }
 	}
 
-	cfg := limiter.Config{
-		Max:        max,
-		Expiration: expiration,
-		LimitReached: func(c *fiber.Ctx) error {
-			return httperr.Fail(httperr.ErrTooManyRequests)
-		},
-	}
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
 
-	if len(skipPrefixes) > 0 {
-		cfg.Next = func(c *fiber.Ctx) bool {
-			for _, p := range skipPrefixes {
-				if strings.HasPrefix(c.Path(), p) {
-					return true // skip limiter
-				}
-			}
-			return false
+		res := limits.Allow(c.Context(), policy, key(c))
+		reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+		if tighterRateLimit(c, res.Remaining) {
+			c.Set(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+			c.Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+			c.Set(RateLimitResetHeader, reset)
+			c.Set(RateLimitPolicyHeader, strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(int(policy.Window.Seconds())))
+		}
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, reset)
+			c.Locals("log_level", "info")
+			return httperr.Fail(httperr.ErrTooManyRequests)
 		}
+		return c.Next()
 	}
+}
 
-	return limiter.New(cfg)
+// tighterRateLimit reports whether remaining is below what an earlier
+// limiter on the route already reported
+func tighterRateLimit(c *fiber.Ctx, remaining int) bool {
+	prev, err := strconv.Atoi(c.GetRespHeader(RateLimitRemainingHeader))
+	return err != nil || remaining < prev
+}
+
+// verifiedUserID returns the user_id claim of token if its signature
+// verifies with jwtSecret
+func verifiedUserID(token, jwtSecret string) string {
+	if token == "" {
+		return ""
+	}
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (any, error) {
+		return []byte(jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !parsed.Valid {
+		return ""
+	}
+	claims, _ := parsed.Claims.(jwt.MapClaims)
+	userID, _ := claims["user_id"].(string)
+	return userID
 }
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"note-pulse/cmd/server/handlers/httperr"
+	"note-pulse/cmd/server/testutil"
+	"note-pulse/internal/services/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newRateLimitTestApp() *fiber.App {
+	limits := ratelimit.NewService(ratelimit.NewMemoryRepository(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	byUser := KeyByUser(jwtTestSecret)
+
+	app := fiber.New(fiber.Config{ErrorHandler: httperr.Handler})
+	app.Use(BuildRateLimiter(limits, ratelimit.Policy{Name: "app", Limit: 3, Window: time.Hour}, byUser, SkipPaths("/auth")))
+	app.Use(BuildRateLimiter(limits, ratelimit.Policy{Name: "write", Limit: 1, Window: time.Hour}, byUser, SkipReads("/auth")))
+	app.Use(BuildRateLimiter(limits, ratelimit.Policy{Name: "off", Window: time.Hour}, KeyByIP, nil))
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(200) }
+	app.Get("/notes", ok)
+	app.Post("/notes", ok)
+	app.Post("/auth/sign-in", ok)
+	return app
+}
+
+func TestRateLimiterPerUser(t *testing.T) {
+	app := newRateLimitTestApp()
+
+	send := func(method, path, token string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp
+	}
+
+	alice, err := testutil.CreateTestJWT(bson.NewObjectID().Hex(), "alice@example.com", []byte(jwtTestSecret), time.Hour)
+	require.NoError(t, err)
+	bob, err := testutil.CreateTestJWT(bson.NewObjectID().Hex(), "bob@example.com", []byte(jwtTestSecret), time.Hour)
+	require.NoError(t, err)
+	forged, err := testutil.CreateTestJWT(bson.NewObjectID().Hex(), "eve@example.com", []byte("another-secret-of-32-plus-characters"), time.Hour)
+	require.NoError(t, err)
+
+	resp := send("GET", "/notes", alice)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get(RateLimitLimitHeader))
+	assert.Equal(t, "2", resp.Header.Get(RateLimitRemainingHeader))
+	assert.Equal(t, "3;w=3600", resp.Header.Get(RateLimitPolicyHeader))
+	assert.NotEmpty(t, resp.Header.Get(RateLimitResetHeader))
+
+	resp = send("POST", "/notes", alice)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(RateLimitLimitHeader), "the tightest policy is reported")
+	assert.Equal(t, "0", resp.Header.Get(RateLimitRemainingHeader))
+
+	resp = send("POST", "/notes", alice)
+	assert.Equal(t, 429, resp.StatusCode, "writes have their own, stricter budget")
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+
+	assert.Equal(t, 429, send("GET", "/notes", alice).StatusCode, "every request counts against the app budget")
+	assert.Equal(t, 200, send("GET", "/notes", bob).StatusCode, "users behind one IP have their own buckets")
+	assert.Equal(t, 200, send("POST", "/auth/sign-in", alice).StatusCode, "skipped paths are not limited")
+
+	// A token that does not verify counts against the IP, shared with
+	// anonymous callers
+	assert.Equal(t, 200, send("GET", "/notes", forged).StatusCode)
+	assert.Equal(t, 200, send("GET", "/notes", "").StatusCode)
+	assert.Equal(t, 200, send("GET", "/notes", "").StatusCode)
+	assert.Equal(t, 429, send("GET", "/notes", forged).StatusCode)
+}
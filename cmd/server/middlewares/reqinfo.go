@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"note-pulse/internal/utils/reqinfo"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxRequestIDLen bounds a request ID taken from the client
+const maxRequestIDLen = 64
+
+// RequestInfo records the caller's IP, User-Agent and request ID on the
+// request so that services can read them back with reqinfo.From(ctx).
+// Handlers pass c.Context() to services, and fasthttp resolves ctx.Value
+// through the same user values that c.Locals writes, so no handler changes
+// are needed. The request ID comes from X-Request-ID when a proxy sent a
+// usable one, else a new one is made; either way it is echoed back.
+func RequestInfo() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(requestID) {
+			requestID = bson.NewObjectID().Hex()
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		c.Locals(reqinfo.ContextKey, reqinfo.Info{
+			IP:        c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+			RequestID: requestID,
+		})
+		return c.Next()
+	}
+}
+
+// validRequestID reports whether id is short and printable ASCII
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"note-pulse/internal/utils/reqinfo"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestInfoReachesServiceContext(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestInfo())
+
+	var got reqinfo.Info
+	app.Get("/", func(c *fiber.Ctx) error {
+		// services receive c.Context(), not c.UserContext()
+		got = reqinfo.From(c.Context())
+		return c.SendStatus(200)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "np-test/1.0")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	assert.Equal(t, "np-test/1.0", got.UserAgent)
+	assert.NotEmpty(t, got.IP)
+}
+
+func TestRequestInfoRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestInfo())
+
+	var got reqinfo.Info
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = reqinfo.From(c.Context())
+		return c.SendStatus(200)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Len(t, got.RequestID, 24, "a request without an ID gets one")
+	assert.Equal(t, got.RequestID, resp.Header.Get("X-Request-ID"))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "edge-7f3a")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "edge-7f3a", got.RequestID)
+	assert.Equal(t, "edge-7f3a", resp.Header.Get("X-Request-ID"))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "has spaces")
+	_, err = app.Test(req)
+	require.NoError(t, err)
+	assert.NotEqual(t, "has spaces", got.RequestID)
+}
@@ -6,16 +6,38 @@ import (
 	"time"
 
 	"note-pulse/cmd/server/handlers"
+	adminHandlers "note-pulse/cmd/server/handlers/admin"
+	auditHandlers "note-pulse/cmd/server/handlers/audit"
 	"note-pulse/cmd/server/handlers/auth"
 	"note-pulse/cmd/server/handlers/httperr"
+	inboundHandlers "note-pulse/cmd/server/handlers/inbound"
+	notebooksHandlers "note-pulse/cmd/server/handlers/notebooks"
 	notesHandlers "note-pulse/cmd/server/handlers/notes"
+	templatesHandlers "note-pulse/cmd/server/handlers/templates"
+	viewsHandlers "note-pulse/cmd/server/handlers/views"
+	webhooksHandlers "note-pulse/cmd/server/handlers/webhooks"
+	workspacesHandlers "note-pulse/cmd/server/handlers/workspaces"
 	"note-pulse/cmd/server/middlewares"
+	"note-pulse/internal/clients/blobstore"
+	"note-pulse/internal/clients/mail"
 	"note-pulse/internal/clients/mongo"
+	"note-pulse/internal/clients/searchindex"
 	"note-pulse/internal/config"
 	"note-pulse/internal/logger"
+	adminServices "note-pulse/internal/services/admin"
+	auditServices "note-pulse/internal/services/audit"
 	authServices "note-pulse/internal/services/auth"
+	idempotencyServices "note-pulse/internal/services/idempotency"
+	inboundServices "note-pulse/internal/services/inbound"
+	notebooksServices "note-pulse/internal/services/notebooks"
 	notesServices "note-pulse/internal/services/notes"
+	ratelimitServices "note-pulse/internal/services/ratelimit"
+	templatesServices "note-pulse/internal/services/templates"
+	viewsServices "note-pulse/internal/services/views"
+	webhooksServices "note-pulse/internal/services/webhooks"
+	workspacesServices "note-pulse/internal/services/workspaces"
 	"note-pulse/internal/utils/crypto"
+	"note-pulse/internal/utils/embedding"
 
 	_ "note-pulse/docs" // Load swagger docs
 
@@ -26,14 +48,16 @@ import (
 	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	RateLimitExpiration = 1 * time.Minute
 )
 
-// setupRouter configures and returns a Fiber app with all routes
-func setupRouter(ctx context.Context, cfg config.Config) *fiber.App {
+// setupRouter configures and returns a Fiber app with all routes. Background
+// workers the services need are started on g.
+func setupRouter(ctx context.Context, cfg config.Config, g *errgroup.Group) *fiber.App {
 
 	// Initialize validator and register password validation
 	v := validator.New()
@@ -55,17 +79,25 @@ func setupRouter(ctx context.Context, cfg config.Config) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: httperr.Handler,
 		Immutable:    true, // make Fiber copy all request-derived strings
+		// Bodies are streamed so that attachment uploads need not fit in
+		// memory; BodyLimit holds every other route to the default limit
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
 	// Global middlewares
 	app.Use(recover.New())
+	// leave room for the multipart framing around an attachment
+	app.Use(middlewares.BodyLimit(fiber.DefaultBodyLimit, isAttachmentUpload, cfg.AttachmentMaxBytes+1<<20))
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Content-Type, Authorization",
+		AllowOrigins:  "*",
+		AllowHeaders:  "Content-Type, Authorization, Idempotency-Key",
+		ExposeHeaders: "Idempotency-Replayed, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After",
 	}))
+	app.Use(middlewares.RequestInfo())
 
 	if cfg.RouteMetricsEnabled {
-		middlewares.AttachMetrics(app)
+		middlewares.AttachMetrics(app, append(authServices.Collectors(), webhooksServices.Collectors()...)...)
 	}
 
 	// Health check endpoint, outside versioned API to appease scanners and to avoid logging
@@ -87,13 +119,31 @@ func setupRouter(ctx context.Context, cfg config.Config) *fiber.App {
 		logger.L().Info("request logging disabled")
 	}
 
-	// App rate limiting
-	v1.Use(middlewares.BuildRateLimiter(cfg.AppRatePerMin, RateLimitExpiration, "/api/v1/auth"))
+	// App rate limiting, per user once signed in and per IP before. With the
+	// mongo store every replica counts into the same buckets.
+	var rateLimitsRepo ratelimitServices.Repository = ratelimitServices.NewMemoryRepository()
+	if cfg.RateLimitStore == config.RateLimitStoreMongo {
+		mongoRateLimits, err := mongo.NewRateLimitsRepo(ctx, mongo.DB())
+		if err != nil {
+			logger.L().Error("failed to create rate limits repository", "error", err)
+			panic(err)
+		}
+		rateLimitsRepo = mongoRateLimits
+	}
+	rateLimits := ratelimitServices.NewService(rateLimitsRepo, logger.L())
+	byUser := middlewares.KeyByUser(cfg.JWTSecret)
 
-	jwtMiddleware := middlewares.JWT(cfg)
+	v1.Use(middlewares.BuildRateLimiter(rateLimits,
+		ratelimitServices.Policy{Name: "app", Limit: cfg.AppRatePerMin, Window: RateLimitExpiration},
+		byUser, middlewares.SkipPaths("/api/v1/auth")))
+	v1.Use(middlewares.BuildRateLimiter(rateLimits,
+		ratelimitServices.Policy{Name: "write", Limit: cfg.WriteRatePerMin, Window: RateLimitExpiration},
+		byUser, middlewares.SkipReads("/api/v1/auth")))
 
 	authGrp := v1.Group("/auth",
-		middlewares.BuildRateLimiter(cfg.AuthRatePerMin, RateLimitExpiration),
+		middlewares.BuildRateLimiter(rateLimits,
+			ratelimitServices.Policy{Name: "auth", Limit: cfg.AuthRatePerMin, Window: RateLimitExpiration},
+			middlewares.KeyByIP, nil),
 	)
 
 	usersRepo, newUsersRepoErr := mongo.NewUsersRepo(ctx, mongo.DB())
@@ -106,38 +156,421 @@ func setupRouter(ctx context.Context, cfg config.Config) *fiber.App {
 		logger.L().Error("failed to create refresh tokens repository", "error", newRefreshTokensRepoErr)
 		panic(newRefreshTokensRepoErr)
 	}
+	loginAttemptsRepo, newLoginAttemptsRepoErr := mongo.NewLoginAttemptsRepo(ctx, mongo.DB())
+	if newLoginAttemptsRepoErr != nil {
+		logger.L().Error("failed to create login attempts repository", "error", newLoginAttemptsRepoErr)
+		panic(newLoginAttemptsRepoErr)
+	}
+	hub := notesServices.NewHub(cfg.WSOutboxBuffer)
+	hub.SetMaxConnsPerUser(cfg.WSMaxConnsPerUser)
+	g.Go(func() error { return hub.Run(ctx) })
+
+	// Audit log of security and data events; entries expire after the
+	// retention period
+	auditLogRepo, err := mongo.NewAuditLogRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create audit log repository", "error", err)
+		panic(err)
+	}
+	auditSvc := auditServices.NewService(auditLogRepo, time.Duration(cfg.AuditRetentionDays)*24*time.Hour, logger.L())
+	auditH := auditHandlers.NewHandlers(auditSvc, v)
+
+	// Outgoing mail; without SMTP_HOST, features that send email fail
+	mailer := mail.NewSMTP(cfg)
+	if mailer == nil {
+		logger.L().Warn("SMTP_HOST is not set, email changes and workspace invitations are disabled")
+	}
+
 	authSvc := authServices.NewService(usersRepo, refreshTokensRepo, cfg, logger.L())
+	if mailer != nil {
+		authSvc.SetMailer(mailer)
+	}
+	authSvc.SetSessionCloser(hub)
+	authSvc.SetLoginAttempts(loginAttemptsRepo)
+	authSvc.SetAuditSink(auditSvc)
 	authHandlers := auth.NewHandlers(authSvc, v)
 
-	authGrp.Post("/sign-up", authHandlers.SignUp)
+	// Every authenticated request also checks that the user is still active
+	jwtMiddleware := middlewares.JWT(cfg, authSvc)
+
+	// Retries sent with an Idempotency-Key get the first attempt's response
+	idempotencyKeysRepo, err := mongo.NewIdempotencyKeysRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create idempotency keys repository", "error", err)
+		panic(err)
+	}
+	idempotencySvc := idempotencyServices.NewService(idempotencyKeysRepo, time.Duration(cfg.IdempotencyTTLHours)*time.Hour, logger.L())
+	idempotencyMiddleware := middlewares.Idempotency(idempotencySvc)
+
+	// Sign-up responses hold tokens, which must not be stored with the key
+	authGrp.Post("/sign-up", middlewares.IdempotencyStatusOnly(idempotencySvc), authHandlers.SignUp)
 	authGrp.Post("/sign-in", authHandlers.SignIn)
 	authGrp.Post("/refresh", authHandlers.Refresh)
 	authGrp.Post("/sign-out", jwtMiddleware, authHandlers.SignOut)
 	authGrp.Post("/sign-out-all", jwtMiddleware, authHandlers.SignOutAll)
 
+	// Session (device) management
+	sessionsGrp := v1.Group("/sessions", jwtMiddleware)
+	sessionsGrp.Get("/", authHandlers.ListSessions)
+	sessionsGrp.Delete("/:id", authHandlers.RevokeSession)
+
+	v1.Get("/audit", jwtMiddleware, auditH.List)
+
 	// Notes routes
 	notesRepo, err := mongo.NewNotesRepo(ctx, mongo.DB())
 	if err != nil {
 		logger.L().Error(notesServices.ErrCreateNotesRepo.Error(), "error", err)
 		panic(err)
 	}
-	hub := notesServices.NewHub(cfg.WSOutboxBuffer)
 	notesSvc := notesServices.NewService(notesRepo, hub, logger.L())
+	notesSvc.SetAuditSink(auditSvc)
+	authSvc.AddPurger(notesSvc)
+
+	// Jobs that must run on one replica hold a lease
+	leasesRepo, err := mongo.NewLeasesRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create leases repository", "error", err)
+		panic(err)
+	}
+	setupSearchIndex(ctx, cfg, g, notesSvc, leasesRepo)
+
+	// Note events are stored with their writes, in one transaction on a
+	// replica set, and the relay publishes those a crash left behind
+	noteOutboxRepo, err := mongo.NewNoteOutboxRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create note outbox repository", "error", err)
+		panic(err)
+	}
+	notesSvc.SetOutbox(noteOutboxRepo)
+	if !noteOutboxRepo.SupportsTransactions() {
+		logger.L().Info("using fallback note outbox for standalone MongoDB")
+	}
+
+	// Plan limits; usage counters follow each note and attachment write
+	noteUsageRepo, err := mongo.NewNoteUsageRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create note usage repository", "error", err)
+		panic(err)
+	}
+	notesSvc.SetQuotas(noteUsageRepo, notesServices.Limits{
+		MaxNotes:        cfg.MaxNotesPerUser,
+		MaxTitleChars:   cfg.MaxTitleChars,
+		MaxBodyBytes:    cfg.MaxBodyBytes,
+		MaxStorageBytes: cfg.MaxStorageBytes,
+	})
+
+	// Related notes; vectors are computed in the background after each write
+	noteVectorsRepo, err := mongo.NewNoteVectorsRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create note vectors repository", "error", err)
+		panic(err)
+	}
+	notesSvc.SetEmbedder(embedding.NewHashed(embedding.DefaultDims), noteVectorsRepo)
+	g.Go(func() error { return notesSvc.RunEmbedder(ctx) })
+
+	// Wiki links; an empty link index is built from the notes in the
+	// background, and until then older notes have no backlinks
+	noteLinksRepo, err := mongo.NewNoteLinksRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create note links repository", "error", err)
+		panic(err)
+	}
+	notesSvc.SetLinkIndex(noteLinksRepo)
+	if empty, err := noteLinksRepo.Empty(ctx); err != nil {
+		logger.L().Error("failed to check the link index", "error", err)
+	} else if empty {
+		g.Go(func() error {
+			n, err := notesSvc.ReindexLinks(ctx)
+			if err != nil {
+				logger.L().Error("failed to build link index", "error", err)
+				return nil
+			}
+			logger.L().Info("built link index", "notes", n)
+			return nil
+		})
+	}
+
+	// Attachments; blobs of deleted notes are collected in the background
+	attachmentsRepo, err := mongo.NewAttachmentsRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create attachments repository", "error", err)
+		panic(err)
+	}
+	blobs, err := blobstore.Init(cfg)
+	if err != nil {
+		logger.L().Error("failed to open blob store", "error", err, "backend", cfg.BlobBackend)
+		panic(err)
+	}
+	notesSvc.SetAttachments(attachmentsRepo, blobs, cfg.AttachmentMaxBytes)
+	g.Go(func() error { return notesSvc.RunAttachmentGC(ctx) })
+
+	// Reminders fire from whichever replica holds the lease
+	notesSvc.SetLease(leasesRepo)
+	g.Go(func() error { return notesSvc.RunReminders(ctx) })
+
+	// Shared workspaces; notes without a workspace_id stay in the personal one
+	workspacesRepo, err := mongo.NewWorkspacesRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create workspaces repository", "error", err)
+		panic(err)
+	}
+	workspaceMembersRepo, err := mongo.NewWorkspaceMembersRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create workspace members repository", "error", err)
+		panic(err)
+	}
+	workspaceInvitationsRepo, err := mongo.NewWorkspaceInvitationsRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create workspace invitations repository", "error", err)
+		panic(err)
+	}
+	workspacesSvc := workspacesServices.NewService(workspacesRepo, workspaceMembersRepo, workspaceInvitationsRepo, usersRepo, notesRepo, logger.L())
+	if mailer != nil {
+		workspacesSvc.SetMailer(mailer)
+	}
+	notesSvc.SetWorkspaceAccess(workspacesSvc)
+	hub.SetWorkspaceMembers(workspacesSvc)
+	authSvc.AddPurger(workspacesSvc)
+	g.Go(func() error { return authSvc.RunAccountPurger(ctx) })
 	notesH := notesHandlers.NewHandlers(notesSvc, v)
 
-	notesGrp := v1.Group("/notes", jwtMiddleware)
+	notesGrp := v1.Group("/notes", jwtMiddleware, idempotencyMiddleware)
 	notesGrp.Post("/", notesH.Create)
 	notesGrp.Get("/", notesH.List)
+	notesGrp.Post("/arrange", notesH.Arrange)
+	notesGrp.Get("/graph", notesH.Graph)
 	notesGrp.Patch("/:id", notesH.Update)
 	notesGrp.Delete("/:id", notesH.Delete)
+	notesGrp.Get("/:id/related", notesH.Related)
+	notesGrp.Get("/:id/backlinks", notesH.Backlinks)
+	notesGrp.Patch("/:id/position", notesH.Move)
+	notesGrp.Post("/:id/items", notesH.AddItem)
+	notesGrp.Put("/:id/items/order", notesH.ReorderItems)
+	notesGrp.Patch("/:id/items/:itemId", notesH.UpdateItem)
+	notesGrp.Delete("/:id/items/:itemId", notesH.DeleteItem)
+	notesGrp.Post("/:id/attachments", notesH.UploadAttachment)
+	notesGrp.Get("/:id/attachments", notesH.ListAttachments)
+	notesGrp.Get("/:id/attachments/:attachmentId", notesH.DownloadAttachment)
+	notesGrp.Get("/:id/attachments/:attachmentId/thumbnail", notesH.AttachmentThumbnail)
+	notesGrp.Delete("/:id/attachments/:attachmentId", notesH.DeleteAttachment)
+	v1.Get("/me/usage", jwtMiddleware, notesH.Usage)
+
+	workspacesH := workspacesHandlers.NewHandlers(workspacesSvc, v)
+	workspacesGrp := v1.Group("/workspaces", jwtMiddleware)
+	workspacesGrp.Get("/", workspacesH.List)
+	workspacesGrp.Post("/", workspacesH.Create)
+	workspacesGrp.Post("/invitations/accept", workspacesH.AcceptInvitation)
+	workspacesGrp.Get("/:id", workspacesH.Get)
+	workspacesGrp.Patch("/:id", workspacesH.Rename)
+	workspacesGrp.Delete("/:id", workspacesH.Delete)
+	workspacesGrp.Get("/:id/members", workspacesH.ListMembers)
+	workspacesGrp.Patch("/:id/members/:userId", workspacesH.SetMemberRole)
+	workspacesGrp.Delete("/:id/members/:userId", workspacesH.RemoveMember)
+	workspacesGrp.Get("/:id/invitations", workspacesH.ListInvitations)
+	workspacesGrp.Post("/:id/invitations", workspacesH.Invite)
+	workspacesGrp.Delete("/:id/invitations/:invitationId", workspacesH.RevokeInvitation)
+
+	// Saved views; their counts are pushed over the WebSocket as notes change
+	viewsRepo, err := mongo.NewViewsRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create views repository", "error", err)
+		panic(err)
+	}
+	viewsSvc := viewsServices.NewService(viewsRepo, notesSvc, logger.L())
+	viewsSvc.SetPusher(hub)
+	hub.AddListener(viewsSvc)
+	authSvc.AddPurger(viewsSvc)
+	g.Go(func() error { return viewsSvc.Run(ctx) })
+	viewsH := viewsHandlers.NewHandlers(viewsSvc, v)
+
+	viewsGrp := v1.Group("/views", jwtMiddleware)
+	viewsGrp.Get("/", viewsH.List)
+	viewsGrp.Post("/", viewsH.Create)
+	viewsGrp.Get("/:id", viewsH.Get)
+	viewsGrp.Patch("/:id", viewsH.Update)
+	viewsGrp.Delete("/:id", viewsH.Delete)
+	viewsGrp.Get("/:id/notes", viewsH.Notes)
+
+	// Note templates; scheduled ones make notes from whichever replica holds
+	// the lease
+	templatesRepo, err := mongo.NewTemplatesRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create templates repository", "error", err)
+		panic(err)
+	}
+	templatesSvc := templatesServices.NewService(templatesRepo, notesSvc, logger.L())
+	templatesSvc.SetLease(leasesRepo)
+	authSvc.AddPurger(templatesSvc)
+	g.Go(func() error { return templatesSvc.RunRecurrences(ctx) })
+	templatesH := templatesHandlers.NewHandlers(templatesSvc, v)
+
+	templatesGrp := v1.Group("/templates", jwtMiddleware)
+	templatesGrp.Get("/", templatesH.List)
+	templatesGrp.Post("/", templatesH.Create)
+	templatesGrp.Get("/:id", templatesH.Get)
+	templatesGrp.Patch("/:id", templatesH.Update)
+	templatesGrp.Delete("/:id", templatesH.Delete)
+	notesGrp.Post("/from-template/:id", templatesH.Instantiate)
+
+	// Notebooks file personal notes in a tree
+	notebooksRepo, err := mongo.NewNotebooksRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create notebooks repository", "error", err)
+		panic(err)
+	}
+	notebooksSvc := notebooksServices.NewService(notebooksRepo, notesSvc, logger.L())
+	notesSvc.SetNotebooks(notebooksSvc)
+	authSvc.AddPurger(notebooksSvc)
+	notebooksH := notebooksHandlers.NewHandlers(notebooksSvc, v)
+
+	notebooksGrp := v1.Group("/notebooks", jwtMiddleware)
+	notebooksGrp.Get("/", notebooksH.List)
+	notebooksGrp.Post("/", notebooksH.Create)
+	notebooksGrp.Get("/:id", notebooksH.Get)
+	notebooksGrp.Patch("/:id", notebooksH.Update)
+	notebooksGrp.Delete("/:id", notebooksH.Delete)
+	notesGrp.Post("/:id/move", notesH.MoveToNotebook)
+	notesGrp.Post("/:id/copy", notesH.CopyToNotebook)
+
+	// Webhooks hear every broadcast note event; deliveries are stored and
+	// sent by whichever replica claims them first
+	webhooksRepo, err := mongo.NewWebhooksRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create webhooks repository", "error", err)
+		panic(err)
+	}
+	webhookDeliveriesRepo, err := mongo.NewWebhookDeliveriesRepo(ctx, mongo.DB())
+	if err != nil {
+		logger.L().Error("failed to create webhook deliveries repository", "error", err)
+		panic(err)
+	}
+	webhookClient := webhooksServices.NewClient(time.Duration(cfg.WebhookTimeoutSec)*time.Second, cfg.WebhookAllowPrivate)
+	webhooksSvc := webhooksServices.NewService(webhooksRepo, webhookDeliveriesRepo, webhookClient, logger.L())
+	webhooksSvc.SetWorkspaceAccess(workspacesSvc)
+	hub.AddListener(webhooksSvc)
+	notesSvc.AddEventRecorder(webhooksSvc)
+	authSvc.AddPurger(webhooksSvc)
+	g.Go(func() error { return webhooksSvc.Run(ctx) })
+	// The relay starts once every recorder is registered, so that the
+	// events it hands over reach them all
+	g.Go(func() error { return notesSvc.RunOutboxRelay(ctx) })
+	webhooksH := webhooksHandlers.NewHandlers(webhooksSvc, v)
+
+	webhooksGrp := v1.Group("/webhooks", jwtMiddleware)
+	webhooksGrp.Get("/", webhooksH.List)
+	webhooksGrp.Post("/", webhooksH.Create)
+	webhooksGrp.Get("/:id", webhooksH.Get)
+	webhooksGrp.Patch("/:id", webhooksH.Update)
+	webhooksGrp.Delete("/:id", webhooksH.Delete)
+	webhooksGrp.Get("/:id/deliveries", webhooksH.Deliveries)
+	webhooksGrp.Post("/:id/deliveries/:deliveryId/redeliver", webhooksH.Redeliver)
+
+	// Email-to-note: mail to a user's inbound address becomes a note of theirs
+	if cfg.InboundSMTPEnabled {
+		inboundRepo, err := mongo.NewInboundAddressesRepo(ctx, mongo.DB())
+		if err != nil {
+			logger.L().Error("failed to create inbound addresses repository", "error", err)
+			panic(err)
+		}
+		inboundSvc := inboundServices.NewService(inboundRepo, notesSvc, cfg.InboundMailDomain, cfg.InboundRatePerHour, logger.L())
+		inboundSvc.SetUserStatus(authSvc)
+		authSvc.AddPurger(inboundSvc)
+		smtpServer := inboundServices.NewServer(inboundSvc, cfg.InboundMaxBytes, logger.L())
+		g.Go(func() error { return smtpServer.ListenAndServe(ctx, cfg.InboundSMTPAddr) })
+		inboundH := inboundHandlers.NewHandlers(inboundSvc)
+
+		v1.Get("/me/inbound-address", jwtMiddleware, inboundH.Get)
+		v1.Post("/me/inbound-address", jwtMiddleware, inboundH.Rotate)
+		v1.Delete("/me/inbound-address", jwtMiddleware, inboundH.Delete)
+	}
 
 	// WebSocket routes
 	wsHandlers := notesHandlers.NewWebSocketHandlers(hub, cfg.JWTSecret, cfg.WSMaxSessionSec)
+	wsHandlers.SetUserStatus(authSvc)
 	app.Use("/ws", notesHandlers.LogWSConnections(cfg.JWTSecret))
-	app.Get("/ws/notes/stream", wsHandlers.WSUpgrade, websocket.New(wsHandlers.WSNotesStream))
+	wsConnectLimiter := middlewares.BuildRateLimiter(rateLimits,
+		ratelimitServices.Policy{Name: "ws_connect", Limit: cfg.WSConnectRatePerMin, Window: RateLimitExpiration},
+		byUser, nil)
+	app.Get("/ws/notes/stream", wsHandlers.WSUpgrade, wsConnectLimiter, websocket.New(wsHandlers.WSNotesStream))
 
 	// User profile endpoint (for testing JWT middleware and for future use)
 	v1.Get("/me", jwtMiddleware, handlers.Me)
 
+	// Admin API
+	adminSvc := adminServices.NewService(usersRepo, notesRepo, authSvc, logger.L())
+	adminH := adminHandlers.NewHandlers(adminSvc, v)
+
+	adminGrp := v1.Group("/admin", jwtMiddleware, middlewares.RequireRole(authServices.RoleAdmin))
+	adminGrp.Get("/users", adminH.ListUsers)
+	adminGrp.Get("/users/:id", adminH.GetUser)
+	adminGrp.Post("/users/:id/disable", adminH.DisableUser)
+	adminGrp.Post("/users/:id/enable", adminH.EnableUser)
+	adminGrp.Post("/users/:id/sign-out", adminH.ForceSignOut)
+	adminGrp.Put("/users/:id/role", adminH.SetRole)
+	adminGrp.Get("/audit", auditH.ListAll)
+
+	// Account self-service
+	v1.Post("/me/password", jwtMiddleware, authHandlers.ChangePassword)
+	v1.Post("/me/email", jwtMiddleware, authHandlers.RequestEmailChange)
+	v1.Post("/me/email/confirm", jwtMiddleware, authHandlers.ConfirmEmailChange)
+	v1.Delete("/me", jwtMiddleware, authHandlers.DeleteAccount)
+
 	return app
 }
+
+// setupSearchIndex swaps the Mongo text index of notesSvc for the embedded
+// one when SEARCH_BACKEND asks for it. A new or emptied index is rebuilt in
+// the background; until then searches miss older notes. The index only sees
+// this replica's writes, so the server refuses to start while another
+// replica holds the index lease.
+func setupSearchIndex(ctx context.Context, cfg config.Config, g *errgroup.Group, notesSvc *notesServices.Service, lease searchindex.Lease) {
+	if cfg.SearchBackend != config.SearchBackendEmbedded {
+		return
+	}
+	if err := searchindex.Claim(ctx, lease); err != nil {
+		logger.L().Error("failed to claim search index", "error", err)
+		panic(err)
+	}
+	g.Go(func() error { return searchindex.KeepClaim(ctx, lease, logger.L()) })
+
+	idx, err := searchindex.Init(cfg)
+	if err != nil {
+		logger.L().Error("failed to open search index", "error", err, "path", cfg.SearchIndexPath)
+		panic(err)
+	}
+	if idx == nil {
+		return
+	}
+	notesSvc.SetSearchIndex(idx)
+	logger.L().Info("using embedded search index", "path", cfg.SearchIndexPath, "language", cfg.SearchLanguage)
+
+	if idx.Fresh() {
+		g.Go(func() error {
+			n, err := notesSvc.Reindex(ctx)
+			if err != nil {
+				logger.L().Error("failed to build search index", "error", err)
+				return nil
+			}
+			logger.L().Info("built search index", "notes", n)
+			return nil
+		})
+	}
+	g.Go(func() error {
+		<-ctx.Done()
+		return idx.Close()
+	})
+}
+
+// isAttachmentUpload matches POST /api/v1/notes/{id}/attachments, the one
+// route whose body may exceed Fiber's default limit
+func isAttachmentUpload(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodPost {
+		return false
+	}
+	rest, ok := strings.CutPrefix(c.Path(), "/api/v1/notes/")
+	if !ok {
+		return false
+	}
+	id, tail, _ := strings.Cut(rest, "/")
+	return id != "" && strings.TrimSuffix(tail, "/") == "attachments"
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"note-pulse/internal/config"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,3 +63,31 @@ func TestRequestLoggingConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAttachmentUpload(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if isAttachmentUpload(c) {
+			return c.SendStatus(fiber.StatusAccepted)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		upload bool
+	}{
+		{"POST", "/api/v1/notes/683cdb8aa96ad71e8e075bd9/attachments", true},
+		{"POST", "/api/v1/notes/683cdb8aa96ad71e8e075bd9/attachments/", true},
+		{"GET", "/api/v1/notes/683cdb8aa96ad71e8e075bd9/attachments", false},
+		{"POST", "/api/v1/notes/683cdb8aa96ad71e8e075bd9/items", false},
+		{"POST", "/api/v1/notes//attachments", false},
+		{"POST", "/api/v1/auth/sign-in", false},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, tt.upload, resp.StatusCode == fiber.StatusAccepted, "%s %s", tt.method, tt.path)
+	}
+}
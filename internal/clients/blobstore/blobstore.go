@@ -0,0 +1,38 @@
+// Package blobstore implements notes.BlobStore on the local filesystem or an
+// S3-compatible bucket.
+package blobstore
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"note-pulse/internal/config"
+	"note-pulse/internal/services/notes"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or escape the
+// store with ".."
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Init opens the blob store BLOB_BACKEND selects
+func Init(cfg config.Config) (notes.BlobStore, error) {
+	if cfg.BlobBackend == config.BlobBackendS3 {
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	}
+	return NewLocal(cfg.BlobPath)
+}
+
+// checkKey rejects keys that are not clean relative slash paths
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+	return nil
+}
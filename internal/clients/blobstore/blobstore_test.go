@@ -0,0 +1,63 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exerciseStore checks the notes.BlobStore contract
+func exerciseStore(t *testing.T, store notes.BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	content := "hello attachment"
+	require.NoError(t, store.Put(ctx, "attachments/a1", strings.NewReader(content), int64(len(content)), "text/plain"))
+
+	rc, err := store.Get(ctx, "attachments/a1")
+	require.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, content, string(got))
+
+	_, err = store.Get(ctx, "attachments/missing")
+	assert.ErrorIs(t, err, notes.ErrBlobNotFound)
+
+	require.NoError(t, store.Delete(ctx, "attachments/a1", "attachments/missing"))
+	_, err = store.Get(ctx, "attachments/a1")
+	assert.ErrorIs(t, err, notes.ErrBlobNotFound)
+
+	assert.ErrorIs(t, store.Put(ctx, "../escape", strings.NewReader("x"), 1, ""), ErrInvalidKey)
+}
+
+func TestLocal(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	exerciseStore(t, store)
+}
+
+func TestLocal_ShortWrite(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "attachments/a1", strings.NewReader("abc"), 10, "")
+	require.Error(t, err)
+	_, err = store.Get(context.Background(), "attachments/a1")
+	assert.ErrorIs(t, err, notes.ErrBlobNotFound)
+}
+
+func TestCheckKey(t *testing.T) {
+	for _, key := range []string{"attachments/a1", "a", "attachments/a1.thumb"} {
+		assert.NoError(t, checkKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "..", "../x", "a/../../x", "a//b", `a\b`, "a/"} {
+		assert.ErrorIs(t, checkKey(key), ErrInvalidKey, key)
+	}
+}
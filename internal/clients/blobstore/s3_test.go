@@ -0,0 +1,100 @@
+package blobstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// s3Stub serves the path-style object calls of an S3-compatible service from
+// memory, the way a MinIO server would for one bucket
+type s3Stub struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if !ok {
+		s.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = body
+		s.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"stub"`)
+	case http.MethodGet, http.MethodHead:
+		body, ok := s.objects[key]
+		if !ok {
+			s.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", s.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("ETag", `"stub"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3Stub) fail(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
+
+func TestS3(t *testing.T) {
+	stub := &s3Stub{bucket: "notes", objects: map[string][]byte{}, types: map[string]string{}}
+	// TLS keeps the client from streaming signed chunks, which the stub
+	// would have to decode
+	srv := httptest.NewTLSServer(stub)
+	defer srv.Close()
+
+	store, err := NewS3(S3Options{
+		Endpoint:  srv.URL,
+		Bucket:    "notes",
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Transport: srv.Client().Transport,
+	})
+	require.NoError(t, err)
+
+	exerciseStore(t, store)
+	assert.Empty(t, stub.objects)
+}
+
+func TestNewS3_InvalidEndpoint(t *testing.T) {
+	_, err := NewS3(S3Options{Endpoint: "not a url", Bucket: "notes"})
+	assert.Error(t, err)
+}
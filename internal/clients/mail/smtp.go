@@ -0,0 +1,147 @@
+// Package mail sends the server's outgoing email, such as email change
+// confirmations and workspace invitations, through an SMTP relay.
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-pulse/internal/config"
+)
+
+// sendTimeout bounds one delivery to the relay, from dial to QUIT
+const sendTimeout = 30 * time.Second
+
+// ErrInvalidRecipient is returned for a recipient that is not one plain
+// email address
+var ErrInvalidRecipient = errors.New("invalid recipient address")
+
+// SMTP delivers mail through an SMTP relay. It upgrades to TLS when the relay
+// offers STARTTLS and authenticates when a username is configured.
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string // From header, e.g. "NotePulse <no-reply@example.com>"
+	fromAddr string // envelope sender
+}
+
+// NewSMTP returns a mailer for the SMTP_* settings, or nil when SMTP_HOST is
+// empty. Config validation guarantees SMTP_FROM parses.
+func NewSMTP(cfg config.Config) *SMTP {
+	if cfg.SMTPHost == "" {
+		return nil
+	}
+	from, _ := mail.ParseAddress(cfg.SMTPFrom)
+	return &SMTP{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     from.String(),
+		fromAddr: from.Address,
+	}
+}
+
+// SendEmailChangeToken sends the token confirming a new email address
+func (s *SMTP) SendEmailChangeToken(ctx context.Context, to, token string) error {
+	body := "Someone asked to use this address for their NotePulse account.\r\n\r\n" +
+		"Confirm the change with this token:\r\n\r\n" +
+		token + "\r\n\r\n" +
+		"It expires in 24 hours. If you did not ask for this, ignore this email.\r\n"
+	return s.Send(ctx, to, "Confirm your new email address", body)
+}
+
+// SendWorkspaceInvitation sends the token accepting an invitation to
+// workspaceName
+func (s *SMTP) SendWorkspaceInvitation(ctx context.Context, to, workspaceName, token string) error {
+	body := fmt.Sprintf("You were invited to the NotePulse workspace %q.\r\n\r\n", workspaceName) +
+		"Accept the invitation with this token:\r\n\r\n" +
+		token + "\r\n\r\n" +
+		"It expires in 7 days.\r\n"
+	return s.Send(ctx, to, "Invitation to "+workspaceName, body)
+}
+
+// Send delivers a plain-text message to one recipient
+func (s *SMTP) Send(ctx context.Context, to, subject, body string) error {
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil || rcpt.Name != "" {
+		return ErrInvalidRecipient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to dial SMTP relay: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to greet SMTP relay: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate to SMTP relay: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.fromAddr); err != nil {
+		return fmt.Errorf("SMTP relay refused sender: %w", err)
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return fmt.Errorf("SMTP relay refused recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP relay refused data: %w", err)
+	}
+	if _, err := w.Write(s.message(rcpt.Address, subject, body)); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP relay rejected message: %w", err)
+	}
+	return c.Quit()
+}
+
+// message renders the headers and body of a message
+func (s *SMTP) message(to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
@@ -0,0 +1,120 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"note-pulse/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relayed is one message a fakeRelay accepted
+type relayed struct {
+	from string
+	to   string
+	data string
+}
+
+// fakeRelay is a minimal SMTP relay without TLS or authentication. It
+// refuses recipients at reject.example.com.
+func fakeRelay(t *testing.T) (config.Config, <-chan relayed) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	got := make(chan relayed, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			serveRelay(conn, got)
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return config.Config{SMTPHost: host, SMTPPort: p, SMTPFrom: "NotePulse <no-reply@example.com>"}, got
+}
+
+func serveRelay(conn net.Conn, got chan<- relayed) {
+	defer func() { _ = conn.Close() }()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake relay")
+
+	var msg relayed
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 fake relay")
+		case "MAIL":
+			msg.from = arg
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			if strings.Contains(arg, "@reject.example.com") {
+				_ = tp.PrintfLine("550 no such user")
+				continue
+			}
+			msg.to = arg
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+			_ = tp.PrintfLine("250 queued")
+			got <- msg
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTPSendsInvitation(t *testing.T) {
+	cfg, got := fakeRelay(t)
+	m := NewSMTP(cfg)
+	require.NotNil(t, m)
+
+	require.NoError(t, m.SendWorkspaceInvitation(context.Background(), "ann@example.com", "Team\r\nBcc: x@example.com", "tok-123"))
+
+	msg := <-got
+	assert.Equal(t, "FROM:<no-reply@example.com>", msg.from)
+	assert.Equal(t, "TO:<ann@example.com>", msg.to)
+	assert.Contains(t, msg.data, "From: \"NotePulse\" <no-reply@example.com>\n")
+	assert.Contains(t, msg.data, "To: ann@example.com\n")
+	assert.Contains(t, msg.data, "tok-123")
+	assert.NotContains(t, msg.data, "\nBcc:", "a workspace name cannot inject headers")
+}
+
+func TestSMTPFailsLoudly(t *testing.T) {
+	cfg, _ := fakeRelay(t)
+	m := NewSMTP(cfg)
+
+	err := m.SendEmailChangeToken(context.Background(), "bob@reject.example.com", "tok")
+	var perr *textproto.Error
+	require.ErrorAs(t, err, &perr, "a refused recipient is an error")
+	assert.Equal(t, 550, perr.Code)
+
+	assert.ErrorIs(t, m.SendEmailChangeToken(context.Background(), "Bob <bob@example.com>, eve@example.com", "tok"), ErrInvalidRecipient)
+
+	assert.Nil(t, NewSMTP(config.Config{}), "no SMTP_HOST means no mailer")
+}
@@ -0,0 +1,136 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AttachmentsRepo implements notes.AttachmentStore for MongoDB
+type AttachmentsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewAttachmentsRepo creates a new attachments repository
+func NewAttachmentsRepo(parentCtx context.Context, db *mongo.Database) (*AttachmentsRepo, error) {
+	collection := db.Collection("attachments")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "note_id", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create attachments indexes: %w", err)
+	}
+
+	return &AttachmentsRepo{collection: collection}, nil
+}
+
+// Create inserts an attachment
+func (r *AttachmentsRepo) Create(ctx context.Context, a *notes.Attachment) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, a); err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	return nil
+}
+
+// Find finds an attachment of a note
+func (r *AttachmentsRepo) Find(ctx context.Context, noteID, attachmentID bson.ObjectID) (*notes.Attachment, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var a notes.Attachment
+	if err := r.collection.FindOne(ctx, bson.M{"_id": attachmentID, "note_id": noteID}).Decode(&a); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, notes.ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to find attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// ListForNote returns the attachments of a note, oldest first
+func (r *AttachmentsRepo) ListForNote(ctx context.Context, noteID bson.ObjectID) ([]*notes.Attachment, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"note_id": noteID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attachments: %w", err)
+	}
+
+	result := []*notes.Attachment{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+	return result, nil
+}
+
+// CountForNote returns how many attachments a note has
+func (r *AttachmentsRepo) CountForNote(ctx context.Context, noteID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"note_id": noteID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	return n, nil
+}
+
+// Delete deletes an attachment
+func (r *AttachmentsRepo) Delete(ctx context.Context, attachmentID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": attachmentID})
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return notes.ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// Orphans returns up to limit attachments whose note is gone
+func (r *AttachmentsRepo) Orphans(ctx context.Context, limit int) ([]*notes.Attachment, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "notes"},
+			{Key: "localField", Value: "note_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "pipeline", Value: bson.A{bson.M{"$project": bson.M{"_id": 1}}}},
+			{Key: "as", Value: "note"},
+		}}},
+		{{Key: "$match", Value: bson.M{"note": bson.M{"$size": 0}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"note": 0}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned attachments: %w", err)
+	}
+
+	result := []*notes.Attachment{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+	return result, nil
+}
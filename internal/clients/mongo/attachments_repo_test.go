@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAttachmentsRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewAttachmentsRepo(ctx, db)
+	require.NoError(t, err)
+	notesRepo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	note := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Trip", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	require.NoError(t, notesRepo.Create(ctx, note))
+
+	kept := &notes.Attachment{ID: bson.NewObjectID(), NoteID: note.ID, UserID: userID, Name: "map.png", Size: 10, MIME: "image/png", CreatedAt: time.Now().UTC()}
+	orphan := &notes.Attachment{ID: bson.NewObjectID(), NoteID: bson.NewObjectID(), UserID: userID, Name: "old.pdf", Size: 20, MIME: "application/pdf", CreatedAt: time.Now().UTC()}
+	require.NoError(t, repo.Create(ctx, kept))
+	require.NoError(t, repo.Create(ctx, orphan))
+
+	found, err := repo.Find(ctx, note.ID, kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "map.png", found.Name)
+
+	_, err = repo.Find(ctx, note.ID, orphan.ID)
+	assert.ErrorIs(t, err, notes.ErrAttachmentNotFound, "an attachment is only found through its note")
+
+	n, err := repo.CountForNote(ctx, note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	orphans, err := repo.Orphans(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, orphan.ID, orphans[0].ID)
+
+	require.NoError(t, repo.Delete(ctx, orphan.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, orphan.ID), notes.ErrAttachmentNotFound)
+
+	list, err := repo.ListForNote(ctx, note.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, kept.ID, list[0].ID)
+}
@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"note-pulse/internal/services/audit"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AuditLogRepo implements audit.Repository for MongoDB. Entries are only
+// ever inserted, and expire at their expires_at.
+type AuditLogRepo struct {
+	collection *mongo.Collection
+}
+
+// NewAuditLogRepo creates a new audit log repository
+func NewAuditLogRepo(parentCtx context.Context, db *mongo.Database) (*AuditLogRepo, error) {
+	collection := db.Collection("audit_log")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "_id", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create audit log indexes: %w", err)
+	}
+
+	return &AuditLogRepo{collection: collection}, nil
+}
+
+// Insert appends an entry
+func (r *AuditLogRepo) Insert(ctx context.Context, e *audit.Entry) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, e); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit entries matching f, newest first
+func (r *AuditLogRepo) List(ctx context.Context, f audit.Filter, limit int) ([]*audit.Entry, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{}
+	if f.UserID != nil {
+		filter["user_id"] = *f.UserID
+	}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.Before != nil {
+		filter["_id"] = bson.M{"$lt": *f.Before}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+
+	result := []*audit.Entry{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAuditLogRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewAuditLogRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	entry := func(userID bson.ObjectID, action string) *audit.Entry {
+		return &audit.Entry{
+			ID:        bson.NewObjectID(),
+			UserID:    &userID,
+			ActorID:   &userID,
+			Action:    action,
+			IP:        "203.0.113.7",
+			After:     audit.Summary{"title": "Plan"},
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+	signIn := entry(userID, audit.ActionSignIn)
+	created := entry(userID, audit.ActionNoteCreate)
+	other := entry(otherID, audit.ActionSignIn)
+	for _, e := range []*audit.Entry{signIn, created, other} {
+		require.NoError(t, repo.Insert(ctx, e))
+	}
+
+	got, err := repo.List(ctx, audit.Filter{UserID: &userID}, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, created.ID, got[0].ID, "newest first")
+	assert.Equal(t, audit.Summary{"title": "Plan"}, got[0].After)
+	assert.Equal(t, "203.0.113.7", got[0].IP)
+
+	got, err = repo.List(ctx, audit.Filter{UserID: &userID}, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	got, err = repo.List(ctx, audit.Filter{UserID: &userID, Before: &got[0].ID}, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, signIn.ID, got[0].ID)
+
+	got, err = repo.List(ctx, audit.Filter{Action: audit.ActionSignIn}, 10)
+	require.NoError(t, err)
+	assert.Len(t, got, 2, "an admin query spans users")
+
+	got, err = repo.List(ctx, audit.Filter{Action: audit.ActionSignOut}, 10)
+	require.NoError(t, err)
+	assert.NotNil(t, got)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/idempotency"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// IdempotencyKeysRepo implements idempotency.Repository for MongoDB. Keys
+// expire at their expires_at.
+type IdempotencyKeysRepo struct {
+	collection *mongo.Collection
+}
+
+// NewIdempotencyKeysRepo creates a new idempotency keys repository
+func NewIdempotencyKeysRepo(parentCtx context.Context, db *mongo.Database) (*IdempotencyKeysRepo, error) {
+	collection := db.Collection("idempotency_keys")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency key indexes: %w", err)
+	}
+
+	return &IdempotencyKeysRepo{collection: collection}, nil
+}
+
+// Insert stores a new key, or returns idempotency.ErrKeyExists
+func (r *IdempotencyKeysRepo) Insert(ctx context.Context, rec *idempotency.Record) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, rec); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return idempotency.ErrKeyExists
+		}
+		return fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Find returns the key with id, or idempotency.ErrKeyNotFound
+func (r *IdempotencyKeysRepo) Find(ctx context.Context, id string) (*idempotency.Record, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var rec idempotency.Record
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rec); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, idempotency.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return &rec, nil
+}
+
+// Claim locks an unfinished key whose lock ran out until until
+func (r *IdempotencyKeysRepo) Claim(ctx context.Context, id string, now, until time.Time) (bool, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":          id,
+		"response":     bson.M{"$exists": false},
+		"locked_until": bson.M{"$lte": now},
+	}
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"locked_until": until}})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// Complete stores the response of the request holding a key
+func (r *IdempotencyKeysRepo) Complete(ctx context.Context, id string, resp *idempotency.Response) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"response": resp}}); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key
+func (r *IdempotencyKeysRepo) Delete(ctx context.Context, id string) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
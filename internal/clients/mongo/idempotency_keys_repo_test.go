@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/idempotency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeysRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewIdempotencyKeysRepo(ctx, db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	rec := &idempotency.Record{
+		ID:          "user/k1",
+		Fingerprint: "abc",
+		LockedUntil: now.Add(time.Minute),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	require.NoError(t, repo.Insert(ctx, rec))
+	assert.ErrorIs(t, repo.Insert(ctx, rec), idempotency.ErrKeyExists)
+
+	claimed, err := repo.Claim(ctx, rec.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed, "the lock still holds")
+	claimed, err = repo.Claim(ctx, rec.ID, now.Add(2*time.Minute), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	resp := &idempotency.Response{Status: 201, ContentType: "application/json", Body: []byte(`{"ok":true}`)}
+	require.NoError(t, repo.Complete(ctx, rec.ID, resp))
+	got, err := repo.Find(ctx, rec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, resp, got.Response)
+	assert.Equal(t, "abc", got.Fingerprint)
+
+	claimed, err = repo.Claim(ctx, rec.ID, now.Add(time.Hour), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, claimed, "a finished key is never claimed")
+
+	require.NoError(t, repo.Delete(ctx, rec.ID))
+	_, err = repo.Find(ctx, rec.ID)
+	assert.ErrorIs(t, err, idempotency.ErrKeyNotFound)
+}
@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/inbound"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// InboundAddressesRepo implements inbound.Repository for MongoDB
+type InboundAddressesRepo struct {
+	collection *mongo.Collection
+}
+
+// NewInboundAddressesRepo creates a new inbound addresses repository
+func NewInboundAddressesRepo(parentCtx context.Context, db *mongo.Database) (*InboundAddressesRepo, error) {
+	collection := db.Collection("inbound_addresses")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create inbound addresses indexes: %w", err)
+	}
+
+	return &InboundAddressesRepo{collection: collection}, nil
+}
+
+// Get returns the inbound address of a user
+func (r *InboundAddressesRepo) Get(ctx context.Context, userID bson.ObjectID) (*inbound.Address, error) {
+	return r.findOne(ctx, bson.M{"user_id": userID})
+}
+
+// FindByToken returns the inbound address with a token
+func (r *InboundAddressesRepo) FindByToken(ctx context.Context, token string) (*inbound.Address, error) {
+	return r.findOne(ctx, bson.M{"token": token})
+}
+
+func (r *InboundAddressesRepo) findOne(ctx context.Context, filter bson.M) (*inbound.Address, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var a inbound.Address
+	if err := r.collection.FindOne(ctx, filter).Decode(&a); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, inbound.ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("failed to find inbound address: %w", err)
+	}
+	return &a, nil
+}
+
+// Put stores the inbound address of a user, replacing the previous one
+func (r *InboundAddressesRepo) Put(ctx context.Context, a *inbound.Address) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"token": a.Token, "created_at": a.CreatedAt}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var stored inbound.Address
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": a.UserID}, update, opts).Decode(&stored); err != nil {
+		return fmt.Errorf("failed to upsert inbound address: %w", err)
+	}
+	a.ID = stored.ID
+	return nil
+}
+
+// Delete deletes the inbound address of a user
+func (r *InboundAddressesRepo) Delete(ctx context.Context, userID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete inbound address: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return inbound.ErrAddressNotFound
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes the inbound address of a user, if any
+func (r *InboundAddressesRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete inbound address: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/inbound"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestInboundAddressesRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewInboundAddressesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	_, err = repo.Get(ctx, userID)
+	assert.ErrorIs(t, err, inbound.ErrAddressNotFound)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	first := &inbound.Address{UserID: userID, Token: "first", CreatedAt: now}
+	require.NoError(t, repo.Put(ctx, first))
+	second := &inbound.Address{UserID: userID, Token: "second", CreatedAt: now.Add(time.Minute)}
+	require.NoError(t, repo.Put(ctx, second))
+	assert.Equal(t, first.ID, second.ID, "a user has one address")
+
+	got, err := repo.Get(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", got.Token)
+
+	_, err = repo.FindByToken(ctx, "first")
+	assert.ErrorIs(t, err, inbound.ErrAddressNotFound)
+	got, err = repo.FindByToken(ctx, "second")
+	require.NoError(t, err)
+	assert.Equal(t, userID, got.UserID)
+
+	require.NoError(t, repo.Delete(ctx, userID))
+	assert.ErrorIs(t, repo.Delete(ctx, userID), inbound.ErrAddressNotFound)
+	n, err := repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
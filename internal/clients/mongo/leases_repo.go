@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// LeasesRepo implements notes.Lease for MongoDB. A lease is a document named
+// after the job, held by one repo at a time until it expires.
+type LeasesRepo struct {
+	collection *mongo.Collection
+	holder     string
+}
+
+// NewLeasesRepo creates a new leases repository with a holder ID unique to
+// this process
+func NewLeasesRepo(_ context.Context, db *mongo.Database) (*LeasesRepo, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &LeasesRepo{
+		collection: db.Collection("leases"),
+		holder:     fmt.Sprintf("%s/%d/%s", host, os.Getpid(), bson.NewObjectID().Hex()),
+	}, nil
+}
+
+// Acquire takes the named lease for ttl when it is free or expired, or extends
+// it when this repo already holds it, and reports whether it is held
+func (r *LeasesRepo) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"holder": r.holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"holder": r.holder, "expires_at": now.Add(ttl)}}
+
+	// Another holder's live lease fails the filter, and the upsert then
+	// collides with its _id
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	return true, nil
+}
+
+// Release gives the named lease up if this repo holds it, so another replica
+// need not wait for it to expire
+func (r *LeasesRepo) Release(ctx context.Context, name string) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": name, "holder": r.holder}); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
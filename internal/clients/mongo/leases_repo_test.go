@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasesRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	first, err := NewLeasesRepo(ctx, db)
+	require.NoError(t, err)
+	second, err := NewLeasesRepo(ctx, db)
+	require.NoError(t, err)
+
+	held, err := first.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	held, err = second.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, held, "a live lease belongs to its holder")
+
+	held, err = first.Acquire(ctx, "job", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, held, "the holder renews its lease")
+
+	time.Sleep(10 * time.Millisecond)
+	held, err = second.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held, "an expired lease is taken over")
+
+	held, err = second.Acquire(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held, "leases are independent")
+
+	require.NoError(t, first.Release(ctx, "other"))
+	held, err = first.Acquire(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, held, "only the holder releases a lease")
+
+	require.NoError(t, second.Release(ctx, "other"))
+	held, err = first.Acquire(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held, "a released lease is free at once")
+}
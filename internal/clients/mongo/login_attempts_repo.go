@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"note-pulse/internal/services/auth"
+)
+
+// LoginAttemptsRepo tracks failed sign-ins per email in MongoDB
+type LoginAttemptsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewLoginAttemptsRepo creates a new LoginAttemptsRepo instance
+func NewLoginAttemptsRepo(parentCtx context.Context, db *mongo.Database) (*LoginAttemptsRepo, error) {
+	collection := db.Collection("login_attempts")
+
+	indexes := []mongo.IndexModel{
+		// Records disappear once both the failure window and any lockout are over
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, refreshTokenOpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create login_attempts indexes: %w", err)
+	}
+
+	return &LoginAttemptsRepo{
+		collection: collection,
+	}, nil
+}
+
+// Find returns the attempts record for email, or nil when there is none
+func (r *LoginAttemptsRepo) Find(ctx context.Context, email string) (*auth.LoginAttempts, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	var attempts auth.LoginAttempts
+	err := r.collection.FindOne(ctx, bson.M{"_id": email}).Decode(&attempts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find login attempts: %w", err)
+	}
+	return &attempts, nil
+}
+
+// RecordFailure atomically counts a failed attempt. The counter restarts at 1
+// when the previous failure fell outside the window.
+func (r *LoginAttemptsRepo) RecordFailure(ctx context.Context, email string, window time.Duration) (*auth.LoginAttempts, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(window)
+
+	// An update pipeline lets the window check and the increment happen in a
+	// single round trip, so concurrent failures are never lost.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$expires_at", now}},
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+				1,
+			}},
+			"last_failure_at": now,
+			// Never shorten the lifetime of an active lockout
+			"expires_at": bson.M{"$max": bson.A{"$expires_at", expiresAt}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempts auth.LoginAttempts
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": email}, update, opts).Decode(&attempts); err != nil {
+		safeLog().Error("failed to record login failure", "error", err)
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return &attempts, nil
+}
+
+// Lock locks the account until the given time
+func (r *LoginAttemptsRepo) Lock(ctx context.Context, email string, until time.Time) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"locked_until": until},
+		"$max": bson.M{"expires_at": until},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": email}, update, options.UpdateOne().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+	return nil
+}
+
+// Reset clears the failure count and any lockout
+func (r *LoginAttemptsRepo) Reset(ctx context.Context, email string) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": email}); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
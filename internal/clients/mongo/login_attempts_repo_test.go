@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptsRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewLoginAttemptsRepo(ctx, db)
+	require.NoError(t, err)
+
+	const email = "victim@example.com"
+
+	attempts, err := repo.Find(ctx, email)
+	require.NoError(t, err)
+	assert.Nil(t, attempts, "no record before the first failure")
+
+	for i := 1; i <= 3; i++ {
+		attempts, err = repo.RecordFailure(ctx, email, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, attempts.Failures)
+	}
+
+	until := time.Now().UTC().Add(time.Hour)
+	require.NoError(t, repo.Lock(ctx, email, until))
+
+	attempts, err = repo.Find(ctx, email)
+	require.NoError(t, err)
+	require.NotNil(t, attempts)
+	assert.True(t, attempts.Locked(time.Now().UTC()))
+	assert.WithinDuration(t, until, attempts.ExpiresAt, time.Second, "lockout keeps the record alive")
+
+	require.NoError(t, repo.Reset(ctx, email))
+	attempts, err = repo.Find(ctx, email)
+	require.NoError(t, err)
+	assert.Nil(t, attempts)
+}
+
+func TestLoginAttemptsRepoWindowRestarts(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewLoginAttemptsRepo(ctx, db)
+	require.NoError(t, err)
+
+	const email = "someone@example.com"
+
+	_, err = repo.RecordFailure(ctx, email, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	attempts, err := repo.RecordFailure(ctx, email, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures, "failures outside the window are forgotten")
+}
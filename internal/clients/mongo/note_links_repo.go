@@ -0,0 +1,204 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NoteLinksRepo implements notes.LinkIndex for MongoDB. Each note has one
+// document with its title and outgoing links, so backlinks are an index
+// lookup on links.note_id.
+type NoteLinksRepo struct {
+	collection *mongo.Collection
+}
+
+// NewNoteLinksRepo creates a new note links repository
+func NewNoteLinksRepo(parentCtx context.Context, db *mongo.Database) (*NoteLinksRepo, error) {
+	collection := db.Collection("note_links")
+
+	indexes := []mongo.IndexModel{
+		// Resolving links by title and listing the graph of a workspace
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "workspace_id", Value: 1}, {Key: "title", Value: 1}}},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "title", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// Backlinks, and the links broken or healed by deletes and titles
+		{Keys: bson.D{{Key: "links.note_id", Value: 1}}},
+		{Keys: bson.D{{Key: "links.target", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create note links indexes: %w", err)
+	}
+
+	return &NoteLinksRepo{collection: collection}, nil
+}
+
+// linkScope selects the entries of a workspace, or of the personal notes of
+// userID when workspaceID is nil
+func linkScope(userID bson.ObjectID, workspaceID *bson.ObjectID) bson.M {
+	if workspaceID != nil {
+		return bson.M{"workspace_id": *workspaceID}
+	}
+	return bson.M{"user_id": userID, "workspace_id": nil}
+}
+
+// Empty reports whether no note has an entry yet, as before the first
+// ReindexLinks
+func (r *NoteLinksRepo) Empty(ctx context.Context) (bool, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to count note links: %w", err)
+	}
+	return n == 0, nil
+}
+
+// Put stores the entry of a note, replacing any previous one
+func (r *NoteLinksRepo) Put(ctx context.Context, entry *notes.NoteLinks) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.collection.ReplaceOne(ctx, bson.M{"_id": entry.NoteID}, entry, opts); err != nil {
+		return fmt.Errorf("failed to put note links: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the entry of a note; a missing entry is not an error
+func (r *NoteLinksRepo) Delete(ctx context.Context, noteID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": noteID}); err != nil {
+		return fmt.Errorf("failed to delete note links: %w", err)
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes the entries of a user's personal notes
+func (r *NoteLinksRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, linkScope(userID, nil))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete note links: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Find returns the entries of a workspace with one of ids or titles, oldest
+// first and without their links
+func (r *NoteLinksRepo) Find(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, ids []bson.ObjectID, titles []string) ([]*notes.NoteLinks, error) {
+	var or bson.A
+	if len(ids) > 0 {
+		or = append(or, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	if len(titles) > 0 {
+		or = append(or, bson.M{"title": bson.M{"$in": titles}})
+	}
+	if len(or) == 0 {
+		return []*notes.NoteLinks{}, nil
+	}
+
+	filter := linkScope(userID, workspaceID)
+	filter["$or"] = or
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"links": 0})
+	return r.find(ctx, filter, opts)
+}
+
+// Backlinks returns up to limit entries linking to noteID, newest first
+func (r *NoteLinksRepo) Backlinks(ctx context.Context, noteID bson.ObjectID, limit int) ([]*notes.NoteLinks, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	return r.find(ctx, bson.M{"links.note_id": noteID}, opts)
+}
+
+// Scope returns up to limit entries of a workspace, oldest first
+func (r *NoteLinksRepo) Scope(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, limit int) ([]*notes.NoteLinks, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return r.find(ctx, linkScope(userID, workspaceID), opts)
+}
+
+// Heal points the broken links naming title in a workspace at noteID and
+// returns the entries it changed. A note never links to itself.
+func (r *NoteLinksRepo) Heal(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, title string, noteID bson.ObjectID) ([]*notes.NoteLinks, error) {
+	broken := bson.M{"target": title, "note_id": bson.M{"$exists": false}}
+	filter := linkScope(userID, workspaceID)
+	filter["_id"] = bson.M{"$ne": noteID}
+	filter["links"] = bson.M{"$elemMatch": broken}
+
+	update := bson.M{"$set": bson.M{"links.$[l].note_id": noteID}}
+	arrayFilters := bson.A{bson.M{"l.target": title, "l.note_id": bson.M{"$exists": false}}}
+	return r.updateLinks(ctx, filter, update, arrayFilters)
+}
+
+// Break marks the links to noteID broken and returns the entries it changed
+func (r *NoteLinksRepo) Break(ctx context.Context, noteID bson.ObjectID) ([]*notes.NoteLinks, error) {
+	update := bson.M{"$unset": bson.M{"links.$[l].note_id": ""}}
+	arrayFilters := bson.A{bson.M{"l.note_id": noteID}}
+	return r.updateLinks(ctx, bson.M{"links.note_id": noteID}, update, arrayFilters)
+}
+
+// updateLinks applies update to the links arrayFilters select in the
+// entries matching filter, and returns those entries as updated. Entries
+// are picked by ID first so the changed ones can be read back.
+func (r *NoteLinksRepo) updateLinks(ctx context.Context, filter, update bson.M, arrayFilters bson.A) ([]*notes.NoteLinks, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note links: %w", err)
+	}
+	var rows []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode note links: %w", err)
+	}
+	if len(rows) == 0 {
+		return []*notes.NoteLinks{}, nil
+	}
+	ids := make([]bson.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	opts := options.UpdateMany().SetArrayFilters(arrayFilters)
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update, opts); err != nil {
+		return nil, fmt.Errorf("failed to update note links: %w", err)
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (r *NoteLinksRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptionsBuilder) ([]*notes.NoteLinks, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note links: %w", err)
+	}
+	result := []*notes.NoteLinks{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode note links: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNoteLinksRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNoteLinksRepo(ctx, db)
+	require.NoError(t, err)
+
+	empty, err := repo.Empty(ctx)
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	userID := bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	plan := &notes.NoteLinks{NoteID: bson.NewObjectID(), UserID: userID, Title: "Plan"}
+	index := &notes.NoteLinks{
+		NoteID: bson.NewObjectID(),
+		UserID: userID,
+		Title:  "Index",
+		Links:  []notes.Link{{Target: "Plan", NoteID: &plan.NoteID}, {Target: "Budget"}},
+	}
+	shared := &notes.NoteLinks{NoteID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Title: "Plan", Links: []notes.Link{{Target: "Budget"}}}
+	for _, e := range []*notes.NoteLinks{plan, index, shared} {
+		require.NoError(t, repo.Put(ctx, e))
+	}
+
+	empty, err = repo.Empty(ctx)
+	require.NoError(t, err)
+	assert.False(t, empty)
+
+	found, err := repo.Find(ctx, userID, nil, []bson.ObjectID{index.NoteID}, []string{"Plan"})
+	require.NoError(t, err)
+	require.Len(t, found, 2, "the workspace's Plan stays out")
+	assert.Equal(t, plan.NoteID, found[0].NoteID)
+	assert.Empty(t, found[1].Links, "links are not loaded")
+
+	backlinks, err := repo.Backlinks(ctx, plan.NoteID, 10)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 1)
+	assert.Equal(t, index.NoteID, backlinks[0].NoteID)
+
+	// A personal Budget heals only personal links
+	budgetID := bson.NewObjectID()
+	healed, err := repo.Heal(ctx, userID, nil, "Budget", budgetID)
+	require.NoError(t, err)
+	require.Len(t, healed, 1)
+	assert.Equal(t, index.NoteID, healed[0].NoteID)
+	assert.Equal(t, &budgetID, healed[0].Links[1].NoteID)
+	assert.Equal(t, &plan.NoteID, healed[0].Links[0].NoteID, "other links are kept")
+
+	scope, err := repo.Scope(ctx, userID, &workspaceID, 10)
+	require.NoError(t, err)
+	require.Len(t, scope, 1)
+	assert.Nil(t, scope[0].Links[0].NoteID)
+
+	broken, err := repo.Break(ctx, plan.NoteID)
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Nil(t, broken[0].Links[0].NoteID)
+	assert.Equal(t, &budgetID, broken[0].Links[1].NoteID)
+
+	broken, err = repo.Break(ctx, plan.NoteID)
+	require.NoError(t, err)
+	assert.Empty(t, broken)
+
+	require.NoError(t, repo.Delete(ctx, plan.NoteID))
+	n, err := repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "workspace entries are kept")
+}
@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NoteOutboxRepo implements notes.Outbox for MongoDB. On a replica set the
+// notes service stores events in the transaction of its note writes.
+type NoteOutboxRepo struct {
+	collection *mongo.Collection
+}
+
+// NewNoteOutboxRepo creates a new note outbox repository
+func NewNoteOutboxRepo(parentCtx context.Context, db *mongo.Database) (*NoteOutboxRepo, error) {
+	collection := db.Collection("note_outbox")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "next_at", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create note outbox indexes: %w", err)
+	}
+
+	return &NoteOutboxRepo{collection: collection}, nil
+}
+
+// Add inserts events
+func (r *NoteOutboxRepo) Add(ctx context.Context, events ...*notes.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	docs := make([]any, len(events))
+	for i, ev := range events {
+		docs[i] = ev
+	}
+	if _, err := r.collection.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert outbox events: %w", err)
+	}
+	return nil
+}
+
+// Claim atomically takes the event due first at now and sets its next_at to
+// until, so that other relays skip it meanwhile
+func (r *NoteOutboxRepo) Claim(ctx context.Context, now, until time.Time) (*notes.OutboxEvent, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{"next_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_at": until}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var ev notes.OutboxEvent
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ev); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim outbox event: %w", err)
+	}
+	return &ev, nil
+}
+
+// Done deletes a published event; an event already gone is no error
+func (r *NoteOutboxRepo) Done(ctx context.Context, id bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete outbox event: %w", err)
+	}
+	return nil
+}
+
+// SupportsTransactions returns whether the MongoDB instance supports transactions
+func (r *NoteOutboxRepo) SupportsTransactions() bool {
+	return IsReplicaSet()
+}
+
+// WithTransaction runs fn in a transaction of a new session. The driver
+// retries fn on transient errors, so it must be safe to run again.
+func (r *NoteOutboxRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	sess, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(sc context.Context) (any, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNoteOutboxRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNoteOutboxRepo(ctx, db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	event := func(nextAt time.Time) *notes.OutboxEvent {
+		note := &notes.Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "Plan"}
+		return &notes.OutboxEvent{
+			ID:        bson.NewObjectID(),
+			Event:     notes.NoteEvent{Type: "updated", Note: note},
+			NextAt:    nextAt,
+			CreatedAt: now,
+		}
+	}
+	due, later := event(now.Add(-time.Second)), event(now.Add(time.Minute))
+	require.NoError(t, repo.Add(ctx, due, later))
+	require.NoError(t, repo.Add(ctx))
+
+	claimed, err := repo.Claim(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, due.ID, claimed.ID)
+	assert.Equal(t, "updated", claimed.Event.Type)
+	assert.Equal(t, due.Event.Note.ID, claimed.Event.Note.ID)
+	assert.Equal(t, "Plan", claimed.Event.Note.Title)
+
+	claimed, err = repo.Claim(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a claimed event is not due again until its claim runs out")
+
+	require.NoError(t, repo.Done(ctx, due.ID))
+	require.NoError(t, repo.Done(ctx, due.ID), "an event already gone is no error")
+	claimed, err = repo.Claim(ctx, now.Add(2*time.Minute), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, later.ID, claimed.ID)
+
+	if !repo.SupportsTransactions() {
+		return
+	}
+	rolledBack := event(now)
+	err = repo.WithTransaction(ctx, func(tx context.Context) error {
+		require.NoError(t, repo.Add(tx, rolledBack))
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	claimed, err = repo.Claim(ctx, now, now)
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "the event of an aborted transaction is not stored")
+}
@@ -0,0 +1,192 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// NoteUsageRepo implements notes.UsageRepo for MongoDB. Each user's counters
+// are a document in note_usage named after them, counted from the notes and
+// attachments collections the first time the user is seen and kept up to
+// date with $inc from then on.
+type NoteUsageRepo struct {
+	collection  *mongo.Collection
+	notes       *mongo.Collection
+	attachments *mongo.Collection
+}
+
+// NewNoteUsageRepo creates a new note usage repository
+func NewNoteUsageRepo(_ context.Context, db *mongo.Database) (*NoteUsageRepo, error) {
+	return &NoteUsageRepo{
+		collection:  db.Collection("note_usage"),
+		notes:       db.Collection("notes"),
+		attachments: db.Collection("attachments"),
+	}, nil
+}
+
+// Get returns the usage of userID, counting it first if it is not kept yet
+func (r *NoteUsageRepo) Get(ctx context.Context, userID bson.ObjectID) (*notes.Usage, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	usage, err := r.find(ctx, userID)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return usage, err
+	}
+	if err := r.seed(ctx, userID); err != nil {
+		return nil, err
+	}
+	return r.find(ctx, userID)
+}
+
+// Reserve adds delta to the usage of userID. The counters delta grows must
+// stay within their non-zero limits, or nothing changes and
+// notes.ErrQuotaExceeded is returned.
+func (r *NoteUsageRepo) Reserve(ctx context.Context, userID bson.ObjectID, delta, limit notes.Usage) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": userID}
+	if delta.NoteCount > 0 && limit.NoteCount > 0 {
+		filter["note_count"] = bson.M{"$lte": limit.NoteCount - delta.NoteCount}
+	}
+	if delta.StorageBytes > 0 && limit.StorageBytes > 0 {
+		filter["storage_bytes"] = bson.M{"$lte": limit.StorageBytes - delta.StorageBytes}
+	}
+	update := incUsage(delta)
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to reserve usage: %w", err)
+	}
+	if res.MatchedCount == 1 {
+		return nil
+	}
+
+	// Either the user is over a limit or their usage is not kept yet
+	if _, err := r.find(ctx, userID); err == nil {
+		return notes.ErrQuotaExceeded
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if err := r.seed(ctx, userID); err != nil {
+		return err
+	}
+	if res, err = r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to reserve usage: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return notes.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Add adds delta to the usage of userID. Usage that is not kept yet is
+// left alone; it is counted in full when first needed.
+func (r *NoteUsageRepo) Add(ctx context.Context, userID bson.ObjectID, delta notes.Usage) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, incUsage(delta)); err != nil {
+		return fmt.Errorf("failed to update usage: %w", err)
+	}
+	return nil
+}
+
+// Delete drops the usage of userID
+func (r *NoteUsageRepo) Delete(ctx context.Context, userID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete usage: %w", err)
+	}
+	return nil
+}
+
+func (r *NoteUsageRepo) find(ctx context.Context, userID bson.ObjectID) (*notes.Usage, error) {
+	var usage notes.Usage
+	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&usage)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// seed counts the usage of userID and stores it, unless another request
+// stored it first
+func (r *NoteUsageRepo) seed(ctx context.Context, userID bson.ObjectID) error {
+	usage, err := r.count(ctx, userID)
+	if err != nil {
+		return err
+	}
+	doc := bson.M{"_id": userID, "note_count": usage.NoteCount, "storage_bytes": usage.StorageBytes}
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to store usage: %w", err)
+	}
+	return nil
+}
+
+// count adds up the notes of userID with the bytes of their titles, bodies
+// and checklist items, and the bytes of the attachments they uploaded
+func (r *NoteUsageRepo) count(ctx context.Context, userID bson.ObjectID) (notes.Usage, error) {
+	textBytes := bson.M{"$add": bson.A{
+		bson.M{"$strLenBytes": bson.M{"$ifNull": bson.A{"$title", ""}}},
+		bson.M{"$strLenBytes": bson.M{"$ifNull": bson.A{"$body", ""}}},
+		bson.M{"$reduce": bson.M{
+			"input":        bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+			"initialValue": 0,
+			"in":           bson.M{"$add": bson.A{"$$value", bson.M{"$strLenBytes": "$$this.text"}}},
+		}},
+	}}
+	notesPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "bytes": bson.M{"$sum": textBytes}}}},
+	}
+	attachmentsPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "bytes": bson.M{"$sum": "$size"}}}},
+	}
+
+	noteCount, noteBytes, err := sumUsage(ctx, r.notes, notesPipeline)
+	if err != nil {
+		return notes.Usage{}, err
+	}
+	_, attachmentBytes, err := sumUsage(ctx, r.attachments, attachmentsPipeline)
+	if err != nil {
+		return notes.Usage{}, err
+	}
+	return notes.Usage{NoteCount: noteCount, StorageBytes: noteBytes + attachmentBytes}, nil
+}
+
+// sumUsage runs a pipeline grouping documents into one count and bytes row
+func sumUsage(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline) (int64, int64, error) {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count usage: %w", err)
+	}
+	var rows []struct {
+		Count int64 `bson:"count"`
+		Bytes int64 `bson:"bytes"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode usage: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, 0, nil
+	}
+	return rows[0].Count, rows[0].Bytes, nil
+}
+
+func incUsage(delta notes.Usage) bson.M {
+	return bson.M{"$inc": bson.M{"note_count": delta.NoteCount, "storage_bytes": delta.StorageBytes}}
+}
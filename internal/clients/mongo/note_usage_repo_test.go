@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNoteUsageRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNoteUsageRepo(ctx, db)
+	require.NoError(t, err)
+
+	// Usage that is not kept yet is counted from the stored notes
+	userID := bson.NewObjectID()
+	_, err = db.Collection("notes").InsertMany(ctx, []any{
+		bson.M{"_id": bson.NewObjectID(), "user_id": userID, "title": "Plan", "body": "héllo"},
+		bson.M{"_id": bson.NewObjectID(), "user_id": userID, "title": "List", "items": bson.A{bson.M{"text": "milk"}}},
+		bson.M{"_id": bson.NewObjectID(), "user_id": bson.NewObjectID(), "title": "Other"},
+	})
+	require.NoError(t, err)
+	_, err = db.Collection("attachments").InsertOne(ctx, bson.M{"_id": bson.NewObjectID(), "user_id": userID, "size": 100})
+	require.NoError(t, err)
+
+	usage, err := repo.Get(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, &notes.Usage{NoteCount: 2, StorageBytes: 4 + 6 + 4 + 4 + 100}, usage)
+
+	limit := notes.Usage{NoteCount: 3, StorageBytes: 200}
+	require.NoError(t, repo.Reserve(ctx, userID, notes.Usage{NoteCount: 1, StorageBytes: 10}, limit))
+	assert.ErrorIs(t, repo.Reserve(ctx, userID, notes.Usage{NoteCount: 1}, limit), notes.ErrQuotaExceeded)
+	assert.ErrorIs(t, repo.Reserve(ctx, userID, notes.Usage{StorageBytes: 100}, limit), notes.ErrQuotaExceeded)
+	require.NoError(t, repo.Reserve(ctx, userID, notes.Usage{StorageBytes: -50}, limit), "shrinking is never refused")
+
+	require.NoError(t, repo.Add(ctx, userID, notes.Usage{NoteCount: -1, StorageBytes: -10}))
+	usage, err = repo.Get(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, &notes.Usage{NoteCount: 2, StorageBytes: 68}, usage)
+
+	// A first reservation counts the user before checking it
+	fresh := bson.NewObjectID()
+	require.NoError(t, repo.Reserve(ctx, fresh, notes.Usage{NoteCount: 1, StorageBytes: 5}, limit))
+	require.NoError(t, repo.Add(ctx, bson.NewObjectID(), notes.Usage{NoteCount: -1}), "unknown users are left alone")
+
+	require.NoError(t, repo.Delete(ctx, fresh))
+	n, err := db.Collection("note_usage").CountDocuments(ctx, bson.M{"_id": fresh})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
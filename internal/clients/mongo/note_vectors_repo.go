@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NoteVectorsRepo implements notes.VectorStore for MongoDB. Vectors live
+// apart from the notes so that listing notes never loads them.
+type NoteVectorsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewNoteVectorsRepo creates a new note vectors repository
+func NewNoteVectorsRepo(parentCtx context.Context, db *mongo.Database) (*NoteVectorsRepo, error) {
+	collection := db.Collection("note_vectors")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "workspace_id", Value: 1}}},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create note vectors indexes: %w", err)
+	}
+
+	return &NoteVectorsRepo{collection: collection}, nil
+}
+
+// Upsert stores the vector of a note, replacing any previous one
+func (r *NoteVectorsRepo) Upsert(ctx context.Context, v *notes.NoteVector) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.collection.ReplaceOne(ctx, bson.M{"_id": v.NoteID}, v, opts); err != nil {
+		return fmt.Errorf("failed to upsert note vector: %w", err)
+	}
+	return nil
+}
+
+// Find returns the vector of a note
+func (r *NoteVectorsRepo) Find(ctx context.Context, noteID bson.ObjectID) (*notes.NoteVector, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var v notes.NoteVector
+	if err := r.collection.FindOne(ctx, bson.M{"_id": noteID}).Decode(&v); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, notes.ErrNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to find note vector: %w", err)
+	}
+	return &v, nil
+}
+
+// Delete deletes the vector of a note; a missing vector is not an error
+func (r *NoteVectorsRepo) Delete(ctx context.Context, noteID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": noteID}); err != nil {
+		return fmt.Errorf("failed to delete note vector: %w", err)
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes the vectors of a user's personal notes
+func (r *NoteVectorsRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "workspace_id": nil})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete note vectors: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Scope returns the vectors of a workspace, or of the personal notes of
+// userID when workspaceID is nil
+func (r *NoteVectorsRepo) Scope(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID) ([]*notes.NoteVector, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "workspace_id": nil}
+	if workspaceID != nil {
+		filter = bson.M{"workspace_id": *workspaceID}
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note vectors: %w", err)
+	}
+
+	var result []*notes.NoteVector
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode note vectors: %w", err)
+	}
+	return result, nil
+}
+
+// Models returns the model of each given note that has a vector
+func (r *NoteVectorsRepo) Models(ctx context.Context, noteIDs []bson.ObjectID) (map[bson.ObjectID]string, error) {
+	models := make(map[bson.ObjectID]string, len(noteIDs))
+	if len(noteIDs) == 0 {
+		return models, nil
+	}
+
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"model": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": noteIDs}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note vector models: %w", err)
+	}
+
+	var rows []struct {
+		NoteID bson.ObjectID `bson:"_id"`
+		Model  string        `bson:"model"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode note vector models: %w", err)
+	}
+	for _, row := range rows {
+		models[row.NoteID] = row.Model
+	}
+	return models, nil
+}
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNoteVectorsRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNoteVectorsRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID, workspaceID := bson.NewObjectID(), bson.NewObjectID()
+	personal := &notes.NoteVector{NoteID: bson.NewObjectID(), UserID: userID, Model: "m1", Vector: []float32{0.6, 0.8}}
+	shared := &notes.NoteVector{NoteID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Model: "m1", Vector: []float32{1, 0}}
+	require.NoError(t, repo.Upsert(ctx, personal))
+	require.NoError(t, repo.Upsert(ctx, shared))
+
+	found, err := repo.Find(ctx, personal.NoteID)
+	require.NoError(t, err)
+	assert.Equal(t, personal, found)
+	_, err = repo.Find(ctx, bson.NewObjectID())
+	assert.ErrorIs(t, err, notes.ErrNoteNotFound)
+
+	personal.Model, personal.Vector = "m2", []float32{0, 1}
+	require.NoError(t, repo.Upsert(ctx, personal))
+
+	scope, err := repo.Scope(ctx, userID, nil)
+	require.NoError(t, err)
+	require.Len(t, scope, 1)
+	assert.Equal(t, []float32{0, 1}, scope[0].Vector)
+	scope, err = repo.Scope(ctx, bson.NewObjectID(), &workspaceID)
+	require.NoError(t, err)
+	require.Len(t, scope, 1)
+	assert.Equal(t, shared.NoteID, scope[0].NoteID)
+
+	models, err := repo.Models(ctx, []bson.ObjectID{personal.NoteID, shared.NoteID, bson.NewObjectID()})
+	require.NoError(t, err)
+	assert.Equal(t, map[bson.ObjectID]string{personal.NoteID: "m2", shared.NoteID: "m1"}, models)
+
+	n, err := repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n, "workspace vectors stay")
+	require.NoError(t, repo.Delete(ctx, shared.NoteID))
+	require.NoError(t, repo.Delete(ctx, shared.NoteID))
+}
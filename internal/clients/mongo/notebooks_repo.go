@@ -0,0 +1,207 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"note-pulse/internal/services/notebooks"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NotebooksRepo implements notebooks.Repository for MongoDB
+type NotebooksRepo struct {
+	collection *mongo.Collection
+}
+
+// NewNotebooksRepo creates a new notebooks repository
+func NewNotebooksRepo(parentCtx context.Context, db *mongo.Database) (*NotebooksRepo, error) {
+	collection := db.Collection("notebooks")
+
+	indexes := []mongo.IndexModel{
+		// Sibling names are unique; a missing parent_id indexes as null, so
+		// top-level notebooks are siblings too
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "parent_id", Value: 1},
+				{Key: "name", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		// Listing in tree order and subtree lookups by path prefix
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "path", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create notebooks indexes: %w", err)
+	}
+
+	return &NotebooksRepo{collection: collection}, nil
+}
+
+// Create inserts a notebook
+func (r *NotebooksRepo) Create(ctx context.Context, nb *notebooks.Notebook) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, nb); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return notebooks.ErrNotebookExists
+		}
+		return fmt.Errorf("failed to insert notebook: %w", err)
+	}
+	return nil
+}
+
+// List returns the notebooks of a user in path order
+func (r *NotebooksRepo) List(ctx context.Context, userID bson.ObjectID) ([]*notebooks.Notebook, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// Count returns how many notebooks a user has
+func (r *NotebooksRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notebooks: %w", err)
+	}
+	return n, nil
+}
+
+// Find finds a notebook of a user
+func (r *NotebooksRepo) Find(ctx context.Context, userID, notebookID bson.ObjectID) (*notebooks.Notebook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var nb notebooks.Notebook
+	if err := r.collection.FindOne(ctx, bson.M{"_id": notebookID, "user_id": userID}).Decode(&nb); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, notebooks.ErrNotebookNotFound
+		}
+		return nil, fmt.Errorf("failed to find notebook: %w", err)
+	}
+	return &nb, nil
+}
+
+// Subtree returns the notebook at path and the notebooks nested in it, in
+// path order
+func (r *NotebooksRepo) Subtree(ctx context.Context, userID bson.ObjectID, path string) ([]*notebooks.Notebook, error) {
+	return r.find(ctx, subtreeFilter(userID, path))
+}
+
+// Rename renames a notebook of a user
+func (r *NotebooksRepo) Rename(ctx context.Context, userID, notebookID bson.ObjectID, name string) (*notebooks.Notebook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"name": name, "updated_at": time.Now().UTC()}}
+	return r.updateOne(ctx, bson.M{"_id": notebookID, "user_id": userID}, update)
+}
+
+// Move puts nb under parentID at path and depth, then rewrites the path
+// prefix and depth of the notebooks nested in it. Moving the notebook
+// itself first lets a name clash fail before anything changed.
+func (r *NotebooksRepo) Move(ctx context.Context, userID bson.ObjectID, nb *notebooks.Notebook, parentID *bson.ObjectID, path string, depth int) (*notebooks.Notebook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	set := bson.M{"path": path, "depth": depth, "updated_at": now}
+	update := bson.M{"$set": set}
+	if parentID == nil {
+		update["$unset"] = bson.M{"parent_id": ""}
+	} else {
+		set["parent_id"] = *parentID
+	}
+	moved, err := r.updateOne(ctx, bson.M{"_id": nb.ID, "user_id": userID, "path": nb.Path}, update)
+	if err != nil {
+		return nil, err
+	}
+
+	oldLen := len(nb.Path)
+	rewrite := bson.A{bson.M{"$set": bson.M{
+		"path": bson.M{"$concat": bson.A{
+			path,
+			bson.M{"$substrBytes": bson.A{"$path", oldLen, bson.M{"$subtract": bson.A{bson.M{"$strLenBytes": "$path"}, oldLen}}}},
+		}},
+		"depth":      bson.M{"$add": bson.A{"$depth", depth - nb.Depth}},
+		"updated_at": now,
+	}}}
+	if _, err := r.collection.UpdateMany(ctx, subtreeFilter(userID, nb.Path), rewrite); err != nil {
+		return nil, fmt.Errorf("failed to move nested notebooks: %w", err)
+	}
+	return moved, nil
+}
+
+// DeleteMany deletes notebooks of a user
+func (r *NotebooksRepo) DeleteMany(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "_id": bson.M{"$in": notebookIDs}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notebooks: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteAllForUser deletes every notebook of a user
+func (r *NotebooksRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notebooks: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *NotebooksRepo) find(ctx context.Context, filter bson.M) ([]*notebooks.Notebook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "path", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notebooks: %w", err)
+	}
+
+	result := []*notebooks.Notebook{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode notebooks: %w", err)
+	}
+	return result, nil
+}
+
+func (r *NotebooksRepo) updateOne(ctx context.Context, filter, update bson.M) (*notebooks.Notebook, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var nb notebooks.Notebook
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&nb); err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, notebooks.ErrNotebookNotFound
+		case mongo.IsDuplicateKeyError(err):
+			return nil, notebooks.ErrNotebookExists
+		}
+		return nil, fmt.Errorf("failed to update notebook: %w", err)
+	}
+	return &nb, nil
+}
+
+// subtreeFilter selects the notebooks whose path starts with path; an
+// anchored prefix regex uses the path index
+func subtreeFilter(userID bson.ObjectID, path string) bson.M {
+	return bson.M{"user_id": userID, "path": bson.M{"$regex": "^" + regexp.QuoteMeta(path)}}
+}
@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notebooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNotebooksRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotebooksRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	create := func(name string, parent *notebooks.Notebook) *notebooks.Notebook {
+		nb := &notebooks.Notebook{ID: bson.NewObjectID(), UserID: userID, Name: name, CreatedAt: now, UpdatedAt: now}
+		nb.Path = "/" + nb.ID.Hex() + "/"
+		if parent != nil {
+			nb.ParentID = &parent.ID
+			nb.Path = parent.Path + nb.ID.Hex() + "/"
+			nb.Depth = parent.Depth + 1
+		}
+		require.NoError(t, repo.Create(ctx, nb))
+		return nb
+	}
+	work := create("Work", nil)
+	projects := create("Projects", work)
+	alpha := create("Alpha", projects)
+	home := create("Home", nil)
+
+	err = repo.Create(ctx, &notebooks.Notebook{ID: bson.NewObjectID(), UserID: userID, Name: "Work", Path: "/x/"})
+	assert.ErrorIs(t, err, notebooks.ErrNotebookExists, "top-level names clash too")
+	err = repo.Create(ctx, &notebooks.Notebook{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Name: "Work", Path: "/y/"})
+	assert.NoError(t, err, "names are per user")
+
+	n, err := repo.Count(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	subtree, err := repo.Subtree(ctx, userID, work.Path)
+	require.NoError(t, err)
+	require.Len(t, subtree, 3)
+	assert.Equal(t, work.ID, subtree[0].ID, "the notebook comes before those nested in it")
+
+	_, err = repo.Rename(ctx, userID, projects.ID, "Alpha")
+	assert.NoError(t, err, "a child may share a name with its own child")
+	_, err = repo.Rename(ctx, userID, home.ID, "Work")
+	assert.ErrorIs(t, err, notebooks.ErrNotebookExists)
+
+	// Moving Projects under Home rewrites the paths below it
+	path := home.Path + projects.ID.Hex() + "/"
+	moved, err := repo.Move(ctx, userID, projects, &home.ID, path, 1)
+	require.NoError(t, err)
+	assert.Equal(t, path, moved.Path)
+	assert.Equal(t, home.ID, *moved.ParentID)
+	child, err := repo.Find(ctx, userID, alpha.ID)
+	require.NoError(t, err)
+	assert.Equal(t, path+alpha.ID.Hex()+"/", child.Path)
+	assert.Equal(t, 2, child.Depth)
+
+	_, err = repo.Move(ctx, userID, projects, nil, "/"+projects.ID.Hex()+"/", 0)
+	assert.ErrorIs(t, err, notebooks.ErrNotebookNotFound, "a stale path does not move")
+
+	top, err := repo.Move(ctx, userID, moved, nil, "/"+projects.ID.Hex()+"/", 0)
+	require.NoError(t, err)
+	assert.Nil(t, top.ParentID)
+	child, err = repo.Find(ctx, userID, alpha.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, child.Depth)
+
+	deleted, err := repo.DeleteMany(ctx, userID, []bson.ObjectID{projects.ID, alpha.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	_, err = repo.Find(ctx, userID, alpha.ID)
+	assert.ErrorIs(t, err, notebooks.ErrNotebookNotFound)
+
+	deleted, err = repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// placementUpdate stores p's board and position; the default board is
+// stored as no board_id at all
+func placementUpdate(p notes.Placement) bson.M {
+	set := bson.M{"position": p.Position}
+	update := bson.M{"$set": set}
+	if p.BoardID == "" {
+		update["$unset"] = bson.M{"board_id": ""}
+	} else {
+		set["board_id"] = p.BoardID
+	}
+	return update
+}
+
+// SetPosition places one note on a board
+func (r *NotesRepo) SetPosition(ctx context.Context, p notes.Placement) (*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var note notes.Note
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": p.NoteID}, placementUpdate(p), opts).Decode(&note)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return &note, nil
+}
+
+// Arrange places many notes of one workspace with a single unordered bulk
+// write and returns the notes of the workspace among them
+func (r *NotesRepo) Arrange(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, placements []notes.Placement) ([]*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	scope := bson.M{"user_id": userID, "workspace_id": nil}
+	if workspaceID != nil {
+		scope = bson.M{"workspace_id": *workspaceID}
+	}
+
+	models := make([]mongo.WriteModel, 0, len(placements))
+	ids := make([]bson.ObjectID, 0, len(placements))
+	for _, p := range placements {
+		filter := bson.M{"_id": p.NoteID}
+		for k, v := range scope {
+			filter[k] = v
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(placementUpdate(p)))
+		ids = append(ids, p.NoteID)
+	}
+	if _, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, fmt.Errorf("failed to arrange notes: %w", err)
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	for k, v := range scope {
+		filter[k] = v
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find arranged notes: %w", err)
+	}
+	placed := []*notes.Note{}
+	if err := cursor.All(ctx, &placed); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %w", err)
+	}
+	return placed, nil
+}
+
+// boardFilter adds the board and viewport conditions of req. A viewport
+// keeps the notes whose rectangle intersects it; without a board_id it
+// covers the default board.
+func boardFilter(filter bson.M, req notes.ListNotesRequest) {
+	if req.BoardID != "" {
+		filter["board_id"] = req.BoardID
+	}
+	if req.Viewport == "" {
+		return
+	}
+	v, err := notes.ParseViewport(req.Viewport)
+	if err != nil {
+		return
+	}
+	if req.BoardID == "" {
+		filter["board_id"] = bson.M{"$exists": false}
+	}
+	filter["position.x"] = bson.M{"$lt": v.X + v.Width}
+	filter["position.right"] = bson.M{"$gt": v.X}
+	filter["position.y"] = bson.M{"$lt": v.Y + v.Height}
+	filter["position.bottom"] = bson.M{"$gt": v.Y}
+}
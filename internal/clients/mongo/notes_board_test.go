@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestBoardFilter(t *testing.T) {
+	filter := bson.M{}
+	boardFilter(filter, notes.ListNotesRequest{})
+	assert.Empty(t, filter)
+
+	filter = bson.M{}
+	boardFilter(filter, notes.ListNotesRequest{BoardID: "planning"})
+	assert.Equal(t, bson.M{"board_id": "planning"}, filter)
+
+	filter = bson.M{}
+	boardFilter(filter, notes.ListNotesRequest{Viewport: "10,20,100,50"})
+	assert.Equal(t, bson.M{
+		"board_id":        bson.M{"$exists": false},
+		"position.x":      bson.M{"$lt": float64(110)},
+		"position.right":  bson.M{"$gt": float64(10)},
+		"position.y":      bson.M{"$lt": float64(70)},
+		"position.bottom": bson.M{"$gt": float64(20)},
+	}, filter, "a viewport without board_id covers the default board")
+}
+
+func TestNotesRepoBoard(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	create := func(title string) *notes.Note {
+		n := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: title, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, repo.Create(ctx, n))
+		return n
+	}
+	place := func(x, y, w, h float64) notes.Position {
+		return notes.Position{X: x, Y: y, Width: w, Height: h, Right: x + w, Bottom: y + h}
+	}
+	inside := create("inside")
+	edge := create("edge")
+	outside := create("outside")
+	other := create("other board")
+	create("unplaced")
+	foreign := &notes.Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "foreign", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, foreign))
+
+	moved, err := repo.SetPosition(ctx, notes.Placement{NoteID: inside.ID, BoardID: "planning", Position: place(10, 10, 100, 100)})
+	require.NoError(t, err)
+	assert.Equal(t, "planning", moved.BoardID)
+	assert.Equal(t, now, moved.UpdatedAt, "placing is not an edit")
+
+	moved, err = repo.SetPosition(ctx, notes.Placement{NoteID: inside.ID, Position: place(10, 10, 100, 100)})
+	require.NoError(t, err)
+	assert.Empty(t, moved.BoardID, "the default board clears board_id")
+
+	_, err = repo.SetPosition(ctx, notes.Placement{NoteID: bson.NewObjectID(), Position: place(0, 0, 1, 1)})
+	assert.ErrorIs(t, err, notes.ErrNoteNotFound)
+
+	placed, err := repo.Arrange(ctx, userID, nil, []notes.Placement{
+		{NoteID: edge.ID, Position: place(-50, -50, 60, 60)},
+		{NoteID: outside.ID, Position: place(500, 500, 10, 10)},
+		{NoteID: other.ID, BoardID: "planning", Position: place(0, 0, 50, 50)},
+		{NoteID: foreign.ID, Position: place(0, 0, 50, 50)},
+	})
+	require.NoError(t, err)
+	assert.Len(t, placed, 3, "another user's note is skipped")
+
+	found, err := repo.FindByID(ctx, foreign.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.Position)
+
+	titles := func(req notes.ListNotesRequest) []string {
+		req.Limit = 10
+		req.Sort = "title"
+		req.Order = "asc"
+		list, _, _, err := repo.List(ctx, userID, req, 0)
+		require.NoError(t, err)
+		out := make([]string, 0, len(list))
+		for _, n := range list {
+			out = append(out, n.Title)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"edge", "inside"}, titles(notes.ListNotesRequest{Viewport: "0,0,200,200"}))
+	assert.Equal(t, []string{"inside"}, titles(notes.ListNotesRequest{Viewport: "10,10,1,1"}))
+	assert.Empty(t, titles(notes.ListNotesRequest{Viewport: "110,0,100,100"}), "touching edges do not intersect")
+	assert.Equal(t, []string{"other board"}, titles(notes.ListNotesRequest{BoardID: "planning", Viewport: "0,0,200,200"}))
+	assert.Equal(t, []string{"other board"}, titles(notes.ListNotesRequest{BoardID: "planning"}))
+}
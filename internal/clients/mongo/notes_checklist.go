@@ -0,0 +1,104 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// updateChecklist applies an item operation guarded by filter. When no note
+// matches, miss is returned if the note exists, so callers tell a missing
+// note from a failed guard.
+func (r *NotesRepo) updateChecklist(ctx context.Context, noteID bson.ObjectID, filter bson.M, update any, miss error) (*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter["_id"] = noteID
+	filter["type"] = notes.TypeChecklist
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var note notes.Note
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&note)
+	if err == nil {
+		return &note, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to update checklist: %w", err)
+	}
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": noteID, "type": notes.TypeChecklist})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find checklist: %w", err)
+	}
+	if n == 0 {
+		return nil, notes.ErrNoteNotFound
+	}
+	return nil, miss
+}
+
+// AddItem inserts an item at position, or appends it when position is negative
+func (r *NotesRepo) AddItem(ctx context.Context, noteID bson.ObjectID, item notes.ChecklistItem, position int) (*notes.Note, error) {
+	push := bson.M{"$each": bson.A{item}}
+	if position >= 0 {
+		push["$position"] = position
+	}
+
+	filter := bson.M{fmt.Sprintf("items.%d", notes.MaxChecklistItems-1): bson.M{"$exists": false}}
+	update := bson.M{
+		"$push": bson.M{"items": push},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	return r.updateChecklist(ctx, noteID, filter, update, notes.ErrChecklistFull)
+}
+
+// UpdateItem sets the text or checked state of one item
+func (r *NotesRepo) UpdateItem(ctx context.Context, noteID, itemID bson.ObjectID, patch notes.UpdateItem) (*notes.Note, error) {
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if patch.Text != nil {
+		set["items.$.text"] = *patch.Text
+	}
+	if patch.Checked != nil {
+		set["items.$.checked"] = *patch.Checked
+	}
+
+	filter := bson.M{"items.id": itemID}
+	return r.updateChecklist(ctx, noteID, filter, bson.M{"$set": set}, notes.ErrItemNotFound)
+}
+
+// DeleteItem removes one item
+func (r *NotesRepo) DeleteItem(ctx context.Context, noteID, itemID bson.ObjectID) (*notes.Note, error) {
+	filter := bson.M{"items.id": itemID}
+	update := bson.M{
+		"$pull": bson.M{"items": bson.M{"id": itemID}},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	return r.updateChecklist(ctx, noteID, filter, update, notes.ErrItemNotFound)
+}
+
+// ReorderItems rearranges the items server-side, so a concurrent edit of an
+// item's text or checked state survives the reorder
+func (r *NotesRepo) ReorderItems(ctx context.Context, noteID bson.ObjectID, itemIDs []bson.ObjectID) (*notes.Note, error) {
+	filter := bson.M{
+		"items":    bson.M{"$size": len(itemIDs)},
+		"items.id": bson.M{"$all": itemIDs},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+		{Key: "items", Value: bson.M{"$map": bson.M{
+			"input": itemIDs,
+			"as":    "id",
+			"in": bson.M{"$arrayElemAt": bson.A{
+				"$items",
+				bson.M{"$indexOfArray": bson.A{"$items.id", "$$id"}},
+			}},
+		}}},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}}}}
+	return r.updateChecklist(ctx, noteID, filter, update, notes.ErrItemOrder)
+}
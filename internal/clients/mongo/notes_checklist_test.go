@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNotesRepoChecklistItems(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	a := notes.ChecklistItem{ID: bson.NewObjectID(), Text: "a"}
+	b := notes.ChecklistItem{ID: bson.NewObjectID(), Text: "b"}
+	note := &notes.Note{
+		ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "List",
+		Type: notes.TypeChecklist, Items: []notes.ChecklistItem{a},
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	require.NoError(t, repo.Create(ctx, note))
+
+	got, err := repo.AddItem(ctx, note.ID, b, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, itemTexts(got))
+
+	checked := true
+	got, err = repo.UpdateItem(ctx, note.ID, a.ID, notes.UpdateItem{Checked: &checked})
+	require.NoError(t, err)
+	assert.True(t, got.Items[1].Checked)
+	_, err = repo.UpdateItem(ctx, note.ID, bson.NewObjectID(), notes.UpdateItem{Checked: &checked})
+	assert.ErrorIs(t, err, notes.ErrItemNotFound)
+
+	// The reorder keeps the check made meanwhile
+	got, err = repo.ReorderItems(ctx, note.ID, []bson.ObjectID{a.ID, b.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, itemTexts(got))
+	assert.True(t, got.Items[0].Checked)
+	_, err = repo.ReorderItems(ctx, note.ID, []bson.ObjectID{a.ID})
+	assert.ErrorIs(t, err, notes.ErrItemOrder)
+
+	got, err = repo.DeleteItem(ctx, note.ID, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, itemTexts(got))
+	_, err = repo.DeleteItem(ctx, bson.NewObjectID(), a.ID)
+	assert.ErrorIs(t, err, notes.ErrNoteNotFound)
+}
+
+func itemTexts(n *notes.Note) []string {
+	texts := make([]string, len(n.Items))
+	for i, it := range n.Items {
+		texts[i] = it.Text
+	}
+	return texts
+}
@@ -0,0 +1,114 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNotesRepoDueDates(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	create := func(title string, due *time.Time) *notes.Note {
+		n := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: title, DueAt: due, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, repo.Create(ctx, n))
+		return n
+	}
+	late := create("late", at(-time.Hour))
+	soon := create("soon", at(time.Hour))
+	next := create("next week", at(7*24*time.Hour))
+	create("someday", nil)
+
+	titles := func(list []*notes.Note) []string {
+		out := make([]string, 0, len(list))
+		for _, n := range list {
+			out = append(out, n.Title)
+		}
+		return out
+	}
+
+	list, total, _, err := repo.List(ctx, userID, notes.ListNotesRequest{Sort: notes.SortDueAt, Order: "asc", Limit: 10}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{late.Title, soon.Title, next.Title}, titles(list), "notes without a due date are left out")
+	assert.Equal(t, int64(3), total)
+
+	cursor := repo.generateCursorFromNote(list[0], notes.SortDueAt)
+	list, _, _, err = repo.List(ctx, userID, notes.ListNotesRequest{Sort: notes.SortDueAt, Order: "asc", Limit: 10, Cursor: cursor}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{soon.Title, next.Title}, titles(list))
+
+	list, _, _, err = repo.List(ctx, userID, notes.ListNotesRequest{Overdue: true, Limit: 10}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{late.Title}, titles(list))
+
+	list, _, _, err = repo.List(ctx, userID, notes.ListNotesRequest{DueBefore: now.Add(2 * time.Hour).Format(time.RFC3339), Sort: notes.SortDueAt, Order: "asc", Limit: 10}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{late.Title, soon.Title}, titles(list))
+}
+
+func TestNotesRepoReminders(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	due := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "due", RemindAt: &past, CreatedAt: now, UpdatedAt: now}
+	later := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "later", RemindAt: &future, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, due))
+	require.NoError(t, repo.Create(ctx, later))
+
+	list, err := repo.DueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, due.ID, list[0].ID)
+
+	marked, err := repo.MarkReminded(ctx, due.ID, past, now)
+	require.NoError(t, err)
+	require.NotNil(t, marked.RemindedAt)
+
+	_, err = repo.MarkReminded(ctx, due.ID, past, now)
+	assert.ErrorIs(t, err, notes.ErrNoteNotFound, "a reminder is claimed once")
+
+	list, err = repo.DueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	// A new remind_at re-arms the reminder
+	rearm := now.Add(-time.Second)
+	_, err = repo.Update(ctx, userID, due.ID, notes.UpdateNote{RemindAt: &rearm})
+	require.NoError(t, err)
+	list, err = repo.DueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Nil(t, list[0].RemindedAt)
+
+	// The zero time clears it
+	_, err = repo.Update(ctx, userID, due.ID, notes.UpdateNote{RemindAt: &time.Time{}})
+	require.NoError(t, err)
+	list, err = repo.DueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
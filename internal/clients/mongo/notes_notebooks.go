@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// notebookFilter adds the notebook condition of req: the Inbox, the
+// notebooks the service resolved, or else the one notebook named
+func notebookFilter(filter bson.M, req notes.ListNotesRequest) {
+	switch {
+	case req.Notebook == notes.NotebookInbox:
+		filter["notebook_id"] = bson.M{"$exists": false}
+	case len(req.NotebookIDs) > 0:
+		filter["notebook_id"] = bson.M{"$in": req.NotebookIDs}
+	case req.Notebook != "":
+		if notebookID, err := bson.ObjectIDFromHex(req.Notebook); err == nil {
+			filter["notebook_id"] = notebookID
+		}
+	}
+}
+
+// personalInNotebooks selects the personal notes of userID filed in notebookIDs
+func personalInNotebooks(userID bson.ObjectID, notebookIDs []bson.ObjectID) bson.M {
+	return bson.M{
+		"user_id":      userID,
+		"workspace_id": nil,
+		"notebook_id":  bson.M{"$in": notebookIDs},
+	}
+}
+
+// SetNotebook files a personal note in a notebook, or the Inbox when
+// notebookID is nil
+func (r *NotesRepo) SetNotebook(ctx context.Context, noteID bson.ObjectID, notebookID *bson.ObjectID) (*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now().UTC()}
+	update := bson.M{"$set": set}
+	if notebookID == nil {
+		update["$unset"] = bson.M{"notebook_id": ""}
+	} else {
+		set["notebook_id"] = *notebookID
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var note notes.Note
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": noteID, "workspace_id": nil}, update, opts).Decode(&note)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return &note, nil
+}
+
+// ClearNotebooks moves the personal notes of userID filed in notebookIDs to
+// the Inbox
+func (r *NotesRepo) ClearNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{"notebook_id": ""},
+		"$set":   bson.M{"updated_at": time.Now().UTC()},
+	}
+	result, err := r.collection.UpdateMany(ctx, personalInNotebooks(userID, notebookIDs), update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear notebooks: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// InNotebooks returns up to limit personal notes of userID filed in
+// notebookIDs, in ID order
+func (r *NotesRepo) InNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID, limit int) ([]*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, personalInNotebooks(userID, notebookIDs), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notebook notes: %w", err)
+	}
+
+	var notesList []*notes.Note
+	if err := cursor.All(ctx, &notesList); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %w", err)
+	}
+	return notesList, nil
+}
+
+// NotebookCounts counts the notes matching req per notebook, the Inbox
+// under a nil ID
+func (r *NotesRepo) NotebookCounts(ctx context.Context, userID bson.ObjectID, req notes.ListNotesRequest) ([]notes.NotebookCount, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter, err := r.buildBasicListFilter(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$group": bson.M{"_id": "$notebook_id", "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notebook notes: %w", err)
+	}
+
+	counts := []notes.NotebookCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode notebook counts: %w", err)
+	}
+	return counts, nil
+}
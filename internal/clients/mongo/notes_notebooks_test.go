@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNotebookFilter(t *testing.T) {
+	filter := bson.M{}
+	notebookFilter(filter, notes.ListNotesRequest{})
+	assert.Empty(t, filter)
+
+	filter = bson.M{}
+	notebookFilter(filter, notes.ListNotesRequest{Notebook: notes.NotebookInbox})
+	assert.Equal(t, bson.M{"notebook_id": bson.M{"$exists": false}}, filter)
+
+	a, b := bson.NewObjectID(), bson.NewObjectID()
+	filter = bson.M{}
+	notebookFilter(filter, notes.ListNotesRequest{Notebook: a.Hex(), NotebookIDs: []bson.ObjectID{a, b}})
+	assert.Equal(t, bson.M{"notebook_id": bson.M{"$in": []bson.ObjectID{a, b}}}, filter)
+
+	filter = bson.M{}
+	notebookFilter(filter, notes.ListNotesRequest{Notebook: a.Hex()})
+	assert.Equal(t, bson.M{"notebook_id": a}, filter)
+}
+
+func TestNotesRepoNotebooks(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	work, home := bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	create := func(title string, notebookID *bson.ObjectID) *notes.Note {
+		n := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: title, NotebookID: notebookID, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, repo.Create(ctx, n))
+		return n
+	}
+	plan := create("plan", &work)
+	create("report", &work)
+	create("garden", &home)
+	inbox := create("inbox", nil)
+	shared := &notes.Note{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Title: "shared", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, shared))
+
+	titles := func(req notes.ListNotesRequest) []string {
+		req.Limit = 10
+		req.Sort = "title"
+		req.Order = "asc"
+		list, _, _, err := repo.List(ctx, userID, req, 0)
+		require.NoError(t, err)
+		out := make([]string, 0, len(list))
+		for _, n := range list {
+			out = append(out, n.Title)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"plan", "report"}, titles(notes.ListNotesRequest{Notebook: work.Hex()}))
+	assert.Equal(t, []string{"garden", "plan", "report"}, titles(notes.ListNotesRequest{Notebook: work.Hex(), NotebookIDs: []bson.ObjectID{work, home}}))
+	assert.Equal(t, []string{"inbox"}, titles(notes.ListNotesRequest{Notebook: notes.NotebookInbox}))
+
+	counts, err := repo.NotebookCounts(ctx, userID, notes.ListNotesRequest{})
+	require.NoError(t, err)
+	got := map[string]int64{}
+	for _, c := range counts {
+		key := "inbox"
+		if c.NotebookID != nil {
+			key = c.NotebookID.Hex()
+		}
+		got[key] = c.Count
+	}
+	assert.Equal(t, map[string]int64{"inbox": 1, work.Hex(): 2, home.Hex(): 1}, got)
+
+	moved, err := repo.SetNotebook(ctx, inbox.ID, &home)
+	require.NoError(t, err)
+	assert.Equal(t, &home, moved.NotebookID)
+	moved, err = repo.SetNotebook(ctx, plan.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, moved.NotebookID)
+	_, err = repo.SetNotebook(ctx, shared.ID, &home)
+	assert.ErrorIs(t, err, notes.ErrNoteNotFound, "workspace notes are not filed")
+
+	in, err := repo.InNotebooks(ctx, userID, []bson.ObjectID{home}, 1)
+	require.NoError(t, err)
+	assert.Len(t, in, 1)
+
+	cleared, err := repo.ClearNotebooks(ctx, userID, []bson.ObjectID{work, home})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cleared)
+	assert.Equal(t, []string{"garden", "inbox", "plan", "report"}, titles(notes.ListNotesRequest{Notebook: notes.NotebookInbox}))
+}
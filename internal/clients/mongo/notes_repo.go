@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"regexp"
 	"time"
 
 	"note-pulse/internal/logger"
+	"note-pulse/internal/services/admin"
 	"note-pulse/internal/services/notes"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -27,7 +29,7 @@ func repoCtx(parent context.Context) (context.Context, context.CancelFunc) {
 // calcCounts returns the filtered and unfiltered document counts in one place.
 func (r *NotesRepo) calcCounts(
 	ctx context.Context,
-	userID bson.ObjectID,
+	scope bson.M,
 	filter bson.M,
 	hasFilters bool,
 ) (int64, int64, error) {
@@ -38,14 +40,25 @@ func (r *NotesRepo) calcCounts(
 	if !hasFilters {
 		return total, total, nil
 	}
-	unfilteredFilter := bson.M{"user_id": userID}
-	unfiltered, err := r.collection.CountDocuments(ctx, unfilteredFilter)
+	unfiltered, err := r.collection.CountDocuments(ctx, scope)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to count unfiltered documents: %w", err)
 	}
 	return total, unfiltered, nil
 }
 
+// scopeFilter selects the notes a list request covers: those of the shared
+// workspace it names, or else the user's personal notes, i.e. the ones
+// without a workspace_id.
+func (r *NotesRepo) scopeFilter(userID bson.ObjectID, req notes.ListNotesRequest) bson.M {
+	if req.WorkspaceID != "" {
+		if workspaceID, err := bson.ObjectIDFromHex(req.WorkspaceID); err == nil {
+			return bson.M{"workspace_id": workspaceID}
+		}
+	}
+	return bson.M{"user_id": userID, "workspace_id": nil}
+}
+
 // translateNotFound maps the driver ErrNoDocuments to the domain-level ErrNoteNotFound.
 func translateNotFound(err error) error {
 	if errors.Is(err, mongo.ErrNoDocuments) {
@@ -100,6 +113,86 @@ func NewNotesRepo(parentCtx context.Context, db *mongo.Database) (*NotesRepo, er
 			},
 			Options: options.Index().SetName("user_title_desc_id_desc"),
 		},
+		// Shared workspace boards; personal notes have no workspace_id and
+		// are left out of these indexes
+		{
+			Keys: bson.D{
+				{Key: "workspace_id", Value: 1},
+				{Key: "created_at", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().
+				SetName("workspace_created_desc_id_desc").
+				SetPartialFilterExpression(bson.M{"workspace_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "workspace_id", Value: 1},
+				{Key: "updated_at", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().
+				SetName("workspace_updated_desc_id_desc").
+				SetPartialFilterExpression(bson.M{"workspace_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "workspace_id", Value: 1},
+				{Key: "title", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("workspace_title_asc_id_asc").
+				SetPartialFilterExpression(bson.M{"workspace_id": bson.M{"$exists": true}}),
+		},
+		// Due date sorting and the reminder scheduler; notes without the
+		// field are left out
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "due_at", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("user_due_asc_id_asc").
+				SetPartialFilterExpression(bson.M{"due_at": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "remind_at", Value: 1}},
+			Options: options.Index().
+				SetName("remind_at_asc").
+				SetPartialFilterExpression(bson.M{"remind_at": bson.M{"$exists": true}}),
+		},
+		// Notebook listings, including the Inbox of notes without one
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "notebook_id", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+			Options: options.Index().SetName("user_notebook_id_asc"),
+		},
+		// Viewport queries on a board; unplaced notes are left out
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "board_id", Value: 1},
+				{Key: "position.x", Value: 1},
+			},
+			Options: options.Index().
+				SetName("user_board_x_asc").
+				SetPartialFilterExpression(bson.M{"position": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "workspace_id", Value: 1},
+				{Key: "board_id", Value: 1},
+				{Key: "position.x", Value: 1},
+			},
+			Options: options.Index().
+				SetName("workspace_board_x_asc").
+				SetPartialFilterExpression(bson.M{"position": bson.M{"$exists": true}}),
+		},
 		// Text search index for title and body
 		{
 			Keys: bson.D{
@@ -154,7 +247,7 @@ func (r *NotesRepo) List(ctx context.Context, userID bson.ObjectID, req notes.Li
 	var err error
 	if offset >= 0 {
 		// Offset-based pagination: no cursor filters
-		filter = r.buildBasicListFilter(userID, req)
+		filter, err = r.buildBasicListFilter(userID, req)
 	} else {
 		// Cursor-based pagination: include cursor filters
 		filter, err = r.buildListFilter(userID, req)
@@ -163,16 +256,21 @@ func (r *NotesRepo) List(ctx context.Context, userID bson.ObjectID, req notes.Li
 		return nil, 0, 0, err
 	}
 
-	opts := r.buildFindOptions(req, req.Limit, offset)
-
-	// Check if any actual filters are applied (excluding pagination)
-	hasFilters := req.Color != "" || req.Q != ""
-
-	totalCount, totalCountUnfiltered, err := r.calcCounts(ctx, userID, filter, hasFilters)
+	totalCount, totalCountUnfiltered, err := r.calcCounts(ctx, r.scopeFilter(userID, req), filter, hasFilters(req))
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("failed to calculate counts: %w", err)
 	}
 
+	if req.Sort == notes.SortRelevance {
+		notesList, err := r.listByRelevance(ctx, filter, req, offset)
+		if err != nil {
+			return nil, totalCount, totalCountUnfiltered, err
+		}
+		return notesList, totalCount, totalCountUnfiltered, nil
+	}
+
+	opts := r.buildFindOptions(req, req.Limit, offset)
+
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, totalCount, totalCountUnfiltered, fmt.Errorf("failed to find notes: %w", err)
@@ -192,31 +290,29 @@ func (r *NotesRepo) List(ctx context.Context, userID bson.ObjectID, req notes.Li
 }
 
 // buildBasicListFilter constructs the MongoDB filter for offset-based queries (no cursor filters)
-func (r *NotesRepo) buildBasicListFilter(userID bson.ObjectID, req notes.ListNotesRequest) bson.M {
-	filter := bson.M{"user_id": userID}
+func (r *NotesRepo) buildBasicListFilter(userID bson.ObjectID, req notes.ListNotesRequest) (bson.M, error) {
+	filter := r.scopeFilter(userID, req)
 
-	if req.Color != "" {
-		filter["color"] = req.Color
+	if err := r.applyFilters(filter, req); err != nil {
+		return nil, fmt.Errorf("failed to build list filter: %w", err)
 	}
 
-	r.addSearchFilter(filter, req.Q)
-
 	// No cursor filter for offset-based pagination
 
-	return filter
+	return filter, nil
 }
 
 // buildListFilter constructs the MongoDB filter for the List query
 func (r *NotesRepo) buildListFilter(userID bson.ObjectID, req notes.ListNotesRequest) (bson.M, error) {
-	filter := bson.M{"user_id": userID}
+	filter := r.scopeFilter(userID, req)
 
-	if req.Color != "" {
-		filter["color"] = req.Color
+	if err := r.applyFilters(filter, req); err != nil {
+		return nil, fmt.Errorf("failed to build list filter: %w", err)
 	}
 
-	r.addSearchFilter(filter, req.Q)
-
-	if req.Cursor != "" {
+	// Relevance cursors compare the text score, which only exists inside the
+	// aggregation pipeline; listByRelevance applies them there
+	if req.Cursor != "" && req.Sort != notes.SortRelevance {
 		if err := r.addCursorFilter(filter, req); err != nil {
 			return nil, fmt.Errorf("failed to build list filter: %w", err)
 		}
@@ -225,30 +321,145 @@ func (r *NotesRepo) buildListFilter(userID bson.ObjectID, req notes.ListNotesReq
 	return filter, nil
 }
 
-// addSearchFilter adds search conditions to the filter
-func (r *NotesRepo) addSearchFilter(filter bson.M, query string) {
-	if query == "" {
-		return
+// listByRelevance runs a full-text list sorted by text score. The score is
+// materialised as a field so the cursor can page on (score, _id). With search
+// index matches it is looked up from their scores instead.
+func (r *NotesRepo) listByRelevance(ctx context.Context, filter bson.M, req notes.ListNotesRequest, offset int) ([]*notes.Note, error) {
+	dir := -1
+	operator := "$lt"
+	if req.Order == "asc" {
+		dir = 1
+		operator = "$gt"
+	}
+
+	var score any = bson.M{"$meta": "textScore"}
+	if m := req.Matches; m != nil {
+		score = bson.M{"$arrayElemAt": bson.A{
+			m.Scores,
+			bson.M{"$indexOfArray": bson.A{m.IDs, "$_id"}},
+		}}
+	}
+
+	pipeline := mongo.Pipeline{
+		// $text must be in the first stage
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{"score": score}}},
+	}
+
+	if req.Cursor != "" {
+		cursor, err := notes.DecodeScoreCursor(req.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor format: %w", err)
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
+			"$or": bson.A{
+				bson.M{"score": bson.M{operator: cursor.Score}},
+				bson.M{"score": cursor.Score, "_id": bson.M{operator: cursor.ID}},
+			},
+		}}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "score", Value: dir}, {Key: "_id", Value: dir}}}})
+	if offset >= 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: int64(offset)}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(req.Limit)}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notes by relevance: %w", err)
+	}
+
+	var notesList []*notes.Note
+	if err := cursor.All(ctx, &notesList); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %w", err)
+	}
+	return notesList, nil
+}
+
+// addSearchFilter compiles the search query q into filter. Its conditions
+// go under $and so they never clash with the $or of cursor and anchor filters.
+// Non-nil matches stand in for the text index: only those notes are kept.
+// Similarity searches set them without any q.
+func (r *NotesRepo) addSearchFilter(filter bson.M, q string, matches *notes.SearchMatches) error {
+	if q == "" && matches == nil {
+		return nil
+	}
+
+	query, err := notes.ParseQuery(q)
+	if err != nil {
+		return fmt.Errorf("failed to parse search query: %w", err)
 	}
 
-	if len(query) >= 3 {
+	var conds bson.A
+	if matches != nil {
+		conds = append(conds, bson.M{"_id": bson.M{"$in": matches.IDs}})
+	} else if len(query.Text) >= notes.MinTextSearchLen {
 		// Use MongoDB text search for better performance
-		filter["$text"] = bson.M{"$search": query}
-	} else {
+		filter["$text"] = bson.M{"$search": query.Text}
+	} else if query.Text != "" {
 		// Fall back to regex for short queries
-		pattern := regexp.QuoteMeta(query)
-		regex := bson.M{"$regex": pattern, "$options": "i"}
-		filter["$or"] = bson.A{
-			bson.M{"title": regex},
-			bson.M{"body": regex},
+		conds = append(conds, titleOrBody(containsRegex(query.Text)))
+	}
+
+	for _, clause := range query.Clauses {
+		cond := clauseFilter(clause)
+		if clause.Negate {
+			cond = bson.M{"$nor": bson.A{cond}}
+		}
+		conds = append(conds, cond)
+	}
+
+	if len(conds) > 0 {
+		filter["$and"] = conds
+	}
+	return nil
+}
+
+// clauseFilter returns the condition a query clause matches
+func clauseFilter(clause notes.QueryClause) bson.M {
+	switch clause.Field {
+	case notes.FieldTitle, notes.FieldBody:
+		return bson.M{clause.Field: containsRegex(clause.Value)}
+	case notes.FieldColor:
+		return bson.M{"color": bson.M{"$regex": "^" + regexp.QuoteMeta(clause.Value) + "$", "$options": "i"}}
+	case notes.FieldCreated, notes.FieldUpdated:
+		bounds := bson.M{}
+		for _, b := range clause.Bounds {
+			bounds[dateOperators[b.Op]] = b.At
 		}
+		return bson.M{clause.Field + "_at": bounds}
+	default:
+		return titleOrBody(containsRegex(clause.Value))
 	}
 }
 
+var dateOperators = map[string]string{
+	notes.OpGT:  "$gt",
+	notes.OpGTE: "$gte",
+	notes.OpLT:  "$lt",
+	notes.OpLTE: "$lte",
+}
+
+// containsRegex matches s anywhere, ignoring case
+func containsRegex(s string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(s), "$options": "i"}
+}
+
+func titleOrBody(regex bson.M) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"title": regex},
+		bson.M{"body": regex},
+	}}
+}
+
 // addCursorFilter adds cursor pagination conditions to the filter
 func (r *NotesRepo) addCursorFilter(filter bson.M, req notes.ListNotesRequest) error {
-	if req.Sort == "title" {
+	switch req.Sort {
+	case notes.SortTitle:
 		return r.addTitleCursorFilter(filter, req.Cursor, req.Order)
+	case notes.SortDueAt:
+		return r.addDueCursorFilter(filter, req.Cursor, req.Order)
 	}
 	return r.addObjectIDCursorFilter(filter, req.Cursor, req.Order)
 }
@@ -304,12 +515,33 @@ func (r *NotesRepo) addTitleCursorFilter(filter bson.M, cursorStr, order string)
 	return nil
 }
 
+// addDueCursorFilter adds cursor pagination filter for due date sorting
+func (r *NotesRepo) addDueCursorFilter(filter bson.M, cursorStr, order string) error {
+	cursor, err := notes.DecodeDueCursor(cursorStr)
+	if err != nil {
+		return fmt.Errorf("invalid cursor format: %w", err)
+	}
+
+	operator := "$lt"
+	if order == "asc" {
+		operator = "$gt"
+	}
+	filter["$or"] = bson.A{
+		bson.M{"due_at": bson.M{operator: cursor.DueAt}},
+		bson.M{
+			"due_at": cursor.DueAt,
+			"_id":    bson.M{operator: cursor.ID},
+		},
+	}
+	return nil
+}
+
 // buildFindOptions constructs the MongoDB find options for sorting and pagination
 func (r *NotesRepo) buildFindOptions(req notes.ListNotesRequest, limit int, offset int) *options.FindOptionsBuilder {
 	sortKey := "created_at"
 	if req.Sort != "" {
 		switch req.Sort {
-		case "created_at", "updated_at", "title":
+		case "created_at", "updated_at", "title", notes.SortDueAt:
 			sortKey = req.Sort
 		default:
 			sortKey = "created_at"
@@ -333,6 +565,18 @@ func (r *NotesRepo) buildFindOptions(req notes.ListNotesRequest, limit int, offs
 	return opts
 }
 
+// FindByID finds a note by ID regardless of owner. Callers check access.
+func (r *NotesRepo) FindByID(ctx context.Context, noteID bson.ObjectID) (*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var note notes.Note
+	if err := r.collection.FindOne(ctx, bson.M{"_id": noteID}).Decode(&note); err != nil {
+		return nil, translateNotFound(err)
+	}
+	return &note, nil
+}
+
 // Update updates a note belonging to the specified user
 func (r *NotesRepo) Update(ctx context.Context, userID, noteID bson.ObjectID, patch notes.UpdateNote) (*notes.Note, error) {
 	ctx, cancel := repoCtx(ctx)
@@ -359,9 +603,34 @@ func (r *NotesRepo) Update(ctx context.Context, userID, noteID bson.ObjectID, pa
 	if patch.Color != nil {
 		update["$set"].(bson.M)["color"] = *patch.Color
 	}
+	unset := bson.M{}
+	if patch.Format != nil {
+		// Plain notes store no format
+		if *patch.Format == "" {
+			unset["format"] = ""
+		} else {
+			update["$set"].(bson.M)["format"] = *patch.Format
+		}
+	}
+	for field, t := range map[string]*time.Time{"due_at": patch.DueAt, "remind_at": patch.RemindAt} {
+		switch {
+		case t == nil:
+		case t.IsZero():
+			unset[field] = ""
+		default:
+			update["$set"].(bson.M)[field] = *t
+		}
+	}
+	if patch.RemindAt != nil {
+		// A new reminder time re-arms the reminder
+		unset["reminded_at"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
 	// Skip update if only updated_at would be set (micro-optimization)
-	if len(update["$set"].(bson.M)) == 1 {
+	if len(update["$set"].(bson.M)) == 1 && update["$unset"] == nil {
 		var existingNote notes.Note
 		err := r.collection.FindOne(ctx, filter).Decode(&existingNote)
 		if err != nil {
@@ -403,6 +672,143 @@ func (r *NotesRepo) Delete(ctx context.Context, userID, noteID bson.ObjectID) er
 	return nil
 }
 
+// DeleteAllForUser deletes every personal note of a user and returns how many
+// were removed. Notes in shared workspaces are kept.
+func (r *NotesRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "workspace_id": nil})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notes for user: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// Scan returns up to limit notes of any user with an ID above after, in ID
+// order. Reindexing walks the collection with it.
+func (r *NotesRepo) Scan(ctx context.Context, after bson.ObjectID, limit int) ([]*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$gt": after}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notes: %w", err)
+	}
+
+	var notesList []*notes.Note
+	if err := cursor.All(ctx, &notesList); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %w", err)
+	}
+	return notesList, nil
+}
+
+// DeleteAllForWorkspace deletes every note of a shared workspace and returns
+// how many were removed
+func (r *NotesRepo) DeleteAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"workspace_id": workspaceID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notes for workspace: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DueReminders returns up to limit notes of any user whose remind_at is at or
+// before now and has not fired yet, oldest first
+func (r *NotesRepo) DueReminders(ctx context.Context, now time.Time, limit int) ([]*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"remind_at":   bson.M{"$lte": now},
+		"reminded_at": bson.M{"$exists": false},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due reminders: %w", err)
+	}
+
+	var notesList []*notes.Note
+	if err := cursor.All(ctx, &notesList); err != nil {
+		return nil, fmt.Errorf("failed to decode notes: %w", err)
+	}
+	return notesList, nil
+}
+
+// MarkReminded records that the reminder set for remindAt fired at at. Only
+// one caller claims a reminder: once it is marked, or if remind_at changed
+// meanwhile, ErrNoteNotFound is returned.
+func (r *NotesRepo) MarkReminded(ctx context.Context, noteID bson.ObjectID, remindAt, at time.Time) (*notes.Note, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":         noteID,
+		"remind_at":   remindAt,
+		"reminded_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"reminded_at": at}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var note notes.Note
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&note)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return &note, nil
+}
+
+// UsageByUser returns the note count and stored bytes of each given user.
+// Users without notes are absent from the map.
+func (r *NotesRepo) UsageByUser(ctx context.Context, userIDs []bson.ObjectID) (map[bson.ObjectID]admin.Usage, error) {
+	usage := make(map[bson.ObjectID]admin.Usage, len(userIDs))
+	if len(userIDs) == 0 {
+		return usage, nil
+	}
+
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$in": userIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$user_id",
+			"count": bson.M{"$sum": 1},
+			"bytes": bson.M{"$sum": bson.M{"$bsonSize": "$$ROOT"}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate note usage: %w", err)
+	}
+
+	var rows []struct {
+		UserID bson.ObjectID `bson:"_id"`
+		Count  int64         `bson:"count"`
+		Bytes  int64         `bson:"bytes"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode note usage: %w", err)
+	}
+
+	for _, row := range rows {
+		usage[row.UserID] = admin.Usage{NoteCount: row.Count, StorageBytes: row.Bytes}
+	}
+	return usage, nil
+}
+
 // FindOne finds a single note by anchor and verifies it matches the filters
 func (r *NotesRepo) FindOne(ctx context.Context, userID bson.ObjectID, req notes.ListNotesRequest, anchor string) (*notes.Note, error) {
 	ctx, cancel := repoCtx(ctx)
@@ -411,30 +817,33 @@ func (r *NotesRepo) FindOne(ctx context.Context, userID bson.ObjectID, req notes
 	var noteID bson.ObjectID
 	var err error
 
-	if req.Sort == "title" {
+	switch req.Sort {
+	case notes.SortTitle:
 		cursor, err := notes.DecodeCompositeCursor(anchor)
 		if err != nil {
 			return nil, fmt.Errorf("invalid anchor cursor: %w", err)
 		}
 		noteID = cursor.ID
-	} else {
+	case notes.SortDueAt:
+		cursor, err := notes.DecodeDueCursor(anchor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anchor cursor: %w", err)
+		}
+		noteID = cursor.ID
+	default:
 		noteID, err = bson.ObjectIDFromHex(anchor)
 		if err != nil {
 			return nil, fmt.Errorf("invalid anchor ID: %w", err)
 		}
 	}
 
-	filter := bson.M{
-		"_id":     noteID,
-		"user_id": userID,
-	}
+	filter := r.scopeFilter(userID, req)
+	filter["_id"] = noteID
 
-	if req.Color != "" {
-		filter["color"] = req.Color
+	if err := r.applyFilters(filter, req); err != nil {
+		return nil, err
 	}
 
-	r.addSearchFilter(filter, req.Q)
-
 	var note notes.Note
 	err = r.collection.FindOne(ctx, filter).Decode(&note)
 	if err != nil {
@@ -510,8 +919,11 @@ func (r *NotesRepo) GetAnchorIndex(ctx context.Context, userID bson.ObjectID, re
 	sortKey := r.getSortKey(req.Sort)
 	order := r.getSortOrder(req.Order)
 
-	beforeFilter := r.buildBeforeFilter(userID, sortKey, order, anchor)
-	r.applyFilters(beforeFilter, req)
+	beforeFilter := r.buildBeforeFilter(sortKey, order, anchor)
+	maps.Copy(beforeFilter, r.scopeFilter(userID, req))
+	if err := r.applyFilters(beforeFilter, req); err != nil {
+		return -1, err
+	}
 
 	// Optional hint for large workspaces with duplicate titles
 	opts := options.Count()
@@ -534,7 +946,7 @@ func (r *NotesRepo) GetAnchorIndex(ctx context.Context, userID bson.ObjectID, re
 // getSortKey returns the validated sort key
 func (r *NotesRepo) getSortKey(sort string) string {
 	switch sort {
-	case "created_at", "updated_at", "title":
+	case "created_at", "updated_at", "title", notes.SortDueAt:
 		return sort
 	default:
 		return "created_at"
@@ -550,22 +962,21 @@ func (r *NotesRepo) getSortOrder(order string) string {
 }
 
 // buildBeforeFilter builds the filter for documents before the anchor
-func (r *NotesRepo) buildBeforeFilter(userID bson.ObjectID, sortKey, order string, anchor *notes.Note) bson.M {
+func (r *NotesRepo) buildBeforeFilter(sortKey, order string, anchor *notes.Note) bson.M {
 	if sortKey == "title" {
-		return r.buildTitleBeforeFilter(userID, order, anchor)
+		return r.buildTitleBeforeFilter(order, anchor)
 	}
-	return r.buildDateBeforeFilter(userID, sortKey, order, anchor)
+	return r.buildDateBeforeFilter(sortKey, order, anchor)
 }
 
 // buildTitleBeforeFilter builds the before filter for title sorting
-func (r *NotesRepo) buildTitleBeforeFilter(userID bson.ObjectID, order string, anchor *notes.Note) bson.M {
+func (r *NotesRepo) buildTitleBeforeFilter(order string, anchor *notes.Note) bson.M {
 	operator := "$lt"
 	if order == "desc" {
 		operator = "$gt"
 	}
 
 	return bson.M{
-		"user_id": userID,
 		"$or": bson.A{
 			bson.M{"title": bson.M{operator: anchor.Title}},
 			bson.M{
@@ -577,10 +988,16 @@ func (r *NotesRepo) buildTitleBeforeFilter(userID bson.ObjectID, order string, a
 }
 
 // buildDateBeforeFilter builds the before filter for date/time sorting
-func (r *NotesRepo) buildDateBeforeFilter(userID bson.ObjectID, sortKey, order string, anchor *notes.Note) bson.M {
+func (r *NotesRepo) buildDateBeforeFilter(sortKey, order string, anchor *notes.Note) bson.M {
 	anchorValue := anchor.CreatedAt
-	if sortKey == "updated_at" {
+	switch sortKey {
+	case "updated_at":
 		anchorValue = anchor.UpdatedAt
+	case notes.SortDueAt:
+		anchorValue = time.Time{}
+		if anchor.DueAt != nil {
+			anchorValue = *anchor.DueAt
+		}
 	}
 
 	operator := "$lt"
@@ -589,7 +1006,6 @@ func (r *NotesRepo) buildDateBeforeFilter(userID bson.ObjectID, sortKey, order s
 	}
 
 	return bson.M{
-		"user_id": userID,
 		"$or": bson.A{
 			bson.M{sortKey: bson.M{operator: anchorValue}},
 			bson.M{
@@ -600,12 +1016,45 @@ func (r *NotesRepo) buildDateBeforeFilter(userID bson.ObjectID, sortKey, order s
 	}
 }
 
-// applyFilters applies color and search filters to the given filter
-func (r *NotesRepo) applyFilters(filter bson.M, req notes.ListNotesRequest) {
+// hasFilters reports whether req narrows its scope beyond pagination
+func hasFilters(req notes.ListNotesRequest) bool {
+	return req.Color != "" || req.Q != "" || req.Matches != nil ||
+		req.DueBefore != "" || req.Overdue || req.Sort == notes.SortDueAt ||
+		req.BoardID != "" || req.Viewport != "" || req.Notebook != ""
+}
+
+// applyFilters applies color, due date, board, notebook and search filters
+// to the given filter
+func (r *NotesRepo) applyFilters(filter bson.M, req notes.ListNotesRequest) error {
 	if req.Color != "" {
 		filter["color"] = req.Color
 	}
-	r.addSearchFilter(filter, req.Q)
+	boardFilter(filter, req)
+	notebookFilter(filter, req)
+	if due := dueFilter(req, time.Now().UTC()); len(due) > 0 {
+		filter["due_at"] = due
+	}
+	return r.addSearchFilter(filter, req.Q, req.Matches)
+}
+
+// dueFilter returns the due_at condition of req. Sorting by due date drops
+// the notes without one.
+func dueFilter(req notes.ListNotesRequest, now time.Time) bson.M {
+	due := bson.M{}
+	if req.Sort == notes.SortDueAt {
+		due["$exists"] = true
+	}
+	var before time.Time
+	if t, err := time.Parse(time.RFC3339, req.DueBefore); err == nil {
+		before = t
+	}
+	if req.Overdue && (before.IsZero() || now.Before(before)) {
+		before = now
+	}
+	if !before.IsZero() {
+		due["$lt"] = before
+	}
+	return due
 }
 
 // GetCounts gets the total and unfiltered counts for the current request
@@ -613,21 +1062,24 @@ func (r *NotesRepo) GetCounts(ctx context.Context, userID bson.ObjectID, req not
 	ctx, cancel := repoCtx(ctx)
 	defer cancel()
 
-	filter := bson.M{"user_id": userID}
-	if req.Color != "" {
-		filter["color"] = req.Color
+	scope := r.scopeFilter(userID, req)
+	filter := maps.Clone(scope)
+	if err := r.applyFilters(filter, req); err != nil {
+		return 0, 0, err
 	}
-	r.addSearchFilter(filter, req.Q)
 
-	hasFilters := req.Color != "" || req.Q != ""
-
-	return r.calcCounts(ctx, userID, filter, hasFilters)
+	return r.calcCounts(ctx, scope, filter, hasFilters(req))
 }
 
 // generateCursorFromNote generates a cursor string from a note based on sort criteria
 func (r *NotesRepo) generateCursorFromNote(note *notes.Note, sort string) string {
-	if sort == "title" {
+	switch sort {
+	case notes.SortTitle:
 		return notes.EncodeCompositeCursor(note.Title, note.ID)
+	case notes.SortDueAt:
+		if note.DueAt != nil {
+			return notes.EncodeDueCursor(*note.DueAt, note.ID)
+		}
 	}
 	return note.ID.Hex()
 }
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"testing"
+	"time"
 
 	"note-pulse/internal/services/notes"
 
@@ -133,3 +134,53 @@ func TestNewNotesRepoIndexCreationError(t *testing.T) {
 	// Test passes if function signature is correct (returns repo, error)
 	assert.True(t, true, "NewNotesRepo has correct signature returning (*NotesRepo, error)")
 }
+
+func TestAddSearchFilter(t *testing.T) {
+	repo := &NotesRepo{}
+
+	filter := bson.M{}
+	err := repo.addSearchFilter(filter, `standup -draft color:#f00 updated:<2026-01-01`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$search": "standup"}, filter["$text"])
+	assert.Equal(t, bson.A{
+		bson.M{"$nor": bson.A{titleOrBody(containsRegex("draft"))}},
+		bson.M{"color": bson.M{"$regex": "^#f00$", "$options": "i"}},
+		bson.M{"updated_at": bson.M{"$lt": time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}, filter["$and"])
+
+	// Short text falls back to a regex that must not clobber cursor filters
+	filter = bson.M{"$or": bson.A{}}
+	assert.NoError(t, repo.addSearchFilter(filter, "ab title:x", nil))
+	assert.NotContains(t, filter, "$text")
+	assert.Equal(t, bson.A{}, filter["$or"])
+	assert.Equal(t, bson.A{
+		titleOrBody(containsRegex("ab")),
+		bson.M{"title": containsRegex("x")},
+	}, filter["$and"])
+
+	// Search index matches replace $text
+	id := bson.NewObjectID()
+	filter = bson.M{}
+	matches := &notes.SearchMatches{IDs: []bson.ObjectID{id}, Scores: []float64{1}}
+	assert.NoError(t, repo.addSearchFilter(filter, "standup title:x", matches))
+	assert.NotContains(t, filter, "$text")
+	assert.Equal(t, bson.A{
+		bson.M{"_id": bson.M{"$in": []bson.ObjectID{id}}},
+		bson.M{"title": containsRegex("x")},
+	}, filter["$and"])
+
+	assert.ErrorIs(t, repo.addSearchFilter(bson.M{}, "created:soon", nil), notes.ErrBadRequest)
+}
+
+func TestDueFilter(t *testing.T) {
+	now := time.Date(2026, 1, 9, 12, 0, 0, 0, time.UTC)
+	later := "2026-02-01T00:00:00Z"
+	earlier := "2026-01-01T00:00:00Z"
+
+	assert.Empty(t, dueFilter(notes.ListNotesRequest{}, now))
+	assert.Equal(t, bson.M{"$exists": true}, dueFilter(notes.ListNotesRequest{Sort: notes.SortDueAt}, now))
+	assert.Equal(t, bson.M{"$lt": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, dueFilter(notes.ListNotesRequest{DueBefore: later}, now))
+	assert.Equal(t, bson.M{"$lt": now}, dueFilter(notes.ListNotesRequest{Overdue: true}, now))
+	assert.Equal(t, bson.M{"$lt": now}, dueFilter(notes.ListNotesRequest{Overdue: true, DueBefore: later}, now), "overdue narrows a later due_before")
+	assert.Equal(t, bson.M{"$lt": time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}, dueFilter(notes.ListNotesRequest{Overdue: true, DueBefore: earlier}, now))
+}
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RateLimitsRepo implements ratelimit.Repository for MongoDB, so that every
+// replica counts into the same windows. Counters are dropped some time
+// after their expires_at.
+type RateLimitsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewRateLimitsRepo creates a new rate limit counters repository
+func NewRateLimitsRepo(parentCtx context.Context, db *mongo.Database) (*RateLimitsRepo, error) {
+	collection := db.Collection("rate_limits")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create rate limit indexes: %w", err)
+	}
+
+	return &RateLimitsRepo{collection: collection}, nil
+}
+
+// Incr adds one to the counter named key. A single update both counts and
+// restarts a window that ended, so concurrent replicas never lose a request.
+func (r *RateLimitsRepo) Incr(ctx context.Context, key string, now time.Time, window time.Duration) (int64, time.Time, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	// A missing expires_at sorts before any date, so a new counter starts
+	// its first window
+	open := bson.M{"$gt": bson.A{"$expires_at", now}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"count":      bson.M{"$cond": bson.A{open, bson.M{"$add": bson.A{"$count", 1}}, 1}},
+			"expires_at": bson.M{"$cond": bson.A{open, "$expires_at", now.Add(window)}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Count     int64     `bson:"count"`
+		ExpiresAt time.Time `bson:"expires_at"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another request inserted the counter first; it exists now
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count request: %w", err)
+	}
+	return counter.Count, counter.ExpiresAt, nil
+}
@@ -0,0 +1,46 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitsRepoIncr(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewRateLimitsRepo(ctx, db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := repo.Incr(ctx, "app:user:a", now, time.Minute)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	n, expiresAt, err := repo.Incr(ctx, "app:user:a", now.Add(time.Second), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n, "concurrent requests are all counted")
+	assert.True(t, expiresAt.Equal(now.Add(time.Minute)), "the window runs from the first request")
+
+	n, _, err = repo.Incr(ctx, "app:user:b", now, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, expiresAt, err = repo.Incr(ctx, "app:user:a", now.Add(time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "an ended window starts over")
+	assert.True(t, expiresAt.Equal(now.Add(2*time.Minute)))
+}
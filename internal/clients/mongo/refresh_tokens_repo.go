@@ -58,6 +58,10 @@ func NewRefreshTokensRepo(parentCtx context.Context, db *mongo.Database) (*Refre
 			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "lookup_hash", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
+		// Session lookup for device management
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "session_id", Value: 1}},
+		},
 	}
 
 	ctx, cancel := context.WithTimeout(parentCtx, refreshTokenOpTimeout)
@@ -73,18 +77,30 @@ func NewRefreshTokensRepo(parentCtx context.Context, db *mongo.Database) (*Refre
 	}, nil
 }
 
-// Create creates a new refresh token record
-func (r *RefreshTokensRepo) Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time) error {
+// Create creates a new refresh token record bound to the given session
+func (r *RefreshTokensRepo) Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time, meta auth.SessionMeta) error {
 	// Use SHA-256 for both storage and lookup (faster than bcrypt)
 	h := sha256.Sum256([]byte(rawToken))
 	tokenHash := hex.EncodeToString(h[:])
 
+	now := time.Now().UTC()
+	startedAt := meta.StartedAt
+	if startedAt.IsZero() {
+		startedAt = now
+	}
+
 	refreshToken := auth.RefreshToken{
-		UserID:     userID,
-		TokenHash:  tokenHash,
-		LookupHash: tokenHash, // Same value since we only use SHA-256 now
-		ExpiresAt:  expiresAt,
-		CreatedAt:  time.Now().UTC(),
+		UserID:           userID,
+		SessionID:        meta.SessionID,
+		TokenHash:        tokenHash,
+		LookupHash:       tokenHash, // Same value since we only use SHA-256 now
+		DeviceName:       meta.DeviceName,
+		UserAgent:        meta.UserAgent,
+		IP:               meta.IP,
+		ExpiresAt:        expiresAt,
+		CreatedAt:        now,
+		SessionStartedAt: startedAt,
+		LastRefreshAt:    meta.LastRefreshAt,
 	}
 
 	_, err := r.collection.InsertOne(ctx, refreshToken)
@@ -193,3 +209,126 @@ func (r *RefreshTokensRepo) RevokeAllForUser(ctx context.Context, userID bson.Ob
 
 	return nil
 }
+
+// Touch records a refresh on a token that is kept (rotation disabled)
+func (r *RefreshTokensRepo) Touch(ctx context.Context, id bson.ObjectID, meta auth.SessionMeta) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	set := bson.M{"last_refresh_at": time.Now().UTC()}
+	if meta.LastRefreshAt != nil {
+		set["last_refresh_at"] = *meta.LastRefreshAt
+	}
+	if meta.UserAgent != "" {
+		set["user_agent"] = meta.UserAgent
+	}
+	if meta.IP != "" {
+		set["ip"] = meta.IP
+	}
+
+	if _, err := r.collection.UpdateByID(ctx, id, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to touch refresh token: %w", err)
+	}
+	return nil
+}
+
+// ListActiveForUser returns the active refresh tokens of a user, most
+// recently refreshed or created first
+func (r *RefreshTokensRepo) ListActiveForUser(ctx context.Context, userID bson.ObjectID) ([]*auth.RefreshToken, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	// activity is the last refresh, or creation for tokens never refreshed
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    userID,
+			"revoked_at": ExistsFalse,
+			"expires_at": bson.M{"$gt": time.Now().UTC()},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"activity_at": bson.M{"$ifNull": bson.A{"$last_refresh_at", "$created_at"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "activity_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$project", Value: bson.M{"activity_at": 0}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh tokens: %w", err)
+	}
+
+	var tokens []*auth.RefreshToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// SessionActive reports whether a session of userID still has an active
+// refresh token
+func (r *RefreshTokensRepo) SessionActive(ctx context.Context, userID, sessionID bson.ObjectID) (bool, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	filter := sessionFilter(userID, sessionID)
+	filter["expires_at"] = bson.M{"$gt": time.Now().UTC()}
+
+	n, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RevokeSession revokes the active refresh tokens of one session and returns how many were revoked.
+// Legacy tokens without a session_id are matched by their own _id.
+func (r *RefreshTokensRepo) RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) (int64, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	filter := sessionFilter(userID, sessionID)
+	update := bson.M{
+		"$set": bson.M{
+			"revoked_at": time.Now().UTC(),
+		},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		safeLog().Error("failed to revoke session", "error", err, "user_id", userID.Hex(), "session_id", sessionID.Hex())
+		return 0, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	safeLog().Debug("revoked session", "user_id", userID.Hex(), "session_id", sessionID.Hex(), "revoked_count", result.ModifiedCount)
+
+	return result.ModifiedCount, nil
+}
+
+// DeleteAllForUser removes every refresh token of a user, revoked or not
+func (r *RefreshTokensRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		safeLog().Error("failed to delete refresh tokens for user", "error", err, "user_id", userID.Hex())
+		return fmt.Errorf("failed to delete refresh tokens for user: %w", err)
+	}
+
+	safeLog().Debug("deleted refresh tokens for user", "user_id", userID.Hex(), "deleted_count", result.DeletedCount)
+
+	return nil
+}
+
+// sessionFilter matches the unrevoked refresh tokens of one session.
+// Legacy tokens without a session_id are matched by their own _id.
+func sessionFilter(userID, sessionID bson.ObjectID) bson.M {
+	return bson.M{
+		"user_id":    userID,
+		"revoked_at": ExistsFalse,
+		"$or": bson.A{
+			bson.M{"session_id": sessionID},
+			bson.M{"_id": sessionID, "session_id": ExistsFalse},
+		},
+	}
+}
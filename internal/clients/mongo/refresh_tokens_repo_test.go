@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"note-pulse/internal/services/auth"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -37,7 +39,7 @@ func createTestRefreshToken(t *testing.T, ctx context.Context, repo *RefreshToke
 	rawToken := testRefreshToken
 	expiresAt := time.Now().UTC().Add(30 * 24 * time.Hour)
 
-	err := repo.Create(ctx, userID, rawToken, expiresAt)
+	err := repo.Create(ctx, userID, rawToken, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
 	return userID, rawToken, expiresAt, err
@@ -82,7 +84,7 @@ func TestRefreshTokensRepoFindActiveExpired(t *testing.T) {
 	rawToken := testRefreshToken
 	expiresAt := time.Now().UTC().Add(-1 * time.Hour)
 
-	err := repo.Create(ctx, userID, rawToken, expiresAt)
+	err := repo.Create(ctx, userID, rawToken, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
 	_, err = repo.FindActive(ctx, rawToken)
@@ -97,7 +99,7 @@ func TestRefreshTokensRepoRevoke(t *testing.T) {
 	rawToken := testRefreshToken
 	expiresAt := time.Now().UTC().Add(30 * 24 * time.Hour)
 
-	err := repo.Create(ctx, userID, rawToken, expiresAt)
+	err := repo.Create(ctx, userID, rawToken, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
 	token, err := repo.FindActive(ctx, rawToken)
@@ -125,13 +127,13 @@ func TestRefreshTokensRepoRevokeAllForUser(t *testing.T) {
 	token2 := "token2"
 	otherToken := "other-token"
 
-	err := repo.Create(ctx, userID, token1, expiresAt)
+	err := repo.Create(ctx, userID, token1, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
-	err = repo.Create(ctx, userID, token2, expiresAt)
+	err = repo.Create(ctx, userID, token2, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
-	err = repo.Create(ctx, otherUserID, otherToken, expiresAt)
+	err = repo.Create(ctx, otherUserID, otherToken, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
 	err = repo.RevokeAllForUser(ctx, userID)
@@ -157,10 +159,10 @@ func TestRefreshTokensRepoFindActiveMultipleTokens(t *testing.T) {
 	token1 := "token1"
 	token2 := "token2"
 
-	err := repo.Create(ctx, userID, token1, expiresAt)
+	err := repo.Create(ctx, userID, token1, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
-	err = repo.Create(ctx, userID, token2, expiresAt)
+	err = repo.Create(ctx, userID, token2, expiresAt, auth.SessionMeta{})
 	require.NoError(t, err, msgShouldCreate)
 
 	foundToken1, err := repo.FindActive(ctx, token1)
@@ -195,13 +197,13 @@ func TestRefreshTokensRepoCreateDuplicate(t *testing.T) {
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		err := repo.Create(ctx, userID, rawToken, expiresAt)
+		err := repo.Create(ctx, userID, rawToken, expiresAt, auth.SessionMeta{})
 		errors <- err
 	}()
 
 	go func() {
 		defer wg.Done()
-		err := repo.Create(ctx, userID, rawToken, expiresAt)
+		err := repo.Create(ctx, userID, rawToken, expiresAt, auth.SessionMeta{})
 		errors <- err
 	}()
 
@@ -221,3 +223,65 @@ func TestRefreshTokensRepoCreateDuplicate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), count, "exactly one token should exist")
 }
+
+func TestRefreshTokensRepoSessions(t *testing.T) {
+	ctx, repo, _, cleanup := setupRefreshTokensRepo(t)
+	defer cleanup()
+
+	userID := bson.NewObjectID()
+	expiresAt := time.Now().UTC().Add(testExpiresAt)
+
+	phone := auth.SessionMeta{
+		SessionID:  bson.NewObjectID(),
+		DeviceName: "phone",
+		UserAgent:  "phone-agent",
+		IP:         "203.0.113.7",
+	}
+	laptop := auth.SessionMeta{
+		SessionID:  bson.NewObjectID(),
+		DeviceName: "laptop",
+	}
+
+	require.NoError(t, repo.Create(ctx, userID, "phone-token", expiresAt, phone), msgShouldCreate)
+	require.NoError(t, repo.Create(ctx, userID, "laptop-token", expiresAt, laptop), msgShouldCreate)
+
+	tokens, err := repo.ListActiveForUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, laptop.SessionID, tokens[0].SessionID, "newest session should come first")
+	assert.Equal(t, "phone-agent", tokens[1].UserAgent)
+	assert.Equal(t, "203.0.113.7", tokens[1].IP)
+
+	phoneToken, err := repo.FindActive(ctx, "phone-token")
+	require.NoError(t, err, msgShouldFind)
+	require.NoError(t, repo.Touch(ctx, phoneToken.ID, phone))
+
+	tokens, err = repo.ListActiveForUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, phone.SessionID, tokens[0].SessionID, "a refresh moves the session first")
+
+	revoked, err := repo.RevokeSession(ctx, userID, phone.SessionID)
+	require.NoError(t, err, msgShouldRevoke)
+	assert.Equal(t, int64(1), revoked)
+
+	_, err = repo.FindActive(ctx, "phone-token")
+	assert.Equal(t, mongo.ErrNoDocuments, err, msgShouldNotFind)
+
+	active, err := repo.SessionActive(ctx, userID, phone.SessionID)
+	require.NoError(t, err)
+	assert.False(t, active, "a revoked session is no longer active")
+	active, err = repo.SessionActive(ctx, userID, laptop.SessionID)
+	require.NoError(t, err)
+	assert.True(t, active)
+	active, err = repo.SessionActive(ctx, bson.NewObjectID(), laptop.SessionID)
+	require.NoError(t, err)
+	assert.False(t, active, "sessions belong to their user")
+
+	_, err = repo.FindActive(ctx, "laptop-token")
+	assert.NoError(t, err, msgShouldFind)
+
+	revoked, err = repo.RevokeSession(ctx, bson.NewObjectID(), laptop.SessionID)
+	require.NoError(t, err)
+	assert.Zero(t, revoked, "other users must not revoke the session")
+}
@@ -0,0 +1,212 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/templates"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TemplatesRepo implements templates.Repository for MongoDB
+type TemplatesRepo struct {
+	collection *mongo.Collection
+}
+
+// NewTemplatesRepo creates a new templates repository
+func NewTemplatesRepo(parentCtx context.Context, db *mongo.Database) (*TemplatesRepo, error) {
+	collection := db.Collection("templates")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "recurrence.next_at", Value: 1}},
+			Options: options.Index().
+				SetName("recurrence_next_at_asc").
+				SetPartialFilterExpression(bson.M{"recurrence.next_at": bson.M{"$exists": true}}),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create templates indexes: %w", err)
+	}
+
+	return &TemplatesRepo{collection: collection}, nil
+}
+
+// Create inserts a template
+func (r *TemplatesRepo) Create(ctx context.Context, t *templates.Template) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, t); err != nil {
+		return fmt.Errorf("failed to insert template: %w", err)
+	}
+	return nil
+}
+
+// List returns the templates of a user sorted by name
+func (r *TemplatesRepo) List(ctx context.Context, userID bson.ObjectID) ([]*templates.Template, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find templates: %w", err)
+	}
+
+	result := []*templates.Template{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode templates: %w", err)
+	}
+	return result, nil
+}
+
+// Count returns how many templates a user has
+func (r *TemplatesRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count templates: %w", err)
+	}
+	return n, nil
+}
+
+// Find finds a template of a user
+func (r *TemplatesRepo) Find(ctx context.Context, userID, templateID bson.ObjectID) (*templates.Template, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var t templates.Template
+	if err := r.collection.FindOne(ctx, bson.M{"_id": templateID, "user_id": userID}).Decode(&t); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, templates.ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to find template: %w", err)
+	}
+	return &t, nil
+}
+
+// Update applies patch to a template of a user and returns the updated document
+func (r *TemplatesRepo) Update(ctx context.Context, userID, templateID bson.ObjectID, patch templates.UpdateTemplate) (*templates.Template, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.Title != nil {
+		set["title"] = *patch.Title
+	}
+	if patch.Body != nil {
+		set["body"] = *patch.Body
+	}
+	if patch.Color != nil {
+		set["color"] = *patch.Color
+	}
+	if patch.Format != nil {
+		set["format"] = *patch.Format
+	}
+	if patch.Recurrence != nil {
+		set["recurrence"] = *patch.Recurrence
+	}
+	update := bson.M{"$set": set}
+	if patch.ClearRecurrence {
+		update["$unset"] = bson.M{"recurrence": ""}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var t templates.Template
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": templateID, "user_id": userID}, update, opts).Decode(&t)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, templates.ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return &t, nil
+}
+
+// Delete deletes a template of a user
+func (r *TemplatesRepo) Delete(ctx context.Context, userID, templateID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": templateID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return templates.ErrTemplateNotFound
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes every template of a user
+func (r *TemplatesRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete templates: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Due returns up to limit templates of any user whose next occurrence is at
+// or before now, earliest first
+func (r *TemplatesRepo) Due(ctx context.Context, now time.Time, limit int) ([]*templates.Template, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "recurrence.next_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"recurrence.next_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due templates: %w", err)
+	}
+
+	var result []*templates.Template
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode templates: %w", err)
+	}
+	return result, nil
+}
+
+// Advance moves the schedule of a template from the occurrence from to next
+// if no one else has, recording last as its latest occurrence
+func (r *TemplatesRepo) Advance(ctx context.Context, templateID bson.ObjectID, from, last time.Time, next *time.Time) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"recurrence.last_at": last}
+	update := bson.M{"$set": set}
+	if next != nil {
+		set["recurrence.next_at"] = *next
+	} else {
+		update["$unset"] = bson.M{"recurrence.next_at": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": templateID, "recurrence.next_at": from}, update)
+	if err != nil {
+		return fmt.Errorf("failed to advance template: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return templates.ErrTemplateNotFound
+	}
+	return nil
+}
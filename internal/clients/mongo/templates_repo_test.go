@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/templates"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestTemplatesRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewTemplatesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	retro := &templates.Template{
+		ID: bson.NewObjectID(), UserID: userID, Name: "Retro", Title: "Retro {{week}}",
+		Recurrence: &templates.Recurrence{RRule: "FREQ=WEEKLY", Start: past, Timezone: "UTC", NextAt: &past},
+		CreatedAt:  now, UpdatedAt: now,
+	}
+	plain := &templates.Template{ID: bson.NewObjectID(), UserID: userID, Name: "Plain", Title: "Plain", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, retro))
+	require.NoError(t, repo.Create(ctx, plain))
+
+	list, err := repo.List(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "Plain", list[0].Name)
+
+	_, err = repo.Find(ctx, bson.NewObjectID(), retro.ID)
+	assert.ErrorIs(t, err, templates.ErrTemplateNotFound)
+
+	due, err := repo.Due(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, retro.ID, due[0].ID)
+
+	require.NoError(t, repo.Advance(ctx, retro.ID, past, past, &future))
+	assert.ErrorIs(t, repo.Advance(ctx, retro.ID, past, past, &future), templates.ErrTemplateNotFound, "an occurrence is claimed once")
+	due, err = repo.Due(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	updated, err := repo.Update(ctx, userID, retro.ID, templates.UpdateTemplate{ClearRecurrence: true})
+	require.NoError(t, err)
+	assert.Nil(t, updated.Recurrence)
+
+	n, err := repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"time"
 
 	"note-pulse/internal/logger"
+	"note-pulse/internal/services/admin"
 	"note-pulse/internal/services/auth"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -22,15 +25,22 @@ type UsersRepo struct {
 func NewUsersRepo(parentCtx context.Context, db *mongo.Database) (*UsersRepo, error) {
 	collection := db.Collection("users")
 
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "email", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// Account purger sweep
+		{
+			Keys:    bson.D{{Key: "deletion_requested_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 
 	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
 	defer cancel()
 
-	if _, err := collection.Indexes().CreateOne(ctx, indexModel); err != nil {
+	if _, err := collection.Indexes().CreateMany(ctx, indexModels); err != nil {
 		// Duplicate index definition is fine - ignore it.
 		if mongo.IsDuplicateKeyError(err) {
 			logger.L().Debug("users index already exists")
@@ -90,3 +100,198 @@ func (r *UsersRepo) FindByID(ctx context.Context, id bson.ObjectID) (*auth.User,
 	}
 	return &user, nil
 }
+
+// UpdatePassword replaces the password hash of a user
+func (r *UsersRepo) UpdatePassword(ctx context.Context, id bson.ObjectID, passwordHash string) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"password_hash": passwordHash,
+		"updated_at":    time.Now().UTC(),
+	}}
+	result, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
+
+// SetPendingEmail stores an email change awaiting confirmation
+func (r *UsersRepo) SetPendingEmail(ctx context.Context, id bson.ObjectID, email, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"pending_email":            email,
+		"pending_email_hash":       tokenHash,
+		"pending_email_expires_at": expiresAt,
+	}}
+	result, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
+
+// ConfirmEmail swaps in the pending email if tokenHash matches and has not expired
+func (r *UsersRepo) ConfirmEmail(ctx context.Context, id bson.ObjectID, tokenHash string) (*auth.User, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id":                      id,
+		"pending_email_hash":       tokenHash,
+		"pending_email_expires_at": bson.M{"$gt": now},
+	}
+	// Pipeline update so the new email can be copied from pending_email
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"email": "$pending_email", "updated_at": now}}},
+		{{Key: "$unset", Value: bson.A{"pending_email", "pending_email_hash", "pending_email_expires_at"}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user auth.User
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, auth.ErrUserNotFound
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, auth.ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to confirm email: %w", err)
+	}
+	return &user, nil
+}
+
+// MarkForDeletion flags the user for the account purger
+func (r *UsersRepo) MarkForDeletion(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deletion_requested_at": ExistsFalse}
+	update := bson.M{"$set": bson.M{"deletion_requested_at": at}}
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to mark user for deletion: %w", err)
+	}
+	return nil
+}
+
+// ListPendingDeletion returns users flagged for deletion, oldest first
+func (r *UsersRepo) ListPendingDeletion(ctx context.Context, limit int) ([]*auth.User, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	filter := bson.M{"deletion_requested_at": bson.M{"$exists": true}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deletion_requested_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users pending deletion: %w", err)
+	}
+
+	var users []*auth.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users pending deletion: %w", err)
+	}
+	return users, nil
+}
+
+// Delete removes the user document
+func (r *UsersRepo) Delete(ctx context.Context, id bson.ObjectID) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// Search lists users matching filter, newest first, with the total match count
+func (r *UsersRepo) Search(ctx context.Context, filter admin.UserFilter, limit, offset int) ([]*auth.User, int64, error) {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Q != "" {
+		query["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Q), "$options": "i"}
+	}
+	switch filter.Role {
+	case "":
+	case auth.RoleUser:
+		// Users created before roles existed have no role field
+		query["role"] = bson.M{"$in": bson.A{auth.RoleUser, nil}}
+	default:
+		query["role"] = filter.Role
+	}
+	if filter.Disabled != nil {
+		query["disabled_at"] = bson.M{"$exists": *filter.Disabled}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	users := make([]*auth.User, 0, limit)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode users: %w", err)
+	}
+	return users, total, nil
+}
+
+// SetDisabled disables the user at disabledAt, or re-enables them when nil
+func (r *UsersRepo) SetDisabled(ctx context.Context, id bson.ObjectID, disabledAt *time.Time) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"disabled_at": ""}, "$set": bson.M{"updated_at": time.Now().UTC()}}
+	if disabledAt != nil {
+		update = bson.M{"$set": bson.M{"disabled_at": *disabledAt, "updated_at": time.Now().UTC()}}
+	}
+
+	result, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("failed to update disabled flag: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
+
+// SetRole changes the role of a user
+func (r *UsersRepo) SetRole(ctx context.Context, id bson.ObjectID, role string) error {
+	ctx, cancel := WithRepoTimeout(ctx, OpTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now().UTC()}}
+	result, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/views"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ViewsRepo implements views.Repository for MongoDB
+type ViewsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewViewsRepo creates a new saved views repository
+func NewViewsRepo(parentCtx context.Context, db *mongo.Database) (*ViewsRepo, error) {
+	collection := db.Collection("views")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create views indexes: %w", err)
+	}
+
+	return &ViewsRepo{collection: collection}, nil
+}
+
+// Create inserts a view
+func (r *ViewsRepo) Create(ctx context.Context, v *views.View) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, v); err != nil {
+		return fmt.Errorf("failed to insert view: %w", err)
+	}
+	return nil
+}
+
+// List returns the views of a user sorted by name
+func (r *ViewsRepo) List(ctx context.Context, userID bson.ObjectID) ([]*views.View, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find views: %w", err)
+	}
+
+	result := []*views.View{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode views: %w", err)
+	}
+	return result, nil
+}
+
+// Count returns how many views a user has
+func (r *ViewsRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count views: %w", err)
+	}
+	return n, nil
+}
+
+// Find finds a view of a user
+func (r *ViewsRepo) Find(ctx context.Context, userID, viewID bson.ObjectID) (*views.View, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var v views.View
+	if err := r.collection.FindOne(ctx, bson.M{"_id": viewID, "user_id": userID}).Decode(&v); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, views.ErrViewNotFound
+		}
+		return nil, fmt.Errorf("failed to find view: %w", err)
+	}
+	return &v, nil
+}
+
+// Update applies patch to a view of a user and returns the updated document
+func (r *ViewsRepo) Update(ctx context.Context, userID, viewID bson.ObjectID, patch views.UpdateView) (*views.View, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.Request != nil {
+		set["request"] = *patch.Request
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var v views.View
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": viewID, "user_id": userID}, bson.M{"$set": set}, opts).Decode(&v)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, views.ErrViewNotFound
+		}
+		return nil, fmt.Errorf("failed to update view: %w", err)
+	}
+	return &v, nil
+}
+
+// Delete deletes a view of a user
+func (r *ViewsRepo) Delete(ctx context.Context, userID, viewID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": viewID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return views.ErrViewNotFound
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes every view of a user
+func (r *ViewsRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete views: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/services/views"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestViewsRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewViewsRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	view := &views.View{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		Name:      "Urgent",
+		Request:   notes.ListNotesRequest{Q: "color:#FF0000 -done", Sort: "updated_at", Order: "desc"},
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, repo.Create(ctx, view))
+
+	found, err := repo.Find(ctx, userID, view.ID)
+	require.NoError(t, err)
+	assert.Equal(t, view.Request, found.Request, "the list request survives a round trip")
+
+	_, err = repo.Find(ctx, otherID, view.ID)
+	assert.ErrorIs(t, err, views.ErrViewNotFound)
+
+	name := "Today"
+	updated, err := repo.Update(ctx, userID, view.ID, views.UpdateView{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	assert.Equal(t, view.Request, updated.Request)
+
+	n, err := repo.Count(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.ErrorIs(t, repo.Delete(ctx, otherID, view.ID), views.ErrViewNotFound)
+	require.NoError(t, repo.Delete(ctx, userID, view.ID))
+
+	list, err := repo.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,201 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/webhooks"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WebhookDeliveriesRepo implements webhooks.DeliveryLog for MongoDB. Entries
+// expire at their expires_at.
+type WebhookDeliveriesRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWebhookDeliveriesRepo creates a new webhook deliveries repository
+func NewWebhookDeliveriesRepo(parentCtx context.Context, db *mongo.Database) (*WebhookDeliveriesRepo, error) {
+	collection := db.Collection("webhook_deliveries")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().
+				SetName("webhook_event_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"event_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "next_at", Value: 1}},
+			Options: options.Index().
+				SetName("pending_next_at_asc").
+				SetPartialFilterExpression(bson.M{"status": webhooks.StatusPending}),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create webhook deliveries indexes: %w", err)
+	}
+
+	return &WebhookDeliveriesRepo{collection: collection}, nil
+}
+
+// Create inserts deliveries. Those of an event the webhook already has a
+// delivery of are skipped, so that recording an event again is harmless.
+func (r *WebhookDeliveriesRepo) Create(ctx context.Context, deliveries ...*webhooks.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	docs := make([]any, len(deliveries))
+	for i, d := range deliveries {
+		docs[i] = d
+	}
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		return fmt.Errorf("failed to insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// onlyDuplicates reports whether every write of a failed bulk insert failed
+// on a duplicate key
+func onlyDuplicates(err error) bool {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+		return false
+	}
+	for _, we := range bwe.WriteErrors {
+		if !mongo.IsDuplicateKeyError(we) {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns up to limit deliveries of a webhook, newest first
+func (r *WebhookDeliveriesRepo) List(ctx context.Context, webhookID bson.ObjectID, limit int) ([]*webhooks.Delivery, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"webhook_id": webhookID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+
+	result := []*webhooks.Delivery{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+	return result, nil
+}
+
+// Find finds a delivery of a webhook
+func (r *WebhookDeliveriesRepo) Find(ctx context.Context, webhookID, deliveryID bson.ObjectID) (*webhooks.Delivery, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var d webhooks.Delivery
+	if err := r.collection.FindOne(ctx, bson.M{"_id": deliveryID, "webhook_id": webhookID}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, webhooks.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// Claim takes the pending delivery due first at now, counting an attempt
+// and moving its next_at to until so that no other sweep takes it
+func (r *WebhookDeliveriesRepo) Claim(ctx context.Context, now, until time.Time) (*webhooks.Delivery, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{"status": webhooks.StatusPending, "next_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set": bson.M{"next_at": until, "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var d webhooks.Delivery
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// Finish records the outcome of an attempt on a delivery
+func (r *WebhookDeliveriesRepo) Finish(ctx context.Context, deliveryID bson.ObjectID, a webhooks.Attempt) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"status": a.Status, "updated_at": time.Now().UTC()}
+	unset := bson.M{}
+	if a.NextAt != nil {
+		set["next_at"] = *a.NextAt
+	} else {
+		unset["next_at"] = ""
+	}
+	if a.ResponseStatus != 0 {
+		set["response_status"] = a.ResponseStatus
+	} else {
+		unset["response_status"] = ""
+	}
+	if a.Error != "" {
+		set["error"] = a.Error
+	} else {
+		unset["error"] = ""
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": deliveryID}, bson.M{"$set": set, "$unset": unset}); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeleteForWebhook deletes the deliveries of a webhook
+func (r *WebhookDeliveriesRepo) DeleteForWebhook(ctx context.Context, webhookID bson.ObjectID) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"webhook_id": webhookID})
+}
+
+// DeleteAllForUser deletes the deliveries of every webhook of a user
+func (r *WebhookDeliveriesRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"user_id": userID})
+}
+
+func (r *WebhookDeliveriesRepo) deleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,136 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestWebhookDeliveriesRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewWebhookDeliveriesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	webhookID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	delivery := func(nextAt time.Time) *webhooks.Delivery {
+		return &webhooks.Delivery{
+			ID:        bson.NewObjectID(),
+			WebhookID: webhookID,
+			UserID:    userID,
+			Event:     "created",
+			Payload:   `{}`,
+			Status:    webhooks.StatusPending,
+			NextAt:    &nextAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+	first, later := delivery(now.Add(-time.Minute)), delivery(now.Add(time.Minute))
+	require.NoError(t, repo.Create(ctx, first, later))
+	require.NoError(t, repo.Create(ctx))
+
+	claimed, err := repo.Claim(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, first.ID, claimed.ID)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	claimed, err = repo.Claim(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a claimed delivery is not due again until its claim runs out")
+
+	require.NoError(t, repo.Finish(ctx, first.ID, webhooks.Attempt{Status: webhooks.StatusDelivered, ResponseStatus: 200}))
+	got, err := repo.Find(ctx, webhookID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.StatusDelivered, got.Status)
+	assert.Equal(t, 200, got.ResponseStatus)
+	assert.Nil(t, got.NextAt)
+
+	claimed, err = repo.Claim(ctx, now.Add(2*time.Minute), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, later.ID, claimed.ID, "finished deliveries are never claimed")
+
+	_, err = repo.Find(ctx, bson.NewObjectID(), first.ID)
+	assert.ErrorIs(t, err, webhooks.ErrDeliveryNotFound)
+
+	list, err := repo.List(ctx, webhookID, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, later.ID, list[0].ID)
+
+	n, err := repo.DeleteForWebhook(ctx, webhookID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestWebhookDeliveriesRepoSkipsRecordedEvents(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewWebhookDeliveriesRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	webhookID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	eventID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	delivery := func(webhookID bson.ObjectID, eventID *bson.ObjectID) *webhooks.Delivery {
+		return &webhooks.Delivery{
+			ID:        bson.NewObjectID(),
+			WebhookID: webhookID,
+			UserID:    userID,
+			Event:     "updated",
+			Payload:   `{}`,
+			Status:    webhooks.StatusPending,
+			NextAt:    &now,
+			EventID:   eventID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	require.NoError(t, repo.Create(ctx, delivery(webhookID, &eventID)))
+	// Recording the event again stores only the webhook that lacked it
+	require.NoError(t, repo.Create(ctx, delivery(webhookID, &eventID), delivery(otherID, &eventID)))
+	// Redeliveries carry no event
+	require.NoError(t, repo.Create(ctx, delivery(webhookID, nil), delivery(webhookID, nil)))
+
+	list, err := repo.List(ctx, webhookID, 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+	list, err = repo.List(ctx, otherID, 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestOnlyDuplicates(t *testing.T) {
+	dup := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 11000}}
+	other := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 121}}
+
+	assert.True(t, onlyDuplicates(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{dup, dup}}))
+	assert.False(t, onlyDuplicates(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{dup, other}}))
+	assert.False(t, onlyDuplicates(mongo.BulkWriteException{
+		WriteErrors:       []mongo.BulkWriteError{dup},
+		WriteConcernError: &mongo.WriteConcernError{Code: 64},
+	}), "a write concern error may lose the inserted deliveries")
+	assert.False(t, onlyDuplicates(context.DeadlineExceeded))
+}
@@ -0,0 +1,241 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/webhooks"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WebhooksRepo implements webhooks.Repository for MongoDB
+type WebhooksRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWebhooksRepo creates a new webhooks repository
+func NewWebhooksRepo(parentCtx context.Context, db *mongo.Database) (*WebhooksRepo, error) {
+	collection := db.Collection("webhooks")
+
+	indexes := []mongo.IndexModel{
+		// Listing a user's webhooks and finding those of personal notes
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "workspace_id", Value: 1}, {Key: "active", Value: 1}}},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "active", Value: 1}}, Options: options.Index().SetSparse(true)},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create webhooks indexes: %w", err)
+	}
+
+	return &WebhooksRepo{collection: collection}, nil
+}
+
+// Create inserts a webhook
+func (r *WebhooksRepo) Create(ctx context.Context, w *webhooks.Webhook) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, w); err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return nil
+}
+
+// List returns the webhooks of a user, oldest first
+func (r *WebhooksRepo) List(ctx context.Context, userID bson.ObjectID) ([]*webhooks.Webhook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhooks: %w", err)
+	}
+
+	result := []*webhooks.Webhook{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+	return result, nil
+}
+
+// Count returns how many webhooks a user has
+func (r *WebhooksRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	return n, nil
+}
+
+// Find finds a webhook of a user
+func (r *WebhooksRepo) Find(ctx context.Context, userID, webhookID bson.ObjectID) (*webhooks.Webhook, error) {
+	return r.findOne(ctx, bson.M{"_id": webhookID, "user_id": userID})
+}
+
+// Get finds a webhook of any user
+func (r *WebhooksRepo) Get(ctx context.Context, webhookID bson.ObjectID) (*webhooks.Webhook, error) {
+	return r.findOne(ctx, bson.M{"_id": webhookID})
+}
+
+func (r *WebhooksRepo) findOne(ctx context.Context, filter bson.M) (*webhooks.Webhook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var w webhooks.Webhook
+	if err := r.collection.FindOne(ctx, filter).Decode(&w); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, webhooks.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	return &w, nil
+}
+
+// Update applies patch to a webhook of a user and returns the updated document
+func (r *WebhooksRepo) Update(ctx context.Context, userID, webhookID bson.ObjectID, patch webhooks.UpdateWebhook) (*webhooks.Webhook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now().UTC()}
+	unset := bson.M{}
+	if patch.URL != nil {
+		set["url"] = *patch.URL
+	}
+	if patch.Secret != nil {
+		set["secret"] = *patch.Secret
+	}
+	if patch.Events != nil {
+		if len(*patch.Events) > 0 {
+			set["events"] = *patch.Events
+		} else {
+			unset["events"] = ""
+		}
+	}
+	if patch.Active != nil {
+		set["active"] = *patch.Active
+		set["failures"] = 0
+		unset["disabled_at"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var w webhooks.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": webhookID, "user_id": userID}, update, opts).Decode(&w)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, webhooks.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return &w, nil
+}
+
+// Delete deletes a webhook of a user
+func (r *WebhooksRepo) Delete(ctx context.Context, userID, webhookID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": webhookID, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return webhooks.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes every webhook of a user
+func (r *WebhooksRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhooks: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Subscribed returns the active webhooks of a workspace, or of the personal
+// notes of userID, that list event or no events at all
+func (r *WebhooksRepo) Subscribed(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, event string) ([]*webhooks.Webhook, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"active": true,
+		"$or":    bson.A{bson.M{"events": event}, bson.M{"events": bson.M{"$exists": false}}},
+	}
+	if workspaceID != nil {
+		filter["workspace_id"] = *workspaceID
+	} else {
+		filter["user_id"] = userID
+		filter["workspace_id"] = nil
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhooks: %w", err)
+	}
+	var result []*webhooks.Webhook
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+	return result, nil
+}
+
+// RecordSuccess clears the failures of a webhook
+func (r *WebhooksRepo) RecordSuccess(ctx context.Context, webhookID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": webhookID}, bson.M{"$set": bson.M{"failures": 0}}); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt on an active webhook and disables
+// it once disableAfter attempts in a row have failed
+func (r *WebhooksRepo) RecordFailure(ctx context.Context, webhookID bson.ObjectID, disableAfter int, now time.Time) (bool, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var w webhooks.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": webhookID, "active": true}, bson.M{"$inc": bson.M{"failures": 1}}, opts).Decode(&w)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if w.Failures < disableAfter {
+		return false, nil
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": webhookID, "active": true},
+		bson.M{"$set": bson.M{"active": false, "disabled_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to disable webhook: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,95 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestWebhooksRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewWebhooksRepo(ctx, db)
+	require.NoError(t, err)
+
+	userID := bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	all := &webhooks.Webhook{ID: bson.NewObjectID(), UserID: userID, URL: "https://example.com/all", Secret: "s", Active: true, CreatedAt: now, UpdatedAt: now}
+	created := &webhooks.Webhook{ID: bson.NewObjectID(), UserID: userID, URL: "https://example.com/created", Secret: "s", Events: []string{"created"}, Active: true, CreatedAt: now, UpdatedAt: now}
+	shared := &webhooks.Webhook{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, URL: "https://example.com/shared", Secret: "s", Active: true, CreatedAt: now, UpdatedAt: now}
+	for _, w := range []*webhooks.Webhook{all, created, shared} {
+		require.NoError(t, repo.Create(ctx, w))
+	}
+
+	count, err := repo.Count(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	subscribed, err := repo.Subscribed(ctx, userID, nil, "updated")
+	require.NoError(t, err)
+	require.Len(t, subscribed, 1)
+	assert.Equal(t, all.ID, subscribed[0].ID)
+
+	subscribed, err = repo.Subscribed(ctx, userID, nil, "created")
+	require.NoError(t, err)
+	assert.Len(t, subscribed, 2)
+
+	subscribed, err = repo.Subscribed(ctx, bson.NewObjectID(), &workspaceID, "created")
+	require.NoError(t, err)
+	require.Len(t, subscribed, 1, "workspace webhooks see every member's notes")
+	assert.Equal(t, shared.ID, subscribed[0].ID)
+
+	_, err = repo.Find(ctx, bson.NewObjectID(), all.ID)
+	assert.ErrorIs(t, err, webhooks.ErrWebhookNotFound)
+
+	// Failures disable the webhook once, at the threshold
+	disabled, err := repo.RecordFailure(ctx, created.ID, 2, now)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+	disabled, err = repo.RecordFailure(ctx, created.ID, 2, now)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	disabled, err = repo.RecordFailure(ctx, created.ID, 2, now)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+
+	got, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Active)
+	assert.Equal(t, 2, got.Failures)
+	require.NotNil(t, got.DisabledAt)
+
+	subscribed, err = repo.Subscribed(ctx, userID, nil, "created")
+	require.NoError(t, err)
+	assert.Len(t, subscribed, 1)
+
+	active := true
+	events := []string{}
+	got, err = repo.Update(ctx, userID, created.ID, webhooks.UpdateWebhook{Active: &active, Events: &events})
+	require.NoError(t, err)
+	assert.True(t, got.Active)
+	assert.Zero(t, got.Failures)
+	assert.Nil(t, got.DisabledAt)
+	assert.Empty(t, got.Events)
+
+	require.NoError(t, repo.RecordSuccess(ctx, created.ID))
+
+	hooks, err := repo.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 3)
+
+	require.NoError(t, repo.Delete(ctx, userID, all.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, userID, all.ID), webhooks.ErrWebhookNotFound)
+	n, err := repo.DeleteAllForUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
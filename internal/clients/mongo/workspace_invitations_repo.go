@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/workspaces"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WorkspaceInvitationsRepo implements workspaces.InvitationsRepo for MongoDB
+type WorkspaceInvitationsRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWorkspaceInvitationsRepo creates a new workspace invitations repository
+func NewWorkspaceInvitationsRepo(parentCtx context.Context, db *mongo.Database) (*WorkspaceInvitationsRepo, error) {
+	collection := db.Collection("workspace_invitations")
+
+	indexes := []mongo.IndexModel{
+		// Expired invitations disappear on their own
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create workspace_invitations indexes: %w", err)
+	}
+
+	return &WorkspaceInvitationsRepo{collection: collection}, nil
+}
+
+// Create inserts an invitation
+func (r *WorkspaceInvitationsRepo) Create(ctx context.Context, inv *workspaces.Invitation) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, inv); err != nil {
+		return fmt.Errorf("failed to insert workspace invitation: %w", err)
+	}
+	return nil
+}
+
+// FindByTokenHash finds an invitation by the hash of its token
+func (r *WorkspaceInvitationsRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*workspaces.Invitation, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var inv workspaces.Invitation
+	if err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&inv); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, workspaces.ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to find workspace invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+// ListByWorkspace lists the pending invitations of a workspace, newest first
+func (r *WorkspaceInvitationsRepo) ListByWorkspace(ctx context.Context, workspaceID bson.ObjectID) ([]*workspaces.Invitation, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"workspace_id": workspaceID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workspace invitations: %w", err)
+	}
+
+	result := []*workspaces.Invitation{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace invitations: %w", err)
+	}
+	return result, nil
+}
+
+// Delete deletes an invitation of a workspace
+func (r *WorkspaceInvitationsRepo) Delete(ctx context.Context, workspaceID, id bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "workspace_id": workspaceID})
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace invitation: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return workspaces.ErrInvitationNotFound
+	}
+	return nil
+}
+
+// DeleteAllForWorkspace deletes every invitation of a workspace
+func (r *WorkspaceInvitationsRepo) DeleteAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"workspace_id": workspaceID}); err != nil {
+		return fmt.Errorf("failed to delete workspace invitations: %w", err)
+	}
+	return nil
+}
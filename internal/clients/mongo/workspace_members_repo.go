@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-pulse/internal/services/workspaces"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WorkspaceMembersRepo implements workspaces.MembersRepo for MongoDB
+type WorkspaceMembersRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWorkspaceMembersRepo creates a new workspace members repository
+func NewWorkspaceMembersRepo(parentCtx context.Context, db *mongo.Database) (*WorkspaceMembersRepo, error) {
+	collection := db.Collection("workspace_members")
+
+	indexes := []mongo.IndexModel{
+		// One membership per user and workspace; also serves access checks
+		{
+			Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// Listing a user's workspaces
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create workspace_members indexes: %w", err)
+	}
+
+	return &WorkspaceMembersRepo{collection: collection}, nil
+}
+
+// Add inserts a membership
+func (r *WorkspaceMembersRepo) Add(ctx context.Context, m *workspaces.Member) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return workspaces.ErrAlreadyMember
+		}
+		return fmt.Errorf("failed to insert workspace member: %w", err)
+	}
+	return nil
+}
+
+// Find returns the membership of a user in a workspace
+func (r *WorkspaceMembersRepo) Find(ctx context.Context, workspaceID, userID bson.ObjectID) (*workspaces.Member, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var m workspaces.Member
+	if err := r.collection.FindOne(ctx, memberFilter(workspaceID, userID)).Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, workspaces.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to find workspace member: %w", err)
+	}
+	return &m, nil
+}
+
+// ListByWorkspace lists the members of a workspace in joining order
+func (r *WorkspaceMembersRepo) ListByWorkspace(ctx context.Context, workspaceID bson.ObjectID) ([]*workspaces.Member, error) {
+	return r.find(ctx, bson.M{"workspace_id": workspaceID})
+}
+
+// ListByUser lists the memberships of a user
+func (r *WorkspaceMembersRepo) ListByUser(ctx context.Context, userID bson.ObjectID) ([]*workspaces.Member, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *WorkspaceMembersRepo) find(ctx context.Context, filter bson.M) ([]*workspaces.Member, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "joined_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workspace members: %w", err)
+	}
+
+	result := []*workspaces.Member{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace members: %w", err)
+	}
+	return result, nil
+}
+
+// SetRole changes the role of a member
+func (r *WorkspaceMembersRepo) SetRole(ctx context.Context, workspaceID, userID bson.ObjectID, role string) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, memberFilter(workspaceID, userID), bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return fmt.Errorf("failed to set workspace role: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return workspaces.ErrMemberNotFound
+	}
+	return nil
+}
+
+// Remove deletes a membership
+func (r *WorkspaceMembersRepo) Remove(ctx context.Context, workspaceID, userID bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, memberFilter(workspaceID, userID))
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return workspaces.ErrMemberNotFound
+	}
+	return nil
+}
+
+// RemoveAllForWorkspace deletes every membership of a workspace
+func (r *WorkspaceMembersRepo) RemoveAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) error {
+	return r.removeMany(ctx, bson.M{"workspace_id": workspaceID})
+}
+
+// RemoveAllForUser deletes every membership of a user
+func (r *WorkspaceMembersRepo) RemoveAllForUser(ctx context.Context, userID bson.ObjectID) error {
+	return r.removeMany(ctx, bson.M{"user_id": userID})
+}
+
+func (r *WorkspaceMembersRepo) removeMany(ctx context.Context, filter bson.M) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to remove workspace members: %w", err)
+	}
+	return nil
+}
+
+func memberFilter(workspaceID, userID bson.ObjectID) bson.M {
+	return bson.M{"workspace_id": workspaceID, "user_id": userID}
+}
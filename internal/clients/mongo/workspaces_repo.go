@@ -0,0 +1,126 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/services/workspaces"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WorkspacesRepo implements workspaces.WorkspacesRepo for MongoDB
+type WorkspacesRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWorkspacesRepo creates a new workspaces repository
+func NewWorkspacesRepo(parentCtx context.Context, db *mongo.Database) (*WorkspacesRepo, error) {
+	collection := db.Collection("workspaces")
+
+	indexes := []mongo.IndexModel{
+		// Purging an account looks up the workspaces it owns
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, OpTimeout)
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create workspaces indexes: %w", err)
+	}
+
+	return &WorkspacesRepo{collection: collection}, nil
+}
+
+// Create inserts a new workspace
+func (r *WorkspacesRepo) Create(ctx context.Context, ws *workspaces.Workspace) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, ws); err != nil {
+		return fmt.Errorf("failed to insert workspace: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds a workspace by ID
+func (r *WorkspacesRepo) FindByID(ctx context.Context, id bson.ObjectID) (*workspaces.Workspace, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	var ws workspaces.Workspace
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&ws); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, workspaces.ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to find workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// FindByIDs returns the existing workspaces among ids, sorted by name
+func (r *WorkspacesRepo) FindByIDs(ctx context.Context, ids []bson.ObjectID) ([]*workspaces.Workspace, error) {
+	if len(ids) == 0 {
+		return []*workspaces.Workspace{}, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// ListOwnedBy returns the workspaces owned by a user
+func (r *WorkspacesRepo) ListOwnedBy(ctx context.Context, ownerID bson.ObjectID) ([]*workspaces.Workspace, error) {
+	return r.find(ctx, bson.M{"owner_id": ownerID})
+}
+
+func (r *WorkspacesRepo) find(ctx context.Context, filter bson.M) ([]*workspaces.Workspace, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workspaces: %w", err)
+	}
+
+	result := []*workspaces.Workspace{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode workspaces: %w", err)
+	}
+	return result, nil
+}
+
+// Rename sets the name of a workspace and returns the updated document
+func (r *WorkspacesRepo) Rename(ctx context.Context, id bson.ObjectID, name string) (*workspaces.Workspace, error) {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"name": name, "updated_at": time.Now().UTC()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ws workspaces.Workspace
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&ws); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, workspaces.ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to rename workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// Delete deletes a workspace document
+func (r *WorkspacesRepo) Delete(ctx context.Context, id bson.ObjectID) error {
+	ctx, cancel := repoCtx(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return workspaces.ErrWorkspaceNotFound
+	}
+	return nil
+}
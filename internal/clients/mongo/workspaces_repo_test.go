@@ -0,0 +1,105 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/services/workspaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestWorkspaceMembersRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewWorkspaceMembersRepo(ctx, db)
+	require.NoError(t, err)
+
+	workspaceID, userID := bson.NewObjectID(), bson.NewObjectID()
+	member := &workspaces.Member{WorkspaceID: workspaceID, UserID: userID, Role: workspaces.RoleMember, JoinedAt: time.Now().UTC()}
+
+	require.NoError(t, repo.Add(ctx, member))
+	assert.ErrorIs(t, repo.Add(ctx, member), workspaces.ErrAlreadyMember)
+
+	require.NoError(t, repo.SetRole(ctx, workspaceID, userID, workspaces.RoleViewer))
+	found, err := repo.Find(ctx, workspaceID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, workspaces.RoleViewer, found.Role)
+
+	byUser, err := repo.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, byUser, 1)
+
+	require.NoError(t, repo.Remove(ctx, workspaceID, userID))
+	_, err = repo.Find(ctx, workspaceID, userID)
+	assert.ErrorIs(t, err, workspaces.ErrMemberNotFound)
+}
+
+func TestWorkspaceInvitationsRepo(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewWorkspaceInvitationsRepo(ctx, db)
+	require.NoError(t, err)
+
+	workspaceID := bson.NewObjectID()
+	inv := &workspaces.Invitation{
+		ID:          bson.NewObjectID(),
+		WorkspaceID: workspaceID,
+		Email:       "teammate@example.com",
+		Role:        workspaces.RoleMember,
+		TokenHash:   "hash",
+		CreatedAt:   time.Now().UTC(),
+		ExpiresAt:   time.Now().UTC().Add(time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, inv))
+
+	found, err := repo.FindByTokenHash(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, inv.ID, found.ID)
+
+	assert.ErrorIs(t, repo.Delete(ctx, bson.NewObjectID(), inv.ID), workspaces.ErrInvitationNotFound, "scoped to the workspace")
+	require.NoError(t, repo.Delete(ctx, workspaceID, inv.ID))
+	_, err = repo.FindByTokenHash(ctx, "hash")
+	assert.ErrorIs(t, err, workspaces.ErrInvitationNotFound)
+}
+
+func TestNotesRepoWorkspaceScope(t *testing.T) {
+	_, db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo, err := NewNotesRepo(ctx, db)
+	require.NoError(t, err)
+
+	author := bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	require.NoError(t, repo.Create(ctx, &notes.Note{ID: bson.NewObjectID(), UserID: author, Title: "personal"}))
+	require.NoError(t, repo.Create(ctx, &notes.Note{ID: bson.NewObjectID(), UserID: author, WorkspaceID: &workspaceID, Title: "shared"}))
+
+	personal, total, _, err := repo.List(ctx, author, notes.ListNotesRequest{Limit: 10}, -1)
+	require.NoError(t, err)
+	require.Len(t, personal, 1)
+	assert.Equal(t, "personal", personal[0].Title)
+	assert.Equal(t, int64(1), total)
+
+	shared, _, _, err := repo.List(ctx, bson.NewObjectID(), notes.ListNotesRequest{Limit: 10, WorkspaceID: workspaceID.Hex()}, -1)
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	assert.Equal(t, "shared", shared[0].Title)
+
+	deleted, err := repo.DeleteAllForUser(ctx, author)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "shared notes outlive their author")
+
+	deleted, err = repo.DeleteAllForWorkspace(ctx, workspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
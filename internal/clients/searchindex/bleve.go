@@ -0,0 +1,391 @@
+// Package searchindex implements notes.SearchIndex with an embedded Bleve
+// index stored next to the server.
+package searchindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"note-pulse/internal/config"
+	"note-pulse/internal/services/notes"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/analysis/lang/da"
+	"github.com/blevesearch/bleve/v2/analysis/lang/de"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fi"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	"github.com/blevesearch/bleve/v2/analysis/lang/no"
+	"github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Languages maps SEARCH_LANGUAGE values to their Bleve analyzers. Language
+// analyzers stem words and drop stop words; "standard" only lower-cases.
+var Languages = map[string]string{
+	"standard": standard.Name,
+	"cjk":      cjk.AnalyzerName,
+	"da":       da.AnalyzerName,
+	"de":       de.AnalyzerName,
+	"en":       en.AnalyzerName,
+	"es":       es.AnalyzerName,
+	"fi":       fi.AnalyzerName,
+	"fr":       fr.AnalyzerName,
+	"it":       it.AnalyzerName,
+	"nl":       nl.AnalyzerName,
+	"no":       no.AnalyzerName,
+	"pt":       pt.AnalyzerName,
+	"ru":       ru.AnalyzerName,
+	"sv":       sv.AnalyzerName,
+}
+
+// ErrUnknownLanguage is returned for a SEARCH_LANGUAGE without an analyzer
+var ErrUnknownLanguage = errors.New("unknown search language")
+
+// ErrClosed is returned by an Index after Close
+var ErrClosed = errors.New("search index is closed")
+
+// lockTimeout bounds the wait for an index another process holds open
+const lockTimeout = "2s"
+
+// titleBoost ranks title matches above body matches
+const titleBoost = 2
+
+// removeBatch bounds the notes RemoveUser finds and deletes at a time
+const removeBatch = 1000
+
+// Options tune how the index analyzes and matches text
+type Options struct {
+	// Language is a key of Languages. Changing it rebuilds the index.
+	Language string
+	// Prefix lets every word also match the words it starts
+	Prefix bool
+	// Fuzziness is the edit distance a word may be off by, 0 to 2
+	Fuzziness int
+}
+
+// Index is an on-disk Bleve index of note titles and bodies
+type Index struct {
+	path string
+	opts Options
+
+	mu    sync.RWMutex
+	idx   bleve.Index
+	fresh bool
+}
+
+// document is what the index stores for a note
+type document struct {
+	// Scope is the workspace the note lists in: "w:<id>" for shared
+	// workspaces and "u:<author id>" for personal notes
+	Scope string `json:"scope"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Init opens the index configured by cfg. It returns nil when notes are
+// searched through Mongo.
+func Init(cfg config.Config) (*Index, error) {
+	if cfg.SearchBackend != config.SearchBackendEmbedded {
+		return nil, nil
+	}
+	return Open(cfg.SearchIndexPath, Options{
+		Language:  cfg.SearchLanguage,
+		Prefix:    cfg.SearchPrefix,
+		Fuzziness: cfg.SearchFuzziness,
+	})
+}
+
+// Open opens the index at path, creating it when missing. An index built for
+// another language is emptied; Fresh reports that it needs a reindex.
+func Open(path string, opts Options) (*Index, error) {
+	if _, ok := Languages[opts.Language]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, opts.Language)
+	}
+
+	i := &Index{path: path, opts: opts}
+	idx, err := bleve.OpenUsing(path, runtimeConfig())
+	switch {
+	case errors.Is(err, bleve.ErrorIndexPathDoesNotExist):
+		return i, i.create()
+	case err != nil:
+		return nil, fmt.Errorf("failed to open search index %s: %w", path, err)
+	}
+
+	i.idx = idx
+	if impl, ok := idx.Mapping().(*mapping.IndexMappingImpl); !ok || impl.DefaultAnalyzer != Languages[opts.Language] {
+		if err := i.Reset(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
+
+func runtimeConfig() map[string]any {
+	return map[string]any{"bolt_timeout": lockTimeout}
+}
+
+// create builds an empty index at i.path
+func (i *Index) create() error {
+	if err := os.MkdirAll(filepath.Dir(i.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create search index directory: %w", err)
+	}
+	idx, err := bleve.NewUsing(i.path, newMapping(Languages[i.opts.Language]),
+		bleve.Config.DefaultIndexType, bleve.Config.DefaultKVStore, runtimeConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create search index %s: %w", i.path, err)
+	}
+	i.idx = idx
+	i.fresh = true
+	return nil
+}
+
+func newMapping(analyzer string) *mapping.IndexMappingImpl {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = analyzer
+	text.Store = false
+
+	scope := bleve.NewKeywordFieldMapping()
+	scope.Store = false
+	scope.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt("scope", scope)
+	doc.AddFieldMappingsAt("title", text)
+	doc.AddFieldMappingsAt("body", text)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = analyzer
+	return m
+}
+
+// Fresh reports whether the index was created or emptied when it opened and
+// so holds none of the existing notes yet
+func (i *Index) Fresh() bool {
+	return i.fresh
+}
+
+// Close closes the index. Closing it again is a no-op.
+func (i *Index) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.idx == nil {
+		return nil
+	}
+	err := i.idx.Close()
+	i.idx = nil
+	return err
+}
+
+// Index adds or replaces notes
+func (i *Index) Index(ctx context.Context, list ...*notes.Note) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.idx == nil {
+		return ErrClosed
+	}
+
+	batch := i.idx.NewBatch()
+	for _, n := range list {
+		doc := document{Scope: scope(n.UserID, n.WorkspaceID), Title: n.Title, Body: n.Body}
+		if err := batch.Index(n.ID.Hex(), doc); err != nil {
+			return fmt.Errorf("failed to index note %s: %w", n.ID.Hex(), err)
+		}
+	}
+	return i.idx.Batch(batch)
+}
+
+// Remove deletes notes from the index
+func (i *Index) Remove(ctx context.Context, noteIDs ...bson.ObjectID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.idx == nil {
+		return ErrClosed
+	}
+
+	batch := i.idx.NewBatch()
+	for _, id := range noteIDs {
+		batch.Delete(id.Hex())
+	}
+	return i.idx.Batch(batch)
+}
+
+// RemoveUser deletes the personal notes of userID from the index
+func (i *Index) RemoveUser(ctx context.Context, userID bson.ObjectID) error {
+	inScope := bleve.NewTermQuery(scope(userID, nil))
+	inScope.SetField("scope")
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		removed, err := i.removeMatches(ctx, inScope)
+		if err != nil {
+			return err
+		}
+		if removed < removeBatch {
+			return nil
+		}
+	}
+}
+
+// removeMatches deletes up to removeBatch notes matching q and returns how
+// many it deleted
+func (i *Index) removeMatches(ctx context.Context, q query.Query) (int, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.idx == nil {
+		return 0, ErrClosed
+	}
+
+	res, err := i.idx.SearchInContext(ctx, bleve.NewSearchRequestOptions(q, removeBatch, 0, false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to search index: %w", err)
+	}
+	if len(res.Hits) == 0 {
+		return 0, nil
+	}
+	batch := i.idx.NewBatch()
+	for _, h := range res.Hits {
+		batch.Delete(h.ID)
+	}
+	if err := i.idx.Batch(batch); err != nil {
+		return 0, fmt.Errorf("failed to remove notes from index: %w", err)
+	}
+	return len(res.Hits), nil
+}
+
+// Reset replaces the index with an empty one
+func (i *Index) Reset(_ context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.idx == nil {
+		return ErrClosed
+	}
+
+	if err := i.idx.Close(); err != nil {
+		return fmt.Errorf("failed to close search index: %w", err)
+	}
+	if err := os.RemoveAll(i.path); err != nil {
+		return fmt.Errorf("failed to remove search index: %w", err)
+	}
+	return i.create()
+}
+
+// Search returns the best matches for req, most relevant first. Plain words
+// are ORed and quoted phrases ANDed, like Mongo's $text.
+func (i *Index) Search(ctx context.Context, req notes.SearchRequest) ([]notes.SearchHit, error) {
+	q := i.buildQuery(req)
+	if q == nil {
+		return nil, nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.idx == nil {
+		return nil, ErrClosed
+	}
+
+	sr := bleve.NewSearchRequestOptions(q, req.Limit, 0, false)
+	res, err := i.idx.SearchInContext(ctx, sr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	hits := make([]notes.SearchHit, 0, len(res.Hits))
+	for _, h := range res.Hits {
+		id, err := bson.ObjectIDFromHex(h.ID)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, notes.SearchHit{ID: id, Score: h.Score})
+	}
+	return hits, nil
+}
+
+// buildQuery returns nil when req has nothing to look for
+func (i *Index) buildQuery(req notes.SearchRequest) query.Query {
+	words, phrases := notes.SplitText(req.Text)
+
+	var must []query.Query
+	for _, phrase := range phrases {
+		must = append(must, bothFields(func(field string) query.Query {
+			q := bleve.NewMatchPhraseQuery(phrase)
+			q.SetField(field)
+			return q
+		}))
+	}
+
+	var should []query.Query
+	for _, word := range words {
+		should = append(should, bothFields(func(field string) query.Query {
+			return i.wordQuery(word, field)
+		}))
+	}
+	if len(should) > 0 {
+		must = append(must, bleve.NewDisjunctionQuery(should...))
+	}
+	if len(must) == 0 {
+		return nil
+	}
+
+	inScope := bleve.NewTermQuery(scope(req.UserID, req.WorkspaceID))
+	inScope.SetField("scope")
+	return bleve.NewConjunctionQuery(append(must, inScope)...)
+}
+
+// wordQuery matches word in field, also as a prefix or with typos when the
+// options allow it
+func (i *Index) wordQuery(word, field string) query.Query {
+	match := bleve.NewMatchQuery(word)
+	match.SetField(field)
+	match.SetFuzziness(i.opts.Fuzziness)
+	if !i.opts.Prefix {
+		return match
+	}
+
+	// Prefixes are not analyzed, so they only need lower-casing
+	prefix := bleve.NewPrefixQuery(strings.ToLower(word))
+	prefix.SetField(field)
+	return bleve.NewDisjunctionQuery(match, prefix)
+}
+
+// bothFields ORs a query over the title and the body, favouring the title
+func bothFields(build func(field string) query.Query) query.Query {
+	title := build("title")
+	if b, ok := title.(query.BoostableQuery); ok {
+		b.SetBoost(titleBoost)
+	}
+	return bleve.NewDisjunctionQuery(title, build("body"))
+}
+
+func scope(userID bson.ObjectID, workspaceID *bson.ObjectID) string {
+	if workspaceID != nil {
+		return "w:" + workspaceID.Hex()
+	}
+	return "u:" + userID.Hex()
+}
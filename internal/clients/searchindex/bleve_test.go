@@ -0,0 +1,149 @@
+package searchindex
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func openTestIndex(t *testing.T, opts Options) (*Index, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "search.bleve")
+	idx, err := Open(path, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = idx.Close() })
+	return idx, path
+}
+
+func hitIDs(hits []notes.SearchHit) []bson.ObjectID {
+	ids := make([]bson.ObjectID, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	idx, _ := openTestIndex(t, Options{Language: "en"})
+	assert.True(t, idx.Fresh())
+
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	standup := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Standup", Body: "Action items from the meetings"}
+	retro := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Retro", Body: "What went well in the meeting"}
+	shared := &notes.Note{ID: bson.NewObjectID(), UserID: otherID, WorkspaceID: &workspaceID, Title: "Meeting agenda"}
+	foreign := &notes.Note{ID: bson.NewObjectID(), UserID: otherID, Title: "Meeting"}
+	require.NoError(t, idx.Index(ctx, standup, retro, shared, foreign))
+
+	search := func(text string, workspace *bson.ObjectID) []bson.ObjectID {
+		hits, err := idx.Search(ctx, notes.SearchRequest{UserID: userID, WorkspaceID: workspace, Text: text, Limit: 10})
+		require.NoError(t, err)
+		return hitIDs(hits)
+	}
+
+	// Stemming matches "meetings" and "meeting"; other scopes stay out
+	assert.ElementsMatch(t, []bson.ObjectID{standup.ID, retro.ID}, search("meeting", nil))
+	assert.Equal(t, []bson.ObjectID{shared.ID}, search("meeting", &workspaceID))
+
+	// Words are ORed, phrases ANDed
+	assert.ElementsMatch(t, []bson.ObjectID{standup.ID, retro.ID}, search("standup retro", nil))
+	assert.Equal(t, []bson.ObjectID{retro.ID}, search(`meeting "went well"`, nil))
+
+	// Title matches rank first
+	inBody := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Numbers", Body: "The budget for next year"}
+	inTitle := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Budget", Body: "For next year"}
+	require.NoError(t, idx.Index(ctx, inBody, inTitle))
+	hits, err := idx.Search(ctx, notes.SearchRequest{UserID: userID, Text: "budget", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{inTitle.ID, inBody.ID}, hitIDs(hits))
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+
+	// Updates replace, removals drop
+	standup.Title = "Planning"
+	require.NoError(t, idx.Index(ctx, standup))
+	assert.Empty(t, search("standup", nil))
+	require.NoError(t, idx.Remove(ctx, retro.ID))
+	assert.Equal(t, []bson.ObjectID{standup.ID}, search("meeting", nil))
+}
+
+func TestIndexRemoveUser(t *testing.T) {
+	ctx := context.Background()
+	idx, _ := openTestIndex(t, Options{Language: "standard"})
+
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	var list []*notes.Note
+	for range removeBatch + 5 {
+		list = append(list, &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Diary"})
+	}
+	shared := &notes.Note{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Title: "Diary"}
+	other := &notes.Note{ID: bson.NewObjectID(), UserID: otherID, Title: "Diary"}
+	require.NoError(t, idx.Index(ctx, append(list, shared, other)...))
+
+	require.NoError(t, idx.RemoveUser(ctx, userID))
+	count, err := idx.idx.DocCount()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count, "only the personal notes of the user go")
+
+	hits, err := idx.Search(ctx, notes.SearchRequest{UserID: otherID, Text: "diary", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{other.ID}, hitIDs(hits))
+	hits, err = idx.Search(ctx, notes.SearchRequest{UserID: userID, WorkspaceID: &workspaceID, Text: "diary", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{shared.ID}, hitIDs(hits))
+}
+
+func TestIndexPrefixAndFuzziness(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	note := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Quarterly targets"}
+
+	search := func(idx *Index, text string) []bson.ObjectID {
+		hits, err := idx.Search(ctx, notes.SearchRequest{UserID: userID, Text: text, Limit: 10})
+		require.NoError(t, err)
+		return hitIDs(hits)
+	}
+
+	strict, _ := openTestIndex(t, Options{Language: "standard"})
+	require.NoError(t, strict.Index(ctx, note))
+	assert.Empty(t, search(strict, "quart"))
+	assert.Empty(t, search(strict, "quartely"))
+
+	loose, _ := openTestIndex(t, Options{Language: "standard", Prefix: true, Fuzziness: 1})
+	require.NoError(t, loose.Index(ctx, note))
+	assert.Equal(t, []bson.ObjectID{note.ID}, search(loose, "quart"))
+	assert.Equal(t, []bson.ObjectID{note.ID}, search(loose, "quartely"))
+}
+
+func TestOpenRebuildsOnLanguageChange(t *testing.T) {
+	ctx := context.Background()
+	idx, path := openTestIndex(t, Options{Language: "en"})
+	require.NoError(t, idx.Index(ctx, &notes.Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "x"}))
+	require.NoError(t, idx.Close())
+
+	same, err := Open(path, Options{Language: "en"})
+	require.NoError(t, err)
+	assert.False(t, same.Fresh())
+	count, err := same.idx.DocCount()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	require.NoError(t, same.Close())
+
+	other, err := Open(path, Options{Language: "de"})
+	require.NoError(t, err)
+	defer other.Close()
+	assert.True(t, other.Fresh())
+	count, err = other.idx.DocCount()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	_, err = Open(path, Options{Language: "klingon"})
+	assert.ErrorIs(t, err, ErrUnknownLanguage)
+}
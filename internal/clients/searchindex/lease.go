@@ -0,0 +1,72 @@
+package searchindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// leaseName names the lease the replica serving the index holds
+	leaseName = "search-index"
+	// leaseTTL is how long another replica waits after the holder stops
+	leaseTTL = 30 * time.Second
+	// leaseRenewInterval renews well before the lease expires
+	leaseRenewInterval = leaseTTL / 3
+)
+
+// ErrIndexInUse is returned when another server replica serves the embedded
+// index. Each replica would keep its own index and miss the writes of the
+// others, so the embedded backend runs on a single replica only.
+var ErrIndexInUse = errors.New("the embedded search index is in use by another replica; run a single replica or use SEARCH_BACKEND=mongo")
+
+// Lease lets one server replica at a time serve the embedded index
+type Lease interface {
+	// Acquire takes the named lease for ttl, or renews it for the holder,
+	// and reports whether this replica holds it
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+	// Release gives the named lease up if this replica holds it
+	Release(ctx context.Context, name string) error
+}
+
+// Claim takes the index lease, failing with ErrIndexInUse while another
+// replica holds it
+func Claim(ctx context.Context, lease Lease) error {
+	held, err := lease.Acquire(ctx, leaseName, leaseTTL)
+	if err != nil {
+		return fmt.Errorf("failed to claim search index: %w", err)
+	}
+	if !held {
+		return ErrIndexInUse
+	}
+	return nil
+}
+
+// KeepClaim renews a lease taken with Claim until ctx is done and then
+// releases it. It returns ErrIndexInUse if another replica took the lease
+// over, e.g. after this one could not reach the database for leaseTTL.
+func KeepClaim(ctx context.Context, lease Lease, log *slog.Logger) error {
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := lease.Release(context.WithoutCancel(ctx), leaseName); err != nil {
+				log.Warn("failed to release search index lease", "error", err)
+			}
+			return nil
+		case <-ticker.C:
+		}
+
+		held, err := lease.Acquire(ctx, leaseName, leaseTTL)
+		switch {
+		case err != nil:
+			log.Error("failed to renew search index lease", "error", err)
+		case !held:
+			return ErrIndexInUse
+		}
+	}
+}
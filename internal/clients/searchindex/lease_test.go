@@ -0,0 +1,59 @@
+package searchindex
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memLease is a lease table shared by replicas named by holder
+type memLease struct {
+	mu      sync.Mutex
+	holders map[string]string
+}
+
+// replicaLease is one replica's view of a memLease
+type replicaLease struct {
+	*memLease
+	holder string
+}
+
+func (l replicaLease) Acquire(_ context.Context, name string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.holders[name]; ok && h != l.holder {
+		return false, nil
+	}
+	l.holders[name] = l.holder
+	return true, nil
+}
+
+func (l replicaLease) Release(_ context.Context, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holders[name] == l.holder {
+		delete(l.holders, name)
+	}
+	return nil
+}
+
+func TestClaimAllowsOneReplica(t *testing.T) {
+	table := &memLease{holders: map[string]string{}}
+	first, second := replicaLease{table, "a"}, replicaLease{table, "b"}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	require.NoError(t, Claim(context.Background(), first))
+	assert.ErrorIs(t, Claim(context.Background(), second), ErrIndexInUse, "a second replica refuses to start")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, KeepClaim(ctx, first, log))
+
+	assert.NoError(t, Claim(context.Background(), second), "a stopped replica releases the index")
+}
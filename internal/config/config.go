@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"net/mail"
 	"strings"
 	"sync"
 
@@ -15,6 +16,10 @@ var (
 	ErrBcryptCostRange            = errors.New("BCRYPT_COST must be between 8 and 16")
 	ErrAuthRatePerMin             = errors.New("AUTH_RATE_PER_MIN must be greater than or equal to 1")
 	ErrAppRatePerMin              = errors.New("APP_RATE_PER_MIN must be greater than or equal to 0, 0 means no rate limiting")
+	ErrWriteRatePerMin            = errors.New("WRITE_RATE_PER_MIN must be greater than or equal to 0, 0 means no rate limiting")
+	ErrWSConnectRatePerMin        = errors.New("WS_CONNECT_RATE_PER_MIN must be greater than or equal to 0, 0 means no rate limiting")
+	ErrWSMaxConnsPerUser          = errors.New("WS_MAX_CONNS_PER_USER must be greater than or equal to 0, 0 means no cap")
+	ErrRateLimitStore             = errors.New("RATE_LIMIT_STORE must be memory or mongo")
 	ErrLogLevelEmpty              = errors.New("LOG_LEVEL cannot be empty")
 	ErrLogFormatEmpty             = errors.New("LOG_FORMAT cannot be empty")
 	ErrMongoURIEmpty              = errors.New("MONGO_URI cannot be empty")
@@ -24,6 +29,25 @@ var (
 	ErrAccessTokenMinutesPositive = errors.New("ACCESS_TOKEN_MINUTES must be greater than 0")
 	ErrRefreshTokenDaysPositive   = errors.New("REFRESH_TOKEN_DAYS must be greater than 0")
 	ErrJWTAlgorithmUnsupported    = errors.New("JWT_ALGORITHM must be HS256")
+	ErrLoginMaxFailures           = errors.New("LOGIN_MAX_FAILURES must be greater than or equal to 0, 0 disables account lockout")
+	ErrLoginLockoutMinutes        = errors.New("LOGIN_LOCKOUT_MINUTES must be greater than 0")
+	ErrLoginFailureDelayMs        = errors.New("LOGIN_FAILURE_DELAY_MS must be greater than or equal to 0")
+	ErrSearchBackend              = errors.New("SEARCH_BACKEND must be mongo or embedded")
+	ErrSearchIndexPathEmpty       = errors.New("SEARCH_INDEX_PATH cannot be empty with the embedded search backend")
+	ErrSearchFuzziness            = errors.New("SEARCH_FUZZINESS must be between 0 and 2")
+	ErrBlobBackend                = errors.New("BLOB_BACKEND must be local or s3")
+	ErrBlobPathEmpty              = errors.New("BLOB_PATH cannot be empty with the local blob backend")
+	ErrS3EndpointEmpty            = errors.New("S3_ENDPOINT and S3_BUCKET cannot be empty with the s3 blob backend")
+	ErrAttachmentMaxBytes         = errors.New("ATTACHMENT_MAX_BYTES must be greater than 0")
+	ErrWebhookTimeoutSec          = errors.New("WEBHOOK_TIMEOUT_SEC must be between 1 and 30")
+	ErrInboundMailDomainEmpty     = errors.New("INBOUND_MAIL_DOMAIN cannot be empty when INBOUND_SMTP_ENABLED is true")
+	ErrInboundMaxBytes            = errors.New("INBOUND_MAX_BYTES must be greater than 0")
+	ErrInboundRatePerHour         = errors.New("INBOUND_RATE_PER_HOUR must be greater than 0")
+	ErrAuditRetentionDays         = errors.New("AUDIT_RETENTION_DAYS must be greater than 0")
+	ErrNoteLimits                 = errors.New("MAX_NOTES_PER_USER, MAX_TITLE_CHARS, MAX_BODY_BYTES and MAX_STORAGE_BYTES must be greater than 0")
+	ErrIdempotencyTTLHours        = errors.New("IDEMPOTENCY_TTL_HOURS must be greater than 0")
+	ErrSMTPPortRange              = errors.New("SMTP_PORT must be between 1 and 65535")
+	ErrSMTPFrom                   = errors.New("SMTP_FROM must be an email address when SMTP_HOST is set")
 )
 
 // Config holds all application configuration.
@@ -32,6 +56,13 @@ type Config struct {
 	BcryptCost            int    `mapstructure:"BCRYPT_COST"`
 	AuthRatePerMin        int    `mapstructure:"AUTH_RATE_PER_MIN"`
 	AppRatePerMin         int    `mapstructure:"APP_RATE_PER_MIN"`
+	WriteRatePerMin       int    `mapstructure:"WRITE_RATE_PER_MIN"`
+	WSConnectRatePerMin   int    `mapstructure:"WS_CONNECT_RATE_PER_MIN"`
+	WSMaxConnsPerUser     int    `mapstructure:"WS_MAX_CONNS_PER_USER"`
+	RateLimitStore        string `mapstructure:"RATE_LIMIT_STORE"`
+	LoginMaxFailures      int    `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginLockoutMinutes   int    `mapstructure:"LOGIN_LOCKOUT_MINUTES"`
+	LoginFailureDelayMs   int    `mapstructure:"LOGIN_FAILURE_DELAY_MS"`
 	LogLevel              string `mapstructure:"LOG_LEVEL"`
 	LogFormat             string `mapstructure:"LOG_FORMAT"`
 	MongoURI              string `mapstructure:"MONGO_URI"`
@@ -50,8 +81,57 @@ type Config struct {
 	PyroscopeServerAddr   string `mapstructure:"PYROSCOPE_SERVER_ADDR"`
 	PyroscopeAppName      string `mapstructure:"PYROSCOPE_APP_NAME"`
 	DevMode               bool   `mapstructure:"DEV_MODE"`
+	SearchBackend         string `mapstructure:"SEARCH_BACKEND"`
+	SearchIndexPath       string `mapstructure:"SEARCH_INDEX_PATH"`
+	SearchLanguage        string `mapstructure:"SEARCH_LANGUAGE"`
+	SearchPrefix          bool   `mapstructure:"SEARCH_PREFIX"`
+	SearchFuzziness       int    `mapstructure:"SEARCH_FUZZINESS"`
+	BlobBackend           string `mapstructure:"BLOB_BACKEND"`
+	BlobPath              string `mapstructure:"BLOB_PATH"`
+	S3Endpoint            string `mapstructure:"S3_ENDPOINT"`
+	S3Bucket              string `mapstructure:"S3_BUCKET"`
+	S3Region              string `mapstructure:"S3_REGION"`
+	S3AccessKey           string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey           string `mapstructure:"S3_SECRET_KEY"`
+	AttachmentMaxBytes    int64  `mapstructure:"ATTACHMENT_MAX_BYTES"`
+	WebhookTimeoutSec     int    `mapstructure:"WEBHOOK_TIMEOUT_SEC"`
+	WebhookAllowPrivate   bool   `mapstructure:"WEBHOOK_ALLOW_PRIVATE"`
+	InboundSMTPEnabled    bool   `mapstructure:"INBOUND_SMTP_ENABLED"`
+	InboundSMTPAddr       string `mapstructure:"INBOUND_SMTP_ADDR"`
+	InboundMailDomain     string `mapstructure:"INBOUND_MAIL_DOMAIN"`
+	InboundMaxBytes       int64  `mapstructure:"INBOUND_MAX_BYTES"`
+	InboundRatePerHour    int    `mapstructure:"INBOUND_RATE_PER_HOUR"`
+	AuditRetentionDays    int    `mapstructure:"AUDIT_RETENTION_DAYS"`
+	MaxNotesPerUser       int64  `mapstructure:"MAX_NOTES_PER_USER"`
+	MaxTitleChars         int    `mapstructure:"MAX_TITLE_CHARS"`
+	MaxBodyBytes          int    `mapstructure:"MAX_BODY_BYTES"`
+	MaxStorageBytes       int64  `mapstructure:"MAX_STORAGE_BYTES"`
+	IdempotencyTTLHours   int    `mapstructure:"IDEMPOTENCY_TTL_HOURS"`
+	SMTPHost              string `mapstructure:"SMTP_HOST"`
+	SMTPPort              int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername          string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword          string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom              string `mapstructure:"SMTP_FROM"`
 }
 
+// Search backends
+const (
+	SearchBackendMongo    = "mongo"    // the notes collection's text index
+	SearchBackendEmbedded = "embedded" // an on-disk index next to the server
+)
+
+// Rate limit stores
+const (
+	RateLimitStoreMemory = "memory" // counters per replica
+	RateLimitStoreMongo  = "mongo"  // counters shared by every replica
+)
+
+// Blob backends for attachments
+const (
+	BlobBackendLocal = "local" // files under BLOB_PATH
+	BlobBackendS3    = "s3"    // an S3-compatible bucket
+)
+
 var (
 	cachedConfig *Config
 	configMutex  sync.RWMutex
@@ -82,6 +162,13 @@ func Load() (Config, error) {
 	v.SetDefault("BCRYPT_COST", 8)
 	v.SetDefault("AUTH_RATE_PER_MIN", 5)
 	v.SetDefault("APP_RATE_PER_MIN", 0)
+	v.SetDefault("WRITE_RATE_PER_MIN", 0)       // POST, PUT, PATCH and DELETE on app routes
+	v.SetDefault("WS_CONNECT_RATE_PER_MIN", 30) // WebSocket connects per user
+	v.SetDefault("WS_MAX_CONNS_PER_USER", 10)   // open WebSocket connections per user
+	v.SetDefault("RATE_LIMIT_STORE", RateLimitStoreMemory)
+	v.SetDefault("LOGIN_MAX_FAILURES", 5)       // failed sign-ins before an account is locked
+	v.SetDefault("LOGIN_LOCKOUT_MINUTES", 15)   // lockout duration and failure-counting window
+	v.SetDefault("LOGIN_FAILURE_DELAY_MS", 250) // base of the progressive delay after a failure
 	v.SetDefault("LOG_LEVEL", "info")
 	v.SetDefault("LOG_FORMAT", "json")
 	v.SetDefault("MONGO_URI", "mongodb://mongo:27017")
@@ -100,6 +187,29 @@ func Load() (Config, error) {
 	v.SetDefault("PYROSCOPE_SERVER_ADDR", "http://pyroscope:4040")
 	v.SetDefault("PYROSCOPE_APP_NAME", "notepulse-server")
 	v.SetDefault("JWT_SECRET", "")
+	v.SetDefault("SEARCH_BACKEND", SearchBackendMongo)
+	v.SetDefault("SEARCH_INDEX_PATH", "data/search.bleve")
+	v.SetDefault("SEARCH_LANGUAGE", "en") // analyzer of the embedded index
+	v.SetDefault("SEARCH_PREFIX", true)   // embedded: words also match as prefixes
+	v.SetDefault("SEARCH_FUZZINESS", 1)   // embedded: edit distance allowed per word
+	v.SetDefault("BLOB_BACKEND", BlobBackendLocal)
+	v.SetDefault("BLOB_PATH", "data/blobs")
+	v.SetDefault("S3_REGION", "us-east-1")
+	v.SetDefault("ATTACHMENT_MAX_BYTES", 10<<20) // per uploaded file
+	v.SetDefault("WEBHOOK_TIMEOUT_SEC", 10)      // per delivery attempt
+	v.SetDefault("WEBHOOK_ALLOW_PRIVATE", false) // let webhooks reach private addresses
+	v.SetDefault("INBOUND_SMTP_ENABLED", false)  // email-to-note listener
+	v.SetDefault("INBOUND_SMTP_ADDR", ":2525")
+	v.SetDefault("INBOUND_MAX_BYTES", 10<<20) // per message
+	v.SetDefault("INBOUND_RATE_PER_HOUR", 30) // messages per inbound address
+	v.SetDefault("AUDIT_RETENTION_DAYS", 365) // audit entries expire after
+	v.SetDefault("MAX_NOTES_PER_USER", 10000)
+	v.SetDefault("MAX_TITLE_CHARS", 200)
+	v.SetDefault("MAX_BODY_BYTES", 1<<20)     // per note body
+	v.SetDefault("MAX_STORAGE_BYTES", 1<<30)  // note text and attachments per user
+	v.SetDefault("IDEMPOTENCY_TTL_HOURS", 24) // stored responses are replayed for
+	v.SetDefault("SMTP_HOST", "")             // outgoing mail relay; empty disables mail
+	v.SetDefault("SMTP_PORT", 587)
 
 	// Configure Viper to read from .env file (if present)
 	v.SetConfigName(".env")
@@ -123,6 +233,8 @@ func Load() (Config, error) {
 
 	// Normalize JWT algorithm to uppercase
 	cfg.JWTAlgorithm = strings.ToUpper(cfg.JWTAlgorithm)
+	cfg.SearchBackend = strings.ToLower(cfg.SearchBackend)
+	cfg.BlobBackend = strings.ToLower(cfg.BlobBackend)
 
 	// Validate the configuration
 	if err := cfg.Validate(); err != nil {
@@ -154,6 +266,18 @@ func (c Config) Validate() error {
 	if err := c.validatePositiveNumbers(); err != nil {
 		return err
 	}
+	if err := c.validateSearch(); err != nil {
+		return err
+	}
+	if err := c.validateBlobs(); err != nil {
+		return err
+	}
+	if err := c.validateInbound(); err != nil {
+		return err
+	}
+	if err := c.validateSMTP(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -189,6 +313,27 @@ func (c Config) validateRanges() error {
 	if c.AppRatePerMin < 0 {
 		return ErrAppRatePerMin
 	}
+	if c.WriteRatePerMin < 0 {
+		return ErrWriteRatePerMin
+	}
+	if c.WSConnectRatePerMin < 0 {
+		return ErrWSConnectRatePerMin
+	}
+	if c.WSMaxConnsPerUser < 0 {
+		return ErrWSMaxConnsPerUser
+	}
+	if c.RateLimitStore != RateLimitStoreMemory && c.RateLimitStore != RateLimitStoreMongo {
+		return ErrRateLimitStore
+	}
+	if c.LoginMaxFailures < 0 {
+		return ErrLoginMaxFailures
+	}
+	if c.LoginMaxFailures > 0 && c.LoginLockoutMinutes <= 0 {
+		return ErrLoginLockoutMinutes
+	}
+	if c.LoginFailureDelayMs < 0 {
+		return ErrLoginFailureDelayMs
+	}
 	return nil
 }
 
@@ -223,5 +368,90 @@ func (c Config) validatePositiveNumbers() error {
 	if c.RefreshTokenDays <= 0 {
 		return ErrRefreshTokenDaysPositive
 	}
+	// A delivery attempt must end well before its claim runs out
+	if c.WebhookTimeoutSec < 1 || c.WebhookTimeoutSec > 30 {
+		return ErrWebhookTimeoutSec
+	}
+	if c.AuditRetentionDays <= 0 {
+		return ErrAuditRetentionDays
+	}
+	if c.MaxNotesPerUser <= 0 || c.MaxTitleChars <= 0 || c.MaxBodyBytes <= 0 || c.MaxStorageBytes <= 0 {
+		return ErrNoteLimits
+	}
+	if c.IdempotencyTTLHours <= 0 {
+		return ErrIdempotencyTTLHours
+	}
+	return nil
+}
+
+// validateSearch validates the search backend settings. The language is
+// checked when the embedded index opens, which knows its analyzers.
+func (c Config) validateSearch() error {
+	switch c.SearchBackend {
+	case SearchBackendMongo:
+		return nil
+	case SearchBackendEmbedded:
+	default:
+		return ErrSearchBackend
+	}
+	if c.SearchIndexPath == "" {
+		return ErrSearchIndexPathEmpty
+	}
+	if c.SearchFuzziness < 0 || c.SearchFuzziness > 2 {
+		return ErrSearchFuzziness
+	}
+	return nil
+}
+
+// validateBlobs validates the attachment storage settings
+func (c Config) validateBlobs() error {
+	if c.AttachmentMaxBytes <= 0 {
+		return ErrAttachmentMaxBytes
+	}
+	switch c.BlobBackend {
+	case BlobBackendLocal:
+		if c.BlobPath == "" {
+			return ErrBlobPathEmpty
+		}
+	case BlobBackendS3:
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			return ErrS3EndpointEmpty
+		}
+	default:
+		return ErrBlobBackend
+	}
+	return nil
+}
+
+// validateInbound validates the email-to-note settings, which only matter
+// with the SMTP listener on
+func (c Config) validateInbound() error {
+	if !c.InboundSMTPEnabled {
+		return nil
+	}
+	if c.InboundMailDomain == "" {
+		return ErrInboundMailDomainEmpty
+	}
+	if c.InboundMaxBytes <= 0 {
+		return ErrInboundMaxBytes
+	}
+	if c.InboundRatePerHour <= 0 {
+		return ErrInboundRatePerHour
+	}
+	return nil
+}
+
+// validateSMTP validates the outgoing mail relay, which only matters with
+// SMTP_HOST set
+func (c Config) validateSMTP() error {
+	if c.SMTPHost == "" {
+		return nil
+	}
+	if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+		return ErrSMTPPortRange
+	}
+	if _, err := mail.ParseAddress(c.SMTPFrom); err != nil {
+		return ErrSMTPFrom
+	}
 	return nil
 }
@@ -16,20 +16,32 @@ import (
 // can tweak inside table tests.
 func baseValidConfig() Config {
 	return Config{
-		AppPort:            8080,
-		BcryptCost:         12,
-		AuthRatePerMin:     5,
-		LogLevel:           "info",
-		LogFormat:          "json",
-		MongoURI:           "mongodb://localhost:27017",
-		MongoDBName:        "test",
-		JWTSecret:          "this-is-a-super-secret-jwt-key-with-32-plus-chars",
-		JWTAlgorithm:       "HS256",
-		AccessTokenMinutes: 15,
-		RefreshTokenDays:   30,
-		RefreshTokenRotate: true,
-		WSMaxSessionSec:    900,
-		WSOutboxBuffer:     256,
+		AppPort:             8080,
+		BcryptCost:          12,
+		AuthRatePerMin:      5,
+		RateLimitStore:      RateLimitStoreMemory,
+		LogLevel:            "info",
+		LogFormat:           "json",
+		MongoURI:            "mongodb://localhost:27017",
+		MongoDBName:         "test",
+		JWTSecret:           "this-is-a-super-secret-jwt-key-with-32-plus-chars",
+		JWTAlgorithm:        "HS256",
+		AccessTokenMinutes:  15,
+		RefreshTokenDays:    30,
+		RefreshTokenRotate:  true,
+		WSMaxSessionSec:     900,
+		WSOutboxBuffer:      256,
+		SearchBackend:       SearchBackendMongo,
+		BlobBackend:         BlobBackendLocal,
+		BlobPath:            "data/blobs",
+		AttachmentMaxBytes:  10 << 20,
+		WebhookTimeoutSec:   10,
+		AuditRetentionDays:  365,
+		MaxNotesPerUser:     10000,
+		MaxTitleChars:       200,
+		MaxBodyBytes:        1 << 20,
+		MaxStorageBytes:     1 << 30,
+		IdempotencyTTLHours: 24,
 	}
 }
 
@@ -42,6 +54,13 @@ func clearConfigEnvVars(t *testing.T) {
 		"APP_PORT",
 		"BCRYPT_COST",
 		"AUTH_RATE_PER_MIN",
+		"WRITE_RATE_PER_MIN",
+		"WS_CONNECT_RATE_PER_MIN",
+		"WS_MAX_CONNS_PER_USER",
+		"RATE_LIMIT_STORE",
+		"LOGIN_MAX_FAILURES",
+		"LOGIN_LOCKOUT_MINUTES",
+		"LOGIN_FAILURE_DELAY_MS",
 		"LOG_LEVEL",
 		"LOG_FORMAT",
 		"MONGO_URI",
@@ -52,6 +71,29 @@ func clearConfigEnvVars(t *testing.T) {
 		"WS_OUTBOX_BUFFER",
 		"REQUEST_LOGGING_ENABLED",
 		"DEV_MODE",
+		"SEARCH_BACKEND",
+		"SEARCH_INDEX_PATH",
+		"SEARCH_FUZZINESS",
+		"BLOB_BACKEND",
+		"BLOB_PATH",
+		"S3_ENDPOINT",
+		"S3_BUCKET",
+		"ATTACHMENT_MAX_BYTES",
+		"WEBHOOK_TIMEOUT_SEC",
+		"WEBHOOK_ALLOW_PRIVATE",
+		"INBOUND_SMTP_ENABLED",
+		"INBOUND_SMTP_ADDR",
+		"INBOUND_MAIL_DOMAIN",
+		"INBOUND_MAX_BYTES",
+		"INBOUND_RATE_PER_HOUR",
+		"AUDIT_RETENTION_DAYS",
+		"MAX_NOTES_PER_USER",
+		"MAX_TITLE_CHARS",
+		"MAX_BODY_BYTES",
+		"MAX_STORAGE_BYTES",
+		"SMTP_HOST",
+		"SMTP_PORT",
+		"SMTP_FROM",
 	} {
 		if err := os.Unsetenv(k); err != nil {
 			t.Logf("warning: failed to unset %s: %v", k, err)
@@ -71,6 +113,10 @@ func TestConfigLoadDefaults(t *testing.T) {
 	assert.Equal(t, 8080, cfg.AppPort)
 	assert.Equal(t, 8, cfg.BcryptCost)
 	assert.Equal(t, 5, cfg.AuthRatePerMin)
+	assert.Equal(t, 0, cfg.WriteRatePerMin)
+	assert.Equal(t, 30, cfg.WSConnectRatePerMin)
+	assert.Equal(t, 10, cfg.WSMaxConnsPerUser)
+	assert.Equal(t, RateLimitStoreMemory, cfg.RateLimitStore)
 	assert.Equal(t, "info", cfg.LogLevel)
 	assert.Equal(t, "json", cfg.LogFormat)
 	assert.Equal(t, "mongodb://mongo:27017", cfg.MongoURI)
@@ -81,6 +127,18 @@ func TestConfigLoadDefaults(t *testing.T) {
 	assert.Equal(t, 900, cfg.WSMaxSessionSec)
 	assert.Equal(t, 256, cfg.WSOutboxBuffer)
 	assert.True(t, cfg.RequestLoggingEnabled)
+	assert.Equal(t, 5, cfg.LoginMaxFailures)
+	assert.Equal(t, 15, cfg.LoginLockoutMinutes)
+	assert.Equal(t, SearchBackendMongo, cfg.SearchBackend)
+	assert.Equal(t, 1, cfg.SearchFuzziness)
+	assert.True(t, cfg.SearchPrefix)
+	assert.Equal(t, BlobBackendLocal, cfg.BlobBackend)
+	assert.Equal(t, int64(10<<20), cfg.AttachmentMaxBytes)
+	assert.Equal(t, 250, cfg.LoginFailureDelayMs)
+	assert.Equal(t, int64(10000), cfg.MaxNotesPerUser)
+	assert.Equal(t, 1<<20, cfg.MaxBodyBytes)
+	assert.Equal(t, "", cfg.SMTPHost)
+	assert.Equal(t, 587, cfg.SMTPPort)
 }
 
 func TestConfigLoadWithOverride(t *testing.T) {
@@ -215,6 +273,209 @@ func TestConfigValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  ErrAuthRatePerMin.Error(),
 		},
+		{
+			name: "negative write rate",
+			modify: func(c *Config) {
+				c.WriteRatePerMin = -1
+			},
+			wantErr: true,
+			errMsg:  ErrWriteRatePerMin.Error(),
+		},
+		{
+			name: "negative WebSocket connection cap",
+			modify: func(c *Config) {
+				c.WSMaxConnsPerUser = -1
+			},
+			wantErr: true,
+			errMsg:  ErrWSMaxConnsPerUser.Error(),
+		},
+		{
+			name: "unknown rate limit store",
+			modify: func(c *Config) {
+				c.RateLimitStore = "redis"
+			},
+			wantErr: true,
+			errMsg:  ErrRateLimitStore.Error(),
+		},
+		{
+			name: "negative login max failures",
+			modify: func(c *Config) {
+				c.LoginMaxFailures = -1
+			},
+			wantErr: true,
+			errMsg:  ErrLoginMaxFailures.Error(),
+		},
+		{
+			name: "lockout enabled without duration",
+			modify: func(c *Config) {
+				c.LoginMaxFailures = 5
+				c.LoginLockoutMinutes = 0
+			},
+			wantErr: true,
+			errMsg:  ErrLoginLockoutMinutes.Error(),
+		},
+		{
+			name: "negative login failure delay",
+			modify: func(c *Config) {
+				c.LoginFailureDelayMs = -1
+			},
+			wantErr: true,
+			errMsg:  ErrLoginFailureDelayMs.Error(),
+		},
+		{
+			name: "unknown search backend",
+			modify: func(c *Config) {
+				c.SearchBackend = "elastic"
+			},
+			wantErr: true,
+			errMsg:  ErrSearchBackend.Error(),
+		},
+		{
+			name: "embedded search without index path",
+			modify: func(c *Config) {
+				c.SearchBackend = SearchBackendEmbedded
+			},
+			wantErr: true,
+			errMsg:  ErrSearchIndexPathEmpty.Error(),
+		},
+		{
+			name: "search fuzziness too high",
+			modify: func(c *Config) {
+				c.SearchBackend = SearchBackendEmbedded
+				c.SearchIndexPath = "data/search.bleve"
+				c.SearchFuzziness = 3
+			},
+			wantErr: true,
+			errMsg:  ErrSearchFuzziness.Error(),
+		},
+		{
+			name: "unknown blob backend",
+			modify: func(c *Config) {
+				c.BlobBackend = "ftp"
+			},
+			wantErr: true,
+			errMsg:  ErrBlobBackend.Error(),
+		},
+		{
+			name: "local blobs without path",
+			modify: func(c *Config) {
+				c.BlobPath = ""
+			},
+			wantErr: true,
+			errMsg:  ErrBlobPathEmpty.Error(),
+		},
+		{
+			name: "s3 blobs without bucket",
+			modify: func(c *Config) {
+				c.BlobBackend = BlobBackendS3
+				c.S3Endpoint = "https://s3.example.com"
+			},
+			wantErr: true,
+			errMsg:  ErrS3EndpointEmpty.Error(),
+		},
+		{
+			name: "s3 blobs",
+			modify: func(c *Config) {
+				c.BlobBackend = BlobBackendS3
+				c.BlobPath = ""
+				c.S3Endpoint = "https://s3.example.com"
+				c.S3Bucket = "notes"
+			},
+			wantErr: false,
+		},
+		{
+			name: "attachment limit not positive",
+			modify: func(c *Config) {
+				c.AttachmentMaxBytes = 0
+			},
+			wantErr: true,
+			errMsg:  ErrAttachmentMaxBytes.Error(),
+		},
+		{
+			name: "webhook timeout outlasting the delivery claim",
+			modify: func(c *Config) {
+				c.WebhookTimeoutSec = 60
+			},
+			wantErr: true,
+			errMsg:  ErrWebhookTimeoutSec.Error(),
+		},
+		{
+			name: "audit retention not positive",
+			modify: func(c *Config) {
+				c.AuditRetentionDays = 0
+			},
+			wantErr: true,
+			errMsg:  ErrAuditRetentionDays.Error(),
+		},
+		{
+			name: "note limit not positive",
+			modify: func(c *Config) {
+				c.MaxTitleChars = 0
+			},
+			wantErr: true,
+			errMsg:  ErrNoteLimits.Error(),
+		},
+		{
+			name: "idempotency TTL not positive",
+			modify: func(c *Config) {
+				c.IdempotencyTTLHours = 0
+			},
+			wantErr: true,
+			errMsg:  ErrIdempotencyTTLHours.Error(),
+		},
+		{
+			name: "inbound SMTP without a mail domain",
+			modify: func(c *Config) {
+				c.InboundSMTPEnabled = true
+				c.InboundMaxBytes = 10 << 20
+				c.InboundRatePerHour = 30
+			},
+			wantErr: true,
+			errMsg:  ErrInboundMailDomainEmpty.Error(),
+		},
+		{
+			name: "inbound SMTP without a rate limit",
+			modify: func(c *Config) {
+				c.InboundSMTPEnabled = true
+				c.InboundMailDomain = "in.example.com"
+				c.InboundMaxBytes = 10 << 20
+			},
+			wantErr: true,
+			errMsg:  ErrInboundRatePerHour.Error(),
+		},
+		{
+			name: "inbound settings ignored while the listener is off",
+			modify: func(c *Config) {
+				c.InboundMaxBytes = 0
+			},
+			wantErr: false,
+		},
+		{
+			name: "SMTP host without a sender",
+			modify: func(c *Config) {
+				c.SMTPHost = "smtp.example.com"
+				c.SMTPPort = 587
+			},
+			wantErr: true,
+			errMsg:  ErrSMTPFrom.Error(),
+		},
+		{
+			name: "SMTP port out of range",
+			modify: func(c *Config) {
+				c.SMTPHost = "smtp.example.com"
+				c.SMTPPort = 0
+				c.SMTPFrom = "NotePulse <no-reply@example.com>"
+			},
+			wantErr: true,
+			errMsg:  ErrSMTPPortRange.Error(),
+		},
+		{
+			name: "SMTP settings ignored without a host",
+			modify: func(c *Config) {
+				c.SMTPFrom = "not an address"
+			},
+			wantErr: false,
+		},
 		{
 			name: "JWT secret too short for HS256",
 			modify: func(c *Config) {
@@ -0,0 +1,12 @@
+package admin
+
+import "errors"
+
+// ErrListUsers is returned when listing users fails.
+var ErrListUsers = errors.New("failed to list users")
+
+// ErrUpdateUser is returned when a user cannot be updated.
+var ErrUpdateUser = errors.New("failed to update user")
+
+// ErrSelfAction is returned when an admin tries to disable or demote themselves.
+var ErrSelfAction = errors.New("admins cannot disable or demote themselves")
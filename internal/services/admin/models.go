@@ -0,0 +1,43 @@
+package admin
+
+import "note-pulse/internal/services/auth"
+
+// Usage is how much a user stores
+type Usage struct {
+	NoteCount    int64 `json:"note_count" example:"42"`
+	StorageBytes int64 `json:"storage_bytes" example:"18342"`
+}
+
+// UserSummary is the admin view of a user
+type UserSummary struct {
+	*auth.User
+	Usage Usage `json:"usage"`
+}
+
+// UserFilter narrows down ListUsers
+type UserFilter struct {
+	// Q matches a case-insensitive substring of the email
+	Q        string
+	Role     string
+	Disabled *bool
+}
+
+// ListUsersRequest represents an admin user search
+type ListUsersRequest struct {
+	Q        string `query:"q" validate:"omitempty,max=256" example:"example.com"`
+	Role     string `query:"role" validate:"omitempty,oneof=user admin" example:"admin"`
+	Disabled *bool  `query:"disabled" example:"true"`
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=200" example:"50"`
+	Offset   int    `query:"offset" validate:"omitempty,min=0" example:"0"`
+}
+
+// ListUsersResponse is a page of users
+type ListUsersResponse struct {
+	Users      []*UserSummary `json:"users"`
+	TotalCount int64          `json:"total_count" example:"1234"`
+}
+
+// SetRoleRequest changes a user's role
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin" example:"admin"`
+}
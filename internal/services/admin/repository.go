@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"note-pulse/internal/services/auth"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// UsersRepo is the user storage the admin service needs
+type UsersRepo interface {
+	FindByID(ctx context.Context, id bson.ObjectID) (*auth.User, error)
+	FindByEmail(ctx context.Context, email string) (*auth.User, error)
+	Search(ctx context.Context, filter UserFilter, limit, offset int) ([]*auth.User, int64, error)
+	SetDisabled(ctx context.Context, id bson.ObjectID, disabledAt *time.Time) error
+	SetRole(ctx context.Context, id bson.ObjectID, role string) error
+}
+
+// UsageRepo reports per-user storage
+type UsageRepo interface {
+	UsageByUser(ctx context.Context, userIDs []bson.ObjectID) (map[bson.ObjectID]Usage, error)
+}
+
+// Accounts is the part of the auth service the admin service drives
+type Accounts interface {
+	ForceSignOut(ctx context.Context, userID bson.ObjectID) error
+	InvalidateUserStatus(userID bson.ObjectID)
+}
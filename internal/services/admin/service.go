@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"note-pulse/internal/services/auth"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const defaultListLimit = 50
+
+// Service implements admin operations on user accounts. It is shared by the
+// HTTP admin API and the npadmin CLI.
+type Service struct {
+	users    UsersRepo
+	usage    UsageRepo
+	accounts Accounts
+	log      *slog.Logger
+}
+
+// NewService creates a new admin service
+func NewService(users UsersRepo, usage UsageRepo, accounts Accounts, log *slog.Logger) *Service {
+	return &Service{
+		users:    users,
+		usage:    usage,
+		accounts: accounts,
+		log:      log,
+	}
+}
+
+// ListUsers searches users and attaches their storage usage
+func (s *Service) ListUsers(ctx context.Context, req ListUsersRequest) (*ListUsersResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	filter := UserFilter{Q: strings.TrimSpace(req.Q), Role: req.Role, Disabled: req.Disabled}
+	users, total, err := s.users.Search(ctx, filter, limit, req.Offset)
+	if err != nil {
+		s.log.Error(ErrListUsers.Error(), "error", err)
+		return nil, ErrListUsers
+	}
+
+	summaries, err := s.summarize(ctx, users)
+	if err != nil {
+		return nil, ErrListUsers
+	}
+
+	return &ListUsersResponse{Users: summaries, TotalCount: total}, nil
+}
+
+// GetUser returns one user with usage
+func (s *Service) GetUser(ctx context.Context, id bson.ObjectID) (*UserSummary, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, s.lookupError(err, id)
+	}
+	summaries, err := s.summarize(ctx, []*auth.User{user})
+	if err != nil {
+		return nil, ErrListUsers
+	}
+	return summaries[0], nil
+}
+
+// FindUser resolves a user by hex ID or email, for the CLI
+func (s *Service) FindUser(ctx context.Context, idOrEmail string) (*UserSummary, error) {
+	if id, err := bson.ObjectIDFromHex(idOrEmail); err == nil {
+		return s.GetUser(ctx, id)
+	}
+
+	user, err := s.users.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(idOrEmail)))
+	if err != nil {
+		return nil, s.lookupError(err, bson.ObjectID{})
+	}
+	return s.GetUser(ctx, user.ID)
+}
+
+// DisableUser disables an account, signs it out everywhere and closes its
+// live connections. actorID is the admin doing it; zero for the CLI.
+func (s *Service) DisableUser(ctx context.Context, actorID, id bson.ObjectID) (*UserSummary, error) {
+	if id == actorID {
+		return nil, ErrSelfAction
+	}
+
+	now := time.Now().UTC()
+	if err := s.users.SetDisabled(ctx, id, &now); err != nil {
+		return nil, s.updateError(err, id)
+	}
+	s.accounts.InvalidateUserStatus(id)
+
+	if err := s.accounts.ForceSignOut(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.log.Warn("user disabled", "user_id", id.Hex(), "actor_id", actorID.Hex())
+	return s.GetUser(ctx, id)
+}
+
+// EnableUser re-enables a disabled account
+func (s *Service) EnableUser(ctx context.Context, actorID, id bson.ObjectID) (*UserSummary, error) {
+	if err := s.users.SetDisabled(ctx, id, nil); err != nil {
+		return nil, s.updateError(err, id)
+	}
+	s.accounts.InvalidateUserStatus(id)
+
+	s.log.Warn("user enabled", "user_id", id.Hex(), "actor_id", actorID.Hex())
+	return s.GetUser(ctx, id)
+}
+
+// ForceSignOut revokes every session of a user
+func (s *Service) ForceSignOut(ctx context.Context, actorID, id bson.ObjectID) error {
+	if _, err := s.users.FindByID(ctx, id); err != nil {
+		return s.lookupError(err, id)
+	}
+	if err := s.accounts.ForceSignOut(ctx, id); err != nil {
+		return err
+	}
+
+	s.log.Warn("user force signed out", "user_id", id.Hex(), "actor_id", actorID.Hex())
+	return nil
+}
+
+// SetRole changes a user's role
+func (s *Service) SetRole(ctx context.Context, actorID, id bson.ObjectID, role string) (*UserSummary, error) {
+	if id == actorID && role != auth.RoleAdmin {
+		return nil, ErrSelfAction
+	}
+
+	if err := s.users.SetRole(ctx, id, role); err != nil {
+		return nil, s.updateError(err, id)
+	}
+	s.accounts.InvalidateUserStatus(id)
+
+	s.log.Warn("user role changed", "user_id", id.Hex(), "role", role, "actor_id", actorID.Hex())
+	return s.GetUser(ctx, id)
+}
+
+func (s *Service) summarize(ctx context.Context, users []*auth.User) ([]*UserSummary, error) {
+	ids := make([]bson.ObjectID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+
+	usage, err := s.usage.UsageByUser(ctx, ids)
+	if err != nil {
+		s.log.Error("failed to load user usage", "error", err)
+		return nil, err
+	}
+
+	summaries := make([]*UserSummary, len(users))
+	for i, u := range users {
+		summaries[i] = &UserSummary{User: u, Usage: usage[u.ID]}
+	}
+	return summaries, nil
+}
+
+func (s *Service) lookupError(err error, id bson.ObjectID) error {
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return auth.ErrUserNotFound
+	}
+	s.log.Error("failed to find user", "error", err, "user_id", id.Hex())
+	return ErrListUsers
+}
+
+func (s *Service) updateError(err error, id bson.ObjectID) error {
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return auth.ErrUserNotFound
+	}
+	s.log.Error(ErrUpdateUser.Error(), "error", err, "user_id", id.Hex())
+	return ErrUpdateUser
+}
@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// MockUsersRepo is a mock implementation of UsersRepo
+type MockUsersRepo struct {
+	mock.Mock
+}
+
+func (m *MockUsersRepo) FindByID(ctx context.Context, id bson.ObjectID) (*auth.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+
+func (m *MockUsersRepo) FindByEmail(ctx context.Context, email string) (*auth.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+
+func (m *MockUsersRepo) Search(ctx context.Context, filter UserFilter, limit, offset int) ([]*auth.User, int64, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	return args.Get(0).([]*auth.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUsersRepo) SetDisabled(ctx context.Context, id bson.ObjectID, disabledAt *time.Time) error {
+	args := m.Called(ctx, id, disabledAt)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepo) SetRole(ctx context.Context, id bson.ObjectID, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+// MockUsageRepo is a mock implementation of UsageRepo
+type MockUsageRepo struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepo) UsageByUser(ctx context.Context, userIDs []bson.ObjectID) (map[bson.ObjectID]Usage, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[bson.ObjectID]Usage), args.Error(1)
+}
+
+// MockAccounts is a mock implementation of Accounts
+type MockAccounts struct {
+	mock.Mock
+}
+
+func (m *MockAccounts) ForceSignOut(ctx context.Context, userID bson.ObjectID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAccounts) InvalidateUserStatus(userID bson.ObjectID) {
+	m.Called(userID)
+}
+
+func TestServiceListUsersAttachesUsage(t *testing.T) {
+	withNotes := &auth.User{ID: bson.NewObjectID(), Email: "a@example.com"}
+	empty := &auth.User{ID: bson.NewObjectID(), Email: "b@example.com"}
+
+	users := new(MockUsersRepo)
+	usage := new(MockUsageRepo)
+	users.On("Search", mock.Anything, UserFilter{Q: "example"}, defaultListLimit, 0).
+		Return([]*auth.User{withNotes, empty}, int64(2), nil)
+	usage.On("UsageByUser", mock.Anything, []bson.ObjectID{withNotes.ID, empty.ID}).
+		Return(map[bson.ObjectID]Usage{withNotes.ID: {NoteCount: 3, StorageBytes: 900}}, nil)
+
+	svc := NewService(users, usage, new(MockAccounts), silentLogger)
+	resp, err := svc.ListUsers(context.Background(), ListUsersRequest{Q: " example "})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Users, 2)
+	assert.Equal(t, int64(2), resp.TotalCount)
+	assert.Equal(t, Usage{NoteCount: 3, StorageBytes: 900}, resp.Users[0].Usage)
+	assert.Equal(t, Usage{}, resp.Users[1].Usage)
+}
+
+func TestServiceDisableUserSignsOut(t *testing.T) {
+	target := &auth.User{ID: bson.NewObjectID()}
+	actor := bson.NewObjectID()
+
+	users := new(MockUsersRepo)
+	usage := new(MockUsageRepo)
+	accounts := new(MockAccounts)
+	users.On("SetDisabled", mock.Anything, target.ID, mock.AnythingOfType("*time.Time")).Return(nil)
+	users.On("FindByID", mock.Anything, target.ID).Return(target, nil)
+	usage.On("UsageByUser", mock.Anything, mock.Anything).Return(map[bson.ObjectID]Usage{}, nil)
+	accounts.On("InvalidateUserStatus", target.ID).Return()
+	accounts.On("ForceSignOut", mock.Anything, target.ID).Return(nil)
+
+	svc := NewService(users, usage, accounts, silentLogger)
+	_, err := svc.DisableUser(context.Background(), actor, target.ID)
+	require.NoError(t, err)
+
+	accounts.AssertExpectations(t)
+}
+
+func TestServiceRejectsSelfActions(t *testing.T) {
+	self := bson.NewObjectID()
+	svc := NewService(new(MockUsersRepo), new(MockUsageRepo), new(MockAccounts), silentLogger)
+
+	_, err := svc.DisableUser(context.Background(), self, self)
+	assert.ErrorIs(t, err, ErrSelfAction)
+
+	_, err = svc.SetRole(context.Background(), self, self, auth.RoleUser)
+	assert.ErrorIs(t, err, ErrSelfAction)
+}
+
+func TestServiceFindUserByEmail(t *testing.T) {
+	user := &auth.User{ID: bson.NewObjectID(), Email: "ops@example.com"}
+
+	users := new(MockUsersRepo)
+	usage := new(MockUsageRepo)
+	users.On("FindByEmail", mock.Anything, "ops@example.com").Return(user, nil)
+	users.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	usage.On("UsageByUser", mock.Anything, []bson.ObjectID{user.ID}).Return(map[bson.ObjectID]Usage{}, nil)
+
+	svc := NewService(users, usage, new(MockAccounts), silentLogger)
+	got, err := svc.FindUser(context.Background(), "Ops@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	users.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, auth.ErrUserNotFound)
+	_, err = svc.FindUser(context.Background(), "nobody@example.com")
+	assert.ErrorIs(t, err, auth.ErrUserNotFound)
+}
@@ -0,0 +1,9 @@
+package audit
+
+import "errors"
+
+// ErrBadRequest is returned for malformed list queries.
+var ErrBadRequest = errors.New("bad request")
+
+// ErrListAudit is returned when listing audit entries fails.
+var ErrListAudit = errors.New("failed to list audit entries")
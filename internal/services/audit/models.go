@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionSignUp         = "auth.sign_up"
+	ActionSignIn         = "auth.sign_in"
+	ActionSignInFailed   = "auth.sign_in_failed"
+	ActionRefresh        = "auth.refresh"
+	ActionSignOut        = "auth.sign_out"
+	ActionSignOutAll     = "auth.sign_out_all"
+	ActionPasswordChange = "auth.password_change"
+	ActionNoteCreate     = "note.create"
+	ActionNoteUpdate     = "note.update"
+	ActionNoteDelete     = "note.delete"
+)
+
+// Summary describes what an entry is about before or after the event, e.g.
+// a note's title and body length, never its body or any secret
+type Summary map[string]string
+
+// Entry is an append-only record of a security or data event
+type Entry struct {
+	ID bson.ObjectID `bson:"_id" json:"id" example:"683cdb8aa96ad71e8e075be1"`
+	// UserID is the account the entry belongs to; failed sign-ins with an
+	// unknown email belong to none
+	UserID *bson.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty" example:"683cdb8aa96ad71e8e075bd0"`
+	// ActorID is who acted; failed sign-ins have no actor
+	ActorID *bson.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty" example:"683cdb8aa96ad71e8e075bd0"`
+	Action  string         `bson:"action" json:"action" example:"note.update"`
+	// TargetID is the note or session acted on
+	TargetID  *bson.ObjectID `bson:"target_id,omitempty" json:"target_id,omitempty" example:"683cdb8aa96ad71e8e075bd1"`
+	IP        string         `bson:"ip,omitempty" json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent string         `bson:"user_agent,omitempty" json:"user_agent,omitempty" example:"Mozilla/5.0 (Linux; Android 14)"`
+	RequestID string         `bson:"request_id,omitempty" json:"request_id,omitempty" example:"683cdb8aa96ad71e8e075be0"`
+	Before    Summary        `bson:"before,omitempty" json:"before,omitempty"`
+	After     Summary        `bson:"after,omitempty" json:"after,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time      `bson:"expires_at" json:"-"`
+}
+
+// ListAuditRequest pages through audit entries, newest first
+type ListAuditRequest struct {
+	Action string `query:"action" validate:"omitempty,max=64" example:"auth.sign_in_failed"`
+	// UserID narrows the admin-wide query to one account; a user's own
+	// query ignores it
+	UserID string `query:"user_id" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd0"`
+	// Before is the next_before of the previous page
+	Before string `query:"before" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075be1"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100" example:"50"`
+}
+
+// ListAuditResponse is a page of audit entries, newest first
+type ListAuditResponse struct {
+	Entries []*Entry `json:"entries"`
+	// NextBefore fetches the next page; empty on the last one
+	NextBefore string `json:"next_before,omitempty" example:"683cdb8aa96ad71e8e075be1"`
+}
+
+// Filter selects audit entries; zero fields match every entry
+type Filter struct {
+	UserID *bson.ObjectID
+	Action string
+	// Before keeps entries older than this ID
+	Before *bson.ObjectID
+}
@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+// Repository stores audit entries. It has no way to change or delete one;
+// entries go when they expire.
+type Repository interface {
+	Insert(ctx context.Context, e *Entry) error
+	// List returns up to limit entries matching f, newest first
+	List(ctx context.Context, f Filter, limit int) ([]*Entry, error)
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"note-pulse/internal/utils/reqinfo"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defaultEntries is the page size of a list without a limit
+const defaultEntries = 50
+
+// Service appends audit entries and lists them
+type Service struct {
+	repo      Repository
+	retention time.Duration
+	log       *slog.Logger
+}
+
+// NewService creates a new audit service keeping entries for retention
+func NewService(repo Repository, retention time.Duration, log *slog.Logger) *Service {
+	return &Service{repo: repo, retention: retention, log: log}
+}
+
+// Record appends e, stamped with an ID, the time and the client of the
+// current request. It runs to the end even when the request is canceled,
+// and a failure is logged rather than failing the event it records.
+func (s *Service) Record(ctx context.Context, e *Entry) {
+	info := reqinfo.From(ctx)
+	now := time.Now().UTC()
+	e.ID = bson.NewObjectID()
+	e.IP, e.UserAgent, e.RequestID = info.IP, info.UserAgent, info.RequestID
+	e.CreatedAt = now
+	e.ExpiresAt = now.Add(s.retention)
+
+	if err := s.repo.Insert(context.WithoutCancel(ctx), e); err != nil {
+		s.log.Error("failed to record audit entry", "error", err, "action", e.Action, "request_id", e.RequestID)
+	}
+}
+
+// List returns the entries of userID's own account
+func (s *Service) List(ctx context.Context, userID bson.ObjectID, req ListAuditRequest) (*ListAuditResponse, error) {
+	req.UserID = userID.Hex()
+	return s.list(ctx, req)
+}
+
+// ListAll returns the entries of every account, or of req.UserID; it is
+// for admins
+func (s *Service) ListAll(ctx context.Context, req ListAuditRequest) (*ListAuditResponse, error) {
+	return s.list(ctx, req)
+}
+
+func (s *Service) list(ctx context.Context, req ListAuditRequest) (*ListAuditResponse, error) {
+	f := Filter{Action: req.Action}
+	if req.UserID != "" {
+		id, err := bson.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		f.UserID = &id
+	}
+	if req.Before != "" {
+		id, err := bson.ObjectIDFromHex(req.Before)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		f.Before = &id
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultEntries
+	}
+
+	entries, err := s.repo.List(ctx, f, limit)
+	if err != nil {
+		s.log.Error(ErrListAudit.Error(), "error", err, "user_id", req.UserID)
+		return nil, ErrListAudit
+	}
+	resp := &ListAuditResponse{Entries: entries}
+	if len(entries) == limit {
+		resp.NextBefore = entries[len(entries)-1].ID.Hex()
+	}
+	return resp, nil
+}
+
+// Diff keeps the keys of before and after whose values differ, so an entry
+// shows only what changed
+func Diff(before, after Summary) (Summary, Summary) {
+	changedBefore, changedAfter := Summary{}, Summary{}
+	for k, v := range before {
+		if after[k] != v {
+			changedBefore[k] = v
+		}
+	}
+	for k, v := range after {
+		if before[k] != v {
+			changedAfter[k] = v
+		}
+	}
+	return changedBefore, changedAfter
+}
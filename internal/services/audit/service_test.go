@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"note-pulse/internal/utils/reqinfo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memRepo keeps entries in insertion order, which is ID order
+type memRepo struct {
+	entries []*Entry
+	err     error
+	ctxErr  error
+}
+
+func (r *memRepo) Insert(ctx context.Context, e *Entry) error {
+	r.ctxErr = ctx.Err()
+	if r.err != nil {
+		return r.err
+	}
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *memRepo) List(_ context.Context, f Filter, limit int) ([]*Entry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	result := []*Entry{}
+	for i := len(r.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		e := r.entries[i]
+		switch {
+		case f.UserID != nil && (e.UserID == nil || *e.UserID != *f.UserID),
+			f.Action != "" && e.Action != f.Action,
+			f.Before != nil && e.ID.Hex() >= f.Before.Hex():
+			continue
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func TestRecord(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewService(repo, 24*time.Hour, silentLogger)
+	userID := bson.NewObjectID()
+
+	ctx := reqinfo.With(context.Background(), reqinfo.Info{IP: "203.0.113.7", UserAgent: "np-test/1.0", RequestID: "req-1"})
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	svc.Record(ctx, &Entry{UserID: &userID, ActorID: &userID, Action: ActionSignIn})
+
+	require.Len(t, repo.entries, 1)
+	got := repo.entries[0]
+	assert.False(t, got.ID.IsZero())
+	assert.Equal(t, "203.0.113.7", got.IP)
+	assert.Equal(t, "np-test/1.0", got.UserAgent)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.WithinDuration(t, time.Now(), got.CreatedAt, time.Second)
+	assert.Equal(t, 24*time.Hour, got.ExpiresAt.Sub(got.CreatedAt))
+	assert.NoError(t, repo.ctxErr, "a canceled request still gets its entry")
+
+	repo.err = errors.New("no primary")
+	assert.NotPanics(t, func() {
+		svc.Record(context.Background(), &Entry{Action: ActionSignInFailed})
+	})
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	repo := &memRepo{}
+	svc := NewService(repo, time.Hour, silentLogger)
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	for _, id := range []bson.ObjectID{userID, otherID, userID, userID} {
+		svc.Record(ctx, &Entry{UserID: &id, ActorID: &id, Action: ActionNoteCreate})
+	}
+
+	// A user's own query ignores user_id
+	resp, err := svc.List(ctx, userID, ListAuditRequest{UserID: otherID.Hex(), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, repo.entries[3].ID, resp.Entries[0].ID)
+	assert.Equal(t, resp.Entries[1].ID.Hex(), resp.NextBefore)
+
+	resp, err = svc.List(ctx, userID, ListAuditRequest{Before: resp.NextBefore, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 1)
+	assert.Equal(t, repo.entries[0].ID, resp.Entries[0].ID)
+	assert.Empty(t, resp.NextBefore, "the last page")
+
+	resp, err = svc.ListAll(ctx, ListAuditRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.Entries, 4)
+	resp, err = svc.ListAll(ctx, ListAuditRequest{UserID: otherID.Hex()})
+	require.NoError(t, err)
+	assert.Len(t, resp.Entries, 1)
+
+	_, err = svc.ListAll(ctx, ListAuditRequest{Before: "nope"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	repo.err = errors.New("no primary")
+	_, err = svc.List(ctx, userID, ListAuditRequest{})
+	assert.ErrorIs(t, err, ErrListAudit)
+}
+
+func TestDiff(t *testing.T) {
+	before, after := Diff(
+		Summary{"title": "Plan", "body_chars": "10", "color": "#FFD700"},
+		Summary{"title": "Plan B", "body_chars": "10", "format": "markdown"},
+	)
+	assert.Equal(t, Summary{"title": "Plan", "color": "#FFD700"}, before)
+	assert.Equal(t, Summary{"title": "Plan B", "format": "markdown"}, after)
+}
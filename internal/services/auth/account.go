@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-pulse/internal/services/audit"
+	"note-pulse/internal/utils/crypto"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// emailChangeTTL is how long an email confirmation token stays valid
+	emailChangeTTL = 24 * time.Hour
+
+	// purgeQueueSize bounds deletions waiting for the purger; overflow is
+	// picked up by the periodic sweep
+	purgeQueueSize = 64
+
+	// purgeSweepInterval is how often the purger looks for deletions that
+	// were not queued, e.g. because the server restarted mid-purge
+	purgeSweepInterval = time.Minute
+
+	purgeSweepBatch = 50
+)
+
+// Mailer delivers account emails
+type Mailer interface {
+	SendEmailChangeToken(ctx context.Context, to, token string) error
+}
+
+// UserDataPurger deletes everything a service stores for a user. Services
+// owning user data register one so account deletion cascades to them.
+type UserDataPurger interface {
+	PurgeUser(ctx context.Context, userID bson.ObjectID) error
+}
+
+// SetMailer wires the transport used for confirmation emails. Without one,
+// email changes fail with ErrMailNotConfigured.
+func (s *Service) SetMailer(m Mailer) {
+	s.mailer = m
+}
+
+// AddPurger registers a purger that runs when an account is deleted
+func (s *Service) AddPurger(p UserDataPurger) {
+	s.purgers = append(s.purgers, p)
+}
+
+// ChangePasswordRequest represents a password change request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required" example:"Password123"`
+	NewPassword     string `json:"new_password" validate:"required,password" example:"NewPassword123"`
+}
+
+// ChangeEmailRequest starts an email change
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email" example:"new@example.com"`
+	Password string `json:"password" validate:"required" example:"Password123"`
+}
+
+// ConfirmEmailRequest completes an email change
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required" example:"email_token_example_abcd1234"`
+}
+
+// DeleteAccountRequest confirms an account deletion
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required" example:"Password123"`
+}
+
+// verifyPassword loads the user and checks password against it. Wrong
+// passwords count toward the sign-in lockout of the user's email, and a
+// locked account fails even with the right one, so a stolen access token
+// cannot be used to guess the password.
+func (s *Service) verifyPassword(ctx context.Context, userID bson.ObjectID, password string) (*User, error) {
+	user, err := s.usersRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error("failed to find user", "error", err, "user_id", userID.Hex())
+		return nil, err
+	}
+	if user.DeletionRequestedAt != nil {
+		return nil, ErrUserNotFound
+	}
+	if s.isLockedOut(ctx, user.Email) {
+		s.recordSignInFailure(ctx, user.Email, &user.ID, failureLockedOut)
+		return nil, ErrWrongPassword
+	}
+	if err := crypto.CheckPassword(password, user.PasswordHash); err != nil {
+		s.log.Info("password confirmation failed", "user_id", userID.Hex())
+		s.recordLoginFailure(ctx, user.Email)
+		s.recordSignInFailure(ctx, user.Email, &user.ID, failureWrongPassword)
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the user's password and signs every other device
+// out. currentSessionID keeps the calling device signed in; when it is empty
+// all sessions are revoked.
+func (s *Service) ChangePassword(ctx context.Context, userID bson.ObjectID, currentSessionID string, req ChangePasswordRequest) error {
+	user, err := s.verifyPassword(ctx, userID, req.CurrentPassword)
+	if err != nil {
+		if errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		return ErrChangePassword
+	}
+
+	hash, err := crypto.HashPassword(req.NewPassword, s.config.BcryptCost)
+	if err != nil {
+		s.log.Error("failed to hash password", "error", err, "user_id", userID.Hex())
+		return ErrChangePassword
+	}
+	if err := s.usersRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		s.log.Error(ErrChangePassword.Error(), "error", err, "user_id", userID.Hex())
+		return ErrChangePassword
+	}
+
+	s.ResetLoginAttempts(ctx, user.Email)
+
+	if err := s.revokeOtherSessions(ctx, userID, currentSessionID); err != nil {
+		// The password is already changed; report the failure so the
+		// client can fall back to sign-out-all.
+		s.log.Error("failed to revoke other sessions after password change", "error", err, "user_id", userID.Hex())
+		return ErrChangePassword
+	}
+	s.record(ctx, audit.ActionPasswordChange, userID, nil, nil)
+
+	s.log.Info("password changed", "user_id", userID.Hex())
+	return nil
+}
+
+// revokeOtherSessions revokes every session except keep and closes their
+// live connections
+func (s *Service) revokeOtherSessions(ctx context.Context, userID bson.ObjectID, keep string) error {
+	tokens, err := s.refreshTokenRepo.ListActiveForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[bson.ObjectID]struct{}, len(tokens))
+	for _, t := range tokens {
+		key := t.SessionKey()
+		if _, dup := seen[key]; dup || key.Hex() == keep {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if _, err := s.refreshTokenRepo.RevokeSession(ctx, userID, key); err != nil {
+			return err
+		}
+		s.closeSession(ctx, userID, key)
+	}
+	return nil
+}
+
+// RequestEmailChange verifies the password and sends a confirmation token to
+// the new address. The email only changes once the token is confirmed.
+func (s *Service) RequestEmailChange(ctx context.Context, userID bson.ObjectID, req ChangeEmailRequest) error {
+	if s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+	newEmail := normalizeEmail(req.NewEmail)
+
+	user, err := s.verifyPassword(ctx, userID, req.Password)
+	if err != nil {
+		if errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		return ErrChangeEmail
+	}
+	if newEmail == user.Email {
+		return ErrEmailTaken
+	}
+
+	existing, err := s.usersRepo.FindByEmail(ctx, newEmail)
+	if err == nil && existing != nil {
+		return ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		s.log.Error(ErrChangeEmail.Error(), "error", err, "user_id", userID.Hex())
+		return ErrChangeEmail
+	}
+
+	token, err := crypto.NewOpaqueToken()
+	if err != nil {
+		s.log.Error("failed to generate email token", "error", err, "user_id", userID.Hex())
+		return ErrChangeEmail
+	}
+
+	expiresAt := time.Now().UTC().Add(emailChangeTTL)
+	if err := s.usersRepo.SetPendingEmail(ctx, userID, newEmail, crypto.HashToken(token), expiresAt); err != nil {
+		s.log.Error(ErrChangeEmail.Error(), "error", err, "user_id", userID.Hex())
+		return ErrChangeEmail
+	}
+
+	if err := s.mailer.SendEmailChangeToken(ctx, newEmail, token); err != nil {
+		s.log.Error("failed to send email change token", "error", err, "user_id", userID.Hex())
+		return ErrChangeEmail
+	}
+
+	s.log.Info("email change requested", "user_id", userID.Hex())
+	return nil
+}
+
+// ConfirmEmailChange applies a pending email change
+func (s *Service) ConfirmEmailChange(ctx context.Context, userID bson.ObjectID, req ConfirmEmailRequest) (*User, error) {
+	user, err := s.usersRepo.ConfirmEmail(ctx, userID, crypto.HashToken(req.Token))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			return nil, ErrInvalidEmailToken
+		case errors.Is(err, ErrDuplicate):
+			return nil, ErrEmailTaken
+		}
+		s.log.Error(ErrChangeEmail.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrChangeEmail
+	}
+
+	s.log.Info("email changed", "user_id", userID.Hex())
+	return user, nil
+}
+
+// DeleteAccount schedules the account and all of its data for deletion. The
+// user is signed out everywhere right away; the data is purged in the
+// background by RunAccountPurger.
+func (s *Service) DeleteAccount(ctx context.Context, userID bson.ObjectID, req DeleteAccountRequest) error {
+	if _, err := s.verifyPassword(ctx, userID, req.Password); err != nil {
+		if errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		return ErrDeleteAccount
+	}
+
+	if err := s.usersRepo.MarkForDeletion(ctx, userID, time.Now().UTC()); err != nil {
+		s.log.Error(ErrDeleteAccount.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteAccount
+	}
+
+	if err := s.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		// Not fatal: the purger deletes the tokens and refresh already
+		// rejects users scheduled for deletion.
+		s.log.Error("failed to revoke refresh tokens of deleted account", "error", err, "user_id", userID.Hex())
+	}
+	s.sessionCache.forgetUser(userID)
+
+	select {
+	case s.purgeQueue <- userID:
+	default:
+		s.log.Warn("account purge queue full, deferring to sweep", "user_id", userID.Hex())
+	}
+
+	s.log.Info("account deletion scheduled", "user_id", userID.Hex())
+	return nil
+}
+
+// RunAccountPurger purges accounts scheduled for deletion until ctx is done.
+// Besides queued deletions it periodically sweeps for leftovers, so a purge
+// interrupted by a restart is resumed.
+func (s *Service) RunAccountPurger(ctx context.Context) error {
+	ticker := time.NewTicker(purgeSweepInterval)
+	defer ticker.Stop()
+
+	s.sweepPendingDeletions(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case userID := <-s.purgeQueue:
+			if err := s.purgeAccount(ctx, userID); err != nil {
+				s.log.Error("account purge failed, will retry on next sweep", "error", err, "user_id", userID.Hex())
+			}
+		case <-ticker.C:
+			s.sweepPendingDeletions(ctx)
+		}
+	}
+}
+
+func (s *Service) sweepPendingDeletions(ctx context.Context) {
+	users, err := s.usersRepo.ListPendingDeletion(ctx, purgeSweepBatch)
+	if err != nil {
+		s.log.Error("failed to list accounts pending deletion", "error", err)
+		return
+	}
+	for _, u := range users {
+		if err := s.purgeAccount(ctx, u.ID); err != nil {
+			s.log.Error("account purge failed, will retry on next sweep", "error", err, "user_id", u.ID.Hex())
+		}
+	}
+}
+
+// purgeAccount deletes the user's data, then the user, then closes any live
+// connections. Every step is idempotent so a failed purge can be retried.
+func (s *Service) purgeAccount(ctx context.Context, userID bson.ObjectID) error {
+	user, err := s.usersRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.DeletionRequestedAt == nil {
+		return nil
+	}
+
+	for _, p := range s.purgers {
+		if err := p.PurgeUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if err := s.refreshTokenRepo.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	s.ResetLoginAttempts(ctx, user.Email)
+	if err := s.usersRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	if s.sessionCloser != nil {
+		s.sessionCloser.CloseUser(ctx, userID)
+	}
+
+	s.log.Info("account purged", "user_id", userID.Hex())
+	return nil
+}
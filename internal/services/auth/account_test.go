@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"note-pulse/internal/utils/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// recordingMailer captures the tokens it is asked to send
+type recordingMailer struct {
+	to, token string
+}
+
+func (m *recordingMailer) SendEmailChangeToken(_ context.Context, to, token string) error {
+	m.to, m.token = to, token
+	return nil
+}
+
+// stubPurger records purged users
+type stubPurger struct {
+	purged []bson.ObjectID
+	err    error
+}
+
+func (p *stubPurger) PurgeUser(_ context.Context, userID bson.ObjectID) error {
+	p.purged = append(p.purged, userID)
+	return p.err
+}
+
+func newAccountTestUser(t *testing.T) *User {
+	t.Helper()
+	hash, err := crypto.HashPassword(testPassword, 4)
+	require.NoError(t, err)
+	return &User{ID: bson.NewObjectID(), Email: testUserEmail, PasswordHash: hash}
+}
+
+func TestServiceChangePasswordRevokesOtherSessions(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.BcryptCost = 4
+	user := newAccountTestUser(t)
+	current, other := bson.NewObjectID(), bson.NewObjectID()
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	userRepo.On("UpdatePassword", mock.Anything, user.ID, mock.AnythingOfType("string")).Return(nil)
+	refreshRepo.On("ListActiveForUser", mock.Anything, user.ID).Return([]*RefreshToken{
+		{ID: bson.NewObjectID(), SessionID: current},
+		{ID: bson.NewObjectID(), SessionID: other},
+	}, nil)
+	refreshRepo.On("RevokeSession", mock.Anything, user.ID, other).Return(int64(1), nil)
+
+	closer := &stubSessionCloser{}
+	service := NewService(userRepo, refreshRepo, cfg, silentLogger)
+	service.SetSessionCloser(closer)
+
+	err := service.ChangePassword(context.Background(), user.ID, current.Hex(), ChangePasswordRequest{
+		CurrentPassword: testPassword,
+		NewPassword:     "NewPassword123",
+	})
+	require.NoError(t, err)
+
+	newHash := userRepo.Calls[1].Arguments.String(2)
+	assert.NoError(t, crypto.CheckPassword("NewPassword123", newHash))
+	assert.Equal(t, []string{other.Hex()}, closer.closed, "only other sessions are signed out")
+	refreshRepo.AssertNotCalled(t, "RevokeSession", mock.Anything, user.ID, current)
+}
+
+func TestServiceChangePasswordWrongPassword(t *testing.T) {
+	user := newAccountTestUser(t)
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+	service := NewService(userRepo, refreshRepo, getTestConfig(), silentLogger)
+	err := service.ChangePassword(context.Background(), user.ID, "", ChangePasswordRequest{
+		CurrentPassword: "WrongPassword1",
+		NewPassword:     "NewPassword123",
+	})
+	require.ErrorIs(t, err, ErrWrongPassword)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceEmailChange(t *testing.T) {
+	user := newAccountTestUser(t)
+	const newEmail = "new@example.com"
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	userRepo.On("FindByEmail", mock.Anything, newEmail).Return(nil, ErrUserNotFound)
+
+	var storedHash string
+	userRepo.On("SetPendingEmail", mock.Anything, user.ID, newEmail, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { storedHash = args.String(3) }).
+		Return(nil)
+
+	mailer := &recordingMailer{}
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	service.SetMailer(mailer)
+
+	err := service.RequestEmailChange(context.Background(), user.ID, ChangeEmailRequest{NewEmail: " New@Example.com ", Password: testPassword})
+	require.NoError(t, err)
+	assert.Equal(t, newEmail, mailer.to)
+	assert.NotEqual(t, mailer.token, storedHash, "only the token hash is stored")
+	assert.Equal(t, crypto.HashToken(mailer.token), storedHash)
+
+	updated := &User{ID: user.ID, Email: newEmail}
+	userRepo.On("ConfirmEmail", mock.Anything, user.ID, storedHash).Return(updated, nil)
+	userRepo.On("ConfirmEmail", mock.Anything, user.ID, mock.Anything).Return(nil, ErrUserNotFound)
+
+	got, err := service.ConfirmEmailChange(context.Background(), user.ID, ConfirmEmailRequest{Token: mailer.token})
+	require.NoError(t, err)
+	assert.Equal(t, newEmail, got.Email)
+
+	_, err = service.ConfirmEmailChange(context.Background(), user.ID, ConfirmEmailRequest{Token: "bogus"})
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+}
+
+func TestServiceEmailChangeTaken(t *testing.T) {
+	user := newAccountTestUser(t)
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	userRepo.On("FindByEmail", mock.Anything, "taken@example.com").Return(&User{ID: bson.NewObjectID()}, nil)
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	service.SetMailer(&recordingMailer{})
+	err := service.RequestEmailChange(context.Background(), user.ID, ChangeEmailRequest{NewEmail: "taken@example.com", Password: testPassword})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+}
+
+func TestServiceEmailChangeWithoutMailer(t *testing.T) {
+	user := newAccountTestUser(t)
+	userRepo := new(MockUsersRepo)
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	err := service.RequestEmailChange(context.Background(), user.ID, ChangeEmailRequest{NewEmail: "new@example.com", Password: testPassword})
+	assert.ErrorIs(t, err, ErrMailNotConfigured)
+	userRepo.AssertNotCalled(t, "SetPendingEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceDeleteAccountPurgesInBackground(t *testing.T) {
+	user := newAccountTestUser(t)
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Once()
+	userRepo.On("MarkForDeletion", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			at := args.Get(2).(time.Time)
+			user.DeletionRequestedAt = &at
+		}).
+		Return(nil)
+	refreshRepo.On("RevokeAllForUser", mock.Anything, user.ID).Return(nil)
+
+	purger := &stubPurger{}
+	closer := &stubSessionCloser{}
+	service := NewService(userRepo, refreshRepo, getTestConfig(), silentLogger)
+	service.AddPurger(purger)
+	service.SetSessionCloser(closer)
+
+	require.NoError(t, service.DeleteAccount(context.Background(), user.ID, DeleteAccountRequest{Password: testPassword}))
+	assert.Empty(t, purger.purged, "purging happens in the background")
+
+	done := make(chan struct{})
+	userRepo.On("ListPendingDeletion", mock.Anything, purgeSweepBatch).Return([]*User{}, nil)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	refreshRepo.On("DeleteAllForUser", mock.Anything, user.ID).Return(nil)
+	userRepo.On("Delete", mock.Anything, user.ID).Run(func(mock.Arguments) { close(done) }).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_ = service.RunAccountPurger(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("account was not purged")
+	}
+	cancel()
+	<-stopped
+
+	assert.Equal(t, []bson.ObjectID{user.ID}, purger.purged)
+	assert.Equal(t, []string{"user:" + user.ID.Hex()}, closer.closed, "live connections are closed after the purge")
+}
+
+func TestServicePurgeStopsOnPurgerError(t *testing.T) {
+	user := newAccountTestUser(t)
+	now := time.Now().UTC()
+	user.DeletionRequestedAt = &now
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	service.AddPurger(&stubPurger{err: errors.New("boom")})
+
+	require.Error(t, service.purgeAccount(context.Background(), user.ID))
+	userRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestServiceSignInRejectsAccountPendingDeletion(t *testing.T) {
+	user := newAccountTestUser(t)
+	now := time.Now().UTC()
+	user.DeletionRequestedAt = &now
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByEmail", mock.Anything, testUserEmail).Return(user, nil)
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	_, err := service.SignIn(context.Background(), SignInRequest{Email: testUserEmail, Password: testPassword})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+
+	"note-pulse/internal/services/audit"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Reasons of failed sign-ins in the audit log
+const (
+	failureUnknownEmail    = "unknown_email"
+	failureWrongPassword   = "wrong_password"
+	failureLockedOut       = "locked_out"
+	failureDisabled        = "disabled"
+	failureDeletionPending = "deletion_requested"
+	failureLookup          = "lookup_failed"
+)
+
+// Keys of the audit summaries of accounts
+const (
+	auditEmail  = "email"
+	auditReason = "reason"
+)
+
+// AuditSink records security events of accounts; audit.Service implements
+// it. Record must not fail the event it records.
+type AuditSink interface {
+	Record(ctx context.Context, e *audit.Entry)
+}
+
+// SetAuditSink makes sign-ups, sign-ins, refreshes, sign-outs and password
+// changes leave an audit entry
+func (s *Service) SetAuditSink(a AuditSink) {
+	s.audit = a
+}
+
+// record appends an entry of action by userID on their own account
+func (s *Service) record(ctx context.Context, action string, userID bson.ObjectID, targetID *bson.ObjectID, after audit.Summary) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.Record(ctx, &audit.Entry{
+		UserID:   &userID,
+		ActorID:  &userID,
+		Action:   action,
+		TargetID: targetID,
+		After:    after,
+	})
+}
+
+// recordSignInFailure appends a failed sign-in with email, which belongs to
+// the account of userID when the email is known
+func (s *Service) recordSignInFailure(ctx context.Context, email string, userID *bson.ObjectID, reason string) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.Record(ctx, &audit.Entry{
+		UserID: userID,
+		Action: audit.ActionSignInFailed,
+		After:  audit.Summary{auditEmail: email, auditReason: reason},
+	})
+}
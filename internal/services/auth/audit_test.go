@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"note-pulse/internal/services/audit"
+	"note-pulse/internal/utils/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// recordingSink keeps the audit entries it is given
+type recordingSink struct {
+	entries []*audit.Entry
+}
+
+func (s *recordingSink) Record(_ context.Context, e *audit.Entry) {
+	s.entries = append(s.entries, e)
+}
+
+func TestServiceAuditsSignIns(t *testing.T) {
+	ctx := context.Background()
+	hashedPassword, err := crypto.HashPassword(testPassword, 8)
+	require.NoError(t, err)
+	user := &User{ID: bson.NewObjectID(), Email: testUserEmail, PasswordHash: hashedPassword}
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	userRepo.On("FindByEmail", mock.Anything, testUserEmail).Return(user, nil)
+	userRepo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, ErrUserNotFound)
+	refreshRepo.On("Create", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	refreshRepo.On("RevokeAllForUser", mock.Anything, user.ID).Return(nil)
+
+	sink := &recordingSink{}
+	service := NewService(userRepo, refreshRepo, getTestConfig(), silentLogger)
+	service.SetAuditSink(sink)
+
+	_, err = service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: "Wrong-password1"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.SignIn(ctx, SignInRequest{Email: "nobody@example.com", Password: testPassword})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: testPassword})
+	require.NoError(t, err)
+	require.NoError(t, service.SignOutAll(ctx, user.ID))
+
+	require.Len(t, sink.entries, 4)
+	wrong, unknown, signedIn, signedOut := sink.entries[0], sink.entries[1], sink.entries[2], sink.entries[3]
+
+	assert.Equal(t, audit.ActionSignInFailed, wrong.Action)
+	assert.Equal(t, &user.ID, wrong.UserID, "a failure with a known email belongs to its account")
+	assert.Nil(t, wrong.ActorID)
+	assert.Equal(t, audit.Summary{"email": testUserEmail, "reason": failureWrongPassword}, wrong.After)
+
+	assert.Nil(t, unknown.UserID)
+	assert.Equal(t, failureUnknownEmail, unknown.After["reason"])
+
+	assert.Equal(t, audit.ActionSignIn, signedIn.Action)
+	assert.Equal(t, &user.ID, signedIn.ActorID)
+	assert.NotNil(t, signedIn.TargetID, "a sign-in names its session")
+
+	assert.Equal(t, audit.ActionSignOutAll, signedOut.Action)
+}
@@ -20,9 +20,46 @@ var ErrSignOut = errors.New("failed to sign out")
 // ErrSignOutAll is returned when sign out all process fails.
 var ErrSignOutAll = errors.New("failed to sign out all devices")
 
+// ErrListSessions is returned when listing sessions fails.
+var ErrListSessions = errors.New("failed to list sessions")
+
+// ErrRevokeSession is returned when revoking a session fails.
+var ErrRevokeSession = errors.New("failed to revoke session")
+
+// ErrChangePassword is returned when a password change cannot be stored.
+var ErrChangePassword = errors.New("failed to change password")
+
+// ErrChangeEmail is returned when an email change cannot be stored.
+var ErrChangeEmail = errors.New("failed to change email")
+
+// ErrDeleteAccount is returned when an account deletion cannot be scheduled.
+var ErrDeleteAccount = errors.New("failed to delete account")
+
+// ErrWrongPassword is returned when the password confirming a sensitive
+// account operation does not match.
+var ErrWrongPassword = errors.New("password is incorrect")
+
+// ErrEmailTaken is returned when the requested email belongs to another account.
+var ErrEmailTaken = errors.New("email is already in use")
+
+// ErrMailNotConfigured is returned when an email must be sent but no mail
+// transport is configured.
+var ErrMailNotConfigured = errors.New("email delivery is not configured")
+
+// ErrInvalidEmailToken is returned when an email confirmation token is
+// unknown, already used or expired.
+var ErrInvalidEmailToken = errors.New("invalid or expired email confirmation token")
+
 // ErrInvalidCredentials is returned when user provides invalid login credentials.
 var ErrInvalidCredentials = errors.New("invalid credentials")
 
+// ErrAccountDisabled is returned when an admin disabled the account.
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// ErrSessionRevoked is returned when an access token belongs to a session
+// that was signed out or revoked.
+var ErrSessionRevoked = errors.New("session was revoked")
+
 // ErrRegistrationFailed is returned when user registration fails.
 var ErrRegistrationFailed = errors.New("registration failed")
 
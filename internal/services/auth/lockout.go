@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// maxFailureDelay caps the progressive delay applied after failed sign-ins
+const maxFailureDelay = 5 * time.Second
+
+// SetLoginAttempts enables per-account brute-force protection. Without it
+// sign-in relies on the IP rate limiter only.
+func (s *Service) SetLoginAttempts(repo LoginAttemptsRepo) {
+	s.loginAttempts = repo
+}
+
+func (s *Service) lockoutEnabled() bool {
+	return s.loginAttempts != nil && s.config.LoginMaxFailures > 0
+}
+
+func (s *Service) lockoutWindow() time.Duration {
+	return time.Duration(s.config.LoginLockoutMinutes) * time.Minute
+}
+
+// isLockedOut reports whether sign-in for email is temporarily blocked.
+// Storage errors fail open: a flaky lockout store must not lock everyone out.
+func (s *Service) isLockedOut(ctx context.Context, email string) bool {
+	if !s.lockoutEnabled() {
+		return false
+	}
+
+	attempts, err := s.loginAttempts.Find(ctx, email)
+	if err != nil {
+		s.log.Error("failed to load login attempts", "error", err, "email", email)
+		return false
+	}
+	if !attempts.Locked(time.Now().UTC()) {
+		return false
+	}
+
+	lockedSignIns.Inc()
+	s.log.Warn("sign-in rejected: account locked", "email", email, "locked_until", attempts.LockedUntil)
+	return true
+}
+
+// recordLoginFailure counts a failed sign-in, locks the account once the
+// threshold is reached and slows the caller down progressively.
+func (s *Service) recordLoginFailure(ctx context.Context, email string) {
+	signInFailures.Inc()
+	if !s.lockoutEnabled() {
+		return
+	}
+
+	attempts, err := s.loginAttempts.RecordFailure(ctx, email, s.lockoutWindow())
+	if err != nil {
+		s.log.Error("failed to record login failure", "error", err, "email", email)
+		return
+	}
+
+	if attempts.Failures >= s.config.LoginMaxFailures && !attempts.Locked(time.Now().UTC()) {
+		until := time.Now().UTC().Add(s.lockoutWindow())
+		if err := s.loginAttempts.Lock(ctx, email, until); err != nil {
+			s.log.Error("failed to lock account", "error", err, "email", email)
+		} else {
+			accountLockouts.Inc()
+			s.log.Warn("account locked after failed sign-ins", "email", email, "failures", attempts.Failures, "locked_until", until)
+		}
+	}
+
+	s.tarpit(ctx, attempts.Failures)
+}
+
+// tarpit sleeps base * 2^(failures-1), capped at maxFailureDelay
+func (s *Service) tarpit(ctx context.Context, failures int) {
+	delay := time.Duration(s.config.LoginFailureDelayMs) * time.Millisecond
+	if delay <= 0 || failures <= 0 {
+		return
+	}
+	for i := 1; i < failures && delay < maxFailureDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxFailureDelay)
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// ResetLoginAttempts clears failed attempts and any lockout for email. It is
+// called after a successful sign-in or a password change.
+func (s *Service) ResetLoginAttempts(ctx context.Context, email string) {
+	if s.loginAttempts == nil {
+		return
+	}
+	email = normalizeEmail(email)
+	if err := s.loginAttempts.Reset(ctx, email); err != nil {
+		s.log.Error("failed to reset login attempts", "error", err, "email", email)
+	}
+}
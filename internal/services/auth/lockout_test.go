@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"note-pulse/internal/utils/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memLoginAttempts is an in-memory LoginAttemptsRepo
+type memLoginAttempts struct {
+	records map[string]*LoginAttempts
+}
+
+func newMemLoginAttempts() *memLoginAttempts {
+	return &memLoginAttempts{records: map[string]*LoginAttempts{}}
+}
+
+func (m *memLoginAttempts) Find(_ context.Context, email string) (*LoginAttempts, error) {
+	return m.records[email], nil
+}
+
+func (m *memLoginAttempts) RecordFailure(_ context.Context, email string, window time.Duration) (*LoginAttempts, error) {
+	now := time.Now().UTC()
+	a := m.records[email]
+	if a == nil || !a.ExpiresAt.After(now) {
+		a = &LoginAttempts{Email: email}
+		m.records[email] = a
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	if exp := now.Add(window); exp.After(a.ExpiresAt) {
+		a.ExpiresAt = exp
+	}
+	return a, nil
+}
+
+func (m *memLoginAttempts) Lock(_ context.Context, email string, until time.Time) error {
+	m.records[email].LockedUntil = &until
+	return nil
+}
+
+func (m *memLoginAttempts) Reset(_ context.Context, email string) error {
+	delete(m.records, email)
+	return nil
+}
+
+func newLockoutTestService(t *testing.T) (*Service, *MockUsersRepo, *memLoginAttempts) {
+	t.Helper()
+
+	cfg := getTestConfig()
+	cfg.AccessTokenMinutes = 15
+	cfg.LoginMaxFailures = 3
+	cfg.LoginLockoutMinutes = 15
+
+	hashedPassword, err := crypto.HashPassword(testPassword, 4)
+	require.NoError(t, err)
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByEmail", mock.Anything, testUserEmail).
+		Return(&User{ID: bson.NewObjectID(), Email: testUserEmail, PasswordHash: hashedPassword}, nil)
+	userRepo.On("FindByEmail", mock.Anything, "ghost@example.com").Return(nil, ErrUserNotFound)
+
+	refreshRepo := new(MockRefreshTokensRepo)
+	refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	attempts := newMemLoginAttempts()
+	service := NewService(userRepo, refreshRepo, cfg, silentLogger)
+	service.SetLoginAttempts(attempts)
+	return service, userRepo, attempts
+}
+
+func TestSignInLocksAccountAfterRepeatedFailures(t *testing.T) {
+	service, userRepo, attempts := newLockoutTestService(t)
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: "WrongPassword1"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	require.True(t, attempts.records[testUserEmail].Locked(time.Now().UTC()))
+
+	lookups := len(userRepo.Calls)
+	_, err := service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: testPassword})
+	require.ErrorIs(t, err, ErrInvalidCredentials, "locked accounts get the generic error, even with the right password")
+	assert.Len(t, userRepo.Calls, lookups, "locked sign-ins must not reach the password check")
+}
+
+func TestSignInLocksUnknownEmails(t *testing.T) {
+	service, _, attempts := newLockoutTestService(t)
+
+	for range 3 {
+		_, err := service.SignIn(context.Background(), SignInRequest{Email: "Ghost@example.com", Password: testPassword})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	assert.True(t, attempts.records["ghost@example.com"].Locked(time.Now().UTC()),
+		"unknown emails lock just like real ones so lockouts do not reveal accounts")
+}
+
+func TestSignInSuccessResetsFailures(t *testing.T) {
+	service, _, attempts := newLockoutTestService(t)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: "WrongPassword1"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	require.Equal(t, 2, attempts.records[testUserEmail].Failures)
+
+	_, err := service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: testPassword})
+	require.NoError(t, err)
+	assert.NotContains(t, attempts.records, testUserEmail)
+}
+
+func TestPasswordConfirmationsLockAccount(t *testing.T) {
+	service, userRepo, attempts := newLockoutTestService(t)
+	ctx := context.Background()
+
+	user, err := userRepo.FindByEmail(ctx, testUserEmail)
+	require.NoError(t, err)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+	for range 3 {
+		err := service.DeleteAccount(ctx, user.ID, DeleteAccountRequest{Password: "WrongPassword1"})
+		require.ErrorIs(t, err, ErrWrongPassword)
+	}
+	require.True(t, attempts.records[testUserEmail].Locked(time.Now().UTC()),
+		"wrong confirmations count like failed sign-ins")
+
+	err = service.ChangePassword(ctx, user.ID, "", ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "NewPassword123"})
+	assert.ErrorIs(t, err, ErrWrongPassword, "a locked account cannot confirm its password")
+	_, err = service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: testPassword})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSignInLockExpires(t *testing.T) {
+	service, _, attempts := newLockoutTestService(t)
+
+	past := time.Now().UTC().Add(-time.Minute)
+	attempts.records[testUserEmail] = &LoginAttempts{Email: testUserEmail, Failures: 3, LockedUntil: &past, ExpiresAt: past}
+
+	_, err := service.SignIn(context.Background(), SignInRequest{Email: testUserEmail, Password: testPassword})
+	require.NoError(t, err)
+}
+
+func TestTarpitIsCappedAndCancellable(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.LoginFailureDelayMs = 1000
+	service := NewService(new(MockUsersRepo), new(MockRefreshTokensRepo), cfg, silentLogger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	service.tarpit(ctx, 50)
+	assert.Less(t, time.Since(start), time.Second, "tarpit should return once the request is gone")
+}
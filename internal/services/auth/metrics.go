@@ -0,0 +1,24 @@
+package auth
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	signInFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "auth_sign_in_failures_total",
+		Help: "Failed sign-in attempts (unknown email or wrong password)",
+	})
+	accountLockouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "auth_account_lockouts_total",
+		Help: "Accounts locked after too many failed sign-ins",
+	})
+	lockedSignIns = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "auth_locked_sign_in_attempts_total",
+		Help: "Sign-in attempts rejected because the account was locked",
+	})
+)
+
+// Collectors returns the Prometheus collectors of the auth service so the
+// router can register them next to the HTTP metrics.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{signInFailures, accountLockouts, lockedSignIns}
+}
@@ -6,11 +6,83 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User represents a user in the system
 type User struct {
 	ID           bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty" example:"683cdb8aa96ad71e8e075bd1"`
 	Email        string        `bson:"email" json:"email" example:"test@example.com"`
 	PasswordHash string        `bson:"password_hash" json:"-" example:"$2a$10$1234567890"`
-	CreatedAt    time.Time     `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
-	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+	// Role is empty for users created before roles existed; see EffectiveRole
+	Role       string     `bson:"role,omitempty" json:"role,omitempty" example:"user"`
+	DisabledAt *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+
+	// Email change awaiting confirmation; only the token hash is stored
+	PendingEmail          string     `bson:"pending_email,omitempty" json:"pending_email,omitempty" example:"new@example.com"`
+	PendingEmailHash      string     `bson:"pending_email_hash,omitempty" json:"-"`
+	PendingEmailExpiresAt *time.Time `bson:"pending_email_expires_at,omitempty" json:"-"`
+
+	// DeletionRequestedAt is set once the user asked for account deletion;
+	// the account purger removes the user and their data afterwards.
+	DeletionRequestedAt *time.Time `bson:"deletion_requested_at,omitempty" json:"-"`
+}
+
+// Session is the public view of a signed-in device, backed by its active
+// refresh token.
+type Session struct {
+	ID            string     `json:"id" example:"683cdb8aa96ad71e8e075bd2"`
+	DeviceName    string     `json:"device_name,omitempty" example:"Pixel 8"`
+	UserAgent     string     `json:"user_agent,omitempty" example:"Mozilla/5.0 (Linux; Android 14)"`
+	IP            string     `json:"ip,omitempty" example:"203.0.113.7"`
+	CreatedAt     time.Time  `json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	LastRefreshAt *time.Time `json:"last_refresh_at,omitempty" example:"2025-06-02T08:12:03.005703677Z"`
+	ExpiresAt     time.Time  `json:"expires_at" example:"2025-07-01T23:00:26.005703677Z"`
+	Current       bool       `json:"current" example:"true"`
+}
+
+// ListSessionsResponse represents the list of active sessions of a user
+type ListSessionsResponse struct {
+	Sessions []*Session `json:"sessions"`
+}
+
+// LoginAttempts tracks consecutive failed sign-ins for one email address.
+// Records exist for unknown emails too, so lockouts never reveal whether an
+// account exists.
+type LoginAttempts struct {
+	Email         string     `bson:"_id"`
+	Failures      int        `bson:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt     time.Time  `bson:"expires_at"`
+}
+
+// Locked reports whether the account is locked at the given instant.
+func (a *LoginAttempts) Locked(now time.Time) bool {
+	return a != nil && a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
+// EffectiveRole returns the user's role, defaulting to RoleUser
+func (u *User) EffectiveRole() string {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// Disabled reports whether an admin disabled the account
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// UserStatus is what request authentication needs to know about a user
+// beyond the JWT claims
+type UserStatus struct {
+	Role     string
+	Disabled bool
 }
@@ -17,12 +17,32 @@ type UsersRepo interface {
 	Create(ctx context.Context, user *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindByID(ctx context.Context, id bson.ObjectID) (*User, error)
+
+	// UpdatePassword replaces the password hash of a user
+	UpdatePassword(ctx context.Context, id bson.ObjectID, passwordHash string) error
+
+	// SetPendingEmail stores an email change awaiting confirmation
+	SetPendingEmail(ctx context.Context, id bson.ObjectID, email, tokenHash string, expiresAt time.Time) error
+
+	// ConfirmEmail swaps in the pending email if tokenHash matches and has not
+	// expired. Returns ErrUserNotFound when nothing matches and ErrDuplicate
+	// when the address was taken in the meantime.
+	ConfirmEmail(ctx context.Context, id bson.ObjectID, tokenHash string) (*User, error)
+
+	// MarkForDeletion flags the user for the account purger
+	MarkForDeletion(ctx context.Context, id bson.ObjectID, at time.Time) error
+
+	// ListPendingDeletion returns users flagged for deletion, oldest first
+	ListPendingDeletion(ctx context.Context, limit int) ([]*User, error)
+
+	// Delete removes the user document
+	Delete(ctx context.Context, id bson.ObjectID) error
 }
 
 // RefreshTokensRepo defines the interface for refresh token data access operations
 type RefreshTokensRepo interface {
-	// Create creates a new refresh token record
-	Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time) error
+	// Create creates a new refresh token record bound to the given session
+	Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time, meta SessionMeta) error
 
 	// FindActive finds an active (non-revoked, non-expired) refresh token by raw token
 	FindActive(ctx context.Context, rawToken string) (*RefreshToken, error)
@@ -33,6 +53,21 @@ type RefreshTokensRepo interface {
 	// RevokeAllForUser revokes all active refresh tokens for a specific user
 	RevokeAllForUser(ctx context.Context, userID bson.ObjectID) error
 
+	// DeleteAllForUser removes every refresh token of a user, revoked or not
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) error
+
+	// Touch records a refresh on a token that is kept (rotation disabled)
+	Touch(ctx context.Context, id bson.ObjectID, meta SessionMeta) error
+
+	// ListActiveForUser returns the active refresh tokens of a user, newest activity first
+	ListActiveForUser(ctx context.Context, userID bson.ObjectID) ([]*RefreshToken, error)
+
+	// SessionActive reports whether a session of userID still has an active refresh token
+	SessionActive(ctx context.Context, userID, sessionID bson.ObjectID) (bool, error)
+
+	// RevokeSession revokes the active refresh tokens of one session and returns how many were revoked
+	RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) (int64, error)
+
 	// Client returns the MongoDB client for transaction support
 	Client() *mongo.Client
 
@@ -40,13 +75,63 @@ type RefreshTokensRepo interface {
 	SupportsTransactions() bool
 }
 
+// SessionMeta describes the device a refresh token is issued to. It is
+// carried over on rotation so a session keeps its identity across refreshes.
+type SessionMeta struct {
+	SessionID     bson.ObjectID
+	DeviceName    string
+	UserAgent     string
+	IP            string
+	StartedAt     time.Time
+	LastRefreshAt *time.Time
+}
+
 // RefreshToken represents a refresh token document
 type RefreshToken struct {
-	ID         bson.ObjectID `bson:"_id,omitempty"`
-	UserID     bson.ObjectID `bson:"user_id"`
-	TokenHash  string        `bson:"token_hash"`
-	LookupHash string        `bson:"lookup_hash"`
-	ExpiresAt  time.Time     `bson:"expires_at"`
-	CreatedAt  time.Time     `bson:"created_at"`
-	RevokedAt  *time.Time    `bson:"revoked_at,omitempty"`
+	ID               bson.ObjectID `bson:"_id,omitempty"`
+	UserID           bson.ObjectID `bson:"user_id"`
+	SessionID        bson.ObjectID `bson:"session_id,omitempty"`
+	TokenHash        string        `bson:"token_hash"`
+	LookupHash       string        `bson:"lookup_hash"`
+	DeviceName       string        `bson:"device_name,omitempty"`
+	UserAgent        string        `bson:"user_agent,omitempty"`
+	IP               string        `bson:"ip,omitempty"`
+	ExpiresAt        time.Time     `bson:"expires_at"`
+	CreatedAt        time.Time     `bson:"created_at"`
+	SessionStartedAt time.Time     `bson:"session_started_at,omitempty"`
+	LastRefreshAt    *time.Time    `bson:"last_refresh_at,omitempty"`
+	RevokedAt        *time.Time    `bson:"revoked_at,omitempty"`
+}
+
+// SessionKey returns the session the token belongs to. Tokens issued before
+// sessions were tracked act as their own single-token session.
+func (t *RefreshToken) SessionKey() bson.ObjectID {
+	if t.SessionID.IsZero() {
+		return t.ID
+	}
+	return t.SessionID
+}
+
+// sessionStart returns when the token's session began.
+func (t *RefreshToken) sessionStart() time.Time {
+	if t.SessionStartedAt.IsZero() {
+		return t.CreatedAt
+	}
+	return t.SessionStartedAt
+}
+
+// LoginAttemptsRepo tracks failed sign-in attempts per account
+type LoginAttemptsRepo interface {
+	// Find returns the attempts record for email, or nil when there is none
+	Find(ctx context.Context, email string) (*LoginAttempts, error)
+
+	// RecordFailure counts a failed attempt. Failures older than window are
+	// forgotten, so the returned count only covers the current window.
+	RecordFailure(ctx context.Context, email string, window time.Duration) (*LoginAttempts, error)
+
+	// Lock locks the account until the given time
+	Lock(ctx context.Context, email string, until time.Time) error
+
+	// Reset clears the failure count and any lockout
+	Reset(ctx context.Context, email string) error
 }
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"note-pulse/internal/config"
+	"note-pulse/internal/services/audit"
 	"note-pulse/internal/utils/crypto"
+	"note-pulse/internal/utils/reqinfo"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -23,6 +25,21 @@ type Service struct {
 	refreshTokenRepo RefreshTokensRepo
 	config           config.Config
 	log              *slog.Logger
+	sessionCloser    SessionCloser
+	loginAttempts    LoginAttemptsRepo
+	mailer           Mailer
+	purgers          []UserDataPurger
+	purgeQueue       chan bson.ObjectID
+	statusCache      *userStatusCache
+	sessionCache     *sessionCache
+	audit            AuditSink
+}
+
+// SessionCloser terminates live connections (e.g. WebSockets) that were
+// opened under a session or by a user. The notes Hub implements it.
+type SessionCloser interface {
+	CloseSession(ctx context.Context, userID bson.ObjectID, sessionID string)
+	CloseUser(ctx context.Context, userID bson.ObjectID)
 }
 
 // ErrInvalidRefreshToken is returned whenever the caller supplies a refresh
@@ -32,6 +49,10 @@ var ErrInvalidRefreshToken = errors.New("invalid refresh token")
 // ErrUserNotFound user not found in DB
 var ErrUserNotFound = errors.New("user not found")
 
+// ErrSessionNotFound is returned when a session does not exist, is already
+// revoked or belongs to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
 // NewService creates a new auth service
 func NewService(usersRepo UsersRepo, refreshTokenRepo RefreshTokensRepo, cfg config.Config, log *slog.Logger) *Service {
 	return &Service{
@@ -39,19 +60,30 @@ func NewService(usersRepo UsersRepo, refreshTokenRepo RefreshTokensRepo, cfg con
 		refreshTokenRepo: refreshTokenRepo,
 		config:           cfg,
 		log:              log,
+		purgeQueue:       make(chan bson.ObjectID, purgeQueueSize),
+		statusCache:      newUserStatusCache(userStatusTTL),
+		sessionCache:     newSessionCache(userStatusTTL),
 	}
 }
 
+// SetSessionCloser wires the component that drops live connections when a
+// session is revoked. It must be called before the service handles requests.
+func (s *Service) SetSessionCloser(c SessionCloser) {
+	s.sessionCloser = c
+}
+
 // SignUpRequest represents a user registration request
 type SignUpRequest struct {
-	Email    string `json:"email" validate:"required,email" example:"test@example.com"`
-	Password string `json:"password" validate:"required,password" example:"Password123"`
+	Email      string `json:"email" validate:"required,email" example:"test@example.com"`
+	Password   string `json:"password" validate:"required,password" example:"Password123"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=64" example:"Pixel 8"`
 }
 
 // SignInRequest represents a user login request
 type SignInRequest struct {
-	Email    string `json:"email" validate:"required,email" example:"test@example.com"`
-	Password string `json:"password" validate:"required" example:"Password123"`
+	Email      string `json:"email" validate:"required,email" example:"test@example.com"`
+	Password   string `json:"password" validate:"required" example:"Password123"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=64" example:"Pixel 8"`
 }
 
 // Response represents the response for successful authentication
@@ -87,6 +119,7 @@ func (s *Service) SignUp(ctx context.Context, req SignUpRequest) (*Response, err
 		ID:           bson.NewObjectID(),
 		Email:        email,
 		PasswordHash: hashedPassword,
+		Role:         RoleUser,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -99,7 +132,9 @@ func (s *Service) SignUp(ctx context.Context, req SignUpRequest) (*Response, err
 		return nil, errors.New("failed to create user")
 	}
 
-	accessToken, err := s.GenerateAccessToken(user)
+	meta := s.newSessionMeta(ctx, req.DeviceName)
+
+	accessToken, err := s.generateAccessToken(user, meta.SessionID.Hex())
 	if err != nil {
 		return nil, ErrGenAccessToken
 	}
@@ -110,11 +145,12 @@ func (s *Service) SignUp(ctx context.Context, req SignUpRequest) (*Response, err
 	}
 
 	refreshExpiresAt := now.Add(time.Duration(s.config.RefreshTokenDays) * 24 * time.Hour)
-	if err := s.refreshTokenRepo.Create(ctx, user.ID, refreshToken, refreshExpiresAt); err != nil {
+	if err := s.refreshTokenRepo.Create(ctx, user.ID, refreshToken, refreshExpiresAt, meta); err != nil {
 		s.log.Error("failed to store refresh token", "error", err, "user_id", user.ID.Hex())
 		return nil, ErrGenRefreshToken
 	}
 
+	s.record(ctx, audit.ActionSignUp, user.ID, &meta.SessionID, audit.Summary{auditEmail: email})
 	return &Response{
 		User:         user,
 		Token:        accessToken,
@@ -126,22 +162,48 @@ func (s *Service) SignUp(ctx context.Context, req SignUpRequest) (*Response, err
 func (s *Service) SignIn(ctx context.Context, req SignInRequest) (*Response, error) {
 	email := normalizeEmail(req.Email)
 
+	if s.isLockedOut(ctx, email) {
+		s.recordSignInFailure(ctx, email, nil, failureLockedOut)
+		return nil, ErrInvalidCredentials
+	}
+
 	user, err := s.usersRepo.FindByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
 			s.log.Info("user not found for signin", "email", email)
+			s.recordLoginFailure(ctx, email)
+			s.recordSignInFailure(ctx, email, nil, failureUnknownEmail)
 		} else {
 			s.log.Error("failed to find user by email", "error", err)
+			s.recordSignInFailure(ctx, email, nil, failureLookup)
 		}
 		return nil, ErrInvalidCredentials
 	}
 
 	if err := crypto.CheckPassword(req.Password, user.PasswordHash); err != nil {
 		s.log.Error("failed to check password", "error", err)
+		s.recordLoginFailure(ctx, email)
+		s.recordSignInFailure(ctx, email, &user.ID, failureWrongPassword)
 		return nil, ErrInvalidCredentials
 	}
 
-	accessToken, err := s.GenerateAccessToken(user)
+	if user.DeletionRequestedAt != nil {
+		s.log.Info("sign-in rejected: account scheduled for deletion", "user_id", user.ID.Hex())
+		s.recordSignInFailure(ctx, email, &user.ID, failureDeletionPending)
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.Disabled() {
+		s.log.Info("sign-in rejected: account disabled", "user_id", user.ID.Hex())
+		s.recordSignInFailure(ctx, email, &user.ID, failureDisabled)
+		return nil, ErrAccountDisabled
+	}
+
+	s.ResetLoginAttempts(ctx, email)
+
+	meta := s.newSessionMeta(ctx, req.DeviceName)
+
+	accessToken, err := s.generateAccessToken(user, meta.SessionID.Hex())
 	if err != nil {
 		s.log.Error(ErrGenAccessToken.Error(), "error", err)
 		return nil, ErrGenAccessToken
@@ -154,11 +216,12 @@ func (s *Service) SignIn(ctx context.Context, req SignInRequest) (*Response, err
 	}
 
 	refreshExpiresAt := time.Now().UTC().Add(time.Duration(s.config.RefreshTokenDays) * 24 * time.Hour)
-	if err := s.refreshTokenRepo.Create(ctx, user.ID, refreshToken, refreshExpiresAt); err != nil {
+	if err := s.refreshTokenRepo.Create(ctx, user.ID, refreshToken, refreshExpiresAt, meta); err != nil {
 		s.log.Error("failed to store refresh token", "error", err, "user_id", user.ID.Hex())
 		return nil, ErrGenRefreshToken
 	}
 
+	s.record(ctx, audit.ActionSignIn, user.ID, &meta.SessionID, nil)
 	return &Response{
 		User:         user,
 		Token:        accessToken,
@@ -170,8 +233,49 @@ func normalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
+// newSessionMeta starts a new session for the client of the current request
+func (s *Service) newSessionMeta(ctx context.Context, deviceName string) SessionMeta {
+	info := reqinfo.From(ctx)
+	return SessionMeta{
+		SessionID:  bson.NewObjectID(),
+		DeviceName: strings.TrimSpace(deviceName),
+		UserAgent:  info.UserAgent,
+		IP:         info.IP,
+		StartedAt:  time.Now().UTC(),
+	}
+}
+
+// refreshedSessionMeta carries a session over to its next refresh, updating
+// the client details with those of the current request.
+func refreshedSessionMeta(ctx context.Context, token *RefreshToken) SessionMeta {
+	info := reqinfo.From(ctx)
+	now := time.Now().UTC()
+	meta := SessionMeta{
+		SessionID:     token.SessionKey(),
+		DeviceName:    token.DeviceName,
+		UserAgent:     token.UserAgent,
+		IP:            token.IP,
+		StartedAt:     token.sessionStart(),
+		LastRefreshAt: &now,
+	}
+	if info.UserAgent != "" {
+		meta.UserAgent = info.UserAgent
+	}
+	if info.IP != "" {
+		meta.IP = info.IP
+	}
+	return meta
+}
+
 // GenerateAccessToken generates a short-lived access token
 func (s *Service) GenerateAccessToken(user *User) (string, error) {
+	return s.generateAccessToken(user, "")
+}
+
+// generateAccessToken generates a short-lived access token bound to a session.
+// The session id travels in the "sid" claim so live connections can be
+// dropped when the session is revoked.
+func (s *Service) generateAccessToken(user *User, sessionID string) (string, error) {
 	var b [16]byte
 	if _, err := rand.Read(b[:]); err != nil {
 		return "", errors.New("failed to generate token id")
@@ -184,9 +288,13 @@ func (s *Service) GenerateAccessToken(user *User) (string, error) {
 		"jti":     jti,
 		"user_id": user.ID.Hex(),
 		"email":   user.Email,
+		"role":    user.EffectiveRole(),
 		"exp":     now.Add(time.Duration(s.config.AccessTokenMinutes) * time.Minute).Unix(),
 		"iat":     now.Unix(),
 	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 
 	var method jwt.SigningMethod
 	switch strings.ToUpper(s.config.JWTAlgorithm) {
@@ -227,16 +335,19 @@ func (s *Service) Refresh(ctx context.Context, rawRefreshToken string) (*Respons
 		return nil, err
 	}
 
-	accessToken, err := s.GenerateAccessToken(user)
+	meta := refreshedSessionMeta(ctx, refreshToken)
+
+	accessToken, err := s.generateAccessToken(user, meta.SessionID.Hex())
 	if err != nil {
 		s.log.Error(ErrGenAccessToken.Error(), "error", err)
 		return nil, ErrRefreshTokens
 	}
 
-	newRefreshToken, err := s.handleRefreshTokenRotation(ctx, rawRefreshToken, refreshToken, user)
+	newRefreshToken, err := s.handleRefreshTokenRotation(ctx, rawRefreshToken, refreshToken, user, meta)
 	if err != nil {
 		return nil, err
 	}
+	s.record(ctx, audit.ActionRefresh, user.ID, &meta.SessionID, nil)
 
 	return &Response{
 		User:         user,
@@ -262,13 +373,25 @@ func (s *Service) validateRefreshTokenAndUser(ctx context.Context, rawRefreshTok
 		s.log.Error("failed to find user for refresh token", "error", err, "user_id", refreshToken.UserID.Hex())
 		return nil, nil, ErrInvalidRefreshToken
 	}
+	if user.DeletionRequestedAt != nil {
+		s.log.Info("refresh rejected: account scheduled for deletion", "user_id", user.ID.Hex())
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if user.Disabled() {
+		s.log.Info("refresh rejected: account disabled", "user_id", user.ID.Hex())
+		return nil, nil, ErrInvalidRefreshToken
+	}
 
 	return refreshToken, user, nil
 }
 
 // handleRefreshTokenRotation handles refresh token rotation if enabled
-func (s *Service) handleRefreshTokenRotation(ctx context.Context, rawRefreshToken string, refreshToken *RefreshToken, user *User) (string, error) {
+func (s *Service) handleRefreshTokenRotation(ctx context.Context, rawRefreshToken string, refreshToken *RefreshToken, user *User, meta SessionMeta) (string, error) {
 	if !s.config.RefreshTokenRotate {
+		if err := s.refreshTokenRepo.Touch(ctx, refreshToken.ID, meta); err != nil {
+			// Bookkeeping only: the refresh itself is still valid.
+			s.log.Warn("failed to record refresh on session", "error", err, "token_id", refreshToken.ID.Hex())
+		}
 		return rawRefreshToken, nil
 	}
 
@@ -280,7 +403,7 @@ func (s *Service) handleRefreshTokenRotation(ctx context.Context, rawRefreshToke
 
 	newRefreshExpiresAt := time.Now().UTC().Add(time.Duration(s.config.RefreshTokenDays) * 24 * time.Hour)
 
-	if err := s.executeTokenRotation(ctx, user.ID, refreshToken.ID, newRefreshToken, newRefreshExpiresAt); err != nil {
+	if err := s.executeTokenRotation(ctx, user.ID, refreshToken.ID, newRefreshToken, newRefreshExpiresAt, meta); err != nil {
 		return "", err
 	}
 
@@ -288,18 +411,18 @@ func (s *Service) handleRefreshTokenRotation(ctx context.Context, rawRefreshToke
 }
 
 // executeTokenRotation executes the token rotation using transactions or fallback mode
-func (s *Service) executeTokenRotation(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time) error {
+func (s *Service) executeTokenRotation(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time, meta SessionMeta) error {
 	if !s.refreshTokenRepo.SupportsTransactions() {
-		return s.executeTokenRotationFallback(ctx, userID, oldTokenID, newRefreshToken, newRefreshExpiresAt)
+		return s.executeTokenRotationFallback(ctx, userID, oldTokenID, newRefreshToken, newRefreshExpiresAt, meta)
 	}
-	return s.executeTokenRotationWithTransaction(ctx, userID, oldTokenID, newRefreshToken, newRefreshExpiresAt)
+	return s.executeTokenRotationWithTransaction(ctx, userID, oldTokenID, newRefreshToken, newRefreshExpiresAt, meta)
 }
 
 // executeTokenRotationFallback handles token rotation without transactions
-func (s *Service) executeTokenRotationFallback(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time) error {
+func (s *Service) executeTokenRotationFallback(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time, meta SessionMeta) error {
 	s.log.Info("using fallback token rotation for standalone MongoDB")
 
-	if err := s.refreshTokenRepo.Create(ctx, userID, newRefreshToken, newRefreshExpiresAt); err != nil {
+	if err := s.refreshTokenRepo.Create(ctx, userID, newRefreshToken, newRefreshExpiresAt, meta); err != nil {
 		s.log.Error("failed to store new refresh token in fallback mode", "error", err)
 		return ErrRefreshTokens
 	}
@@ -313,7 +436,7 @@ func (s *Service) executeTokenRotationFallback(ctx context.Context, userID, oldT
 }
 
 // executeTokenRotationWithTransaction handles token rotation using MongoDB transactions
-func (s *Service) executeTokenRotationWithTransaction(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time) error {
+func (s *Service) executeTokenRotationWithTransaction(ctx context.Context, userID, oldTokenID bson.ObjectID, newRefreshToken string, newRefreshExpiresAt time.Time, meta SessionMeta) error {
 	client := s.refreshTokenRepo.Client()
 	sess, err := client.StartSession()
 	if err != nil {
@@ -323,7 +446,7 @@ func (s *Service) executeTokenRotationWithTransaction(ctx context.Context, userI
 	defer sess.EndSession(ctx)
 
 	_, err = sess.WithTransaction(ctx, func(sc context.Context) (any, error) {
-		if err := s.refreshTokenRepo.Create(sc, userID, newRefreshToken, newRefreshExpiresAt); err != nil {
+		if err := s.refreshTokenRepo.Create(sc, userID, newRefreshToken, newRefreshExpiresAt, meta); err != nil {
 			s.log.Error("failed to store new refresh token in transaction", "error", err)
 			return nil, err
 		}
@@ -367,6 +490,10 @@ func (s *Service) SignOut(ctx context.Context, userID bson.ObjectID, rawRefreshT
 		return ErrSignOut
 	}
 
+	sessionID := refreshToken.SessionKey()
+	s.closeSession(ctx, userID, sessionID)
+	s.record(ctx, audit.ActionSignOut, userID, &sessionID, nil)
+
 	s.log.Info("user signed out successfully", "user_id", userID.Hex())
 	return nil
 }
@@ -377,7 +504,73 @@ func (s *Service) SignOutAll(ctx context.Context, userID bson.ObjectID) error {
 		s.log.Error("failed to revoke all refresh tokens for user", "error", err, "user_id", userID.Hex())
 		return ErrSignOutAll
 	}
+	s.sessionCache.forgetUser(userID)
+	s.record(ctx, audit.ActionSignOutAll, userID, nil, nil)
 
 	s.log.Info("user signed out from all devices", "user_id", userID.Hex())
 	return nil
 }
+
+// ListSessions returns the active sessions of a user. currentSessionID is
+// the "sid" of the caller's access token and marks the calling device.
+func (s *Service) ListSessions(ctx context.Context, userID bson.ObjectID, currentSessionID string) (*ListSessionsResponse, error) {
+	tokens, err := s.refreshTokenRepo.ListActiveForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListSessions.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListSessions
+	}
+
+	// With rotation in fallback mode a session may briefly own two tokens;
+	// tokens are sorted by latest activity, so the first one wins.
+	seen := make(map[bson.ObjectID]struct{}, len(tokens))
+	sessions := make([]*Session, 0, len(tokens))
+	for _, t := range tokens {
+		key := t.SessionKey()
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		sessions = append(sessions, &Session{
+			ID:            key.Hex(),
+			DeviceName:    t.DeviceName,
+			UserAgent:     t.UserAgent,
+			IP:            t.IP,
+			CreatedAt:     t.sessionStart(),
+			LastRefreshAt: t.LastRefreshAt,
+			ExpiresAt:     t.ExpiresAt,
+			Current:       currentSessionID != "" && key.Hex() == currentSessionID,
+		})
+	}
+
+	return &ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// RevokeSession signs a single device out: its refresh tokens are revoked and
+// its live WebSocket connections are closed.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) error {
+	revoked, err := s.refreshTokenRepo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		s.log.Error(ErrRevokeSession.Error(), "error", err, "user_id", userID.Hex(), "session_id", sessionID.Hex())
+		return ErrRevokeSession
+	}
+	if revoked == 0 {
+		return ErrSessionNotFound
+	}
+
+	s.closeSession(ctx, userID, sessionID)
+
+	s.log.Info("session revoked", "user_id", userID.Hex(), "session_id", sessionID.Hex())
+	return nil
+}
+
+// closeSession marks a revoked session so that its access tokens stop
+// working on this instance at once, and drops its live connections when a
+// closer is wired
+func (s *Service) closeSession(ctx context.Context, userID, sessionID bson.ObjectID) {
+	s.sessionCache.put(sessionID, userID, false, time.Now())
+	if s.sessionCloser == nil {
+		return
+	}
+	s.sessionCloser.CloseSession(ctx, userID, sessionID.Hex())
+}
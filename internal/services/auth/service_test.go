@@ -11,7 +11,9 @@ import (
 
 	"note-pulse/internal/config"
 	"note-pulse/internal/utils/crypto"
+	"note-pulse/internal/utils/reqinfo"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -66,8 +68,44 @@ func (m *MockUsersRepo) FindByID(ctx context.Context, id bson.ObjectID) (*User,
 	return args.Get(0).(*User), args.Error(1)
 }
 
-func (m *MockRefreshTokensRepo) Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time) error {
-	args := m.Called(ctx, userID, rawToken, expiresAt)
+func (m *MockUsersRepo) UpdatePassword(ctx context.Context, id bson.ObjectID, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepo) SetPendingEmail(ctx context.Context, id bson.ObjectID, email, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, id, email, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepo) ConfirmEmail(ctx context.Context, id bson.ObjectID, tokenHash string) (*User, error) {
+	args := m.Called(ctx, id, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUsersRepo) MarkForDeletion(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepo) ListPendingDeletion(ctx context.Context, limit int) ([]*User, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*User), args.Error(1)
+}
+
+func (m *MockUsersRepo) Delete(ctx context.Context, id bson.ObjectID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokensRepo) Create(ctx context.Context, userID bson.ObjectID, rawToken string, expiresAt time.Time, meta SessionMeta) error {
+	args := m.Called(ctx, userID, rawToken, expiresAt, meta)
 	return args.Error(0)
 }
 
@@ -89,6 +127,34 @@ func (m *MockRefreshTokensRepo) RevokeAllForUser(ctx context.Context, userID bso
 	return args.Error(0)
 }
 
+func (m *MockRefreshTokensRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokensRepo) Touch(ctx context.Context, id bson.ObjectID, meta SessionMeta) error {
+	args := m.Called(ctx, id, meta)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokensRepo) ListActiveForUser(ctx context.Context, userID bson.ObjectID) ([]*RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokensRepo) SessionActive(ctx context.Context, userID, sessionID bson.ObjectID) (bool, error) {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokensRepo) RevokeSession(ctx context.Context, userID, sessionID bson.ObjectID) (int64, error) {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRefreshTokensRepo) Client() *mongo.Client {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -162,7 +228,7 @@ func TestServiceSignUp(t *testing.T) {
 			tt.setup(repo)
 
 			refreshRepo := new(MockRefreshTokensRepo)
-			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			service := NewService(repo, refreshRepo, cfg, silentLogger)
 			resp, err := service.SignUp(context.Background(), tt.req)
 
@@ -241,6 +307,7 @@ func TestServiceRefreshTransactionRollback(t *testing.T) {
 
 		refreshRepo.On("FindActive", mock.Anything, rawToken).Return(existingToken, nil)
 		userRepo.On("FindByID", mock.Anything, userID).Return(user, nil)
+		refreshRepo.On("Touch", mock.Anything, tokenID, mock.AnythingOfType("auth.SessionMeta")).Return(nil)
 
 		service := NewService(userRepo, refreshRepo, cfgNoRotation, silentLogger)
 
@@ -282,7 +349,7 @@ func TestServiceGenerateJWTDifferentAlgorithms(t *testing.T) {
 
 			repo := new(MockUsersRepo)
 			refreshRepo := new(MockRefreshTokensRepo)
-			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			service := NewService(repo, refreshRepo, cfg, silentLogger)
 
 			user := &User{
@@ -369,7 +436,7 @@ func TestServiceRefreshStandaloneMongo(t *testing.T) {
 		// Note: Client() is NOT called when SupportsTransactions() returns false
 
 		// Expect fallback behavior: create new token, then revoke old token
-		refreshRepo.On("Create", mock.Anything, userID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		refreshRepo.On("Create", mock.Anything, userID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time"), mock.AnythingOfType("auth.SessionMeta")).Return(nil)
 		refreshRepo.On("Revoke", mock.Anything, tokenID).Return(nil)
 
 		service := NewService(userRepo, refreshRepo, cfg, silentLogger)
@@ -402,7 +469,7 @@ func TestServiceRefreshStandaloneMongo(t *testing.T) {
 		// Note: Client() is NOT called when SupportsTransactions() returns false
 
 		// Create succeeds, but revoke fails - should still return success
-		refreshRepo.On("Create", mock.Anything, userID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		refreshRepo.On("Create", mock.Anything, userID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time"), mock.AnythingOfType("auth.SessionMeta")).Return(nil)
 		refreshRepo.On("Revoke", mock.Anything, tokenID).Return(errors.New("revoke failed"))
 
 		service := NewService(userRepo, refreshRepo, cfg, silentLogger)
@@ -488,7 +555,7 @@ func TestServiceSignIn(t *testing.T) {
 			tt.setup(repo)
 
 			refreshRepo := new(MockRefreshTokensRepo)
-			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			refreshRepo.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			service := NewService(repo, refreshRepo, cfg, silentLogger)
 			resp, err := service.SignIn(context.Background(), tt.req)
 
@@ -507,3 +574,162 @@ func TestServiceSignIn(t *testing.T) {
 		})
 	}
 }
+
+// stubSessionCloser records CloseSession calls
+type stubSessionCloser struct {
+	closed []string
+}
+
+func (s *stubSessionCloser) CloseSession(_ context.Context, _ bson.ObjectID, sessionID string) {
+	s.closed = append(s.closed, sessionID)
+}
+
+func (s *stubSessionCloser) CloseUser(_ context.Context, userID bson.ObjectID) {
+	s.closed = append(s.closed, "user:"+userID.Hex())
+}
+
+func TestServiceSignInRecordsSession(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.AccessTokenMinutes = 15
+	cfg.RefreshTokenDays = 30
+
+	hashedPassword, err := crypto.HashPassword(testPassword, 8)
+	require.NoError(t, err)
+
+	user := &User{ID: bson.NewObjectID(), Email: testUserEmail, PasswordHash: hashedPassword}
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	userRepo.On("FindByEmail", mock.Anything, testUserEmail).Return(user, nil)
+
+	var meta SessionMeta
+	refreshRepo.On("Create", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.AnythingOfType("auth.SessionMeta")).
+		Run(func(args mock.Arguments) { meta = args.Get(4).(SessionMeta) }).
+		Return(nil)
+
+	service := NewService(userRepo, refreshRepo, cfg, silentLogger)
+
+	ctx := reqinfo.With(context.Background(), reqinfo.Info{IP: "203.0.113.7", UserAgent: "test-agent"})
+	resp, err := service.SignIn(ctx, SignInRequest{Email: testUserEmail, Password: testPassword, DeviceName: " Pixel 8 "})
+	require.NoError(t, err)
+
+	assert.False(t, meta.SessionID.IsZero(), "sign-in should start a session")
+	assert.Equal(t, "Pixel 8", meta.DeviceName)
+	assert.Equal(t, "203.0.113.7", meta.IP)
+	assert.Equal(t, "test-agent", meta.UserAgent)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (any, error) { return []byte(testJWTSecret), nil })
+	require.NoError(t, err)
+	assert.Equal(t, meta.SessionID.Hex(), claims["sid"], "access token should carry the session id")
+}
+
+func TestServiceRefreshKeepsSession(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.RefreshTokenDays = 30
+	cfg.RefreshTokenRotate = true
+
+	userID := bson.NewObjectID()
+	sessionID := bson.NewObjectID()
+	startedAt := time.Now().UTC().Add(-48 * time.Hour)
+	existing := &RefreshToken{
+		ID:               bson.NewObjectID(),
+		UserID:           userID,
+		SessionID:        sessionID,
+		DeviceName:       "laptop",
+		UserAgent:        "old-agent",
+		IP:               "198.51.100.1",
+		SessionStartedAt: startedAt,
+		ExpiresAt:        time.Now().UTC().Add(time.Hour),
+	}
+
+	userRepo := new(MockUsersRepo)
+	refreshRepo := new(MockRefreshTokensRepo)
+	refreshRepo.On("FindActive", mock.Anything, "raw").Return(existing, nil)
+	userRepo.On("FindByID", mock.Anything, userID).Return(&User{ID: userID, Email: testUserEmail}, nil)
+	refreshRepo.On("SupportsTransactions").Return(false)
+	refreshRepo.On("Revoke", mock.Anything, existing.ID).Return(nil)
+
+	var meta SessionMeta
+	refreshRepo.On("Create", mock.Anything, userID, mock.Anything, mock.Anything, mock.AnythingOfType("auth.SessionMeta")).
+		Run(func(args mock.Arguments) { meta = args.Get(4).(SessionMeta) }).
+		Return(nil)
+
+	service := NewService(userRepo, refreshRepo, cfg, silentLogger)
+
+	ctx := reqinfo.With(context.Background(), reqinfo.Info{IP: "203.0.113.9"})
+	_, err := service.Refresh(ctx, "raw")
+	require.NoError(t, err)
+
+	assert.Equal(t, sessionID, meta.SessionID, "rotation must keep the session id")
+	assert.Equal(t, "laptop", meta.DeviceName)
+	assert.Equal(t, "old-agent", meta.UserAgent, "unknown user agent keeps the previous one")
+	assert.Equal(t, "203.0.113.9", meta.IP)
+	assert.True(t, startedAt.Equal(meta.StartedAt))
+	require.NotNil(t, meta.LastRefreshAt)
+}
+
+func TestServiceListSessions(t *testing.T) {
+	userID := bson.NewObjectID()
+	now := time.Now().UTC()
+	current := bson.NewObjectID()
+	legacy := &RefreshToken{ID: bson.NewObjectID(), UserID: userID, CreatedAt: now.Add(-time.Hour)}
+
+	refreshRepo := new(MockRefreshTokensRepo)
+	refreshRepo.On("ListActiveForUser", mock.Anything, userID).Return([]*RefreshToken{
+		{ID: bson.NewObjectID(), UserID: userID, SessionID: current, DeviceName: "phone", CreatedAt: now},
+		{ID: bson.NewObjectID(), UserID: userID, SessionID: current, DeviceName: "phone", CreatedAt: now.Add(-time.Minute)},
+		legacy,
+	}, nil)
+
+	service := NewService(new(MockUsersRepo), refreshRepo, getTestConfig(), silentLogger)
+
+	resp, err := service.ListSessions(context.Background(), userID, current.Hex())
+	require.NoError(t, err)
+	require.Len(t, resp.Sessions, 2, "tokens of one session should be collapsed")
+
+	assert.Equal(t, current.Hex(), resp.Sessions[0].ID)
+	assert.True(t, resp.Sessions[0].Current)
+	assert.Equal(t, legacy.ID.Hex(), resp.Sessions[1].ID, "legacy tokens are their own session")
+	assert.False(t, resp.Sessions[1].Current)
+}
+
+func TestServiceRevokeSession(t *testing.T) {
+	userID := bson.NewObjectID()
+	sessionID := bson.NewObjectID()
+
+	t.Run("revokes tokens and closes connections", func(t *testing.T) {
+		refreshRepo := new(MockRefreshTokensRepo)
+		refreshRepo.On("RevokeSession", mock.Anything, userID, sessionID).Return(int64(1), nil)
+		closer := &stubSessionCloser{}
+
+		service := NewService(new(MockUsersRepo), refreshRepo, getTestConfig(), silentLogger)
+		service.SetSessionCloser(closer)
+
+		require.NoError(t, service.RevokeSession(context.Background(), userID, sessionID))
+		assert.Equal(t, []string{sessionID.Hex()}, closer.closed)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		refreshRepo := new(MockRefreshTokensRepo)
+		refreshRepo.On("RevokeSession", mock.Anything, userID, sessionID).Return(int64(0), nil)
+		closer := &stubSessionCloser{}
+
+		service := NewService(new(MockUsersRepo), refreshRepo, getTestConfig(), silentLogger)
+		service.SetSessionCloser(closer)
+
+		err := service.RevokeSession(context.Background(), userID, sessionID)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.Empty(t, closer.closed)
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		refreshRepo := new(MockRefreshTokensRepo)
+		refreshRepo.On("RevokeSession", mock.Anything, userID, sessionID).Return(int64(0), errors.New("boom"))
+
+		service := NewService(new(MockUsersRepo), refreshRepo, getTestConfig(), silentLogger)
+
+		err := service.RevokeSession(context.Background(), userID, sessionID)
+		assert.ErrorIs(t, err, ErrRevokeSession)
+	})
+}
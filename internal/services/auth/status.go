@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// userStatusTTL bounds how long another instance may keep accepting access
+// tokens of a user who was just disabled or demoted
+const userStatusTTL = 30 * time.Second
+
+// userStatusCacheMax triggers a sweep of expired entries
+const userStatusCacheMax = 10_000
+
+type userStatusEntry struct {
+	status    UserStatus
+	expiresAt time.Time
+}
+
+// userStatusCache keeps recent user status lookups so authenticated requests
+// do not hit Mongo every time
+type userStatusCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	m   map[bson.ObjectID]userStatusEntry
+}
+
+func newUserStatusCache(ttl time.Duration) *userStatusCache {
+	return &userStatusCache{ttl: ttl, m: make(map[bson.ObjectID]userStatusEntry)}
+}
+
+func (c *userStatusCache) get(id bson.ObjectID, now time.Time) (UserStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.m[id]
+	if !ok || now.After(e.expiresAt) {
+		return UserStatus{}, false
+	}
+	return e.status, true
+}
+
+func (c *userStatusCache) put(id bson.ObjectID, status UserStatus, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.m) >= userStatusCacheMax {
+		for k, e := range c.m {
+			if now.After(e.expiresAt) {
+				delete(c.m, k)
+			}
+		}
+	}
+	c.m[id] = userStatusEntry{status: status, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *userStatusCache) forget(id bson.ObjectID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, id)
+}
+
+// UserStatus returns the current role and disabled flag of a user. Results
+// are cached briefly; users scheduled for deletion count as disabled.
+func (s *Service) UserStatus(ctx context.Context, userID bson.ObjectID) (UserStatus, error) {
+	now := time.Now()
+	if status, ok := s.statusCache.get(userID, now); ok {
+		return status, nil
+	}
+
+	user, err := s.usersRepo.FindByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			s.log.Error("failed to load user status", "error", err, "user_id", userID.Hex())
+		}
+		return UserStatus{}, err
+	}
+
+	status := UserStatus{
+		Role:     user.EffectiveRole(),
+		Disabled: user.Disabled() || user.DeletionRequestedAt != nil,
+	}
+	s.statusCache.put(userID, status, now)
+	return status, nil
+}
+
+// InvalidateUserStatus drops the cached status so the next request sees a
+// role or disabled change immediately on this instance
+func (s *Service) InvalidateUserStatus(userID bson.ObjectID) {
+	s.statusCache.forget(userID)
+}
+
+type sessionEntry struct {
+	userID    bson.ObjectID
+	active    bool
+	expiresAt time.Time
+}
+
+// sessionCache keeps recent session lookups, so the "sid" of every access
+// token can be checked without hitting Mongo on each request
+type sessionCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	m   map[bson.ObjectID]sessionEntry
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{ttl: ttl, m: make(map[bson.ObjectID]sessionEntry)}
+}
+
+func (c *sessionCache) get(sessionID, userID bson.ObjectID, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.m[sessionID]
+	if !ok || e.userID != userID || now.After(e.expiresAt) {
+		return false, false
+	}
+	return e.active, true
+}
+
+func (c *sessionCache) put(sessionID, userID bson.ObjectID, active bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.m) >= userStatusCacheMax {
+		for k, e := range c.m {
+			if now.After(e.expiresAt) {
+				delete(c.m, k)
+			}
+		}
+	}
+	c.m[sessionID] = sessionEntry{userID: userID, active: active, expiresAt: now.Add(c.ttl)}
+}
+
+// forgetUser drops the sessions of userID, after all of them were revoked
+func (c *sessionCache) forgetUser(userID bson.ObjectID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.m {
+		if e.userID == userID {
+			delete(c.m, k)
+		}
+	}
+}
+
+// SessionActive reports whether the session an access token was issued
+// under, its "sid" claim, still has an unrevoked refresh token. Results are
+// cached like UserStatus; sessions revoked on this instance are seen at
+// once, on other instances within userStatusTTL.
+func (s *Service) SessionActive(ctx context.Context, userID bson.ObjectID, sessionID string) (bool, error) {
+	sid, err := bson.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	if active, ok := s.sessionCache.get(sid, userID, now); ok {
+		return active, nil
+	}
+
+	active, err := s.refreshTokenRepo.SessionActive(ctx, userID, sid)
+	if err != nil {
+		s.log.Error("failed to check session", "error", err, "user_id", userID.Hex(), "session_id", sessionID)
+		return false, err
+	}
+	s.sessionCache.put(sid, userID, active, now)
+	return active, nil
+}
+
+// ForceSignOut revokes every session of a user and closes their live
+// connections. Access tokens already issued stop working with their
+// sessions.
+func (s *Service) ForceSignOut(ctx context.Context, userID bson.ObjectID) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		s.log.Error(ErrSignOutAll.Error(), "error", err, "user_id", userID.Hex())
+		return ErrSignOutAll
+	}
+	s.sessionCache.forgetUser(userID)
+
+	if s.sessionCloser != nil {
+		s.sessionCloser.CloseUser(ctx, userID)
+	}
+
+	s.log.Info("user signed out by admin", "user_id", userID.Hex())
+	return nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestServiceUserStatusIsCached(t *testing.T) {
+	user := &User{ID: bson.NewObjectID(), Email: testUserEmail}
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+
+	for range 3 {
+		st, err := service.UserStatus(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, UserStatus{Role: RoleUser}, st, "users without a stored role are plain users")
+	}
+	userRepo.AssertNumberOfCalls(t, "FindByID", 1)
+
+	now := time.Now().UTC()
+	user.DisabledAt = &now
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Once()
+	service.InvalidateUserStatus(user.ID)
+
+	st, err := service.UserStatus(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, st.Disabled, "invalidation makes the change visible at once")
+}
+
+func TestServiceSignInRejectsDisabledAccount(t *testing.T) {
+	user := newAccountTestUser(t)
+	now := time.Now().UTC()
+	user.DisabledAt = &now
+
+	userRepo := new(MockUsersRepo)
+	userRepo.On("FindByEmail", mock.Anything, testUserEmail).Return(user, nil)
+
+	service := NewService(userRepo, new(MockRefreshTokensRepo), getTestConfig(), silentLogger)
+	_, err := service.SignIn(context.Background(), SignInRequest{Email: testUserEmail, Password: testPassword})
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+}
+
+func TestServiceSessionActiveIsCachedAndSeesRevocation(t *testing.T) {
+	userID, sessionID := bson.NewObjectID(), bson.NewObjectID()
+
+	tokenRepo := new(MockRefreshTokensRepo)
+	tokenRepo.On("SessionActive", mock.Anything, userID, sessionID).Return(true, nil).Once()
+	tokenRepo.On("RevokeSession", mock.Anything, userID, sessionID).Return(int64(1), nil)
+
+	service := NewService(new(MockUsersRepo), tokenRepo, getTestConfig(), silentLogger)
+
+	for range 3 {
+		active, err := service.SessionActive(context.Background(), userID, sessionID.Hex())
+		require.NoError(t, err)
+		assert.True(t, active)
+	}
+	tokenRepo.AssertNumberOfCalls(t, "SessionActive", 1)
+
+	require.NoError(t, service.RevokeSession(context.Background(), userID, sessionID))
+
+	active, err := service.SessionActive(context.Background(), userID, sessionID.Hex())
+	require.NoError(t, err)
+	assert.False(t, active, "revocation makes the change visible at once")
+	tokenRepo.AssertNumberOfCalls(t, "SessionActive", 1)
+
+	otherUser := bson.NewObjectID()
+	tokenRepo.On("SessionActive", mock.Anything, otherUser, sessionID).Return(false, nil).Once()
+	active, err = service.SessionActive(context.Background(), otherUser, sessionID.Hex())
+	require.NoError(t, err)
+	assert.False(t, active, "a session of another user is not active")
+}
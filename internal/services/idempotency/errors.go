@@ -0,0 +1,15 @@
+package idempotency
+
+import "errors"
+
+// ErrKeyReused is returned when a key comes back with a different request.
+var ErrKeyReused = errors.New("idempotency key was used for a different request")
+
+// ErrInFlight is returned when the first request with a key has not finished yet.
+var ErrInFlight = errors.New("a request with this idempotency key is in progress")
+
+// ErrKeyExists is returned by Repository.Insert when the key is already stored.
+var ErrKeyExists = errors.New("idempotency key already exists")
+
+// ErrKeyNotFound is returned by Repository.Find when the key is not stored.
+var ErrKeyNotFound = errors.New("idempotency key not found")
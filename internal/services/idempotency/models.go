@@ -0,0 +1,26 @@
+package idempotency
+
+import "time"
+
+// Response is a stored response, replayed to retries of its request
+type Response struct {
+	Status      int    `bson:"status"`
+	ContentType string `bson:"content_type,omitempty"`
+	Body        []byte `bson:"body,omitempty"`
+}
+
+// Record is an idempotency key with the request that first used it and,
+// once that finished, its response
+type Record struct {
+	// ID is the key within the scope of its caller
+	ID string `bson:"_id"`
+	// Fingerprint is a hash of the method, path and body of the request
+	Fingerprint string `bson:"fingerprint"`
+	// Response is nil while the request is in flight
+	Response *Response `bson:"response,omitempty"`
+	// LockedUntil is when an unfinished request counts as abandoned, so a
+	// retry may run it again
+	LockedUntil time.Time `bson:"locked_until"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,20 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Repository stores idempotency keys until they expire
+type Repository interface {
+	// Insert stores a new record, or returns ErrKeyExists
+	Insert(ctx context.Context, r *Record) error
+	// Find returns the record of id, or ErrKeyNotFound
+	Find(ctx context.Context, id string) (*Record, error)
+	// Claim locks an unfinished record whose lock ran out at now until
+	// until, and reports whether it did
+	Claim(ctx context.Context, id string, now, until time.Time) (bool, error)
+	// Complete stores the response of a record
+	Complete(ctx context.Context, id string, resp *Response) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// lockTimeout is how long a request holds its key before a retry may take
+// it over, e.g. after the server stopped mid-request
+const lockTimeout = time.Minute
+
+// Service claims idempotency keys and stores the responses of their
+// requests, so a client retrying a request under the same key gets the
+// response of the first attempt instead of running it again
+type Service struct {
+	repo Repository
+	ttl  time.Duration
+	log  *slog.Logger
+}
+
+// NewService creates a new idempotency service keeping keys for ttl
+func NewService(repo Repository, ttl time.Duration, log *slog.Logger) *Service {
+	return &Service{repo: repo, ttl: ttl, log: log}
+}
+
+// Fingerprint identifies a request by its method, path and body
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key within scope for the request with fingerprint. It
+// returns nil when the request should run, and the stored response when an
+// earlier one with the same key and fingerprint finished. A key of another
+// request fails with ErrKeyReused, one whose request is still running with
+// ErrInFlight.
+func (s *Service) Begin(ctx context.Context, scope, key, fingerprint string) (*Response, error) {
+	id := recordID(scope, key)
+	now := time.Now().UTC()
+	rec := &Record{
+		ID:          id,
+		Fingerprint: fingerprint,
+		LockedUntil: now.Add(lockTimeout),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	// A record can expire or be released between the insert and the find
+	for range 2 {
+		err := s.repo.Insert(ctx, rec)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, ErrKeyExists) {
+			return nil, err
+		}
+
+		stored, err := s.repo.Find(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stored.Fingerprint != fingerprint {
+			return nil, ErrKeyReused
+		}
+		if stored.Response != nil {
+			return stored.Response, nil
+		}
+
+		claimed, err := s.repo.Claim(ctx, id, now, now.Add(lockTimeout))
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
+			return nil, ErrInFlight
+		}
+		s.log.Info("retrying abandoned idempotent request", "scope", scope)
+		return nil, nil
+	}
+	return nil, ErrInFlight
+}
+
+// Complete stores the response of the request that claimed key, to replay
+// to its retries. It runs to the end even when the request is canceled.
+func (s *Service) Complete(ctx context.Context, scope, key string, resp *Response) {
+	if err := s.repo.Complete(context.WithoutCancel(ctx), recordID(scope, key), resp); err != nil {
+		s.log.Error("failed to store idempotent response", "error", err, "scope", scope)
+	}
+}
+
+// Release frees key after a failure worth retrying, so the next attempt
+// runs the request again
+func (s *Service) Release(ctx context.Context, scope, key string) {
+	if err := s.repo.Delete(context.WithoutCancel(ctx), recordID(scope, key)); err != nil {
+		s.log.Error("failed to release idempotency key", "error", err, "scope", scope)
+	}
+}
+
+// recordID keeps the keys of different callers apart
+func recordID(scope, key string) string {
+	return scope + "/" + key
+}
@@ -0,0 +1,103 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type memRepo struct {
+	records map[string]*Record
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{records: make(map[string]*Record)}
+}
+
+func (r *memRepo) Insert(_ context.Context, rec *Record) error {
+	if _, ok := r.records[rec.ID]; ok {
+		return ErrKeyExists
+	}
+	stored := *rec
+	r.records[rec.ID] = &stored
+	return nil
+}
+
+func (r *memRepo) Find(_ context.Context, id string) (*Record, error) {
+	rec, ok := r.records[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	found := *rec
+	return &found, nil
+}
+
+func (r *memRepo) Claim(_ context.Context, id string, now, until time.Time) (bool, error) {
+	rec, ok := r.records[id]
+	if !ok || rec.Response != nil || rec.LockedUntil.After(now) {
+		return false, nil
+	}
+	rec.LockedUntil = until
+	return true, nil
+}
+
+func (r *memRepo) Complete(_ context.Context, id string, resp *Response) error {
+	if rec, ok := r.records[id]; ok {
+		rec.Response = resp
+	}
+	return nil
+}
+
+func (r *memRepo) Delete(_ context.Context, id string) error {
+	delete(r.records, id)
+	return nil
+}
+
+func TestServiceBegin(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	svc := NewService(repo, time.Hour, silentLogger)
+	create := Fingerprint("POST", "/api/v1/notes", []byte(`{"title":"Plan"}`))
+
+	resp, err := svc.Begin(ctx, "user", "k1", create)
+	require.NoError(t, err)
+	assert.Nil(t, resp, "the first request runs")
+
+	_, err = svc.Begin(ctx, "user", "k1", create)
+	assert.ErrorIs(t, err, ErrInFlight)
+	_, err = svc.Begin(ctx, "user", "k1", Fingerprint("POST", "/api/v1/notes", []byte(`{"title":"Other"}`)))
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	resp, err = svc.Begin(ctx, "other-user", "k1", create)
+	require.NoError(t, err)
+	assert.Nil(t, resp, "keys are scoped to their caller")
+
+	stored := &Response{Status: 201, ContentType: "application/json", Body: []byte(`{"note":{}}`)}
+	svc.Complete(ctx, "user", "k1", stored)
+	resp, err = svc.Begin(ctx, "user", "k1", create)
+	require.NoError(t, err)
+	assert.Equal(t, stored, resp)
+
+	// A released key runs again
+	_, err = svc.Begin(ctx, "user", "k2", create)
+	require.NoError(t, err)
+	svc.Release(ctx, "user", "k2")
+	resp, err = svc.Begin(ctx, "user", "k2", create)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+
+	// So does one whose request was abandoned
+	repo.records[recordID("user", "k2")].LockedUntil = time.Now().Add(-time.Second)
+	resp, err = svc.Begin(ctx, "user", "k2", create)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	_, err = svc.Begin(ctx, "user", "k2", create)
+	assert.ErrorIs(t, err, ErrInFlight)
+}
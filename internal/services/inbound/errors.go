@@ -0,0 +1,27 @@
+package inbound
+
+import "errors"
+
+// ErrAddressNotFound is returned when a user has no inbound address.
+var ErrAddressNotFound = errors.New("inbound address not found")
+
+// ErrUnknownRecipient is returned for mail to an address that is not a user's current inbound address.
+var ErrUnknownRecipient = errors.New("unknown recipient")
+
+// ErrRateLimited is returned when an address has taken too many messages lately.
+var ErrRateLimited = errors.New("too many messages to this address")
+
+// ErrMalformedMessage is returned for mail that cannot be parsed.
+var ErrMalformedMessage = errors.New("malformed message")
+
+// ErrGetAddress is returned when an inbound address cannot be read.
+var ErrGetAddress = errors.New("failed to get inbound address")
+
+// ErrRotateAddress is returned when a new inbound address cannot be stored.
+var ErrRotateAddress = errors.New("failed to create inbound address")
+
+// ErrDeleteAddress is returned when an inbound address cannot be deleted.
+var ErrDeleteAddress = errors.New("failed to delete inbound address")
+
+// ErrDeliver is returned when a message cannot be turned into a note.
+var ErrDeliver = errors.New("failed to deliver message")
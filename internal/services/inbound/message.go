@@ -0,0 +1,139 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	// maxPartDepth bounds the nesting of multipart bodies
+	maxPartDepth = 5
+	// maxAttachments bounds the attachments listed in a note
+	maxAttachments = 50
+	// unnamed names attachments without a file name
+	unnamed = "unnamed"
+)
+
+var wordDecoder = mime.WordDecoder{}
+
+// parseMessage reads the subject, text and attachments of a raw message.
+// Text parts in other charsets than UTF-8 are kept as valid UTF-8 at best.
+func parseMessage(data []byte) (*Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrMalformedMessage
+	}
+
+	p := &parser{msg: &Message{Subject: decodeWords(m.Header.Get("Subject"))}}
+	if err := p.walk(textproto.MIMEHeader(m.Header), m.Body, 0); err != nil {
+		return nil, ErrMalformedMessage
+	}
+	p.msg.Text = p.text
+	if p.msg.Text == "" {
+		p.msg.Text = p.html
+	}
+	return p.msg, nil
+}
+
+type parser struct {
+	msg        *Message
+	text, html string
+}
+
+// walk takes the first text/plain and text/html parts of a body and lists
+// the others that carry a file name or are attachments
+func (p *parser) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	ctype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		ctype, params = "text/plain", nil
+	}
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := decodeWords(dparams["filename"])
+	if name == "" {
+		name = decodeWords(params["name"])
+	}
+
+	if strings.HasPrefix(ctype, "multipart/") && depth < maxPartDepth {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := p.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content := decodeTransfer(body, header.Get("Content-Transfer-Encoding"))
+	switch {
+	case disposition == "attachment" || name != "":
+		size, err := io.Copy(io.Discard, content)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = unnamed
+		}
+		if len(p.msg.Attachments) < maxAttachments {
+			p.msg.Attachments = append(p.msg.Attachments, Attachment{Name: name, Size: size})
+		}
+	case ctype == "text/plain" && p.text == "":
+		text, err := readText(content)
+		if err != nil {
+			return err
+		}
+		p.text = text
+	case ctype == "text/html" && p.html == "":
+		html, err := readText(content)
+		if err != nil {
+			return err
+		}
+		p.html = html
+	default:
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+func readText(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToValidUTF8(strings.ReplaceAll(string(b), "\r\n", "\n"), "�"), nil
+}
+
+// decodeWords decodes RFC 2047 encoded words, keeping s as it is when they
+// are in a charset the decoder does not know
+func decodeWords(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
@@ -0,0 +1,100 @@
+package inbound
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func crlf(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
+
+func TestParseMessagePlain(t *testing.T) {
+	msg, err := parseMessage(crlf(`From: ann@example.com
+Subject: =?UTF-8?Q?Caf=C3=A9_order?=
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Two espressos, one =
+latte.
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "Café order", msg.Subject)
+	assert.Equal(t, "Two espressos, one latte.\n", msg.Text)
+	assert.Empty(t, msg.Attachments)
+}
+
+func TestParseMessageMultipart(t *testing.T) {
+	msg, err := parseMessage(crlf(`From: ann@example.com
+Subject: Invoice
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary=outer
+
+--outer
+Content-Type: multipart/alternative; boundary=inner
+
+--inner
+Content-Type: text/html
+
+<p>Please <b>pay</b></p>
+--inner
+Content-Type: text/plain
+
+Please pay
+--inner--
+--outer
+Content-Type: application/pdf; name="invoice.pdf"
+Content-Disposition: attachment; filename="invoice.pdf"
+Content-Transfer-Encoding: base64
+
+aGVsbG8g
+d29ybGQ=
+--outer
+Content-Type: image/png
+Content-Disposition: attachment
+Content-Transfer-Encoding: base64
+
+AAEC
+--outer
+Content-Type: text/plain; name="=?UTF-8?B?w7xiZXIudHh0?="
+
+inline file
+--outer--
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "Invoice", msg.Subject)
+	assert.Equal(t, "Please pay", msg.Text, "text/plain wins over text/html")
+	assert.Equal(t, []Attachment{
+		{Name: "invoice.pdf", Size: 11},
+		{Name: unnamed, Size: 3},
+		{Name: "über.txt", Size: 11},
+	}, msg.Attachments)
+}
+
+func TestParseMessageHTMLOnly(t *testing.T) {
+	msg, err := parseMessage(crlf(`Subject: Hi
+Content-Type: text/html
+
+<p>Hello</p><script>alert(1)</script>
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", noteRequest(msg).Title)
+	assert.Equal(t, "Hello", noteRequest(msg).Body)
+}
+
+func TestParseMessageMalformed(t *testing.T) {
+	_, err := parseMessage([]byte("no headers here"))
+	assert.ErrorIs(t, err, ErrMalformedMessage)
+
+	_, err = parseMessage(crlf(`Subject: Broken
+Content-Type: multipart/mixed; boundary=b
+
+--b
+Content-Type: text/plain
+
+never closed`))
+	assert.ErrorIs(t, err, ErrMalformedMessage)
+}
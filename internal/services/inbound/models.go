@@ -0,0 +1,36 @@
+package inbound
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Address is a user's secret inbound address: mail to <Token>@<domain>
+// becomes a note of theirs
+type Address struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    bson.ObjectID `bson:"user_id"`
+	Token     string        `bson:"token"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// AddressResponse shows a user their inbound address
+type AddressResponse struct {
+	Address   string    `json:"address" example:"k7q2m4x9c3v8b1n6z5t0w2r4yd@in.notepulse.example"`
+	CreatedAt time.Time `json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+}
+
+// Attachment is a MIME attachment of a message, kept by name and size only
+type Attachment struct {
+	Name string
+	Size int64
+}
+
+// Message is the part of an email that makes a note
+type Message struct {
+	Subject string
+	// Text is the text/plain body, else the text/html one
+	Text        string
+	Attachments []Attachment
+}
@@ -0,0 +1,17 @@
+package inbound
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Repository stores inbound addresses, at most one per user
+type Repository interface {
+	Get(ctx context.Context, userID bson.ObjectID) (*Address, error)
+	// Put stores a, replacing the user's previous address
+	Put(ctx context.Context, a *Address) error
+	FindByToken(ctx context.Context, token string) (*Address, error)
+	Delete(ctx context.Context, userID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
@@ -0,0 +1,271 @@
+package inbound
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"note-pulse/internal/services/auth"
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/utils/sanitize"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxTitleRunes bounds the title taken from a subject
+	maxTitleRunes = 200
+	// noSubject titles messages without a subject
+	noSubject = "(no subject)"
+	// rateWindow is the window of the per-address rate limit
+	rateWindow = time.Hour
+	// rateKeysMax triggers a sweep of expired rate limit windows
+	rateKeysMax = 10_000
+)
+
+// tokenEncoding spells address tokens in lower case, as mail systems may
+// change the case of the local part
+var tokenEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// NoteCreator creates the notes of delivered mail; notes.Service does, and
+// broadcasts them as usual
+type NoteCreator interface {
+	Create(ctx context.Context, userID bson.ObjectID, req notes.CreateNoteRequest) (*notes.NoteResponse, error)
+}
+
+// UserStatusProvider reports disabled and deleted users, whose mail is
+// refused
+type UserStatusProvider interface {
+	UserStatus(ctx context.Context, userID bson.ObjectID) (auth.UserStatus, error)
+}
+
+// Service manages inbound addresses and turns mail to them into notes
+type Service struct {
+	repo    Repository
+	notes   NoteCreator
+	users   UserStatusProvider
+	domain  string
+	limiter *limiter
+	log     *slog.Logger
+}
+
+// NewService creates a new inbound mail service for addresses at domain,
+// each taking at most ratePerHour messages an hour
+func NewService(repo Repository, notes NoteCreator, domain string, ratePerHour int, log *slog.Logger) *Service {
+	return &Service{
+		repo:    repo,
+		notes:   notes,
+		domain:  strings.ToLower(domain),
+		limiter: newLimiter(ratePerHour, rateWindow),
+		log:     log,
+	}
+}
+
+// SetUserStatus makes mail to disabled or deleted users bounce
+func (s *Service) SetUserStatus(p UserStatusProvider) {
+	s.users = p
+}
+
+// Address returns the user's inbound address
+func (s *Service) Address(ctx context.Context, userID bson.ObjectID) (*AddressResponse, error) {
+	a, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrAddressNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		s.log.Error(ErrGetAddress.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrGetAddress
+	}
+	return s.response(a), nil
+}
+
+// Rotate gives the user a new inbound address; mail to the previous one
+// bounces from then on
+func (s *Service) Rotate(ctx context.Context, userID bson.ObjectID) (*AddressResponse, error) {
+	token, err := newToken()
+	if err != nil {
+		s.log.Error(ErrRotateAddress.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrRotateAddress
+	}
+	a := &Address{
+		UserID:    userID,
+		Token:     token,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Put(ctx, a); err != nil {
+		s.log.Error(ErrRotateAddress.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrRotateAddress
+	}
+	return s.response(a), nil
+}
+
+// Delete stops the user's inbound address
+func (s *Service) Delete(ctx context.Context, userID bson.ObjectID) error {
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		if errors.Is(err, ErrAddressNotFound) {
+			return ErrAddressNotFound
+		}
+		s.log.Error(ErrDeleteAddress.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteAddress
+	}
+	return nil
+}
+
+// PurgeUser deletes the inbound address of a deleted account
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	if _, err := s.repo.DeleteAllForUser(ctx, userID); err != nil {
+		s.log.Error(ErrDeleteAddress.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteAddress
+	}
+	return nil
+}
+
+// Recipient resolves an envelope recipient to the address of an active
+// user. Each accepted recipient counts against the rate limit of its
+// address.
+func (s *Service) Recipient(ctx context.Context, rcpt string) (*Address, error) {
+	at := strings.LastIndexByte(rcpt, '@')
+	if at <= 0 || !strings.EqualFold(rcpt[at+1:], s.domain) {
+		return nil, ErrUnknownRecipient
+	}
+	token := strings.ToLower(rcpt[:at])
+
+	a, err := s.repo.FindByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrAddressNotFound) {
+			return nil, ErrUnknownRecipient
+		}
+		s.log.Error("failed to find inbound address", "error", err)
+		return nil, ErrDeliver
+	}
+	if s.users != nil {
+		status, err := s.users.UserStatus(ctx, a.UserID)
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			return nil, ErrUnknownRecipient
+		case err != nil:
+			return nil, ErrDeliver
+		case status.Disabled:
+			return nil, ErrUnknownRecipient
+		}
+	}
+	if !s.limiter.allow(token, time.Now()) {
+		return nil, ErrRateLimited
+	}
+	return a, nil
+}
+
+// Deliver turns a raw RFC 5322 message into a note for each recipient
+func (s *Service) Deliver(ctx context.Context, to []*Address, data []byte) error {
+	msg, err := parseMessage(data)
+	if err != nil {
+		return err
+	}
+	req := noteRequest(msg)
+
+	var failed error
+	for _, a := range to {
+		created, err := s.notes.Create(ctx, a.UserID, req)
+		if errors.Is(err, notes.ErrNoteTooLarge) || errors.Is(err, notes.ErrQuotaExceeded) {
+			s.log.Info("note of email refused", "error", err, "user_id", a.UserID.Hex())
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		if err != nil {
+			s.log.Error(ErrDeliver.Error(), "error", err, "user_id", a.UserID.Hex())
+			failed = ErrDeliver
+			continue
+		}
+		s.log.Info("created note from email", "user_id", a.UserID.Hex(), "note_id", created.Note.ID.Hex(), "attachments", len(msg.Attachments))
+	}
+	return failed
+}
+
+func (s *Service) response(a *Address) *AddressResponse {
+	return &AddressResponse{Address: a.Token + "@" + s.domain, CreatedAt: a.CreatedAt}
+}
+
+// noteRequest makes the note of a message: the subject is its title and
+// the text its body, followed by the list of attachments
+func noteRequest(msg *Message) notes.CreateNoteRequest {
+	title := sanitize.Clean(msg.Subject)
+	if utf8.RuneCountInString(title) > maxTitleRunes {
+		title = strings.TrimSpace(string([]rune(title)[:maxTitleRunes]))
+	}
+	if title == "" {
+		title = noSubject
+	}
+
+	var body strings.Builder
+	body.WriteString(strings.TrimSpace(msg.Text))
+	if len(msg.Attachments) > 0 {
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		body.WriteString("Attachments:")
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(&body, "\n- %s (%d bytes)", a.Name, a.Size)
+		}
+	}
+
+	return notes.CreateNoteRequest{Title: title, Body: sanitize.Clean(body.String())}
+}
+
+func newToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return tokenEncoding.EncodeToString(b[:]), nil
+}
+
+// limiter counts events per key in fixed windows
+type limiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string]*window
+}
+
+type window struct {
+	start time.Time
+	n     int
+}
+
+func newLimiter(limit int, d time.Duration) *limiter {
+	return &limiter{limit: limit, window: d, hits: make(map[string]*window)}
+}
+
+// allow counts an event of key at now, reporting whether it is within the
+// limit
+func (l *limiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.hits[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		if len(l.hits) >= rateKeysMax {
+			for k, w := range l.hits {
+				if now.Sub(w.start) >= l.window {
+					delete(l.hits, k)
+				}
+			}
+		}
+		w = &window{start: now}
+		l.hits[key] = w
+	}
+	if w.n >= l.limit {
+		return false
+	}
+	w.n++
+	return true
+}
@@ -0,0 +1,230 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/auth"
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+const testDomain = "in.example.com"
+
+type memRepo struct {
+	mu    sync.Mutex
+	addrs map[bson.ObjectID]*Address
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{addrs: map[bson.ObjectID]*Address{}}
+}
+
+func (r *memRepo) Get(_ context.Context, userID bson.ObjectID) (*Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if a, ok := r.addrs[userID]; ok {
+		return a, nil
+	}
+	return nil, ErrAddressNotFound
+}
+
+func (r *memRepo) Put(_ context.Context, a *Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addrs[a.UserID] = a
+	return nil
+}
+
+func (r *memRepo) FindByToken(_ context.Context, token string) (*Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.addrs {
+		if a.Token == token {
+			return a, nil
+		}
+	}
+	return nil, ErrAddressNotFound
+}
+
+func (r *memRepo) Delete(_ context.Context, userID bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.addrs[userID]; !ok {
+		return ErrAddressNotFound
+	}
+	delete(r.addrs, userID)
+	return nil
+}
+
+func (r *memRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	if err := r.Delete(ctx, userID); err != nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// memNotes records the notes created
+type memNotes struct {
+	mu      sync.Mutex
+	created []notes.CreateNoteRequest
+	users   []bson.ObjectID
+	err     error
+}
+
+func (n *memNotes) Create(_ context.Context, userID bson.ObjectID, req notes.CreateNoteRequest) (*notes.NoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return nil, n.err
+	}
+	n.created = append(n.created, req)
+	n.users = append(n.users, userID)
+	return &notes.NoteResponse{Note: &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: req.Title, Body: req.Body}}, nil
+}
+
+func (n *memNotes) all() []notes.CreateNoteRequest {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notes.CreateNoteRequest(nil), n.created...)
+}
+
+type fakeStatuses map[bson.ObjectID]auth.UserStatus
+
+func (f fakeStatuses) UserStatus(_ context.Context, userID bson.ObjectID) (auth.UserStatus, error) {
+	status, ok := f[userID]
+	if !ok {
+		return auth.UserStatus{}, auth.ErrUserNotFound
+	}
+	return status, nil
+}
+
+func newTestService(ratePerHour int) (*Service, *memNotes) {
+	n := &memNotes{}
+	return NewService(newMemRepo(), n, testDomain, ratePerHour, silentLogger), n
+}
+
+func TestServiceAddress(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(10)
+	userID := bson.NewObjectID()
+
+	_, err := svc.Address(ctx, userID)
+	assert.ErrorIs(t, err, ErrAddressNotFound)
+
+	first, err := svc.Rotate(ctx, userID)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z2-7]{26}@in\.example\.com$`, first.Address)
+
+	got, err := svc.Address(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, first.Address, got.Address)
+
+	second, err := svc.Rotate(ctx, userID)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Address, second.Address)
+
+	_, err = svc.Recipient(ctx, first.Address)
+	assert.ErrorIs(t, err, ErrUnknownRecipient, "the old address bounces")
+	a, err := svc.Recipient(ctx, second.Address)
+	require.NoError(t, err)
+	assert.Equal(t, userID, a.UserID)
+
+	require.NoError(t, svc.Delete(ctx, userID))
+	assert.ErrorIs(t, svc.Delete(ctx, userID), ErrAddressNotFound)
+	_, err = svc.Recipient(ctx, second.Address)
+	assert.ErrorIs(t, err, ErrUnknownRecipient)
+}
+
+func TestServiceRecipient(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(2)
+	active, disabled := bson.NewObjectID(), bson.NewObjectID()
+	svc.SetUserStatus(fakeStatuses{active: {}, disabled: {Disabled: true}})
+
+	addr, err := svc.Rotate(ctx, active)
+	require.NoError(t, err)
+	disabledAddr, err := svc.Rotate(ctx, disabled)
+	require.NoError(t, err)
+	gone, err := svc.Rotate(ctx, bson.NewObjectID())
+	require.NoError(t, err)
+
+	for _, rcpt := range []string{
+		"nobody@" + testDomain,
+		addr.Address[:26] + "@other.example.com",
+		"no-at-sign",
+		disabledAddr.Address,
+		gone.Address,
+	} {
+		_, err := svc.Recipient(ctx, rcpt)
+		assert.ErrorIs(t, err, ErrUnknownRecipient, rcpt)
+	}
+
+	upper := addr.Address[:26] + "@IN.EXAMPLE.COM"
+	_, err = svc.Recipient(ctx, upper)
+	require.NoError(t, err, "domains are case-insensitive")
+	_, err = svc.Recipient(ctx, addr.Address)
+	require.NoError(t, err)
+	_, err = svc.Recipient(ctx, addr.Address)
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
+
+func TestServiceDeliver(t *testing.T) {
+	ctx := context.Background()
+	svc, created := newTestService(10)
+	userID := bson.NewObjectID()
+	addr, err := svc.Rotate(ctx, userID)
+	require.NoError(t, err)
+	a, err := svc.Recipient(ctx, addr.Address)
+	require.NoError(t, err)
+
+	raw := "From: Ann <ann@example.com>\r\nSubject: <b>Ticket</b> 42\r\n\r\nThe <i>printer</i> is on fire.\r\n"
+	require.NoError(t, svc.Deliver(ctx, []*Address{a}, []byte(raw)))
+	got := created.all()
+	require.Len(t, got, 1)
+	assert.Equal(t, "Ticket 42", got[0].Title)
+	assert.Equal(t, "The printer is on fire.", got[0].Body)
+
+	assert.ErrorIs(t, svc.Deliver(ctx, []*Address{a}, []byte("not a message")), ErrMalformedMessage)
+
+	created.err = errors.New("boom")
+	assert.ErrorIs(t, svc.Deliver(ctx, []*Address{a}, []byte(raw)), ErrDeliver)
+
+	// A note refused over quota is not worth retrying
+	created.err = notes.ErrQuotaExceeded
+	assert.ErrorIs(t, svc.Deliver(ctx, []*Address{a}, []byte(raw)), notes.ErrQuotaExceeded)
+}
+
+func TestNoteRequest(t *testing.T) {
+	req := noteRequest(&Message{
+		Text:        "  See attached.  ",
+		Attachments: []Attachment{{Name: "report.pdf", Size: 1234}, {Name: unnamed, Size: 0}},
+	})
+	assert.Equal(t, noSubject, req.Title)
+	assert.Equal(t, "See attached.\n\nAttachments:\n- report.pdf (1234 bytes)\n- unnamed (0 bytes)", req.Body)
+
+	long := noteRequest(&Message{Subject: strings.Repeat("é", 300)})
+	assert.Equal(t, maxTitleRunes, len([]rune(long.Title)))
+	assert.Empty(t, long.Body)
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, time.Hour)
+	now := time.Now()
+	assert.True(t, l.allow("a", now))
+	assert.True(t, l.allow("a", now))
+	assert.False(t, l.allow("a", now))
+	assert.True(t, l.allow("b", now), "keys are counted apart")
+	assert.True(t, l.allow("a", now.Add(time.Hour)), "a new window starts over")
+}
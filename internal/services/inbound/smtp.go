@@ -0,0 +1,325 @@
+package inbound
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"note-pulse/internal/services/notes"
+)
+
+const (
+	// maxSessions bounds the SMTP connections served at once
+	maxSessions = 100
+	// maxRecipients bounds the recipients of one message
+	maxRecipients = 10
+	// maxLineBytes bounds a command line
+	maxLineBytes = 1024
+	// commandTimeout is how long a client may take to send a command
+	commandTimeout = 5 * time.Minute
+	// dataTimeout is how long a client may take to send a message
+	dataTimeout = 10 * time.Minute
+)
+
+// Server is a receive-only SMTP server for inbound addresses. It takes mail
+// for them from any client, without TLS or authentication: the address
+// itself is the secret.
+type Server struct {
+	svc      *Service
+	maxBytes int64
+	log      *slog.Logger
+
+	sessions chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer creates an SMTP server rejecting messages over maxBytes
+func NewServer(svc *Service, maxBytes int64, log *slog.Logger) *Server {
+	return &Server{
+		svc:      svc,
+		maxBytes: maxBytes,
+		log:      log,
+		sessions: make(chan struct{}, maxSessions),
+	}
+}
+
+// ListenAndServe serves SMTP on addr until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for SMTP: %w", err)
+	}
+	s.log.Info("inbound SMTP listening", "addr", ln.Addr().String(), "domain", s.svc.domain)
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves SMTP on ln until ctx is done, then closes ln and waits for
+// the sessions in progress to end
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	defer s.wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return fmt.Errorf("failed to accept SMTP connection: %w", err)
+		}
+
+		select {
+		case s.sessions <- struct{}{}:
+		default:
+			_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+			_, _ = io.WriteString(conn, "421 4.3.2 Too busy, try again later\r\n")
+			conn.Close()
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() { <-s.sessions }()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+// session is the state of one SMTP connection
+type session struct {
+	srv   *Server
+	conn  net.Conn
+	r     *bufio.Reader
+	hello bool
+	from  bool
+	to    []*Address
+}
+
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	// Shutting down ends a wait for the next command at once
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+	ss := &session{srv: s, conn: conn, r: bufio.NewReaderSize(conn, maxLineBytes)}
+
+	ss.reply(220, s.svc.domain+" NotePulse ESMTP ready")
+	for {
+		_ = conn.SetDeadline(time.Now().Add(commandTimeout))
+		if ctx.Err() != nil {
+			ss.reply(421, "4.3.2 Shutting down")
+			return
+		}
+		line, err := ss.readLine()
+		if err != nil {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				ss.reply(500, "5.5.2 Line too long")
+			}
+			return
+		}
+		if !ss.handle(ctx, line) {
+			return
+		}
+	}
+}
+
+// handle runs one command, reporting whether the session goes on
+func (ss *session) handle(ctx context.Context, line string) bool {
+	verb, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToUpper(verb) {
+	case "EHLO":
+		ss.hello = true
+		ss.reset()
+		ss.replyLines(250, ss.srv.svc.domain, "SIZE "+strconv.FormatInt(ss.srv.maxBytes, 10), "8BITMIME", "ENHANCEDSTATUSCODES")
+	case "HELO":
+		ss.hello = true
+		ss.reset()
+		ss.reply(250, ss.srv.svc.domain)
+	case "MAIL":
+		ss.mail(arg)
+	case "RCPT":
+		ss.rcpt(ctx, arg)
+	case "DATA":
+		return ss.data(ctx)
+	case "RSET":
+		ss.reset()
+		ss.reply(250, "2.0.0 OK")
+	case "NOOP":
+		ss.reply(250, "2.0.0 OK")
+	case "VRFY":
+		ss.reply(252, "2.5.0 Cannot verify, send some mail")
+	case "QUIT":
+		ss.reply(221, "2.0.0 Bye")
+		return false
+	default:
+		ss.reply(502, "5.5.2 Command not recognized")
+	}
+	return true
+}
+
+func (ss *session) mail(arg string) {
+	if !ss.hello {
+		ss.reply(503, "5.5.1 Say EHLO first")
+		return
+	}
+	if ss.from {
+		ss.reply(503, "5.5.1 Sender already given")
+		return
+	}
+	// Any sender may write to an inbound address
+	_, params, ok := cutPath(arg, "FROM:")
+	if !ok {
+		ss.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+		return
+	}
+	for _, p := range strings.Fields(params) {
+		key, value, _ := strings.Cut(p, "=")
+		if strings.EqualFold(key, "SIZE") {
+			if size, err := strconv.ParseInt(value, 10, 64); err == nil && size > ss.srv.maxBytes {
+				ss.reply(552, "5.3.4 Message too big")
+				return
+			}
+		}
+	}
+	ss.from = true
+	ss.reply(250, "2.1.0 OK")
+}
+
+func (ss *session) rcpt(ctx context.Context, arg string) {
+	if !ss.from {
+		ss.reply(503, "5.5.1 Need MAIL first")
+		return
+	}
+	if len(ss.to) >= maxRecipients {
+		ss.reply(452, "4.5.3 Too many recipients")
+		return
+	}
+	path, _, ok := cutPath(arg, "TO:")
+	if !ok {
+		ss.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+		return
+	}
+
+	a, err := ss.srv.svc.Recipient(ctx, path)
+	switch {
+	case errors.Is(err, ErrUnknownRecipient):
+		ss.reply(550, "5.1.1 No such mailbox")
+	case errors.Is(err, ErrRateLimited):
+		ss.reply(450, "4.7.1 Too many messages to this address, try again later")
+	case err != nil:
+		ss.reply(451, "4.3.0 Temporary failure, try again later")
+	default:
+		ss.to = append(ss.to, a)
+		ss.reply(250, "2.1.5 OK")
+	}
+}
+
+// data reads the message and delivers it, reporting whether the session
+// goes on
+func (ss *session) data(ctx context.Context) bool {
+	if len(ss.to) == 0 {
+		ss.reply(503, "5.5.1 Need RCPT first")
+		return true
+	}
+	ss.reply(354, "End data with <CR><LF>.<CR><LF>")
+	_ = ss.conn.SetDeadline(time.Now().Add(dataTimeout))
+
+	dot := textproto.NewReader(ss.r).DotReader()
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(dot, ss.srv.maxBytes+1))
+	if err == nil && n > ss.srv.maxBytes {
+		// Read up to the final dot so the client hears the reply
+		_, err = io.Copy(io.Discard, dot)
+		if err == nil {
+			ss.reset()
+			ss.reply(552, "5.3.4 Message too big")
+			return true
+		}
+	}
+	if err != nil {
+		return false
+	}
+
+	to := ss.to
+	ss.reset()
+	switch err := ss.srv.svc.Deliver(ctx, to, buf.Bytes()); {
+	case errors.Is(err, ErrMalformedMessage):
+		ss.reply(554, "5.6.0 Malformed message")
+	case errors.Is(err, notes.ErrNoteTooLarge):
+		ss.reply(552, "5.3.4 Message too big")
+	case errors.Is(err, notes.ErrQuotaExceeded):
+		ss.reply(552, "5.2.2 Mailbox full")
+	case err != nil:
+		ss.reply(451, "4.3.0 Temporary failure, try again later")
+	default:
+		ss.reply(250, "2.0.0 OK")
+	}
+	return true
+}
+
+func (ss *session) reset() {
+	ss.from = false
+	ss.to = nil
+}
+
+// readLine reads a command line without its CRLF; longer lines than the
+// buffer fail with bufio.ErrBufferFull
+func (ss *session) readLine() (string, error) {
+	line, err := ss.r.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+func (ss *session) reply(code int, text string) {
+	ss.replyLines(code, text)
+}
+
+func (ss *session) replyLines(code int, lines ...string) {
+	var b strings.Builder
+	for i, l := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		fmt.Fprintf(&b, "%d%s%s\r\n", code, sep, l)
+	}
+	if _, err := io.WriteString(ss.conn, b.String()); err != nil {
+		ss.srv.log.Debug("failed to write SMTP reply", "error", err)
+	}
+}
+
+// cutPath parses "FROM:<path> params" for prefix FROM: or TO:
+func cutPath(arg, prefix string) (string, string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", "", false
+	}
+	end := strings.IndexByte(rest, '>')
+	if end < 0 {
+		return "", "", false
+	}
+	return rest[1:end], strings.TrimSpace(rest[end+1:]), true
+}
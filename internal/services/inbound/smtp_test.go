@@ -0,0 +1,106 @@
+package inbound
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// startServer serves SMTP on a loopback port until the test ends
+func startServer(t *testing.T, svc *Service, maxBytes int64) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- NewServer(svc, maxBytes, silentLogger).Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return ln.Addr().String()
+}
+
+func TestServerDelivers(t *testing.T) {
+	svc, created := newTestService(10)
+	userID := bson.NewObjectID()
+	addr, err := svc.Rotate(context.Background(), userID)
+	require.NoError(t, err)
+	server := startServer(t, svc, 1<<20)
+
+	msg := "From: ann@example.com\r\nSubject: Printer\r\n\r\nIt is on fire.\r\n.leading dot\r\n"
+	require.NoError(t, smtp.SendMail(server, nil, "ann@example.com", []string{addr.Address}, []byte(msg)))
+
+	got := created.all()
+	require.Len(t, got, 1)
+	assert.Equal(t, "Printer", got[0].Title)
+	assert.Equal(t, "It is on fire.\n.leading dot", got[0].Body)
+	assert.Equal(t, []bson.ObjectID{userID}, created.users)
+
+	err = smtp.SendMail(server, nil, "ann@example.com", []string{"nobody@" + testDomain}, []byte(msg))
+	var perr *textproto.Error
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 550, perr.Code)
+}
+
+func TestServerRateLimits(t *testing.T) {
+	svc, created := newTestService(1)
+	addr, err := svc.Rotate(context.Background(), bson.NewObjectID())
+	require.NoError(t, err)
+	server := startServer(t, svc, 1<<20)
+
+	msg := []byte("Subject: One\r\n\r\nbody\r\n")
+	require.NoError(t, smtp.SendMail(server, nil, "ann@example.com", []string{addr.Address}, msg))
+	err = smtp.SendMail(server, nil, "ann@example.com", []string{addr.Address}, msg)
+	var perr *textproto.Error
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 450, perr.Code)
+	assert.Len(t, created.all(), 1)
+}
+
+func TestServerRejectsOversizedMessages(t *testing.T) {
+	svc, created := newTestService(10)
+	addr, err := svc.Rotate(context.Background(), bson.NewObjectID())
+	require.NoError(t, err)
+	server := startServer(t, svc, 64)
+
+	conn, err := net.Dial("tcp", server)
+	require.NoError(t, err)
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	expect := func(code int) {
+		t.Helper()
+		_, _, err := tp.ReadResponse(code)
+		require.NoError(t, err)
+	}
+	send := func(line string, code int) {
+		t.Helper()
+		require.NoError(t, tp.PrintfLine("%s", line))
+		expect(code)
+	}
+
+	expect(220)
+	send("MAIL FROM:<ann@example.com>", 503)
+	send("EHLO client.example.com", 250)
+	send("MAIL FROM:<ann@example.com> SIZE=1000", 552)
+	send("MAIL FROM:<ann@example.com> SIZE=10", 250)
+	send("DATA", 503)
+	send("RCPT TO:<"+addr.Address+">", 250)
+	send("DATA", 354)
+	w := tp.DotWriter()
+	_, err = w.Write([]byte("Subject: Big\r\n\r\n" + strings.Repeat("x", 200) + "\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	expect(552)
+	assert.Empty(t, created.all())
+
+	// The session goes on after the rejection
+	send("NOOP", 250)
+	send("QUIT", 221)
+}
@@ -0,0 +1,34 @@
+package notebooks
+
+import (
+	"errors"
+
+	"note-pulse/internal/services/notes"
+)
+
+// ErrNotebookNotFound is returned when a notebook does not exist or belongs to someone else.
+var ErrNotebookNotFound = notes.ErrNotebookNotFound
+
+// ErrNotebookExists is returned when the parent already holds a notebook with the same name.
+var ErrNotebookExists = errors.New("a notebook with this name already exists here")
+
+// ErrTooManyNotebooks is returned when a user already has the maximum number of notebooks.
+var ErrTooManyNotebooks = errors.New("too many notebooks")
+
+// ErrTooDeep is returned when a notebook would nest deeper than MaxDepth.
+var ErrTooDeep = errors.New("notebooks nest too deep")
+
+// ErrNotebookCycle is returned when a notebook would move into itself or one of its own notebooks.
+var ErrNotebookCycle = errors.New("a notebook cannot move into itself")
+
+// ErrCreateNotebook is returned when notebook creation fails.
+var ErrCreateNotebook = errors.New("failed to create notebook")
+
+// ErrListNotebooks is returned when notebooks cannot be read.
+var ErrListNotebooks = errors.New("failed to list notebooks")
+
+// ErrUpdateNotebook is returned when a notebook cannot be renamed or moved.
+var ErrUpdateNotebook = errors.New("failed to update notebook")
+
+// ErrDeleteNotebook is returned when notebook deletion fails.
+var ErrDeleteNotebook = errors.New("failed to delete notebook")
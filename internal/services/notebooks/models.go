@@ -0,0 +1,51 @@
+package notebooks
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Notebook groups personal notes; notebooks nest up to MaxDepth levels
+type Notebook struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd9"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	// ParentID is nil for a top-level notebook
+	ParentID *bson.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty" example:"683cdb8aa96ad71e8e075bda"`
+	Name     string         `bson:"name" json:"name" example:"Projects"`
+	// Path is the materialized path of IDs from the top-level notebook down
+	// to this one; the notebooks nested in it are those whose path starts
+	// with its own
+	Path string `bson:"path" json:"path" example:"/683cdb8aa96ad71e8e075bda/683cdb8aa96ad71e8e075bd9/"`
+	// Depth is 0 for a top-level notebook
+	Depth     int       `bson:"depth" json:"depth" example:"1"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+}
+
+// CreateNotebookRequest creates a notebook
+type CreateNotebookRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100" example:"Projects"`
+	// ParentID nests the notebook; empty creates a top-level one
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bda"`
+}
+
+// UpdateNotebookRequest renames a notebook or moves it with everything in it
+type UpdateNotebookRequest struct {
+	Name *string `json:"name,omitempty" validate:"omitempty,min=1,max=100" example:"Archive"`
+	// ParentID moves the notebook under another one; "" makes it top-level
+	ParentID *string `json:"parent_id,omitempty" validate:"omitempty,max=24" example:"683cdb8aa96ad71e8e075bda"`
+}
+
+// DeleteNotebookRequest says what happens to the notes of a deleted notebook
+// and the notebooks nested in it
+type DeleteNotebookRequest struct {
+	// Notes is inbox (default) to keep them outside any notebook, or delete
+	Notes string `query:"notes" validate:"omitempty,oneof=inbox delete" example:"inbox"`
+}
+
+// ListNotebooksResponse lists a user's notebooks in path order, each parent
+// before the notebooks nested in it
+type ListNotebooksResponse struct {
+	Notebooks []*Notebook `json:"notebooks"`
+}
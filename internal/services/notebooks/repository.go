@@ -0,0 +1,32 @@
+package notebooks
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Repository stores notebooks. Every lookup is scoped to the owner.
+type Repository interface {
+	// Create returns ErrNotebookExists when the parent holds a notebook of
+	// the same name
+	Create(ctx context.Context, nb *Notebook) error
+	List(ctx context.Context, userID bson.ObjectID) ([]*Notebook, error)
+	Count(ctx context.Context, userID bson.ObjectID) (int64, error)
+	Find(ctx context.Context, userID, notebookID bson.ObjectID) (*Notebook, error)
+	// Subtree returns the notebook at path followed by every notebook
+	// nested in it
+	Subtree(ctx context.Context, userID bson.ObjectID, path string) ([]*Notebook, error)
+	Rename(ctx context.Context, userID, notebookID bson.ObjectID, name string) (*Notebook, error)
+	// Move puts nb under parentID, nil for the top level, at path and depth,
+	// and carries every notebook nested in it along
+	Move(ctx context.Context, userID bson.ObjectID, nb *Notebook, parentID *bson.ObjectID, path string, depth int) (*Notebook, error)
+	DeleteMany(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error)
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
+
+// Notes handles the notes filed in notebooks that are deleted
+type Notes interface {
+	ReleaseNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error)
+	DeleteInNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error)
+}
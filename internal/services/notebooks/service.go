@@ -0,0 +1,287 @@
+package notebooks
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/utils/sanitize"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MaxDepth is how many levels notebooks nest
+const MaxDepth = 8
+
+// maxNotebooksPerUser bounds the notebooks of one user
+const maxNotebooksPerUser = 500
+
+// DeleteNotes is the DeleteNotebookRequest value that deletes the notes
+const DeleteNotes = "delete"
+
+// Service manages notebooks and resolves them for the notes service
+type Service struct {
+	repo  Repository
+	notes Notes
+	log   *slog.Logger
+}
+
+// NewService creates a new notebooks service
+func NewService(repo Repository, notes Notes, log *slog.Logger) *Service {
+	return &Service{
+		repo:  repo,
+		notes: notes,
+		log:   log,
+	}
+}
+
+// List returns the user's notebooks
+func (s *Service) List(ctx context.Context, userID bson.ObjectID) (*ListNotebooksResponse, error) {
+	list, err := s.repo.List(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListNotebooks.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListNotebooks
+	}
+	return &ListNotebooksResponse{Notebooks: list}, nil
+}
+
+// Create creates a notebook, nested in ParentID if given
+func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateNotebookRequest) (*Notebook, error) {
+	name, err := cleanName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.Count(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrCreateNotebook.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateNotebook
+	}
+	if n >= maxNotebooksPerUser {
+		return nil, ErrTooManyNotebooks
+	}
+
+	parent, err := s.parent(ctx, userID, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	nb := &Notebook{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	nb.Path, nb.Depth = placement(parent, nb.ID)
+	if parent != nil {
+		nb.ParentID = &parent.ID
+	}
+	if nb.Depth >= MaxDepth {
+		return nil, ErrTooDeep
+	}
+
+	if err := s.repo.Create(ctx, nb); err != nil {
+		if errors.Is(err, ErrNotebookExists) {
+			return nil, ErrNotebookExists
+		}
+		s.log.Error(ErrCreateNotebook.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateNotebook
+	}
+	return nb, nil
+}
+
+// Get returns one notebook
+func (s *Service) Get(ctx context.Context, userID, notebookID bson.ObjectID) (*Notebook, error) {
+	return s.find(ctx, userID, notebookID)
+}
+
+// Update renames a notebook and moves it, with the notebooks nested in it,
+// under another parent
+func (s *Service) Update(ctx context.Context, userID, notebookID bson.ObjectID, req UpdateNotebookRequest) (*Notebook, error) {
+	nb, err := s.find(ctx, userID, notebookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if nb, err = s.move(ctx, nb, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != nil {
+		name, err := cleanName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if nb, err = s.repo.Rename(ctx, userID, notebookID, name); err != nil {
+			return nil, s.updateError(err, userID, notebookID)
+		}
+	}
+	return nb, nil
+}
+
+// move puts nb under the notebook rawParent names, or at the top level for ""
+func (s *Service) move(ctx context.Context, nb *Notebook, rawParent string) (*Notebook, error) {
+	parent, err := s.parent(ctx, nb.UserID, rawParent)
+	if err != nil {
+		return nil, err
+	}
+	if parent != nil && strings.HasPrefix(parent.Path, nb.Path) {
+		return nil, ErrNotebookCycle
+	}
+
+	path, depth := placement(parent, nb.ID)
+	if path == nb.Path {
+		return nb, nil
+	}
+
+	subtree, err := s.repo.Subtree(ctx, nb.UserID, nb.Path)
+	if err != nil {
+		s.log.Error(ErrUpdateNotebook.Error(), "error", err, "user_id", nb.UserID.Hex(), "notebook_id", nb.ID.Hex())
+		return nil, ErrUpdateNotebook
+	}
+	deepest := nb.Depth
+	for _, child := range subtree {
+		deepest = max(deepest, child.Depth)
+	}
+	if depth+deepest-nb.Depth >= MaxDepth {
+		return nil, ErrTooDeep
+	}
+
+	var parentID *bson.ObjectID
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	moved, err := s.repo.Move(ctx, nb.UserID, nb, parentID, path, depth)
+	if err != nil {
+		return nil, s.updateError(err, nb.UserID, nb.ID)
+	}
+	return moved, nil
+}
+
+// Delete deletes a notebook and every notebook nested in it. Their notes go
+// to the Inbox, or are deleted when req asks to.
+func (s *Service) Delete(ctx context.Context, userID, notebookID bson.ObjectID, req DeleteNotebookRequest) error {
+	nb, err := s.find(ctx, userID, notebookID)
+	if err != nil {
+		return err
+	}
+	subtree, err := s.repo.Subtree(ctx, userID, nb.Path)
+	if err != nil {
+		s.log.Error(ErrDeleteNotebook.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+		return ErrDeleteNotebook
+	}
+	ids := notebookIDs(subtree)
+
+	// Notes go first so a failure leaves the notebooks to retry with
+	if req.Notes == DeleteNotes {
+		_, err = s.notes.DeleteInNotebooks(ctx, userID, ids)
+	} else {
+		_, err = s.notes.ReleaseNotebooks(ctx, userID, ids)
+	}
+	if err != nil {
+		return ErrDeleteNotebook
+	}
+
+	if _, err := s.repo.DeleteMany(ctx, userID, ids); err != nil {
+		s.log.Error(ErrDeleteNotebook.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+		return ErrDeleteNotebook
+	}
+	return nil
+}
+
+// Subtree returns notebookID followed, with recursive set, by the notebooks
+// nested in it. It implements notes.Notebooks.
+func (s *Service) Subtree(ctx context.Context, userID, notebookID bson.ObjectID, recursive bool) ([]bson.ObjectID, error) {
+	nb, err := s.find(ctx, userID, notebookID)
+	if err != nil {
+		return nil, err
+	}
+	if !recursive {
+		return []bson.ObjectID{nb.ID}, nil
+	}
+
+	subtree, err := s.repo.Subtree(ctx, userID, nb.Path)
+	if err != nil {
+		s.log.Error(ErrListNotebooks.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+		return nil, ErrListNotebooks
+	}
+	return notebookIDs(subtree), nil
+}
+
+// PurgeUser deletes every notebook of a user
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	deleted, err := s.repo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrDeleteNotebook.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteNotebook
+	}
+	s.log.Info("purged notebooks of deleted account", "user_id", userID.Hex(), "deleted", deleted)
+	return nil
+}
+
+// parent finds the notebook a parent_id names; "" is the top level and
+// yields nil
+func (s *Service) parent(ctx context.Context, userID bson.ObjectID, raw string) (*Notebook, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parentID, err := bson.ObjectIDFromHex(raw)
+	if err != nil {
+		return nil, notes.ErrBadRequest
+	}
+	return s.find(ctx, userID, parentID)
+}
+
+func (s *Service) find(ctx context.Context, userID, notebookID bson.ObjectID) (*Notebook, error) {
+	nb, err := s.repo.Find(ctx, userID, notebookID)
+	if err != nil {
+		if errors.Is(err, ErrNotebookNotFound) {
+			return nil, ErrNotebookNotFound
+		}
+		s.log.Error(ErrListNotebooks.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+		return nil, ErrListNotebooks
+	}
+	return nb, nil
+}
+
+// updateError keeps the errors callers map to 4xx and logs the rest
+func (s *Service) updateError(err error, userID, notebookID bson.ObjectID) error {
+	if errors.Is(err, ErrNotebookNotFound) || errors.Is(err, ErrNotebookExists) {
+		return err
+	}
+	s.log.Error(ErrUpdateNotebook.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+	return ErrUpdateNotebook
+}
+
+// placement returns the path and depth of notebookID under parent, nil for
+// the top level
+func placement(parent *Notebook, notebookID bson.ObjectID) (string, int) {
+	if parent == nil {
+		return "/" + notebookID.Hex() + "/", 0
+	}
+	return parent.Path + notebookID.Hex() + "/", parent.Depth + 1
+}
+
+// cleanName strips markup from a notebook name, which must keep some text
+func cleanName(name string) (string, error) {
+	clean := strings.TrimSpace(sanitize.Clean(name))
+	if clean == "" {
+		return "", notes.ErrBadRequest
+	}
+	return clean, nil
+}
+
+func notebookIDs(list []*Notebook) []bson.ObjectID {
+	ids := make([]bson.ObjectID, 0, len(list))
+	for _, nb := range list {
+		ids = append(ids, nb.ID)
+	}
+	return ids
+}
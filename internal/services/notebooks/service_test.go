@@ -0,0 +1,356 @@
+package notebooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memRepo is an in-memory Repository
+type memRepo struct {
+	mu        sync.Mutex
+	notebooks map[bson.ObjectID]*Notebook
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{notebooks: map[bson.ObjectID]*Notebook{}}
+}
+
+func (m *memRepo) clash(nb *Notebook, parentID *bson.ObjectID, name string) bool {
+	for _, other := range m.notebooks {
+		if other.ID != nb.ID && other.UserID == nb.UserID && other.Name == name && sameParent(other.ParentID, parentID) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameParent(a, b *bson.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (m *memRepo) Create(_ context.Context, nb *Notebook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clash(nb, nb.ParentID, nb.Name) {
+		return ErrNotebookExists
+	}
+	cp := *nb
+	m.notebooks[nb.ID] = &cp
+	return nil
+}
+
+func (m *memRepo) List(_ context.Context, userID bson.ObjectID) ([]*Notebook, error) {
+	return m.filter(func(nb *Notebook) bool { return nb.UserID == userID }), nil
+}
+
+func (m *memRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	list, err := m.List(ctx, userID)
+	return int64(len(list)), err
+}
+
+func (m *memRepo) Find(_ context.Context, userID, notebookID bson.ObjectID) (*Notebook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	nb, ok := m.notebooks[notebookID]
+	if !ok || nb.UserID != userID {
+		return nil, ErrNotebookNotFound
+	}
+	cp := *nb
+	return &cp, nil
+}
+
+func (m *memRepo) Subtree(_ context.Context, userID bson.ObjectID, path string) ([]*Notebook, error) {
+	return m.filter(func(nb *Notebook) bool { return nb.UserID == userID && strings.HasPrefix(nb.Path, path) }), nil
+}
+
+func (m *memRepo) Rename(ctx context.Context, userID, notebookID bson.ObjectID, name string) (*Notebook, error) {
+	m.mu.Lock()
+	nb, ok := m.notebooks[notebookID]
+	if !ok || nb.UserID != userID {
+		m.mu.Unlock()
+		return nil, ErrNotebookNotFound
+	}
+	if m.clash(nb, nb.ParentID, name) {
+		m.mu.Unlock()
+		return nil, ErrNotebookExists
+	}
+	nb.Name = name
+	m.mu.Unlock()
+	return m.Find(ctx, userID, notebookID)
+}
+
+func (m *memRepo) Move(ctx context.Context, userID bson.ObjectID, nb *Notebook, parentID *bson.ObjectID, path string, depth int) (*Notebook, error) {
+	m.mu.Lock()
+	stored, ok := m.notebooks[nb.ID]
+	if !ok || stored.UserID != userID || stored.Path != nb.Path {
+		m.mu.Unlock()
+		return nil, ErrNotebookNotFound
+	}
+	if m.clash(stored, parentID, stored.Name) {
+		m.mu.Unlock()
+		return nil, ErrNotebookExists
+	}
+	stored.ParentID = parentID
+	for _, other := range m.notebooks {
+		if other.UserID == userID && strings.HasPrefix(other.Path, nb.Path) {
+			other.Path = path + strings.TrimPrefix(other.Path, nb.Path)
+			other.Depth += depth - nb.Depth
+		}
+	}
+	m.mu.Unlock()
+	return m.Find(ctx, userID, nb.ID)
+}
+
+func (m *memRepo) DeleteMany(_ context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, id := range notebookIDs {
+		if nb, ok := m.notebooks[id]; ok && nb.UserID == userID {
+			delete(m.notebooks, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	list, _ := m.List(ctx, userID)
+	return m.DeleteMany(ctx, userID, notebookIDs(list))
+}
+
+func (m *memRepo) filter(keep func(*Notebook) bool) []*Notebook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*Notebook{}
+	for _, nb := range m.notebooks {
+		if keep(nb) {
+			cp := *nb
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// fakeNotes records which notebooks had their notes released or deleted
+type fakeNotes struct {
+	released []bson.ObjectID
+	deleted  []bson.ObjectID
+	err      error
+}
+
+func (f *fakeNotes) ReleaseNotebooks(_ context.Context, _ bson.ObjectID, ids []bson.ObjectID) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.released = append(f.released, ids...)
+	return int64(len(ids)), nil
+}
+
+func (f *fakeNotes) DeleteInNotebooks(_ context.Context, _ bson.ObjectID, ids []bson.ObjectID) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.deleted = append(f.deleted, ids...)
+	return int64(len(ids)), nil
+}
+
+func TestServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	work, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: " <b>Work</b> "})
+	require.NoError(t, err)
+	assert.Equal(t, "Work", work.Name)
+	assert.Nil(t, work.ParentID)
+	assert.Equal(t, "/"+work.ID.Hex()+"/", work.Path)
+	assert.Zero(t, work.Depth)
+
+	projects, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "Projects", ParentID: work.ID.Hex()})
+	require.NoError(t, err)
+	require.NotNil(t, projects.ParentID)
+	assert.Equal(t, work.ID, *projects.ParentID)
+	assert.Equal(t, work.Path+projects.ID.Hex()+"/", projects.Path)
+	assert.Equal(t, 1, projects.Depth)
+
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "Work"})
+	assert.ErrorIs(t, err, ErrNotebookExists)
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "Work", ParentID: work.ID.Hex()})
+	assert.NoError(t, err, "names are unique among siblings only")
+
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "<i></i>"})
+	assert.ErrorIs(t, err, notes.ErrBadRequest)
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "x", ParentID: bson.NewObjectID().Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound)
+	_, err = svc.Create(ctx, bson.NewObjectID(), CreateNotebookRequest{Name: "x", ParentID: work.ID.Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound, "another user's notebook is not a parent")
+
+	list, err := svc.List(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, list.Notebooks, 3)
+	assert.Equal(t, work.ID, list.Notebooks[0].ID, "parents come first")
+}
+
+func TestServiceCreateTooDeep(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	parentID := ""
+	for depth := 0; depth < MaxDepth; depth++ {
+		nb, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "level", ParentID: parentID})
+		require.NoError(t, err)
+		assert.Equal(t, depth, nb.Depth)
+		parentID = nb.ID.Hex()
+	}
+	_, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "level", ParentID: parentID})
+	assert.ErrorIs(t, err, ErrTooDeep)
+}
+
+func TestServiceUpdate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	repo := newMemRepo()
+	svc := NewService(repo, &fakeNotes{}, silentLogger)
+
+	a, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "A"})
+	require.NoError(t, err)
+	b, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "B", ParentID: a.ID.Hex()})
+	require.NoError(t, err)
+	c, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "C", ParentID: b.ID.Hex()})
+	require.NoError(t, err)
+	d, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "D"})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, userID, a.ID, UpdateNotebookRequest{ParentID: ptr(c.ID.Hex())})
+	assert.ErrorIs(t, err, ErrNotebookCycle)
+	_, err = svc.Update(ctx, userID, a.ID, UpdateNotebookRequest{ParentID: ptr(a.ID.Hex())})
+	assert.ErrorIs(t, err, ErrNotebookCycle)
+	_, err = svc.Update(ctx, userID, a.ID, UpdateNotebookRequest{ParentID: ptr("nope")})
+	assert.ErrorIs(t, err, notes.ErrBadRequest)
+
+	// Moving B under D carries C along
+	moved, err := svc.Update(ctx, userID, b.ID, UpdateNotebookRequest{ParentID: ptr(d.ID.Hex()), Name: ptr("Beta")})
+	require.NoError(t, err)
+	assert.Equal(t, "Beta", moved.Name)
+	assert.Equal(t, d.ID, *moved.ParentID)
+	assert.Equal(t, d.Path+b.ID.Hex()+"/", moved.Path)
+	child, err := svc.Get(ctx, userID, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, moved.Path+c.ID.Hex()+"/", child.Path)
+	assert.Equal(t, 2, child.Depth)
+
+	top, err := svc.Update(ctx, userID, b.ID, UpdateNotebookRequest{ParentID: ptr("")})
+	require.NoError(t, err)
+	assert.Nil(t, top.ParentID)
+	assert.Zero(t, top.Depth)
+	child, err = svc.Get(ctx, userID, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, child.Depth)
+
+	_, err = svc.Update(ctx, userID, b.ID, UpdateNotebookRequest{Name: ptr("D")})
+	assert.ErrorIs(t, err, ErrNotebookExists)
+	_, err = svc.Update(ctx, bson.NewObjectID(), b.ID, UpdateNotebookRequest{Name: ptr("x")})
+	assert.ErrorIs(t, err, ErrNotebookNotFound)
+}
+
+func TestServiceUpdateTooDeep(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	var chain []*Notebook
+	parentID := ""
+	for depth := 0; depth < MaxDepth-1; depth++ {
+		nb, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "level", ParentID: parentID})
+		require.NoError(t, err)
+		chain = append(chain, nb)
+		parentID = nb.ID.Hex()
+	}
+	root, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "pair"})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "leaf", ParentID: root.ID.Hex()})
+	require.NoError(t, err)
+
+	// The pair fits under the second-to-last level but not under the last
+	_, err = svc.Update(ctx, userID, root.ID, UpdateNotebookRequest{ParentID: ptr(chain[len(chain)-1].ID.Hex())})
+	assert.ErrorIs(t, err, ErrTooDeep)
+	_, err = svc.Update(ctx, userID, root.ID, UpdateNotebookRequest{ParentID: ptr(chain[len(chain)-2].ID.Hex())})
+	assert.NoError(t, err)
+}
+
+func TestServiceDelete(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	repo := newMemRepo()
+	fn := &fakeNotes{}
+	svc := NewService(repo, fn, silentLogger)
+
+	a, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "A"})
+	require.NoError(t, err)
+	b, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "B", ParentID: a.ID.Hex()})
+	require.NoError(t, err)
+	other, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "Other"})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, userID, a.ID, DeleteNotebookRequest{}))
+	assert.ElementsMatch(t, []bson.ObjectID{a.ID, b.ID}, fn.released)
+	assert.Empty(t, fn.deleted)
+	_, err = svc.Get(ctx, userID, b.ID)
+	assert.ErrorIs(t, err, ErrNotebookNotFound)
+
+	require.NoError(t, svc.Delete(ctx, userID, other.ID, DeleteNotebookRequest{Notes: DeleteNotes}))
+	assert.Equal(t, []bson.ObjectID{other.ID}, fn.deleted)
+
+	kept, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "Kept"})
+	require.NoError(t, err)
+	fn.err = errors.New("boom")
+	assert.ErrorIs(t, svc.Delete(ctx, userID, kept.ID, DeleteNotebookRequest{}), ErrDeleteNotebook)
+	_, err = svc.Get(ctx, userID, kept.ID)
+	assert.NoError(t, err, "the notebook stays when its notes could not be handled")
+}
+
+func TestServiceSubtree(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	a, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "A"})
+	require.NoError(t, err)
+	b, err := svc.Create(ctx, userID, CreateNotebookRequest{Name: "B", ParentID: a.ID.Hex()})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, userID, CreateNotebookRequest{Name: "Sibling"})
+	require.NoError(t, err)
+
+	ids, err := svc.Subtree(ctx, userID, a.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{a.ID}, ids)
+
+	ids, err = svc.Subtree(ctx, userID, a.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{a.ID, b.ID}, ids)
+
+	_, err = svc.Subtree(ctx, bson.NewObjectID(), a.ID, true)
+	assert.ErrorIs(t, err, notes.ErrNotebookNotFound)
+}
+
+func ptr[T any](v T) *T { return &v }
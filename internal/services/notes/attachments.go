@@ -0,0 +1,403 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"note-pulse/internal/utils/sanitize"
+	"note-pulse/internal/utils/thumbnail"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// MaxAttachmentsPerNote bounds the files of one note
+	MaxAttachmentsPerNote = 50
+	// attachmentGCInterval is how often RunAttachmentGC looks for orphans
+	attachmentGCInterval = 10 * time.Minute
+	// attachmentGCBatch bounds the orphans removed per sweep
+	attachmentGCBatch = 100
+	// maxAttachmentName bounds stored file names, in bytes
+	maxAttachmentName = 255
+)
+
+// attachmentTypes are the sniffed MIME types accepted for upload. HTML, SVG
+// and other active content are refused outright.
+var attachmentTypes = map[string]bool{
+	"image/png":                true,
+	"image/jpeg":               true,
+	"image/gif":                true,
+	"image/webp":               true,
+	"image/bmp":                true,
+	"application/pdf":          true,
+	"text/plain":               true,
+	"application/zip":          true,
+	"application/x-gzip":       true,
+	"application/octet-stream": true,
+}
+
+// Attachment is a file attached to a note. Its bytes, and those of its
+// thumbnail, live in the BlobStore.
+type Attachment struct {
+	ID     bson.ObjectID `bson:"_id" json:"id" example:"683cdb8aa96ad71e8e075bdb"`
+	NoteID bson.ObjectID `bson:"note_id" json:"note_id" example:"683cdb8aa96ad71e8e075bd1"`
+	// UserID is the uploader
+	UserID bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	Name   string        `bson:"name" json:"name" example:"screenshot.png"`
+	Size   int64         `bson:"size" json:"size" example:"48213"`
+	// MIME is sniffed from the content, never taken from the client
+	MIME   string `bson:"mime" json:"mime" example:"image/png"`
+	SHA256 string `bson:"sha256" json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	// Thumbnail is set for images the server made a PNG thumbnail of
+	Thumbnail bool      `bson:"thumbnail" json:"thumbnail" example:"true"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+}
+
+// AttachmentsResponse lists the attachments of a note, oldest first
+type AttachmentsResponse struct {
+	Attachments []*Attachment `json:"attachments"`
+}
+
+// UploadRequest is a file to attach. Content is read more than once.
+type UploadRequest struct {
+	Name    string
+	Size    int64
+	Content io.ReadSeeker
+}
+
+// BlobStore keeps attachment bytes under opaque keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns ErrBlobNotFound for a missing key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the keys; missing ones are not an error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// AttachmentStore persists attachment metadata
+type AttachmentStore interface {
+	Create(ctx context.Context, a *Attachment) error
+	// Find returns ErrAttachmentNotFound unless noteID has the attachment
+	Find(ctx context.Context, noteID, attachmentID bson.ObjectID) (*Attachment, error)
+	ListForNote(ctx context.Context, noteID bson.ObjectID) ([]*Attachment, error)
+	CountForNote(ctx context.Context, noteID bson.ObjectID) (int64, error)
+	Delete(ctx context.Context, attachmentID bson.ObjectID) error
+	// Orphans returns up to limit attachments whose note no longer exists
+	Orphans(ctx context.Context, limit int) ([]*Attachment, error)
+}
+
+// SetAttachments enables attachments of up to maxBytes each. Orphaned blobs
+// are collected by RunAttachmentGC, which must be started too.
+func (s *Service) SetAttachments(store AttachmentStore, blobs BlobStore, maxBytes int64) {
+	s.attachments = store
+	s.blobs = blobs
+	s.maxAttachmentBytes = maxBytes
+}
+
+// blobKeys are the keys of an attachment's content and thumbnail
+func blobKeys(a *Attachment) []string {
+	key := "attachments/" + a.ID.Hex()
+	if a.Thumbnail {
+		return []string{key, key + ".thumb"}
+	}
+	return []string{key}
+}
+
+// sniffType detects the MIME type of content from its first bytes
+func sniffType(content io.ReadSeeker) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// allowedType reports whether a sniffed MIME type may be uploaded
+func allowedType(sniffed string) bool {
+	base, _, err := mime.ParseMediaType(sniffed)
+	return err == nil && attachmentTypes[base]
+}
+
+// attachmentName keeps the base name of an uploaded file, without markup or
+// control characters
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(sanitize.Clean(name), `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	for len(name) > maxAttachmentName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// Upload attaches a file to a note. The MIME type is sniffed from the content
+// and images get a thumbnail.
+func (s *Service) Upload(ctx context.Context, userID, noteID bson.ObjectID, req UploadRequest) (*Attachment, error) {
+	if s.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	if req.Size <= 0 {
+		return nil, ErrBadRequest
+	}
+	if req.Size > s.maxAttachmentBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	if _, err := s.accessibleNote(ctx, userID, noteID, true); err != nil {
+		return nil, s.noteAccessError(err, ErrUploadAttachment, userID, noteID)
+	}
+	count, err := s.attachments.CountForNote(ctx, noteID)
+	if err != nil {
+		s.log.Error(ErrUploadAttachment.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrUploadAttachment
+	}
+	if count >= MaxAttachmentsPerNote {
+		return nil, ErrTooManyAttachments
+	}
+
+	sniffed, err := sniffType(req.Content)
+	if err != nil {
+		s.log.Error(ErrUploadAttachment.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrUploadAttachment
+	}
+	if !allowedType(sniffed) {
+		s.log.Info("refused attachment type", "mime", sniffed, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrUnsupportedMedia
+	}
+
+	growth := Usage{StorageBytes: req.Size}
+	if err := s.reserve(ctx, userID, growth); err != nil {
+		return nil, err
+	}
+
+	a := &Attachment{
+		ID:        bson.NewObjectID(),
+		NoteID:    noteID,
+		UserID:    userID,
+		Name:      attachmentName(req.Name),
+		Size:      req.Size,
+		MIME:      sniffed,
+		CreatedAt: time.Now(),
+	}
+	err = s.storeBlobs(ctx, a, req.Content)
+	if err == nil {
+		err = s.attachments.Create(ctx, a)
+	}
+	if err != nil {
+		s.release(ctx, userID, growth)
+		s.log.Error(ErrUploadAttachment.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		if err := s.blobs.Delete(ctx, blobKeys(a)...); err != nil {
+			s.log.Error("failed to delete blobs of a failed upload", "error", err, "attachment_id", a.ID.Hex())
+		}
+		return nil, ErrUploadAttachment
+	}
+	return a, nil
+}
+
+// storeBlobs writes the content, hashing it on the way, then the thumbnail.
+// A thumbnail that cannot be made is skipped.
+func (s *Service) storeBlobs(ctx context.Context, a *Attachment, content io.ReadSeeker) error {
+	keys := blobKeys(a)
+	h := sha256.New()
+	if err := s.blobs.Put(ctx, keys[0], io.TeeReader(io.LimitReader(content, a.Size), h), a.Size, a.MIME); err != nil {
+		return err
+	}
+	a.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if !strings.HasPrefix(a.MIME, "image/") {
+		return nil
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	thumb, err := thumbnail.PNG(content)
+	if err != nil {
+		s.log.Info("no thumbnail for image attachment", "error", err, "attachment_id", a.ID.Hex())
+		return nil
+	}
+
+	a.Thumbnail = true
+	if err := s.blobs.Put(ctx, blobKeys(a)[1], bytes.NewReader(thumb), int64(len(thumb)), "image/png"); err != nil {
+		a.Thumbnail = false
+		s.log.Error("failed to store thumbnail", "error", err, "attachment_id", a.ID.Hex())
+	}
+	return nil
+}
+
+// ListAttachments returns the attachments of a note userID can read
+func (s *Service) ListAttachments(ctx context.Context, userID, noteID bson.ObjectID) (*AttachmentsResponse, error) {
+	if s.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	if _, err := s.accessibleNote(ctx, userID, noteID, false); err != nil {
+		return nil, s.noteAccessError(err, ErrListNotes, userID, noteID)
+	}
+
+	list, err := s.attachments.ListForNote(ctx, noteID)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+	if list == nil {
+		list = []*Attachment{}
+	}
+	return &AttachmentsResponse{Attachments: list}, nil
+}
+
+// OpenAttachment returns an attachment with its content, or its thumbnail's.
+// The caller closes the reader.
+func (s *Service) OpenAttachment(ctx context.Context, userID, noteID, attachmentID bson.ObjectID, thumb bool) (*Attachment, io.ReadCloser, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrNoBlobStore
+	}
+	if _, err := s.accessibleNote(ctx, userID, noteID, false); err != nil {
+		return nil, nil, s.noteAccessError(err, ErrListNotes, userID, noteID)
+	}
+
+	a, err := s.findAttachment(ctx, userID, noteID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if thumb && !a.Thumbnail {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	key := blobKeys(a)[0]
+	if thumb {
+		key = blobKeys(a)[1]
+	}
+	rc, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			s.log.Error("attachment blob is missing", "attachment_id", a.ID.Hex(), "key", key)
+		} else {
+			s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "attachment_id", a.ID.Hex())
+		}
+		return nil, nil, ErrListNotes
+	}
+	return a, rc, nil
+}
+
+// DeleteAttachment removes an attachment and its blobs
+func (s *Service) DeleteAttachment(ctx context.Context, userID, noteID, attachmentID bson.ObjectID) error {
+	if s.blobs == nil {
+		return ErrNoBlobStore
+	}
+	if _, err := s.accessibleNote(ctx, userID, noteID, true); err != nil {
+		return s.noteAccessError(err, ErrDeleteAttachment, userID, noteID)
+	}
+
+	a, err := s.findAttachment(ctx, userID, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.removeAttachment(ctx, a); err != nil {
+		s.log.Error(ErrDeleteAttachment.Error(), "error", err, "user_id", userID.Hex(), "attachment_id", a.ID.Hex())
+		return ErrDeleteAttachment
+	}
+	return nil
+}
+
+func (s *Service) findAttachment(ctx context.Context, userID, noteID, attachmentID bson.ObjectID) (*Attachment, error) {
+	a, err := s.attachments.Find(ctx, noteID, attachmentID)
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+	return a, nil
+}
+
+// removeAttachment deletes the blobs before the metadata, so a failure leaves
+// a record the collector retries, and then frees the uploader's storage
+func (s *Service) removeAttachment(ctx context.Context, a *Attachment) error {
+	if err := s.blobs.Delete(ctx, blobKeys(a)...); err != nil {
+		return err
+	}
+	if err := s.attachments.Delete(ctx, a.ID); err != nil {
+		return err
+	}
+	s.release(ctx, a.UserID, Usage{StorageBytes: a.Size})
+	return nil
+}
+
+// removeAttachments drops the attachments of a deleted note. Whatever fails
+// is left to RunAttachmentGC.
+func (s *Service) removeAttachments(ctx context.Context, noteID bson.ObjectID) {
+	if s.blobs == nil {
+		return
+	}
+	list, err := s.attachments.ListForNote(ctx, noteID)
+	if err != nil {
+		s.log.Error("failed to list attachments of deleted note", "error", err, "note_id", noteID.Hex())
+		return
+	}
+	for _, a := range list {
+		if err := s.removeAttachment(ctx, a); err != nil {
+			s.log.Error("failed to delete attachment of deleted note", "error", err, "attachment_id", a.ID.Hex())
+		}
+	}
+}
+
+// RunAttachmentGC deletes the attachments of notes that no longer exist,
+// whether removed one by one or with a whole account, until ctx is done
+func (s *Service) RunAttachmentGC(ctx context.Context) error {
+	ticker := time.NewTicker(attachmentGCInterval)
+	defer ticker.Stop()
+
+	for {
+		s.collectAttachments(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) collectAttachments(ctx context.Context) {
+	orphans, err := s.attachments.Orphans(ctx, attachmentGCBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("failed to list orphaned attachments", "error", err)
+		}
+		return
+	}
+
+	removed := 0
+	for _, a := range orphans {
+		if err := s.removeAttachment(ctx, a); err != nil {
+			s.log.Error("failed to delete orphaned attachment, will retry", "error", err, "attachment_id", a.ID.Hex())
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		s.log.Info("collected orphaned attachments", "attachments", removed)
+	}
+}
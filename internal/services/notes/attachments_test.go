@@ -0,0 +1,288 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memBlobs is an in-memory BlobStore
+type memBlobs struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (m *memBlobs) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = b
+	return nil
+}
+
+func (m *memBlobs) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memBlobs) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.blobs, k)
+	}
+	return nil
+}
+
+func (m *memBlobs) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.blobs))
+	for k := range m.blobs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// memAttachments is an in-memory AttachmentStore; notes lists the notes that
+// still exist
+type memAttachments struct {
+	mu    sync.Mutex
+	list  []*Attachment
+	notes map[bson.ObjectID]bool
+}
+
+func (m *memAttachments) Create(_ context.Context, a *Attachment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.list = append(m.list, a)
+	return nil
+}
+
+func (m *memAttachments) Find(_ context.Context, noteID, attachmentID bson.ObjectID) (*Attachment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.list {
+		if a.ID == attachmentID && a.NoteID == noteID {
+			return a, nil
+		}
+	}
+	return nil, ErrAttachmentNotFound
+}
+
+func (m *memAttachments) ListForNote(_ context.Context, noteID bson.ObjectID) ([]*Attachment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Attachment
+	for _, a := range m.list {
+		if a.NoteID == noteID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memAttachments) CountForNote(ctx context.Context, noteID bson.ObjectID) (int64, error) {
+	list, err := m.ListForNote(ctx, noteID)
+	return int64(len(list)), err
+}
+
+func (m *memAttachments) Delete(_ context.Context, attachmentID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, a := range m.list {
+		if a.ID == attachmentID {
+			m.list = append(m.list[:i], m.list[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAttachmentNotFound
+}
+
+func (m *memAttachments) Orphans(_ context.Context, limit int) ([]*Attachment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Attachment
+	for _, a := range m.list {
+		if !m.notes[a.NoteID] && len(out) < limit {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func newAttachmentService(repo *MockNotesRepo, maxBytes int64) (*Service, *memAttachments, *memBlobs) {
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	store := &memAttachments{notes: map[bson.ObjectID]bool{}}
+	blobs := &memBlobs{blobs: map[string][]byte{}}
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetAttachments(store, blobs, maxBytes)
+	return svc, store, blobs
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func upload(name string, content []byte) UploadRequest {
+	return UploadRequest{Name: name, Size: int64(len(content)), Content: bytes.NewReader(content)}
+}
+
+func TestServiceUpload(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	note := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Trip"}
+
+	repo := new(MockNotesRepo)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	svc, _, blobs := newAttachmentService(repo, 1<<20)
+
+	img := pngBytes(t, 800, 400)
+	a, err := svc.Upload(ctx, userID, note.ID, upload(`C:\Users\me\map.png`, img))
+	require.NoError(t, err)
+	sum := sha256.Sum256(img)
+	assert.Equal(t, hex.EncodeToString(sum[:]), a.SHA256)
+	assert.Equal(t, "image/png", a.MIME)
+	assert.Equal(t, "map.png", a.Name, "client paths are dropped")
+	assert.True(t, a.Thumbnail)
+	assert.Len(t, blobs.keys(), 2)
+
+	_, rc, err := svc.OpenAttachment(ctx, userID, note.ID, a.ID, true)
+	require.NoError(t, err)
+	thumb, err := png.DecodeConfig(rc)
+	require.NoError(t, err)
+	assert.Equal(t, 256, thumb.Width)
+	assert.Equal(t, 128, thumb.Height)
+
+	// The type comes from the content, not the name
+	text, err := svc.Upload(ctx, userID, note.ID, upload("notes.png", []byte("just some text")))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", text.MIME)
+	assert.False(t, text.Thumbnail)
+	_, _, err = svc.OpenAttachment(ctx, userID, note.ID, text.ID, true)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	_, err = svc.Upload(ctx, userID, note.ID, upload("page.txt", []byte("<!DOCTYPE html><script>alert(1)</script>")))
+	assert.ErrorIs(t, err, ErrUnsupportedMedia)
+
+	_, err = svc.Upload(ctx, userID, note.ID, upload("big.bin", make([]byte, 2<<20)))
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+
+	_, err = svc.Upload(ctx, bson.NewObjectID(), note.ID, upload("map.png", img))
+	assert.ErrorIs(t, err, ErrNoteNotFound, "other users cannot attach files")
+
+	resp, err := svc.ListAttachments(ctx, userID, note.ID)
+	require.NoError(t, err)
+	assert.Len(t, resp.Attachments, 2)
+
+	_, rc, err = svc.OpenAttachment(ctx, userID, note.ID, text.ID, false)
+	require.NoError(t, err)
+	body, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "just some text", string(body))
+
+	require.NoError(t, svc.DeleteAttachment(ctx, userID, note.ID, a.ID))
+	assert.Len(t, blobs.keys(), 1)
+	assert.ErrorIs(t, svc.DeleteAttachment(ctx, userID, note.ID, a.ID), ErrAttachmentNotFound)
+}
+
+func TestServiceUploadLimit(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	note := &Note{ID: bson.NewObjectID(), UserID: userID}
+
+	repo := new(MockNotesRepo)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	svc, store, _ := newAttachmentService(repo, 1<<20)
+	for range MaxAttachmentsPerNote {
+		require.NoError(t, store.Create(ctx, &Attachment{ID: bson.NewObjectID(), NoteID: note.ID}))
+	}
+
+	_, err := svc.Upload(ctx, userID, note.ID, upload("one-more.txt", []byte("text")))
+	assert.ErrorIs(t, err, ErrTooManyAttachments)
+}
+
+func TestServiceDeleteRemovesAttachments(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	note := &Note{ID: bson.NewObjectID(), UserID: userID}
+
+	repo := new(MockNotesRepo)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	repo.On("Delete", mock.Anything, userID, note.ID).Return(nil)
+	svc, store, blobs := newAttachmentService(repo, 1<<20)
+
+	_, err := svc.Upload(ctx, userID, note.ID, upload("map.png", pngBytes(t, 10, 10)))
+	require.NoError(t, err)
+	require.NotEmpty(t, blobs.keys())
+
+	require.NoError(t, svc.Delete(ctx, userID, note.ID))
+	assert.Empty(t, blobs.keys())
+	assert.Empty(t, store.list)
+}
+
+func TestRunAttachmentGC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	userID := bson.NewObjectID()
+	kept := &Note{ID: bson.NewObjectID(), UserID: userID}
+	gone := &Note{ID: bson.NewObjectID(), UserID: userID}
+
+	repo := new(MockNotesRepo)
+	repo.On("FindByID", mock.Anything, kept.ID).Return(kept, nil)
+	repo.On("FindByID", mock.Anything, gone.ID).Return(gone, nil)
+	svc, store, blobs := newAttachmentService(repo, 1<<20)
+
+	a, err := svc.Upload(ctx, userID, kept.ID, upload("a.txt", []byte("kept")))
+	require.NoError(t, err)
+	_, err = svc.Upload(ctx, userID, gone.ID, upload("b.txt", []byte("gone")))
+	require.NoError(t, err)
+
+	// Only kept is still in the notes collection, e.g. after an account purge
+	store.notes[kept.ID] = true
+
+	done := make(chan error, 1)
+	go func() { done <- svc.RunAttachmentGC(ctx) }()
+	require.Eventually(t, func() bool { return len(blobs.keys()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"attachments/" + a.ID.Hex()}, blobs.keys())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestAttachmentName(t *testing.T) {
+	assert.Equal(t, "report.pdf", attachmentName("../../report.pdf"))
+	assert.Equal(t, "map.png", attachmentName("<b>map.png</b>"))
+	assert.Equal(t, "file", attachmentName(""))
+	assert.Equal(t, "ab.txt", attachmentName("a\x00b.txt"))
+	long := attachmentName(strings.Repeat("é", 200))
+	assert.LessOrEqual(t, len(long), maxAttachmentName)
+	assert.True(t, strings.HasSuffix(long, "é"), "names are cut on rune boundaries")
+}
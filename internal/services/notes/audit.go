@@ -0,0 +1,69 @@
+package notes
+
+import (
+	"context"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"note-pulse/internal/services/audit"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// AuditSink records note creates, updates and deletes; audit.Service
+// implements it. Record must not fail the write it records.
+type AuditSink interface {
+	Record(ctx context.Context, e *audit.Entry)
+}
+
+// SetAuditSink makes note creates, updates and deletes leave an audit entry
+func (s *Service) SetAuditSink(a AuditSink) {
+	s.audit = a
+}
+
+// record appends an entry of action by actorID on noteID
+func (s *Service) record(ctx context.Context, action string, actorID, noteID bson.ObjectID, before, after audit.Summary) {
+	s.audit.Record(ctx, &audit.Entry{
+		UserID:   &actorID,
+		ActorID:  &actorID,
+		Action:   action,
+		TargetID: &noteID,
+		Before:   before,
+		After:    after,
+	})
+}
+
+// auditSummary describes a note for the audit log: its metadata and the
+// length of its body, never the body itself
+func auditSummary(note *Note) audit.Summary {
+	if note == nil {
+		return nil
+	}
+	summary := audit.Summary{
+		"title":      note.Title,
+		"body_chars": strconv.Itoa(utf8.RuneCountInString(note.Body)),
+	}
+	if note.Type != "" {
+		summary["type"] = note.Type
+	}
+	if note.Format != "" {
+		summary["format"] = note.Format
+	}
+	if note.Color != "" {
+		summary["color"] = note.Color
+	}
+	if note.WorkspaceID != nil {
+		summary["workspace_id"] = note.WorkspaceID.Hex()
+	}
+	if note.NotebookID != nil {
+		summary["notebook_id"] = note.NotebookID.Hex()
+	}
+	if note.DueAt != nil {
+		summary["due_at"] = note.DueAt.Format(time.RFC3339)
+	}
+	if note.RemindAt != nil {
+		summary["remind_at"] = note.RemindAt.Format(time.RFC3339)
+	}
+	return summary
+}
@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"context"
+	"testing"
+
+	"note-pulse/internal/services/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// recordingSink keeps the audit entries it is given
+type recordingSink struct {
+	entries []*audit.Entry
+}
+
+func (s *recordingSink) Record(_ context.Context, e *audit.Entry) {
+	s.entries = append(s.entries, e)
+}
+
+func TestServiceAuditsNoteWrites(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	sink := &recordingSink{}
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetAuditSink(sink)
+
+	created, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: "Ship it", Color: testColor})
+	require.NoError(t, err)
+	note := *created.Note
+	renamed := note
+	renamed.Title = "Plan B"
+	repo.On("FindByID", mock.Anything, note.ID).Return(&note, nil)
+	repo.On("Update", mock.Anything, userID, note.ID, mock.Anything).Return(&renamed, nil)
+	repo.On("Delete", mock.Anything, userID, note.ID).Return(nil)
+
+	_, err = svc.Update(ctx, userID, note.ID, UpdateNoteRequest{Title: strPtr("Plan B")})
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, userID, note.ID))
+
+	require.Len(t, sink.entries, 3)
+	create, update, del := sink.entries[0], sink.entries[1], sink.entries[2]
+
+	assert.Equal(t, audit.ActionNoteCreate, create.Action)
+	assert.Equal(t, &userID, create.ActorID)
+	assert.Equal(t, &note.ID, create.TargetID)
+	assert.Equal(t, audit.Summary{"title": "Plan", "body_chars": "7", "color": testColor}, create.After)
+	assert.Nil(t, create.Before)
+
+	assert.Equal(t, audit.ActionNoteUpdate, update.Action)
+	assert.Equal(t, audit.Summary{"title": "Plan"}, update.Before, "only what changed")
+	assert.Equal(t, audit.Summary{"title": "Plan B"}, update.After)
+
+	assert.Equal(t, audit.ActionNoteDelete, del.Action)
+	assert.Equal(t, "Plan", del.Before["title"])
+	assert.Nil(t, del.After)
+}
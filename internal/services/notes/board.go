@@ -0,0 +1,144 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MaxArrangeNotes bounds the notes placed by one arrange request
+const MaxArrangeNotes = 200
+
+// PositionRequest places a note on a board
+type PositionRequest struct {
+	// BoardID is the board to place the note on; empty is the default board
+	BoardID string  `json:"board_id,omitempty" validate:"omitempty,max=64" example:"planning"`
+	X       float64 `json:"x" validate:"gte=-1000000,lte=1000000" example:"120"`
+	Y       float64 `json:"y" validate:"gte=-1000000,lte=1000000" example:"80"`
+	Width   float64 `json:"width" validate:"gt=0,lte=10000" example:"200"`
+	Height  float64 `json:"height" validate:"gt=0,lte=10000" example:"160"`
+	Z       int     `json:"z" validate:"gte=-1000000,lte=1000000" example:"3"`
+}
+
+// ArrangeItem places one note of an arrange request
+type ArrangeItem struct {
+	ID string `json:"id" validate:"required,mongodb" example:"683cdb8aa96ad71e8e075bd1"`
+	PositionRequest
+}
+
+// ArrangeRequest places many notes of one workspace at once
+type ArrangeRequest struct {
+	// WorkspaceID selects a shared workspace; empty is the personal one
+	WorkspaceID string        `json:"workspace_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd5"`
+	Notes       []ArrangeItem `json:"notes" validate:"required,min=1,max=200,dive"`
+}
+
+// ArrangeResponse lists the notes an arrange request placed. Notes that are
+// not in the workspace are left out.
+type ArrangeResponse struct {
+	Notes []*Note `json:"notes"`
+}
+
+// Placement is a note's board and position as stored
+type Placement struct {
+	NoteID   bson.ObjectID
+	BoardID  string
+	Position Position
+}
+
+// Rect is an axis-aligned rectangle on a board
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+// newPosition stores req as a position with its far edges
+func newPosition(req PositionRequest) Position {
+	return Position{
+		X:      req.X,
+		Y:      req.Y,
+		Width:  req.Width,
+		Height: req.Height,
+		Z:      req.Z,
+		Right:  req.X + req.Width,
+		Bottom: req.Y + req.Height,
+	}
+}
+
+// ParseViewport parses the "x,y,width,height" of a viewport query
+func ParseViewport(s string) (Rect, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Rect{}, ErrBadRequest
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return Rect{}, ErrBadRequest
+		}
+		v[i] = f
+	}
+	r := Rect{X: v[0], Y: v[1], Width: v[2], Height: v[3]}
+	if r.Width <= 0 || r.Height <= 0 {
+		return Rect{}, ErrBadRequest
+	}
+	return r, nil
+}
+
+// Move places a note on a board. Placing is not an edit, so updated_at and
+// the search index stay as they are. Connected clients get a "moved" event.
+func (s *Service) Move(ctx context.Context, userID, noteID bson.ObjectID, req PositionRequest) (*NoteResponse, error) {
+	if _, err := s.accessibleNote(ctx, userID, noteID, true); err != nil {
+		return nil, s.noteAccessError(err, ErrUpdateNote, userID, noteID)
+	}
+
+	note, err := s.repo.SetPosition(ctx, Placement{NoteID: noteID, BoardID: req.BoardID, Position: newPosition(req)})
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrUpdateNote
+	}
+
+	prepareChecklists(note)
+	s.bus.Broadcast(ctx, NoteEvent{Type: EventMoved, Note: note})
+	return &NoteResponse{Note: note}, nil
+}
+
+// Arrange places many notes of one workspace in a single write
+func (s *Service) Arrange(ctx context.Context, userID bson.ObjectID, req ArrangeRequest) (*ArrangeResponse, error) {
+	workspaceID, err := s.resolveWorkspace(ctx, userID, req.WorkspaceID, true)
+	if err != nil {
+		return nil, workspaceError(err, ErrUpdateNote)
+	}
+	if len(req.Notes) > MaxArrangeNotes {
+		return nil, ErrBadRequest
+	}
+
+	placements := make([]Placement, 0, len(req.Notes))
+	seen := make(map[bson.ObjectID]bool, len(req.Notes))
+	for _, item := range req.Notes {
+		noteID, err := bson.ObjectIDFromHex(item.ID)
+		if err != nil || seen[noteID] {
+			return nil, ErrBadRequest
+		}
+		seen[noteID] = true
+		placements = append(placements, Placement{NoteID: noteID, BoardID: item.BoardID, Position: newPosition(item.PositionRequest)})
+	}
+
+	placed, err := s.repo.Arrange(ctx, userID, workspaceID, placements)
+	if err != nil {
+		s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrUpdateNote
+	}
+
+	prepareChecklists(placed...)
+	for _, note := range placed {
+		s.bus.Broadcast(ctx, NoteEvent{Type: EventMoved, Note: note})
+	}
+	return &ArrangeResponse{Notes: placed}, nil
+}
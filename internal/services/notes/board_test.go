@@ -0,0 +1,132 @@
+package notes
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseViewport(t *testing.T) {
+	r, err := ParseViewport("-100, 50.5,1920,1080")
+	require.NoError(t, err)
+	assert.Equal(t, Rect{X: -100, Y: 50.5, Width: 1920, Height: 1080}, r)
+
+	for _, s := range []string{"", "1,2,3", "1,2,3,4,5", "a,0,10,10", "0,0,0,10", "0,0,10,-1"} {
+		_, err := ParseViewport(s)
+		assert.ErrorIs(t, err, ErrBadRequest, s)
+	}
+}
+
+func TestServiceMove(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	note := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Sticky"}
+	req := PositionRequest{BoardID: "planning", X: 10, Y: 20, Width: 200, Height: 100, Z: 2}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	placed := *note
+	placed.BoardID = "planning"
+	placed.Position = &Position{X: 10, Y: 20, Width: 200, Height: 100, Z: 2, Right: 210, Bottom: 120}
+	repo.On("SetPosition", mock.Anything, Placement{NoteID: note.ID, BoardID: "planning", Position: *placed.Position}).Return(&placed, nil)
+	bus.On("Broadcast", mock.Anything, NoteEvent{Type: EventMoved, Note: &placed})
+
+	svc := NewService(repo, bus, silentLogger)
+	resp, err := svc.Move(ctx, userID, note.ID, req)
+	require.NoError(t, err)
+	assert.Equal(t, &placed, resp.Note)
+	repo.AssertExpectations(t)
+	bus.AssertExpectations(t)
+
+	_, err = svc.Move(ctx, bson.NewObjectID(), note.ID, req)
+	assert.ErrorIs(t, err, ErrNoteNotFound, "another user's note")
+}
+
+func TestServiceArrange(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	a, b := bson.NewObjectID(), bson.NewObjectID()
+	pos := PositionRequest{X: 0, Y: 0, Width: 100, Height: 100}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	placedA := &Note{ID: a, UserID: userID, Position: &Position{Width: 100, Height: 100, Right: 100, Bottom: 100}}
+	repo.On("Arrange", mock.Anything, userID, (*bson.ObjectID)(nil), mock.MatchedBy(func(ps []Placement) bool {
+		return len(ps) == 2 && ps[0].NoteID == a && ps[1].NoteID == b && ps[1].Position.Right == 100
+	})).Return([]*Note{placedA}, nil)
+	bus.On("Broadcast", mock.Anything, NoteEvent{Type: EventMoved, Note: placedA})
+
+	svc := NewService(repo, bus, silentLogger)
+	resp, err := svc.Arrange(ctx, userID, ArrangeRequest{Notes: []ArrangeItem{
+		{ID: a.Hex(), PositionRequest: pos},
+		{ID: b.Hex(), PositionRequest: pos},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []*Note{placedA}, resp.Notes, "notes outside the workspace are left out")
+	bus.AssertNumberOfCalls(t, "Broadcast", 1)
+
+	_, err = svc.Arrange(ctx, userID, ArrangeRequest{Notes: []ArrangeItem{
+		{ID: a.Hex(), PositionRequest: pos},
+		{ID: a.Hex(), PositionRequest: pos},
+	}})
+	assert.ErrorIs(t, err, ErrBadRequest, "a note placed twice")
+	repo.AssertNumberOfCalls(t, "Arrange", 1)
+}
+
+func TestHubCoalescesMoves(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub(4)
+	userID := bson.NewObjectID()
+	sub, unsubscribe := hub.Subscribe(ctx, ulid.MustNew(ulid.Timestamp(time.Now().UTC()), rand.Reader), userID)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		_ = hub.Run(ctx)
+		close(done)
+	}()
+	require.Eventually(t, hub.running.Load, time.Second, time.Millisecond)
+
+	dragged := bson.NewObjectID()
+	deleted := bson.NewObjectID()
+	for i := range 50 {
+		hub.Broadcast(ctx, NoteEvent{Type: EventMoved, Note: &Note{ID: dragged, UserID: userID, Position: &Position{X: float64(i)}}})
+	}
+	hub.Broadcast(ctx, NoteEvent{Type: EventMoved, Note: &Note{ID: deleted, UserID: userID}})
+	hub.Broadcast(ctx, NoteEvent{Type: "deleted", Note: &Note{ID: deleted, UserID: userID}})
+
+	ev := <-sub.Ch
+	assert.Equal(t, "deleted", ev.Type, "other events are not held back")
+
+	select {
+	case ev = <-sub.Ch:
+	case <-time.After(time.Second):
+		t.Fatal("no moved event")
+	}
+	assert.Equal(t, EventMoved, ev.Type)
+	assert.Equal(t, dragged, ev.Note.ID)
+	assert.Equal(t, float64(49), ev.Note.Position.X, "the latest position wins")
+
+	select {
+	case ev = <-sub.Ch:
+		t.Fatalf("unexpected %s event for %s", ev.Type, ev.Note.ID.Hex())
+	case <-time.After(3 * moveCoalesceWindow):
+	}
+	_, dropped := hub.Stats()
+	assert.Zero(t, dropped)
+
+	cancel()
+	<-done
+	hub.Broadcast(context.Background(), NoteEvent{Type: EventMoved, Note: &Note{ID: dragged, UserID: userID}})
+	assert.Len(t, sub.Ch, 1, "without Run moves are delivered at once")
+}
@@ -0,0 +1,254 @@
+package notes
+
+import (
+	"context"
+	"errors"
+
+	"note-pulse/internal/utils/sanitize"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TypeChecklist marks notes made of items instead of a free-text body
+const TypeChecklist = "checklist"
+
+// MaxChecklistItems bounds the items of one checklist
+const MaxChecklistItems = 500
+
+// NewItemRequest is an item of a checklist being created
+type NewItemRequest struct {
+	Text    string `json:"text" validate:"required,max=1000" example:"Book flights"`
+	Checked bool   `json:"checked" example:"false"`
+}
+
+// AddItemRequest represents a request to add a checklist item
+type AddItemRequest struct {
+	Text    string `json:"text" validate:"required,max=1000" example:"Book flights"`
+	Checked bool   `json:"checked" example:"false"`
+	// Position inserts the item at this index; by default it goes last
+	Position *int `json:"position,omitempty" validate:"omitempty,min=0" example:"2"`
+}
+
+// UpdateItemRequest represents a checklist item update request
+type UpdateItemRequest struct {
+	Text    *string `json:"text,omitempty" validate:"omitempty,min=1,max=1000" example:"Book flights and hotel"`
+	Checked *bool   `json:"checked,omitempty" example:"true"`
+}
+
+// ReorderItemsRequest lists every item of a checklist in its new order
+type ReorderItemsRequest struct {
+	ItemIDs []string `json:"item_ids" validate:"required,min=1,max=500,dive,mongodb" example:"683cdb8aa96ad71e8e075bd9,683cdb8aa96ad71e8e075bda"`
+}
+
+// ItemResponse is a changed checklist item with its note
+type ItemResponse struct {
+	Note *Note          `json:"note"`
+	Item *ChecklistItem `json:"item"`
+}
+
+// storedType maps a requested note type to the one persisted
+func storedType(noteType string) string {
+	if noteType == TypeChecklist {
+		return TypeChecklist
+	}
+	return ""
+}
+
+// newItems sanitizes the items of a checklist being created
+func newItems(reqs []NewItemRequest) ([]ChecklistItem, error) {
+	if len(reqs) > MaxChecklistItems {
+		return nil, ErrChecklistFull
+	}
+	items := make([]ChecklistItem, 0, len(reqs))
+	for _, r := range reqs {
+		text := sanitize.Clean(r.Text)
+		if text == "" {
+			return nil, ErrBadRequest
+		}
+		items = append(items, ChecklistItem{ID: bson.NewObjectID(), Text: text, Checked: r.Checked})
+	}
+	return items, nil
+}
+
+// prepareChecklists fills the positions and progress of checklist notes
+func prepareChecklists(notes ...*Note) {
+	for _, note := range notes {
+		if note == nil || note.Type != TypeChecklist {
+			continue
+		}
+		progress := &ChecklistProgress{Total: len(note.Items)}
+		for i := range note.Items {
+			note.Items[i].Position = i
+			if note.Items[i].Checked {
+				progress.Done++
+			}
+		}
+		note.Progress = progress
+	}
+}
+
+// findItem returns the item itemID of note, or nil
+func findItem(note *Note, itemID bson.ObjectID) *ChecklistItem {
+	for i := range note.Items {
+		if note.Items[i].ID == itemID {
+			return &note.Items[i]
+		}
+	}
+	return nil
+}
+
+// checklistNote loads a checklist userID may change
+func (s *Service) checklistNote(ctx context.Context, userID, noteID bson.ObjectID) (*Note, error) {
+	note, err := s.accessibleNote(ctx, userID, noteID, true)
+	if err != nil {
+		return nil, s.noteAccessError(err, ErrUpdateNote, userID, noteID)
+	}
+	if note.Type != TypeChecklist {
+		return nil, ErrNotChecklist
+	}
+	return note, nil
+}
+
+// itemError logs and maps a failed item operation
+func (s *Service) itemError(err error, userID, noteID bson.ObjectID) error {
+	for _, known := range []error{ErrNoteNotFound, ErrItemNotFound, ErrChecklistFull, ErrItemOrder} {
+		if errors.Is(err, known) {
+			s.log.Info("checklist item operation rejected", "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+			return known
+		}
+	}
+	s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+	return ErrUpdateNote
+}
+
+// changeItem runs write, an item operation returning the updated note, and
+// publishes an item event for the note with the item pick finds in it
+func (s *Service) changeItem(ctx context.Context, eventType string, write func(ctx context.Context) (*Note, error), pick func(*Note) *ChecklistItem) (*Note, *ChecklistItem, error) {
+	events, err := s.commit(ctx, func(ctx context.Context) ([]NoteEvent, error) {
+		note, err := write(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []NoteEvent{{Type: eventType, Note: note, Item: pick(note)}}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	prepareChecklists(events[0].Note)
+	s.publish(ctx, events...)
+	return events[0].Note, events[0].Item, nil
+}
+
+// AddItem adds an item to a checklist. Items are changed one at a time in the
+// database, so devices editing different items never overwrite each other.
+func (s *Service) AddItem(ctx context.Context, userID, noteID bson.ObjectID, req AddItemRequest) (*ItemResponse, error) {
+	text := sanitize.Clean(req.Text)
+	if text == "" {
+		return nil, ErrBadRequest
+	}
+	checklist, err := s.checklistNote(ctx, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	growth := Usage{StorageBytes: int64(len(text))}
+	if err := s.reserve(ctx, checklist.UserID, growth); err != nil {
+		return nil, err
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+	item := ChecklistItem{ID: bson.NewObjectID(), Text: text, Checked: req.Checked}
+	note, added, err := s.changeItem(ctx, EventItemAdded, func(ctx context.Context) (*Note, error) {
+		return s.repo.AddItem(ctx, noteID, item, position)
+	}, func(note *Note) *ChecklistItem { return findItem(note, item.ID) })
+	if err != nil {
+		s.release(ctx, checklist.UserID, growth)
+		return nil, s.itemError(err, userID, noteID)
+	}
+	return &ItemResponse{Note: note, Item: added}, nil
+}
+
+// UpdateItem changes the text or checked state of a checklist item
+func (s *Service) UpdateItem(ctx context.Context, userID, noteID, itemID bson.ObjectID, req UpdateItemRequest) (*ItemResponse, error) {
+	patch := UpdateItem(req)
+	if patch.Text == nil && patch.Checked == nil {
+		return nil, ErrBadRequest
+	}
+	if patch.Text != nil {
+		text := sanitize.Clean(*patch.Text)
+		if text == "" {
+			return nil, ErrBadRequest
+		}
+		patch.Text = &text
+	}
+	checklist, err := s.checklistNote(ctx, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	var growth Usage
+	if old := findItem(checklist, itemID); old != nil && patch.Text != nil {
+		growth.StorageBytes = int64(len(*patch.Text) - len(old.Text))
+	}
+	if err := s.reserve(ctx, checklist.UserID, growth); err != nil {
+		return nil, err
+	}
+
+	note, updated, err := s.changeItem(ctx, EventItemUpdated, func(ctx context.Context) (*Note, error) {
+		return s.repo.UpdateItem(ctx, noteID, itemID, patch)
+	}, func(note *Note) *ChecklistItem { return findItem(note, itemID) })
+	if err != nil {
+		s.release(ctx, checklist.UserID, growth)
+		return nil, s.itemError(err, userID, noteID)
+	}
+	return &ItemResponse{Note: note, Item: updated}, nil
+}
+
+// DeleteItem removes an item from a checklist
+func (s *Service) DeleteItem(ctx context.Context, userID, noteID, itemID bson.ObjectID) error {
+	checklist, err := s.checklistNote(ctx, userID, noteID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.changeItem(ctx, EventItemDeleted, func(ctx context.Context) (*Note, error) {
+		return s.repo.DeleteItem(ctx, noteID, itemID)
+	}, func(*Note) *ChecklistItem { return &ChecklistItem{ID: itemID} })
+	if err != nil {
+		return s.itemError(err, userID, noteID)
+	}
+	if old := findItem(checklist, itemID); old != nil {
+		s.release(ctx, checklist.UserID, Usage{StorageBytes: int64(len(old.Text))})
+	}
+	return nil
+}
+
+// ReorderItems puts the items of a checklist in the given order, which must
+// name every item exactly once
+func (s *Service) ReorderItems(ctx context.Context, userID, noteID bson.ObjectID, req ReorderItemsRequest) (*NoteResponse, error) {
+	ids := make([]bson.ObjectID, len(req.ItemIDs))
+	seen := make(map[bson.ObjectID]struct{}, len(req.ItemIDs))
+	for i, hex := range req.ItemIDs {
+		id, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		if _, dup := seen[id]; dup {
+			return nil, ErrItemOrder
+		}
+		seen[id] = struct{}{}
+		ids[i] = id
+	}
+	if _, err := s.checklistNote(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	note, _, err := s.changeItem(ctx, EventItemsReordered, func(ctx context.Context) (*Note, error) {
+		return s.repo.ReorderItems(ctx, noteID, ids)
+	}, func(*Note) *ChecklistItem { return nil })
+	if err != nil {
+		return nil, s.itemError(err, userID, noteID)
+	}
+	return &NoteResponse{Note: note}, nil
+}
@@ -0,0 +1,128 @@
+package notes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestServiceCreateChecklist(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	svc := NewService(repo, bus, silentLogger)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := svc.Create(ctx, userID, CreateNoteRequest{
+		Title: "Trip",
+		Type:  TypeChecklist,
+		Items: []NewItemRequest{{Text: "<b>Flights</b>", Checked: true}, {Text: "Hotel"}},
+	})
+	require.NoError(t, err)
+	note := resp.Note
+	assert.Equal(t, TypeChecklist, note.Type)
+	require.Len(t, note.Items, 2)
+	assert.Equal(t, "Flights", note.Items[0].Text)
+	assert.Equal(t, 1, note.Items[1].Position)
+	assert.Equal(t, &ChecklistProgress{Done: 1, Total: 2}, note.Progress)
+
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Note", Items: []NewItemRequest{{Text: "x"}}})
+	assert.ErrorIs(t, err, ErrBadRequest, "only checklists have items")
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Trip", Type: TypeChecklist, Items: []NewItemRequest{{Text: "<i></i>"}}})
+	assert.ErrorIs(t, err, ErrBadRequest, "items need text")
+}
+
+func TestServiceChecklistItems(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	noteID := bson.NewObjectID()
+	first := ChecklistItem{ID: bson.NewObjectID(), Text: "Flights", Checked: true}
+	second := ChecklistItem{ID: bson.NewObjectID(), Text: "Hotel"}
+	checklist := func(items ...ChecklistItem) *Note {
+		return &Note{ID: noteID, UserID: userID, Type: TypeChecklist, Items: items}
+	}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	var events []NoteEvent
+	bus.On("Broadcast", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(1).(NoteEvent))
+	})
+	svc := NewService(repo, bus, silentLogger)
+	repo.On("FindByID", mock.Anything, noteID).Return(checklist(first), nil)
+
+	// Add; the repository stores the item the service built
+	afterAdd := checklist(second, first)
+	repo.On("AddItem", mock.Anything, noteID, mock.AnythingOfType("ChecklistItem"), 0).Run(func(args mock.Arguments) {
+		afterAdd.Items[0] = args.Get(2).(ChecklistItem)
+		second = afterAdd.Items[0]
+	}).Return(afterAdd, nil)
+	position := 0
+	added, err := svc.AddItem(ctx, userID, noteID, AddItemRequest{Text: "Hotel", Position: &position})
+	require.NoError(t, err)
+	assert.Equal(t, "Hotel", added.Item.Text)
+	assert.Equal(t, 0, added.Item.Position)
+	assert.Equal(t, &ChecklistProgress{Done: 1, Total: 2}, added.Note.Progress)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventItemAdded, events[0].Type)
+	assert.Same(t, added.Item, events[0].Item)
+
+	// Update
+	checked := true
+	done := second
+	done.Checked = true
+	repo.On("UpdateItem", mock.Anything, noteID, second.ID, UpdateItem{Checked: &checked}).Return(checklist(done, first), nil)
+	updated, err := svc.UpdateItem(ctx, userID, noteID, second.ID, UpdateItemRequest{Checked: &checked})
+	require.NoError(t, err)
+	assert.True(t, updated.Item.Checked)
+	assert.Equal(t, 2, updated.Note.Progress.Done)
+	assert.Equal(t, EventItemUpdated, events[1].Type)
+
+	_, err = svc.UpdateItem(ctx, userID, noteID, second.ID, UpdateItemRequest{})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	missing := bson.NewObjectID()
+	repo.On("UpdateItem", mock.Anything, noteID, missing, mock.Anything).Return(nil, ErrItemNotFound)
+	_, err = svc.UpdateItem(ctx, userID, noteID, missing, UpdateItemRequest{Checked: &checked})
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	// Reorder
+	order := ReorderItemsRequest{ItemIDs: []string{first.ID.Hex(), second.ID.Hex()}}
+	repo.On("ReorderItems", mock.Anything, noteID, []bson.ObjectID{first.ID, second.ID}).Return(checklist(first, done), nil)
+	reordered, err := svc.ReorderItems(ctx, userID, noteID, order)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reordered.Note.Items[1].Position)
+	assert.Equal(t, EventItemsReordered, events[2].Type)
+	assert.Nil(t, events[2].Item)
+
+	_, err = svc.ReorderItems(ctx, userID, noteID, ReorderItemsRequest{ItemIDs: []string{first.ID.Hex(), first.ID.Hex()}})
+	assert.ErrorIs(t, err, ErrItemOrder)
+
+	// Delete
+	repo.On("DeleteItem", mock.Anything, noteID, second.ID).Return(checklist(first), nil)
+	require.NoError(t, svc.DeleteItem(ctx, userID, noteID, second.ID))
+	assert.Equal(t, EventItemDeleted, events[3].Type)
+	assert.Equal(t, second.ID, events[3].Item.ID)
+	assert.Equal(t, &ChecklistProgress{Done: 1, Total: 1}, events[3].Note.Progress)
+}
+
+func TestServiceItemsNeedChecklist(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	noteID := bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	svc := NewService(repo, new(MockBus), silentLogger)
+	repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: userID}, nil)
+
+	_, err := svc.AddItem(ctx, userID, noteID, AddItemRequest{Text: "x"})
+	assert.ErrorIs(t, err, ErrNotChecklist)
+	_, err = svc.AddItem(ctx, bson.NewObjectID(), noteID, AddItemRequest{Text: "x"})
+	assert.ErrorIs(t, err, ErrNoteNotFound, "other users' notes stay hidden")
+}
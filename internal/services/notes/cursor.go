@@ -3,6 +3,7 @@ package notes
 import (
 	"encoding/base64"
 	"encoding/json"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
@@ -34,3 +35,77 @@ func DecodeCompositeCursor(encoded string) (*CompositeCursor, error) {
 
 	return &cursor, nil
 }
+
+// ScoreCursor represents a cursor for relevance-sorted pagination
+type ScoreCursor struct {
+	Score float64       `json:"score"`
+	ID    bson.ObjectID `json:"id"`
+}
+
+// EncodeScoreCursor encodes a relevance cursor to a URL-safe base64 string
+func EncodeScoreCursor(score float64, id bson.ObjectID) string {
+	cursor := ScoreCursor{Score: score, ID: id}
+	b, _ := json.Marshal(&cursor)
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+// DecodeScoreCursor decodes a URL-safe base64 string to a relevance cursor
+func DecodeScoreCursor(encoded string) (*ScoreCursor, error) {
+	decoded, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor ScoreCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// DueCursor represents a cursor for due date pagination
+type DueCursor struct {
+	DueAt time.Time     `json:"due_at"`
+	ID    bson.ObjectID `json:"id"`
+}
+
+// EncodeDueCursor encodes a due date cursor to a URL-safe base64 string
+func EncodeDueCursor(dueAt time.Time, id bson.ObjectID) string {
+	cursor := DueCursor{DueAt: dueAt, ID: id}
+	b, _ := json.Marshal(&cursor)
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+// DecodeDueCursor decodes a URL-safe base64 string to a due date cursor
+func DecodeDueCursor(encoded string) (*DueCursor, error) {
+	decoded, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor DueCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// noteCursor returns the cursor pointing at note for the given sort
+func noteCursor(note *Note, sort string) string {
+	switch sort {
+	case SortTitle:
+		return EncodeCompositeCursor(note.Title, note.ID)
+	case SortRelevance:
+		return EncodeScoreCursor(note.Score, note.ID)
+	case SortDueAt:
+		var dueAt time.Time
+		if note.DueAt != nil {
+			dueAt = *note.DueAt
+		}
+		return EncodeDueCursor(dueAt, note.ID)
+	default:
+		return note.ID.Hex()
+	}
+}
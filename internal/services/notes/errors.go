@@ -11,6 +11,9 @@ var ErrUpdateNote = errors.New("failed to update note")
 // ErrDeleteNote is returned when note deletion fails.
 var ErrDeleteNote = errors.New("failed to delete note")
 
+// ErrPurgeNotes is returned when the notes of a deleted account cannot be removed.
+var ErrPurgeNotes = errors.New("failed to purge notes")
+
 // ErrCreateNotesRepo is returned when notes repository creation fails.
 var ErrCreateNotesRepo = errors.New("failed to create notes repository")
 
@@ -28,3 +31,75 @@ var ErrInvalidLimit = errors.New("invalid limit")
 
 // ErrOffsetBeyondTotal is returned when offset is beyond total count.
 var ErrOffsetBeyondTotal = errors.New("offset beyond total count")
+
+// ErrWorkspaceNotFound is returned when the workspace does not exist or the user is not a member.
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// ErrWorkspaceReadOnly is returned when a workspace viewer tries to change notes.
+var ErrWorkspaceReadOnly = errors.New("workspace is read-only for this user")
+
+// ErrNoSearchIndex is returned by Reindex when notes are searched through Mongo's text index.
+var ErrNoSearchIndex = errors.New("no search index configured")
+
+// ErrReindex is returned when the search index cannot be rebuilt.
+var ErrReindex = errors.New("failed to rebuild search index")
+
+// ErrNoEmbedder is returned for similarity requests when no embedder is configured.
+var ErrNoEmbedder = errors.New("note similarity is not available")
+
+// ErrNotChecklist is returned for item operations on a note that is not a checklist.
+var ErrNotChecklist = errors.New("note is not a checklist")
+
+// ErrItemNotFound is returned when a checklist has no such item.
+var ErrItemNotFound = errors.New("checklist item not found")
+
+// ErrChecklistFull is returned when a checklist already has the maximum number of items.
+var ErrChecklistFull = errors.New("checklist has too many items")
+
+// ErrItemOrder is returned when a reorder does not list every item of the checklist exactly once.
+var ErrItemOrder = errors.New("item_ids must list every item of the checklist exactly once")
+
+// ErrNoBlobStore is returned for attachment requests when no blob store is configured.
+var ErrNoBlobStore = errors.New("attachments are not available")
+
+// ErrBlobNotFound is returned by a BlobStore for a missing key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// ErrAttachmentNotFound is returned when a note has no such attachment.
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// ErrAttachmentTooLarge is returned when an upload exceeds the configured size limit.
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// ErrUnsupportedMedia is returned when the sniffed type of an upload is not allowed.
+var ErrUnsupportedMedia = errors.New("unsupported attachment type")
+
+// ErrTooManyAttachments is returned when a note already has the maximum number of attachments.
+var ErrTooManyAttachments = errors.New("note has too many attachments")
+
+// ErrUploadAttachment is returned when an attachment cannot be stored.
+var ErrUploadAttachment = errors.New("failed to upload attachment")
+
+// ErrDeleteAttachment is returned when an attachment cannot be removed.
+var ErrDeleteAttachment = errors.New("failed to delete attachment")
+
+// ErrNotebookNotFound is returned when a notebook does not exist or belongs to someone else.
+var ErrNotebookNotFound = errors.New("notebook not found")
+
+// ErrPersonalOnly is returned when a notebook is used with a note or listing of a shared workspace.
+var ErrPersonalOnly = errors.New("notebooks hold personal notes only")
+
+// ErrNoLinkIndex is returned for backlinks and graph requests when no link index is configured.
+var ErrNoLinkIndex = errors.New("note links are not available")
+
+// ErrReindexLinks is returned when the link index cannot be rebuilt.
+var ErrReindexLinks = errors.New("failed to rebuild link index")
+
+// ErrNoteTooLarge is returned when a title or body exceeds the configured length limits.
+var ErrNoteTooLarge = errors.New("note is too large")
+
+// ErrQuotaExceeded is returned when a write would take a user past their note count or storage limit.
+var ErrQuotaExceeded = errors.New("note quota exceeded")
+
+// ErrGetUsage is returned when a user's usage cannot be read.
+var ErrGetUsage = errors.New("failed to get usage")
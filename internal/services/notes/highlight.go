@@ -0,0 +1,243 @@
+package notes
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	// snippetLen is the body snippet length in runes
+	snippetLen = 160
+	// snippetLead is how much context precedes the first match in a snippet
+	snippetLead = 40
+	ellipsis    = '…'
+)
+
+// searchTerm is one thing a query looks for, lower-cased
+type searchTerm struct {
+	text []rune
+	// word terms match every word starting with text, approximating the
+	// stemming of the full-text index; other terms match as substrings
+	word bool
+}
+
+// searchTerms splits q the way the repository searches for it. Short queries
+// are one literal substring (the regex fallback); longer ones follow $text
+// syntax: "quoted phrases", plain words, and -negated words which are left out.
+func searchTerms(q string) []searchTerm {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil
+	}
+	if len(q) < MinTextSearchLen {
+		return []searchTerm{{text: []rune(strings.ToLower(q))}}
+	}
+
+	var terms []searchTerm
+	rest := q
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		if rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				terms = append(terms, searchTerm{text: []rune(strings.ToLower(phrase))})
+			}
+			rest = after
+			continue
+		}
+
+		word := rest
+		rest = ""
+		if i := strings.IndexFunc(word, unicode.IsSpace); i >= 0 {
+			word, rest = word[:i], word[i:]
+		}
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		word = strings.TrimFunc(word, func(r rune) bool { return !isWordRune(r) })
+		if word != "" {
+			terms = append(terms, searchTerm{text: []rune(strings.ToLower(word)), word: true})
+		}
+	}
+	return terms
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// runeRange is a half-open range of rune offsets
+type runeRange struct{ start, end int }
+
+// findMatches returns the merged, ordered ranges of text matched by terms
+func findMatches(text []rune, terms []searchTerm) []runeRange {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var ranges []runeRange
+	for _, term := range terms {
+		n := len(term.text)
+		if n == 0 {
+			continue
+		}
+		for i := 0; i+n <= len(lower); i++ {
+			if term.word && i > 0 && isWordRune(lower[i-1]) {
+				continue
+			}
+			if !slices.Equal(lower[i:i+n], term.text) {
+				continue
+			}
+
+			end := i + n
+			if term.word {
+				for end < len(lower) && isWordRune(lower[end]) {
+					end++
+				}
+			}
+			ranges = append(ranges, runeRange{i, end})
+			i = end - 1
+		}
+	}
+
+	return mergeRanges(ranges)
+}
+
+func mergeRanges(ranges []runeRange) []runeRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	slices.SortFunc(ranges, func(a, b runeRange) int { return a.start - b.start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.start <= last.end {
+			last.end = max(last.end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// utf16Ranges converts rune ranges over text into UTF-16 offsets, shifted by
+// base runes that precede text in the rendered string
+func utf16Ranges(text []rune, ranges []runeRange, base int) []MatchRange {
+	offsets := make([]int, len(text)+1)
+	for i, r := range text {
+		offsets[i+1] = offsets[i] + utf16.RuneLen(r)
+	}
+
+	result := make([]MatchRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, MatchRange{Start: base + offsets[r.start], End: base + offsets[r.end]})
+	}
+	return result
+}
+
+// snippet cuts a window of body around its first match and returns the
+// snippet with the matches that fall inside it
+func snippet(body []rune, matches []runeRange) (string, []MatchRange) {
+	start := 0
+	if len(matches) > 0 && len(body) > snippetLen {
+		start = max(0, matches[0].start-snippetLead)
+		// Start on a word boundary unless that would skip the match
+		for start > 0 && start < matches[0].start && !unicode.IsSpace(body[start-1]) {
+			start++
+		}
+	}
+	end := min(len(body), start+snippetLen)
+	if end < len(body) {
+		for cut := end; cut > start && cut > end-20; cut-- {
+			if unicode.IsSpace(body[cut]) {
+				end = cut
+				break
+			}
+		}
+	}
+
+	window := body[start:end]
+	var inside []runeRange
+	for _, m := range matches {
+		if m.end <= start || m.start >= end {
+			continue
+		}
+		inside = append(inside, runeRange{max(m.start, start) - start, min(m.end, end) - start})
+	}
+
+	var b strings.Builder
+	base := 0
+	if start > 0 {
+		b.WriteRune(ellipsis)
+		base = utf16.RuneLen(ellipsis)
+	}
+	b.WriteString(strings.TrimRightFunc(string(window), unicode.IsSpace))
+	if end < len(body) {
+		b.WriteRune(ellipsis)
+	}
+
+	return b.String(), utf16Ranges(window, inside, base)
+}
+
+// highlightNote fills note.Highlights for the given terms. The title is
+// highlighted when it matches; the body always yields a snippet so results
+// can show some context.
+func highlightNote(note *Note, terms []searchTerm) {
+	note.Highlights = nil
+
+	title := []rune(note.Title)
+	if matches := findMatches(title, terms); len(matches) > 0 {
+		note.Highlights = append(note.Highlights, Highlight{
+			Field:   "title",
+			Text:    note.Title,
+			Matches: utf16Ranges(title, matches, 0),
+		})
+	}
+
+	if note.Body == "" {
+		return
+	}
+	body := []rune(note.Body)
+	text, matches := snippet(body, findMatches(body, terms))
+	note.Highlights = append(note.Highlights, Highlight{
+		Field:   "body",
+		Text:    text,
+		Matches: matches,
+	})
+}
+
+// queryTerms returns what a parsed query looks for: its free text plus the
+// values of title: and body: filters
+func queryTerms(query *Query) []searchTerm {
+	terms := searchTerms(query.Text)
+	for _, clause := range query.Clauses {
+		if clause.Negate || (clause.Field != FieldTitle && clause.Field != FieldBody) {
+			continue
+		}
+		terms = append(terms, searchTerm{text: []rune(strings.ToLower(clause.Value))})
+	}
+	return terms
+}
+
+// highlightNotes annotates a page of search results
+func highlightNotes(notes []*Note, q string) {
+	query, err := ParseQuery(q)
+	if err != nil {
+		return
+	}
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return
+	}
+	for _, note := range notes {
+		highlightNote(note, terms)
+	}
+}
@@ -0,0 +1,82 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slice16 cuts s by UTF-16 offsets the way a browser would
+func slice16(s string, r MatchRange) string {
+	units := utf16.Encode([]rune(s))
+	return string(utf16.Decode(units[r.Start:r.End]))
+}
+
+func TestSearchTerms(t *testing.T) {
+	terms := searchTerms(`quarterly "action item" -draft targets,`)
+	require.Len(t, terms, 3)
+	assert.Equal(t, "quarterly", string(terms[0].text))
+	assert.True(t, terms[0].word)
+	assert.Equal(t, "action item", string(terms[1].text))
+	assert.False(t, terms[1].word, "phrases match as substrings")
+	assert.Equal(t, "targets", string(terms[2].text), "punctuation is trimmed")
+
+	short := searchTerms("Ab")
+	require.Len(t, short, 1)
+	assert.Equal(t, "ab", string(short[0].text))
+	assert.False(t, short[0].word, "short queries mirror the regex fallback")
+}
+
+func TestHighlightNoteTextSearch(t *testing.T) {
+	note := &Note{Title: "Weekly Meetings", Body: "Discuss meeting targets. The meeting room is booked."}
+	highlightNote(note, searchTerms("meeting"))
+
+	require.Len(t, note.Highlights, 2)
+	title := note.Highlights[0]
+	assert.Equal(t, "title", title.Field)
+	require.Len(t, title.Matches, 1)
+	assert.Equal(t, "Meetings", slice16(title.Text, title.Matches[0]), "whole word is highlighted")
+
+	body := note.Highlights[1]
+	assert.Equal(t, "body", body.Field)
+	assert.Equal(t, note.Body, body.Text, "short bodies are not cut")
+	require.Len(t, body.Matches, 2)
+	for _, m := range body.Matches {
+		assert.Equal(t, "meeting", slice16(body.Text, m))
+	}
+}
+
+func TestHighlightNoteRegexFallback(t *testing.T) {
+	note := &Note{Title: "Go tips", Body: "Cargo and go.mod"}
+	highlightNote(note, searchTerms("go"))
+
+	require.Len(t, note.Highlights, 2)
+	assert.Len(t, note.Highlights[0].Matches, 1)
+	assert.Len(t, note.Highlights[1].Matches, 2, "substring matches inside words too")
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	body := strings.Repeat("filler words here ", 20) + "the 🎯 target is here " + strings.Repeat("more filler ", 20)
+	note := &Note{Title: "Untitled", Body: body}
+	highlightNote(note, searchTerms("target"))
+
+	require.Len(t, note.Highlights, 1, "title without matches is left out")
+	h := note.Highlights[0]
+	assert.True(t, strings.HasPrefix(h.Text, "…"))
+	assert.True(t, strings.HasSuffix(h.Text, "…"))
+	assert.LessOrEqual(t, len([]rune(h.Text)), snippetLen+2)
+	require.Len(t, h.Matches, 1)
+	assert.Equal(t, "target", slice16(h.Text, h.Matches[0]), "offsets count UTF-16 units, emoji included")
+}
+
+func TestHighlightNoMatchStillSnippets(t *testing.T) {
+	note := &Note{Title: "A", Body: strings.Repeat("x", 300)}
+	highlightNote(note, searchTerms("stemmed"))
+
+	require.Len(t, note.Highlights, 1)
+	assert.Empty(t, note.Highlights[0].Matches)
+	assert.True(t, strings.HasSuffix(note.Highlights[0].Text, "…"))
+}
@@ -3,6 +3,7 @@ package notes
 import (
 	"context"
 	"log/slog"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,9 +16,10 @@ import (
 
 // Subscriber represents a connection that can receive note events
 type Subscriber struct {
-	UserID bson.ObjectID
-	Ch     chan NoteEvent
-	Done   chan struct{}
+	UserID    bson.ObjectID
+	SessionID string // "sid" of the access token the connection was opened with; may be empty
+	Ch        chan NoteEvent
+	Done      chan struct{}
 }
 
 // ConnInfo holds connection metadata
@@ -33,32 +35,99 @@ type userSubs struct {
 	m  map[ulid.ULID]ConnInfo
 }
 
+// WorkspaceMembers lists who receives the events of a shared workspace
+type WorkspaceMembers interface {
+	MemberIDs(ctx context.Context, workspaceID bson.ObjectID) ([]bson.ObjectID, error)
+}
+
+// EventListener is told about every broadcast note event together with the
+// recipients that had a live connection. It runs on the caller's goroutine,
+// so implementations must not block.
+type EventListener interface {
+	NoteChanged(ctx context.Context, ev NoteEvent, online []bson.ObjectID)
+}
+
 // Hub manages WebSocket connections and broadcasts events
 type Hub struct {
 	mu          sync.RWMutex
 	subscribers map[bson.ObjectID]*userSubs
 	connIndex   map[ulid.ULID]bson.ObjectID
+	members     WorkspaceMembers
+	listeners   []EventListener
 	bufferSize  int
+	maxPerUser  int
 	dropped     uint64
+
+	// moves holds the latest "moved" event of each note until Run flushes it
+	movesMu sync.Mutex
+	moves   map[bson.ObjectID]NoteEvent
+	wake    chan struct{}
+	running atomic.Bool
+
+	// seen holds the IDs of the latest events broadcast, oldest first in
+	// the ring, so that copies published again by the outbox relay are dropped
+	seenMu   sync.Mutex
+	seen     map[bson.ObjectID]struct{}
+	seenRing []bson.ObjectID
+	seenNext int
 }
 
+// seenEvents bounds the event IDs the hub remembers
+const seenEvents = 4096
+
 // NewHub creates a new event hub with configurable buffer size
 func NewHub(bufferSize int) *Hub {
 	return &Hub{
 		subscribers: make(map[bson.ObjectID]*userSubs),
 		connIndex:   make(map[ulid.ULID]bson.ObjectID),
 		bufferSize:  bufferSize,
+		moves:       make(map[bson.ObjectID]NoteEvent),
+		wake:        make(chan struct{}, 1),
+		seen:        make(map[bson.ObjectID]struct{}, seenEvents),
+		seenRing:    make([]bson.ObjectID, seenEvents),
 	}
 }
 
+// SetWorkspaceMembers makes Broadcast fan workspace note events out to every
+// member instead of only the author
+func (h *Hub) SetWorkspaceMembers(m WorkspaceMembers) {
+	h.mu.Lock()
+	h.members = m
+	h.mu.Unlock()
+}
+
+// SetMaxConnsPerUser caps the connections a user may hold open at once;
+// zero lifts the cap. A connection past the cap closes the user's oldest
+// one, which is most likely a phone that lost its network without hanging
+// up.
+func (h *Hub) SetMaxConnsPerUser(n int) {
+	h.mu.Lock()
+	h.maxPerUser = n
+	h.mu.Unlock()
+}
+
+// AddListener registers l to be told about every broadcast note event
+func (h *Hub) AddListener(l EventListener) {
+	h.mu.Lock()
+	h.listeners = append(h.listeners, l)
+	h.mu.Unlock()
+}
+
 // Subscribe adds a new subscriber to the hub
 func (h *Hub) Subscribe(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID) (*Subscriber, func()) {
+	return h.SubscribeSession(ctx, connULID, userID, "")
+}
+
+// SubscribeSession adds a new subscriber bound to an auth session so that it
+// can later be dropped with CloseSession.
+func (h *Hub) SubscribeSession(ctx context.Context, connULID ulid.ULID, userID bson.ObjectID, sessionID string) (*Subscriber, func()) {
 	log := logger.L()
 	if log != nil && log.Enabled(ctx, slog.LevelDebug) {
 		log.DebugContext(ctx,
 			"subscribing connection",
 			"conn_id", connULID.String(),
-			"user_id", userID.Hex())
+			"user_id", userID.Hex(),
+			"session_id", sessionID)
 	}
 
 	h.mu.Lock()
@@ -69,15 +138,16 @@ func (h *Hub) Subscribe(ctx context.Context, connULID ulid.ULID, userID bson.Obj
 		}
 		h.subscribers[userID] = userBucket
 	}
+	maxPerUser := h.maxPerUser
 	h.mu.Unlock()
 
 	userBucket.mu.Lock()
-	defer userBucket.mu.Unlock()
 
 	sub := &Subscriber{
-		UserID: userID,
-		Ch:     make(chan NoteEvent, h.bufferSize),
-		Done:   make(chan struct{}),
+		UserID:    userID,
+		SessionID: sessionID,
+		Ch:        make(chan NoteEvent, h.bufferSize),
+		Done:      make(chan struct{}),
 	}
 
 	connInfo := ConnInfo{
@@ -87,11 +157,20 @@ func (h *Hub) Subscribe(ctx context.Context, connULID ulid.ULID, userID bson.Obj
 	}
 
 	userBucket.m[connULID] = connInfo
+	evicted := oldestConns(userBucket.m, connULID, maxPerUser)
+	userBucket.mu.Unlock()
 
 	h.mu.Lock()
 	h.connIndex[connULID] = userID
 	h.mu.Unlock()
 
+	for _, id := range evicted {
+		h.Unsubscribe(ctx, id)
+	}
+	if log != nil && len(evicted) > 0 {
+		log.Info("closed connections over the per-user cap", "user_id", userID.Hex(), "connections", len(evicted))
+	}
+
 	cancel := func() {
 		h.Unsubscribe(ctx, connULID)
 	}
@@ -143,23 +222,93 @@ func (h *Hub) Unsubscribe(ctx context.Context, connULID ulid.ULID) {
 	h.mu.Unlock()
 }
 
-// Broadcast delivers ev to every subscriber of ev.Note.UserID
+// Broadcast delivers ev to every subscriber of ev.Note.UserID, or for notes
+// in a shared workspace to every subscriber of each workspace member. While
+// Run is running "moved" events are coalesced per note before delivery.
+// An event whose EventID was broadcast recently is dropped.
 func (h *Hub) Broadcast(ctx context.Context, ev NoteEvent) {
 	if ev.Note == nil {
 		return
 	}
+	if !ev.EventID.IsZero() && h.duplicate(ev.EventID) {
+		return
+	}
+	if h.coalesce(ev) {
+		return
+	}
+	h.publish(ctx, ev)
+}
+
+// duplicate records id and reports whether it was already recorded
+func (h *Hub) duplicate(id bson.ObjectID) bool {
+	h.seenMu.Lock()
+	defer h.seenMu.Unlock()
+
+	if _, ok := h.seen[id]; ok {
+		return true
+	}
+	delete(h.seen, h.seenRing[h.seenNext])
+	h.seenRing[h.seenNext] = id
+	h.seenNext = (h.seenNext + 1) % len(h.seenRing)
+	h.seen[id] = struct{}{}
+	return false
+}
 
+// publish delivers ev to its recipients and tells the listeners
+func (h *Hub) publish(ctx context.Context, ev NoteEvent) {
 	log := logger.L()
 	if log != nil && log.Enabled(ctx, slog.LevelDebug) {
 		log.DebugContext(ctx, "broadcasting event", "user_id", ev.Note.UserID.Hex(),
 			"event_type", ev.Type)
 	}
 
-	bucket := h.bucket(ev.Note.UserID)
-	if bucket == nil {
-		return
+	var online []bson.ObjectID
+	for _, uid := range h.recipients(ctx, ev.Note) {
+		if bucket := h.bucket(uid); bucket != nil {
+			h.deliver(bucket, ev, log)
+			online = append(online, uid)
+		}
+	}
+
+	h.mu.RLock()
+	listeners := h.listeners
+	h.mu.RUnlock()
+	for _, l := range listeners {
+		l.NoteChanged(ctx, ev, online)
 	}
+}
 
+// Send delivers ev to every connection of userID. Unlike Broadcast it needs
+// no note, which suits per-user events like view counts.
+func (h *Hub) Send(_ context.Context, userID bson.ObjectID, ev NoteEvent) {
+	if bucket := h.bucket(userID); bucket != nil {
+		h.deliver(bucket, ev, logger.L())
+	}
+}
+
+// recipients returns the users whose connections receive events about note
+func (h *Hub) recipients(ctx context.Context, note *Note) []bson.ObjectID {
+	h.mu.RLock()
+	members := h.members
+	h.mu.RUnlock()
+
+	if note.WorkspaceID == nil || members == nil {
+		return []bson.ObjectID{note.UserID}
+	}
+
+	ids, err := members.MemberIDs(ctx, *note.WorkspaceID)
+	if err != nil {
+		// Degrade to the author rather than dropping the event for everyone
+		if log := logger.L(); log != nil {
+			log.Error("failed to resolve workspace members", "error", err, "workspace_id", note.WorkspaceID.Hex())
+		}
+		return []bson.ObjectID{note.UserID}
+	}
+	return ids
+}
+
+// deliver sends ev to every connection in bucket
+func (h *Hub) deliver(bucket *userSubs, ev NoteEvent, log *slog.Logger) {
 	bucket.mu.RLock()
 	for _, connInfo := range bucket.m {
 		sendOrDrop(connInfo.Subscriber.Ch, ev, func() {
@@ -172,6 +321,81 @@ func (h *Hub) Broadcast(ctx context.Context, ev NoteEvent) {
 	bucket.mu.RUnlock()
 }
 
+// CloseSession unsubscribes every connection of userID that was opened under
+// sessionID. Closing Done tells the WebSocket handler to hang up.
+func (h *Hub) CloseSession(ctx context.Context, userID bson.ObjectID, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	n := h.closeMatching(ctx, userID, func(sub *Subscriber) bool { return sub.SessionID == sessionID })
+
+	log := logger.L()
+	if log != nil && n > 0 {
+		log.Info("closed session connections", "user_id", userID.Hex(), "session_id", sessionID, "connections", n)
+	}
+}
+
+// CloseUser unsubscribes every connection of userID, e.g. after the account
+// was deleted.
+func (h *Hub) CloseUser(ctx context.Context, userID bson.ObjectID) {
+	n := h.closeMatching(ctx, userID, func(*Subscriber) bool { return true })
+
+	log := logger.L()
+	if log != nil && n > 0 {
+		log.Info("closed user connections", "user_id", userID.Hex(), "connections", n)
+	}
+}
+
+// closeMatching unsubscribes the connections of userID accepted by match and
+// returns how many were closed
+func (h *Hub) closeMatching(ctx context.Context, userID bson.ObjectID, match func(*Subscriber) bool) int {
+	bucket := h.bucket(userID)
+	if bucket == nil {
+		return 0
+	}
+
+	var conns []ulid.ULID
+	bucket.mu.RLock()
+	for id, connInfo := range bucket.m {
+		if match(connInfo.Subscriber) {
+			conns = append(conns, id)
+		}
+	}
+	bucket.mu.RUnlock()
+
+	for _, id := range conns {
+		h.Unsubscribe(ctx, id)
+	}
+	return len(conns)
+}
+
+// oldestConns returns the connections to close so that conns holds at most
+// max, oldest first and never newest; none when max is zero
+func oldestConns(conns map[ulid.ULID]ConnInfo, newest ulid.ULID, max int) []ulid.ULID {
+	if max <= 0 || len(conns) <= max {
+		return nil
+	}
+	older := make([]ConnInfo, 0, len(conns)-1)
+	for id, connInfo := range conns {
+		if id != newest {
+			older = append(older, connInfo)
+		}
+	}
+	slices.SortFunc(older, func(a, b ConnInfo) int {
+		if c := a.ConnectedAt.Compare(b.ConnectedAt); c != 0 {
+			return c
+		}
+		return a.ID.Compare(b.ID)
+	})
+
+	ids := make([]ulid.ULID, 0, len(conns)-max)
+	for _, connInfo := range older[:len(conns)-max] {
+		ids = append(ids, connInfo.ID)
+	}
+	return ids
+}
+
 // GetSubscriberCount returns the current number of subscribers (for testing)
 func (h *Hub) GetSubscriberCount() int {
 	h.mu.RLock()
@@ -0,0 +1,73 @@
+package notes
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// moveCoalesceWindow is how long Run collects "moved" events before
+// delivering the latest position of each note. A drag sends far more
+// updates than a client can render, so only the last of each window counts.
+const moveCoalesceWindow = 50 * time.Millisecond
+
+// coalesce queues a "moved" event for Run and reports whether it did. A
+// deleted note's queued move is dropped so it cannot arrive after the
+// deletion.
+func (h *Hub) coalesce(ev NoteEvent) bool {
+	if !h.running.Load() {
+		return false
+	}
+
+	switch ev.Type {
+	case EventMoved:
+		h.movesMu.Lock()
+		h.moves[ev.Note.ID] = ev
+		h.movesMu.Unlock()
+
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+		return true
+	case "deleted":
+		h.movesMu.Lock()
+		delete(h.moves, ev.Note.ID)
+		h.movesMu.Unlock()
+	}
+	return false
+}
+
+// Run delivers coalesced "moved" events until ctx is cancelled. Without Run
+// every "moved" event is delivered as it is broadcast.
+func (h *Hub) Run(ctx context.Context) error {
+	h.running.Store(true)
+	defer h.running.Store(false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-h.wake:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(moveCoalesceWindow):
+		}
+		h.flushMoves(ctx)
+	}
+}
+
+// flushMoves delivers the queued "moved" events
+func (h *Hub) flushMoves(ctx context.Context) {
+	h.movesMu.Lock()
+	moves := maps.Clone(h.moves)
+	clear(h.moves)
+	h.movesMu.Unlock()
+
+	for _, ev := range moves {
+		h.publish(ctx, ev)
+	}
+}
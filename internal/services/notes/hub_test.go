@@ -469,3 +469,213 @@ func TestHubBroadcastAfterUnsubscribeNoPanic(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestHubCloseSession(t *testing.T) {
+	hub := NewHub(256)
+	userID := bson.NewObjectID()
+	ctx := context.Background()
+
+	phoneConn := ulid.MustNew(ulid.Timestamp(time.Now().UTC()), rand.Reader)
+	laptopConn := ulid.MustNew(ulid.Timestamp(time.Now().UTC()), rand.Reader)
+
+	phone, cancelPhone := hub.SubscribeSession(ctx, phoneConn, userID, "phone-session")
+	defer cancelPhone()
+	laptop, cancelLaptop := hub.SubscribeSession(ctx, laptopConn, userID, "laptop-session")
+	defer cancelLaptop()
+
+	// Another user's connection on the same session id must survive.
+	other, cancelOther := hub.SubscribeSession(ctx, ulid.Make(), bson.NewObjectID(), "phone-session")
+	defer cancelOther()
+
+	hub.CloseSession(ctx, userID, "phone-session")
+
+	select {
+	case <-phone.Done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("revoked session connection should be closed")
+	}
+
+	select {
+	case <-laptop.Done:
+		t.Fatal("other sessions must stay connected")
+	case <-other.Done:
+		t.Fatal("other users must stay connected")
+	default:
+	}
+
+	assert.Equal(t, 2, hub.GetSubscriberCount())
+}
+
+func TestHubCloseUser(t *testing.T) {
+	hub := NewHub(256)
+	userID := bson.NewObjectID()
+	ctx := context.Background()
+
+	first, cancelFirst := hub.SubscribeSession(ctx, ulid.Make(), userID, "a")
+	defer cancelFirst()
+	second, cancelSecond := hub.SubscribeSession(ctx, ulid.Make(), userID, "b")
+	defer cancelSecond()
+	other, cancelOther := hub.Subscribe(ctx, ulid.Make(), bson.NewObjectID())
+	defer cancelOther()
+
+	hub.CloseUser(ctx, userID)
+
+	for _, sub := range []*Subscriber{first, second} {
+		select {
+		case <-sub.Done:
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("every connection of the user should be closed")
+		}
+	}
+
+	select {
+	case <-other.Done:
+		t.Fatal("other users must stay connected")
+	default:
+	}
+
+	assert.Equal(t, 1, hub.GetSubscriberCount())
+}
+
+func TestHubMaxConnsPerUser(t *testing.T) {
+	hub := NewHub(256)
+	hub.SetMaxConnsPerUser(2)
+	userID := bson.NewObjectID()
+	ctx := context.Background()
+
+	first, cancelFirst := hub.Subscribe(ctx, ulid.Make(), userID)
+	defer cancelFirst()
+	second, cancelSecond := hub.Subscribe(ctx, ulid.Make(), userID)
+	defer cancelSecond()
+	other, cancelOther := hub.Subscribe(ctx, ulid.Make(), bson.NewObjectID())
+	defer cancelOther()
+	third, cancelThird := hub.Subscribe(ctx, ulid.Make(), userID)
+	defer cancelThird()
+
+	select {
+	case <-first.Done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("the oldest connection past the cap should be closed")
+	}
+
+	for _, sub := range []*Subscriber{second, third, other} {
+		select {
+		case <-sub.Done:
+			t.Fatal("connections within the cap must stay connected")
+		default:
+		}
+	}
+
+	assert.Equal(t, 3, hub.GetSubscriberCount())
+}
+
+type staticMembers map[bson.ObjectID][]bson.ObjectID
+
+func (m staticMembers) MemberIDs(_ context.Context, workspaceID bson.ObjectID) ([]bson.ObjectID, error) {
+	return m[workspaceID], nil
+}
+
+func TestHubBroadcastWorkspaceFanOut(t *testing.T) {
+	hub := NewHub(256)
+	ctx := context.Background()
+
+	author, member, outsider := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	hub.SetWorkspaceMembers(staticMembers{workspaceID: {author, member}})
+
+	authorSub, cancelAuthor := hub.Subscribe(ctx, ulid.Make(), author)
+	defer cancelAuthor()
+	memberSub, cancelMember := hub.Subscribe(ctx, ulid.Make(), member)
+	defer cancelMember()
+	outsiderSub, cancelOutsider := hub.Subscribe(ctx, ulid.Make(), outsider)
+	defer cancelOutsider()
+
+	hub.Broadcast(ctx, NoteEvent{Type: "created", Note: &Note{ID: bson.NewObjectID(), UserID: author, WorkspaceID: &workspaceID}})
+
+	for _, sub := range []*Subscriber{authorSub, memberSub} {
+		select {
+		case ev := <-sub.Ch:
+			assert.Equal(t, "created", ev.Type)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("every workspace member should receive the event")
+		}
+	}
+
+	// Personal notes still only reach their author
+	hub.Broadcast(ctx, NoteEvent{Type: "created", Note: &Note{ID: bson.NewObjectID(), UserID: member}})
+	select {
+	case <-memberSub.Ch:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("author should receive personal events")
+	}
+
+	assert.Empty(t, outsiderSub.Ch)
+	assert.Empty(t, authorSub.Ch)
+}
+
+// recordingListener remembers who was online for each event
+type recordingListener struct {
+	mu     sync.Mutex
+	online [][]bson.ObjectID
+}
+
+func (l *recordingListener) NoteChanged(_ context.Context, _ NoteEvent, online []bson.ObjectID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.online = append(l.online, online)
+}
+
+func TestHubListenersAndSend(t *testing.T) {
+	hub := NewHub(8)
+	listener := &recordingListener{}
+	hub.AddListener(listener)
+
+	userID, offline := bson.NewObjectID(), bson.NewObjectID()
+	sub, cancel := hub.Subscribe(context.Background(), ulid.MustNew(ulid.Timestamp(time.Now().UTC()), rand.Reader), userID)
+	defer cancel()
+
+	hub.Broadcast(context.Background(), NoteEvent{Type: "created", Note: &Note{ID: bson.NewObjectID(), UserID: userID}})
+	hub.Broadcast(context.Background(), NoteEvent{Type: "created", Note: &Note{ID: bson.NewObjectID(), UserID: offline}})
+	<-sub.Ch
+
+	listener.mu.Lock()
+	assert.Equal(t, [][]bson.ObjectID{{userID}, nil}, listener.online)
+	listener.mu.Unlock()
+
+	counts := []ViewCount{{ViewID: bson.NewObjectID().Hex(), Count: 3}}
+	hub.Send(context.Background(), userID, NoteEvent{Type: EventViewCounts, Views: counts})
+	hub.Send(context.Background(), offline, NoteEvent{Type: EventViewCounts})
+
+	select {
+	case ev := <-sub.Ch:
+		assert.Equal(t, EventViewCounts, ev.Type)
+		assert.Equal(t, counts, ev.Views)
+	case <-time.After(time.Second):
+		t.Fatal("view counts not delivered")
+	}
+}
+
+func TestHubDropsRepeatedEvents(t *testing.T) {
+	hub := NewHub(256)
+	userID := bson.NewObjectID()
+	ctx := context.Background()
+
+	sub, cancel := hub.Subscribe(ctx, ulid.Make(), userID)
+	defer cancel()
+
+	note := &Note{ID: bson.NewObjectID(), UserID: userID}
+	ev := NoteEvent{EventID: bson.NewObjectID(), Type: "updated", Note: note}
+	hub.Broadcast(ctx, ev)
+	hub.Broadcast(ctx, ev)
+	hub.Broadcast(ctx, NoteEvent{Type: "updated", Note: note})
+	hub.Broadcast(ctx, NoteEvent{Type: "updated", Note: note})
+
+	require.Len(t, sub.Ch, 3, "events without an ID are never dropped")
+	assert.Equal(t, ev.EventID, (<-sub.Ch).EventID)
+
+	// The hub forgets the oldest IDs
+	for range seenEvents {
+		hub.duplicate(bson.NewObjectID())
+	}
+	assert.False(t, hub.duplicate(ev.EventID))
+}
@@ -0,0 +1,454 @@
+package notes
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxLinks bounds the links indexed per note
+	maxLinks = 100
+	// maxLinkTarget is the longest text between brackets that is a link
+	maxLinkTarget = 200
+	// defaultBacklinks is how many backlinks are returned without a limit
+	defaultBacklinks = 50
+	// maxRetitled bounds the linking notes rewritten when a title changes
+	maxRetitled = 500
+	// MaxGraphNodes bounds the notes of a link graph
+	MaxGraphNodes = 2000
+)
+
+// linkPattern matches [[Note title]] and [[id]] links
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// Link is a [[wiki link]] in a note body
+type Link struct {
+	// Target is the text between the brackets: a note ID or title
+	Target string `bson:"target" json:"target" example:"Quarterly plan"`
+	// NoteID is the note the link points at; nil while the link is broken
+	NoteID *bson.ObjectID `bson:"note_id,omitempty" json:"note_id,omitempty" example:"683cdb8aa96ad71e8e075bd9"`
+}
+
+// NoteLinks is the link index entry of a note: its title, which links by
+// title resolve against, and its outgoing links
+type NoteLinks struct {
+	NoteID      bson.ObjectID  `bson:"_id"`
+	UserID      bson.ObjectID  `bson:"user_id"`
+	WorkspaceID *bson.ObjectID `bson:"workspace_id,omitempty"`
+	Title       string         `bson:"title"`
+	Links       []Link         `bson:"links,omitempty"`
+}
+
+// LinkIndex stores the outgoing links of every note. The service keeps it
+// in sync on Create, Update and Delete; ReindexLinks rebuilds it from the
+// repository. Links only resolve within one workspace, so every note a
+// link reaches can be read by whoever reads the link.
+type LinkIndex interface {
+	// Put adds or replaces the entry of a note
+	Put(ctx context.Context, entry *NoteLinks) error
+	Delete(ctx context.Context, noteID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+	// Find returns the entries of a workspace, nil for the personal notes
+	// of userID, whose note ID is in ids or whose title is in titles,
+	// oldest first
+	Find(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, ids []bson.ObjectID, titles []string) ([]*NoteLinks, error)
+	// Backlinks returns up to limit entries with a link to noteID
+	Backlinks(ctx context.Context, noteID bson.ObjectID, limit int) ([]*NoteLinks, error)
+	// Scope returns up to limit entries of a workspace, oldest first
+	Scope(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, limit int) ([]*NoteLinks, error)
+	// Heal points the broken links naming title in a workspace at noteID
+	// and returns the entries it changed
+	Heal(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, title string, noteID bson.ObjectID) ([]*NoteLinks, error)
+	// Break marks the links to noteID broken and returns the entries it
+	// changed
+	Break(ctx context.Context, noteID bson.ObjectID) ([]*NoteLinks, error)
+}
+
+// BacklinksRequest represents a backlinks request
+type BacklinksRequest struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=200" example:"50"`
+	Render string `query:"render" validate:"omitempty,oneof=html" example:"html"`
+}
+
+// BacklinksResponse lists the notes linking to a note, newest first
+type BacklinksResponse struct {
+	Notes []*Note `json:"notes"`
+}
+
+// GraphRequest asks for the link graph of one workspace
+type GraphRequest struct {
+	// WorkspaceID selects a shared workspace; empty is the personal one
+	WorkspaceID string `query:"workspace_id" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd0"`
+}
+
+// GraphNode is a note of a link graph
+type GraphNode struct {
+	ID    bson.ObjectID `json:"id" example:"683cdb8aa96ad71e8e075bd9"`
+	Title string        `json:"title" example:"Quarterly plan"`
+}
+
+// GraphEdge is a link from the note Source to the note Target
+type GraphEdge struct {
+	Source bson.ObjectID `json:"source" example:"683cdb8aa96ad71e8e075bd9"`
+	Target bson.ObjectID `json:"target" example:"683cdb8aa96ad71e8e075bda"`
+}
+
+// BrokenLink is a link of the note Source that points at no note
+type BrokenLink struct {
+	Source bson.ObjectID `json:"source" example:"683cdb8aa96ad71e8e075bd9"`
+	Target string        `json:"target" example:"Deleted plan"`
+}
+
+// GraphResponse is the link graph of a workspace. Truncated is set when it
+// has more than MaxGraphNodes notes; the oldest are kept.
+type GraphResponse struct {
+	Nodes     []GraphNode  `json:"nodes"`
+	Edges     []GraphEdge  `json:"edges"`
+	Broken    []BrokenLink `json:"broken"`
+	Truncated bool         `json:"truncated"`
+}
+
+// SetLinkIndex enables wiki links, backlinks and the link graph
+func (s *Service) SetLinkIndex(idx LinkIndex) {
+	s.links = idx
+}
+
+// parseLinks returns the distinct link targets of body in order. Links to
+// the note itself, by ID or title, are left out.
+func parseLinks(body string, self *Note) []string {
+	var targets []string
+	seen := map[string]bool{}
+	for _, m := range linkPattern.FindAllStringSubmatch(body, -1) {
+		target := strings.TrimSpace(m[1])
+		if target == "" || len(target) > maxLinkTarget || seen[target] {
+			continue
+		}
+		if target == self.ID.Hex() || target == self.Title {
+			continue
+		}
+		seen[target] = true
+		targets = append(targets, target)
+		if len(targets) == maxLinks {
+			break
+		}
+	}
+	return targets
+}
+
+// resolveLinks parses the links of note and points each at the note of its
+// workspace with that ID or, failing that, the oldest with that title
+func (s *Service) resolveLinks(ctx context.Context, note *Note) ([]Link, error) {
+	targets := parseLinks(note.Body, note)
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	var ids []bson.ObjectID
+	for _, target := range targets {
+		if id, err := bson.ObjectIDFromHex(target); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	found, err := s.links.Find(ctx, note.UserID, note.WorkspaceID, ids, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[bson.ObjectID]bool{}
+	byTitle := map[string]bson.ObjectID{}
+	for _, entry := range found {
+		byID[entry.NoteID] = true
+		if _, ok := byTitle[entry.Title]; !ok {
+			byTitle[entry.Title] = entry.NoteID
+		}
+	}
+
+	links := make([]Link, 0, len(targets))
+	for _, target := range targets {
+		link := Link{Target: target}
+		if id, err := bson.ObjectIDFromHex(target); err == nil && byID[id] {
+			link.NoteID = &id
+		} else if id, ok := byTitle[target]; ok {
+			link.NoteID = &id
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// indexLinks stores the title and links of a note in the link index. Like
+// the search index, a failure is logged and leaves the entry stale.
+func (s *Service) indexLinks(ctx context.Context, note *Note) {
+	if s.links == nil {
+		return
+	}
+	links, err := s.resolveLinks(ctx, note)
+	if err == nil {
+		err = s.links.Put(ctx, &NoteLinks{
+			NoteID:      note.ID,
+			UserID:      note.UserID,
+			WorkspaceID: note.WorkspaceID,
+			Title:       note.Title,
+			Links:       links,
+		})
+	}
+	if err != nil {
+		s.log.Error("failed to index note links", "error", err, "note_id", note.ID.Hex())
+	}
+}
+
+// linked indexes a new note and points the broken links naming its title
+// at it
+func (s *Service) linked(ctx context.Context, note *Note) {
+	if s.links == nil {
+		return
+	}
+	s.indexLinks(ctx, note)
+	s.healLinks(ctx, note)
+}
+
+// healLinks points the broken links naming the title of note at it
+func (s *Service) healLinks(ctx context.Context, note *Note) {
+	healed, err := s.links.Heal(ctx, note.UserID, note.WorkspaceID, note.Title, note.ID)
+	if err != nil {
+		s.log.Error("failed to heal note links", "error", err, "note_id", note.ID.Hex())
+		return
+	}
+	s.broadcastLinks(ctx, healed)
+}
+
+// retitled follows a title change: the notes linking to note by its old
+// title are rewritten to use the new one, and broken links naming the new
+// title now reach it
+func (s *Service) retitled(ctx context.Context, note *Note) {
+	if s.links == nil {
+		return
+	}
+	backlinks, err := s.links.Backlinks(ctx, note.ID, maxRetitled)
+	if err != nil {
+		s.log.Error("failed to find backlinks", "error", err, "note_id", note.ID.Hex())
+		return
+	}
+	if len(backlinks) == maxRetitled {
+		s.log.Warn("too many backlinks to rewrite them all", "note_id", note.ID.Hex(), "rewritten", maxRetitled)
+	}
+
+	for _, entry := range backlinks {
+		var stale []string
+		for _, link := range entry.Links {
+			if link.NoteID != nil && *link.NoteID == note.ID && link.Target != note.ID.Hex() && link.Target != note.Title {
+				stale = append(stale, link.Target)
+			}
+		}
+		if len(stale) > 0 {
+			s.rewriteLinks(ctx, entry.NoteID, stale, note.Title)
+		}
+	}
+	s.healLinks(ctx, note)
+}
+
+// rewriteLinks replaces the links to the titles in stale with links to
+// title in the body of noteID, as an ordinary update by its author
+func (s *Service) rewriteLinks(ctx context.Context, noteID bson.ObjectID, stale []string, title string) {
+	// A title that cannot sit between brackets keeps the old links, which
+	// break
+	if strings.ContainsAny(title, "[]\n") {
+		return
+	}
+	note, err := s.repo.FindByID(ctx, noteID)
+	if err != nil {
+		s.log.Error("failed to load linking note", "error", err, "note_id", noteID.Hex())
+		return
+	}
+
+	body := note.Body
+	for _, old := range stale {
+		body = strings.ReplaceAll(body, "[["+old+"]]", "[["+title+"]]")
+	}
+	if body == note.Body {
+		return
+	}
+
+	events, err := s.commitNote(ctx, "updated", func(ctx context.Context) (*Note, error) {
+		return s.repo.Update(ctx, note.UserID, noteID, UpdateNote{Body: &body})
+	})
+	if err != nil {
+		s.log.Error("failed to rewrite note links", "error", err, "note_id", noteID.Hex())
+		return
+	}
+	updated := events[0].Note
+	s.adjust(ctx, note.UserID, Usage{StorageBytes: int64(len(body) - len(note.Body))})
+	s.indexNote(ctx, updated)
+	s.indexLinks(ctx, updated)
+	s.scheduleEmbed(noteID)
+	prepareChecklists(updated)
+
+	s.publish(ctx, events...)
+}
+
+// unlinkNote removes a deleted note from the link index and breaks the
+// links to it
+func (s *Service) unlinkNote(ctx context.Context, noteID bson.ObjectID) {
+	if s.links == nil {
+		return
+	}
+	if err := s.links.Delete(ctx, noteID); err != nil {
+		s.log.Error("failed to remove note links", "error", err, "note_id", noteID.Hex())
+	}
+	broken, err := s.links.Break(ctx, noteID)
+	if err != nil {
+		s.log.Error("failed to break note links", "error", err, "note_id", noteID.Hex())
+		return
+	}
+	s.broadcastLinks(ctx, broken)
+}
+
+// broadcastLinks tells the clients of each entry that its links changed
+// while its body did not
+func (s *Service) broadcastLinks(ctx context.Context, entries []*NoteLinks) {
+	for _, entry := range entries {
+		links := entry.Links
+		if links == nil {
+			links = []Link{}
+		}
+		s.bus.Broadcast(ctx, NoteEvent{
+			Type:  EventLinks,
+			Note:  &Note{ID: entry.NoteID, UserID: entry.UserID, WorkspaceID: entry.WorkspaceID},
+			Links: links,
+		})
+	}
+}
+
+// Backlinks returns the notes linking to a note userID can read
+func (s *Service) Backlinks(ctx context.Context, userID, noteID bson.ObjectID, req BacklinksRequest) (*BacklinksResponse, error) {
+	if s.links == nil {
+		return nil, ErrNoLinkIndex
+	}
+	note, err := s.accessibleNote(ctx, userID, noteID, false)
+	if err != nil {
+		return nil, s.noteAccessError(err, ErrListNotes, userID, noteID)
+	}
+
+	limit := cmp.Or(req.Limit, defaultBacklinks)
+	entries, err := s.links.Backlinks(ctx, noteID, limit)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+	if len(entries) == 0 {
+		return &BacklinksResponse{Notes: []*Note{}}, nil
+	}
+
+	ids := make([]bson.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.NoteID)
+	}
+	listReq := ListNotesRequest{Limit: limit, Matches: &SearchMatches{IDs: ids, Scores: make([]float64, len(ids))}}
+	if note.WorkspaceID != nil {
+		listReq.WorkspaceID = note.WorkspaceID.Hex()
+	}
+	linking, _, _, err := s.repo.List(ctx, userID, listReq, -1)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+	if linking == nil {
+		linking = []*Note{}
+	}
+	prepareChecklists(linking...)
+	if req.Render == RenderHTML {
+		s.renderNotes(linking)
+	}
+	return &BacklinksResponse{Notes: linking}, nil
+}
+
+// Graph returns the notes of a workspace userID can read with the links
+// between them
+func (s *Service) Graph(ctx context.Context, userID bson.ObjectID, req GraphRequest) (*GraphResponse, error) {
+	if s.links == nil {
+		return nil, ErrNoLinkIndex
+	}
+	workspaceID, err := s.resolveWorkspace(ctx, userID, req.WorkspaceID, false)
+	if err != nil {
+		return nil, workspaceError(err, ErrListNotes)
+	}
+
+	entries, err := s.links.Scope(ctx, userID, workspaceID, MaxGraphNodes+1)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListNotes
+	}
+	resp := &GraphResponse{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Broken: []BrokenLink{}}
+	if len(entries) > MaxGraphNodes {
+		entries, resp.Truncated = entries[:MaxGraphNodes], true
+	}
+
+	nodes := make(map[bson.ObjectID]bool, len(entries))
+	for _, entry := range entries {
+		nodes[entry.NoteID] = true
+		resp.Nodes = append(resp.Nodes, GraphNode{ID: entry.NoteID, Title: entry.Title})
+	}
+	edges := map[GraphEdge]bool{}
+	for _, entry := range entries {
+		for _, link := range entry.Links {
+			if link.NoteID == nil {
+				resp.Broken = append(resp.Broken, BrokenLink{Source: entry.NoteID, Target: link.Target})
+				continue
+			}
+			// A note may name the same target by title and by ID
+			edge := GraphEdge{Source: entry.NoteID, Target: *link.NoteID}
+			if nodes[edge.Target] && !edges[edge] {
+				edges[edge] = true
+				resp.Edges = append(resp.Edges, edge)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// ReindexLinks rebuilds the link index from every note in the repository
+// and returns how many notes it indexed. A first pass stores the titles so
+// that the second resolves links whatever order the notes come in.
+func (s *Service) ReindexLinks(ctx context.Context) (int, error) {
+	if s.links == nil {
+		return 0, ErrNoLinkIndex
+	}
+	if _, err := s.reindexLinks(ctx, false); err != nil {
+		return 0, err
+	}
+	return s.reindexLinks(ctx, true)
+}
+
+// reindexLinks puts an entry for every note, with its links when withLinks
+// is set
+func (s *Service) reindexLinks(ctx context.Context, withLinks bool) (int, error) {
+	indexed := 0
+	var after bson.ObjectID
+	for {
+		batch, err := s.repo.Scan(ctx, after, reindexBatch)
+		if err != nil {
+			return indexed, fmt.Errorf("%w: %w", ErrReindexLinks, err)
+		}
+		for _, note := range batch {
+			entry := &NoteLinks{NoteID: note.ID, UserID: note.UserID, WorkspaceID: note.WorkspaceID, Title: note.Title}
+			if withLinks {
+				if entry.Links, err = s.resolveLinks(ctx, note); err != nil {
+					return indexed, fmt.Errorf("%w: %w", ErrReindexLinks, err)
+				}
+			}
+			if err := s.links.Put(ctx, entry); err != nil {
+				return indexed, fmt.Errorf("%w: %w", ErrReindexLinks, err)
+			}
+			indexed++
+		}
+		if len(batch) < reindexBatch {
+			return indexed, nil
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
@@ -0,0 +1,284 @@
+package notes
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memLinks is an in-memory LinkIndex
+type memLinks struct {
+	mu      sync.Mutex
+	entries map[bson.ObjectID]*NoteLinks
+}
+
+func newMemLinks() *memLinks {
+	return &memLinks{entries: map[bson.ObjectID]*NoteLinks{}}
+}
+
+func (m *memLinks) get(noteID bson.ObjectID) *NoteLinks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[noteID]; ok {
+		return copyEntry(e)
+	}
+	return nil
+}
+
+func copyEntry(e *NoteLinks) *NoteLinks {
+	cp := *e
+	cp.Links = slices.Clone(e.Links)
+	return &cp
+}
+
+func inScope(e *NoteLinks, userID bson.ObjectID, workspaceID *bson.ObjectID) bool {
+	if workspaceID != nil {
+		return e.WorkspaceID != nil && *e.WorkspaceID == *workspaceID
+	}
+	return e.WorkspaceID == nil && e.UserID == userID
+}
+
+func (m *memLinks) Put(_ context.Context, entry *NoteLinks) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[entry.NoteID] = copyEntry(entry)
+	return nil
+}
+
+func (m *memLinks) Delete(_ context.Context, noteID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, noteID)
+	return nil
+}
+
+func (m *memLinks) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, e := range m.entries {
+		if inScope(e, userID, nil) {
+			delete(m.entries, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memLinks) sorted(keep func(*NoteLinks) bool, desc bool, limit int) []*NoteLinks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*NoteLinks{}
+	for _, e := range m.entries {
+		if keep(e) {
+			result = append(result, copyEntry(e))
+		}
+	}
+	slices.SortFunc(result, func(a, b *NoteLinks) int {
+		if desc {
+			return b.NoteID.Timestamp().Compare(a.NoteID.Timestamp())
+		}
+		return a.NoteID.Timestamp().Compare(b.NoteID.Timestamp())
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func (m *memLinks) Find(_ context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, ids []bson.ObjectID, titles []string) ([]*NoteLinks, error) {
+	return m.sorted(func(e *NoteLinks) bool {
+		return inScope(e, userID, workspaceID) && (slices.Contains(ids, e.NoteID) || slices.Contains(titles, e.Title))
+	}, false, 0), nil
+}
+
+func (m *memLinks) Backlinks(_ context.Context, noteID bson.ObjectID, limit int) ([]*NoteLinks, error) {
+	return m.sorted(func(e *NoteLinks) bool {
+		return slices.ContainsFunc(e.Links, func(l Link) bool { return l.NoteID != nil && *l.NoteID == noteID })
+	}, true, limit), nil
+}
+
+func (m *memLinks) Scope(_ context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, limit int) ([]*NoteLinks, error) {
+	return m.sorted(func(e *NoteLinks) bool { return inScope(e, userID, workspaceID) }, false, limit), nil
+}
+
+func (m *memLinks) Heal(_ context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, title string, noteID bson.ObjectID) ([]*NoteLinks, error) {
+	return m.update(func(e *NoteLinks, l *Link) bool {
+		if e.NoteID == noteID || !inScope(e, userID, workspaceID) || l.NoteID != nil || l.Target != title {
+			return false
+		}
+		l.NoteID = &noteID
+		return true
+	}), nil
+}
+
+func (m *memLinks) Break(_ context.Context, noteID bson.ObjectID) ([]*NoteLinks, error) {
+	return m.update(func(_ *NoteLinks, l *Link) bool {
+		if l.NoteID == nil || *l.NoteID != noteID {
+			return false
+		}
+		l.NoteID = nil
+		return true
+	}), nil
+}
+
+func (m *memLinks) update(change func(*NoteLinks, *Link) bool) []*NoteLinks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := []*NoteLinks{}
+	for _, e := range m.entries {
+		hit := false
+		for i := range e.Links {
+			if change(e, &e.Links[i]) {
+				hit = true
+			}
+		}
+		if hit {
+			changed = append(changed, copyEntry(e))
+		}
+	}
+	return changed
+}
+
+func TestParseLinks(t *testing.T) {
+	self := &Note{ID: bson.NewObjectID(), Title: "Index"}
+	body := "See [[Plan]] and [[ Plan ]], [[" + self.ID.Hex() + "]], [[Index]], [[]] and [[Road [map]]\n[[Budget]]"
+	assert.Equal(t, []string{"Plan", "Budget"}, parseLinks(body, self))
+	assert.Empty(t, parseLinks("no links [here]", self))
+}
+
+func TestServiceLinksLifecycle(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	links := newMemLinks()
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetLinkIndex(links)
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	var events []NoteEvent
+	bus.On("Broadcast", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(1).(NoteEvent))
+	})
+
+	plan, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: "Steps"})
+	require.NoError(t, err)
+	index, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Index", Body: "[[Plan]], [[" + plan.Note.ID.Hex() + "]] and [[Budget]]"})
+	require.NoError(t, err)
+
+	entry := links.get(index.Note.ID)
+	require.NotNil(t, entry)
+	require.Len(t, entry.Links, 3)
+	assert.Equal(t, plan.Note.ID, *entry.Links[0].NoteID, "by title")
+	assert.Equal(t, plan.Note.ID, *entry.Links[1].NoteID, "by ID")
+	assert.Nil(t, entry.Links[2].NoteID, "no note is called Budget yet")
+
+	// Creating Budget heals the broken link and says so
+	events = nil
+	budget, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Budget"})
+	require.NoError(t, err)
+	assert.Equal(t, budget.Note.ID, *links.get(index.Note.ID).Links[2].NoteID)
+	require.Len(t, events, 2)
+	assert.Equal(t, EventLinks, events[1].Type)
+	assert.Equal(t, index.Note.ID, events[1].Note.ID)
+
+	// Renaming Plan rewrites the link by title and keeps the one by ID
+	renamed := *plan.Note
+	title := "Roadmap"
+	renamed.Title = title
+	repo.On("Update", mock.Anything, userID, plan.Note.ID, mock.Anything).Return(&renamed, nil).Once()
+	stored := *index.Note
+	repo.On("FindByID", mock.Anything, index.Note.ID).Return(&stored, nil)
+	rewritten := stored
+	rewritten.Body = "[[Roadmap]], [[" + plan.Note.ID.Hex() + "]] and [[Budget]]"
+	repo.On("Update", mock.Anything, userID, index.Note.ID, UpdateNote{Body: &rewritten.Body}).Return(&rewritten, nil).Once()
+
+	_, err = svc.Update(ctx, userID, plan.Note.ID, UpdateNoteRequest{Title: &title})
+	require.NoError(t, err)
+	entry = links.get(index.Note.ID)
+	assert.Equal(t, "Roadmap", entry.Links[0].Target)
+	assert.Equal(t, plan.Note.ID, *entry.Links[0].NoteID)
+	assert.Equal(t, "Roadmap", links.get(plan.Note.ID).Title)
+
+	// Deleting Budget breaks the link to it and says so
+	events = nil
+	repo.On("Delete", mock.Anything, userID, budget.Note.ID).Return(nil)
+	require.NoError(t, svc.Delete(ctx, userID, budget.Note.ID))
+	assert.Nil(t, links.get(budget.Note.ID))
+	assert.Nil(t, links.get(index.Note.ID).Links[2].NoteID)
+	require.Len(t, events, 2)
+	assert.Equal(t, EventLinks, events[0].Type)
+	assert.Equal(t, "Budget", events[0].Links[2].Target)
+	assert.Equal(t, "deleted", events[1].Type)
+}
+
+func TestServiceBacklinksAndGraph(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	links := newMemLinks()
+	svc := NewService(repo, new(MockBus), silentLogger)
+
+	_, err := svc.Graph(ctx, userID, GraphRequest{})
+	assert.ErrorIs(t, err, ErrNoLinkIndex)
+	svc.SetLinkIndex(links)
+
+	target := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Target"}
+	source := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Source", Body: "[[Target]] [[Gone]] [[" + target.ID.Hex() + "]]"}
+	for _, n := range []*Note{target, source} {
+		require.NoError(t, links.Put(ctx, &NoteLinks{NoteID: n.ID, UserID: userID, Title: n.Title}))
+	}
+	svc.indexLinks(ctx, source)
+	require.NoError(t, links.Put(ctx, &NoteLinks{NoteID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "Foreign"}))
+
+	repo.On("FindByID", mock.Anything, target.ID).Return(target, nil)
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		return req.Matches != nil && slices.Equal(req.Matches.IDs, []bson.ObjectID{source.ID}) && req.WorkspaceID == ""
+	}), -1).Return([]*Note{source}, int64(1), int64(1), nil)
+
+	resp, err := svc.Backlinks(ctx, userID, target.ID, BacklinksRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []*Note{source}, resp.Notes)
+	_, err = svc.Backlinks(ctx, bson.NewObjectID(), target.ID, BacklinksRequest{})
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+
+	graph, err := svc.Graph(ctx, userID, GraphRequest{})
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 2, "other users' notes stay out")
+	assert.Equal(t, []GraphEdge{{Source: source.ID, Target: target.ID}}, graph.Edges)
+	assert.Equal(t, []BrokenLink{{Source: source.ID, Target: "Gone"}}, graph.Broken)
+	assert.False(t, graph.Truncated)
+}
+
+func TestServiceReindexLinks(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	// The link comes before its target in scan order
+	source := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Source", Body: "[[Target]]"}
+	target := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Target"}
+
+	repo := new(MockNotesRepo)
+	repo.On("Scan", mock.Anything, bson.ObjectID{}, reindexBatch).Return([]*Note{source, target}, nil)
+
+	svc := NewService(repo, new(MockBus), silentLogger)
+	_, err := svc.ReindexLinks(ctx)
+	assert.ErrorIs(t, err, ErrNoLinkIndex)
+
+	links := newMemLinks()
+	svc.SetLinkIndex(links)
+	n, err := svc.ReindexLinks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, links.get(source.ID).Links, 1)
+	assert.Equal(t, target.ID, *links.get(source.ID).Links[0].NoteID)
+}
@@ -8,13 +8,61 @@ import (
 
 // Note represents a sticky note in the system
 type Note struct {
-	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty" example:"683cdb8aa96ad71e8e075bd1"`
-	UserID    bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
-	Title     string        `bson:"title" json:"title" validate:"required" example:"Meeting Notes"`
-	Body      string        `bson:"body" json:"body" example:"Remember to discuss the quarterly targets"`
-	Color     string        `bson:"color" json:"color" validate:"omitempty,hexcolor" example:"#FFD700"`
-	CreatedAt time.Time     `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
-	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty" example:"683cdb8aa96ad71e8e075bd1"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	// WorkspaceID is nil for notes in the author's personal workspace
+	WorkspaceID *bson.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty" example:"683cdb8aa96ad71e8e075bd5"`
+	Title       string         `bson:"title" json:"title" validate:"required" example:"Meeting Notes"`
+	Body        string         `bson:"body" json:"body" example:"Remember to discuss the quarterly targets"`
+	Color       string         `bson:"color" json:"color" validate:"omitempty,hexcolor" example:"#FFD700"`
+	// Format is "markdown", or empty for plain text
+	Format string `bson:"format,omitempty" json:"format,omitempty" example:"markdown"`
+	// Type is "checklist", or empty for a free-text note
+	Type string `bson:"type,omitempty" json:"type,omitempty" example:"checklist"`
+	// Items are the entries of a checklist in display order
+	Items []ChecklistItem `bson:"items,omitempty" json:"items,omitempty"`
+	// DueAt is when the note should be done by
+	DueAt *time.Time `bson:"due_at,omitempty" json:"due_at,omitempty" example:"2026-01-09T17:00:00Z"`
+	// RemindAt is when the scheduler sends a "reminder" event; RemindedAt is
+	// set once it has, and cleared when RemindAt changes
+	RemindAt   *time.Time `bson:"remind_at,omitempty" json:"remind_at,omitempty" example:"2026-01-09T09:00:00Z"`
+	RemindedAt *time.Time `bson:"reminded_at,omitempty" json:"reminded_at,omitempty" example:"2026-01-09T09:00:04Z"`
+	// NotebookID files a personal note in a notebook; nil is the Inbox
+	NotebookID *bson.ObjectID `bson:"notebook_id,omitempty" json:"notebook_id,omitempty" example:"683cdb8aa96ad71e8e075bd9"`
+	// BoardID names the board of the workspace the note is placed on; empty
+	// is the default board
+	BoardID string `bson:"board_id,omitempty" json:"board_id,omitempty" example:"planning"`
+	// Position is nil until the note is placed on a board
+	Position  *Position `bson:"position,omitempty" json:"position,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+
+	// Score is the full-text relevance, or the similarity for similar_to and
+	// related notes; only set when sorting by relevance
+	Score float64 `bson:"score,omitempty" json:"score,omitempty" example:"1.5"`
+	// Highlights locate the search matches, only set when listing with q
+	Highlights []Highlight `bson:"-" json:"highlights,omitempty"`
+	// Progress counts the checked items of a checklist
+	Progress *ChecklistProgress `bson:"-" json:"progress,omitempty"`
+	// RenderedHTML is the body as safe HTML, only set with render=html
+	RenderedHTML string `bson:"-" json:"rendered_html,omitempty" example:"<p>Remember to discuss the <strong>quarterly</strong> targets</p>"`
+}
+
+// Highlight marks where a search matched inside one field of a note
+type Highlight struct {
+	Field string `json:"field" example:"body"` // "title" or "body"
+	// Text is the whole title, or a short snippet of the body around the
+	// first match
+	Text string `json:"text" example:"…remember to discuss the quarterly targets…"`
+	// Matches are offsets into Text, counted in UTF-16 code units so
+	// browsers can slice Text with them directly
+	Matches []MatchRange `json:"matches"`
+}
+
+// MatchRange is a half-open [Start, End) range
+type MatchRange struct {
+	Start int `json:"start" example:"13"`
+	End   int `json:"end" example:"20"`
 }
 
 // UpdateNote represents the fields that can be updated in a note
@@ -22,12 +70,91 @@ type UpdateNote struct {
 	Title *string `json:"title,omitempty" validate:"omitempty,min=1" example:"Updated Meeting Notes"`
 	Body  *string `json:"body,omitempty" example:"Updated content for the meeting"`
 	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor" example:"#FF6B6B"`
+	// Format is stored empty for plain text
+	Format *string `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	// DueAt and RemindAt are cleared when set to the zero time
+	DueAt    *time.Time `json:"due_at,omitempty" example:"2026-01-09T17:00:00Z"`
+	RemindAt *time.Time `json:"remind_at,omitempty" example:"2026-01-09T09:00:00Z"`
+}
+
+// Position is the rectangle of a note on its board and its stacking order;
+// higher Z is drawn on top
+type Position struct {
+	X      float64 `bson:"x" json:"x" example:"120"`
+	Y      float64 `bson:"y" json:"y" example:"80"`
+	Width  float64 `bson:"width" json:"width" example:"200"`
+	Height float64 `bson:"height" json:"height" example:"160"`
+	Z      int     `bson:"z" json:"z" example:"3"`
+	// Right and Bottom are the far edges, stored so that viewport queries
+	// are plain range filters
+	Right  float64 `bson:"right" json:"-"`
+	Bottom float64 `bson:"bottom" json:"-"`
+}
+
+// ChecklistItem is one entry of a checklist note
+type ChecklistItem struct {
+	ID      bson.ObjectID `bson:"id" json:"id" example:"683cdb8aa96ad71e8e075bd9"`
+	Text    string        `bson:"text" json:"text" example:"Book flights"`
+	Checked bool          `bson:"checked" json:"checked" example:"false"`
+	// Position is the item's index in Items; the array order is stored
+	Position int `bson:"-" json:"position" example:"0"`
+}
+
+// ChecklistProgress is how many items of a checklist are done, e.g. 3/7
+type ChecklistProgress struct {
+	Done  int `json:"done" example:"3"`
+	Total int `json:"total" example:"7"`
+}
+
+// UpdateItem represents the fields that can be updated in a checklist item
+type UpdateItem struct {
+	Text    *string
+	Checked *bool
 }
 
 // NoteEvent represents an event that occurred on a note
 type NoteEvent struct {
-	Type string `json:"type"` // "created", "updated", "deleted"
-	Note *Note  `json:"note"`
+	// EventID tells copies of an event apart from new ones; an event may be
+	// delivered more than once. Events derived from others, like "moved",
+	// "links" and "view_counts", have none.
+	EventID bson.ObjectID `bson:"-" json:"event_id,omitzero" example:"683cdb8aa96ad71e8e075bda"`
+	Type    string        `bson:"type" json:"type"` // "created", "updated", "deleted", "moved", "links", "view_counts", "reminder" or an item event
+	Note    *Note         `bson:"note" json:"note"`
+	// Item is the item an "item_*" event is about
+	Item *ChecklistItem `bson:"item,omitempty" json:"item,omitempty"`
+	// Views carries the fresh counts of a "view_counts" event, which has no Note
+	Views []ViewCount `bson:"views,omitempty" json:"views,omitempty"`
+	// Links carries all the links of the note of a "links" event
+	Links []Link `bson:"links,omitempty" json:"links,omitempty"`
+}
+
+// EventViewCounts is the type of events pushing saved view counts
+const EventViewCounts = "view_counts"
+
+// EventMoved is the type of the lightweight events sent when a note is
+// placed; the hub coalesces them per note
+const EventMoved = "moved"
+
+// EventReminder is the type of events sent when a note's remind_at comes
+const EventReminder = "reminder"
+
+// EventLinks is the type of events sent when links of a note break or heal
+// because the notes they point at were deleted, created or renamed
+const EventLinks = "links"
+
+// Checklist item events carry the whole note, with its new progress, and the
+// item concerned; a reorder has no single item
+const (
+	EventItemAdded      = "item_added"
+	EventItemUpdated    = "item_updated"
+	EventItemDeleted    = "item_deleted"
+	EventItemsReordered = "items_reordered"
+)
+
+// ViewCount is how many notes a saved view matches
+type ViewCount struct {
+	ViewID string `json:"view_id" example:"683cdb8aa96ad71e8e075bd7"`
+	Count  int64  `json:"count" example:"12"`
 }
 
 // DeletedNoteData represents the minimal data for a deleted note event
@@ -0,0 +1,234 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"note-pulse/internal/services/audit"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NotebookInbox is the notebook filter value for personal notes outside any
+// notebook
+const NotebookInbox = "inbox"
+
+// notebookBatch bounds the notes deleted per round when a notebook goes
+const notebookBatch = 100
+
+// Notebooks resolves the notebooks notes are filed in. Notebooks belong to
+// one user and only hold that user's personal notes.
+type Notebooks interface {
+	// Subtree returns notebookID followed, with recursive set, by every
+	// notebook nested in it. It returns ErrNotebookNotFound unless userID
+	// owns notebookID.
+	Subtree(ctx context.Context, userID, notebookID bson.ObjectID, recursive bool) ([]bson.ObjectID, error)
+}
+
+// SetNotebooks enables filing notes in notebooks
+func (s *Service) SetNotebooks(n Notebooks) {
+	s.notebooks = n
+}
+
+// NotebookRequest names the notebook a note is moved or copied to
+type NotebookRequest struct {
+	// NotebookID is the target notebook; empty is the Inbox
+	NotebookID string `json:"notebook_id" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd9"`
+}
+
+// NotebookCount is how many notes of a listing are filed in one notebook
+type NotebookCount struct {
+	// NotebookID is absent for the Inbox
+	NotebookID *bson.ObjectID `bson:"_id" json:"notebook_id,omitempty" example:"683cdb8aa96ad71e8e075bd9"`
+	Count      int64          `bson:"count" json:"count" example:"12"`
+}
+
+// resolveNotebook parses the notebook a personal note is filed in and checks
+// that userID owns it. Empty is the Inbox and yields nil.
+func (s *Service) resolveNotebook(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, raw string, fallback error) (*bson.ObjectID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if workspaceID != nil {
+		return nil, ErrPersonalOnly
+	}
+	notebookID, err := bson.ObjectIDFromHex(raw)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	if _, err := s.subtree(ctx, userID, notebookID, false, fallback); err != nil {
+		return nil, err
+	}
+	return &notebookID, nil
+}
+
+// resolveNotebookFilter fills in the notebooks the notebook filter of req
+// covers
+func (s *Service) resolveNotebookFilter(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, req *ListNotesRequest) error {
+	req.NotebookIDs = nil
+	if req.Notebook == "" {
+		return nil
+	}
+	if workspaceID != nil {
+		return ErrPersonalOnly
+	}
+	if req.Notebook == NotebookInbox {
+		return nil
+	}
+
+	notebookID, err := bson.ObjectIDFromHex(req.Notebook)
+	if err != nil {
+		return ErrBadRequest
+	}
+	ids, err := s.subtree(ctx, userID, notebookID, req.Recursive, ErrListNotes)
+	if err != nil {
+		return err
+	}
+	req.NotebookIDs = ids
+	return nil
+}
+
+// subtree looks up a notebook of userID, mapping failures other than a
+// missing notebook to fallback
+func (s *Service) subtree(ctx context.Context, userID, notebookID bson.ObjectID, recursive bool, fallback error) ([]bson.ObjectID, error) {
+	if s.notebooks == nil {
+		return nil, ErrNotebookNotFound
+	}
+	ids, err := s.notebooks.Subtree(ctx, userID, notebookID, recursive)
+	if err != nil {
+		if errors.Is(err, ErrNotebookNotFound) {
+			return nil, ErrNotebookNotFound
+		}
+		s.log.Error(fallback.Error(), "error", err, "user_id", userID.Hex(), "notebook_id", notebookID.Hex())
+		return nil, fallback
+	}
+	return ids, nil
+}
+
+// notebookCounts counts the personal notes matching req in each notebook,
+// ignoring its notebook filter. Counts are left out when they fail.
+func (s *Service) notebookCounts(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) []NotebookCount {
+	if s.notebooks == nil {
+		return nil
+	}
+	req.Notebook, req.NotebookIDs = "", nil
+	counts, err := s.repo.NotebookCounts(ctx, userID, req)
+	if err != nil {
+		s.log.Error("failed to count notebook notes", "error", err, "user_id", userID.Hex())
+		return nil
+	}
+	return counts
+}
+
+// MoveToNotebook files a personal note in a notebook, or in the Inbox
+func (s *Service) MoveToNotebook(ctx context.Context, userID, noteID bson.ObjectID, req NotebookRequest) (*NoteResponse, error) {
+	note, err := s.accessibleNote(ctx, userID, noteID, true)
+	if err != nil {
+		return nil, s.noteAccessError(err, ErrUpdateNote, userID, noteID)
+	}
+	if note.WorkspaceID != nil {
+		return nil, ErrPersonalOnly
+	}
+	notebookID, err := s.resolveNotebook(ctx, userID, nil, req.NotebookID, ErrUpdateNote)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.commitNote(ctx, "updated", func(ctx context.Context) (*Note, error) {
+		return s.repo.SetNotebook(ctx, noteID, notebookID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrUpdateNote
+	}
+	moved := events[0].Note
+	prepareChecklists(moved)
+
+	s.publish(ctx, events...)
+	return &NoteResponse{Note: moved}, nil
+}
+
+// CopyToNotebook copies a note userID can read into one of their notebooks,
+// or the Inbox. The copy is a new personal note with the same content and
+// due date; reminders, board placement and attachments stay with the
+// original.
+func (s *Service) CopyToNotebook(ctx context.Context, userID, noteID bson.ObjectID, req NotebookRequest) (*NoteResponse, error) {
+	note, err := s.accessibleNote(ctx, userID, noteID, false)
+	if err != nil {
+		return nil, s.noteAccessError(err, ErrCreateNote, userID, noteID)
+	}
+	notebookID, err := s.resolveNotebook(ctx, userID, nil, req.NotebookID, ErrCreateNote)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ChecklistItem
+	for _, item := range note.Items {
+		items = append(items, ChecklistItem{ID: bson.NewObjectID(), Text: item.Text, Checked: item.Checked})
+	}
+
+	now := time.Now()
+	return s.insert(ctx, &Note{
+		ID:         bson.NewObjectID(),
+		UserID:     userID,
+		Title:      note.Title,
+		Body:       note.Body,
+		Color:      note.Color,
+		Format:     note.Format,
+		Type:       note.Type,
+		Items:      items,
+		DueAt:      note.DueAt,
+		NotebookID: notebookID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
+
+// ReleaseNotebooks moves the notes of userID filed in notebookIDs to the
+// Inbox and returns how many moved. No events are sent; clients reload
+// after deleting a notebook.
+func (s *Service) ReleaseNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	n, err := s.repo.ClearNotebooks(ctx, userID, notebookIDs)
+	if err != nil {
+		s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex())
+		return 0, ErrUpdateNote
+	}
+	return n, nil
+}
+
+// DeleteInNotebooks deletes the notes of userID filed in notebookIDs like
+// Delete does, and returns how many went
+func (s *Service) DeleteInNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	var deleted int64
+	for {
+		batch, err := s.repo.InNotebooks(ctx, userID, notebookIDs, notebookBatch)
+		if err != nil {
+			s.log.Error(ErrDeleteNote.Error(), "error", err, "user_id", userID.Hex())
+			return deleted, ErrDeleteNote
+		}
+
+		for _, note := range batch {
+			events, err := s.deleteNote(ctx, userID, note.ID, nil)
+			if err != nil {
+				if errors.Is(err, ErrNoteNotFound) {
+					continue
+				}
+				s.log.Error(ErrDeleteNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", note.ID.Hex())
+				return deleted, ErrDeleteNote
+			}
+			s.deleted(ctx, note.ID, events)
+			s.release(ctx, userID, noteUsage(note))
+			if s.audit != nil {
+				s.record(ctx, audit.ActionNoteDelete, userID, note.ID, auditSummary(note), nil)
+			}
+			deleted++
+		}
+		if len(batch) < notebookBatch {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package notes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeNotebooks knows the notebooks of one user as a parent map
+type fakeNotebooks struct {
+	userID  bson.ObjectID
+	parents map[bson.ObjectID]*bson.ObjectID
+}
+
+func (f *fakeNotebooks) Subtree(_ context.Context, userID, notebookID bson.ObjectID, recursive bool) ([]bson.ObjectID, error) {
+	if _, ok := f.parents[notebookID]; !ok || userID != f.userID {
+		return nil, ErrNotebookNotFound
+	}
+	ids := []bson.ObjectID{notebookID}
+	if recursive {
+		for id, parent := range f.parents {
+			if parent != nil && *parent == notebookID {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func TestServiceListNotebookFilter(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	work, project := bson.NewObjectID(), bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	svc := NewService(repo, new(MockBus), silentLogger)
+
+	_, err := svc.List(ctx, userID, ListNotesRequest{Notebook: work.Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound, "notebooks are not configured")
+
+	svc.SetNotebooks(&fakeNotebooks{userID: userID, parents: map[bson.ObjectID]*bson.ObjectID{work: nil, project: &work}})
+	_, err = svc.List(ctx, userID, ListNotesRequest{Notebook: "drafts"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = svc.List(ctx, userID, ListNotesRequest{Notebook: bson.NewObjectID().Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound)
+	_, err = svc.List(ctx, bson.NewObjectID(), ListNotesRequest{Notebook: work.Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound, "another user's notebook")
+
+	var listed ListNotesRequest
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		listed = req
+		return true
+	}), mock.Anything).Return([]*Note{}, int64(0), int64(3), nil)
+	counts := []NotebookCount{{Count: 2}, {NotebookID: &project, Count: 1}}
+	repo.On("NotebookCounts", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		return req.Notebook == "" && req.NotebookIDs == nil && req.Color == "#FFFFFF"
+	})).Return(counts, nil)
+
+	resp, err := svc.List(ctx, userID, ListNotesRequest{Notebook: work.Hex(), Color: "#FFFFFF"})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{work}, listed.NotebookIDs)
+	assert.Equal(t, counts, resp.NotebookCounts, "counts ignore the notebook filter")
+
+	_, err = svc.List(ctx, userID, ListNotesRequest{Notebook: work.Hex(), Recursive: true, Color: "#FFFFFF"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []bson.ObjectID{work, project}, listed.NotebookIDs)
+
+	_, err = svc.List(ctx, userID, ListNotesRequest{Notebook: NotebookInbox, Color: "#FFFFFF"})
+	require.NoError(t, err)
+	assert.Equal(t, NotebookInbox, listed.Notebook)
+	assert.Nil(t, listed.NotebookIDs)
+}
+
+func TestServiceMoveToNotebook(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	notebookID := bson.NewObjectID()
+	note := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Plan"}
+	workspaceID := bson.NewObjectID()
+	shared := &Note{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Title: "Shared"}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	repo.On("FindByID", mock.Anything, shared.ID).Return(shared, nil)
+	filed := *note
+	filed.NotebookID = &notebookID
+	repo.On("SetNotebook", mock.Anything, note.ID, &notebookID).Return(&filed, nil)
+	repo.On("SetNotebook", mock.Anything, note.ID, (*bson.ObjectID)(nil)).Return(note, nil)
+	bus.On("Broadcast", mock.Anything, mock.MatchedBy(func(e NoteEvent) bool { return e.Type == "updated" }))
+
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetWorkspaceAccess(fakeAccess{workspaceID: {userID: "write"}})
+	svc.SetNotebooks(&fakeNotebooks{userID: userID, parents: map[bson.ObjectID]*bson.ObjectID{notebookID: nil}})
+
+	resp, err := svc.MoveToNotebook(ctx, userID, note.ID, NotebookRequest{NotebookID: notebookID.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, &notebookID, resp.Note.NotebookID)
+
+	resp, err = svc.MoveToNotebook(ctx, userID, note.ID, NotebookRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Note.NotebookID, "an empty notebook is the Inbox")
+
+	_, err = svc.MoveToNotebook(ctx, userID, note.ID, NotebookRequest{NotebookID: bson.NewObjectID().Hex()})
+	assert.ErrorIs(t, err, ErrNotebookNotFound)
+	_, err = svc.MoveToNotebook(ctx, userID, shared.ID, NotebookRequest{NotebookID: notebookID.Hex()})
+	assert.ErrorIs(t, err, ErrPersonalOnly)
+	_, err = svc.MoveToNotebook(ctx, bson.NewObjectID(), note.ID, NotebookRequest{})
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+	bus.AssertNumberOfCalls(t, "Broadcast", 2)
+}
+
+func TestServiceCopyToNotebook(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	notebookID := bson.NewObjectID()
+	itemID := bson.NewObjectID()
+	note := &Note{
+		ID:       bson.NewObjectID(),
+		UserID:   userID,
+		Title:    "Packing",
+		Type:     TypeChecklist,
+		Items:    []ChecklistItem{{ID: itemID, Text: "Socks", Checked: true}},
+		BoardID:  "trips",
+		Position: &Position{Width: 100, Height: 100},
+	}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil)
+	var created *Note
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(n *Note) bool {
+		created = n
+		return true
+	})).Return(nil)
+	bus.On("Broadcast", mock.Anything, mock.MatchedBy(func(e NoteEvent) bool { return e.Type == "created" }))
+
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetNotebooks(&fakeNotebooks{userID: userID, parents: map[bson.ObjectID]*bson.ObjectID{notebookID: nil}})
+
+	resp, err := svc.CopyToNotebook(ctx, userID, note.ID, NotebookRequest{NotebookID: notebookID.Hex()})
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	assert.NotEqual(t, note.ID, resp.Note.ID)
+	assert.Equal(t, "Packing", resp.Note.Title)
+	assert.Equal(t, &notebookID, resp.Note.NotebookID)
+	require.Len(t, resp.Note.Items, 1)
+	assert.Equal(t, "Socks", resp.Note.Items[0].Text)
+	assert.NotEqual(t, itemID, resp.Note.Items[0].ID, "items get fresh IDs")
+	assert.Nil(t, resp.Note.Position, "board placement stays with the original")
+	assert.Empty(t, resp.Note.BoardID)
+
+	_, err = svc.CopyToNotebook(ctx, userID, note.ID, NotebookRequest{NotebookID: "nope"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	bus.AssertExpectations(t)
+}
+
+func TestServiceDeleteInNotebooks(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	ids := []bson.ObjectID{bson.NewObjectID()}
+
+	full := make([]*Note, notebookBatch)
+	for i := range full {
+		full[i] = &Note{ID: bson.NewObjectID(), UserID: userID}
+	}
+	last := &Note{ID: bson.NewObjectID(), UserID: userID}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("InNotebooks", mock.Anything, userID, ids, notebookBatch).Return(full, nil).Once()
+	repo.On("InNotebooks", mock.Anything, userID, ids, notebookBatch).Return([]*Note{last}, nil).Once()
+	repo.On("Delete", mock.Anything, userID, mock.Anything).Return(nil)
+	bus.On("Broadcast", mock.Anything, mock.MatchedBy(func(e NoteEvent) bool { return e.Type == "deleted" }))
+
+	svc := NewService(repo, bus, silentLogger)
+	deleted, err := svc.DeleteInNotebooks(ctx, userID, ids)
+	require.NoError(t, err)
+	assert.Equal(t, int64(notebookBatch+1), deleted)
+	repo.AssertExpectations(t)
+	bus.AssertNumberOfCalls(t, "Broadcast", notebookBatch+1)
+}
@@ -0,0 +1,204 @@
+package notes
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// outboxInterval is how often RunOutboxRelay looks for unpublished events
+	outboxInterval = 5 * time.Second
+	// outboxGrace is how long a stored event waits for the write that stored
+	// it to publish it, before the relay takes over
+	outboxGrace = 30 * time.Second
+	// outboxClaim is how long a claimed event is hidden from other relays
+	outboxClaim = time.Minute
+	// outboxBatch bounds the events relayed per sweep
+	outboxBatch = 100
+)
+
+// OutboxEvent is a note event stored with the write it tells of, until it
+// is published
+type OutboxEvent struct {
+	// ID is the EventID of Event, which clients use to drop duplicates
+	ID        bson.ObjectID `bson:"_id"`
+	Event     NoteEvent     `bson:"event"`
+	NextAt    time.Time     `bson:"next_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// Outbox stores note events until they are published. Where the database
+// supports transactions the events are stored in the transaction of the
+// write, so a crash between the write and the broadcast loses none of them.
+type Outbox interface {
+	Add(ctx context.Context, events ...*OutboxEvent) error
+	// Claim returns the event due first at now and hides it until until, or
+	// nil when none is due
+	Claim(ctx context.Context, now, until time.Time) (*OutboxEvent, error)
+	// Done removes a published event
+	Done(ctx context.Context, id bson.ObjectID) error
+	SupportsTransactions() bool
+	// WithTransaction runs fn in a transaction; the writes fn makes with
+	// the context it is given commit or abort together
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// EventRecorder stores what it derives from a committed note event, e.g.
+// webhook deliveries. An event leaves the outbox only once every recorder
+// stored it, so the relay hands events whose recording failed over again:
+// RecordEvent must be idempotent by EventID.
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, ev NoteEvent) error
+}
+
+// SetOutbox makes note writes store their events in o, and RunOutboxRelay
+// publish the ones left behind
+func (s *Service) SetOutbox(o Outbox) {
+	s.outbox = o
+}
+
+// AddEventRecorder registers r for every committed note event. Events that
+// are broadcast without a commit, like "moved", do not reach it.
+func (s *Service) AddEventRecorder(r EventRecorder) {
+	s.recorders = append(s.recorders, r)
+}
+
+// commit runs write, which changes notes and returns the events telling of
+// it, and gives each event an EventID. With an outbox on a replica set the
+// events are stored in the transaction of the write. A standalone server
+// has no transactions, so they are stored right after it, and a crash in
+// between loses them as it did before.
+func (s *Service) commit(ctx context.Context, write func(ctx context.Context) ([]NoteEvent, error)) ([]NoteEvent, error) {
+	if s.outbox == nil {
+		events, err := write(ctx)
+		stampEvents(events)
+		return events, err
+	}
+
+	if !s.outbox.SupportsTransactions() {
+		events, err := write(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.outbox.Add(ctx, outboxEvents(events)...); err != nil {
+			s.log.Error("failed to store note events in outbox", "error", err)
+		}
+		return events, nil
+	}
+
+	var events []NoteEvent
+	err := s.outbox.WithTransaction(ctx, func(tx context.Context) error {
+		var err error
+		if events, err = write(tx); err != nil {
+			return err
+		}
+		return s.outbox.Add(tx, outboxEvents(events)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// commitNote runs write, which changes one note and returns it, and commits
+// an event of eventType for it
+func (s *Service) commitNote(ctx context.Context, eventType string, write func(ctx context.Context) (*Note, error)) ([]NoteEvent, error) {
+	return s.commit(ctx, func(ctx context.Context) ([]NoteEvent, error) {
+		note, err := write(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []NoteEvent{{Type: eventType, Note: note}}, nil
+	})
+}
+
+// publish broadcasts events, hands them to the recorders and removes them
+// from the outbox. An event that a recorder failed on or that fails to be
+// removed is published again by the relay, and the hub drops the copy.
+func (s *Service) publish(ctx context.Context, events ...NoteEvent) {
+	for _, ev := range events {
+		s.bus.Broadcast(ctx, ev)
+		if !s.recordEvent(ctx, ev) || s.outbox == nil {
+			continue
+		}
+		if err := s.outbox.Done(ctx, ev.EventID); err != nil {
+			s.log.Warn("failed to remove published note event from outbox", "error", err, "event_id", ev.EventID.Hex())
+		}
+	}
+}
+
+// recordEvent hands ev to the recorders and reports whether all of them
+// stored it
+func (s *Service) recordEvent(ctx context.Context, ev NoteEvent) bool {
+	recorded := true
+	for _, r := range s.recorders {
+		if err := r.RecordEvent(ctx, ev); err != nil {
+			s.log.Error("failed to record note event", "error", err, "event_id", ev.EventID.Hex(), "event_type", ev.Type)
+			recorded = false
+		}
+	}
+	return recorded
+}
+
+// RunOutboxRelay publishes the stored events whose writes did not publish
+// them, e.g. because the server stopped in between, until ctx is done.
+// Events are published at least once.
+func (s *Service) RunOutboxRelay(ctx context.Context) error {
+	if s.outbox == nil {
+		return nil
+	}
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+
+	for {
+		s.relay(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes the stored events due at now
+func (s *Service) relay(ctx context.Context, now time.Time) {
+	for range outboxBatch {
+		stored, err := s.outbox.Claim(ctx, now, now.Add(outboxClaim))
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to claim outbox event", "error", err)
+			}
+			return
+		}
+		if stored == nil {
+			return
+		}
+
+		ev := stored.Event
+		ev.EventID = stored.ID
+		prepareChecklists(ev.Note)
+		s.log.Info("relaying note event from outbox", "event_id", ev.EventID.Hex(), "event_type", ev.Type)
+		s.publish(ctx, ev)
+	}
+}
+
+// stampEvents gives each event a new EventID
+func stampEvents(events []NoteEvent) {
+	for i := range events {
+		events[i].EventID = bson.NewObjectID()
+	}
+}
+
+// outboxEvents stamps events and returns their outbox records, due to the
+// relay once the write had time to publish them itself
+func outboxEvents(events []NoteEvent) []*OutboxEvent {
+	stampEvents(events)
+	now := time.Now().UTC()
+	stored := make([]*OutboxEvent, len(events))
+	for i, ev := range events {
+		stored[i] = &OutboxEvent{ID: ev.EventID, Event: ev, NextAt: now.Add(outboxGrace), CreatedAt: now}
+	}
+	return stored
+}
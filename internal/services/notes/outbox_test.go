@@ -0,0 +1,221 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type txKey struct{}
+
+// memOutbox is an in-memory Outbox whose transactions roll back the events
+// added in them when they fail
+type memOutbox struct {
+	tx     bool
+	events map[bson.ObjectID]*OutboxEvent
+	addErr error
+	// inTx records whether each Add ran in a transaction
+	inTx []bool
+}
+
+func newMemOutbox(tx bool) *memOutbox {
+	return &memOutbox{tx: tx, events: make(map[bson.ObjectID]*OutboxEvent)}
+}
+
+func (o *memOutbox) Add(ctx context.Context, events ...*OutboxEvent) error {
+	if o.addErr != nil {
+		return o.addErr
+	}
+	o.inTx = append(o.inTx, ctx.Value(txKey{}) != nil)
+	for _, ev := range events {
+		o.events[ev.ID] = ev
+	}
+	return nil
+}
+
+func (o *memOutbox) Claim(_ context.Context, now, until time.Time) (*OutboxEvent, error) {
+	var due *OutboxEvent
+	for _, ev := range o.events {
+		if !ev.NextAt.After(now) && (due == nil || ev.NextAt.Before(due.NextAt)) {
+			due = ev
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	due.NextAt = until
+	claimed := *due
+	return &claimed, nil
+}
+
+func (o *memOutbox) Done(_ context.Context, id bson.ObjectID) error {
+	delete(o.events, id)
+	return nil
+}
+
+func (o *memOutbox) SupportsTransactions() bool { return o.tx }
+
+func (o *memOutbox) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	before := maps.Clone(o.events)
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		o.events = before
+		return err
+	}
+	return nil
+}
+
+func TestCommitStoresEventsInTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	outbox := newMemOutbox(true)
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetOutbox(outbox)
+
+	resp, err := svc.Create(ctx, bson.NewObjectID(), CreateNoteRequest{Title: "Plan"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true}, outbox.inTx, "the event is stored with the note")
+	assert.Empty(t, outbox.events, "a published event leaves the outbox")
+	bus.AssertNumberOfCalls(t, "Broadcast", 1)
+	ev := bus.Calls[0].Arguments.Get(1).(NoteEvent)
+	assert.Equal(t, "created", ev.Type)
+	assert.Equal(t, resp.Note.ID, ev.Note.ID)
+	assert.False(t, ev.EventID.IsZero())
+}
+
+func TestCommitRollsBackEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	noteID := bson.NewObjectID()
+	repo.On("Update", mock.Anything, mock.Anything, noteID, mock.Anything).Return(nil, errors.New("write conflict"))
+
+	outbox := newMemOutbox(true)
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetOutbox(outbox)
+
+	_, err := svc.Update(ctx, bson.NewObjectID(), noteID, UpdateNoteRequest{Title: strPtr("Plan")})
+	assert.ErrorIs(t, err, ErrUpdateNote)
+	assert.Empty(t, outbox.events)
+	bus.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+
+	// A write whose event cannot be stored fails as a whole
+	repo.On("Delete", mock.Anything, mock.Anything, noteID).Return(nil)
+	outbox.addErr = errors.New("no primary")
+	err = svc.Delete(ctx, bson.NewObjectID(), noteID)
+	assert.ErrorIs(t, err, ErrDeleteNote)
+	bus.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+}
+
+func TestCommitWithoutTransactions(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	noteID := bson.NewObjectID()
+	repo.On("Delete", mock.Anything, mock.Anything, noteID).Return(nil)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	outbox := newMemOutbox(false)
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetOutbox(outbox)
+
+	require.NoError(t, svc.Delete(ctx, bson.NewObjectID(), noteID))
+	assert.Equal(t, []bool{false}, outbox.inTx, "a standalone server stores the event after the write")
+	assert.Empty(t, outbox.events)
+
+	// Failing to store the event does not fail the write that happened
+	outbox.addErr = errors.New("no primary")
+	require.NoError(t, svc.Delete(ctx, bson.NewObjectID(), noteID))
+	bus.AssertNumberOfCalls(t, "Broadcast", 2)
+}
+
+func TestRelayPublishesStoredEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	outbox := newMemOutbox(true)
+	svc := NewService(new(MockNotesRepo), bus, silentLogger)
+	svc.SetOutbox(outbox)
+
+	note := &Note{
+		ID:     bson.NewObjectID(),
+		UserID: bson.NewObjectID(),
+		Type:   TypeChecklist,
+		Items:  []ChecklistItem{{ID: bson.NewObjectID(), Checked: true}, {ID: bson.NewObjectID()}},
+	}
+	stored := outboxEvents([]NoteEvent{{Type: "updated", Note: note}, {Type: "deleted", Note: note}})
+	require.NoError(t, outbox.Add(ctx, stored...))
+
+	svc.relay(ctx, time.Now().UTC())
+	bus.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+
+	// Past the grace period the write that stored the events is taken to
+	// have failed to publish them
+	svc.relay(ctx, time.Now().UTC().Add(outboxGrace))
+	bus.AssertNumberOfCalls(t, "Broadcast", 2)
+	var ids []bson.ObjectID
+	for _, call := range bus.Calls {
+		ev := call.Arguments.Get(1).(NoteEvent)
+		ids = append(ids, ev.EventID)
+		assert.Equal(t, &ChecklistProgress{Done: 1, Total: 2}, ev.Note.Progress)
+	}
+	assert.ElementsMatch(t, []bson.ObjectID{stored[0].ID, stored[1].ID}, ids)
+	assert.Empty(t, outbox.events)
+}
+
+func TestRunOutboxRelayWithoutOutbox(t *testing.T) {
+	svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+	assert.NoError(t, svc.RunOutboxRelay(context.Background()))
+}
+
+// flakyRecorder fails to record events while err is set
+type flakyRecorder struct {
+	err      error
+	recorded []bson.ObjectID
+}
+
+func (r *flakyRecorder) RecordEvent(_ context.Context, ev NoteEvent) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.recorded = append(r.recorded, ev.EventID)
+	return nil
+}
+
+func TestRecorderFailureKeepsEventInOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	noteID := bson.NewObjectID()
+	repo.On("Delete", mock.Anything, mock.Anything, noteID).Return(nil)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	outbox := newMemOutbox(true)
+	recorder := &flakyRecorder{err: errors.New("no primary")}
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetOutbox(outbox)
+	svc.AddEventRecorder(recorder)
+
+	require.NoError(t, svc.Delete(ctx, bson.NewObjectID(), noteID))
+	require.Len(t, outbox.events, 1, "an event that was not recorded stays in the outbox")
+	assert.Empty(t, recorder.recorded)
+
+	recorder.err = nil
+	svc.relay(ctx, time.Now().UTC().Add(outboxGrace))
+	assert.Empty(t, outbox.events)
+	require.Len(t, recorder.recorded, 1, "the relay records the event")
+	ev := bus.Calls[1].Arguments.Get(1).(NoteEvent)
+	assert.Equal(t, ev.EventID, recorder.recorded[0])
+}
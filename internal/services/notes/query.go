@@ -0,0 +1,255 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Search query fields. FieldText is free text that must not appear in the
+// title or body; positive free text goes to Query.Text instead.
+const (
+	FieldText    = "text"
+	FieldTitle   = "title"
+	FieldBody    = "body"
+	FieldColor   = "color"
+	FieldCreated = "created"
+	FieldUpdated = "updated"
+)
+
+// Date comparison operators of a DateBound
+const (
+	OpGT  = ">"
+	OpGTE = ">="
+	OpLT  = "<"
+	OpLTE = "<="
+)
+
+// Query is a parsed search query such as
+//
+//	title:"standup" -draft color:#FF0000 updated:>2026-01-01 body:"action item"
+//
+// Free words and "quoted phrases" make up Text, which the repository hands to
+// the full-text index. Every other token is a clause ANDed on top of it.
+type Query struct {
+	Text    string
+	Clauses []QueryClause
+}
+
+// QueryClause is one field filter of a Query
+type QueryClause struct {
+	Field  string
+	Negate bool
+	// Value is the case-insensitive substring for text fields and the hex
+	// color for FieldColor
+	Value string
+	// Bounds restrict FieldCreated and FieldUpdated
+	Bounds []DateBound
+}
+
+// DateBound compares a date field with At
+type DateBound struct {
+	Op string
+	At time.Time
+}
+
+// QueryError points at the token of a search query that could not be parsed.
+// It matches ErrBadRequest with errors.Is.
+type QueryError struct {
+	Token  string
+	Pos    int // byte offset of Token in the query
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid search query at position %d (%s): %s", e.Pos, e.Token, e.Reason)
+}
+
+// Unwrap makes a QueryError an ErrBadRequest
+func (e *QueryError) Unwrap() error {
+	return ErrBadRequest
+}
+
+var hexColorRe = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// queryToken is one whitespace-separated token of a query
+type queryToken struct {
+	raw    string
+	pos    int
+	negate bool
+	field  string
+	value  string
+	quoted bool
+}
+
+// ParseQuery parses a search query. Prefixes that are not a known field,
+// like the "re" of "re:meeting", are left as plain text.
+func ParseQuery(q string) (*Query, error) {
+	tokens, err := tokenizeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+	var text []string
+	for _, tok := range tokens {
+		if tok.field == "" && !tok.negate {
+			if tok.quoted {
+				if strings.TrimSpace(tok.value) == "" {
+					return nil, tok.fail("empty phrase")
+				}
+				text = append(text, `"`+tok.value+`"`)
+			} else {
+				text = append(text, tok.value)
+			}
+			continue
+		}
+
+		clause, err := tok.clause()
+		if err != nil {
+			return nil, err
+		}
+		query.Clauses = append(query.Clauses, clause)
+	}
+	query.Text = strings.Join(text, " ")
+
+	return query, nil
+}
+
+// tokenizeQuery splits q on whitespace, keeping quoted values together
+func tokenizeQuery(q string) ([]queryToken, error) {
+	var tokens []queryToken
+	i := 0
+	for i < len(q) {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		tok := queryToken{pos: i}
+		if q[i] == '-' && i+1 < len(q) && !startsWithSpace(q[i+1:]) {
+			tok.negate = true
+			i++
+		}
+		if name, ok := fieldPrefix(q[i:]); ok {
+			tok.field = name
+			i += len(name) + 1
+		}
+
+		if i < len(q) && q[i] == '"' {
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				tok.raw = q[tok.pos:]
+				return nil, tok.fail("unterminated quote")
+			}
+			tok.value = q[i+1 : i+1+end]
+			tok.quoted = true
+			i += end + 2
+		} else {
+			end := strings.IndexFunc(q[i:], unicode.IsSpace)
+			if end < 0 {
+				end = len(q) - i
+			}
+			tok.value = q[i : i+end]
+			i += end
+		}
+
+		tok.raw = q[tok.pos:i]
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+// fieldPrefix returns the lower-cased field name s starts with, if any
+func fieldPrefix(s string) (string, bool) {
+	name, _, found := strings.Cut(s, ":")
+	if !found {
+		return "", false
+	}
+	switch strings.ToLower(name) {
+	case FieldTitle, FieldBody, FieldColor, FieldCreated, FieldUpdated:
+		return strings.ToLower(name), true
+	}
+	return "", false
+}
+
+func (t queryToken) fail(reason string) *QueryError {
+	return &QueryError{Token: t.raw, Pos: t.pos, Reason: reason}
+}
+
+// clause turns a field or negated token into a QueryClause
+func (t queryToken) clause() (QueryClause, error) {
+	clause := QueryClause{Field: t.field, Negate: t.negate}
+	if clause.Field == "" {
+		clause.Field = FieldText
+	}
+
+	if strings.TrimSpace(t.value) == "" {
+		return clause, t.fail("missing value")
+	}
+
+	switch clause.Field {
+	case FieldText, FieldTitle, FieldBody:
+		clause.Value = t.value
+	case FieldColor:
+		if !hexColorRe.MatchString(t.value) {
+			return clause, t.fail("color must be a hex color like #FF0000")
+		}
+		clause.Value = t.value
+	case FieldCreated, FieldUpdated:
+		bounds, err := parseDateBounds(t.value)
+		if err != nil {
+			return clause, t.fail(err.Error())
+		}
+		clause.Bounds = bounds
+	}
+	return clause, nil
+}
+
+// parseDateBounds reads an optional comparison operator followed by a day
+// (2006-01-02, UTC) or an RFC 3339 time. A day covers all of its 24 hours:
+// updated:>2026-01-01 starts on January 2nd and a bare day matches that day.
+func parseDateBounds(value string) ([]DateBound, error) {
+	op := ""
+	for _, candidate := range []string{OpGTE, OpLTE, OpGT, OpLT} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = value[len(candidate):]
+			break
+		}
+	}
+
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case OpGT:
+			return []DateBound{{OpGTE, next}}, nil
+		case OpGTE:
+			return []DateBound{{OpGTE, day}}, nil
+		case OpLT:
+			return []DateBound{{OpLT, day}}, nil
+		case OpLTE:
+			return []DateBound{{OpLT, next}}, nil
+		}
+		return []DateBound{{OpGTE, day}, {OpLT, next}}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("expected a date like 2026-01-01 or an RFC 3339 time")
+	}
+	if op == "" {
+		return []DateBound{{OpGTE, at}, {OpLTE, at}}, nil
+	}
+	return []DateBound{{op, at}}, nil
+}
@@ -0,0 +1,122 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`title:"standup" -draft color:#FF0000 updated:>2026-01-01 body:"action item" weekly "sync notes"`)
+	require.NoError(t, err)
+
+	assert.Equal(t, `weekly "sync notes"`, q.Text)
+	require.Len(t, q.Clauses, 5)
+
+	assert.Equal(t, QueryClause{Field: FieldTitle, Value: "standup"}, q.Clauses[0])
+	assert.Equal(t, QueryClause{Field: FieldText, Negate: true, Value: "draft"}, q.Clauses[1])
+	assert.Equal(t, QueryClause{Field: FieldColor, Value: "#FF0000"}, q.Clauses[2])
+	assert.Equal(t, FieldUpdated, q.Clauses[3].Field)
+	assert.Equal(t, []DateBound{{OpGTE, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}}, q.Clauses[3].Bounds)
+	assert.Equal(t, QueryClause{Field: FieldBody, Value: "action item"}, q.Clauses[4])
+}
+
+func TestParseQueryPlainText(t *testing.T) {
+	q, err := ParseQuery(`  re:meeting  notes "big plan" - `)
+	require.NoError(t, err)
+	assert.Equal(t, `re:meeting notes "big plan" -`, q.Text, "unknown prefixes and a lone dash stay text")
+	assert.Empty(t, q.Clauses)
+
+	q, err = ParseQuery("")
+	require.NoError(t, err)
+	assert.Empty(t, q.Text)
+	assert.Empty(t, q.Clauses)
+}
+
+func TestParseQueryFieldsAreCaseInsensitive(t *testing.T) {
+	q, err := ParseQuery(`-Title:Draft`)
+	require.NoError(t, err)
+	require.Len(t, q.Clauses, 1)
+	assert.Equal(t, QueryClause{Field: FieldTitle, Negate: true, Value: "Draft"}, q.Clauses[0])
+}
+
+func TestParseQueryDates(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	next := day.AddDate(0, 0, 1)
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  []DateBound
+	}{
+		{"2026-03-01", []DateBound{{OpGTE, day}, {OpLT, next}}},
+		{">2026-03-01", []DateBound{{OpGTE, next}}},
+		{">=2026-03-01", []DateBound{{OpGTE, day}}},
+		{"<2026-03-01", []DateBound{{OpLT, day}}},
+		{"<=2026-03-01", []DateBound{{OpLT, next}}},
+		{">2026-03-01T12:30:00Z", []DateBound{{OpGT, at}}},
+		{"2026-03-01T12:30:00Z", []DateBound{{OpGTE, at}, {OpLTE, at}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			q, err := ParseQuery("created:" + tt.value)
+			require.NoError(t, err)
+			require.Len(t, q.Clauses, 1)
+			assert.Equal(t, FieldCreated, q.Clauses[0].Field)
+			assert.Equal(t, tt.want, q.Clauses[0].Bounds)
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		q      string
+		token  string
+		pos    int
+		reason string
+	}{
+		{`notes color:red`, "color:red", 6, "hex color"},
+		{`updated:>yesterday`, "updated:>yesterday", 0, "expected a date"},
+		{`meeting title:"standup`, `title:"standup`, 8, "unterminated quote"},
+		{`a body: b`, "body:", 2, "missing value"},
+		{`x ""`, `""`, 2, "empty phrase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			_, err := ParseQuery(tt.q)
+			var qerr *QueryError
+			require.True(t, errors.As(err, &qerr), "got %v", err)
+			assert.Equal(t, tt.token, qerr.Token)
+			assert.Equal(t, tt.pos, qerr.Pos)
+			assert.Contains(t, qerr.Reason, tt.reason)
+			assert.ErrorIs(t, err, ErrBadRequest)
+			assert.Contains(t, err.Error(), tt.token)
+		})
+	}
+}
+
+func TestHighlightQueryFilters(t *testing.T) {
+	note := &Note{Title: "Daily standup", Body: "Action item: ship it"}
+	highlightNotes([]*Note{note}, `title:standup body:"action item" -draft`)
+
+	require.Len(t, note.Highlights, 2)
+	assert.Equal(t, "standup", slice16(note.Title, note.Highlights[0].Matches[0]))
+	assert.Equal(t, "Action item", slice16(note.Highlights[1].Text, note.Highlights[1].Matches[0]))
+}
+
+func TestServiceListRejectsBadQuery(t *testing.T) {
+	svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+
+	_, err := svc.List(context.Background(), bson.NewObjectID(), ListNotesRequest{Q: "color:blue"})
+	var qerr *QueryError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "color:blue", qerr.Token)
+
+	_, err = svc.List(context.Background(), bson.NewObjectID(), ListNotesRequest{Q: "title:standup", Sort: SortRelevance})
+	assert.ErrorIs(t, err, ErrBadRequest, "relevance needs free text, not just filters")
+}
@@ -0,0 +1,158 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Limits are the plan limits every user writes under. A zero field is
+// unlimited.
+type Limits struct {
+	MaxNotes        int64 `json:"max_notes" example:"10000"`
+	MaxTitleChars   int   `json:"max_title_chars" example:"200"`
+	MaxBodyBytes    int   `json:"max_body_bytes" example:"1048576"`
+	MaxStorageBytes int64 `json:"max_storage_bytes" example:"1073741824"`
+}
+
+// Usage is what a user stores: the notes they wrote, shared workspaces
+// included, and the bytes of their titles, bodies, checklist items and
+// uploaded attachments
+type Usage struct {
+	NoteCount    int64 `bson:"note_count" json:"note_count" example:"42"`
+	StorageBytes int64 `bson:"storage_bytes" json:"storage_bytes" example:"18342"`
+}
+
+// UsageResponse is a user's usage with the limits it counts against
+type UsageResponse struct {
+	Usage  Usage  `json:"usage"`
+	Limits Limits `json:"limits"`
+}
+
+// UsageRepo keeps running usage counters per user
+type UsageRepo interface {
+	// Get returns the usage of userID. The first time, it is counted from
+	// the stored notes and attachments.
+	Get(ctx context.Context, userID bson.ObjectID) (*Usage, error)
+	// Reserve adds delta to the usage of userID, or returns ErrQuotaExceeded
+	// when a growing counter would pass its limit; a zero limit is unlimited
+	Reserve(ctx context.Context, userID bson.ObjectID, delta, limit Usage) error
+	// Add adds delta without looking at limits
+	Add(ctx context.Context, userID bson.ObjectID, delta Usage) error
+	// Delete drops the counters of userID
+	Delete(ctx context.Context, userID bson.ObjectID) error
+}
+
+// SetQuotas enforces limits on note writes, keeping each user's usage in
+// usage as notes and attachments come and go
+func (s *Service) SetQuotas(usage UsageRepo, limits Limits) {
+	s.usage = usage
+	s.limits = limits
+}
+
+// Usage returns what userID stores and the limits it counts against.
+// Without a usage repository nothing is counted.
+func (s *Service) Usage(ctx context.Context, userID bson.ObjectID) (*UsageResponse, error) {
+	if s.usage == nil {
+		return &UsageResponse{Limits: s.limits}, nil
+	}
+	usage, err := s.usage.Get(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrGetUsage.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrGetUsage
+	}
+	return &UsageResponse{Usage: *usage, Limits: s.limits}, nil
+}
+
+// checkSize refuses a title or body, as stored, over the length limits
+func (s *Service) checkSize(title, body *string) error {
+	if title != nil && s.limits.MaxTitleChars > 0 && utf8.RuneCountInString(*title) > s.limits.MaxTitleChars {
+		return fmt.Errorf("%w: title is longer than %d characters", ErrNoteTooLarge, s.limits.MaxTitleChars)
+	}
+	if body != nil && s.limits.MaxBodyBytes > 0 && len(*body) > s.limits.MaxBodyBytes {
+		return fmt.Errorf("%w: body is larger than %d bytes", ErrNoteTooLarge, s.limits.MaxBodyBytes)
+	}
+	return nil
+}
+
+// reserve charges delta to userID ahead of a write, which releases it if it
+// fails. Growth past the limits fails with ErrQuotaExceeded; a usage store
+// that cannot be reached lets the write through, and the counters are off
+// until the user's next recount.
+func (s *Service) reserve(ctx context.Context, userID bson.ObjectID, delta Usage) error {
+	if s.usage == nil || delta == (Usage{}) {
+		return nil
+	}
+	limit := Usage{NoteCount: s.limits.MaxNotes, StorageBytes: s.limits.MaxStorageBytes}
+	err := s.usage.Reserve(ctx, userID, delta, limit)
+	if errors.Is(err, ErrQuotaExceeded) {
+		s.log.Info("write refused over quota", "user_id", userID.Hex(), "notes", delta.NoteCount, "bytes", delta.StorageBytes)
+		return ErrQuotaExceeded
+	}
+	if err != nil {
+		s.log.Error("failed to reserve usage", "error", err, "user_id", userID.Hex())
+	}
+	return nil
+}
+
+// adjust adds delta to the usage of userID without looking at limits
+func (s *Service) adjust(ctx context.Context, userID bson.ObjectID, delta Usage) {
+	if s.usage == nil || delta == (Usage{}) {
+		return
+	}
+	if err := s.usage.Add(ctx, userID, delta); err != nil {
+		s.log.Error("failed to update usage", "error", err, "user_id", userID.Hex())
+	}
+}
+
+// release gives usage back to userID, after a removal or a failed write
+func (s *Service) release(ctx context.Context, userID bson.ObjectID, usage Usage) {
+	s.adjust(ctx, userID, Usage{NoteCount: -usage.NoteCount, StorageBytes: -usage.StorageBytes})
+}
+
+// recount drops the counters of userID, so they are counted again from the
+// stored notes and attachments when next needed. It stands in for release
+// when the size of what was removed is not known.
+func (s *Service) recount(ctx context.Context, userID bson.ObjectID) {
+	if s.usage == nil {
+		return
+	}
+	if err := s.usage.Delete(ctx, userID); err != nil {
+		s.log.Error("failed to reset usage", "error", err, "user_id", userID.Hex())
+	}
+}
+
+// noteUsage is what note counts against its author's limits
+func noteUsage(note *Note) Usage {
+	return Usage{NoteCount: 1, StorageBytes: storageBytes(note)}
+}
+
+// storageBytes is the size of the text of a note
+func storageBytes(note *Note) int64 {
+	if note == nil {
+		return 0
+	}
+	n := len(note.Title) + len(note.Body)
+	for _, item := range note.Items {
+		n += len(item.Text)
+	}
+	return int64(n)
+}
+
+// updateUsage is how much patch grows a note, or shrinks it when negative
+func updateUsage(before *Note, patch UpdateNote) Usage {
+	if before == nil || (patch.Title == nil && patch.Body == nil) {
+		return Usage{}
+	}
+	after := *before
+	if patch.Title != nil {
+		after.Title = *patch.Title
+	}
+	if patch.Body != nil {
+		after.Body = *patch.Body
+	}
+	return Usage{StorageBytes: storageBytes(&after) - storageBytes(before)}
+}
@@ -0,0 +1,129 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memUsage keeps usage counters in memory
+type memUsage struct {
+	usage map[bson.ObjectID]Usage
+}
+
+func newMemUsage() *memUsage {
+	return &memUsage{usage: make(map[bson.ObjectID]Usage)}
+}
+
+func (u *memUsage) Get(_ context.Context, userID bson.ObjectID) (*Usage, error) {
+	usage := u.usage[userID]
+	return &usage, nil
+}
+
+func (u *memUsage) Reserve(ctx context.Context, userID bson.ObjectID, delta, limit Usage) error {
+	usage := u.usage[userID]
+	if delta.NoteCount > 0 && limit.NoteCount > 0 && usage.NoteCount+delta.NoteCount > limit.NoteCount {
+		return ErrQuotaExceeded
+	}
+	if delta.StorageBytes > 0 && limit.StorageBytes > 0 && usage.StorageBytes+delta.StorageBytes > limit.StorageBytes {
+		return ErrQuotaExceeded
+	}
+	return u.Add(ctx, userID, delta)
+}
+
+func (u *memUsage) Add(_ context.Context, userID bson.ObjectID, delta Usage) error {
+	usage := u.usage[userID]
+	usage.NoteCount += delta.NoteCount
+	usage.StorageBytes += delta.StorageBytes
+	u.usage[userID] = usage
+	return nil
+}
+
+func (u *memUsage) Delete(_ context.Context, userID bson.ObjectID) error {
+	delete(u.usage, userID)
+	return nil
+}
+
+func TestServiceEnforcesQuotas(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	usage := newMemUsage()
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetQuotas(usage, Limits{MaxNotes: 2, MaxTitleChars: 5, MaxBodyBytes: 12, MaxStorageBytes: 30})
+
+	created, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: "Ship it"})
+	require.NoError(t, err)
+	assert.Equal(t, Usage{NoteCount: 1, StorageBytes: 11}, usage.usage[userID])
+
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Résumé"})
+	assert.ErrorIs(t, err, ErrNoteTooLarge, "titles count characters")
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: strings.Repeat("x", 13)})
+	assert.ErrorIs(t, err, ErrNoteTooLarge)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+
+	// Growing a note is charged by what it adds
+	note := *created.Note
+	longer := note
+	longer.Body = "Ship it now"
+	repo.On("FindByID", mock.Anything, note.ID).Return(&note, nil)
+	repo.On("Update", mock.Anything, userID, note.ID, mock.Anything).Return(&longer, nil).Once()
+	_, err = svc.Update(ctx, userID, note.ID, UpdateNoteRequest{Body: strPtr("Ship it now")})
+	require.NoError(t, err)
+	assert.Equal(t, Usage{NoteCount: 1, StorageBytes: 15}, usage.usage[userID])
+	note.Body = longer.Body
+
+	// A write that fails gives its reservation back
+	repo.On("Update", mock.Anything, userID, note.ID, mock.Anything).Return(nil, errors.New("boom")).Once()
+	_, err = svc.Update(ctx, userID, note.ID, UpdateNoteRequest{Body: strPtr("0123456789")})
+	assert.ErrorIs(t, err, ErrUpdateNote)
+	assert.Equal(t, Usage{NoteCount: 1, StorageBytes: 15}, usage.usage[userID])
+
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: "0123456789"})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "More"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "two notes at most")
+
+	repo.On("Delete", mock.Anything, userID, note.ID).Return(nil)
+	require.NoError(t, svc.Delete(ctx, userID, note.ID))
+	assert.Equal(t, Usage{NoteCount: 1, StorageBytes: 14}, usage.usage[userID], "the stored note is freed")
+
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Large", Body: "0123456789ab"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "storage is full")
+
+	resp, err := svc.Usage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{NoteCount: 1, StorageBytes: 14}, resp.Usage)
+	assert.Equal(t, int64(2), resp.Limits.MaxNotes)
+}
+
+func TestServiceDeleteRecountsUnloadedNote(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	noteID := bson.NewObjectID()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	usage := newMemUsage()
+	usage.usage[userID] = Usage{NoteCount: 2, StorageBytes: 40}
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetQuotas(usage, Limits{})
+
+	repo.On("FindByID", mock.Anything, noteID).Return(nil, errors.New("boom"))
+	repo.On("Delete", mock.Anything, userID, noteID).Return(nil)
+	require.NoError(t, svc.Delete(ctx, userID, noteID))
+
+	_, kept := usage.usage[userID]
+	assert.False(t, kept, "counters are dropped to be counted again rather than freed as an empty note")
+}
@@ -0,0 +1,294 @@
+package notes
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"note-pulse/internal/utils/embedding"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxSimilar bounds the notes a similarity search ranks
+	maxSimilar = 200
+	// minSimilarity drops notes that merely share a stray word
+	minSimilarity = 0.1
+	// defaultRelated is how many related notes are returned without a limit
+	defaultRelated = 10
+)
+
+// Embedder turns note text into a vector. Vectors of the same Model compare
+// with cosine similarity.
+type Embedder interface {
+	Model() string
+	Embed(text string) []float32
+}
+
+// NoteVector is the stored embedding of a note's title and body
+type NoteVector struct {
+	NoteID      bson.ObjectID  `bson:"_id"`
+	UserID      bson.ObjectID  `bson:"user_id"`
+	WorkspaceID *bson.ObjectID `bson:"workspace_id,omitempty"`
+	Model       string         `bson:"model"`
+	Vector      []float32      `bson:"vector"`
+}
+
+// VectorStore persists note vectors
+type VectorStore interface {
+	Upsert(ctx context.Context, v *NoteVector) error
+	// Find returns ErrNoteNotFound when the note has no vector
+	Find(ctx context.Context, noteID bson.ObjectID) (*NoteVector, error)
+	Delete(ctx context.Context, noteID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+	// Scope returns the vectors of a workspace; nil selects the personal
+	// notes of userID
+	Scope(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID) ([]*NoteVector, error)
+	// Models returns the model of each given note that has a vector
+	Models(ctx context.Context, noteIDs []bson.ObjectID) (map[bson.ObjectID]string, error)
+}
+
+// RelatedNotesResponse lists the notes most similar to another one, best
+// first. Each note's score is its cosine similarity.
+type RelatedNotesResponse struct {
+	Notes []*Note `json:"notes"`
+}
+
+// RelatedNotesRequest represents a related notes request
+type RelatedNotesRequest struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=50" example:"10"`
+	Render string `query:"render" validate:"omitempty,oneof=html" example:"html"`
+}
+
+// SetEmbedder enables related notes and similar_to. Vectors are computed by
+// RunEmbedder, which must be started too.
+func (s *Service) SetEmbedder(e Embedder, store VectorStore) {
+	s.embedder = e
+	s.vectors = store
+}
+
+// scheduleEmbed queues a note for RunEmbedder
+func (s *Service) scheduleEmbed(noteID bson.ObjectID) {
+	if s.embedder == nil {
+		return
+	}
+
+	s.embedMu.Lock()
+	s.embedPending[noteID] = struct{}{}
+	s.embedMu.Unlock()
+
+	select {
+	case s.embedWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunEmbedder computes the vectors of notes written since it started, and
+// first of every note whose vector is missing or from another model, until
+// ctx is done
+func (s *Service) RunEmbedder(ctx context.Context) error {
+	if err := s.backfillVectors(ctx); err != nil && ctx.Err() == nil {
+		s.log.Error("failed to backfill note vectors", "error", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.embedWake:
+		}
+
+		s.embedMu.Lock()
+		pending := slices.Collect(maps.Keys(s.embedPending))
+		clear(s.embedPending)
+		s.embedMu.Unlock()
+
+		for _, noteID := range pending {
+			note, err := s.repo.FindByID(ctx, noteID)
+			if err != nil {
+				if !errors.Is(err, ErrNoteNotFound) {
+					s.log.Error("failed to load note to embed", "error", err, "note_id", noteID.Hex())
+				}
+				continue
+			}
+			if err := s.storeVector(ctx, note); err != nil {
+				s.log.Error("failed to store note vector", "error", err, "note_id", noteID.Hex())
+			}
+		}
+	}
+}
+
+// backfillVectors embeds every note without an up-to-date vector
+func (s *Service) backfillVectors(ctx context.Context) error {
+	model := s.embedder.Model()
+	embedded := 0
+
+	var after bson.ObjectID
+	for {
+		batch, err := s.repo.Scan(ctx, after, reindexBatch)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		ids := make([]bson.ObjectID, len(batch))
+		for i, n := range batch {
+			ids[i] = n.ID
+		}
+		models, err := s.vectors.Models(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, n := range batch {
+			if models[n.ID] == model {
+				continue
+			}
+			if err := s.storeVector(ctx, n); err != nil {
+				return err
+			}
+			embedded++
+		}
+
+		after = batch[len(batch)-1].ID
+		if len(batch) < reindexBatch {
+			break
+		}
+	}
+
+	if embedded > 0 {
+		s.log.Info("backfilled note vectors", "notes", embedded, "model", model)
+	}
+	return nil
+}
+
+func (s *Service) embed(note *Note) []float32 {
+	return s.embedder.Embed(note.Title + "\n" + note.Body)
+}
+
+func (s *Service) storeVector(ctx context.Context, note *Note) error {
+	return s.vectors.Upsert(ctx, &NoteVector{
+		NoteID:      note.ID,
+		UserID:      note.UserID,
+		WorkspaceID: note.WorkspaceID,
+		Model:       s.embedder.Model(),
+		Vector:      s.embed(note),
+	})
+}
+
+// similar ranks the notes of a workspace by cosine similarity to vec. Notes
+// whose vector is not computed yet are left out.
+func (s *Service) similar(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, vec []float32, exclude bson.ObjectID) (*SearchMatches, error) {
+	vectors, err := s.vectors.Scope(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		id    bson.ObjectID
+		score float64
+	}
+	model := s.embedder.Model()
+	var ranked []scored
+	for _, v := range vectors {
+		if v.NoteID == exclude || v.Model != model {
+			continue
+		}
+		if score := embedding.Cosine(vec, v.Vector); score >= minSimilarity {
+			ranked = append(ranked, scored{v.NoteID, score})
+		}
+	}
+	slices.SortFunc(ranked, func(a, b scored) int { return cmp.Compare(b.score, a.score) })
+	ranked = ranked[:min(len(ranked), maxSimilar)]
+
+	matches := &SearchMatches{
+		IDs:    make([]bson.ObjectID, len(ranked)),
+		Scores: make([]float64, len(ranked)),
+	}
+	for i, r := range ranked {
+		matches.IDs[i], matches.Scores[i] = r.id, r.score
+	}
+	return matches, nil
+}
+
+// resolveSimilar ranks the notes of a list request by similarity to its
+// similar_to text
+func (s *Service) resolveSimilar(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, req *ListNotesRequest) error {
+	if req.SimilarTo == "" {
+		return nil
+	}
+	if s.embedder == nil {
+		return ErrNoEmbedder
+	}
+
+	matches, err := s.similar(ctx, userID, workspaceID, s.embedder.Embed(req.SimilarTo), bson.ObjectID{})
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex())
+		return ErrListNotes
+	}
+	req.Matches = matches
+	return nil
+}
+
+// Related returns the notes most similar to noteID from the same workspace
+func (s *Service) Related(ctx context.Context, userID, noteID bson.ObjectID, req RelatedNotesRequest) (*RelatedNotesResponse, error) {
+	if s.embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	note, err := s.accessibleNote(ctx, userID, noteID, false)
+	if err != nil {
+		return nil, s.noteAccessError(err, ErrListNotes, userID, noteID)
+	}
+
+	// A vector still in the queue is computed on the spot
+	var vec []float32
+	stored, err := s.vectors.Find(ctx, noteID)
+	switch {
+	case err == nil && stored.Model == s.embedder.Model():
+		vec = stored.Vector
+	case err == nil || errors.Is(err, ErrNoteNotFound):
+		vec = s.embed(note)
+	default:
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+
+	matches, err := s.similar(ctx, userID, note.WorkspaceID, vec, noteID)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+
+	listReq := ListNotesRequest{Sort: SortRelevance, Limit: cmp.Or(req.Limit, defaultRelated), Matches: matches}
+	if note.WorkspaceID != nil {
+		listReq.WorkspaceID = note.WorkspaceID.Hex()
+	}
+	related, _, _, err := s.repo.List(ctx, userID, listReq, -1)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return nil, ErrListNotes
+	}
+	if related == nil {
+		related = []*Note{}
+	}
+	prepareChecklists(related...)
+	if req.Render == RenderHTML {
+		s.renderNotes(related)
+	}
+	return &RelatedNotesResponse{Notes: related}, nil
+}
+
+// removeVector drops the vector of a deleted note
+func (s *Service) removeVector(ctx context.Context, noteID bson.ObjectID) {
+	if s.embedder == nil {
+		return
+	}
+	if err := s.vectors.Delete(ctx, noteID); err != nil {
+		s.log.Error("failed to delete note vector", "error", err, "note_id", noteID.Hex())
+	}
+}
@@ -0,0 +1,229 @@
+package notes
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/utils/embedding"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memVectors is an in-memory VectorStore
+type memVectors struct {
+	mu      sync.Mutex
+	vectors map[bson.ObjectID]*NoteVector
+}
+
+func newMemVectors() *memVectors {
+	return &memVectors{vectors: make(map[bson.ObjectID]*NoteVector)}
+}
+
+func (m *memVectors) Upsert(_ context.Context, v *NoteVector) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vectors[v.NoteID] = v
+	return nil
+}
+
+func (m *memVectors) Find(_ context.Context, noteID bson.ObjectID) (*NoteVector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.vectors[noteID]
+	if !ok {
+		return nil, ErrNoteNotFound
+	}
+	return v, nil
+}
+
+func (m *memVectors) Delete(_ context.Context, noteID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vectors, noteID)
+	return nil
+}
+
+func (m *memVectors) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, v := range m.vectors {
+		if v.UserID == userID && v.WorkspaceID == nil {
+			delete(m.vectors, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memVectors) Scope(_ context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID) ([]*NoteVector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*NoteVector
+	for _, v := range m.vectors {
+		switch {
+		case workspaceID == nil && v.WorkspaceID == nil && v.UserID == userID,
+			workspaceID != nil && v.WorkspaceID != nil && *v.WorkspaceID == *workspaceID:
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func (m *memVectors) Models(_ context.Context, noteIDs []bson.ObjectID) (map[bson.ObjectID]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	models := make(map[bson.ObjectID]string)
+	for _, id := range noteIDs {
+		if v, ok := m.vectors[id]; ok {
+			models[id] = v.Model
+		}
+	}
+	return models, nil
+}
+
+func (m *memVectors) get(noteID bson.ObjectID) *NoteVector {
+	v, _ := m.Find(context.Background(), noteID)
+	return v
+}
+
+func newEmbeddingService(repo *MockNotesRepo) (*Service, *memVectors) {
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	vectors := newMemVectors()
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetEmbedder(embedding.NewHashed(embedding.DefaultDims), vectors)
+	return svc, vectors
+}
+
+func TestRunEmbedder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	userID := bson.NewObjectID()
+
+	old := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Standup"}
+	stale := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Retro"}
+	repo := new(MockNotesRepo)
+	repo.On("Scan", mock.Anything, bson.ObjectID{}, reindexBatch).Return([]*Note{old, stale}, nil)
+
+	svc, vectors := newEmbeddingService(repo)
+	require.NoError(t, vectors.Upsert(ctx, &NoteVector{NoteID: stale.ID, UserID: userID, Model: "older"}))
+
+	done := make(chan error, 1)
+	go func() { done <- svc.RunEmbedder(ctx) }()
+
+	// Backfill covers notes without a vector and vectors of another model
+	model := svc.embedder.Model()
+	require.Eventually(t, func() bool {
+		v := vectors.get(stale.ID)
+		return vectors.get(old.ID) != nil && v != nil && v.Model == model
+	}, time.Second, 5*time.Millisecond)
+
+	// Writes recompute asynchronously
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	created, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Daily sync"})
+	require.NoError(t, err)
+	note := created.Note
+	repo.On("FindByID", mock.Anything, note.ID).Return(note, nil).Once()
+	require.Eventually(t, func() bool { return vectors.get(note.ID) != nil }, time.Second, 5*time.Millisecond)
+
+	before := vectors.get(note.ID).Vector
+	edited := *note
+	edited.Body = "Blocked on the release"
+	repo.On("Update", mock.Anything, userID, note.ID, mock.Anything).Return(&edited, nil)
+	repo.On("FindByID", mock.Anything, note.ID).Return(&edited, nil)
+	_, err = svc.Update(ctx, userID, note.ID, UpdateNoteRequest{Body: &edited.Body})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return !assert.ObjectsAreEqual(before, vectors.get(note.ID).Vector)
+	}, time.Second, 5*time.Millisecond)
+
+	// A color change leaves the text, and so the vector, alone
+	color := "#FFFFFF"
+	_, err = svc.Update(ctx, userID, note.ID, UpdateNoteRequest{Color: &color})
+	require.NoError(t, err)
+	svc.embedMu.Lock()
+	assert.Empty(t, svc.embedPending)
+	svc.embedMu.Unlock()
+
+	repo.On("Delete", mock.Anything, userID, note.ID).Return(nil)
+	require.NoError(t, svc.Delete(ctx, userID, note.ID))
+	assert.Nil(t, vectors.get(note.ID))
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServiceRelated(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	standup := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Standup", Body: "Blockers and action items"}
+	sync := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Daily sync", Body: "Action items"}
+	groceries := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Groceries", Body: "Milk, eggs"}
+	foreign := &Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "Standup"}
+
+	repo := new(MockNotesRepo)
+	svc, vectors := newEmbeddingService(repo)
+	for _, n := range []*Note{sync, groceries, foreign} {
+		require.NoError(t, svc.storeVector(ctx, n))
+	}
+
+	repo.On("FindByID", mock.Anything, standup.ID).Return(standup, nil)
+	repo.On("FindByID", mock.Anything, foreign.ID).Return(foreign, nil)
+	var listed ListNotesRequest
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		listed = req
+		return true
+	}), -1).Return([]*Note{sync}, int64(1), int64(3), nil)
+
+	// The vector of a note not embedded yet is computed on the spot
+	resp, err := svc.Related(ctx, userID, standup.ID, RelatedNotesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []*Note{sync}, resp.Notes)
+	assert.Equal(t, SortRelevance, listed.Sort)
+	assert.Equal(t, defaultRelated, listed.Limit)
+	require.NotNil(t, listed.Matches)
+	assert.Equal(t, sync.ID, listed.Matches.IDs[0], "the closest note ranks first")
+	assert.NotContains(t, listed.Matches.IDs, foreign.ID, "other users' notes stay out")
+	assert.Nil(t, vectors.get(standup.ID))
+
+	_, err = svc.Related(ctx, userID, foreign.ID, RelatedNotesRequest{})
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+
+	_, err = NewService(repo, new(MockBus), silentLogger).Related(ctx, userID, standup.ID, RelatedNotesRequest{})
+	assert.ErrorIs(t, err, ErrNoEmbedder)
+}
+
+func TestServiceListSimilarTo(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	repo := new(MockNotesRepo)
+	svc, _ := newEmbeddingService(repo)
+	sync := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Daily sync"}
+	require.NoError(t, svc.storeVector(ctx, sync))
+
+	for name, req := range map[string]ListNotesRequest{
+		"free text":  {SimilarTo: "standup", Q: "meeting"},
+		"title sort": {SimilarTo: "standup", Sort: "title"},
+		"anchor":     {SimilarTo: "standup", Anchor: sync.ID.Hex()},
+	} {
+		_, err := svc.List(ctx, userID, req)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+	}
+
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		return req.Sort == SortRelevance && req.Matches != nil &&
+			assert.ObjectsAreEqual([]bson.ObjectID{sync.ID}, req.Matches.IDs)
+	}), -1).Return([]*Note{sync}, int64(1), int64(1), nil)
+
+	resp, err := svc.List(ctx, userID, ListNotesRequest{SimilarTo: "standup", Q: "color:#FFF"})
+	require.NoError(t, err)
+	assert.Len(t, resp.Notes, 1)
+}
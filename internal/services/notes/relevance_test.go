@@ -0,0 +1,59 @@
+package notes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestServiceListRelevance(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	t.Run("requires a full-text query", func(t *testing.T) {
+		svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+		_, err := svc.List(ctx, userID, ListNotesRequest{Sort: SortRelevance, Q: "ab"})
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("cannot be anchored", func(t *testing.T) {
+		svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+		_, err := svc.List(ctx, userID, ListNotesRequest{Sort: SortRelevance, Q: "meeting", Anchor: bson.NewObjectID().Hex()})
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("rejects non-score cursors", func(t *testing.T) {
+		svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+		_, err := svc.List(ctx, userID, ListNotesRequest{Sort: SortRelevance, Q: "meeting", Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("pages with score cursors and highlights", func(t *testing.T) {
+		repo := new(MockNotesRepo)
+		svc := NewService(repo, new(MockBus), silentLogger)
+
+		best := &Note{ID: bson.NewObjectID(), Title: "Meeting", Body: "meeting agenda", Score: 2.5}
+		next := &Note{ID: bson.NewObjectID(), Title: "Other", Body: "one meeting", Score: 0.75}
+		extra := &Note{ID: bson.NewObjectID(), Title: "Extra", Body: "meeting", Score: 0.5}
+		repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+			return req.Sort == SortRelevance && req.Limit == 3
+		}), -1).Return([]*Note{best, next, extra}, int64(3), int64(10), nil)
+
+		resp, err := svc.List(ctx, userID, ListNotesRequest{Sort: "Relevance", Q: "meeting", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, resp.Notes, 2)
+		assert.True(t, resp.HasMore)
+
+		cursor, err := DecodeScoreCursor(resp.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, next.ID, cursor.ID)
+		assert.InDelta(t, 0.75, cursor.Score, 0)
+
+		require.NotEmpty(t, resp.Notes[0].Highlights)
+		assert.Equal(t, "title", resp.Notes[0].Highlights[0].Field)
+	})
+}
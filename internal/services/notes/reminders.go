@@ -0,0 +1,125 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// reminderInterval is how often RunReminders looks for due reminders
+	reminderInterval = 15 * time.Second
+	// reminderLease names the lease that keeps one replica firing reminders
+	reminderLease = "reminders"
+	// reminderLeaseTTL outlives a few sweeps, so a holder that stops is
+	// replaced within a minute
+	reminderLeaseTTL = 4 * reminderInterval
+	// reminderBatch bounds the reminders loaded at once
+	reminderBatch = 100
+)
+
+// Lease lets one server replica at a time run a job
+type Lease interface {
+	// Acquire takes the named lease for ttl, or renews it for the holder,
+	// and reports whether this replica holds it
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
+
+// ReminderNotifier is told about every reminder fired, besides the Hub,
+// e.g. to send an email or call webhooks
+type ReminderNotifier interface {
+	NotifyReminder(ctx context.Context, note *Note) error
+}
+
+// SetLease makes RunReminders fire reminders only on the replica holding the
+// lease. Without one, every replica sweeps; the per-note claim in
+// MarkReminded still fires each reminder once.
+func (s *Service) SetLease(lease Lease) {
+	s.lease = lease
+}
+
+// AddReminderNotifier registers n for fired reminders
+func (s *Service) AddReminderNotifier(n ReminderNotifier) {
+	s.notifiers = append(s.notifiers, n)
+}
+
+// RunReminders fires due reminders until ctx is done. Reminders that came due
+// while no server ran fire at the first sweep.
+func (s *Service) RunReminders(ctx context.Context) error {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fireReminders(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireReminders sends the reminders due at now
+func (s *Service) fireReminders(ctx context.Context, now time.Time) {
+	if s.lease != nil {
+		held, err := s.lease.Acquire(ctx, reminderLease, reminderLeaseTTL)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to acquire reminder lease", "error", err)
+			}
+			return
+		}
+		if !held {
+			return
+		}
+	}
+
+	for {
+		due, err := s.repo.DueReminders(ctx, now, reminderBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to find due reminders", "error", err)
+			}
+			return
+		}
+
+		fired := 0
+		for _, note := range due {
+			if s.fireReminder(ctx, note, now) {
+				fired++
+			}
+		}
+		// A batch where nothing fired would come back unchanged
+		if len(due) < reminderBatch || fired == 0 {
+			return
+		}
+	}
+}
+
+// fireReminder claims the reminder of note and sends it, reporting whether
+// it was sent
+func (s *Service) fireReminder(ctx context.Context, note *Note, now time.Time) bool {
+	if note.RemindAt == nil {
+		return false
+	}
+
+	events, err := s.commitNote(ctx, EventReminder, func(ctx context.Context) (*Note, error) {
+		return s.repo.MarkReminded(ctx, note.ID, *note.RemindAt, now)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNoteNotFound) {
+			s.log.Error("failed to mark reminder", "error", err, "note_id", note.ID.Hex())
+		}
+		return false
+	}
+
+	claimed := events[0].Note
+	prepareChecklists(claimed)
+	s.publish(ctx, events...)
+	for _, n := range s.notifiers {
+		if err := n.NotifyReminder(ctx, claimed); err != nil {
+			s.log.Error("failed to notify reminder", "error", err, "note_id", note.ID.Hex())
+		}
+	}
+	return true
+}
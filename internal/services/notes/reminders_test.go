@@ -0,0 +1,135 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeLease is a Lease that is held or not
+type fakeLease struct {
+	held bool
+	err  error
+}
+
+func (l *fakeLease) Acquire(_ context.Context, _ string, _ time.Duration) (bool, error) {
+	return l.held, l.err
+}
+
+// recordingNotifier records the notes it was told about
+type recordingNotifier struct {
+	notes []*Note
+	err   error
+}
+
+func (n *recordingNotifier) NotifyReminder(_ context.Context, note *Note) error {
+	n.notes = append(n.notes, note)
+	return n.err
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestFireReminders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	remindAt := now.Add(-time.Minute)
+	due := &Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "Call", RemindAt: &remindAt}
+	taken := &Note{ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Title: "Taken", RemindAt: &remindAt}
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	repo.On("DueReminders", mock.Anything, now, reminderBatch).Return([]*Note{due, taken}, nil)
+	claimed := *due
+	claimed.RemindedAt = &now
+	repo.On("MarkReminded", mock.Anything, due.ID, remindAt, now).Return(&claimed, nil)
+	// Another replica fired this one between the query and the claim
+	repo.On("MarkReminded", mock.Anything, taken.ID, remindAt, now).Return(nil, ErrNoteNotFound)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetLease(&fakeLease{held: true})
+	notifier := &recordingNotifier{err: errors.New("smtp down")}
+	svc.AddReminderNotifier(notifier)
+
+	svc.fireReminders(ctx, now)
+
+	bus.AssertNumberOfCalls(t, "Broadcast", 1)
+	ev := bus.Calls[0].Arguments.Get(1).(NoteEvent)
+	assert.Equal(t, EventReminder, ev.Type)
+	assert.Equal(t, due.ID, ev.Note.ID)
+	require.Len(t, notifier.notes, 1, "a failing notifier does not stop the sweep")
+	assert.Equal(t, due.ID, notifier.notes[0].ID)
+	repo.AssertExpectations(t)
+}
+
+func TestFireRemindersWithoutLease(t *testing.T) {
+	for name, lease := range map[string]*fakeLease{
+		"held elsewhere": {held: false},
+		"lease error":    {err: errors.New("no primary")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockNotesRepo)
+			bus := new(MockBus)
+			svc := NewService(repo, bus, silentLogger)
+			svc.SetLease(lease)
+
+			svc.fireReminders(context.Background(), time.Now())
+
+			repo.AssertNotCalled(t, "DueReminders", mock.Anything, mock.Anything, mock.Anything)
+			bus.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRunReminders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	swept := make(chan struct{}, 1)
+	repo.On("DueReminders", mock.Anything, mock.Anything, reminderBatch).
+		Run(func(mock.Arguments) {
+			select {
+			case swept <- struct{}{}:
+			default:
+			}
+		}).
+		Return([]*Note{}, nil)
+	svc := NewService(repo, bus, silentLogger)
+
+	done := make(chan error, 1)
+	go func() { done <- svc.RunReminders(ctx) }()
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("reminders are swept at start")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestParseUpdateTime(t *testing.T) {
+	got, err := parseUpdateTime(nil)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = parseUpdateTime(strPtr(""))
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.True(t, got.IsZero(), `"" clears the field`)
+
+	got, err = parseUpdateTime(strPtr("2026-01-09T18:00:00+01:00"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 9, 17, 0, 0, 0, time.UTC), *got)
+
+	_, err = parseUpdateTime(strPtr("tomorrow"))
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
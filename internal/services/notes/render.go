@@ -0,0 +1,99 @@
+package notes
+
+import (
+	"sync"
+
+	"note-pulse/internal/utils/markdown"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Note formats. Plain notes store an empty Format.
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+// RenderHTML is the render parameter value that adds rendered_html
+const RenderHTML = "html"
+
+// renderCacheMax bounds the cached renders; a full cache is emptied
+const renderCacheMax = 10_000
+
+// renderEntry is the HTML of one version of a note
+type renderEntry struct {
+	version int64
+	html    string
+}
+
+// renderCache keeps the latest render of each note. Every write moves
+// updated_at, which serves as the version, so stale entries never match.
+type renderCache struct {
+	mu sync.Mutex
+	m  map[bson.ObjectID]renderEntry
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{m: make(map[bson.ObjectID]renderEntry)}
+}
+
+func (c *renderCache) get(id bson.ObjectID, version int64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.m[id]
+	if !ok || e.version != version {
+		return "", false
+	}
+	return e.html, true
+}
+
+func (c *renderCache) put(id bson.ObjectID, version int64, html string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.m[id]; !ok && len(c.m) >= renderCacheMax {
+		clear(c.m)
+	}
+	c.m[id] = renderEntry{version: version, html: html}
+}
+
+func (c *renderCache) forget(id bson.ObjectID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, id)
+}
+
+// storedFormat maps a requested format to the one persisted
+func storedFormat(format string) string {
+	if format == FormatMarkdown {
+		return FormatMarkdown
+	}
+	return ""
+}
+
+// renderNotes fills RenderedHTML. Markdown goes through the Markdown
+// renderer, plain text becomes escaped paragraphs.
+func (s *Service) renderNotes(notes []*Note) {
+	for _, note := range notes {
+		// Mongo keeps milliseconds, so a note fresh from a write and the
+		// same note read back share a version
+		version := note.UpdatedAt.UnixMilli()
+		if html, ok := s.renders.get(note.ID, version); ok {
+			note.RenderedHTML = html
+			continue
+		}
+
+		if note.Format != FormatMarkdown {
+			note.RenderedHTML = markdown.PlainToHTML(note.Body)
+		} else {
+			html, err := markdown.ToHTML(note.Body)
+			if err != nil {
+				s.log.Error("failed to render note", "error", err, "note_id", note.ID.Hex())
+				continue
+			}
+			note.RenderedHTML = html
+		}
+		s.renders.put(note.ID, version, note.RenderedHTML)
+	}
+}
@@ -0,0 +1,97 @@
+package notes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestServiceMarkdownBodies(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	noteID := bson.NewObjectID()
+	source := "<b>Plan</b>\n\n- [ ] one\n    - [x] nested"
+
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	svc := NewService(repo, bus, silentLogger)
+
+	var created *Note
+	repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*Note)
+	}).Return(nil)
+	_, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Plan", Body: source, Format: FormatMarkdown})
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, created.Format)
+	assert.Equal(t, "Plan\n\n- [ ] one\n    - [x] nested", created.Body, "markdown keeps its layout")
+
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Plain", Body: source, Format: FormatPlain})
+	require.NoError(t, err)
+	assert.Empty(t, created.Format, "plain notes store no format")
+	assert.Equal(t, "Plan\n\n- [ ] one\n- [x] nested", created.Body)
+
+	// A body alone is cleaned for the note's current format
+	var patch UpdateNote
+	repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: userID, Format: FormatMarkdown}, nil).Once()
+	repo.On("Update", mock.Anything, userID, noteID, mock.Anything).Run(func(args mock.Arguments) {
+		patch = args.Get(3).(UpdateNote)
+	}).Return(&Note{ID: noteID, UserID: userID}, nil)
+	_, err = svc.Update(ctx, userID, noteID, UpdateNoteRequest{Body: &source})
+	require.NoError(t, err)
+	assert.Equal(t, "Plan\n\n- [ ] one\n    - [x] nested", *patch.Body)
+	assert.Nil(t, patch.Format)
+
+	// Switching to plain flattens the new body and clears the format
+	plain := FormatPlain
+	_, err = svc.Update(ctx, userID, noteID, UpdateNoteRequest{Body: &source, Format: &plain})
+	require.NoError(t, err)
+	assert.Equal(t, "Plan\n\n- [ ] one\n- [x] nested", *patch.Body)
+	require.NotNil(t, patch.Format)
+	assert.Empty(t, *patch.Format)
+
+	missing := bson.NewObjectID()
+	repo.On("FindByID", mock.Anything, missing).Return(nil, ErrNoteNotFound)
+	_, err = svc.Update(ctx, userID, missing, UpdateNoteRequest{Body: &source})
+	assert.ErrorIs(t, err, ErrNoteNotFound, "a body alone needs the note to exist")
+	repo.AssertExpectations(t)
+}
+
+func TestServiceListRendersHTML(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	now := time.Now()
+
+	md := &Note{ID: bson.NewObjectID(), UserID: userID, Format: FormatMarkdown, Body: "**bold** <i>x</i>", UpdatedAt: now}
+	plain := &Note{ID: bson.NewObjectID(), UserID: userID, Body: "a < b\nc", UpdatedAt: now}
+
+	repo := new(MockNotesRepo)
+	svc := NewService(repo, new(MockBus), silentLogger)
+	repo.On("List", mock.Anything, userID, mock.Anything, -1).Return([]*Note{md, plain}, int64(2), int64(2), nil)
+
+	resp, err := svc.List(ctx, userID, ListNotesRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Notes[0].RenderedHTML, "rendering is opt-in")
+
+	resp, err = svc.List(ctx, userID, ListNotesRequest{Render: RenderHTML})
+	require.NoError(t, err)
+	assert.Equal(t, "<p><strong>bold</strong> x</p>\n", resp.Notes[0].RenderedHTML)
+	assert.Equal(t, "<p>a &lt; b<br>\nc</p>\n", resp.Notes[1].RenderedHTML)
+
+	// Renders are cached per version
+	md.Body = "changed"
+	svc.renderNotes([]*Note{md})
+	assert.Equal(t, "<p><strong>bold</strong> x</p>\n", md.RenderedHTML)
+	md.UpdatedAt = now.Add(time.Second)
+	svc.renderNotes([]*Note{md})
+	assert.Equal(t, "<p>changed</p>\n", md.RenderedHTML)
+
+	svc.renders.forget(md.ID)
+	_, ok := svc.renders.get(md.ID, md.UpdatedAt.UnixMilli())
+	assert.False(t, ok)
+}
@@ -2,6 +2,7 @@ package notes
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
@@ -9,15 +10,57 @@ import (
 // Repository defines the interface for notes repository operations
 type Repository interface {
 	Create(ctx context.Context, n *Note) error
+	FindByID(ctx context.Context, noteID bson.ObjectID) (*Note, error)
 	List(ctx context.Context, userID bson.ObjectID, filter ListNotesRequest, skip int) ([]*Note, int64, int64, error)
 	Update(ctx context.Context, userID, noteID bson.ObjectID, patch UpdateNote) (*Note, error)
 	Delete(ctx context.Context, userID, noteID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+	// Scan returns up to limit notes of any user with an ID above after, in
+	// ID order
+	Scan(ctx context.Context, after bson.ObjectID, limit int) ([]*Note, error)
+
+	// Checklist item operations change one item in place and return the
+	// updated note. They return ErrItemNotFound for a missing item,
+	// ErrChecklistFull when AddItem would exceed MaxChecklistItems and
+	// ErrItemOrder when itemIDs of ReorderItems are not exactly the items.
+	// A negative position appends.
+	AddItem(ctx context.Context, noteID bson.ObjectID, item ChecklistItem, position int) (*Note, error)
+	UpdateItem(ctx context.Context, noteID, itemID bson.ObjectID, patch UpdateItem) (*Note, error)
+	DeleteItem(ctx context.Context, noteID, itemID bson.ObjectID) (*Note, error)
+	ReorderItems(ctx context.Context, noteID bson.ObjectID, itemIDs []bson.ObjectID) (*Note, error)
 
 	// New methods for anchor-based pagination
 	FindOne(ctx context.Context, userID bson.ObjectID, req ListNotesRequest, anchor string) (*Note, error)
 	ListSide(ctx context.Context, userID bson.ObjectID, req ListNotesRequest, anchor *Note, limit int, direction string) ([]*Note, bool, error)
 	GetAnchorIndex(ctx context.Context, userID bson.ObjectID, req ListNotesRequest, anchor *Note) (int64, error)
 	GetCounts(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) (int64, int64, error)
+
+	// DueReminders returns up to limit notes of any user whose reminder is
+	// due at now and has not fired, earliest first
+	DueReminders(ctx context.Context, now time.Time, limit int) ([]*Note, error)
+	// MarkReminded records that the reminder set for remindAt fired and
+	// returns the note. It returns ErrNoteNotFound when the reminder already
+	// fired or was moved in the meantime.
+	MarkReminded(ctx context.Context, noteID bson.ObjectID, remindAt, at time.Time) (*Note, error)
+
+	// SetPosition places one note on a board and returns it
+	SetPosition(ctx context.Context, p Placement) (*Note, error)
+	// Arrange places the notes of the personal workspace of userID, or of
+	// workspaceID, and returns those it placed. Notes outside the workspace
+	// are skipped.
+	Arrange(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, placements []Placement) ([]*Note, error)
+
+	// SetNotebook files a note in a notebook, or the Inbox when notebookID is
+	// nil, and returns it
+	SetNotebook(ctx context.Context, noteID bson.ObjectID, notebookID *bson.ObjectID) (*Note, error)
+	// ClearNotebooks moves the personal notes of userID filed in notebookIDs
+	// to the Inbox and returns how many moved
+	ClearNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error)
+	// InNotebooks returns up to limit personal notes of userID filed in
+	// notebookIDs
+	InNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID, limit int) ([]*Note, error)
+	// NotebookCounts counts the personal notes matching req per notebook
+	NotebookCounts(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) ([]NotebookCount, error)
 }
 
 // Bus defines the interface for event broadcasting
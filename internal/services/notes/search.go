@@ -0,0 +1,182 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxSearchHits bounds the note IDs a search index hands to the repository
+	maxSearchHits = 10_000
+	// reindexBatch is how many notes Reindex reads and indexes at a time
+	reindexBatch = 500
+)
+
+// SearchIndex is a full-text index kept next to the repository. Without one
+// the repository's Mongo text index answers the free text of q. With one the
+// service resolves the free text to note IDs first and the repository only
+// filters and sorts by them. The service keeps it in sync on Create, Update,
+// Delete and PurgeUser; Reindex rebuilds it from the repository.
+type SearchIndex interface {
+	Index(ctx context.Context, notes ...*Note) error
+	Remove(ctx context.Context, noteIDs ...bson.ObjectID) error
+	// RemoveUser removes the personal notes of userID
+	RemoveUser(ctx context.Context, userID bson.ObjectID) error
+	Search(ctx context.Context, req SearchRequest) ([]SearchHit, error)
+	// Reset empties the index
+	Reset(ctx context.Context) error
+}
+
+// SearchRequest asks a SearchIndex for the notes of one workspace matching
+// the free text of a query
+type SearchRequest struct {
+	UserID bson.ObjectID
+	// WorkspaceID is nil for the personal workspace of UserID
+	WorkspaceID *bson.ObjectID
+	// Text is Query.Text: plain words, any of which may match, and "quoted
+	// phrases", all of which must
+	Text  string
+	Limit int
+}
+
+// SearchHit is one note found by a SearchIndex
+type SearchHit struct {
+	ID    bson.ObjectID
+	Score float64
+}
+
+// SearchMatches are the hits of a SearchIndex for a list request, in the
+// order of the index. Scores[i] is the relevance of IDs[i].
+type SearchMatches struct {
+	IDs    []bson.ObjectID
+	Scores []float64
+}
+
+// SetSearchIndex replaces the Mongo text index with idx
+func (s *Service) SetSearchIndex(idx SearchIndex) {
+	s.search = idx
+}
+
+// SplitText splits the free text of a Query into its plain words and its
+// quoted phrases
+func SplitText(text string) (words, phrases []string) {
+	rest := text
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		if rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				phrases = append(phrases, phrase)
+			}
+			rest = after
+			continue
+		}
+
+		word := rest
+		rest = ""
+		if i := strings.IndexFunc(word, unicode.IsSpace); i >= 0 {
+			word, rest = word[:i], word[i:]
+		}
+		words = append(words, word)
+	}
+	return words, phrases
+}
+
+// resolveSearch runs the free text of req.Q against the search index and
+// stores the hits on req. Short text keeps the repository's substring match,
+// as it does without an index.
+func (s *Service) resolveSearch(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, req *ListNotesRequest) error {
+	if s.search == nil || req.Q == "" {
+		return nil
+	}
+	query, err := ParseQuery(req.Q)
+	if err != nil {
+		return err
+	}
+	if len(query.Text) < MinTextSearchLen {
+		return nil
+	}
+
+	hits, err := s.search.Search(ctx, SearchRequest{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Text:        query.Text,
+		Limit:       maxSearchHits,
+	})
+	if err != nil {
+		s.log.Error("failed to search the index", "error", err, "user_id", userID.Hex())
+		return ErrListNotes
+	}
+
+	matches := &SearchMatches{
+		IDs:    make([]bson.ObjectID, len(hits)),
+		Scores: make([]float64, len(hits)),
+	}
+	for i, hit := range hits {
+		matches.IDs[i], matches.Scores[i] = hit.ID, hit.Score
+	}
+	req.Matches = matches
+	return nil
+}
+
+// indexNote adds or refreshes a note in the search index. A failure only
+// leaves the index stale until the next reindex, so it is logged and the
+// write still succeeds.
+func (s *Service) indexNote(ctx context.Context, note *Note) {
+	if s.search == nil {
+		return
+	}
+	if err := s.search.Index(ctx, note); err != nil {
+		s.log.Error("failed to index note", "error", err, "note_id", note.ID.Hex())
+	}
+}
+
+// unindexNote removes a deleted note from the search index
+func (s *Service) unindexNote(ctx context.Context, noteID bson.ObjectID) {
+	if s.search == nil {
+		return
+	}
+	if err := s.search.Remove(ctx, noteID); err != nil {
+		s.log.Error("failed to remove note from index", "error", err, "note_id", noteID.Hex())
+	}
+}
+
+// Reindex rebuilds the search index from every note in the repository and
+// returns how many notes it indexed
+func (s *Service) Reindex(ctx context.Context) (int, error) {
+	if s.search == nil {
+		return 0, ErrNoSearchIndex
+	}
+	if err := s.search.Reset(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrReindex, err)
+	}
+
+	indexed := 0
+	var after bson.ObjectID
+	for {
+		batch, err := s.repo.Scan(ctx, after, reindexBatch)
+		if err != nil {
+			return indexed, fmt.Errorf("%w: %w", ErrReindex, err)
+		}
+		if len(batch) == 0 {
+			return indexed, nil
+		}
+		if err := s.search.Index(ctx, batch...); err != nil {
+			return indexed, fmt.Errorf("%w: %w", ErrReindex, err)
+		}
+
+		indexed += len(batch)
+		after = batch[len(batch)-1].ID
+		if len(batch) < reindexBatch {
+			return indexed, nil
+		}
+	}
+}
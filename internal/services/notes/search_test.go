@@ -0,0 +1,190 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memIndex is a SearchIndex that records what it was given and answers
+// every search with hits
+type memIndex struct {
+	docs     map[bson.ObjectID]*Note
+	requests []SearchRequest
+	hits     []SearchHit
+	resets   int
+}
+
+func newMemIndex() *memIndex {
+	return &memIndex{docs: make(map[bson.ObjectID]*Note)}
+}
+
+func (m *memIndex) Index(_ context.Context, notes ...*Note) error {
+	for _, n := range notes {
+		m.docs[n.ID] = n
+	}
+	return nil
+}
+
+func (m *memIndex) Remove(_ context.Context, noteIDs ...bson.ObjectID) error {
+	for _, id := range noteIDs {
+		delete(m.docs, id)
+	}
+	return nil
+}
+
+func (m *memIndex) RemoveUser(_ context.Context, userID bson.ObjectID) error {
+	for id, n := range m.docs {
+		if n.UserID == userID && n.WorkspaceID == nil {
+			delete(m.docs, id)
+		}
+	}
+	return nil
+}
+
+func (m *memIndex) Search(_ context.Context, req SearchRequest) ([]SearchHit, error) {
+	m.requests = append(m.requests, req)
+	return m.hits, nil
+}
+
+func (m *memIndex) Reset(context.Context) error {
+	m.resets++
+	clear(m.docs)
+	return nil
+}
+
+func TestSplitText(t *testing.T) {
+	words, phrases := SplitText(`standup "action item"  notes "" "unterminated`)
+	assert.Equal(t, []string{"standup", "notes"}, words)
+	assert.Equal(t, []string{"action item", "unterminated"}, phrases)
+}
+
+func TestServiceKeepsSearchIndexInSync(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	repo := new(MockNotesRepo)
+	bus := new(MockBus)
+	bus.On("Broadcast", mock.Anything, mock.Anything)
+	idx := newMemIndex()
+	svc := NewService(repo, bus, silentLogger)
+	svc.SetSearchIndex(idx)
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	created, err := svc.Create(ctx, userID, CreateNoteRequest{Title: "Standup"})
+	require.NoError(t, err)
+	assert.Contains(t, idx.docs, created.Note.ID)
+
+	renamed := *created.Note
+	renamed.Title = "Retro"
+	repo.On("Update", mock.Anything, userID, created.Note.ID, mock.Anything).Return(&renamed, nil)
+	_, err = svc.Update(ctx, userID, created.Note.ID, UpdateNoteRequest{Title: &renamed.Title})
+	require.NoError(t, err)
+	assert.Equal(t, "Retro", idx.docs[created.Note.ID].Title)
+
+	repo.On("Delete", mock.Anything, userID, created.Note.ID).Return(nil)
+	require.NoError(t, svc.Delete(ctx, userID, created.Note.ID))
+	assert.NotContains(t, idx.docs, created.Note.ID)
+
+	// A failed write leaves the index alone
+	failing := new(MockNotesRepo)
+	failing.On("Create", mock.Anything, mock.Anything).Return(errors.New("boom"))
+	svc.repo = failing
+	_, err = svc.Create(ctx, userID, CreateNoteRequest{Title: "Lost"})
+	assert.ErrorIs(t, err, ErrCreateNote)
+	assert.Empty(t, idx.docs)
+}
+
+func TestServicePurgeUserEmptiesSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	userID, otherID := bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	repo := new(MockNotesRepo)
+	repo.On("DeleteAllForUser", mock.Anything, userID).Return(int64(2), nil)
+	idx := newMemIndex()
+	svc := NewService(repo, new(MockBus), silentLogger)
+	svc.SetSearchIndex(idx)
+
+	diary := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Diary"}
+	draft := &Note{ID: bson.NewObjectID(), UserID: userID, Title: "Draft"}
+	shared := &Note{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID, Title: "Agenda"}
+	other := &Note{ID: bson.NewObjectID(), UserID: otherID, Title: "Diary"}
+	require.NoError(t, idx.Index(ctx, diary, draft, shared, other))
+
+	require.NoError(t, svc.PurgeUser(ctx, userID))
+	assert.NotContains(t, idx.docs, diary.ID, "a purged user's text does not stay on disk")
+	assert.NotContains(t, idx.docs, draft.ID)
+	assert.Contains(t, idx.docs, shared.ID, "workspace notes stay with the workspace")
+	assert.Contains(t, idx.docs, other.ID)
+}
+
+func TestServiceListUsesSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	hit := SearchHit{ID: bson.NewObjectID(), Score: 1.5}
+
+	repo := new(MockNotesRepo)
+	idx := newMemIndex()
+	idx.hits = []SearchHit{hit}
+	svc := NewService(repo, new(MockBus), silentLogger)
+	svc.SetSearchIndex(idx)
+
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		return req.Matches != nil &&
+			assert.ObjectsAreEqual([]bson.ObjectID{hit.ID}, req.Matches.IDs) &&
+			assert.ObjectsAreEqual([]float64{1.5}, req.Matches.Scores)
+	}), -1).Return([]*Note{{ID: hit.ID, Title: "Meeting"}}, int64(1), int64(3), nil)
+
+	resp, err := svc.List(ctx, userID, ListNotesRequest{Q: `meeting "next week" color:#fff`})
+	require.NoError(t, err)
+	require.Len(t, resp.Notes, 1)
+	require.Len(t, idx.requests, 1)
+	assert.Equal(t, `meeting "next week"`, idx.requests[0].Text)
+	assert.Nil(t, idx.requests[0].WorkspaceID)
+	assert.Equal(t, maxSearchHits, idx.requests[0].Limit)
+
+	// Short text keeps the substring match of the repository
+	repo.On("GetCounts", mock.Anything, userID, mock.MatchedBy(func(req ListNotesRequest) bool {
+		return req.Matches == nil
+	})).Return(int64(2), int64(3), nil)
+	n, err := svc.Count(ctx, userID, ListNotesRequest{Q: "me"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Len(t, idx.requests, 1)
+}
+
+func TestServiceReindex(t *testing.T) {
+	ctx := context.Background()
+
+	svc := NewService(new(MockNotesRepo), new(MockBus), silentLogger)
+	_, err := svc.Reindex(ctx)
+	assert.ErrorIs(t, err, ErrNoSearchIndex)
+
+	first := make([]*Note, reindexBatch)
+	for i := range first {
+		first[i] = &Note{ID: bson.NewObjectID()}
+	}
+	last := []*Note{{ID: bson.NewObjectID()}}
+
+	repo := new(MockNotesRepo)
+	repo.On("Scan", mock.Anything, bson.ObjectID{}, reindexBatch).Return(first, nil)
+	repo.On("Scan", mock.Anything, first[reindexBatch-1].ID, reindexBatch).Return(last, nil)
+
+	idx := newMemIndex()
+	stale := bson.NewObjectID()
+	idx.docs[stale] = &Note{ID: stale}
+	svc = NewService(repo, new(MockBus), silentLogger)
+	svc.SetSearchIndex(idx)
+
+	n, err := svc.Reindex(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, reindexBatch+1, n)
+	assert.Equal(t, 1, idx.resets)
+	assert.Len(t, idx.docs, reindexBatch+1)
+	assert.NotContains(t, idx.docs, stale)
+	repo.AssertExpectations(t)
+}
@@ -6,8 +6,10 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"note-pulse/internal/services/audit"
 	"note-pulse/internal/utils/sanitize"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -16,17 +18,46 @@ import (
 
 // Service handles notes business logic
 type Service struct {
-	repo Repository
-	bus  Bus
-	log  *slog.Logger
+	repo   Repository
+	bus    Bus
+	access WorkspaceAccess
+	search SearchIndex
+	log    *slog.Logger
+
+	renders *renderCache
+
+	embedder     Embedder
+	vectors      VectorStore
+	embedMu      sync.Mutex
+	embedPending map[bson.ObjectID]struct{}
+	embedWake    chan struct{}
+
+	attachments        AttachmentStore
+	blobs              BlobStore
+	maxAttachmentBytes int64
+
+	lease     Lease
+	notifiers []ReminderNotifier
+
+	notebooks Notebooks
+	links     LinkIndex
+	outbox    Outbox
+	recorders []EventRecorder
+	audit     AuditSink
+
+	usage  UsageRepo
+	limits Limits
 }
 
 // NewService creates a new notes service
 func NewService(repo Repository, bus Bus, log *slog.Logger) *Service {
 	return &Service{
-		repo: repo,
-		bus:  bus,
-		log:  log,
+		repo:         repo,
+		bus:          bus,
+		log:          log,
+		renders:      newRenderCache(),
+		embedPending: make(map[bson.ObjectID]struct{}),
+		embedWake:    make(chan struct{}, 1),
 	}
 }
 
@@ -35,6 +66,19 @@ type CreateNoteRequest struct {
 	Title string `json:"title" validate:"required" example:"Meeting Notes"`
 	Body  string `json:"body" example:"Remember to discuss the quarterly targets"`
 	Color string `json:"color" validate:"omitempty,hexcolor" example:"#FFD700"`
+	// Format is plain (default) or markdown
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	// Type checklist creates a checklist of Items; the default is a note
+	Type  string           `json:"type,omitempty" validate:"omitempty,oneof=note checklist" example:"checklist"`
+	Items []NewItemRequest `json:"items,omitempty" validate:"omitempty,dive"`
+	// WorkspaceID places the note in a shared workspace; empty means personal
+	WorkspaceID string `json:"workspace_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd5"`
+	// DueAt is when the note is due; RemindAt asks for a "reminder" event
+	// at that time
+	DueAt    *time.Time `json:"due_at,omitempty" example:"2026-01-09T17:00:00Z"`
+	RemindAt *time.Time `json:"remind_at,omitempty" example:"2026-01-09T09:00:00Z"`
+	// NotebookID files a personal note in one of the user's notebooks
+	NotebookID string `json:"notebook_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd9"`
 }
 
 // UpdateNoteRequest represents a note update request
@@ -42,21 +86,56 @@ type UpdateNoteRequest struct {
 	Title *string `json:"title,omitempty" validate:"omitempty,min=1" example:"Updated Meeting Notes"`
 	Body  *string `json:"body,omitempty" example:"Updated content for the meeting"`
 	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor" example:"#FF6B6B"`
+	// Format switches between plain and markdown; a markdown body keeps its
+	// layout
+	Format *string `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	// DueAt and RemindAt are RFC 3339 times; "" clears them. A new RemindAt
+	// re-arms a reminder that already fired.
+	DueAt    *string `json:"due_at,omitempty" example:"2026-01-09T17:00:00Z"`
+	RemindAt *string `json:"remind_at,omitempty" example:"2026-01-09T09:00:00Z"`
 }
 
-// ListNotesRequest represents a list notes request
+// ListNotesRequest represents a list notes request. Saved views store it, so
+// new filters need json and bson tags too.
 type ListNotesRequest struct {
-	Limit  int    `query:"limit"  validate:"omitempty,min=1,max=100" example:"50"`
-	Cursor string `query:"cursor" validate:"omitempty" example:"683cdb8aa96ad71e8e075bd1"`
-	Anchor string `query:"anchor" validate:"omitempty" example:"683cdb8aa96ad71e8e075bd1"`
-	Span   int    `query:"span"   validate:"omitempty,min=1,max=100" example:"40"`
-	Q      string `query:"q"      validate:"omitempty,min=1,max=256" example:"meeting"`
-	Color  string `query:"color"  validate:"omitempty" example:"#FF0000"`
-	Sort   string `query:"sort"   validate:"omitempty,oneof=created_at updated_at title" example:"created_at"` // sort is case-insensitive.
-	Order  string `query:"order"  validate:"omitempty,oneof=asc desc" example:"desc"`                          // order is case-insensitive.
+	// WorkspaceID selects a shared workspace; empty or the caller's own ID
+	// select the personal workspace
+	WorkspaceID string `query:"workspace_id" json:"workspace_id,omitempty" bson:"workspace_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd5"`
+	Limit       int    `query:"limit"  json:"limit,omitempty" bson:"limit,omitempty" validate:"omitempty,min=1,max=100" example:"50"`
+	Cursor      string `query:"cursor" json:"cursor,omitempty" bson:"cursor,omitempty" validate:"omitempty" example:"683cdb8aa96ad71e8e075bd1"`
+	Anchor      string `query:"anchor" json:"anchor,omitempty" bson:"anchor,omitempty" validate:"omitempty" example:"683cdb8aa96ad71e8e075bd1"`
+	Span        int    `query:"span"   json:"span,omitempty" bson:"span,omitempty" validate:"omitempty,min=1,max=100" example:"40"`
+	Q           string `query:"q"      json:"q,omitempty" bson:"q,omitempty" validate:"omitempty,min=1,max=256" example:"meeting"`
+	Color       string `query:"color"  json:"color,omitempty" bson:"color,omitempty" validate:"omitempty" example:"#FF0000"`
+	Sort        string `query:"sort"   json:"sort,omitempty" bson:"sort,omitempty" validate:"omitempty,oneof=created_at updated_at title relevance due_at" example:"created_at"` // sort is case-insensitive; relevance needs a full-text q; due_at lists only notes with a due date.
+	Order       string `query:"order"  json:"order,omitempty" bson:"order,omitempty" validate:"omitempty,oneof=asc desc" example:"desc"`                                         // order is case-insensitive.
 	// nil   parameter was absent
 	// 0..N  parameter was supplied
-	Offset *int `query:"offset" json:"offset,omitempty" validate:"omitempty,min=0,max=50000" example:"300"`
+	Offset *int `query:"offset" json:"offset,omitempty" bson:"offset,omitempty" validate:"omitempty,min=0,max=50000" example:"300"`
+	// SimilarTo ranks notes by how similar they are to this text; it implies
+	// sort=relevance and excludes free text in Q
+	SimilarTo string `query:"similar_to" json:"similar_to,omitempty" bson:"similar_to,omitempty" validate:"omitempty,max=1024" example:"daily sync"`
+	// DueBefore keeps notes due before this RFC 3339 time
+	DueBefore string `query:"due_before" json:"due_before,omitempty" bson:"due_before,omitempty" validate:"omitempty,max=64" example:"2026-01-10T00:00:00Z"`
+	// Overdue keeps notes whose due date has passed, judged at each request
+	Overdue bool `query:"overdue" json:"overdue,omitempty" bson:"overdue,omitempty" example:"true"`
+	// BoardID keeps the notes placed on this board
+	BoardID string `query:"board_id" json:"board_id,omitempty" bson:"board_id,omitempty" validate:"omitempty,max=64" example:"planning"`
+	// Viewport "x,y,width,height" keeps the placed notes intersecting the
+	// rectangle; without a board_id it looks at the default board
+	Viewport string `query:"viewport" json:"viewport,omitempty" bson:"viewport,omitempty" validate:"omitempty,max=128" example:"0,0,1920,1080"`
+	// Notebook keeps the personal notes of a notebook ID, or of the Inbox
+	// with "inbox"; Recursive adds those of its nested notebooks
+	Notebook  string `query:"notebook" json:"notebook,omitempty" bson:"notebook,omitempty" validate:"omitempty,max=24" example:"683cdb8aa96ad71e8e075bd9"`
+	Recursive bool   `query:"recursive" json:"recursive,omitempty" bson:"recursive,omitempty" example:"true"`
+
+	// Render=html adds rendered_html to every note; saved views do not keep it
+	Render string `query:"render" json:"-" bson:"-" validate:"omitempty,oneof=html" example:"html"`
+	// Matches replace the Mongo text search of Q when a SearchIndex is set;
+	// the service fills them in
+	Matches *SearchMatches `query:"-" json:"-" bson:"-"`
+	// NotebookIDs are the notebooks Notebook covers; the service fills them in
+	NotebookIDs []bson.ObjectID `query:"-" json:"-" bson:"-"`
 }
 
 // NoteResponse represents a single note response
@@ -76,6 +155,9 @@ type ListNotesResponse struct {
 	WindowSize           int     `json:"window_size" example:"20"`
 	Offset               int     `json:"offset,omitempty" example:"300"`
 	TotalPages           int     `json:"total_pages,omitempty" example:"25"`
+	// NotebookCounts count the notes of personal listings in each notebook,
+	// with every filter but notebook applied
+	NotebookCounts []NotebookCount `json:"notebook_counts,omitempty"`
 }
 
 // ErrNoteNotFound - note not found in DB
@@ -87,6 +169,17 @@ const (
 	maxOffset    = 50_000
 )
 
+// Sort keys with their own cursor formats
+const (
+	SortTitle     = "title"
+	SortRelevance = "relevance"
+	SortDueAt     = "due_at"
+)
+
+// MinTextSearchLen is the shortest q that uses the full-text index; shorter
+// queries fall back to a substring match and cannot be sorted by relevance
+const MinTextSearchLen = 3
+
 // Direction constants for ListSide
 const (
 	DirectionBefore = "before"
@@ -95,26 +188,74 @@ const (
 
 // Create creates a new note
 func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateNoteRequest) (*NoteResponse, error) {
+	workspaceID, err := s.resolveWorkspace(ctx, userID, req.WorkspaceID, true)
+	if err != nil {
+		return nil, workspaceError(err, ErrCreateNote)
+	}
+
+	notebookID, err := s.resolveNotebook(ctx, userID, workspaceID, req.NotebookID, ErrCreateNote)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ChecklistItem
+	switch {
+	case req.Type == TypeChecklist:
+		if items, err = newItems(req.Items); err != nil {
+			return nil, err
+		}
+	case len(req.Items) > 0:
+		return nil, ErrBadRequest
+	}
+
 	now := time.Now()
 	note := &Note{
-		ID:        bson.NewObjectID(),
-		UserID:    userID,
-		Title:     sanitize.Clean(req.Title),
-		Body:      sanitize.Clean(req.Body),
-		Color:     req.Color,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:          bson.NewObjectID(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Title:       sanitize.Clean(req.Title),
+		Body:        cleanBody(req.Body, req.Format),
+		Color:       req.Color,
+		Format:      storedFormat(req.Format),
+		Type:        storedType(req.Type),
+		Items:       items,
+		DueAt:       utcTime(req.DueAt),
+		RemindAt:    utcTime(req.RemindAt),
+		NotebookID:  notebookID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	if err := s.repo.Create(ctx, note); err != nil {
-		s.log.Error(ErrCreateNote.Error(), "error", err, "user_id", userID.Hex())
-		return nil, ErrCreateNote
+	return s.insert(ctx, note)
+}
+
+// insert stores a new note, indexes it and tells its clients
+func (s *Service) insert(ctx context.Context, note *Note) (*NoteResponse, error) {
+	if err := s.checkSize(&note.Title, &note.Body); err != nil {
+		return nil, err
+	}
+	usage := noteUsage(note)
+	if err := s.reserve(ctx, note.UserID, usage); err != nil {
+		return nil, err
 	}
 
-	s.bus.Broadcast(ctx, NoteEvent{
-		Type: "created",
-		Note: note,
+	events, err := s.commitNote(ctx, "created", func(ctx context.Context) (*Note, error) {
+		return note, s.repo.Create(ctx, note)
 	})
+	if err != nil {
+		s.release(ctx, note.UserID, usage)
+		s.log.Error(ErrCreateNote.Error(), "error", err, "user_id", note.UserID.Hex())
+		return nil, ErrCreateNote
+	}
+	s.indexNote(ctx, note)
+	s.scheduleEmbed(note.ID)
+	prepareChecklists(note)
+
+	s.publish(ctx, events...)
+	s.linked(ctx, note)
+	if s.audit != nil {
+		s.record(ctx, audit.ActionNoteCreate, note.UserID, note.ID, nil, auditSummary(note))
+	}
 
 	return &NoteResponse{Note: note}, nil
 }
@@ -153,6 +294,57 @@ func (s *Service) validateListRequest(req *ListNotesRequest) error {
 		return ErrBadRequest
 	}
 
+	query, err := ParseQuery(req.Q)
+	if err != nil {
+		s.log.Warn("invalid search query", "q", req.Q, "error", err)
+		return err
+	}
+
+	if req.DueBefore != "" {
+		if _, err := time.Parse(time.RFC3339, req.DueBefore); err != nil {
+			s.log.Warn("invalid due_before", "due_before", req.DueBefore, "error", err)
+			return ErrBadRequest
+		}
+	}
+
+	if req.Notebook != "" && req.Notebook != NotebookInbox {
+		if _, err := bson.ObjectIDFromHex(req.Notebook); err != nil {
+			s.log.Warn("invalid notebook", "notebook", req.Notebook)
+			return ErrBadRequest
+		}
+	}
+
+	if req.Viewport != "" {
+		if _, err := ParseViewport(req.Viewport); err != nil {
+			s.log.Warn("invalid viewport", "viewport", req.Viewport)
+			return err
+		}
+	}
+
+	// similar_to ranks by similarity instead of by text score
+	if req.SimilarTo != "" {
+		if req.Sort == "" {
+			req.Sort = SortRelevance
+		}
+		if req.Sort != SortRelevance || query.Text != "" {
+			s.log.Warn("similar_to needs relevance sort and no free text", "sort", req.Sort, "q", req.Q)
+			return ErrBadRequest
+		}
+	}
+
+	// Relevance needs a text or similarity score, and has no stable absolute
+	// position to anchor on
+	if req.Sort == SortRelevance {
+		if len(query.Text) < MinTextSearchLen && req.SimilarTo == "" {
+			s.log.Warn("relevance sort requires a full-text query", "q", req.Q)
+			return ErrBadRequest
+		}
+		if req.Anchor != "" {
+			s.log.Warn("anchor cannot be used with relevance sort", "anchor", req.Anchor)
+			return ErrBadRequest
+		}
+	}
+
 	// Validate that anchor and cursor cannot be used together
 	if req.Anchor != "" && req.Cursor != "" {
 		s.log.Warn("anchor and cursor cannot be used together", "anchor", req.Anchor, "cursor", req.Cursor)
@@ -182,18 +374,21 @@ func (s *Service) setListRequestDefaults(req *ListNotesRequest) {
 
 // validateCursor validates the cursor format based on sort type
 func (s *Service) validateCursor(cursor, sort string) error {
-	if sort == "title" {
+	var err error
+	switch sort {
+	case SortTitle:
 		// Validate composite cursor format
-		_, err := DecodeCompositeCursor(cursor)
-		if err != nil {
-			return ErrInvalidCursor
-		}
-	} else {
+		_, err = DecodeCompositeCursor(cursor)
+	case SortRelevance:
+		_, err = DecodeScoreCursor(cursor)
+	case SortDueAt:
+		_, err = DecodeDueCursor(cursor)
+	default:
 		// Validate ObjectID cursor format
-		_, err := bson.ObjectIDFromHex(cursor)
-		if err != nil {
-			return ErrInvalidCursor
-		}
+		_, err = bson.ObjectIDFromHex(cursor)
+	}
+	if err != nil {
+		return ErrInvalidCursor
 	}
 	return nil
 }
@@ -203,14 +398,7 @@ func (s *Service) generateNextCursor(notes []*Note, sort string) string {
 	if len(notes) == 0 {
 		return ""
 	}
-
-	last := notes[len(notes)-1]
-	if sort == "title" {
-		// Use composite cursor for title sorting
-		return EncodeCompositeCursor(last.Title, last.ID)
-	}
-	// Use simple ObjectID cursor for other sorts
-	return last.ID.Hex()
+	return noteCursor(notes[len(notes)-1], sort)
 }
 
 // generatePrevCursor generates the previous cursor for pagination
@@ -218,12 +406,7 @@ func (s *Service) generatePrevCursor(notes []*Note, sort string) string {
 	if len(notes) == 0 {
 		return ""
 	}
-
-	first := notes[0]
-	if sort == "title" {
-		return EncodeCompositeCursor(first.Title, first.ID)
-	}
-	return first.ID.Hex()
+	return noteCursor(notes[0], sort)
 }
 
 // reverse reverses a slice of notes in place and returns it for convenience.
@@ -243,6 +426,79 @@ func (s *Service) List(ctx context.Context, userID bson.ObjectID, req ListNotesR
 
 	s.setListRequestDefaults(&req)
 
+	workspaceID, err := s.resolveWorkspace(ctx, userID, req.WorkspaceID, false)
+	if err != nil {
+		return nil, workspaceError(err, ErrListNotes)
+	}
+	// The repository reads an empty WorkspaceID as the personal workspace
+	req.WorkspaceID = ""
+	if workspaceID != nil {
+		req.WorkspaceID = workspaceID.Hex()
+	}
+	if err := s.resolveNotebookFilter(ctx, userID, workspaceID, &req); err != nil {
+		return nil, err
+	}
+	if err := s.resolveSearch(ctx, userID, workspaceID, &req); err != nil {
+		return nil, err
+	}
+	if err := s.resolveSimilar(ctx, userID, workspaceID, &req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.paginate(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID == nil {
+		resp.NotebookCounts = s.notebookCounts(ctx, userID, req)
+	}
+
+	prepareChecklists(resp.Notes...)
+	if req.Q != "" {
+		highlightNotes(resp.Notes, req.Q)
+	}
+	if req.Render == RenderHTML {
+		s.renderNotes(resp.Notes)
+	}
+	return resp, nil
+}
+
+// Count returns how many notes match the filters of req, ignoring its
+// pagination. Saved views use it for their live counts.
+func (s *Service) Count(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) (int64, error) {
+	req.Cursor, req.Anchor, req.Offset = "", "", nil
+	if err := s.validateListRequest(&req); err != nil {
+		return 0, err
+	}
+
+	workspaceID, err := s.resolveWorkspace(ctx, userID, req.WorkspaceID, false)
+	if err != nil {
+		return 0, workspaceError(err, ErrListNotes)
+	}
+	req.WorkspaceID = ""
+	if workspaceID != nil {
+		req.WorkspaceID = workspaceID.Hex()
+	}
+	if err := s.resolveNotebookFilter(ctx, userID, workspaceID, &req); err != nil {
+		return 0, err
+	}
+	if err := s.resolveSearch(ctx, userID, workspaceID, &req); err != nil {
+		return 0, err
+	}
+	if err := s.resolveSimilar(ctx, userID, workspaceID, &req); err != nil {
+		return 0, err
+	}
+
+	total, _, err := s.repo.GetCounts(ctx, userID, req)
+	if err != nil {
+		s.log.Error(ErrListNotes.Error(), "error", err, "user_id", userID.Hex())
+		return 0, ErrListNotes
+	}
+	return total, nil
+}
+
+// paginate picks the pagination mode the request asks for
+func (s *Service) paginate(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) (*ListNotesResponse, error) {
 	// If offset is provided (not nil) and no cursor/anchor, use offset-based pagination
 	if req.Offset != nil && req.Cursor == "" && req.Anchor == "" {
 		return s.offsetList(ctx, userID, req)
@@ -547,28 +803,91 @@ func (s *Service) setAnchorIndex(response *ListNotesResponse, anchorIndex int64)
 	}
 }
 
-// sanitizedUpdateNote creates an UpdateNote with sanitized title and body
-func sanitizedUpdateNote(req UpdateNoteRequest) UpdateNote {
-	patch := UpdateNote(req)
+// cleanBody sanitizes a body for its format
+func cleanBody(body, format string) string {
+	if format == FormatMarkdown {
+		return sanitize.CleanMarkdown(body)
+	}
+	return sanitize.Clean(body)
+}
+
+// utcTime returns t in UTC, or nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// parseUpdateTime parses an RFC 3339 time of an update; "" becomes the zero
+// time, which clears the field
+func parseUpdateTime(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value == "" {
+		return &time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return utcTime(&t), nil
+}
+
+// sanitizedUpdateNote creates an UpdateNote with sanitized title and body.
+// format is the one the note has after the update.
+func sanitizedUpdateNote(req UpdateNoteRequest, format string) UpdateNote {
+	patch := UpdateNote{Title: req.Title, Body: req.Body, Color: req.Color, Format: req.Format}
 
 	if patch.Title != nil {
 		sanitized := sanitize.Clean(*patch.Title)
 		patch.Title = &sanitized
 	}
 	if patch.Body != nil {
-		sanitized := sanitize.Clean(*patch.Body)
+		sanitized := cleanBody(*patch.Body, format)
 		patch.Body = &sanitized
 	}
+	if patch.Format != nil {
+		stored := storedFormat(*patch.Format)
+		patch.Format = &stored
+	}
 
 	return patch
 }
 
 // Update updates a note belonging to the user
 func (s *Service) Update(ctx context.Context, userID, noteID bson.ObjectID, req UpdateNoteRequest) (*NoteResponse, error) {
-	patch := sanitizedUpdateNote(req)
+	dueAt, err := parseUpdateTime(req.DueAt)
+	if err != nil {
+		return nil, err
+	}
+	remindAt, err := parseUpdateTime(req.RemindAt)
+	if err != nil {
+		return nil, err
+	}
 
-	updatedNote, err := s.repo.Update(ctx, userID, noteID, patch)
+	ownerID, format, err := s.updateTarget(ctx, userID, noteID, req)
 	if err != nil {
+		return nil, s.noteAccessError(err, ErrUpdateNote, userID, noteID)
+	}
+	patch := sanitizedUpdateNote(req, format)
+	patch.DueAt, patch.RemindAt = dueAt, remindAt
+	if err := s.checkSize(patch.Title, patch.Body); err != nil {
+		return nil, err
+	}
+	before := s.priorNote(ctx, noteID)
+	growth := updateUsage(before, patch)
+	if err := s.reserve(ctx, ownerID, growth); err != nil {
+		return nil, err
+	}
+
+	events, err := s.commitNote(ctx, "updated", func(ctx context.Context) (*Note, error) {
+		return s.repo.Update(ctx, ownerID, noteID, patch)
+	})
+	if err != nil {
+		s.release(ctx, ownerID, growth)
 		if errors.Is(err, ErrNoteNotFound) {
 			s.log.Info("note not found for update", "user_id", userID.Hex(), "note_id", noteID.Hex())
 			return nil, ErrNoteNotFound
@@ -576,18 +895,55 @@ func (s *Service) Update(ctx context.Context, userID, noteID bson.ObjectID, req
 		s.log.Error(ErrUpdateNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
 		return nil, ErrUpdateNote
 	}
+	updatedNote := events[0].Note
+	s.indexNote(ctx, updatedNote)
+	if patch.Title != nil || patch.Body != nil {
+		s.indexLinks(ctx, updatedNote)
+		s.scheduleEmbed(noteID)
+	}
+	if patch.Title != nil {
+		s.retitled(ctx, updatedNote)
+	}
+	prepareChecklists(updatedNote)
 
-	s.bus.Broadcast(ctx, NoteEvent{
-		Type: "updated",
-		Note: updatedNote,
-	})
+	s.publish(ctx, events...)
+	if s.audit != nil {
+		changedBefore, changedAfter := audit.Diff(auditSummary(before), auditSummary(updatedNote))
+		s.record(ctx, audit.ActionNoteUpdate, userID, noteID, changedBefore, changedAfter)
+	}
 
 	return &NoteResponse{Note: updatedNote}, nil
 }
 
+// updateTarget returns the author of a note userID may change and the format
+// its new body is cleaned for. A body sent without a format needs the note
+// loaded to learn it.
+func (s *Service) updateTarget(ctx context.Context, userID, noteID bson.ObjectID, req UpdateNoteRequest) (bson.ObjectID, string, error) {
+	if req.Body == nil || req.Format != nil {
+		ownerID, _, err := s.noteOwner(ctx, userID, noteID)
+		if req.Format == nil {
+			return ownerID, "", err
+		}
+		return ownerID, *req.Format, err
+	}
+
+	note, err := s.accessibleNote(ctx, userID, noteID, true)
+	if err != nil {
+		return bson.ObjectID{}, "", err
+	}
+	return note.UserID, note.Format, nil
+}
+
 // Delete deletes a note belonging to the user
 func (s *Service) Delete(ctx context.Context, userID, noteID bson.ObjectID) error {
-	if err := s.repo.Delete(ctx, userID, noteID); err != nil {
+	ownerID, workspaceID, err := s.noteOwner(ctx, userID, noteID)
+	if err != nil {
+		return s.noteAccessError(err, ErrDeleteNote, userID, noteID)
+	}
+
+	before := s.priorNote(ctx, noteID)
+	events, err := s.deleteNote(ctx, ownerID, noteID, workspaceID)
+	if err != nil {
 		if errors.Is(err, ErrNoteNotFound) {
 			s.log.Info("note not found for delete", "user_id", userID.Hex(), "note_id", noteID.Hex())
 			return ErrNoteNotFound
@@ -595,17 +951,129 @@ func (s *Service) Delete(ctx context.Context, userID, noteID bson.ObjectID) erro
 		s.log.Error(ErrDeleteNote.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
 		return ErrDeleteNote
 	}
-
-	// Broadcast deletion event with minimal note data
-	deletedNote := &Note{
-		ID:     noteID,
-		UserID: userID,
+	s.deleted(ctx, noteID, events)
+	if before != nil {
+		s.release(ctx, ownerID, noteUsage(before))
+	} else {
+		s.recount(ctx, ownerID)
+	}
+	if s.audit != nil {
+		s.record(ctx, audit.ActionNoteDelete, userID, noteID, auditSummary(before), nil)
 	}
+	return nil
+}
 
-	s.bus.Broadcast(ctx, NoteEvent{
-		Type: "deleted",
-		Note: deletedNote,
+// deleteNote deletes a note and commits its "deleted" event, which carries
+// minimal note data
+func (s *Service) deleteNote(ctx context.Context, ownerID, noteID bson.ObjectID, workspaceID *bson.ObjectID) ([]NoteEvent, error) {
+	return s.commitNote(ctx, "deleted", func(ctx context.Context) (*Note, error) {
+		if err := s.repo.Delete(ctx, ownerID, noteID); err != nil {
+			return nil, err
+		}
+		return &Note{ID: noteID, UserID: ownerID, WorkspaceID: workspaceID}, nil
 	})
+}
+
+// deleted cleans up after a deleted note and publishes its events
+func (s *Service) deleted(ctx context.Context, noteID bson.ObjectID, events []NoteEvent) {
+	s.unindexNote(ctx, noteID)
+	s.unlinkNote(ctx, noteID)
+	s.removeVector(ctx, noteID)
+	s.renders.forget(noteID)
+	s.removeAttachments(ctx, noteID)
+
+	s.publish(ctx, events...)
+}
+
+// noteOwner returns the author and workspace of a note userID may change.
+// Without shared workspaces every note is personal, so the caller is the
+// author and no lookup is needed.
+func (s *Service) noteOwner(ctx context.Context, userID, noteID bson.ObjectID) (bson.ObjectID, *bson.ObjectID, error) {
+	if s.access == nil {
+		return userID, nil, nil
+	}
+
+	note, err := s.writableNote(ctx, userID, noteID)
+	if err != nil {
+		return bson.ObjectID{}, nil, err
+	}
+	return note.UserID, note.WorkspaceID, nil
+}
+
+// priorNote loads a note about to change, for the before summary of its
+// audit entry and the usage it frees or grows by. It returns nil when
+// neither is kept or the load fails.
+func (s *Service) priorNote(ctx context.Context, noteID bson.ObjectID) *Note {
+	if s.audit == nil && s.usage == nil {
+		return nil
+	}
+	note, err := s.repo.FindByID(ctx, noteID)
+	if err != nil {
+		s.log.Warn("failed to load note before change", "error", err, "note_id", noteID.Hex())
+		return nil
+	}
+	return note
+}
+
+// noteAccessError logs and maps a noteOwner failure
+func (s *Service) noteAccessError(err, fallback error, userID, noteID bson.ObjectID) error {
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		s.log.Info("note not found or not accessible", "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return ErrNoteNotFound
+	case errors.Is(err, ErrWorkspaceReadOnly):
+		s.log.Info("read-only workspace member tried to change a note", "user_id", userID.Hex(), "note_id", noteID.Hex())
+		return ErrWorkspaceReadOnly
+	}
+	s.log.Error(fallback.Error(), "error", err, "user_id", userID.Hex(), "note_id", noteID.Hex())
+	return fallback
+}
+
+// workspaceError keeps the access errors callers map to 4xx and folds
+// everything else into fallback
+func workspaceError(err, fallback error) error {
+	if errors.Is(err, ErrBadRequest) || errors.Is(err, ErrWorkspaceNotFound) || errors.Is(err, ErrWorkspaceReadOnly) {
+		return err
+	}
+	return fallback
+}
+
+// PurgeUser deletes every personal note of a user, and its text from the
+// search index. It is registered with the
+// auth service so account deletion cascades to notes; notes the user wrote in
+// shared workspaces belong to the workspace and stay. No events are broadcast:
+// the user's connections are closed once the purge completes.
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	deleted, err := s.repo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrPurgeNotes.Error(), "error", err, "user_id", userID.Hex())
+		return ErrPurgeNotes
+	}
+	if s.search != nil {
+		if err := s.search.RemoveUser(ctx, userID); err != nil {
+			s.log.Error(ErrPurgeNotes.Error(), "error", err, "user_id", userID.Hex())
+			return ErrPurgeNotes
+		}
+	}
+	if s.vectors != nil {
+		if _, err := s.vectors.DeleteAllForUser(ctx, userID); err != nil {
+			s.log.Error(ErrPurgeNotes.Error(), "error", err, "user_id", userID.Hex())
+			return ErrPurgeNotes
+		}
+	}
+	if s.links != nil {
+		if _, err := s.links.DeleteAllForUser(ctx, userID); err != nil {
+			s.log.Error(ErrPurgeNotes.Error(), "error", err, "user_id", userID.Hex())
+			return ErrPurgeNotes
+		}
+	}
+	if s.usage != nil {
+		if err := s.usage.Delete(ctx, userID); err != nil {
+			s.log.Error(ErrPurgeNotes.Error(), "error", err, "user_id", userID.Hex())
+			return ErrPurgeNotes
+		}
+	}
 
+	s.log.Info("purged notes of deleted account", "user_id", userID.Hex(), "deleted", deleted)
 	return nil
 }
@@ -37,6 +37,14 @@ func (m *MockNotesRepo) Create(ctx context.Context, note *Note) error {
 	return args.Error(0)
 }
 
+func (m *MockNotesRepo) FindByID(ctx context.Context, noteID bson.ObjectID) (*Note, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
 func (m *MockNotesRepo) List(ctx context.Context, userID bson.ObjectID, filter ListNotesRequest, skip int) ([]*Note, int64, int64, error) {
 	args := m.Called(ctx, userID, filter, skip)
 	if args.Get(0) == nil {
@@ -58,6 +66,48 @@ func (m *MockNotesRepo) Delete(ctx context.Context, userID, noteID bson.ObjectID
 	return args.Error(0)
 }
 
+func (m *MockNotesRepo) DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotesRepo) Scan(ctx context.Context, after bson.ObjectID, limit int) ([]*Note, error) {
+	args := m.Called(ctx, after, limit)
+	return args.Get(0).([]*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) AddItem(ctx context.Context, noteID bson.ObjectID, item ChecklistItem, position int) (*Note, error) {
+	args := m.Called(ctx, noteID, item, position)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) UpdateItem(ctx context.Context, noteID, itemID bson.ObjectID, patch UpdateItem) (*Note, error) {
+	args := m.Called(ctx, noteID, itemID, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) DeleteItem(ctx context.Context, noteID, itemID bson.ObjectID) (*Note, error) {
+	args := m.Called(ctx, noteID, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) ReorderItems(ctx context.Context, noteID bson.ObjectID, itemIDs []bson.ObjectID) (*Note, error) {
+	args := m.Called(ctx, noteID, itemIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
 func (m *MockNotesRepo) FindOne(ctx context.Context, userID bson.ObjectID, req ListNotesRequest, anchor string) (*Note, error) {
 	args := m.Called(ctx, userID, req, anchor)
 	if args.Get(0) == nil {
@@ -84,6 +134,67 @@ func (m *MockNotesRepo) GetCounts(ctx context.Context, userID bson.ObjectID, req
 	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockNotesRepo) DueReminders(ctx context.Context, now time.Time, limit int) ([]*Note, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) MarkReminded(ctx context.Context, noteID bson.ObjectID, remindAt, at time.Time) (*Note, error) {
+	args := m.Called(ctx, noteID, remindAt, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) SetPosition(ctx context.Context, p Placement) (*Note, error) {
+	args := m.Called(ctx, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) Arrange(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, placements []Placement) ([]*Note, error) {
+	args := m.Called(ctx, userID, workspaceID, placements)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) SetNotebook(ctx context.Context, noteID bson.ObjectID, notebookID *bson.ObjectID) (*Note, error) {
+	args := m.Called(ctx, noteID, notebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) ClearNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID) (int64, error) {
+	args := m.Called(ctx, userID, notebookIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotesRepo) InNotebooks(ctx context.Context, userID bson.ObjectID, notebookIDs []bson.ObjectID, limit int) ([]*Note, error) {
+	args := m.Called(ctx, userID, notebookIDs, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Note), args.Error(1)
+}
+
+func (m *MockNotesRepo) NotebookCounts(ctx context.Context, userID bson.ObjectID, req ListNotesRequest) ([]NotebookCount, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]NotebookCount), args.Error(1)
+}
+
 // MockBus is a mock implementation of Bus
 type MockBus struct {
 	mock.Mock
@@ -718,6 +829,7 @@ func TestServiceUpdate(t *testing.T) {
 				Color: &color,
 			},
 			setup: func(repo *MockNotesRepo, bus *MockBus) {
+				repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: userID}, nil)
 				repo.On("Update", mock.Anything, userID, noteID, mock.AnythingOfType(UpdateNoteMsg)).Return(updatedNote, nil)
 				bus.On("Broadcast", mock.Anything, mock.MatchedBy(func(ev NoteEvent) bool {
 					return ev.Type == "updated"
@@ -1013,6 +1125,7 @@ func TestServiceHTMLSanitization(t *testing.T) {
 					UpdatedAt: now,
 				}
 
+				repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: userID}, nil)
 				repo.On("Update", mock.Anything, userID, noteID, mock.AnythingOfType(UpdateNoteMsg)).Run(func(args mock.Arguments) {
 					capturedPatch = args.Get(3).(UpdateNote)
 				}).Return(mockUpdatedNote, nil)
@@ -1046,3 +1159,22 @@ func TestServiceHTMLSanitization(t *testing.T) {
 // The pagination bug fixes are validated by E2E tests since they require
 // complex integration behavior that's difficult to mock properly.
 // See test/pagination_bugs_e2e_test.go for comprehensive validation.
+
+func TestServiceCountIgnoresPagination(t *testing.T) {
+	repo := new(MockNotesRepo)
+	svc := NewService(repo, new(MockBus), silentLogger)
+	userID := bson.NewObjectID()
+	offset := 5
+
+	repo.On("GetCounts", mock.Anything, userID, ListNotesRequest{Q: "urgent", Sort: "title", Limit: 10}).
+		Return(int64(7), int64(20), nil)
+
+	n, err := svc.Count(context.Background(), userID, ListNotesRequest{
+		Q: "urgent", Sort: "Title", Limit: 10, Cursor: "stale", Offset: &offset,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+
+	_, err = svc.Count(context.Background(), userID, ListNotesRequest{Q: "updated:never"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
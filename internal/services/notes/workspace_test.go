@@ -0,0 +1,132 @@
+package notes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeAccess grants each user a fixed set of rights per workspace
+type fakeAccess map[bson.ObjectID]map[bson.ObjectID]string
+
+func (f fakeAccess) Access(_ context.Context, workspaceID, userID bson.ObjectID) (bool, bool, error) {
+	switch f[workspaceID][userID] {
+	case "write":
+		return true, true, nil
+	case "read":
+		return true, false, nil
+	}
+	return false, false, nil
+}
+
+func TestServiceWorkspaceAccess(t *testing.T) {
+	ctx := context.Background()
+	writer, viewer, outsider := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	access := fakeAccess{workspaceID: {writer: "write", viewer: "read"}}
+
+	newService := func() (*Service, *MockNotesRepo, *MockBus) {
+		repo, bus := new(MockNotesRepo), new(MockBus)
+		svc := NewService(repo, bus, silentLogger)
+		svc.SetWorkspaceAccess(access)
+		return svc, repo, bus
+	}
+
+	t.Run("member creates note in workspace", func(t *testing.T) {
+		svc, repo, bus := newService()
+		repo.On("Create", mock.Anything, mockNote).Return(nil)
+		bus.On("Broadcast", mock.Anything, mock.Anything).Return()
+
+		resp, err := svc.Create(ctx, writer, CreateNoteRequest{Title: "Team", WorkspaceID: workspaceID.Hex()})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Note.WorkspaceID)
+		assert.Equal(t, workspaceID, *resp.Note.WorkspaceID)
+	})
+
+	t.Run("own user ID selects personal workspace", func(t *testing.T) {
+		svc, repo, bus := newService()
+		repo.On("Create", mock.Anything, mockNote).Return(nil)
+		bus.On("Broadcast", mock.Anything, mock.Anything).Return()
+
+		resp, err := svc.Create(ctx, outsider, CreateNoteRequest{Title: "Mine", WorkspaceID: outsider.Hex()})
+		require.NoError(t, err)
+		assert.Nil(t, resp.Note.WorkspaceID)
+	})
+
+	t.Run("viewer cannot create", func(t *testing.T) {
+		svc, repo, _ := newService()
+
+		_, err := svc.Create(ctx, viewer, CreateNoteRequest{Title: "Nope", WorkspaceID: workspaceID.Hex()})
+		assert.ErrorIs(t, err, ErrWorkspaceReadOnly)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("outsider cannot list", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.List(ctx, outsider, ListNotesRequest{WorkspaceID: workspaceID.Hex()})
+		assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+	})
+
+	t.Run("viewer lists workspace notes", func(t *testing.T) {
+		svc, repo, _ := newService()
+		repo.On("List", mock.Anything, viewer, mock.MatchedBy(func(req ListNotesRequest) bool {
+			return req.WorkspaceID == workspaceID.Hex()
+		}), -1).Return([]*Note{}, int64(0), int64(0), nil)
+
+		_, err := svc.List(ctx, viewer, ListNotesRequest{WorkspaceID: workspaceID.Hex()})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("member updates note written by someone else", func(t *testing.T) {
+		svc, repo, bus := newService()
+		noteID := bson.NewObjectID()
+		author := bson.NewObjectID()
+		note := &Note{ID: noteID, UserID: author, WorkspaceID: &workspaceID}
+		repo.On("FindByID", mock.Anything, noteID).Return(note, nil)
+		repo.On("Update", mock.Anything, author, noteID, mock.AnythingOfType(UpdateNoteMsg)).Return(note, nil)
+		bus.On("Broadcast", mock.Anything, mock.Anything).Return()
+
+		title := "Edited"
+		_, err := svc.Update(ctx, writer, noteID, UpdateNoteRequest{Title: &title})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("viewer cannot delete", func(t *testing.T) {
+		svc, repo, _ := newService()
+		noteID := bson.NewObjectID()
+		repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: writer, WorkspaceID: &workspaceID}, nil)
+
+		err := svc.Delete(ctx, viewer, noteID)
+		assert.ErrorIs(t, err, ErrWorkspaceReadOnly)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("personal notes of other users are hidden", func(t *testing.T) {
+		svc, repo, _ := newService()
+		noteID := bson.NewObjectID()
+		repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: writer}, nil)
+
+		err := svc.Delete(ctx, outsider, noteID)
+		assert.ErrorIs(t, err, ErrNoteNotFound)
+	})
+
+	t.Run("delete event carries the workspace", func(t *testing.T) {
+		svc, repo, bus := newService()
+		noteID := bson.NewObjectID()
+		repo.On("FindByID", mock.Anything, noteID).Return(&Note{ID: noteID, UserID: viewer, WorkspaceID: &workspaceID}, nil)
+		repo.On("Delete", mock.Anything, viewer, noteID).Return(nil)
+		bus.On("Broadcast", mock.Anything, mock.MatchedBy(func(ev NoteEvent) bool {
+			return ev.Type == "deleted" && ev.Note.WorkspaceID != nil && *ev.Note.WorkspaceID == workspaceID
+		})).Return()
+
+		require.NoError(t, svc.Delete(ctx, writer, noteID))
+		bus.AssertExpectations(t)
+	})
+}
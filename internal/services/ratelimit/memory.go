@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryRepository drops expired counters
+const sweepInterval = time.Minute
+
+// MemoryRepository keeps counters in the process. Each replica counts on
+// its own, so limits multiply with the number of replicas.
+type MemoryRepository struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextSweep time.Time
+}
+
+type counter struct {
+	n         int64
+	expiresAt time.Time
+}
+
+// NewMemoryRepository creates an empty in-process counter store
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{counters: make(map[string]*counter)}
+}
+
+// Incr adds one to the counter named key
+func (r *MemoryRepository) Incr(_ context.Context, key string, now time.Time, window time.Duration) (int64, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.After(r.nextSweep) {
+		for k, c := range r.counters {
+			if !now.Before(c.expiresAt) {
+				delete(r.counters, k)
+			}
+		}
+		r.nextSweep = now.Add(sweepInterval)
+	}
+
+	c, ok := r.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = &counter{expiresAt: now.Add(window)}
+		r.counters[key] = c
+	}
+	c.n++
+	return c.n, c.expiresAt, nil
+}
@@ -0,0 +1,20 @@
+package ratelimit
+
+import "time"
+
+// Policy allows Limit requests per subject in each Window. A window starts
+// with the first request of a subject and ends Window later.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the state of a subject's bucket after a request was counted
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the window ends and the bucket refills
+	Reset time.Duration
+}
@@ -0,0 +1,14 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Repository keeps request counters that expire with their window
+type Repository interface {
+	// Incr adds one to the counter named key and returns the new count with
+	// the end of its window. A counter whose window ended by now starts
+	// again at one, with a window ending window after now.
+	Incr(ctx context.Context, key string, now time.Time, window time.Duration) (int64, time.Time, error)
+}
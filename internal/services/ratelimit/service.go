@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Service counts requests against policies in fixed windows
+type Service struct {
+	repo Repository
+	log  *slog.Logger
+	now  func() time.Time
+}
+
+// NewService creates a new rate limit service counting in repo
+func NewService(repo Repository, log *slog.Logger) *Service {
+	return &Service{repo: repo, log: log, now: time.Now}
+}
+
+// Allow counts a request of subject against policy. When the counters
+// cannot be reached the request is allowed, so an outage of the store does
+// not take the API down with it.
+func (s *Service) Allow(ctx context.Context, policy Policy, subject string) Result {
+	now := s.now().UTC()
+	n, expiresAt, err := s.repo.Incr(ctx, policy.Name+":"+subject, now, policy.Window)
+	if err != nil {
+		s.log.Error("failed to count request", "error", err, "policy", policy.Name)
+		return Result{Allowed: true, Limit: policy.Limit, Remaining: policy.Limit, Reset: policy.Window}
+	}
+
+	return Result{
+		Allowed:   n <= int64(policy.Limit),
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-int(n), 0),
+		Reset:     expiresAt.Sub(now),
+	}
+}
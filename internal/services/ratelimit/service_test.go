@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type failingRepo struct{}
+
+func (failingRepo) Incr(context.Context, string, time.Time, time.Duration) (int64, time.Time, error) {
+	return 0, time.Time{}, errors.New("store down")
+}
+
+func TestServiceAllow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 45, 0, time.UTC)
+	repo := NewMemoryRepository()
+	svc := NewService(repo, silentLogger)
+	svc.now = func() time.Time { return now }
+	policy := Policy{Name: "app", Limit: 2, Window: time.Minute}
+
+	res := svc.Allow(ctx, policy, "user:a")
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Minute}, res)
+	now = now.Add(20 * time.Second)
+	res = svc.Allow(ctx, policy, "user:a")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 40*time.Second, res.Reset, "the window runs from the first request")
+	res = svc.Allow(ctx, policy, "user:a")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	assert.True(t, svc.Allow(ctx, policy, "user:b").Allowed, "subjects have their own buckets")
+	assert.True(t, svc.Allow(ctx, Policy{Name: "write", Limit: 1, Window: time.Minute}, "user:a").Allowed,
+		"policies have their own buckets")
+
+	now = now.Add(65 * time.Second)
+	res = svc.Allow(ctx, policy, "user:a")
+	assert.True(t, res.Allowed, "the next window starts empty")
+	assert.Equal(t, 1, res.Remaining)
+	assert.Equal(t, time.Minute, res.Reset)
+	assert.Len(t, repo.counters, 1, "expired counters are swept")
+}
+
+func TestServiceAllowFailsOpen(t *testing.T) {
+	svc := NewService(failingRepo{}, silentLogger)
+	res := svc.Allow(context.Background(), Policy{Name: "app", Limit: 1, Window: time.Minute}, "ip:10.0.0.1")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+}
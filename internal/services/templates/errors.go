@@ -0,0 +1,34 @@
+package templates
+
+import (
+	"errors"
+
+	"note-pulse/internal/utils/rrule"
+)
+
+// ErrTemplateNotFound is returned when a template does not exist or belongs to someone else.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrTooManyTemplates is returned when a user already has the maximum number of templates.
+var ErrTooManyTemplates = errors.New("too many templates")
+
+// ErrInvalidRecurrence is returned when the time zone of a recurrence is unknown.
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// ErrCreateTemplate is returned when template creation fails.
+var ErrCreateTemplate = errors.New("failed to create template")
+
+// ErrListTemplates is returned when templates cannot be read.
+var ErrListTemplates = errors.New("failed to list templates")
+
+// ErrUpdateTemplate is returned when a template cannot be changed.
+var ErrUpdateTemplate = errors.New("failed to update template")
+
+// ErrDeleteTemplate is returned when template deletion fails.
+var ErrDeleteTemplate = errors.New("failed to delete template")
+
+// ErrInstantiate is returned when a note cannot be made from a template.
+var ErrInstantiate = errors.New("failed to create note from template")
+
+// ErrInvalidRule is returned for recurrence rules outside the supported RRULE subset.
+var ErrInvalidRule = rrule.ErrInvalidRule
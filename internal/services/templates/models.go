@@ -0,0 +1,88 @@
+package templates
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Template is a saved note: its title and body may hold {{date}} and
+// {{week}} placeholders, filled in whenever a note is made from it
+type Template struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd8"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	Name   string        `bson:"name" json:"name" example:"Weekly retro"`
+	Title  string        `bson:"title" json:"title" example:"Retro {{week}}"`
+	Body   string        `bson:"body" json:"body" example:"Went well:\n\nTo improve:"`
+	Color  string        `bson:"color,omitempty" json:"color,omitempty" example:"#FFD700"`
+	Format string        `bson:"format,omitempty" json:"format,omitempty" example:"markdown"`
+	// WorkspaceID is where notes made from the template go; empty means the
+	// personal workspace
+	WorkspaceID string      `bson:"workspace_id,omitempty" json:"workspace_id,omitempty" example:"683cdb8aa96ad71e8e075bd5"`
+	Recurrence  *Recurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	CreatedAt   time.Time   `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt   time.Time   `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+}
+
+// Recurrence makes notes from a template on an RRULE schedule. Occurrences
+// keep the time of day of Start in Timezone.
+type Recurrence struct {
+	RRule    string    `bson:"rrule" json:"rrule" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Start    time.Time `bson:"start" json:"start" example:"2026-01-05T09:00:00Z"`
+	Timezone string    `bson:"timezone" json:"timezone" example:"Europe/Berlin"`
+	// NextAt is the next occurrence; nil once the rule has ended
+	NextAt *time.Time `bson:"next_at,omitempty" json:"next_at,omitempty" example:"2026-01-12T09:00:00Z"`
+	// LastAt is the latest occurrence that made a note
+	LastAt *time.Time `bson:"last_at,omitempty" json:"last_at,omitempty" example:"2026-01-05T09:00:00Z"`
+}
+
+// UpdateTemplate holds the fields of a template that can change. Recurrence
+// replaces the schedule; ClearRecurrence removes it.
+type UpdateTemplate struct {
+	Name            *string
+	Title           *string
+	Body            *string
+	Color           *string
+	Format          *string
+	Recurrence      *Recurrence
+	ClearRecurrence bool
+}
+
+// RecurrenceRequest sets the schedule of a template
+type RecurrenceRequest struct {
+	// RRule is an RFC 5545 rule: FREQ DAILY, WEEKLY, MONTHLY or YEARLY with
+	// INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH. "" removes the
+	// schedule.
+	RRule string `json:"rrule" validate:"max=256" example:"FREQ=WEEKLY;BYDAY=MO"`
+	// Start is the first possible occurrence and gives the time of day;
+	// defaults to now
+	Start *time.Time `json:"start,omitempty" example:"2026-01-05T09:00:00+01:00"`
+	// Timezone is an IANA zone name; defaults to UTC
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,max=64" example:"Europe/Berlin"`
+}
+
+// CreateTemplateRequest saves a template
+type CreateTemplateRequest struct {
+	Name        string             `json:"name" validate:"required,min=1,max=100" example:"Weekly retro"`
+	Title       string             `json:"title" validate:"required,min=1" example:"Retro {{week}}"`
+	Body        string             `json:"body" example:"Went well:\n\nTo improve:"`
+	Color       string             `json:"color,omitempty" validate:"omitempty,hexcolor" example:"#FFD700"`
+	Format      string             `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	WorkspaceID string             `json:"workspace_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd5"`
+	Recurrence  *RecurrenceRequest `json:"recurrence,omitempty"`
+}
+
+// UpdateTemplateRequest changes a template
+type UpdateTemplateRequest struct {
+	Name       *string            `json:"name,omitempty" validate:"omitempty,min=1,max=100" example:"Weekly retro"`
+	Title      *string            `json:"title,omitempty" validate:"omitempty,min=1" example:"Retro {{week}}"`
+	Body       *string            `json:"body,omitempty" example:"Went well:\n\nTo improve:"`
+	Color      *string            `json:"color,omitempty" validate:"omitempty,hexcolor" example:"#FFD700"`
+	Format     *string            `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	Recurrence *RecurrenceRequest `json:"recurrence,omitempty"`
+}
+
+// ListTemplatesResponse lists the caller's templates
+type ListTemplatesResponse struct {
+	Templates []*Template `json:"templates"`
+}
@@ -0,0 +1,151 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-pulse/internal/utils/rrule"
+)
+
+const (
+	// recurrenceInterval is how often RunRecurrences looks for due templates
+	recurrenceInterval = 30 * time.Second
+	// recurrenceLease names the lease that keeps one replica making notes
+	recurrenceLease = "recurrences"
+	// recurrenceLeaseTTL outlives a few sweeps
+	recurrenceLeaseTTL = 4 * recurrenceInterval
+	// recurrenceBatch bounds the templates loaded at once
+	recurrenceBatch = 100
+)
+
+// schedule builds a recurrence from req and finds its first occurrence
+// after now
+func schedule(req RecurrenceRequest, now time.Time) (*Recurrence, error) {
+	tz := req.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	start := now
+	if req.Start != nil {
+		start = *req.Start
+	}
+	r := &Recurrence{RRule: req.RRule, Start: start.UTC().Truncate(time.Second), Timezone: tz}
+
+	rule, err := r.rule()
+	if err != nil {
+		return nil, err
+	}
+	if next := rule.Next(now); !next.IsZero() {
+		next = next.UTC()
+		r.NextAt = &next
+	}
+	return r, nil
+}
+
+// rule parses the RRULE of r anchored at its start in its time zone
+func (r *Recurrence) rule() (*rrule.Rule, error) {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidRecurrence, r.Timezone)
+	}
+	return rrule.Parse(r.RRule, r.Start.In(loc))
+}
+
+// RunRecurrences makes the notes of scheduled templates until ctx is done.
+// After downtime, a template makes one note for its latest missed
+// occurrence rather than one per occurrence.
+func (s *Service) RunRecurrences(ctx context.Context) error {
+	ticker := time.NewTicker(recurrenceInterval)
+	defer ticker.Stop()
+
+	for {
+		s.fireRecurrences(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireRecurrences makes the notes of the templates due at now
+func (s *Service) fireRecurrences(ctx context.Context, now time.Time) {
+	if s.lease != nil {
+		held, err := s.lease.Acquire(ctx, recurrenceLease, recurrenceLeaseTTL)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to acquire recurrence lease", "error", err)
+			}
+			return
+		}
+		if !held {
+			return
+		}
+	}
+
+	for {
+		due, err := s.repo.Due(ctx, now, recurrenceBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to find due templates", "error", err)
+			}
+			return
+		}
+
+		fired := 0
+		for _, t := range due {
+			if s.fire(ctx, t, now) {
+				fired++
+			}
+		}
+		if len(due) < recurrenceBatch || fired == 0 {
+			return
+		}
+	}
+}
+
+// fire claims the due occurrence of t, moves its schedule past now and makes
+// the note, reporting whether the occurrence was claimed. The claim comes
+// first so that replicas never make the same note twice.
+func (s *Service) fire(ctx context.Context, t *Template, now time.Time) bool {
+	rec := t.Recurrence
+	if rec == nil || rec.NextAt == nil {
+		return false
+	}
+	from := *rec.NextAt
+
+	rule, err := rec.rule()
+	if err != nil {
+		// The rule was valid when saved; end a schedule that no longer is
+		s.log.Error("failed to parse template recurrence", "error", err, "template_id", t.ID.Hex())
+		if err := s.repo.Advance(ctx, t.ID, from, from, nil); err != nil && !errors.Is(err, ErrTemplateNotFound) {
+			s.log.Error("failed to end template recurrence", "error", err, "template_id", t.ID.Hex())
+		}
+		return false
+	}
+
+	last, next := from, rule.Next(from)
+	for !next.IsZero() && !next.After(now) {
+		last, next = next, rule.Next(next)
+	}
+	var nextAt *time.Time
+	if !next.IsZero() {
+		next = next.UTC()
+		nextAt = &next
+	}
+
+	if err := s.repo.Advance(ctx, t.ID, from, last.UTC(), nextAt); err != nil {
+		if !errors.Is(err, ErrTemplateNotFound) {
+			s.log.Error("failed to advance template recurrence", "error", err, "template_id", t.ID.Hex())
+		}
+		return false
+	}
+
+	loc, _ := time.LoadLocation(rec.Timezone)
+	if _, err := s.instantiate(ctx, t, last.In(loc)); err != nil {
+		s.log.Error("failed to create recurring note", "error", err, "user_id", t.UserID.Hex(), "template_id", t.ID.Hex())
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"context"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Repository stores templates. Lookups by ID are scoped to the owner; the
+// recurrence methods serve the scheduler and see every user.
+type Repository interface {
+	Create(ctx context.Context, t *Template) error
+	List(ctx context.Context, userID bson.ObjectID) ([]*Template, error)
+	Count(ctx context.Context, userID bson.ObjectID) (int64, error)
+	Find(ctx context.Context, userID, templateID bson.ObjectID) (*Template, error)
+	Update(ctx context.Context, userID, templateID bson.ObjectID, patch UpdateTemplate) (*Template, error)
+	Delete(ctx context.Context, userID, templateID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+
+	// Due returns up to limit templates whose next occurrence is at or
+	// before now
+	Due(ctx context.Context, now time.Time, limit int) ([]*Template, error)
+	// Advance moves a template's next occurrence from from to next, nil when
+	// the rule has ended, and records last as its latest one. Only one
+	// caller advances from a given occurrence; the others get
+	// ErrTemplateNotFound.
+	Advance(ctx context.Context, templateID bson.ObjectID, from, last time.Time, next *time.Time) error
+}
+
+// Notes creates the notes made from templates
+type Notes interface {
+	Create(ctx context.Context, userID bson.ObjectID, req notes.CreateNoteRequest) (*notes.NoteResponse, error)
+}
@@ -0,0 +1,248 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"note-pulse/internal/services/notes"
+	"note-pulse/internal/utils/sanitize"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxTemplatesPerUser bounds the templates of one user
+const maxTemplatesPerUser = 100
+
+// placeholder matches {{date}} and {{week}}, spaces allowed inside
+var placeholder = regexp.MustCompile(`\{\{\s*(date|week)\s*\}\}`)
+
+// Service manages note templates and makes notes from them, on request or on
+// their schedule
+type Service struct {
+	repo  Repository
+	notes Notes
+	lease notes.Lease
+	log   *slog.Logger
+}
+
+// NewService creates a new templates service
+func NewService(repo Repository, notes Notes, log *slog.Logger) *Service {
+	return &Service{
+		repo:  repo,
+		notes: notes,
+		log:   log,
+	}
+}
+
+// SetLease makes RunRecurrences fire only on the replica holding the lease
+func (s *Service) SetLease(lease notes.Lease) {
+	s.lease = lease
+}
+
+// List returns the user's templates
+func (s *Service) List(ctx context.Context, userID bson.ObjectID) (*ListTemplatesResponse, error) {
+	list, err := s.repo.List(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListTemplates.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListTemplates
+	}
+	return &ListTemplatesResponse{Templates: list}, nil
+}
+
+// Create saves a template
+func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateTemplateRequest) (*Template, error) {
+	now := time.Now().UTC()
+	var recurrence *Recurrence
+	if req.Recurrence != nil && req.Recurrence.RRule != "" {
+		var err error
+		if recurrence, err = schedule(*req.Recurrence, now); err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := s.repo.Count(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrCreateTemplate.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateTemplate
+	}
+	if n >= maxTemplatesPerUser {
+		return nil, ErrTooManyTemplates
+	}
+
+	t := &Template{
+		ID:          bson.NewObjectID(),
+		UserID:      userID,
+		Name:        sanitize.Clean(req.Name),
+		Title:       sanitize.Clean(req.Title),
+		Body:        cleanBody(req.Body, req.Format),
+		Color:       req.Color,
+		Format:      req.Format,
+		WorkspaceID: req.WorkspaceID,
+		Recurrence:  recurrence,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, t); err != nil {
+		s.log.Error(ErrCreateTemplate.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateTemplate
+	}
+	return t, nil
+}
+
+// Get returns one template
+func (s *Service) Get(ctx context.Context, userID, templateID bson.ObjectID) (*Template, error) {
+	return s.find(ctx, userID, templateID)
+}
+
+// Update changes a template. A new recurrence restarts the schedule; one
+// with an empty rrule removes it.
+func (s *Service) Update(ctx context.Context, userID, templateID bson.ObjectID, req UpdateTemplateRequest) (*Template, error) {
+	patch := UpdateTemplate{Color: req.Color, Format: req.Format}
+	if req.Name != nil {
+		name := sanitize.Clean(*req.Name)
+		patch.Name = &name
+	}
+	if req.Title != nil {
+		title := sanitize.Clean(*req.Title)
+		patch.Title = &title
+	}
+	if req.Recurrence != nil {
+		if req.Recurrence.RRule == "" {
+			patch.ClearRecurrence = true
+		} else {
+			recurrence, err := schedule(*req.Recurrence, time.Now().UTC())
+			if err != nil {
+				return nil, err
+			}
+			patch.Recurrence = recurrence
+		}
+	}
+	if req.Body != nil {
+		format := ""
+		if req.Format != nil {
+			format = *req.Format
+		} else {
+			current, err := s.find(ctx, userID, templateID)
+			if err != nil {
+				return nil, err
+			}
+			format = current.Format
+		}
+		body := cleanBody(*req.Body, format)
+		patch.Body = &body
+	}
+
+	t, err := s.repo.Update(ctx, userID, templateID, patch)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		s.log.Error(ErrUpdateTemplate.Error(), "error", err, "user_id", userID.Hex(), "template_id", templateID.Hex())
+		return nil, ErrUpdateTemplate
+	}
+	return t, nil
+}
+
+// Delete deletes a template and its schedule
+func (s *Service) Delete(ctx context.Context, userID, templateID bson.ObjectID) error {
+	if err := s.repo.Delete(ctx, userID, templateID); err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return ErrTemplateNotFound
+		}
+		s.log.Error(ErrDeleteTemplate.Error(), "error", err, "user_id", userID.Hex(), "template_id", templateID.Hex())
+		return ErrDeleteTemplate
+	}
+	return nil
+}
+
+// Instantiate makes a note from a template, filling its placeholders with
+// the current date in the template's time zone
+func (s *Service) Instantiate(ctx context.Context, userID, templateID bson.ObjectID) (*notes.NoteResponse, error) {
+	t, err := s.find(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if t.Recurrence != nil {
+		if loc, err := time.LoadLocation(t.Recurrence.Timezone); err == nil {
+			at = at.In(loc)
+		}
+	}
+	return s.instantiate(ctx, t, at)
+}
+
+// PurgeUser deletes every template of a user
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	deleted, err := s.repo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrDeleteTemplate.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteTemplate
+	}
+	s.log.Info("purged templates of deleted account", "user_id", userID.Hex(), "deleted", deleted)
+	return nil
+}
+
+// instantiate creates the note of t for the date at. Notes go through the
+// notes service, so they are checked, indexed and broadcast like any other.
+func (s *Service) instantiate(ctx context.Context, t *Template, at time.Time) (*notes.NoteResponse, error) {
+	resp, err := s.notes.Create(ctx, t.UserID, notes.CreateNoteRequest{
+		Title:       fill(t.Title, at),
+		Body:        fill(t.Body, at),
+		Color:       t.Color,
+		Format:      t.Format,
+		WorkspaceID: t.WorkspaceID,
+	})
+	if err != nil {
+		for _, known := range []error{
+			notes.ErrBadRequest,
+			notes.ErrWorkspaceNotFound,
+			notes.ErrWorkspaceReadOnly,
+			notes.ErrNoteTooLarge,
+			notes.ErrQuotaExceeded,
+		} {
+			if errors.Is(err, known) {
+				return nil, err
+			}
+		}
+		return nil, ErrInstantiate
+	}
+	return resp, nil
+}
+
+func (s *Service) find(ctx context.Context, userID, templateID bson.ObjectID) (*Template, error) {
+	t, err := s.repo.Find(ctx, userID, templateID)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		s.log.Error(ErrListTemplates.Error(), "error", err, "user_id", userID.Hex(), "template_id", templateID.Hex())
+		return nil, ErrListTemplates
+	}
+	return t, nil
+}
+
+// fill replaces {{date}} with the day of at (2026-01-12) and {{week}} with
+// its ISO week (2026-W03)
+func fill(text string, at time.Time) string {
+	return placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		if placeholder.FindStringSubmatch(m)[1] == "date" {
+			return at.Format("2006-01-02")
+		}
+		year, week := at.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+}
+
+// cleanBody strips markup from a template body the way notes do, keeping
+// the layout of Markdown
+func cleanBody(body, format string) string {
+	if format == notes.FormatMarkdown {
+		return sanitize.CleanMarkdown(body)
+	}
+	return sanitize.Clean(body)
+}
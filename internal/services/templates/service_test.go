@@ -0,0 +1,353 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memRepo is an in-memory Repository
+type memRepo struct {
+	mu        sync.Mutex
+	templates map[bson.ObjectID]*Template
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{templates: map[bson.ObjectID]*Template{}}
+}
+
+func (m *memRepo) Create(_ context.Context, t *Template) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *t
+	m.templates[t.ID] = &cp
+	return nil
+}
+
+func (m *memRepo) List(_ context.Context, userID bson.ObjectID) ([]*Template, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*Template{}
+	for _, t := range m.templates {
+		if t.UserID == userID {
+			cp := *t
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *memRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	list, err := m.List(ctx, userID)
+	return int64(len(list)), err
+}
+
+func (m *memRepo) Find(_ context.Context, userID, templateID bson.ObjectID) (*Template, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.templates[templateID]
+	if !ok || t.UserID != userID {
+		return nil, ErrTemplateNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (m *memRepo) Update(ctx context.Context, userID, templateID bson.ObjectID, patch UpdateTemplate) (*Template, error) {
+	if _, err := m.Find(ctx, userID, templateID); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	t := m.templates[templateID]
+	for field, value := range map[*string]*string{&t.Name: patch.Name, &t.Title: patch.Title, &t.Body: patch.Body, &t.Color: patch.Color, &t.Format: patch.Format} {
+		if value != nil {
+			*field = *value
+		}
+	}
+	if patch.Recurrence != nil {
+		t.Recurrence = patch.Recurrence
+	}
+	if patch.ClearRecurrence {
+		t.Recurrence = nil
+	}
+	m.mu.Unlock()
+	return m.Find(ctx, userID, templateID)
+}
+
+func (m *memRepo) Delete(_ context.Context, userID, templateID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.templates[templateID]
+	if !ok || t.UserID != userID {
+		return ErrTemplateNotFound
+	}
+	delete(m.templates, templateID)
+	return nil
+}
+
+func (m *memRepo) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, t := range m.templates {
+		if t.UserID == userID {
+			delete(m.templates, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memRepo) Due(_ context.Context, now time.Time, limit int) ([]*Template, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*Template
+	for _, t := range m.templates {
+		if t.Recurrence != nil && t.Recurrence.NextAt != nil && !t.Recurrence.NextAt.After(now) && len(result) < limit {
+			cp := *t
+			rec := *t.Recurrence
+			cp.Recurrence = &rec
+			result = append(result, &cp)
+		}
+	}
+	return result, nil
+}
+
+func (m *memRepo) Advance(_ context.Context, templateID bson.ObjectID, from, last time.Time, next *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.templates[templateID]
+	if !ok || t.Recurrence == nil || t.Recurrence.NextAt == nil || !t.Recurrence.NextAt.Equal(from) {
+		return ErrTemplateNotFound
+	}
+	rec := *t.Recurrence
+	rec.LastAt, rec.NextAt = &last, next
+	t.Recurrence = &rec
+	return nil
+}
+
+// fakeNotes records the notes created through it
+type fakeNotes struct {
+	mu      sync.Mutex
+	created []notes.CreateNoteRequest
+	err     error
+}
+
+func (f *fakeNotes) Create(_ context.Context, userID bson.ObjectID, req notes.CreateNoteRequest) (*notes.NoteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, req)
+	return &notes.NoteResponse{Note: &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: req.Title, Body: req.Body}}, nil
+}
+
+// fakeLease is a Lease that is held or not
+type fakeLease struct{ held bool }
+
+func (l fakeLease) Acquire(context.Context, string, time.Duration) (bool, error) {
+	return l.held, nil
+}
+
+func TestFill(t *testing.T) {
+	at := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Retro 2026-W03 (2026-01-12)", fill("Retro {{week}} ({{ date }})", at))
+	assert.Equal(t, "{{name}}", fill("{{name}}", at), "unknown placeholders stay")
+
+	// ISO weeks can belong to the previous year
+	assert.Equal(t, "2026-W53", fill("{{week}}", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	tmpl, err := svc.Create(ctx, userID, CreateTemplateRequest{
+		Name:       "Weekly retro",
+		Title:      "<b>Retro</b> {{week}}",
+		Recurrence: &RecurrenceRequest{RRule: "FREQ=WEEKLY;BYDAY=MO", Start: &start, Timezone: "Europe/Berlin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Retro {{week}}", tmpl.Title)
+	require.NotNil(t, tmpl.Recurrence)
+	require.NotNil(t, tmpl.Recurrence.NextAt)
+	assert.True(t, tmpl.Recurrence.NextAt.After(time.Now()))
+	assert.Equal(t, time.Monday, tmpl.Recurrence.NextAt.In(mustLoad(t, "Europe/Berlin")).Weekday())
+
+	_, err = svc.Create(ctx, userID, CreateTemplateRequest{Name: "x", Title: "x", Recurrence: &RecurrenceRequest{RRule: "FREQ=HOURLY"}})
+	assert.ErrorIs(t, err, ErrInvalidRule)
+	_, err = svc.Create(ctx, userID, CreateTemplateRequest{Name: "x", Title: "x", Recurrence: &RecurrenceRequest{RRule: "FREQ=DAILY", Timezone: "Mars/Olympus"}})
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+
+	plain, err := svc.Create(ctx, userID, CreateTemplateRequest{Name: "Plain", Title: "Plain", Recurrence: &RecurrenceRequest{}})
+	require.NoError(t, err)
+	assert.Nil(t, plain.Recurrence, "an empty rrule means no schedule")
+}
+
+func TestServiceCreateLimit(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	for range maxTemplatesPerUser {
+		_, err := svc.Create(ctx, userID, CreateTemplateRequest{Name: "t", Title: "t"})
+		require.NoError(t, err)
+	}
+	_, err := svc.Create(ctx, userID, CreateTemplateRequest{Name: "t", Title: "t"})
+	assert.ErrorIs(t, err, ErrTooManyTemplates)
+}
+
+func TestServiceUpdate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc := NewService(newMemRepo(), &fakeNotes{}, silentLogger)
+
+	tmpl, err := svc.Create(ctx, userID, CreateTemplateRequest{
+		Name: "Standup", Title: "Standup", Format: notes.FormatMarkdown,
+		Recurrence: &RecurrenceRequest{RRule: "FREQ=DAILY"},
+	})
+	require.NoError(t, err)
+
+	body := "- done\n  - <i>nested</i>"
+	updated, err := svc.Update(ctx, userID, tmpl.ID, UpdateTemplateRequest{Body: &body, Recurrence: &RecurrenceRequest{}})
+	require.NoError(t, err)
+	assert.Equal(t, "- done\n  - nested", updated.Body, "a markdown body keeps its layout")
+	assert.Nil(t, updated.Recurrence)
+
+	_, err = svc.Update(ctx, bson.NewObjectID(), tmpl.ID, UpdateTemplateRequest{Body: &body})
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestServiceInstantiate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	created := &fakeNotes{}
+	svc := NewService(newMemRepo(), created, silentLogger)
+
+	workspaceID := bson.NewObjectID().Hex()
+	tmpl, err := svc.Create(ctx, userID, CreateTemplateRequest{
+		Name: "Retro", Title: "Retro {{date}}", Body: "Week {{week}}", Color: "#FFD700", WorkspaceID: workspaceID,
+	})
+	require.NoError(t, err)
+
+	resp, err := svc.Instantiate(ctx, userID, tmpl.ID)
+	require.NoError(t, err)
+	today := time.Now().UTC()
+	assert.Equal(t, "Retro "+today.Format("2006-01-02"), resp.Note.Title)
+	require.Len(t, created.created, 1)
+	assert.Equal(t, workspaceID, created.created[0].WorkspaceID)
+	assert.Equal(t, "#FFD700", created.created[0].Color)
+
+	_, err = svc.Instantiate(ctx, bson.NewObjectID(), tmpl.ID)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+
+	created.err = notes.ErrWorkspaceReadOnly
+	_, err = svc.Instantiate(ctx, userID, tmpl.ID)
+	assert.ErrorIs(t, err, notes.ErrWorkspaceReadOnly)
+	created.err = errors.New("mongo down")
+	_, err = svc.Instantiate(ctx, userID, tmpl.ID)
+	assert.ErrorIs(t, err, ErrInstantiate)
+}
+
+func TestFireRecurrences(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	created := &fakeNotes{}
+	svc := NewService(repo, created, silentLogger)
+
+	// Due since 5 Jan; the server was down until Wednesday 21 Jan
+	berlin := mustLoad(t, "Europe/Berlin")
+	first := time.Date(2026, 1, 5, 9, 0, 0, 0, berlin)
+	firstUTC := first.UTC()
+	tmpl := &Template{
+		ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Name: "Retro", Title: "Retro {{date}}",
+		Recurrence: &Recurrence{RRule: "FREQ=WEEKLY;BYDAY=MO", Start: firstUTC, Timezone: "Europe/Berlin", NextAt: &firstUTC},
+	}
+	require.NoError(t, repo.Create(ctx, tmpl))
+	now := time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC)
+
+	svc.SetLease(fakeLease{held: false})
+	svc.fireRecurrences(ctx, now)
+	assert.Empty(t, created.created, "another replica holds the lease")
+
+	svc.SetLease(fakeLease{held: true})
+	svc.fireRecurrences(ctx, now)
+	require.Len(t, created.created, 1, "missed occurrences make one note")
+	assert.Equal(t, "Retro 2026-01-19", created.created[0].Title)
+
+	stored, err := repo.Find(ctx, tmpl.UserID, tmpl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 26, 9, 0, 0, 0, berlin), stored.Recurrence.NextAt.In(berlin))
+	assert.Equal(t, time.Date(2026, 1, 19, 9, 0, 0, 0, berlin), stored.Recurrence.LastAt.In(berlin))
+
+	svc.fireRecurrences(ctx, now)
+	assert.Len(t, created.created, 1, "nothing is due until the next occurrence")
+}
+
+func TestFireRecurrencesEnds(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	created := &fakeNotes{}
+	svc := NewService(repo, created, silentLogger)
+
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	tmpl := &Template{
+		ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Name: "Once", Title: "Once",
+		Recurrence: &Recurrence{RRule: "FREQ=DAILY;COUNT=1", Start: start, Timezone: "UTC", NextAt: &start},
+	}
+	require.NoError(t, repo.Create(ctx, tmpl))
+
+	svc.fireRecurrences(ctx, start.Add(time.Minute))
+	require.Len(t, created.created, 1)
+	stored, err := repo.Find(ctx, tmpl.UserID, tmpl.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Recurrence.NextAt, "the rule has ended")
+}
+
+func TestRunRecurrences(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := newMemRepo()
+	created := &fakeNotes{}
+	svc := NewService(repo, created, silentLogger)
+
+	past := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	require.NoError(t, repo.Create(ctx, &Template{
+		ID: bson.NewObjectID(), UserID: bson.NewObjectID(), Name: "Daily", Title: "Daily",
+		Recurrence: &Recurrence{RRule: "FREQ=DAILY", Start: past, Timezone: "UTC", NextAt: &past},
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- svc.RunRecurrences(ctx) }()
+	require.Eventually(t, func() bool {
+		created.mu.Lock()
+		defer created.mu.Unlock()
+		return len(created.created) == 1
+	}, time.Second, 5*time.Millisecond, "due templates fire at start")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
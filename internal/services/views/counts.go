@@ -0,0 +1,90 @@
+package views
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NoteChanged marks the views of every online recipient of ev as stale. It
+// is registered with the hub and only records the users; Run recounts.
+func (s *Service) NoteChanged(_ context.Context, _ notes.NoteEvent, online []bson.ObjectID) {
+	s.touch(online...)
+}
+
+// touch schedules a count push for users
+func (s *Service) touch(users ...bson.ObjectID) {
+	if s.pusher == nil || len(users) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for _, uid := range users {
+		s.dirty[uid] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run pushes fresh view counts to the users whose notes changed until ctx is
+// done. Changes arriving within countsDebounce of each other share a push.
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.wake:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(countsDebounce):
+		}
+		s.flush(ctx)
+	}
+}
+
+// flush pushes counts to every user marked by touch
+func (s *Service) flush(ctx context.Context) {
+	s.mu.Lock()
+	users := maps.Clone(s.dirty)
+	clear(s.dirty)
+	s.mu.Unlock()
+
+	for uid := range users {
+		s.pushCounts(ctx, uid)
+	}
+}
+
+// pushCounts recounts the views of userID and sends them as one event.
+// Views that fail to count are left out rather than reported as empty.
+func (s *Service) pushCounts(ctx context.Context, userID bson.ObjectID) {
+	views, err := s.repo.List(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListViews.Error(), "error", err, "user_id", userID.Hex())
+		return
+	}
+	if len(views) == 0 {
+		return
+	}
+
+	counts := make([]notes.ViewCount, 0, len(views))
+	for _, v := range views {
+		n, err := s.count(ctx, userID, v)
+		if err != nil {
+			continue
+		}
+		counts = append(counts, notes.ViewCount{ViewID: v.ID.Hex(), Count: n})
+	}
+
+	s.pusher.Send(ctx, userID, notes.NoteEvent{Type: notes.EventViewCounts, Views: counts})
+}
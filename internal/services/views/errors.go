@@ -0,0 +1,21 @@
+package views
+
+import "errors"
+
+// ErrViewNotFound is returned when a view does not exist or belongs to someone else.
+var ErrViewNotFound = errors.New("view not found")
+
+// ErrTooManyViews is returned when a user already has the maximum number of views.
+var ErrTooManyViews = errors.New("too many saved views")
+
+// ErrCreateView is returned when view creation fails.
+var ErrCreateView = errors.New("failed to create view")
+
+// ErrListViews is returned when views cannot be read.
+var ErrListViews = errors.New("failed to list views")
+
+// ErrUpdateView is returned when a view cannot be changed.
+var ErrUpdateView = errors.New("failed to update view")
+
+// ErrDeleteView is returned when view deletion fails.
+var ErrDeleteView = errors.New("failed to delete view")
@@ -0,0 +1,46 @@
+package views
+
+import (
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// View is a saved search. Its Request holds the filters and sort of a notes
+// list; pagination comes from whoever runs the view.
+type View struct {
+	ID        bson.ObjectID          `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd7"`
+	UserID    bson.ObjectID          `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	Name      string                 `bson:"name" json:"name" example:"Urgent"`
+	Request   notes.ListNotesRequest `bson:"request" json:"request"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt time.Time              `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+
+	// Count is how many notes the view matches, filled in on reads
+	Count int64 `bson:"-" json:"count" example:"12"`
+}
+
+// UpdateView holds the fields of a view that can change
+type UpdateView struct {
+	Name    *string
+	Request *notes.ListNotesRequest
+}
+
+// CreateViewRequest saves a view
+type CreateViewRequest struct {
+	Name    string                 `json:"name" validate:"required,min=1,max=100" example:"Urgent"`
+	Request notes.ListNotesRequest `json:"request"`
+}
+
+// UpdateViewRequest renames a view or replaces its request
+type UpdateViewRequest struct {
+	Name    *string                 `json:"name,omitempty" validate:"omitempty,min=1,max=100" example:"Urgent"`
+	Request *notes.ListNotesRequest `json:"request,omitempty"`
+}
+
+// ListViewsResponse lists the caller's views with their counts
+type ListViewsResponse struct {
+	Views []*View `json:"views"`
+}
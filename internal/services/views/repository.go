@@ -0,0 +1,31 @@
+package views
+
+import (
+	"context"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Repository stores saved views. Every lookup is scoped to the owner.
+type Repository interface {
+	Create(ctx context.Context, v *View) error
+	List(ctx context.Context, userID bson.ObjectID) ([]*View, error)
+	Count(ctx context.Context, userID bson.ObjectID) (int64, error)
+	Find(ctx context.Context, userID, viewID bson.ObjectID) (*View, error)
+	Update(ctx context.Context, userID, viewID bson.ObjectID, patch UpdateView) (*View, error)
+	Delete(ctx context.Context, userID, viewID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
+
+// Notes runs the list request of a view
+type Notes interface {
+	List(ctx context.Context, userID bson.ObjectID, req notes.ListNotesRequest) (*notes.ListNotesResponse, error)
+	Count(ctx context.Context, userID bson.ObjectID, req notes.ListNotesRequest) (int64, error)
+}
+
+// Pusher delivers an event to every live connection of a user
+type Pusher interface {
+	Send(ctx context.Context, userID bson.ObjectID, ev notes.NoteEvent)
+}
@@ -0,0 +1,237 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxViewsPerUser bounds the counts recomputed on every note change
+	maxViewsPerUser = 50
+	// countsDebounce coalesces bursts of note changes into one push
+	countsDebounce = 250 * time.Millisecond
+)
+
+// Service manages saved views and pushes their live counts
+type Service struct {
+	repo   Repository
+	notes  Notes
+	pusher Pusher
+	log    *slog.Logger
+
+	mu    sync.Mutex
+	dirty map[bson.ObjectID]struct{}
+	wake  chan struct{}
+}
+
+// NewService creates a new views service
+func NewService(repo Repository, notes Notes, log *slog.Logger) *Service {
+	return &Service{
+		repo:  repo,
+		notes: notes,
+		log:   log,
+		dirty: make(map[bson.ObjectID]struct{}),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// SetPusher enables live counts over the connections pusher reaches
+func (s *Service) SetPusher(p Pusher) {
+	s.pusher = p
+}
+
+// filtersOnly drops the pagination of a list request; views store filters
+// and sort only
+func filtersOnly(req notes.ListNotesRequest) notes.ListNotesRequest {
+	req.Limit, req.Span = 0, 0
+	req.Cursor, req.Anchor = "", ""
+	req.Offset = nil
+	return req
+}
+
+// List returns the user's views with their counts
+func (s *Service) List(ctx context.Context, userID bson.ObjectID) (*ListViewsResponse, error) {
+	views, err := s.repo.List(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListViews.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListViews
+	}
+
+	for _, v := range views {
+		v.Count, _ = s.count(ctx, userID, v)
+	}
+	return &ListViewsResponse{Views: views}, nil
+}
+
+// Create saves a view. The request is run once so that a view which could
+// never list anything is rejected up front.
+func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateViewRequest) (*View, error) {
+	n, err := s.repo.Count(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrCreateView.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateView
+	}
+	if n >= maxViewsPerUser {
+		return nil, ErrTooManyViews
+	}
+
+	listReq := filtersOnly(req.Request)
+	count, err := s.notes.Count(ctx, userID, listReq)
+	if err != nil {
+		return nil, notesError(err, ErrCreateView)
+	}
+
+	now := time.Now().UTC()
+	v := &View{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		Name:      req.Name,
+		Request:   listReq,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, v); err != nil {
+		s.log.Error(ErrCreateView.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateView
+	}
+
+	v.Count = count
+	s.touch(userID)
+	return v, nil
+}
+
+// Get returns one view with its count
+func (s *Service) Get(ctx context.Context, userID, viewID bson.ObjectID) (*View, error) {
+	v, err := s.find(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
+	v.Count, _ = s.count(ctx, userID, v)
+	return v, nil
+}
+
+// Update renames a view or replaces its request
+func (s *Service) Update(ctx context.Context, userID, viewID bson.ObjectID, req UpdateViewRequest) (*View, error) {
+	patch := UpdateView{Name: req.Name}
+	var count int64
+	if req.Request != nil {
+		listReq := filtersOnly(*req.Request)
+		var err error
+		if count, err = s.notes.Count(ctx, userID, listReq); err != nil {
+			return nil, notesError(err, ErrUpdateView)
+		}
+		patch.Request = &listReq
+	}
+
+	v, err := s.repo.Update(ctx, userID, viewID, patch)
+	if err != nil {
+		if errors.Is(err, ErrViewNotFound) {
+			return nil, ErrViewNotFound
+		}
+		s.log.Error(ErrUpdateView.Error(), "error", err, "user_id", userID.Hex(), "view_id", viewID.Hex())
+		return nil, ErrUpdateView
+	}
+
+	if req.Request != nil {
+		v.Count = count
+	} else {
+		v.Count, _ = s.count(ctx, userID, v)
+	}
+	s.touch(userID)
+	return v, nil
+}
+
+// Delete deletes a view
+func (s *Service) Delete(ctx context.Context, userID, viewID bson.ObjectID) error {
+	if err := s.repo.Delete(ctx, userID, viewID); err != nil {
+		if errors.Is(err, ErrViewNotFound) {
+			return ErrViewNotFound
+		}
+		s.log.Error(ErrDeleteView.Error(), "error", err, "user_id", userID.Hex(), "view_id", viewID.Hex())
+		return ErrDeleteView
+	}
+	s.touch(userID)
+	return nil
+}
+
+// Notes runs a view with the pagination of page and returns the usual notes
+// list response
+func (s *Service) Notes(ctx context.Context, userID, viewID bson.ObjectID, page notes.ListNotesRequest) (*notes.ListNotesResponse, error) {
+	v, err := s.find(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := v.Request
+	req.Limit, req.Span = page.Limit, page.Span
+	req.Cursor, req.Anchor = page.Cursor, page.Anchor
+	req.Offset = page.Offset
+	req.Render = page.Render
+
+	resp, err := s.notes.List(ctx, userID, req)
+	if err != nil {
+		return nil, notesError(err, notes.ErrListNotes)
+	}
+	return resp, nil
+}
+
+// PurgeUser deletes every view of a user
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	deleted, err := s.repo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrDeleteView.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteView
+	}
+	s.log.Info("purged views of deleted account", "user_id", userID.Hex(), "deleted", deleted)
+	return nil
+}
+
+func (s *Service) find(ctx context.Context, userID, viewID bson.ObjectID) (*View, error) {
+	v, err := s.repo.Find(ctx, userID, viewID)
+	if err != nil {
+		if errors.Is(err, ErrViewNotFound) {
+			return nil, ErrViewNotFound
+		}
+		s.log.Error(ErrListViews.Error(), "error", err, "user_id", userID.Hex(), "view_id", viewID.Hex())
+		return nil, ErrListViews
+	}
+	return v, nil
+}
+
+// count runs the request of v. A view can stop working, e.g. when its owner
+// leaves the workspace it lists, so failures are logged and read as zero.
+func (s *Service) count(ctx context.Context, userID bson.ObjectID, v *View) (int64, error) {
+	n, err := s.notes.Count(ctx, userID, v.Request)
+	if err != nil {
+		s.log.Info("failed to count view", "error", err, "user_id", userID.Hex(), "view_id", v.ID.Hex())
+		return 0, err
+	}
+	return n, nil
+}
+
+// notesError keeps the notes errors callers map to 4xx and folds everything
+// else into fallback
+func notesError(err, fallback error) error {
+	for _, known := range []error{
+		notes.ErrBadRequest,
+		notes.ErrInvalidCursor,
+		notes.ErrInvalidLimit,
+		notes.ErrOffsetBeyondTotal,
+		notes.ErrNoteNotFound,
+		notes.ErrWorkspaceNotFound,
+		notes.ErrNotebookNotFound,
+		notes.ErrPersonalOnly,
+	} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,295 @@
+package views
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memRepo is an in-memory Repository
+type memRepo struct {
+	mu    sync.Mutex
+	views map[bson.ObjectID]*View
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{views: map[bson.ObjectID]*View{}}
+}
+
+func (m *memRepo) Create(_ context.Context, v *View) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *v
+	m.views[v.ID] = &cp
+	return nil
+}
+
+func (m *memRepo) List(_ context.Context, userID bson.ObjectID) ([]*View, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*View{}
+	for _, v := range m.views {
+		if v.UserID == userID {
+			cp := *v
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *memRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	list, err := m.List(ctx, userID)
+	return int64(len(list)), err
+}
+
+func (m *memRepo) Find(_ context.Context, userID, viewID bson.ObjectID) (*View, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.views[viewID]
+	if !ok || v.UserID != userID {
+		return nil, ErrViewNotFound
+	}
+	cp := *v
+	return &cp, nil
+}
+
+func (m *memRepo) Update(ctx context.Context, userID, viewID bson.ObjectID, patch UpdateView) (*View, error) {
+	if _, err := m.Find(ctx, userID, viewID); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	v := m.views[viewID]
+	if patch.Name != nil {
+		v.Name = *patch.Name
+	}
+	if patch.Request != nil {
+		v.Request = *patch.Request
+	}
+	m.mu.Unlock()
+	return m.Find(ctx, userID, viewID)
+}
+
+func (m *memRepo) Delete(ctx context.Context, userID, viewID bson.ObjectID) error {
+	if _, err := m.Find(ctx, userID, viewID); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.views, viewID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memRepo) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, v := range m.views {
+		if v.UserID == userID {
+			delete(m.views, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeNotes counts by q and records the list requests it runs
+type fakeNotes struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	listed []notes.ListNotesRequest
+}
+
+func (f *fakeNotes) List(_ context.Context, _ bson.ObjectID, req notes.ListNotesRequest) (*notes.ListNotesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listed = append(f.listed, req)
+	return &notes.ListNotesResponse{Notes: []*notes.Note{}}, nil
+}
+
+func (f *fakeNotes) Count(_ context.Context, _ bson.ObjectID, req notes.ListNotesRequest) (int64, error) {
+	if _, err := notes.ParseQuery(req.Q); err != nil {
+		return 0, err
+	}
+	if req.WorkspaceID != "" {
+		return 0, notes.ErrWorkspaceNotFound
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[req.Q], nil
+}
+
+// fakePusher records pushed events per user
+type fakePusher struct {
+	mu     sync.Mutex
+	events map[bson.ObjectID][]notes.NoteEvent
+}
+
+func (p *fakePusher) Send(_ context.Context, userID bson.ObjectID, ev notes.NoteEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events[userID] = append(p.events[userID], ev)
+}
+
+func (p *fakePusher) sent(userID bson.ObjectID) []notes.NoteEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.events[userID]
+}
+
+func newTestService() (*Service, *memRepo, *fakeNotes, *fakePusher) {
+	repo := newMemRepo()
+	fn := &fakeNotes{counts: map[string]int64{"urgent": 12, "later": 3}}
+	pusher := &fakePusher{events: map[bson.ObjectID][]notes.NoteEvent{}}
+	svc := NewService(repo, fn, silentLogger)
+	svc.SetPusher(pusher)
+	return svc, repo, fn, pusher
+}
+
+func TestCreateStoresFiltersOnly(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	offset := 10
+
+	v, err := svc.Create(ctx, userID, CreateViewRequest{
+		Name: "Urgent",
+		Request: notes.ListNotesRequest{
+			Q: "urgent", Color: "#FF0000", Sort: "updated_at", Order: "asc",
+			Limit: 20, Cursor: "abc", Anchor: "def", Span: 5, Offset: &offset,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), v.Count)
+
+	stored, err := repo.Find(ctx, userID, v.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notes.ListNotesRequest{Q: "urgent", Color: "#FF0000", Sort: "updated_at", Order: "asc"}, stored.Request)
+}
+
+func TestCreateRejectsBrokenRequests(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	_, err := svc.Create(ctx, userID, CreateViewRequest{Name: "Bad", Request: notes.ListNotesRequest{Q: "color:red"}})
+	var qerr *notes.QueryError
+	assert.ErrorAs(t, err, &qerr)
+
+	_, err = svc.Create(ctx, userID, CreateViewRequest{Name: "Gone", Request: notes.ListNotesRequest{WorkspaceID: bson.NewObjectID().Hex()}})
+	assert.ErrorIs(t, err, notes.ErrWorkspaceNotFound)
+
+	n, _ := repo.Count(ctx, userID)
+	assert.Zero(t, n)
+}
+
+func TestCreateLimitsViewsPerUser(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	for range maxViewsPerUser {
+		_, err := svc.Create(ctx, userID, CreateViewRequest{Name: "v"})
+		require.NoError(t, err)
+	}
+	_, err := svc.Create(ctx, userID, CreateViewRequest{Name: "one too many"})
+	assert.ErrorIs(t, err, ErrTooManyViews)
+}
+
+func TestNotesMergesPagination(t *testing.T) {
+	svc, _, fn, _ := newTestService()
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	v, err := svc.Create(ctx, userID, CreateViewRequest{Name: "Urgent", Request: notes.ListNotesRequest{Q: "urgent", Sort: "title"}})
+	require.NoError(t, err)
+
+	// Page parameters override; filters in the page are ignored
+	_, err = svc.Notes(ctx, userID, v.ID, notes.ListNotesRequest{Limit: 5, Cursor: "next", Q: "ignored"})
+	require.NoError(t, err)
+	require.Len(t, fn.listed, 1)
+	assert.Equal(t, notes.ListNotesRequest{Q: "urgent", Sort: "title", Limit: 5, Cursor: "next"}, fn.listed[0])
+
+	_, err = svc.Notes(ctx, bson.NewObjectID(), v.ID, notes.ListNotesRequest{})
+	assert.ErrorIs(t, err, ErrViewNotFound, "views are private to their owner")
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+
+	v, err := svc.Create(ctx, userID, CreateViewRequest{Name: "Urgent", Request: notes.ListNotesRequest{Q: "urgent"}})
+	require.NoError(t, err)
+
+	name := "Later"
+	updated, err := svc.Update(ctx, userID, v.ID, UpdateViewRequest{Name: &name, Request: &notes.ListNotesRequest{Q: "later", Limit: 9}})
+	require.NoError(t, err)
+	assert.Equal(t, "Later", updated.Name)
+	assert.Equal(t, notes.ListNotesRequest{Q: "later"}, updated.Request)
+	assert.Equal(t, int64(3), updated.Count)
+
+	require.NoError(t, svc.Delete(ctx, userID, v.ID))
+	assert.ErrorIs(t, svc.Delete(ctx, userID, v.ID), ErrViewNotFound)
+}
+
+func TestNoteChangedPushesCounts(t *testing.T) {
+	svc, repo, fn, pusher := newTestService()
+	ctx := context.Background()
+	userID, offline := bson.NewObjectID(), bson.NewObjectID()
+
+	urgent, err := svc.Create(ctx, userID, CreateViewRequest{Name: "Urgent", Request: notes.ListNotesRequest{Q: "urgent"}})
+	require.NoError(t, err)
+	// A view that stopped working is left out of the push
+	require.NoError(t, repo.Create(ctx, &View{ID: bson.NewObjectID(), UserID: userID, Name: "Lost", Request: notes.ListNotesRequest{WorkspaceID: bson.NewObjectID().Hex()}}))
+	svc.flush(ctx)
+	before := len(pusher.sent(userID))
+
+	fn.mu.Lock()
+	fn.counts["urgent"] = 13
+	fn.mu.Unlock()
+
+	// Several changes before the flush make a single push
+	svc.NoteChanged(ctx, notes.NoteEvent{Type: "created"}, []bson.ObjectID{userID})
+	svc.NoteChanged(ctx, notes.NoteEvent{Type: "updated"}, []bson.ObjectID{userID})
+	svc.flush(ctx)
+
+	events := pusher.sent(userID)
+	require.Len(t, events, before+1)
+	last := events[len(events)-1]
+	assert.Equal(t, notes.EventViewCounts, last.Type)
+	assert.Equal(t, []notes.ViewCount{{ViewID: urgent.ID.Hex(), Count: 13}}, last.Views)
+	assert.Empty(t, pusher.sent(offline))
+}
+
+func TestRunDebouncesPushes(t *testing.T) {
+	svc, _, _, pusher := newTestService()
+	userID := bson.NewObjectID()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- svc.Run(ctx) }()
+
+	_, err := svc.Create(ctx, userID, CreateViewRequest{Name: "Urgent", Request: notes.ListNotesRequest{Q: "urgent"}})
+	require.NoError(t, err)
+	svc.NoteChanged(ctx, notes.NoteEvent{Type: "created"}, []bson.ObjectID{userID})
+
+	require.Eventually(t, func() bool { return len(pusher.sent(userID)) > 0 }, time.Second, 10*time.Millisecond)
+	time.Sleep(2 * countsDebounce)
+	assert.Len(t, pusher.sent(userID), 1, "create and note change share one push")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
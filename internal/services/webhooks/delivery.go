@@ -0,0 +1,332 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// deliveryInterval is how often Run looks for deliveries due a retry
+	deliveryInterval = 5 * time.Second
+	// deliveryBatch bounds the deliveries claimed per sweep
+	deliveryBatch = 100
+	// sendConcurrency bounds the requests in flight
+	sendConcurrency = 8
+	// claimTTL hides a claimed delivery from other replicas; it outlives
+	// the request timeout
+	claimTTL = time.Minute
+	// maxAttempts is how often a delivery is tried before it fails
+	maxAttempts = 8
+	// backoffBase is the delay after the first failed attempt; the delay
+	// doubles after each one up to backoffMax
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
+	// disableAfter is how many attempts in a row may fail before the
+	// webhook is disabled
+	disableAfter = 15
+	// deliveryRetention is how long the delivery log is kept
+	deliveryRetention = 30 * 24 * time.Hour
+	// maxResponseBytes bounds the response body read from a receiver
+	maxResponseBytes = 64 << 10
+)
+
+// Headers of a delivery request
+const (
+	HeaderEvent     = "X-NotePulse-Event"
+	HeaderDelivery  = "X-NotePulse-Delivery"
+	HeaderTimestamp = "X-NotePulse-Timestamp"
+	// HeaderSignature is "sha256=" and the hex HMAC-SHA256, keyed with the
+	// webhook secret, of the timestamp header, a dot and the body
+	HeaderSignature = "X-NotePulse-Signature"
+)
+
+// Sign returns the X-NotePulse-Signature of body sent at timestamp (Unix
+// seconds), so that receivers can check deliveries the same way
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run turns queued note events into deliveries and sends them until ctx is
+// done. Pending deliveries are stored, so those that were due while no
+// server ran are sent at the first sweep. Committed note events are stored
+// by RecordEvent before they leave the notes outbox; only the events queued
+// by NoteChanged, like "moved", are lost when still queued at shutdown.
+func (s *Service) Run(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.intake(ctx)
+	}()
+
+	ticker := time.NewTicker(deliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			<-done
+			return nil
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// signal wakes Run to send deliveries now
+func (s *Service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RecordEvent stores a delivery of ev per webhook covering it. It is
+// registered with the notes service, which keeps ev in its outbox and hands
+// it over again when this fails; a webhook gets one delivery per EventID.
+func (s *Service) RecordEvent(ctx context.Context, ev notes.NoteEvent) error {
+	stored, err := s.enqueue(ctx, ev)
+	if err != nil {
+		return err
+	}
+	if stored {
+		s.signal()
+	}
+	return nil
+}
+
+// intake stores a delivery per webhook covering each queued event
+func (s *Service) intake(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-s.queue:
+			stored, err := s.enqueue(ctx, ev)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.log.Error("failed to store webhook deliveries", "error", err, "event_type", ev.Type)
+				}
+				continue
+			}
+			if stored {
+				s.signal()
+			}
+		}
+	}
+}
+
+// enqueue stores the deliveries of ev and reports whether there were any
+func (s *Service) enqueue(ctx context.Context, ev notes.NoteEvent) (bool, error) {
+	if ev.Note == nil {
+		return false, nil
+	}
+	hooks, err := s.repo.Subscribed(ctx, ev.Note.UserID, ev.Note.WorkspaceID, ev.Type)
+	if err != nil {
+		return false, fmt.Errorf("failed to find webhooks of note %s: %w", ev.Note.ID.Hex(), err)
+	}
+	if len(hooks) == 0 {
+		return false, nil
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		// Encoding fails the same way every time; retrying is no use
+		s.log.Error("failed to encode webhook payload", "error", err, "note_id", ev.Note.ID.Hex())
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	deliveries := make([]*Delivery, 0, len(hooks))
+	for _, w := range hooks {
+		// Members who left a workspace stop hearing about it
+		if w.WorkspaceID != nil {
+			read, err := s.canRead(ctx, w)
+			if err != nil {
+				return false, err
+			}
+			if !read {
+				continue
+			}
+		}
+		d := newDelivery(w, ev.Type, string(payload), now)
+		if !ev.EventID.IsZero() {
+			d.EventID = &ev.EventID
+		}
+		deliveries = append(deliveries, d)
+	}
+	if len(deliveries) == 0 {
+		return false, nil
+	}
+	if err := s.deliveries.Create(ctx, deliveries...); err != nil {
+		return false, fmt.Errorf("failed to store deliveries of note %s: %w", ev.Note.ID.Hex(), err)
+	}
+	return true, nil
+}
+
+// canRead reports whether the owner of a workspace webhook may still read
+// the workspace
+func (s *Service) canRead(ctx context.Context, w *Webhook) (bool, error) {
+	if s.access == nil {
+		return false, nil
+	}
+	read, _, err := s.access.Access(ctx, *w.WorkspaceID, w.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check access of webhook %s: %w", w.ID.Hex(), err)
+	}
+	return read, nil
+}
+
+// sweep claims the deliveries due at now and sends them, a few at a time
+func (s *Service) sweep(ctx context.Context, now time.Time) {
+	var g errgroup.Group
+	g.SetLimit(sendConcurrency)
+
+	claimed := 0
+	for claimed < deliveryBatch {
+		d, err := s.deliveries.Claim(ctx, now, now.Add(claimTTL))
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to claim webhook delivery", "error", err)
+			}
+			break
+		}
+		if d == nil {
+			break
+		}
+		claimed++
+		g.Go(func() error {
+			s.attempt(ctx, d)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	// More may be due; go on without waiting for the ticker
+	if claimed == deliveryBatch {
+		s.signal()
+	}
+}
+
+// attempt sends a claimed delivery once and records the outcome on the
+// delivery and on its webhook
+func (s *Service) attempt(ctx context.Context, d *Delivery) {
+	w, err := s.repo.Get(ctx, d.WebhookID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			s.finish(ctx, d, Attempt{Status: StatusFailed, Error: "webhook deleted"})
+			return
+		}
+		// The claim runs out and a later sweep tries again
+		s.log.Error("failed to load webhook", "error", err, "webhook_id", d.WebhookID.Hex())
+		return
+	}
+	if !w.Active {
+		s.finish(ctx, d, Attempt{Status: StatusFailed, Error: ErrWebhookDisabled.Error()})
+		return
+	}
+
+	status, err := s.post(ctx, w, d)
+	if err == nil {
+		s.finish(ctx, d, Attempt{Status: StatusDelivered, ResponseStatus: status})
+		if w.Failures > 0 {
+			if err := s.repo.RecordSuccess(ctx, w.ID); err != nil {
+				s.log.Error("failed to record webhook success", "error", err, "webhook_id", w.ID.Hex())
+			}
+		}
+		return
+	}
+
+	now := time.Now().UTC()
+	a := Attempt{Status: StatusFailed, ResponseStatus: status, Error: err.Error()}
+	if d.Attempts < maxAttempts {
+		next := now.Add(s.delay(d.Attempts))
+		a.Status, a.NextAt = StatusPending, &next
+	}
+	s.finish(ctx, d, a)
+
+	disabled, err := s.repo.RecordFailure(ctx, w.ID, disableAfter, now)
+	if err != nil {
+		s.log.Error("failed to record webhook failure", "error", err, "webhook_id", w.ID.Hex())
+	} else if disabled {
+		s.log.Warn("disabled failing webhook", "webhook_id", w.ID.Hex(), "user_id", w.UserID.Hex())
+	}
+}
+
+func (s *Service) finish(ctx context.Context, d *Delivery, a Attempt) {
+	if err := s.deliveries.Finish(ctx, d.ID, a); err != nil {
+		s.log.Error("failed to record webhook delivery", "error", err, "delivery_id", d.ID.Hex())
+	}
+}
+
+// post sends d to w and returns the response status. Anything but a 2xx
+// answer, redirects included, is an error.
+func (s *Service) post(ctx context.Context, w *Webhook, d *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NotePulse-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.ID.Hex())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// delay is the wait after the attempts-th failed attempt
+func (s *Service) delay(attempts int) time.Duration {
+	d := s.backoff << (attempts - 1)
+	if d <= 0 || d > backoffMax {
+		return backoffMax
+	}
+	return d
+}
+
+// newDelivery makes a delivery of payload to w, due at now
+func newDelivery(w *Webhook, event, payload string, now time.Time) *Delivery {
+	return &Delivery{
+		ID:        bson.NewObjectID(),
+		WebhookID: w.ID,
+		UserID:    w.UserID,
+		Event:     event,
+		Payload:   payload,
+		Status:    StatusPending,
+		NextAt:    &now,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(deliveryRetention),
+	}
+}
@@ -0,0 +1,209 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const testSecret = "0123456789abcdef"
+
+// receiver is an in-process webhook endpoint answering with status and
+// checking signatures as a real one would
+type receiver struct {
+	*httptest.Server
+	status   atomic.Int32
+	requests atomic.Int32
+	verified atomic.Int32
+	bodies   chan string
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{bodies: make(chan string, 16)}
+	r.status.Store(http.StatusNoContent)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requests.Add(1)
+		body, _ := io.ReadAll(req.Body)
+		timestamp, _ := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+		if req.Header.Get(HeaderSignature) == Sign(testSecret, timestamp, body) && req.Header.Get(HeaderEvent) != "" {
+			r.verified.Add(1)
+		}
+		r.bodies <- string(body)
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestSign(t *testing.T) {
+	sig := Sign(testSecret, 1700000000, []byte(`{"type":"created"}`))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.NotEqual(t, sig, Sign(testSecret, 1700000001, []byte(`{"type":"created"}`)), "the timestamp is signed")
+	assert.NotEqual(t, sig, Sign("another-secret-000", 1700000000, []byte(`{"type":"created"}`)))
+}
+
+func TestServiceRunDelivers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	userID := bson.NewObjectID()
+	r := newReceiver(t)
+
+	svc, _, log := newTestService(NewClient(5*time.Second, true))
+	w, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: r.URL, Secret: testSecret})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- svc.Run(ctx) }()
+
+	note := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Plan"}
+	svc.NoteChanged(ctx, notes.NoteEvent{Type: "created", Note: note}, nil)
+	select {
+	case body := <-r.bodies:
+		assert.Contains(t, body, note.ID.Hex())
+	case <-time.After(2 * time.Second):
+		t.Fatal("no delivery")
+	}
+	assert.Equal(t, int32(1), r.verified.Load())
+
+	require.Eventually(t, func() bool {
+		got := log.all()
+		return len(got) == 1 && got[0].Status == StatusDelivered
+	}, 2*time.Second, 10*time.Millisecond)
+	got := log.all()[0]
+	assert.Equal(t, w.ID, got.WebhookID)
+	assert.Equal(t, http.StatusNoContent, got.ResponseStatus)
+	assert.Equal(t, 1, got.Attempts)
+	assert.Nil(t, got.NextAt)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServiceRetries(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	r := newReceiver(t)
+	r.status.Store(http.StatusInternalServerError)
+
+	svc, repo, log := newTestService(NewClient(5*time.Second, true))
+	w, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: r.URL, Secret: testSecret})
+	require.NoError(t, err)
+	require.NoError(t, log.Create(ctx, newDelivery(w, "created", `{"type":"created"}`, time.Now().UTC())))
+
+	svc.sweep(ctx, time.Now().UTC())
+	got := log.all()[0]
+	assert.Equal(t, StatusPending, got.Status)
+	assert.Equal(t, http.StatusInternalServerError, got.ResponseStatus)
+	assert.Equal(t, "unexpected status 500", got.Error)
+	assert.WithinDuration(t, time.Now().Add(backoffBase), *got.NextAt, time.Second)
+
+	svc.sweep(ctx, time.Now().UTC())
+	assert.Equal(t, int32(1), r.requests.Load(), "the retry is not due yet")
+
+	// Sweeping from the future runs out the attempts
+	for range maxAttempts {
+		svc.sweep(ctx, time.Now().Add(2*backoffMax))
+	}
+	got = log.all()[0]
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Equal(t, maxAttempts, got.Attempts)
+	assert.Nil(t, got.NextAt)
+	assert.Equal(t, int32(maxAttempts), r.requests.Load())
+
+	hook, err := repo.Get(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Equal(t, maxAttempts, hook.Failures)
+
+	// A success starts the count of failures over
+	r.status.Store(http.StatusOK)
+	require.NoError(t, log.Create(ctx, newDelivery(w, "created", `{}`, time.Now().UTC())))
+	svc.sweep(ctx, time.Now().UTC())
+	hook, err = repo.Get(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Zero(t, hook.Failures)
+	assert.Equal(t, r.requests.Load(), r.verified.Load(), "every attempt is signed")
+}
+
+func TestServiceDisablesFailingWebhook(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	r := newReceiver(t)
+	r.status.Store(http.StatusGone)
+
+	svc, repo, log := newTestService(NewClient(5*time.Second, true))
+	w, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: r.URL, Secret: testSecret})
+	require.NoError(t, err)
+	repo.hooks[0].Failures = disableAfter - 1
+
+	now := time.Now().UTC()
+	first := newDelivery(w, "created", `{}`, now)
+	second := newDelivery(w, "updated", `{}`, now.Add(time.Minute))
+	require.NoError(t, log.Create(ctx, first, second))
+
+	svc.sweep(ctx, now)
+	hook, err := repo.Get(ctx, w.ID)
+	require.NoError(t, err)
+	assert.False(t, hook.Active)
+	assert.NotNil(t, hook.DisabledAt)
+
+	// Pending deliveries of a disabled webhook fail without a request
+	svc.sweep(ctx, now.Add(2*backoffMax))
+	for _, d := range log.all() {
+		assert.Equal(t, StatusFailed, d.Status)
+	}
+	assert.Equal(t, ErrWebhookDisabled.Error(), log.all()[1].Error)
+	assert.Equal(t, int32(1), r.requests.Load())
+
+	_, err = svc.Redeliver(ctx, userID, w.ID, first.ID)
+	assert.ErrorIs(t, err, ErrWebhookDisabled)
+
+	active := true
+	_, err = svc.Update(ctx, userID, w.ID, UpdateWebhookRequest{Active: &active})
+	require.NoError(t, err)
+	again, err := svc.Redeliver(ctx, userID, w.ID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &first.ID, again.RedeliveryOf)
+	assert.Equal(t, StatusPending, again.Status)
+	assert.Equal(t, "created", again.Event)
+
+	_, err = svc.Redeliver(ctx, userID, w.ID, bson.NewObjectID())
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	_, err = svc.Redeliver(ctx, bson.NewObjectID(), w.ID, first.ID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	resp, err := svc.Deliveries(ctx, userID, w.ID, ListDeliveriesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Deliveries, 3)
+	assert.Equal(t, again.ID, resp.Deliveries[0].ID, "newest first")
+}
+
+func TestDelay(t *testing.T) {
+	svc, _, _ := newTestService(nil)
+	assert.Equal(t, backoffBase, svc.delay(1))
+	assert.Equal(t, 4*backoffBase, svc.delay(3))
+	assert.Equal(t, backoffMax, svc.delay(30))
+	assert.Equal(t, backoffMax, svc.delay(100))
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	r := newReceiver(t)
+
+	_, err := NewClient(time.Second, false).Post(r.URL, "application/json", nil)
+	assert.ErrorIs(t, err, errPrivateAddress)
+	assert.Zero(t, r.requests.Load())
+
+	resp, err := NewClient(time.Second, true).Post(r.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
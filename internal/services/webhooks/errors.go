@@ -0,0 +1,36 @@
+package webhooks
+
+import "errors"
+
+// ErrWebhookNotFound is returned when a webhook does not exist or belongs to someone else.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrDeliveryNotFound is returned when a delivery is not in the log of the webhook.
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// ErrTooManyWebhooks is returned when a user already has the maximum number of webhooks.
+var ErrTooManyWebhooks = errors.New("too many webhooks")
+
+// ErrInvalidURL is returned for webhook URLs that are not http or https.
+var ErrInvalidURL = errors.New("webhook URL must be http or https")
+
+// ErrWebhookDisabled is returned when redelivering to a disabled webhook.
+var ErrWebhookDisabled = errors.New("webhook is disabled")
+
+// ErrCreateWebhook is returned when webhook creation fails.
+var ErrCreateWebhook = errors.New("failed to create webhook")
+
+// ErrListWebhooks is returned when webhooks cannot be read.
+var ErrListWebhooks = errors.New("failed to list webhooks")
+
+// ErrUpdateWebhook is returned when a webhook cannot be changed.
+var ErrUpdateWebhook = errors.New("failed to update webhook")
+
+// ErrDeleteWebhook is returned when webhook deletion fails.
+var ErrDeleteWebhook = errors.New("failed to delete webhook")
+
+// ErrListDeliveries is returned when the delivery log cannot be read.
+var ErrListDeliveries = errors.New("failed to list deliveries")
+
+// ErrRedeliver is returned when a delivery cannot be queued again.
+var ErrRedeliver = errors.New("failed to redeliver")
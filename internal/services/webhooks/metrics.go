@@ -0,0 +1,14 @@
+package webhooks
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var droppedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "webhook_events_dropped_total",
+	Help: "Note events dropped before becoming webhook deliveries because the queue was full",
+})
+
+// Collectors returns the Prometheus collectors of the webhooks service so the
+// router can register them next to the HTTP metrics.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{droppedEvents}
+}
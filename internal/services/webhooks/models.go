@@ -0,0 +1,115 @@
+package webhooks
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Webhook is a user's subscription to note events: each event it covers is
+// POSTed to URL, signed with Secret
+type Webhook struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd9"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	// WorkspaceID selects the shared workspace whose notes the webhook
+	// covers; nil means the user's personal notes
+	WorkspaceID *bson.ObjectID `bson:"workspace_id,omitempty" json:"workspace_id,omitempty" example:"683cdb8aa96ad71e8e075bd5"`
+	URL         string         `bson:"url" json:"url" example:"https://chat.example.com/hooks/notes"`
+	Secret      string         `bson:"secret" json:"-"`
+	// Events lists the event types delivered; empty means all of them
+	Events []string `bson:"events,omitempty" json:"events,omitempty" example:"created,updated"`
+	Active bool     `bson:"active" json:"active" example:"true"`
+	// Failures counts the delivery attempts failed in a row
+	Failures   int        `bson:"failures" json:"failures" example:"0"`
+	DisabledAt *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty" example:"2025-06-02T08:00:00Z"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+}
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"   // waiting for its next attempt
+	StatusDelivered = "delivered" // the receiver answered 2xx
+	StatusFailed    = "failed"    // out of attempts, or the webhook was disabled
+)
+
+// Delivery is one event on its way to a webhook, kept as a log entry once
+// done
+type Delivery struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bda"`
+	WebhookID bson.ObjectID `bson:"webhook_id" json:"webhook_id" example:"683cdb8aa96ad71e8e075bd9"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	Event     string        `bson:"event" json:"event" example:"created"`
+	// Payload is the JSON body POSTed, the note event as sent over the
+	// WebSocket
+	Payload  string `bson:"payload" json:"payload" example:"{\"type\":\"created\",\"note\":{}}"`
+	Status   string `bson:"status" json:"status" example:"delivered"`
+	Attempts int    `bson:"attempts" json:"attempts" example:"1"`
+	// NextAt is when a pending delivery is next attempted
+	NextAt *time.Time `bson:"next_at,omitempty" json:"next_at,omitempty" example:"2025-06-01T23:00:36Z"`
+	// ResponseStatus is the HTTP status of the latest attempt, 0 when no
+	// response came
+	ResponseStatus int    `bson:"response_status,omitempty" json:"response_status,omitempty" example:"200"`
+	Error          string `bson:"error,omitempty" json:"error,omitempty" example:"unexpected status 500"`
+	// EventID is the note event delivered; a webhook gets one delivery per
+	// event however often the event is recorded. Redeliveries have none.
+	EventID *bson.ObjectID `bson:"event_id,omitempty" json:"-"`
+	// RedeliveryOf is the delivery this one repeats
+	RedeliveryOf *bson.ObjectID `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty" example:"683cdb8aa96ad71e8e075bdb"`
+	CreatedAt    time.Time      `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt    time.Time      `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+	// ExpiresAt is when the log entry is dropped
+	ExpiresAt time.Time `bson:"expires_at" json:"-"`
+}
+
+// Attempt is the outcome of one delivery attempt. NextAt is set when the
+// delivery stays pending.
+type Attempt struct {
+	Status         string
+	ResponseStatus int
+	Error          string
+	NextAt         *time.Time
+}
+
+// UpdateWebhook holds the fields of a webhook that can change. Setting
+// Active also clears Failures and DisabledAt.
+type UpdateWebhook struct {
+	URL    *string
+	Secret *string
+	Events *[]string
+	Active *bool
+}
+
+// CreateWebhookRequest subscribes a URL to note events
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,url,max=2048" example:"https://chat.example.com/hooks/notes"`
+	// Secret signs every delivery; keep it to check X-NotePulse-Signature
+	Secret string `json:"secret" validate:"required,min=16,max=256" example:"a-long-random-shared-secret"`
+	// Events lists the event types to deliver; empty means all of them
+	Events      []string `json:"events,omitempty" validate:"omitempty,max=16,dive,oneof=created updated deleted moved links reminder item_added item_updated item_deleted items_reordered" example:"created,updated"`
+	WorkspaceID string   `json:"workspace_id,omitempty" validate:"omitempty,mongodb" example:"683cdb8aa96ad71e8e075bd5"`
+}
+
+// UpdateWebhookRequest changes a webhook. "active": true enables a
+// disabled webhook again.
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url,omitempty" validate:"omitempty,url,max=2048" example:"https://chat.example.com/hooks/notes"`
+	Secret *string   `json:"secret,omitempty" validate:"omitempty,min=16,max=256" example:"a-new-long-random-secret"`
+	Events *[]string `json:"events,omitempty" validate:"omitempty,max=16,dive,oneof=created updated deleted moved links reminder item_added item_updated item_deleted items_reordered" example:"deleted"`
+	Active *bool     `json:"active,omitempty" example:"true"`
+}
+
+// ListWebhooksResponse lists the caller's webhooks
+type ListWebhooksResponse struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+// ListDeliveriesRequest pages through the delivery log of a webhook
+type ListDeliveriesRequest struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100" example:"20"`
+}
+
+// ListDeliveriesResponse is the delivery log of a webhook, newest first
+type ListDeliveriesResponse struct {
+	Deliveries []*Delivery `json:"deliveries"`
+}
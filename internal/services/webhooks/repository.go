@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Repository stores webhooks. Lookups by ID are scoped to the owner; Get,
+// Subscribed and the Record methods serve the delivery worker and see every
+// user.
+type Repository interface {
+	Create(ctx context.Context, w *Webhook) error
+	List(ctx context.Context, userID bson.ObjectID) ([]*Webhook, error)
+	Count(ctx context.Context, userID bson.ObjectID) (int64, error)
+	Find(ctx context.Context, userID, webhookID bson.ObjectID) (*Webhook, error)
+	Update(ctx context.Context, userID, webhookID bson.ObjectID, patch UpdateWebhook) (*Webhook, error)
+	Delete(ctx context.Context, userID, webhookID bson.ObjectID) error
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+
+	// Get returns a webhook of any user
+	Get(ctx context.Context, webhookID bson.ObjectID) (*Webhook, error)
+	// Subscribed returns the active webhooks covering event for the notes
+	// of a workspace, or the personal notes of userID when workspaceID is
+	// nil
+	Subscribed(ctx context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, event string) ([]*Webhook, error)
+	// RecordSuccess clears the failures of a webhook
+	RecordSuccess(ctx context.Context, webhookID bson.ObjectID) error
+	// RecordFailure counts a failed attempt and disables the webhook at
+	// disableAfter failures in a row, reporting whether this call did
+	RecordFailure(ctx context.Context, webhookID bson.ObjectID, disableAfter int, now time.Time) (bool, error)
+}
+
+// DeliveryLog stores deliveries, pending and done
+type DeliveryLog interface {
+	// Create stores deliveries, skipping those whose webhook already has a
+	// delivery of the same EventID
+	Create(ctx context.Context, deliveries ...*Delivery) error
+	// List returns up to limit deliveries of a webhook, newest first
+	List(ctx context.Context, webhookID bson.ObjectID, limit int) ([]*Delivery, error)
+	Find(ctx context.Context, webhookID, deliveryID bson.ObjectID) (*Delivery, error)
+	// Claim takes the pending delivery of any user that is due first at
+	// now, counts an attempt and hides it from other claims until until.
+	// It returns nil when none is due.
+	Claim(ctx context.Context, now, until time.Time) (*Delivery, error)
+	// Finish records the outcome of the attempt on a claimed delivery
+	Finish(ctx context.Context, deliveryID bson.ObjectID, a Attempt) error
+	DeleteForWebhook(ctx context.Context, webhookID bson.ObjectID) (int64, error)
+	DeleteAllForUser(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
+
+// Doer sends delivery requests; *http.Client is one
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
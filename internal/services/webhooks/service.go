@@ -0,0 +1,297 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// maxWebhooksPerUser bounds the webhooks of one user
+	maxWebhooksPerUser = 20
+	// defaultDeliveries is the page size of the delivery log
+	defaultDeliveries = 20
+	// queueSize bounds the events waiting to become deliveries
+	queueSize = 1024
+)
+
+// Service manages webhook subscriptions and delivers note events to them
+type Service struct {
+	repo       Repository
+	deliveries DeliveryLog
+	access     notes.WorkspaceAccess
+	client     Doer
+	log        *slog.Logger
+
+	// queue takes the events broadcast without a commit from the hub to
+	// Run, which stores their deliveries
+	queue   chan notes.NoteEvent
+	dropped atomic.Uint64
+	wake    chan struct{}
+
+	// backoff is the delay after the first failed attempt; it doubles
+	// after each one
+	backoff time.Duration
+}
+
+// NewService creates a new webhooks service
+func NewService(repo Repository, deliveries DeliveryLog, client Doer, log *slog.Logger) *Service {
+	return &Service{
+		repo:       repo,
+		deliveries: deliveries,
+		client:     client,
+		log:        log,
+		queue:      make(chan notes.NoteEvent, queueSize),
+		wake:       make(chan struct{}, 1),
+		backoff:    backoffBase,
+	}
+}
+
+// SetWorkspaceAccess enables webhooks on shared workspaces
+func (s *Service) SetWorkspaceAccess(a notes.WorkspaceAccess) {
+	s.access = a
+}
+
+// List returns the user's webhooks
+func (s *Service) List(ctx context.Context, userID bson.ObjectID) (*ListWebhooksResponse, error) {
+	list, err := s.repo.List(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListWebhooks.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListWebhooks
+	}
+	return &ListWebhooksResponse{Webhooks: list}, nil
+}
+
+// Create subscribes a URL to the note events of the user's personal notes or
+// of a workspace they can read
+func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateWebhookRequest) (*Webhook, error) {
+	if err := checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	workspaceID, err := s.workspace(ctx, userID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.Count(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrCreateWebhook.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateWebhook
+	}
+	if n >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+
+	now := time.Now().UTC()
+	w := &Webhook{
+		ID:          bson.NewObjectID(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Events:      distinct(req.Events),
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, w); err != nil {
+		s.log.Error(ErrCreateWebhook.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateWebhook
+	}
+	return w, nil
+}
+
+// Get returns one webhook
+func (s *Service) Get(ctx context.Context, userID, webhookID bson.ObjectID) (*Webhook, error) {
+	return s.find(ctx, userID, webhookID)
+}
+
+// Update changes a webhook. Setting active, even to true, starts its count
+// of failures over.
+func (s *Service) Update(ctx context.Context, userID, webhookID bson.ObjectID, req UpdateWebhookRequest) (*Webhook, error) {
+	if req.URL != nil {
+		if err := checkURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	patch := UpdateWebhook{URL: req.URL, Secret: req.Secret, Active: req.Active}
+	if req.Events != nil {
+		events := distinct(*req.Events)
+		patch.Events = &events
+	}
+
+	w, err := s.repo.Update(ctx, userID, webhookID, patch)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		s.log.Error(ErrUpdateWebhook.Error(), "error", err, "user_id", userID.Hex(), "webhook_id", webhookID.Hex())
+		return nil, ErrUpdateWebhook
+	}
+	if req.Active != nil && *req.Active {
+		s.signal()
+	}
+	return w, nil
+}
+
+// Delete deletes a webhook with its delivery log; pending deliveries are
+// dropped
+func (s *Service) Delete(ctx context.Context, userID, webhookID bson.ObjectID) error {
+	if err := s.repo.Delete(ctx, userID, webhookID); err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		s.log.Error(ErrDeleteWebhook.Error(), "error", err, "user_id", userID.Hex(), "webhook_id", webhookID.Hex())
+		return ErrDeleteWebhook
+	}
+	if _, err := s.deliveries.DeleteForWebhook(ctx, webhookID); err != nil {
+		// The log expires on its own; only the pending entries linger
+		s.log.Error("failed to delete webhook deliveries", "error", err, "webhook_id", webhookID.Hex())
+	}
+	return nil
+}
+
+// Deliveries returns the delivery log of a webhook, newest first
+func (s *Service) Deliveries(ctx context.Context, userID, webhookID bson.ObjectID, req ListDeliveriesRequest) (*ListDeliveriesResponse, error) {
+	if _, err := s.find(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeliveries
+	}
+	list, err := s.deliveries.List(ctx, webhookID, limit)
+	if err != nil {
+		s.log.Error(ErrListDeliveries.Error(), "error", err, "user_id", userID.Hex(), "webhook_id", webhookID.Hex())
+		return nil, ErrListDeliveries
+	}
+	return &ListDeliveriesResponse{Deliveries: list}, nil
+}
+
+// Redeliver queues the payload of a logged delivery again, as a new delivery
+// with attempts of its own
+func (s *Service) Redeliver(ctx context.Context, userID, webhookID, deliveryID bson.ObjectID) (*Delivery, error) {
+	w, err := s.find(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !w.Active {
+		return nil, ErrWebhookDisabled
+	}
+
+	original, err := s.deliveries.Find(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		s.log.Error(ErrRedeliver.Error(), "error", err, "user_id", userID.Hex(), "delivery_id", deliveryID.Hex())
+		return nil, ErrRedeliver
+	}
+
+	d := newDelivery(w, original.Event, original.Payload, time.Now().UTC())
+	d.RedeliveryOf = &original.ID
+	if err := s.deliveries.Create(ctx, d); err != nil {
+		s.log.Error(ErrRedeliver.Error(), "error", err, "user_id", userID.Hex(), "delivery_id", deliveryID.Hex())
+		return nil, ErrRedeliver
+	}
+	s.signal()
+	return d, nil
+}
+
+// PurgeUser deletes every webhook of a user with their deliveries
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	deleted, err := s.repo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrDeleteWebhook.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteWebhook
+	}
+	if _, err := s.deliveries.DeleteAllForUser(ctx, userID); err != nil {
+		s.log.Error("failed to delete webhook deliveries", "error", err, "user_id", userID.Hex())
+		return ErrDeleteWebhook
+	}
+	s.log.Info("purged webhooks of deleted account", "user_id", userID.Hex(), "deleted", deleted)
+	return nil
+}
+
+// NoteChanged queues ev for the webhooks covering its note. Committed events,
+// which carry an EventID, are skipped: RecordEvent stores them. It is
+// registered with the hub, so it never blocks: when the queue is full the
+// event is dropped and counted in webhook_events_dropped_total.
+func (s *Service) NoteChanged(_ context.Context, ev notes.NoteEvent, _ []bson.ObjectID) {
+	if !ev.EventID.IsZero() {
+		return
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		droppedEvents.Inc()
+		if n := s.dropped.Add(1); n&(n-1) == 0 {
+			s.log.Warn("webhook queue full, dropping events", "dropped", n)
+		}
+	}
+}
+
+// workspace parses a workspace_id and checks that userID can read it. An
+// empty value or the user's own ID select the personal notes and yield nil.
+func (s *Service) workspace(ctx context.Context, userID bson.ObjectID, raw string) (*bson.ObjectID, error) {
+	if raw == "" || raw == userID.Hex() {
+		return nil, nil
+	}
+	id, err := bson.ObjectIDFromHex(raw)
+	if err != nil {
+		return nil, notes.ErrBadRequest
+	}
+	if s.access == nil {
+		return nil, notes.ErrWorkspaceNotFound
+	}
+	read, _, err := s.access.Access(ctx, id, userID)
+	if err != nil {
+		s.log.Error(ErrCreateWebhook.Error(), "error", err, "user_id", userID.Hex(), "workspace_id", raw)
+		return nil, ErrCreateWebhook
+	}
+	if !read {
+		return nil, notes.ErrWorkspaceNotFound
+	}
+	return &id, nil
+}
+
+func (s *Service) find(ctx context.Context, userID, webhookID bson.ObjectID) (*Webhook, error) {
+	w, err := s.repo.Find(ctx, userID, webhookID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		s.log.Error(ErrListWebhooks.Error(), "error", err, "user_id", userID.Hex(), "webhook_id", webhookID.Hex())
+		return nil, ErrListWebhooks
+	}
+	return w, nil
+}
+
+// checkURL rejects URLs other than absolute http and https ones
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// distinct drops repeated event types, keeping the first of each
+func distinct(events []string) []string {
+	var out []string
+	for _, e := range events {
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
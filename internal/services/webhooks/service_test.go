@@ -0,0 +1,415 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memRepo keeps webhooks in memory
+type memRepo struct {
+	mu    sync.Mutex
+	hooks []*Webhook
+}
+
+func (m *memRepo) Create(_ context.Context, w *Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *w
+	m.hooks = append(m.hooks, &cp)
+	return nil
+}
+
+func (m *memRepo) List(_ context.Context, userID bson.ObjectID) ([]*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []*Webhook{}
+	for _, w := range m.hooks {
+		if w.UserID == userID {
+			cp := *w
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepo) Count(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	list, _ := m.List(ctx, userID)
+	return int64(len(list)), nil
+}
+
+func (m *memRepo) lookup(webhookID bson.ObjectID, userID *bson.ObjectID) (*Webhook, error) {
+	for _, w := range m.hooks {
+		if w.ID == webhookID && (userID == nil || w.UserID == *userID) {
+			return w, nil
+		}
+	}
+	return nil, ErrWebhookNotFound
+}
+
+func (m *memRepo) Find(_ context.Context, userID, webhookID bson.ObjectID) (*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := m.lookup(webhookID, &userID)
+	if err != nil {
+		return nil, err
+	}
+	cp := *w
+	return &cp, nil
+}
+
+func (m *memRepo) Get(_ context.Context, webhookID bson.ObjectID) (*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := m.lookup(webhookID, nil)
+	if err != nil {
+		return nil, err
+	}
+	cp := *w
+	return &cp, nil
+}
+
+func (m *memRepo) Update(_ context.Context, userID, webhookID bson.ObjectID, patch UpdateWebhook) (*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := m.lookup(webhookID, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if patch.URL != nil {
+		w.URL = *patch.URL
+	}
+	if patch.Secret != nil {
+		w.Secret = *patch.Secret
+	}
+	if patch.Events != nil {
+		w.Events = *patch.Events
+	}
+	if patch.Active != nil {
+		w.Active, w.Failures, w.DisabledAt = *patch.Active, 0, nil
+	}
+	cp := *w
+	return &cp, nil
+}
+
+func (m *memRepo) Delete(_ context.Context, userID, webhookID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.hooks)
+	m.hooks = slices.DeleteFunc(m.hooks, func(w *Webhook) bool { return w.ID == webhookID && w.UserID == userID })
+	if len(m.hooks) == n {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (m *memRepo) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.hooks)
+	m.hooks = slices.DeleteFunc(m.hooks, func(w *Webhook) bool { return w.UserID == userID })
+	return int64(n - len(m.hooks)), nil
+}
+
+func (m *memRepo) Subscribed(_ context.Context, userID bson.ObjectID, workspaceID *bson.ObjectID, event string) ([]*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Webhook
+	for _, w := range m.hooks {
+		scoped := (workspaceID == nil && w.WorkspaceID == nil && w.UserID == userID) ||
+			(workspaceID != nil && w.WorkspaceID != nil && *w.WorkspaceID == *workspaceID)
+		if w.Active && scoped && (len(w.Events) == 0 || slices.Contains(w.Events, event)) {
+			cp := *w
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepo) RecordSuccess(_ context.Context, webhookID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, err := m.lookup(webhookID, nil); err == nil {
+		w.Failures = 0
+	}
+	return nil
+}
+
+func (m *memRepo) RecordFailure(_ context.Context, webhookID bson.ObjectID, disableAfter int, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := m.lookup(webhookID, nil)
+	if err != nil || !w.Active {
+		return false, nil
+	}
+	w.Failures++
+	if w.Failures < disableAfter {
+		return false, nil
+	}
+	w.Active, w.DisabledAt = false, &now
+	return true, nil
+}
+
+// memLog keeps deliveries in memory, one per webhook and EventID
+type memLog struct {
+	mu         sync.Mutex
+	deliveries []*Delivery
+	createErr  error
+}
+
+func (m *memLog) Create(_ context.Context, deliveries ...*Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.createErr != nil {
+		return m.createErr
+	}
+	for _, d := range deliveries {
+		if d.EventID != nil && slices.ContainsFunc(m.deliveries, func(o *Delivery) bool {
+			return o.WebhookID == d.WebhookID && o.EventID != nil && *o.EventID == *d.EventID
+		}) {
+			continue
+		}
+		cp := *d
+		m.deliveries = append(m.deliveries, &cp)
+	}
+	return nil
+}
+
+func (m *memLog) List(_ context.Context, webhookID bson.ObjectID, limit int) ([]*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []*Delivery{}
+	for i := len(m.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if d := m.deliveries[i]; d.WebhookID == webhookID {
+			cp := *d
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *memLog) Find(_ context.Context, webhookID, deliveryID bson.ObjectID) (*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID && d.WebhookID == webhookID {
+			cp := *d
+			return &cp, nil
+		}
+	}
+	return nil, ErrDeliveryNotFound
+}
+
+func (m *memLog) Claim(_ context.Context, now, until time.Time) (*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due *Delivery
+	for _, d := range m.deliveries {
+		if d.Status == StatusPending && !d.NextAt.After(now) && (due == nil || d.NextAt.Before(*due.NextAt)) {
+			due = d
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	due.Attempts++
+	due.NextAt = &until
+	cp := *due
+	return &cp, nil
+}
+
+func (m *memLog) Finish(_ context.Context, deliveryID bson.ObjectID, a Attempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID {
+			d.Status, d.ResponseStatus, d.Error, d.NextAt = a.Status, a.ResponseStatus, a.Error, a.NextAt
+		}
+	}
+	return nil
+}
+
+func (m *memLog) DeleteForWebhook(_ context.Context, webhookID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.deliveries)
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(d *Delivery) bool { return d.WebhookID == webhookID })
+	return int64(n - len(m.deliveries)), nil
+}
+
+func (m *memLog) DeleteAllForUser(_ context.Context, userID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.deliveries)
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(d *Delivery) bool { return d.UserID == userID })
+	return int64(n - len(m.deliveries)), nil
+}
+
+func (m *memLog) all() []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Delivery, len(m.deliveries))
+	for i, d := range m.deliveries {
+		out[i] = *d
+	}
+	return out
+}
+
+// fakeAccess grants read access to the listed workspace members
+type fakeAccess map[bson.ObjectID][]bson.ObjectID
+
+func (f fakeAccess) Access(_ context.Context, workspaceID, userID bson.ObjectID) (bool, bool, error) {
+	ok := slices.Contains(f[workspaceID], userID)
+	return ok, ok, nil
+}
+
+func newTestService(client Doer) (*Service, *memRepo, *memLog) {
+	repo, log := &memRepo{}, &memLog{}
+	return NewService(repo, log, client, silentLogger), repo, log
+}
+
+func TestServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+	svc, _, _ := newTestService(nil)
+
+	w, err := svc.Create(ctx, userID, CreateWebhookRequest{
+		URL:    "https://chat.example.com/hooks",
+		Secret: "0123456789abcdef",
+		Events: []string{"created", "deleted", "created"},
+	})
+	require.NoError(t, err)
+	assert.True(t, w.Active)
+	assert.Equal(t, []string{"created", "deleted"}, w.Events)
+	assert.Nil(t, w.WorkspaceID)
+
+	_, err = svc.Create(ctx, userID, CreateWebhookRequest{URL: "ftp://files.example.com", Secret: "0123456789abcdef"})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+	_, err = svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef", WorkspaceID: workspaceID.Hex()})
+	assert.ErrorIs(t, err, notes.ErrWorkspaceNotFound, "workspaces are not configured")
+
+	svc.SetWorkspaceAccess(fakeAccess{workspaceID: {userID}})
+	shared, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef", WorkspaceID: workspaceID.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, &workspaceID, shared.WorkspaceID)
+	_, err = svc.Create(ctx, bson.NewObjectID(), CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef", WorkspaceID: workspaceID.Hex()})
+	assert.ErrorIs(t, err, notes.ErrWorkspaceNotFound)
+
+	for range maxWebhooksPerUser - 2 {
+		_, err = svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef"})
+		require.NoError(t, err)
+	}
+	_, err = svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef"})
+	assert.ErrorIs(t, err, ErrTooManyWebhooks)
+}
+
+func TestServiceEnqueue(t *testing.T) {
+	ctx := context.Background()
+	userID, memberID, leftID := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	workspaceID := bson.NewObjectID()
+
+	svc, repo, log := newTestService(nil)
+	svc.SetWorkspaceAccess(fakeAccess{workspaceID: {userID, memberID}})
+	all := &Webhook{ID: bson.NewObjectID(), UserID: userID, Active: true}
+	deletes := &Webhook{ID: bson.NewObjectID(), UserID: userID, Events: []string{"deleted"}, Active: true}
+	paused := &Webhook{ID: bson.NewObjectID(), UserID: userID}
+	member := &Webhook{ID: bson.NewObjectID(), UserID: memberID, WorkspaceID: &workspaceID, Active: true}
+	left := &Webhook{ID: bson.NewObjectID(), UserID: leftID, WorkspaceID: &workspaceID, Active: true}
+	for _, w := range []*Webhook{all, deletes, paused, member, left} {
+		require.NoError(t, repo.Create(ctx, w))
+	}
+
+	note := &notes.Note{ID: bson.NewObjectID(), UserID: userID, Title: "Plan"}
+	stored, err := svc.enqueue(ctx, notes.NoteEvent{Type: "created", Note: note})
+	require.NoError(t, err)
+	assert.True(t, stored)
+	got := log.all()
+	require.Len(t, got, 1)
+	assert.Equal(t, all.ID, got[0].WebhookID)
+	assert.Equal(t, StatusPending, got[0].Status)
+	var payload notes.NoteEvent
+	require.NoError(t, json.Unmarshal([]byte(got[0].Payload), &payload))
+	assert.Equal(t, "created", payload.Type)
+	assert.Equal(t, note.ID, payload.Note.ID)
+
+	// Workspace notes go to the webhooks of members who can still read them
+	shared := &notes.Note{ID: bson.NewObjectID(), UserID: userID, WorkspaceID: &workspaceID}
+	stored, err = svc.enqueue(ctx, notes.NoteEvent{Type: "deleted", Note: shared})
+	require.NoError(t, err)
+	assert.True(t, stored)
+	got = log.all()
+	require.Len(t, got, 2)
+	assert.Equal(t, member.ID, got[1].WebhookID)
+
+	stored, err = svc.enqueue(ctx, notes.NoteEvent{Type: notes.EventViewCounts})
+	require.NoError(t, err)
+	assert.False(t, stored)
+}
+
+func TestServiceRecordEvent(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc, repo, log := newTestService(nil)
+	w := &Webhook{ID: bson.NewObjectID(), UserID: userID, Active: true}
+	require.NoError(t, repo.Create(ctx, w))
+
+	note := &notes.Note{ID: bson.NewObjectID(), UserID: userID}
+	ev := notes.NoteEvent{Type: "updated", Note: note, EventID: bson.NewObjectID()}
+
+	// The notes outbox keeps an event whose deliveries were not stored
+	log.createErr = errors.New("no primary")
+	assert.Error(t, svc.RecordEvent(ctx, ev))
+	assert.Empty(t, log.all())
+
+	log.createErr = nil
+	require.NoError(t, svc.RecordEvent(ctx, ev))
+	require.NoError(t, svc.RecordEvent(ctx, ev), "the relay may hand an event over again")
+	got := log.all()
+	require.Len(t, got, 1)
+	assert.Equal(t, &ev.EventID, got[0].EventID)
+
+	// Committed events reach webhooks through RecordEvent only; the hub
+	// queue takes the others
+	svc.NoteChanged(ctx, ev, nil)
+	assert.Empty(t, svc.queue)
+	svc.NoteChanged(ctx, notes.NoteEvent{Type: "moved", Note: note}, nil)
+	assert.Len(t, svc.queue, 1)
+}
+
+func TestServiceDeleteAndPurge(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	svc, _, log := newTestService(nil)
+
+	w, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://x.example.com", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	other, err := svc.Create(ctx, userID, CreateWebhookRequest{URL: "https://y.example.com", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, log.Create(ctx, newDelivery(w, "created", "{}", now), newDelivery(other, "created", "{}", now)))
+
+	assert.ErrorIs(t, svc.Delete(ctx, bson.NewObjectID(), w.ID), ErrWebhookNotFound)
+	require.NoError(t, svc.Delete(ctx, userID, w.ID))
+	assert.Len(t, log.all(), 1, "the deliveries of the webhook go with it")
+
+	require.NoError(t, svc.PurgeUser(ctx, userID))
+	assert.Empty(t, log.all())
+	list, err := svc.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, list.Webhooks)
+}
@@ -0,0 +1,46 @@
+package workspaces
+
+import "errors"
+
+// ErrWorkspaceNotFound is returned when a workspace does not exist or the caller is not a member.
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// ErrMemberNotFound is returned when a user is not a member of the workspace.
+var ErrMemberNotFound = errors.New("member not found")
+
+// ErrInvitationNotFound is returned when an invitation token is unknown or expired.
+var ErrInvitationNotFound = errors.New("invitation not found or expired")
+
+// ErrForbidden is returned when the caller's role does not allow the action.
+var ErrForbidden = errors.New("insufficient workspace role")
+
+// ErrAlreadyMember is returned when the invited user already belongs to the workspace.
+var ErrAlreadyMember = errors.New("user is already a member")
+
+// ErrInvitationEmail is returned when an invitation is accepted by a different account.
+var ErrInvitationEmail = errors.New("invitation was sent to another email address")
+
+// ErrOwnerRole is returned when the owner would be demoted or removed.
+var ErrOwnerRole = errors.New("the workspace owner cannot be demoted or removed")
+
+// ErrPersonalWorkspace is returned when sharing actions target the personal workspace.
+var ErrPersonalWorkspace = errors.New("the personal workspace cannot be shared")
+
+// ErrCreateWorkspace is returned when workspace creation fails.
+var ErrCreateWorkspace = errors.New("failed to create workspace")
+
+// ErrListWorkspaces is returned when workspaces or members cannot be read.
+var ErrListWorkspaces = errors.New("failed to list workspaces")
+
+// ErrUpdateWorkspace is returned when a workspace or membership cannot be changed.
+var ErrUpdateWorkspace = errors.New("failed to update workspace")
+
+// ErrDeleteWorkspace is returned when workspace deletion fails.
+var ErrDeleteWorkspace = errors.New("failed to delete workspace")
+
+// ErrInvite is returned when an invitation cannot be created or sent.
+var ErrInvite = errors.New("failed to invite to workspace")
+
+// ErrMailNotConfigured is returned when an invitation cannot be emailed
+// because no mail transport is configured.
+var ErrMailNotConfigured = errors.New("email delivery is not configured")
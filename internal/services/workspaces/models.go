@@ -0,0 +1,110 @@
+package workspaces
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Membership roles, from most to least privileged
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// roleRank orders roles so checks can ask for "at least" a role
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// atLeast reports whether role grants at least the rights of min
+func atLeast(role, minRole string) bool {
+	return roleRank[role] >= roleRank[minRole]
+}
+
+// PersonalName is the display name of the implicit personal workspace
+const PersonalName = "Personal"
+
+// Workspace is a shared board whose notes every member can see
+type Workspace struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd5"`
+	Name      string        `bson:"name" json:"name" example:"Team board"`
+	OwnerID   bson.ObjectID `bson:"owner_id" json:"owner_id" example:"683cdb8aa96ad71e8e075bd0"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at" example:"2025-06-01T23:00:26.005703677Z"`
+
+	// Personal marks the implicit workspace holding a user's own notes. Its
+	// ID is the user's ID and it is never stored.
+	Personal bool `bson:"-" json:"personal" example:"false"`
+	// Role is the caller's role, filled in on reads
+	Role string `bson:"-" json:"role,omitempty" example:"member"`
+}
+
+// Member links a user to a workspace with a role
+type Member struct {
+	WorkspaceID bson.ObjectID `bson:"workspace_id" json:"workspace_id" example:"683cdb8aa96ad71e8e075bd5"`
+	UserID      bson.ObjectID `bson:"user_id" json:"user_id" example:"683cdb8aa96ad71e8e075bd0"`
+	Role        string        `bson:"role" json:"role" example:"member"`
+	JoinedAt    time.Time     `bson:"joined_at" json:"joined_at" example:"2025-06-01T23:00:26.005703677Z"`
+
+	// Email is looked up when members are listed
+	Email string `bson:"-" json:"email,omitempty" example:"user@example.com"`
+}
+
+// Invitation asks the owner of an email address to join a workspace
+type Invitation struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id" example:"683cdb8aa96ad71e8e075bd7"`
+	WorkspaceID bson.ObjectID `bson:"workspace_id" json:"workspace_id" example:"683cdb8aa96ad71e8e075bd5"`
+	Email       string        `bson:"email" json:"email" example:"user@example.com"`
+	Role        string        `bson:"role" json:"role" example:"member"`
+	TokenHash   string        `bson:"token_hash" json:"-"`
+	InvitedBy   bson.ObjectID `bson:"invited_by" json:"invited_by" example:"683cdb8aa96ad71e8e075bd0"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at" example:"2025-06-01T23:00:26.005703677Z"`
+	ExpiresAt   time.Time     `bson:"expires_at" json:"expires_at" example:"2025-06-08T23:00:26.005703677Z"`
+}
+
+// CreateWorkspaceRequest creates a shared workspace
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100" example:"Team board"`
+}
+
+// RenameWorkspaceRequest renames a shared workspace
+type RenameWorkspaceRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100" example:"Team board"`
+}
+
+// InviteRequest invites an email address to a workspace
+type InviteRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+	Role  string `json:"role" validate:"required,oneof=admin member viewer" example:"member"`
+}
+
+// SetMemberRoleRequest changes a member's role
+type SetMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member viewer" example:"viewer"`
+}
+
+// AcceptInvitationRequest joins a workspace with the token from the invitation email
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required" example:"invitation_token_example_abcd1234"`
+}
+
+// ListWorkspacesResponse lists the caller's workspaces, personal first
+type ListWorkspacesResponse struct {
+	Workspaces []*Workspace `json:"workspaces"`
+}
+
+// ListMembersResponse lists the members of a workspace
+type ListMembersResponse struct {
+	Members []*Member `json:"members"`
+}
+
+// ListInvitationsResponse lists the pending invitations of a workspace
+type ListInvitationsResponse struct {
+	Invitations []*Invitation `json:"invitations"`
+}
@@ -0,0 +1,50 @@
+package workspaces
+
+import (
+	"context"
+
+	"note-pulse/internal/services/auth"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WorkspacesRepo stores shared workspaces
+type WorkspacesRepo interface {
+	Create(ctx context.Context, ws *Workspace) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*Workspace, error)
+	FindByIDs(ctx context.Context, ids []bson.ObjectID) ([]*Workspace, error)
+	ListOwnedBy(ctx context.Context, ownerID bson.ObjectID) ([]*Workspace, error)
+	Rename(ctx context.Context, id bson.ObjectID, name string) (*Workspace, error)
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+// MembersRepo stores workspace memberships
+type MembersRepo interface {
+	Add(ctx context.Context, m *Member) error
+	Find(ctx context.Context, workspaceID, userID bson.ObjectID) (*Member, error)
+	ListByWorkspace(ctx context.Context, workspaceID bson.ObjectID) ([]*Member, error)
+	ListByUser(ctx context.Context, userID bson.ObjectID) ([]*Member, error)
+	SetRole(ctx context.Context, workspaceID, userID bson.ObjectID, role string) error
+	Remove(ctx context.Context, workspaceID, userID bson.ObjectID) error
+	RemoveAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) error
+	RemoveAllForUser(ctx context.Context, userID bson.ObjectID) error
+}
+
+// InvitationsRepo stores pending invitations
+type InvitationsRepo interface {
+	Create(ctx context.Context, inv *Invitation) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	ListByWorkspace(ctx context.Context, workspaceID bson.ObjectID) ([]*Invitation, error)
+	Delete(ctx context.Context, workspaceID, id bson.ObjectID) error
+	DeleteAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) error
+}
+
+// UsersRepo looks up the accounts behind memberships
+type UsersRepo interface {
+	FindByID(ctx context.Context, id bson.ObjectID) (*auth.User, error)
+}
+
+// NotesRepo removes the notes of a deleted workspace
+type NotesRepo interface {
+	DeleteAllForWorkspace(ctx context.Context, workspaceID bson.ObjectID) (int64, error)
+}
@@ -0,0 +1,495 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"note-pulse/internal/services/auth"
+	"note-pulse/internal/utils/crypto"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// invitationTTL is how long an invitation link stays valid
+const invitationTTL = 7 * 24 * time.Hour
+
+// Mailer delivers workspace invitations
+type Mailer interface {
+	SendWorkspaceInvitation(ctx context.Context, to, workspaceName, token string) error
+}
+
+// Service manages shared workspaces, their members and invitations. Every
+// user also has an implicit personal workspace whose ID is the user's ID; it
+// holds the notes without a workspace_id and cannot be shared.
+type Service struct {
+	workspaces  WorkspacesRepo
+	members     MembersRepo
+	invitations InvitationsRepo
+	users       UsersRepo
+	notes       NotesRepo
+	mailer      Mailer
+	log         *slog.Logger
+}
+
+// NewService creates a new workspaces service
+func NewService(workspaces WorkspacesRepo, members MembersRepo, invitations InvitationsRepo, users UsersRepo, notes NotesRepo, log *slog.Logger) *Service {
+	return &Service{
+		workspaces:  workspaces,
+		members:     members,
+		invitations: invitations,
+		users:       users,
+		notes:       notes,
+		log:         log,
+	}
+}
+
+// SetMailer wires the transport used for invitation emails. Without one,
+// invitations fail with ErrMailNotConfigured.
+func (s *Service) SetMailer(m Mailer) {
+	s.mailer = m
+}
+
+// personalWorkspace describes userID's implicit personal workspace
+func personalWorkspace(userID bson.ObjectID) *Workspace {
+	return &Workspace{
+		ID:       userID,
+		Name:     PersonalName,
+		OwnerID:  userID,
+		Personal: true,
+		Role:     RoleOwner,
+	}
+}
+
+// List returns the personal workspace followed by every shared workspace the
+// user belongs to
+func (s *Service) List(ctx context.Context, userID bson.ObjectID) (*ListWorkspacesResponse, error) {
+	memberships, err := s.members.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListWorkspaces
+	}
+
+	roles := make(map[bson.ObjectID]string, len(memberships))
+	ids := make([]bson.ObjectID, 0, len(memberships))
+	for _, m := range memberships {
+		roles[m.WorkspaceID] = m.Role
+		ids = append(ids, m.WorkspaceID)
+	}
+
+	shared, err := s.workspaces.FindByIDs(ctx, ids)
+	if err != nil {
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrListWorkspaces
+	}
+
+	result := make([]*Workspace, 0, len(shared)+1)
+	result = append(result, personalWorkspace(userID))
+	for _, ws := range shared {
+		ws.Role = roles[ws.ID]
+		result = append(result, ws)
+	}
+	return &ListWorkspacesResponse{Workspaces: result}, nil
+}
+
+// Create creates a shared workspace owned by userID
+func (s *Service) Create(ctx context.Context, userID bson.ObjectID, req CreateWorkspaceRequest) (*Workspace, error) {
+	now := time.Now().UTC()
+	ws := &Workspace{
+		ID:        bson.NewObjectID(),
+		Name:      strings.TrimSpace(req.Name),
+		OwnerID:   userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.workspaces.Create(ctx, ws); err != nil {
+		s.log.Error(ErrCreateWorkspace.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrCreateWorkspace
+	}
+
+	owner := &Member{WorkspaceID: ws.ID, UserID: userID, Role: RoleOwner, JoinedAt: now}
+	if err := s.members.Add(ctx, owner); err != nil {
+		s.log.Error(ErrCreateWorkspace.Error(), "error", err, "user_id", userID.Hex(), "workspace_id", ws.ID.Hex())
+		if derr := s.workspaces.Delete(ctx, ws.ID); derr != nil {
+			s.log.Error("failed to roll back workspace", "error", derr, "workspace_id", ws.ID.Hex())
+		}
+		return nil, ErrCreateWorkspace
+	}
+
+	s.log.Info("workspace created", "user_id", userID.Hex(), "workspace_id", ws.ID.Hex())
+	ws.Role = RoleOwner
+	return ws, nil
+}
+
+// Get returns a workspace the user belongs to
+func (s *Service) Get(ctx context.Context, userID, workspaceID bson.ObjectID) (*Workspace, error) {
+	if workspaceID == userID {
+		return personalWorkspace(userID), nil
+	}
+
+	role, err := s.require(ctx, userID, workspaceID, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, workspaceID, role)
+}
+
+// Rename renames a shared workspace. Admins and the owner may rename.
+func (s *Service) Rename(ctx context.Context, userID, workspaceID bson.ObjectID, req RenameWorkspaceRequest) (*Workspace, error) {
+	role, err := s.require(ctx, userID, workspaceID, RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.workspaces.Rename(ctx, workspaceID, strings.TrimSpace(req.Name))
+	if err != nil {
+		if errors.Is(err, ErrWorkspaceNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		s.log.Error(ErrUpdateWorkspace.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrUpdateWorkspace
+	}
+	ws.Role = role
+	return ws, nil
+}
+
+// Delete deletes a shared workspace with its notes, members and invitations.
+// Only the owner may delete.
+func (s *Service) Delete(ctx context.Context, userID, workspaceID bson.ObjectID) error {
+	if _, err := s.require(ctx, userID, workspaceID, RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.deleteWorkspace(ctx, workspaceID); err != nil {
+		return ErrDeleteWorkspace
+	}
+
+	s.log.Info("workspace deleted", "user_id", userID.Hex(), "workspace_id", workspaceID.Hex())
+	return nil
+}
+
+// deleteWorkspace removes everything stored for a workspace. The membership
+// documents go last so an interrupted delete can be retried by the owner.
+func (s *Service) deleteWorkspace(ctx context.Context, workspaceID bson.ObjectID) error {
+	steps := []struct {
+		what string
+		run  func() error
+	}{
+		{"notes", func() error { _, err := s.notes.DeleteAllForWorkspace(ctx, workspaceID); return err }},
+		{"invitations", func() error { return s.invitations.DeleteAllForWorkspace(ctx, workspaceID) }},
+		{"workspace", func() error { return s.workspaces.Delete(ctx, workspaceID) }},
+		{"members", func() error { return s.members.RemoveAllForWorkspace(ctx, workspaceID) }},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil && !errors.Is(err, ErrWorkspaceNotFound) {
+			s.log.Error(ErrDeleteWorkspace.Error(), "error", err, "step", step.what, "workspace_id", workspaceID.Hex())
+			return err
+		}
+	}
+	return nil
+}
+
+// ListMembers lists the members of a workspace with their emails
+func (s *Service) ListMembers(ctx context.Context, userID, workspaceID bson.ObjectID) (*ListMembersResponse, error) {
+	if _, err := s.require(ctx, userID, workspaceID, RoleViewer); err != nil {
+		return nil, err
+	}
+
+	members, err := s.members.ListByWorkspace(ctx, workspaceID)
+	if err != nil {
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrListWorkspaces
+	}
+
+	for _, m := range members {
+		user, err := s.users.FindByID(ctx, m.UserID)
+		if err != nil {
+			if !errors.Is(err, auth.ErrUserNotFound) {
+				s.log.Warn("failed to look up workspace member", "error", err, "user_id", m.UserID.Hex())
+			}
+			continue
+		}
+		m.Email = user.Email
+	}
+	return &ListMembersResponse{Members: members}, nil
+}
+
+// SetMemberRole changes the role of a member. Admins and the owner may change
+// roles; the owner's own role is fixed.
+func (s *Service) SetMemberRole(ctx context.Context, userID, workspaceID, memberID bson.ObjectID, role string) (*Member, error) {
+	if _, err := s.require(ctx, userID, workspaceID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	member, err := s.members.Find(ctx, workspaceID, memberID)
+	if err != nil {
+		return nil, s.memberError(err, workspaceID)
+	}
+	if member.Role == RoleOwner {
+		return nil, ErrOwnerRole
+	}
+
+	if err := s.members.SetRole(ctx, workspaceID, memberID, role); err != nil {
+		return nil, s.memberError(err, workspaceID)
+	}
+
+	s.log.Info("workspace role changed", "actor_id", userID.Hex(), "workspace_id", workspaceID.Hex(), "user_id", memberID.Hex(), "role", role)
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember removes a member. Admins and the owner may remove others and
+// every member but the owner may leave.
+func (s *Service) RemoveMember(ctx context.Context, userID, workspaceID, memberID bson.ObjectID) error {
+	minRole := RoleAdmin
+	if memberID == userID {
+		minRole = RoleViewer
+	}
+	if _, err := s.require(ctx, userID, workspaceID, minRole); err != nil {
+		return err
+	}
+
+	member, err := s.members.Find(ctx, workspaceID, memberID)
+	if err != nil {
+		return s.memberError(err, workspaceID)
+	}
+	if member.Role == RoleOwner {
+		return ErrOwnerRole
+	}
+
+	if err := s.members.Remove(ctx, workspaceID, memberID); err != nil {
+		return s.memberError(err, workspaceID)
+	}
+
+	s.log.Info("workspace member removed", "actor_id", userID.Hex(), "workspace_id", workspaceID.Hex(), "user_id", memberID.Hex())
+	return nil
+}
+
+// Invite emails an invitation link to join a workspace. Admins and the owner
+// may invite.
+func (s *Service) Invite(ctx context.Context, userID, workspaceID bson.ObjectID, req InviteRequest) (*Invitation, error) {
+	if _, err := s.require(ctx, userID, workspaceID, RoleAdmin); err != nil {
+		return nil, err
+	}
+	if s.mailer == nil {
+		return nil, ErrMailNotConfigured
+	}
+	ws, err := s.load(ctx, workspaceID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := crypto.NewOpaqueToken()
+	if err != nil {
+		s.log.Error(ErrInvite.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrInvite
+	}
+
+	now := time.Now().UTC()
+	inv := &Invitation{
+		ID:          bson.NewObjectID(),
+		WorkspaceID: workspaceID,
+		Email:       strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:        req.Role,
+		TokenHash:   crypto.HashToken(token),
+		InvitedBy:   userID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(invitationTTL),
+	}
+
+	if err := s.invitations.Create(ctx, inv); err != nil {
+		s.log.Error(ErrInvite.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrInvite
+	}
+
+	if err := s.mailer.SendWorkspaceInvitation(ctx, inv.Email, ws.Name, token); err != nil {
+		s.log.Error(ErrInvite.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		if derr := s.invitations.Delete(ctx, workspaceID, inv.ID); derr != nil {
+			s.log.Error("failed to drop unsent invitation", "error", derr, "invitation_id", inv.ID.Hex())
+		}
+		return nil, ErrInvite
+	}
+
+	s.log.Info("workspace invitation sent", "actor_id", userID.Hex(), "workspace_id", workspaceID.Hex(), "invitation_id", inv.ID.Hex())
+	return inv, nil
+}
+
+// ListInvitations lists pending invitations. Admins and the owner may list.
+func (s *Service) ListInvitations(ctx context.Context, userID, workspaceID bson.ObjectID) (*ListInvitationsResponse, error) {
+	if _, err := s.require(ctx, userID, workspaceID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitations.ListByWorkspace(ctx, workspaceID)
+	if err != nil {
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrListWorkspaces
+	}
+	return &ListInvitationsResponse{Invitations: invitations}, nil
+}
+
+// RevokeInvitation deletes a pending invitation. Admins and the owner may revoke.
+func (s *Service) RevokeInvitation(ctx context.Context, userID, workspaceID, invitationID bson.ObjectID) error {
+	if _, err := s.require(ctx, userID, workspaceID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.invitations.Delete(ctx, workspaceID, invitationID); err != nil {
+		if errors.Is(err, ErrInvitationNotFound) {
+			return ErrInvitationNotFound
+		}
+		s.log.Error(ErrUpdateWorkspace.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return ErrUpdateWorkspace
+	}
+	return nil
+}
+
+// AcceptInvitation adds userID to the workspace named by an invitation token.
+// The invitation must have been sent to the user's current email address.
+func (s *Service) AcceptInvitation(ctx context.Context, userID bson.ObjectID, req AcceptInvitationRequest) (*Workspace, error) {
+	inv, err := s.invitations.FindByTokenHash(ctx, crypto.HashToken(req.Token))
+	if err != nil {
+		if !errors.Is(err, ErrInvitationNotFound) {
+			s.log.Error(ErrUpdateWorkspace.Error(), "error", err, "user_id", userID.Hex())
+			return nil, ErrUpdateWorkspace
+		}
+		return nil, ErrInvitationNotFound
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvitationNotFound
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrUpdateWorkspace.Error(), "error", err, "user_id", userID.Hex())
+		return nil, ErrUpdateWorkspace
+	}
+	if !strings.EqualFold(user.Email, inv.Email) {
+		return nil, ErrInvitationEmail
+	}
+
+	ws, err := s.load(ctx, inv.WorkspaceID, inv.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	member := &Member{WorkspaceID: inv.WorkspaceID, UserID: userID, Role: inv.Role, JoinedAt: time.Now().UTC()}
+	addErr := s.members.Add(ctx, member)
+	if addErr != nil && !errors.Is(addErr, ErrAlreadyMember) {
+		s.log.Error(ErrUpdateWorkspace.Error(), "error", addErr, "user_id", userID.Hex(), "workspace_id", inv.WorkspaceID.Hex())
+		return nil, ErrUpdateWorkspace
+	}
+
+	if err := s.invitations.Delete(ctx, inv.WorkspaceID, inv.ID); err != nil && !errors.Is(err, ErrInvitationNotFound) {
+		s.log.Warn("failed to delete accepted invitation", "error", err, "invitation_id", inv.ID.Hex())
+	}
+
+	if addErr != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	s.log.Info("workspace invitation accepted", "user_id", userID.Hex(), "workspace_id", inv.WorkspaceID.Hex(), "role", inv.Role)
+	return ws, nil
+}
+
+// Access reports whether userID may read and write the notes of a shared
+// workspace. It implements notes.WorkspaceAccess.
+func (s *Service) Access(ctx context.Context, workspaceID, userID bson.ObjectID) (bool, bool, error) {
+	member, err := s.members.Find(ctx, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return true, atLeast(member.Role, RoleMember), nil
+}
+
+// MemberIDs lists the users of a workspace. It implements
+// notes.WorkspaceMembers so the hub can fan events out.
+func (s *Service) MemberIDs(ctx context.Context, workspaceID bson.ObjectID) ([]bson.ObjectID, error) {
+	members, err := s.members.ListByWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]bson.ObjectID, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return ids, nil
+}
+
+// PurgeUser deletes the workspaces a deleted account owns and its remaining
+// memberships. It is registered with the auth service.
+func (s *Service) PurgeUser(ctx context.Context, userID bson.ObjectID) error {
+	owned, err := s.workspaces.ListOwnedBy(ctx, userID)
+	if err != nil {
+		s.log.Error(ErrDeleteWorkspace.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteWorkspace
+	}
+
+	for _, ws := range owned {
+		if err := s.deleteWorkspace(ctx, ws.ID); err != nil {
+			return ErrDeleteWorkspace
+		}
+	}
+
+	if err := s.members.RemoveAllForUser(ctx, userID); err != nil {
+		s.log.Error(ErrDeleteWorkspace.Error(), "error", err, "user_id", userID.Hex())
+		return ErrDeleteWorkspace
+	}
+
+	s.log.Info("purged workspaces of deleted account", "user_id", userID.Hex(), "deleted", len(owned))
+	return nil
+}
+
+// require returns userID's role in a shared workspace, failing unless it is
+// at least minRole. Non-members get ErrWorkspaceNotFound so workspace IDs do
+// not leak.
+func (s *Service) require(ctx context.Context, userID, workspaceID bson.ObjectID, minRole string) (string, error) {
+	if workspaceID == userID {
+		return "", ErrPersonalWorkspace
+	}
+
+	member, err := s.members.Find(ctx, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return "", ErrWorkspaceNotFound
+		}
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "user_id", userID.Hex(), "workspace_id", workspaceID.Hex())
+		return "", ErrListWorkspaces
+	}
+
+	if !atLeast(member.Role, minRole) {
+		return "", ErrForbidden
+	}
+	return member.Role, nil
+}
+
+// load reads a workspace and stamps the caller's role on it
+func (s *Service) load(ctx context.Context, workspaceID bson.ObjectID, role string) (*Workspace, error) {
+	ws, err := s.workspaces.FindByID(ctx, workspaceID)
+	if err != nil {
+		if errors.Is(err, ErrWorkspaceNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		s.log.Error(ErrListWorkspaces.Error(), "error", err, "workspace_id", workspaceID.Hex())
+		return nil, ErrListWorkspaces
+	}
+	ws.Role = role
+	return ws, nil
+}
+
+// memberError maps membership repository failures
+func (s *Service) memberError(err error, workspaceID bson.ObjectID) error {
+	if errors.Is(err, ErrMemberNotFound) {
+		return ErrMemberNotFound
+	}
+	s.log.Error(ErrUpdateWorkspace.Error(), "error", err, "workspace_id", workspaceID.Hex())
+	return ErrUpdateWorkspace
+}
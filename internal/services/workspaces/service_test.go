@@ -0,0 +1,454 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"note-pulse/internal/services/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var silentLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memStore is an in-memory implementation of every repository the service uses
+type memStore struct {
+	mu           sync.Mutex
+	workspaces   map[bson.ObjectID]*Workspace
+	members      []*Member
+	invitations  map[bson.ObjectID]*Invitation
+	users        map[bson.ObjectID]*auth.User
+	deletedNotes []bson.ObjectID
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		workspaces:  map[bson.ObjectID]*Workspace{},
+		invitations: map[bson.ObjectID]*Invitation{},
+		users:       map[bson.ObjectID]*auth.User{},
+	}
+}
+
+type memWorkspaces struct{ *memStore }
+type memMembers struct{ *memStore }
+type memInvitations struct{ *memStore }
+type memUsers struct{ *memStore }
+type memNotes struct{ *memStore }
+
+func (m memWorkspaces) Create(_ context.Context, ws *Workspace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *ws
+	m.workspaces[ws.ID] = &cp
+	return nil
+}
+
+func (m memWorkspaces) FindByID(_ context.Context, id bson.ObjectID) (*Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws, ok := m.workspaces[id]
+	if !ok {
+		return nil, ErrWorkspaceNotFound
+	}
+	cp := *ws
+	return &cp, nil
+}
+
+func (m memWorkspaces) FindByIDs(ctx context.Context, ids []bson.ObjectID) ([]*Workspace, error) {
+	result := []*Workspace{}
+	for _, id := range ids {
+		if ws, err := m.FindByID(ctx, id); err == nil {
+			result = append(result, ws)
+		}
+	}
+	return result, nil
+}
+
+func (m memWorkspaces) ListOwnedBy(_ context.Context, ownerID bson.ObjectID) ([]*Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*Workspace{}
+	for _, ws := range m.workspaces {
+		if ws.OwnerID == ownerID {
+			result = append(result, ws)
+		}
+	}
+	return result, nil
+}
+
+func (m memWorkspaces) Rename(ctx context.Context, id bson.ObjectID, name string) (*Workspace, error) {
+	m.mu.Lock()
+	ws, ok := m.workspaces[id]
+	if ok {
+		ws.Name = name
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrWorkspaceNotFound
+	}
+	return m.FindByID(ctx, id)
+}
+
+func (m memWorkspaces) Delete(_ context.Context, id bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.workspaces, id)
+	return nil
+}
+
+func (m memMembers) Add(_ context.Context, member *Member) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.members {
+		if existing.WorkspaceID == member.WorkspaceID && existing.UserID == member.UserID {
+			return ErrAlreadyMember
+		}
+	}
+	cp := *member
+	m.members = append(m.members, &cp)
+	return nil
+}
+
+func (m memMembers) Find(_ context.Context, workspaceID, userID bson.ObjectID) (*Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.members {
+		if existing.WorkspaceID == workspaceID && existing.UserID == userID {
+			cp := *existing
+			return &cp, nil
+		}
+	}
+	return nil, ErrMemberNotFound
+}
+
+func (m memMembers) filter(keep func(*Member) bool) []*Member {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*Member{}
+	for _, existing := range m.members {
+		if keep(existing) {
+			cp := *existing
+			result = append(result, &cp)
+		}
+	}
+	return result
+}
+
+func (m memMembers) ListByWorkspace(_ context.Context, workspaceID bson.ObjectID) ([]*Member, error) {
+	return m.filter(func(x *Member) bool { return x.WorkspaceID == workspaceID }), nil
+}
+
+func (m memMembers) ListByUser(_ context.Context, userID bson.ObjectID) ([]*Member, error) {
+	return m.filter(func(x *Member) bool { return x.UserID == userID }), nil
+}
+
+func (m memMembers) SetRole(_ context.Context, workspaceID, userID bson.ObjectID, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.members {
+		if existing.WorkspaceID == workspaceID && existing.UserID == userID {
+			existing.Role = role
+			return nil
+		}
+	}
+	return ErrMemberNotFound
+}
+
+func (m memMembers) removeWhere(drop func(*Member) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.members[:0]
+	for _, existing := range m.members {
+		if !drop(existing) {
+			kept = append(kept, existing)
+		}
+	}
+	m.members = kept
+}
+
+func (m memMembers) Remove(_ context.Context, workspaceID, userID bson.ObjectID) error {
+	m.removeWhere(func(x *Member) bool { return x.WorkspaceID == workspaceID && x.UserID == userID })
+	return nil
+}
+
+func (m memMembers) RemoveAllForWorkspace(_ context.Context, workspaceID bson.ObjectID) error {
+	m.removeWhere(func(x *Member) bool { return x.WorkspaceID == workspaceID })
+	return nil
+}
+
+func (m memMembers) RemoveAllForUser(_ context.Context, userID bson.ObjectID) error {
+	m.removeWhere(func(x *Member) bool { return x.UserID == userID })
+	return nil
+}
+
+func (m memInvitations) Create(_ context.Context, inv *Invitation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invitations[inv.ID] = inv
+	return nil
+}
+
+func (m memInvitations) FindByTokenHash(_ context.Context, tokenHash string) (*Invitation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, inv := range m.invitations {
+		if inv.TokenHash == tokenHash {
+			return inv, nil
+		}
+	}
+	return nil, ErrInvitationNotFound
+}
+
+func (m memInvitations) ListByWorkspace(_ context.Context, workspaceID bson.ObjectID) ([]*Invitation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*Invitation{}
+	for _, inv := range m.invitations {
+		if inv.WorkspaceID == workspaceID {
+			result = append(result, inv)
+		}
+	}
+	return result, nil
+}
+
+func (m memInvitations) Delete(_ context.Context, workspaceID, id bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if inv, ok := m.invitations[id]; !ok || inv.WorkspaceID != workspaceID {
+		return ErrInvitationNotFound
+	}
+	delete(m.invitations, id)
+	return nil
+}
+
+func (m memInvitations) DeleteAllForWorkspace(_ context.Context, workspaceID bson.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, inv := range m.invitations {
+		if inv.WorkspaceID == workspaceID {
+			delete(m.invitations, id)
+		}
+	}
+	return nil
+}
+
+func (m memUsers) FindByID(_ context.Context, id bson.ObjectID) (*auth.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (m memNotes) DeleteAllForWorkspace(_ context.Context, workspaceID bson.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedNotes = append(m.deletedNotes, workspaceID)
+	return 0, nil
+}
+
+// captureMailer records the last invitation token
+type captureMailer struct {
+	to, token string
+}
+
+func (m *captureMailer) SendWorkspaceInvitation(_ context.Context, to, _ string, token string) error {
+	m.to, m.token = to, token
+	return nil
+}
+
+// failingMailer cannot reach its relay
+type failingMailer struct{}
+
+func (failingMailer) SendWorkspaceInvitation(context.Context, string, string, string) error {
+	return errors.New("relay unreachable")
+}
+
+func newTestService() (*Service, *memStore, *captureMailer) {
+	store := newMemStore()
+	svc := NewService(memWorkspaces{store}, memMembers{store}, memInvitations{store}, memUsers{store}, memNotes{store}, silentLogger)
+	mailer := &captureMailer{}
+	svc.SetMailer(mailer)
+	return svc, store, mailer
+}
+
+func (m *memStore) addUser(email string) bson.ObjectID {
+	id := bson.NewObjectID()
+	m.users[id] = &auth.User{ID: id, Email: email}
+	return id
+}
+
+func TestServiceListIncludesPersonalWorkspace(t *testing.T) {
+	svc, store, _ := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+
+	ws, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: " Team board "})
+	require.NoError(t, err)
+	assert.Equal(t, "Team board", ws.Name)
+	assert.Equal(t, RoleOwner, ws.Role)
+
+	resp, err := svc.List(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, resp.Workspaces, 2)
+	assert.True(t, resp.Workspaces[0].Personal)
+	assert.Equal(t, owner, resp.Workspaces[0].ID)
+	assert.Equal(t, ws.ID, resp.Workspaces[1].ID)
+	assert.Equal(t, RoleOwner, resp.Workspaces[1].Role)
+
+	_, err = svc.Invite(ctx, owner, owner, InviteRequest{Email: "x@example.com", Role: RoleMember})
+	assert.ErrorIs(t, err, ErrPersonalWorkspace)
+}
+
+func TestServiceInvitationFlow(t *testing.T) {
+	svc, store, mailer := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+	invitee := store.addUser("teammate@example.com")
+	stranger := store.addUser("stranger@example.com")
+
+	ws, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: "Team"})
+	require.NoError(t, err)
+
+	inv, err := svc.Invite(ctx, owner, ws.ID, InviteRequest{Email: "Teammate@Example.com", Role: RoleViewer})
+	require.NoError(t, err)
+	assert.Equal(t, "teammate@example.com", inv.Email)
+	assert.Equal(t, "teammate@example.com", mailer.to)
+	assert.NotEqual(t, mailer.token, inv.TokenHash, "only the hash is stored")
+
+	_, err = svc.AcceptInvitation(ctx, stranger, AcceptInvitationRequest{Token: mailer.token})
+	assert.ErrorIs(t, err, ErrInvitationEmail)
+
+	joined, err := svc.AcceptInvitation(ctx, invitee, AcceptInvitationRequest{Token: mailer.token})
+	require.NoError(t, err)
+	assert.Equal(t, ws.ID, joined.ID)
+	assert.Equal(t, RoleViewer, joined.Role)
+
+	_, err = svc.AcceptInvitation(ctx, invitee, AcceptInvitationRequest{Token: mailer.token})
+	assert.ErrorIs(t, err, ErrInvitationNotFound, "tokens are single use")
+
+	read, write, err := svc.Access(ctx, ws.ID, invitee)
+	require.NoError(t, err)
+	assert.True(t, read)
+	assert.False(t, write, "viewers are read-only")
+
+	read, _, err = svc.Access(ctx, ws.ID, stranger)
+	require.NoError(t, err)
+	assert.False(t, read)
+
+	ids, err := svc.MemberIDs(ctx, ws.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []bson.ObjectID{owner, invitee}, ids)
+}
+
+func TestServiceExpiredInvitation(t *testing.T) {
+	svc, store, mailer := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+	invitee := store.addUser("late@example.com")
+
+	ws, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: "Team"})
+	require.NoError(t, err)
+	inv, err := svc.Invite(ctx, owner, ws.ID, InviteRequest{Email: "late@example.com", Role: RoleMember})
+	require.NoError(t, err)
+	inv.ExpiresAt = time.Now().Add(-time.Minute)
+
+	_, err = svc.AcceptInvitation(ctx, invitee, AcceptInvitationRequest{Token: mailer.token})
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+}
+
+func TestServiceRoleChecks(t *testing.T) {
+	svc, store, _ := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+	admin := store.addUser("admin@example.com")
+	member := store.addUser("member@example.com")
+	outsider := store.addUser("outsider@example.com")
+
+	ws, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: "Team"})
+	require.NoError(t, err)
+	require.NoError(t, store.addMember(ws.ID, admin, RoleAdmin))
+	require.NoError(t, store.addMember(ws.ID, member, RoleMember))
+
+	_, err = svc.Get(ctx, outsider, ws.ID)
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound, "non-members do not learn the workspace exists")
+
+	_, err = svc.Invite(ctx, member, ws.ID, InviteRequest{Email: "x@example.com", Role: RoleMember})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = svc.SetMemberRole(ctx, admin, ws.ID, owner, RoleViewer)
+	assert.ErrorIs(t, err, ErrOwnerRole)
+
+	changed, err := svc.SetMemberRole(ctx, admin, ws.ID, member, RoleViewer)
+	require.NoError(t, err)
+	assert.Equal(t, RoleViewer, changed.Role)
+
+	assert.ErrorIs(t, svc.Delete(ctx, admin, ws.ID), ErrForbidden, "only the owner deletes")
+	assert.ErrorIs(t, svc.RemoveMember(ctx, owner, ws.ID, owner), ErrOwnerRole)
+	require.NoError(t, svc.RemoveMember(ctx, member, ws.ID, member), "members may leave")
+
+	members, err := svc.ListMembers(ctx, admin, ws.ID)
+	require.NoError(t, err)
+	require.Len(t, members.Members, 2)
+	assert.Equal(t, "owner@example.com", members.Members[0].Email)
+}
+
+func TestServicePurgeUser(t *testing.T) {
+	svc, store, _ := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+	other := store.addUser("other@example.com")
+
+	owned, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: "Mine"})
+	require.NoError(t, err)
+	joined, err := svc.Create(ctx, other, CreateWorkspaceRequest{Name: "Theirs"})
+	require.NoError(t, err)
+	require.NoError(t, store.addMember(joined.ID, owner, RoleMember))
+	require.NoError(t, store.addMember(owned.ID, other, RoleMember))
+
+	require.NoError(t, svc.PurgeUser(ctx, owner))
+
+	assert.NotContains(t, store.workspaces, owned.ID)
+	assert.Contains(t, store.workspaces, joined.ID)
+	assert.Equal(t, []bson.ObjectID{owned.ID}, store.deletedNotes)
+
+	ids, err := svc.MemberIDs(ctx, joined.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []bson.ObjectID{other}, ids)
+	ids, err = svc.MemberIDs(ctx, owned.ID)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func (m *memStore) addMember(workspaceID, userID bson.ObjectID, role string) error {
+	return memMembers{m}.Add(context.Background(), &Member{WorkspaceID: workspaceID, UserID: userID, Role: role, JoinedAt: time.Now()})
+}
+
+func TestServiceInviteFailsWhenNotSent(t *testing.T) {
+	svc, store, _ := newTestService()
+	ctx := context.Background()
+	owner := store.addUser("owner@example.com")
+	ws, err := svc.Create(ctx, owner, CreateWorkspaceRequest{Name: "Team"})
+	require.NoError(t, err)
+
+	svc.SetMailer(failingMailer{})
+	_, err = svc.Invite(ctx, owner, ws.ID, InviteRequest{Email: "x@example.com", Role: RoleMember})
+	assert.ErrorIs(t, err, ErrInvite)
+
+	svc.SetMailer(nil)
+	_, err = svc.Invite(ctx, owner, ws.ID, InviteRequest{Email: "x@example.com", Role: RoleMember})
+	assert.ErrorIs(t, err, ErrMailNotConfigured)
+
+	list, err := svc.ListInvitations(ctx, owner, ws.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list.Invitations, "unsent invitations are not kept")
+}
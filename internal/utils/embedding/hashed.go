@@ -0,0 +1,128 @@
+// Package embedding turns note text into vectors whose cosine similarity
+// tracks how related two notes are. It runs on the CPU without any model
+// files.
+package embedding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Feature weights. Words count most, concepts bridge synonyms and trigrams
+// catch inflections and typos.
+const (
+	wordWeight    = 1.0
+	conceptWeight = 1.5
+	trigramWeight = 1.0
+)
+
+// DefaultDims is the vector size of NewHashed callers that have no reason
+// to pick another
+const DefaultDims = 256
+
+// Hashed embeds text with the hashing trick: words, their character
+// trigrams and the concepts of a small bundled lexicon are hashed into a
+// fixed number of signed buckets, and the result is L2-normalised.
+type Hashed struct {
+	dims int
+}
+
+// NewHashed creates a Hashed embedder producing vectors of dims floats
+func NewHashed(dims int) *Hashed {
+	return &Hashed{dims: dims}
+}
+
+// Model names the feature set and size. Vectors of different models must
+// not be compared.
+func (h *Hashed) Model() string {
+	return fmt.Sprintf("hashed-ngram-v1-%d", h.dims)
+}
+
+// Embed returns the unit vector of text, or a zero vector when text has no
+// words
+func (h *Hashed) Embed(text string) []float32 {
+	acc := make([]float64, h.dims)
+	for _, word := range words(text) {
+		h.add(acc, "w:"+word, wordWeight)
+		if concept, ok := conceptOf(word); ok {
+			h.add(acc, "c:"+concept, conceptWeight)
+		}
+
+		runes := []rune("^" + word + "$")
+		n := len(runes) - 2
+		for i := 0; i < n; i++ {
+			h.add(acc, "t:"+string(runes[i:i+3]), trigramWeight/math.Sqrt(float64(n)))
+		}
+	}
+
+	var norm float64
+	for _, v := range acc {
+		norm += v * v
+	}
+	vec := make([]float32, h.dims)
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range acc {
+		vec[i] = float32(v / norm)
+	}
+	return vec
+}
+
+// add hashes feature into a bucket. One hash bit picks the sign so that
+// collisions cancel out on average instead of piling up.
+func (h *Hashed) add(acc []float64, feature string, weight float64) {
+	f := fnv.New64a()
+	_, _ = f.Write([]byte(feature))
+	sum := f.Sum64()
+
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	acc[sum%uint64(h.dims)] += weight
+}
+
+// conceptOf looks word up in the lexicon, also without a plural "s"
+func conceptOf(word string) (string, bool) {
+	if concept, ok := lexicon[word]; ok {
+		return concept, true
+	}
+	concept, ok := lexicon[strings.TrimSuffix(word, "s")]
+	return concept, ok
+}
+
+// words splits text into lower-cased words without stop words
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := fields[:0]
+	for _, w := range fields {
+		if _, stop := stopWords[w]; !stop {
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
+
+// Cosine returns the cosine similarity of two vectors of the same model
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,44 @@
+package embedding
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashedEmbed(t *testing.T) {
+	h := NewHashed(DefaultDims)
+	assert.Equal(t, "hashed-ngram-v1-256", h.Model())
+
+	vec := h.Embed("Standup notes: the deploy is blocked")
+	assert.Len(t, vec, DefaultDims)
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	assert.InDelta(t, 1, math.Sqrt(norm), 1e-6)
+
+	assert.Equal(t, vec, h.Embed("standup NOTES the deploy is blocked!"))
+	assert.Zero(t, Cosine(h.Embed("the and of"), vec))
+}
+
+func TestHashedSimilarity(t *testing.T) {
+	h := NewHashed(DefaultDims)
+	sim := func(a, b string) float64 { return Cosine(h.Embed(a), h.Embed(b)) }
+
+	// Synonyms meet through the lexicon
+	assert.Greater(t, sim("standup", "daily sync"), sim("standup", "grocery list"))
+	// Inflections and typos meet through trigrams
+	assert.Greater(t, sim("deployment checklist", "deployments checklists"), 0.5)
+	assert.Greater(t, sim("quarterly budget", "quartely budget"), sim("quarterly budget", "gym workout"))
+	assert.InDelta(t, 1, sim("same text", "same text"), 1e-6)
+}
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1, Cosine([]float32{1, 0}, []float32{2, 0}), 1e-9)
+	assert.InDelta(t, 0, Cosine([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.InDelta(t, -1, Cosine([]float32{1, 0}, []float32{-1, 0}), 1e-9)
+	assert.Zero(t, Cosine([]float32{1}, []float32{1, 0}))
+	assert.Zero(t, Cosine([]float32{0, 0}, []float32{1, 0}))
+}
@@ -0,0 +1,49 @@
+package embedding
+
+// concepts groups words that notes use interchangeably. Every word of a group
+// adds the group's feature, so "standup" and "daily sync" end up close even
+// though they share no letters. Changing the groups changes the vectors; bump
+// the version in Hashed.Model when doing so.
+var concepts = map[string][]string{
+	"meeting":  {"meeting", "standup", "sync", "call", "huddle", "scrum", "retro", "retrospective", "daily", "agenda", "minutes", "1on1"},
+	"task":     {"todo", "task", "action", "chore", "checklist", "followup"},
+	"bug":      {"bug", "issue", "defect", "error", "crash", "incident", "outage", "regression"},
+	"idea":     {"idea", "brainstorm", "brainstorming", "proposal", "concept", "pitch"},
+	"plan":     {"plan", "planning", "roadmap", "schedule", "milestone", "timeline", "quarter", "quarterly", "okr", "goal"},
+	"money":    {"budget", "cost", "expense", "invoice", "price", "spending", "payment", "salary", "tax", "taxes"},
+	"travel":   {"trip", "travel", "flight", "hotel", "vacation", "holiday", "itinerary", "packing"},
+	"health":   {"doctor", "dentist", "appointment", "health", "workout", "gym", "run", "running", "diet", "medication"},
+	"shopping": {"grocery", "groceries", "shopping", "buy", "purchase", "store", "supermarket"},
+	"people":   {"team", "colleague", "manager", "hire", "hiring", "interview", "onboarding", "candidate"},
+	"release":  {"release", "deploy", "deployment", "launch", "ship", "shipping", "rollout", "rollback"},
+	"review":   {"review", "feedback", "critique", "approval"},
+	"docs":     {"docs", "documentation", "spec", "specification", "readme", "wiki", "design"},
+	"learning": {"learn", "learning", "course", "study", "book", "reading", "tutorial", "lecture"},
+	"family":   {"family", "kids", "mom", "dad", "birthday", "anniversary", "wedding", "gift"},
+	"home":     {"home", "house", "apartment", "rent", "repair", "cleaning", "garden", "moving"},
+}
+
+// lexicon maps every word of concepts to its group
+var lexicon = func() map[string]string {
+	m := make(map[string]string)
+	for concept, words := range concepts {
+		for _, w := range words {
+			m[w] = concept
+		}
+	}
+	return m
+}()
+
+// stopWords carry no topic and are left out of vectors
+var stopWords = func() map[string]struct{} {
+	m := make(map[string]struct{})
+	for _, w := range []string{
+		"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+		"has", "have", "i", "if", "in", "into", "is", "it", "its", "me", "my",
+		"of", "on", "or", "our", "so", "that", "the", "their", "then", "there",
+		"this", "to", "up", "was", "we", "were", "will", "with", "you", "your",
+	} {
+		m[w] = struct{}{}
+	}
+	return m
+}()
@@ -0,0 +1,42 @@
+// Package markdown renders note bodies to HTML following CommonMark with the
+// GitHub extensions: tables, task lists, strikethrough and autolinks.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"note-pulse/internal/utils/sanitize"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// md is safe for concurrent use. Raw HTML in the source is omitted, and the
+// output still goes through sanitize.HTML.
+var md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// ToHTML renders Markdown source to safe HTML
+func ToHTML(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return sanitize.HTML(buf.String()), nil
+}
+
+// PlainToHTML renders plain text as paragraphs separated by blank lines, with
+// line breaks kept
+func PlainToHTML(text string) string {
+	var b strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(strings.Trim(para, "\n")), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"emphasis", "**bold** _it_ ~~gone~~", "<p><strong>bold</strong> <em>it</em> <del>gone</del></p>\n"},
+		{"heading", "# Title", "<h1>Title</h1>\n"},
+		{
+			name: "task list",
+			src:  "- [x] done\n- [ ] open",
+			want: "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n<li><input disabled=\"\" type=\"checkbox\"> open</li>\n</ul>\n",
+		},
+		{
+			name: "table",
+			src:  "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			want: "<table>\n<thead>\n<tr>\n<th style=\"text-align: left\">a</th>\n<th style=\"text-align: right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td style=\"text-align: left\">1</td>\n<td style=\"text-align: right\">2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{"code", "```go\nx := 1\n```", "<pre><code class=\"language-go\">x := 1\n</code></pre>\n"},
+		{"autolink", "see https://example.com", "<p>see <a href=\"https://example.com\" rel=\"nofollow noopener\" target=\"_blank\">https://example.com</a></p>\n"},
+		{"unsafe link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"raw html", "<script>alert(1)</script>\n\nok", "\n<p>ok</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToHTML(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlainToHTML(t *testing.T) {
+	assert.Equal(t, "<p>a &lt;b&gt;<br>\nc</p>\n<p>**d**</p>\n", PlainToHTML("a <b>\nc\n\n\n**d**"))
+	assert.Empty(t, PlainToHTML(""))
+}
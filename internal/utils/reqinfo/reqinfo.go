@@ -0,0 +1,35 @@
+// Package reqinfo carries per-request client metadata (IP, user agent,
+// request ID) from the HTTP layer down to the services without widening
+// their signatures.
+package reqinfo
+
+import "context"
+
+// Info describes the client that issued the current request.
+type Info struct {
+	IP        string
+	UserAgent string
+	// RequestID ties log lines and audit entries to one request
+	RequestID string
+}
+
+type ctxKey struct{}
+
+// ContextKey is the key under which Info is stored. It is exported so the
+// HTTP layer can attach Info as a fasthttp user value (c.Locals), which
+// *fasthttp.RequestCtx exposes through its context.Context Value method.
+var ContextKey = ctxKey{}
+
+// With returns a copy of ctx that carries info.
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ContextKey, info)
+}
+
+// From returns the Info stored in ctx, or the zero value when absent.
+func From(ctx context.Context) Info {
+	if ctx == nil {
+		return Info{}
+	}
+	info, _ := ctx.Value(ContextKey).(Info)
+	return info
+}
@@ -0,0 +1,406 @@
+// Package rrule computes occurrences of RFC 5545 recurrence rules. It
+// supports the subset that describes calendar-style schedules: FREQ (DAILY,
+// WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and
+// BYMONTH, with weeks starting on Monday. Occurrences take their time of day
+// and location from DTSTART.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is returned for rules that cannot be parsed or use parts
+// outside the supported subset
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Freq is the FREQ of a rule
+type Freq int
+
+// Supported frequencies
+const (
+	Daily Freq = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+// maxPeriods bounds the periods scanned for one occurrence, so a rule that
+// matches rarely, or never, cannot spin
+const maxPeriods = 50_000
+
+var freqs = map[string]Freq{"DAILY": Daily, "WEEKLY": Weekly, "MONTHLY": Monthly, "YEARLY": Yearly}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// byDay is a BYDAY entry; n is its ordinal within the month, 0 for every
+// such weekday
+type byDay struct {
+	n  int
+	wd time.Weekday
+}
+
+// Rule is a recurrence rule anchored at its DTSTART
+type Rule struct {
+	freq       Freq
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []byDay
+	byMonthDay []int
+	byMonth    []time.Month
+	start      time.Time
+}
+
+// Parse parses rule, with or without its "RRULE:" prefix, anchored at start.
+// A rule that never occurs is invalid.
+func Parse(rule string, start time.Time) (*Rule, error) {
+	rule = strings.TrimSpace(rule)
+	if len(rule) >= 6 && strings.EqualFold(rule[:6], "RRULE:") {
+		rule = rule[6:]
+	}
+
+	r := &Rule{interval: 1, start: start.Truncate(time.Second)}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || key == "" || value == "" {
+			return nil, invalid("malformed part %q", part)
+		}
+		if seen[key] {
+			return nil, invalid("%s given twice", key)
+		}
+		seen[key] = true
+
+		if err := r.set(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if !seen["FREQ"] {
+		return nil, invalid("FREQ is required")
+	}
+	if seen["COUNT"] && seen["UNTIL"] {
+		return nil, invalid("COUNT and UNTIL are exclusive")
+	}
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	if r.Next(r.start.Add(-time.Second)).IsZero() {
+		return nil, invalid("the rule never occurs")
+	}
+	return r, nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
+
+// set applies one KEY=VALUE part
+func (r *Rule) set(key, value string) error {
+	switch key {
+	case "FREQ":
+		f, ok := freqs[value]
+		if !ok {
+			return invalid("unsupported FREQ %s", value)
+		}
+		r.freq = f
+	case "INTERVAL":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 1000 {
+			return invalid("INTERVAL must be 1 to 1000")
+		}
+		r.interval = n
+	case "COUNT":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 10_000 {
+			return invalid("COUNT must be 1 to 10000")
+		}
+		r.count = n
+	case "UNTIL":
+		t, err := r.parseUntil(value)
+		if err != nil {
+			return err
+		}
+		r.until = t
+	case "BYDAY":
+		for _, v := range strings.Split(value, ",") {
+			d, err := parseByDay(v)
+			if err != nil {
+				return err
+			}
+			r.byDay = append(r.byDay, d)
+		}
+	case "BYMONTHDAY":
+		for _, v := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(v)
+			if err != nil || n == 0 || n < -31 || n > 31 {
+				return invalid("bad BYMONTHDAY %s", v)
+			}
+			r.byMonthDay = append(r.byMonthDay, n)
+		}
+	case "BYMONTH":
+		for _, v := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 12 {
+				return invalid("bad BYMONTH %s", v)
+			}
+			r.byMonth = append(r.byMonth, time.Month(n))
+		}
+	case "WKST":
+		if value != "MO" {
+			return invalid("only WKST=MO is supported")
+		}
+	default:
+		return invalid("unsupported part %s", key)
+	}
+	return nil
+}
+
+// parseUntil reads UTC, floating and date-only UNTIL values; the latter two
+// are in the location of DTSTART, and a date includes its whole day
+func (r *Rule) parseUntil(value string) (time.Time, error) {
+	loc := r.start.Location()
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, invalid("bad UNTIL %s", value)
+}
+
+func parseByDay(v string) (byDay, error) {
+	if len(v) < 2 {
+		return byDay{}, invalid("bad BYDAY %s", v)
+	}
+	wd, ok := weekdays[v[len(v)-2:]]
+	if !ok {
+		return byDay{}, invalid("bad BYDAY %s", v)
+	}
+	d := byDay{wd: wd}
+	if prefix := v[:len(v)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return byDay{}, invalid("bad BYDAY %s", v)
+		}
+		d.n = n
+	}
+	return d, nil
+}
+
+// check rejects combinations outside the subset
+func (r *Rule) check() error {
+	for _, d := range r.byDay {
+		if d.n != 0 && r.freq != Monthly {
+			return invalid("BYDAY ordinals need FREQ=MONTHLY")
+		}
+	}
+	if r.freq == Yearly && len(r.byDay) > 0 {
+		return invalid("BYDAY is not supported with FREQ=YEARLY")
+	}
+	if r.freq == Weekly && len(r.byMonthDay) > 0 {
+		return invalid("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	}
+	return nil
+}
+
+// Next returns the first occurrence after after, or the zero time when the
+// rule has ended
+func (r *Rule) Next(after time.Time) time.Time {
+	k, n := 0, 0
+	if r.count == 0 {
+		// Without COUNT, earlier periods need not be counted
+		k = max(r.periodsBefore(after)-1, 0)
+	}
+
+	for end := k + maxPeriods; k < end; k++ {
+		for _, t := range r.period(k) {
+			if t.Before(r.start) {
+				continue
+			}
+			if !r.until.IsZero() && t.After(r.until) {
+				return time.Time{}
+			}
+			n++
+			if r.count > 0 && n > r.count {
+				return time.Time{}
+			}
+			if t.After(after) {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// periodsBefore estimates how many whole periods lie between DTSTART and t
+func (r *Rule) periodsBefore(t time.Time) int {
+	if !t.After(r.start) {
+		return 0
+	}
+	t = t.In(r.start.Location())
+	switch r.freq {
+	case Daily:
+		return int(dayNumber(t)-dayNumber(r.start)) / r.interval
+	case Weekly:
+		return int(dayNumber(t)-dayNumber(r.start)) / 7 / r.interval
+	case Monthly:
+		return ((t.Year()-r.start.Year())*12 + int(t.Month()-r.start.Month())) / r.interval
+	default:
+		return (t.Year() - r.start.Year()) / r.interval
+	}
+}
+
+// dayNumber numbers the civil date of t
+func dayNumber(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// period returns the candidate occurrences of the k-th period in order,
+// before the DTSTART, UNTIL and COUNT limits
+func (r *Rule) period(k int) []time.Time {
+	y, m, d := r.start.Date()
+	var dates []time.Time
+	switch r.freq {
+	case Daily:
+		dates = []time.Time{civil(y, m, d+k*r.interval)}
+	case Weekly:
+		monday := civil(y, m, d-mondayOffset(r.start.Weekday()))
+		week := monday.AddDate(0, 0, 7*k*r.interval)
+		days := r.byDay
+		if len(days) == 0 {
+			days = []byDay{{wd: r.start.Weekday()}}
+		}
+		for _, bd := range days {
+			dates = append(dates, week.AddDate(0, 0, mondayOffset(bd.wd)))
+		}
+	case Monthly:
+		first := civil(y, m+time.Month(k*r.interval), 1)
+		dates = r.monthDays(first, d)
+	case Yearly:
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			dates = append(dates, r.monthDays(civil(y+k*r.interval, month, 1), d)...)
+		}
+	}
+
+	times := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		if !r.matches(date) {
+			continue
+		}
+		times = append(times, time.Date(date.Year(), date.Month(), date.Day(),
+			r.start.Hour(), r.start.Minute(), r.start.Second(), 0, r.start.Location()))
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(times, time.Time.Equal)
+}
+
+// monthDays returns the days of the month starting at first that BYMONTHDAY
+// and BYDAY pick, both when both are given, or day when neither is
+func (r *Rule) monthDays(first time.Time, day int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	var byMonthDay, byWeekday []int
+	useByDay := r.freq == Monthly && len(r.byDay) > 0
+	for _, n := range r.byMonthDay {
+		if n < 0 {
+			n = last + 1 + n
+		}
+		if n >= 1 && n <= last {
+			byMonthDay = append(byMonthDay, n)
+		}
+	}
+	if useByDay {
+		for _, bd := range r.byDay {
+			byWeekday = append(byWeekday, weekdaysOfMonth(first, last, bd)...)
+		}
+	}
+
+	var days []int
+	switch {
+	case len(r.byMonthDay) > 0 && useByDay:
+		for _, n := range byMonthDay {
+			if slices.Contains(byWeekday, n) {
+				days = append(days, n)
+			}
+		}
+	case len(r.byMonthDay) > 0:
+		days = byMonthDay
+	case useByDay:
+		days = byWeekday
+	case day <= last:
+		// A month without DTSTART's day, e.g. the 31st, is skipped
+		days = []int{day}
+	}
+
+	dates := make([]time.Time, 0, len(days))
+	for _, n := range days {
+		dates = append(dates, first.AddDate(0, 0, n-1))
+	}
+	return dates
+}
+
+// weekdaysOfMonth returns the days of the month starting at first that are
+// bd's weekday, or only its n-th one
+func weekdaysOfMonth(first time.Time, last int, bd byDay) []int {
+	var days []int
+	for day := 1 + (int(bd.wd)-int(first.Weekday())+7)%7; day <= last; day += 7 {
+		days = append(days, day)
+	}
+	switch {
+	case bd.n > 0 && bd.n <= len(days):
+		return days[bd.n-1 : bd.n]
+	case bd.n < 0 && -bd.n <= len(days):
+		return days[len(days)+bd.n : len(days)+bd.n+1]
+	case bd.n != 0:
+		return nil
+	}
+	return days
+}
+
+// matches applies the BY parts that only filter the dates of a period
+func (r *Rule) matches(date time.Time) bool {
+	if len(r.byMonth) > 0 && r.freq != Yearly && !slices.Contains(r.byMonth, date.Month()) {
+		return false
+	}
+	if r.freq == Daily {
+		if len(r.byDay) > 0 && !slices.ContainsFunc(r.byDay, func(bd byDay) bool { return bd.wd == date.Weekday() }) {
+			return false
+		}
+		if len(r.byMonthDay) > 0 {
+			last := date.AddDate(0, 1, -date.Day()).Day()
+			if !slices.ContainsFunc(r.byMonthDay, func(n int) bool {
+				return n == date.Day() || n < 0 && last+1+n == date.Day()
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// civil returns the date y-m-d, normalised, at midnight UTC
+func civil(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// mondayOffset is how many days wd comes after Monday
+func mondayOffset(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
@@ -0,0 +1,122 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// occurrences returns up to n occurrences of rule from start
+func occurrences(t *testing.T, rule string, start time.Time, n int) []string {
+	t.Helper()
+	r, err := Parse(rule, start)
+	require.NoError(t, err)
+
+	var out []string
+	for at := start.Add(-time.Second); len(out) < n; {
+		at = r.Next(at)
+		if at.IsZero() {
+			break
+		}
+		out = append(out, at.Format("2006-01-02 15:04 Mon"))
+	}
+	return out
+}
+
+func TestNext(t *testing.T) {
+	// Thursday
+	start := time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{"daily", "FREQ=DAILY", []string{"2026-01-01 09:30 Thu", "2026-01-02 09:30 Fri", "2026-01-03 09:30 Sat"}},
+		{"prefix and case", "rrule:freq=daily;interval=2", []string{"2026-01-01 09:30 Thu", "2026-01-03 09:30 Sat", "2026-01-05 09:30 Mon"}},
+		{"weekdays", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", []string{"2026-01-01 09:30 Thu", "2026-01-02 09:30 Fri", "2026-01-05 09:30 Mon"}},
+		{"weekly on start day", "FREQ=WEEKLY", []string{"2026-01-01 09:30 Thu", "2026-01-08 09:30 Thu", "2026-01-15 09:30 Thu"}},
+		{"every monday", "FREQ=WEEKLY;BYDAY=MO", []string{"2026-01-05 09:30 Mon", "2026-01-12 09:30 Mon", "2026-01-19 09:30 Mon"}},
+		{"fortnightly", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", []string{"2026-01-02 09:30 Fri", "2026-01-12 09:30 Mon", "2026-01-16 09:30 Fri"}},
+		{"monthly on start day", "FREQ=MONTHLY;COUNT=2", []string{"2026-01-01 09:30 Thu", "2026-02-01 09:30 Sun"}},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", []string{"2026-01-31 09:30 Sat", "2026-02-28 09:30 Sat", "2026-03-31 09:30 Tue"}},
+		{"first monday", "FREQ=MONTHLY;BYDAY=1MO", []string{"2026-01-05 09:30 Mon", "2026-02-02 09:30 Mon", "2026-03-02 09:30 Mon"}},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", []string{"2026-01-30 09:30 Fri", "2026-02-27 09:30 Fri", "2026-03-27 09:30 Fri"}},
+		{"friday the 13th", "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", []string{"2026-02-13 09:30 Fri", "2026-03-13 09:30 Fri", "2026-11-13 09:30 Fri"}},
+		{"quarterly", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15", []string{"2026-01-15 09:30 Thu", "2026-04-15 09:30 Wed", "2026-07-15 09:30 Wed"}},
+		{"yearly by month", "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=1", []string{"2026-03-01 09:30 Sun", "2026-09-01 09:30 Tue", "2027-03-01 09:30 Mon"}},
+		{"count", "FREQ=WEEKLY;BYDAY=MO;COUNT=2", []string{"2026-01-05 09:30 Mon", "2026-01-12 09:30 Mon"}},
+		{"until", "FREQ=DAILY;UNTIL=20260103", []string{"2026-01-01 09:30 Thu", "2026-01-02 09:30 Fri", "2026-01-03 09:30 Sat"}},
+		{"until utc", "FREQ=DAILY;UNTIL=20260102T093000Z", []string{"2026-01-01 09:30 Thu", "2026-01-02 09:30 Fri"}},
+		{"month filter", "FREQ=WEEKLY;BYDAY=MO;BYMONTH=2", []string{"2026-02-02 09:30 Mon", "2026-02-09 09:30 Mon", "2026-02-16 09:30 Mon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, occurrences(t, tt.rule, start, 3))
+		})
+	}
+}
+
+func TestNextSkipsMissingDays(t *testing.T) {
+	start := time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2026-01-31 08:00 Sat", "2026-03-31 08:00 Tue", "2026-05-31 08:00 Sun"},
+		occurrences(t, "FREQ=MONTHLY", start, 3), "months without a 31st are skipped")
+
+	leap := time.Date(2024, 2, 29, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2024-02-29 08:00 Thu", "2028-02-29 08:00 Tue"}, occurrences(t, "FREQ=YEARLY", leap, 2))
+}
+
+func TestNextKeepsLocalTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Summer time starts on 29 March 2026
+	start := time.Date(2026, 3, 23, 9, 0, 0, 0, berlin)
+	r, err := Parse("FREQ=WEEKLY;BYDAY=MO", start)
+	require.NoError(t, err)
+
+	next := r.Next(start)
+	assert.Equal(t, time.Date(2026, 3, 30, 9, 0, 0, 0, berlin), next)
+	assert.Equal(t, 7*24*time.Hour-time.Hour, next.Sub(start))
+}
+
+func TestNextFarFromStart(t *testing.T) {
+	start := time.Date(2000, 1, 3, 9, 0, 0, 0, time.UTC)
+	r, err := Parse("FREQ=DAILY", start)
+	require.NoError(t, err)
+
+	after := time.Date(2026, 5, 5, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 5, 6, 9, 0, 0, 0, time.UTC), r.Next(after))
+
+	r, err = Parse("FREQ=WEEKLY;BYDAY=MO;COUNT=3", start)
+	require.NoError(t, err)
+	assert.True(t, r.Next(after).IsZero(), "a rule past its COUNT has ended")
+}
+
+func TestParseErrors(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260301",
+		"FREQ=DAILY;UNTIL=soon",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;UNTIL=20251231",
+		"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+		"FREQ",
+	} {
+		_, err := Parse(rule, start)
+		assert.ErrorIs(t, err, ErrInvalidRule, rule)
+	}
+}
@@ -2,6 +2,7 @@ package sanitize
 
 import (
 	"html"
+	"regexp"
 	"strings"
 
 	"github.com/microcosm-cc/bluemonday"
@@ -17,6 +18,38 @@ var strict = func() *bluemonday.Policy {
 	return p
 }()
 
+// strictInline removes all HTML like strict but without padding stripped tags
+// with spaces, which would indent Markdown lines
+var strictInline = bluemonday.StrictPolicy()
+
+// rendered is the allow-list policy for HTML the server renders from
+// Markdown. It is separate from strict, which never lets any tag through, and
+// is just as read-only after initialization.
+var rendered = func() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote",
+		"pre", "code", "em", "strong", "del", "ul", "ol", "li",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowStyles("text-align").MatchingEnum("left", "center", "right").OnElements("th", "td")
+
+	// Task list items render as disabled checkboxes
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	p.AllowStandardURLs()
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("src", "alt").OnElements("img")
+	p.AllowAttrs("title").OnElements("a", "img")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}()
+
 // Sanitize strips all HTML from arbitrary user input while preserving readability.
 //
 // All note text must pass through sanitize.Sanitize before hitting the DB.
@@ -64,3 +97,40 @@ func Clean(s string) string {
 
 	return sanitized
 }
+
+// CleanMarkdown strips HTML from Markdown source like Clean but keeps its
+// layout: indentation, blank lines and runs of spaces carry meaning there.
+//
+// Examples:
+//   - "<b>bold</b> **too**" -> "bold **too**"
+//   - "- item\n    - nested" -> "- item\n    - nested"
+//   - "a &lt; b" -> "a < b"
+func CleanMarkdown(s string) string {
+	sanitized := html.UnescapeString(strictInline.Sanitize(s))
+	sanitized = strings.ReplaceAll(sanitized, "\u00a0", " ")
+	sanitized = strings.ReplaceAll(sanitized, "\r\n", "\n")
+
+	// Drop blank lines around the text but not the first line's indentation,
+	// which may open a code block
+	sanitized = strings.TrimRight(sanitized, " \t\r\n")
+	for {
+		line, rest, found := strings.Cut(sanitized, "\n")
+		if !found || strings.TrimSpace(line) != "" {
+			break
+		}
+		sanitized = rest
+	}
+	return sanitized
+}
+
+// HTML passes HTML rendered from user content through an allow-list of
+// formatting tags and attributes. Scripts, event handlers, styles other than
+// table alignment and non-http(s) URLs are removed.
+//
+// Examples:
+//   - "<p onclick=\"x()\">Hi</p>" -> "<p>Hi</p>"
+//   - "<a href=\"javascript:x()\">a</a>" -> "a"
+//   - "<input type=\"checkbox\" checked=\"\" disabled=\"\">" -> kept
+func HTML(s string) string {
+	return rendered.Sanitize(s)
+}
@@ -139,3 +139,79 @@ func TestClean(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"strips tags", "<b>bold</b> **too**", "bold **too**"},
+		{"keeps nesting", "- item\n    - nested", "- item\n    - nested"},
+		{"keeps code indentation", "\n\n    code\n\ntext  \n", "    code\n\ntext"},
+		{"unescapes entities", "a &lt; b &amp; c", "a < b & c"},
+		{"keeps quotes", "> quoted", "> quoted"},
+		{"normalizes line endings", "a\r\nb", "a\nb"},
+		{"empty", "  \n ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanMarkdown(tt.input); got != tt.want {
+				t.Errorf("CleanMarkdown(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "keeps formatting",
+			input: `<h1>T</h1><p><strong>a</strong> <em>b</em> <del>c</del></p>`,
+			want:  `<h1>T</h1><p><strong>a</strong> <em>b</em> <del>c</del></p>`,
+		},
+		{
+			name:  "drops event handlers and scripts",
+			input: `<p onclick="x()">Hi</p><script>alert(1)</script>`,
+			want:  `<p>Hi</p>`,
+		},
+		{
+			name:  "drops javascript links",
+			input: `<a href="javascript:alert(1)">a</a>`,
+			want:  `a`,
+		},
+		{
+			name:  "hardens links",
+			input: `<a href="https://example.com">a</a>`,
+			want:  `<a href="https://example.com" rel="nofollow noopener" target="_blank">a</a>`,
+		},
+		{
+			name:  "keeps task checkboxes only",
+			input: `<input checked="" disabled="" type="checkbox"><input type="text" value="x">`,
+			want:  `<input checked="" disabled="" type="checkbox">`,
+		},
+		{
+			name:  "keeps table alignment",
+			input: `<td style="text-align:right;color:red">1</td>`,
+			want:  `<td style="text-align: right">1</td>`,
+		},
+		{
+			name:  "drops other styles and classes",
+			input: `<p style="position:fixed" class="x">a</p><code class="language-go">b</code>`,
+			want:  `<p>a</p><code class="language-go">b</code>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.input); got != tt.want {
+				t.Errorf("HTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Package thumbnail scales images down to small PNG previews.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // decoders register themselves with image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxSide is the longest side of a thumbnail, in pixels
+const MaxSide = 256
+
+// maxPixels refuses images that would take too much memory to decode
+const maxPixels = 40_000_000
+
+// ErrTooLarge is returned for images with more than maxPixels pixels
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// PNG decodes an image from r, which is read twice, and returns a PNG of it
+// no larger than MaxSide on either side. Smaller images keep their size.
+func PNG(r io.ReadSeeker) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := fit(cfg.Width, cfg.Height)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fit scales w×h to fit within MaxSide, keeping the aspect ratio
+func fit(w, h int) (int, int) {
+	if w <= MaxSide && h <= MaxSide {
+		return w, h
+	}
+	if w >= h {
+		return MaxSide, max(1, h*MaxSide/w)
+	}
+	return max(1, w*MaxSide/h), MaxSide
+}
@@ -0,0 +1,50 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1024, 512))
+	for x := 0; x < 1024; x++ {
+		src.Set(x, 100, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, src, nil))
+
+	out, err := PNG(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	thumb, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+}
+
+func TestPNG_NotAnImage(t *testing.T) {
+	_, err := PNG(bytes.NewReader([]byte("plain text")))
+	assert.Error(t, err)
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, wantW, wantH int
+	}{
+		{100, 50, 100, 50},
+		{512, 512, 256, 256},
+		{300, 1200, 64, 256},
+		{10000, 1, 256, 1},
+	}
+	for _, tt := range tests {
+		w, h := fit(tt.w, tt.h)
+		assert.Equal(t, tt.wantW, w, "%dx%d", tt.w, tt.h)
+		assert.Equal(t, tt.wantH, h, "%dx%d", tt.w, tt.h)
+	}
+}
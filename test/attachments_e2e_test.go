@@ -0,0 +1,98 @@
+//go:build e2e
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadFile posts content as the multipart file field and returns the response
+func uploadFile(t *testing.T, url, name string, content []byte, headers map[string]string) *http.Response {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestAttachmentsE2E(t *testing.T) {
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{
+		"BLOB_PATH":            t.TempDir(),
+		"ATTACHMENT_MAX_BYTES": "65536",
+	})
+
+	token := setupTestUser(t, env, "attachments@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	created := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes", map[string]any{"title": "Trip"}, h, http.StatusCreated)
+	noteURL := env.BaseURL + "/api/v1/notes/" + created["note"].(map[string]any)["id"].(string)
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 512, 512))))
+
+	resp := uploadFile(t, noteURL+"/attachments", "map.png", img.Bytes(), h)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var attachment map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&attachment))
+	assert.Equal(t, "image/png", attachment["mime"])
+	assert.Equal(t, true, attachment["thumbnail"])
+	attachmentURL := noteURL + "/attachments/" + attachment["id"].(string)
+
+	download, err := httpJSON("GET", attachmentURL, nil, h)
+	require.NoError(t, err)
+	defer download.Body.Close()
+	assert.Equal(t, http.StatusOK, download.StatusCode)
+	assert.Equal(t, "image/png", download.Header.Get("Content-Type"))
+	assert.Equal(t, "nosniff", download.Header.Get("X-Content-Type-Options"))
+	got, err := io.ReadAll(download.Body)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bytes(), got)
+
+	thumb, err := httpJSON("GET", attachmentURL+"/thumbnail", nil, h)
+	require.NoError(t, err)
+	defer thumb.Body.Close()
+	require.Equal(t, http.StatusOK, thumb.StatusCode)
+	cfg, err := png.DecodeConfig(thumb.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 256, cfg.Width)
+
+	html := uploadFile(t, noteURL+"/attachments", "page.txt", []byte("<html><script>alert(1)</script></html>"), h)
+	html.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, html.StatusCode)
+
+	big := uploadFile(t, noteURL+"/attachments", "big.bin", make([]byte, 70000), h)
+	big.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, big.StatusCode)
+
+	list := makeHTTPRequest(t, "GET", noteURL+"/attachments", nil, h, http.StatusOK)
+	assert.Len(t, list["attachments"], 1)
+
+	// Deleting the note takes its attachments along
+	makeHTTPRequest(t, "DELETE", noteURL, nil, h, http.StatusNoContent)
+	makeHTTPRequest(t, "GET", attachmentURL, nil, h, http.StatusNotFound)
+}
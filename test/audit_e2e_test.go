@@ -0,0 +1,60 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "audit@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	auditURL := env.BaseURL + "/api/v1/audit"
+
+	makeHTTPRequest(t, "POST", env.BaseURL+authPath+"/sign-in", map[string]any{"email": "audit@example.com", "password": "Wrong-password1"}, nil, http.StatusUnauthorized)
+
+	h["X-Request-ID"] = "e2e-audit-1"
+	created := makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Audited", "body": "secret body"}, h, http.StatusCreated)
+	delete(h, "X-Request-ID")
+	noteID := created["note"].(map[string]any)["id"].(string)
+	makeHTTPRequest(t, "PATCH", env.BaseURL+notesPath+"/"+noteID, map[string]any{"title": "Renamed"}, h, http.StatusOK)
+	makeHTTPRequest(t, "DELETE", env.BaseURL+notesPath+"/"+noteID, nil, h, http.StatusNoContent)
+
+	resp := makeHTTPRequest(t, "GET", auditURL, nil, h, http.StatusOK)
+	entries := resp["entries"].([]any)
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.(map[string]any)["action"].(string))
+	}
+	assert.Equal(t, []string{"note.delete", "note.update", "note.create", "auth.sign_in_failed", "auth.sign_up"}, actions)
+
+	update := entries[1].(map[string]any)
+	assert.Equal(t, map[string]any{"title": "Audited"}, update["before"])
+	assert.Equal(t, map[string]any{"title": "Renamed"}, update["after"])
+	assert.Equal(t, noteID, update["target_id"])
+	assert.NotEmpty(t, update["ip"])
+
+	create := entries[2].(map[string]any)
+	assert.Equal(t, "e2e-audit-1", create["request_id"])
+	assert.NotContains(t, create["after"], "body", "bodies stay out of the log")
+
+	failed := entries[3].(map[string]any)
+	assert.Equal(t, "wrong_password", failed["after"].(map[string]any)["reason"])
+	assert.NotContains(t, failed, "actor_id")
+
+	page := makeHTTPRequest(t, "GET", auditURL+"?limit=2&action=note.update", nil, h, http.StatusOK)
+	require.Len(t, page["entries"], 1)
+	assert.NotContains(t, page, "next_before")
+
+	// Another user sees none of it, and the admin-wide query is for admins
+	other := getAuthHeaders(t, setupTestUser(t, env, "audit-other@example.com", "Password123"))
+	otherResp := makeHTTPRequest(t, "GET", auditURL+"?action=note.create", nil, other, http.StatusOK)
+	assert.Empty(t, otherResp["entries"])
+	makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/admin/audit", nil, other, http.StatusForbidden)
+}
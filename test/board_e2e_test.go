@@ -0,0 +1,85 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "board@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	notesURL := env.BaseURL + "/api/v1/notes"
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 100)
+	startWebSocketListener(ws, messages)
+
+	ids := make([]string, 3)
+	for i, title := range []string{"Todo", "Doing", "Done"} {
+		created := makeHTTPRequest(t, "POST", notesURL, map[string]any{"title": title}, h, http.StatusCreated)
+		ids[i] = created["note"].(map[string]any)["id"].(string)
+	}
+	// Drain the created events
+	for range ids {
+		<-messages
+	}
+
+	// A drag: many moves of one note reach the client as far fewer events
+	for x := 0; x <= 200; x += 10 {
+		makeHTTPRequest(t, "PATCH", notesURL+"/"+ids[0]+"/position", map[string]any{
+			"x": x, "y": 40, "width": 200, "height": 160, "z": 1,
+		}, h, http.StatusOK)
+	}
+	var last map[string]any
+	moves := 0
+	for timeout := time.After(2 * time.Second); ; {
+		select {
+		case msg := <-messages:
+			require.Equal(t, "moved", msg["type"])
+			last = msg["note"].(map[string]any)
+			moves++
+			continue
+		case <-timeout:
+		}
+		break
+	}
+	require.NotNil(t, last, "no moved event")
+	assert.Less(t, moves, 21)
+	assert.Equal(t, ids[0], last["id"])
+	assert.Equal(t, float64(200), last["position"].(map[string]any)["x"], "the last move wins")
+	assert.NotContains(t, last, "title", "moved events are lightweight")
+
+	arranged := makeHTTPRequest(t, "POST", notesURL+"/arrange", map[string]any{
+		"notes": []map[string]any{
+			{"id": ids[1], "x": 1000, "y": 0, "width": 200, "height": 160},
+			{"id": ids[2], "board_id": "archive", "x": 0, "y": 0, "width": 200, "height": 160},
+		},
+	}, h, http.StatusOK)
+	assert.Len(t, arranged["notes"], 2)
+	makeHTTPRequest(t, "POST", notesURL+"/arrange", map[string]any{
+		"notes": []map[string]any{
+			{"id": ids[1], "x": 0, "y": 0, "width": 200, "height": 160},
+			{"id": ids[1], "x": 0, "y": 0, "width": 200, "height": 160},
+		},
+	}, h, http.StatusBadRequest)
+
+	list := makeHTTPRequest(t, "GET", notesURL+"?viewport=0,0,800,600", nil, h, http.StatusOK)
+	require.Len(t, list["notes"], 1)
+	assert.Equal(t, ids[0], list["notes"].([]any)[0].(map[string]any)["id"])
+
+	list = makeHTTPRequest(t, "GET", notesURL+"?board_id=archive&viewport=0,0,800,600", nil, h, http.StatusOK)
+	require.Len(t, list["notes"], 1)
+	assert.Equal(t, ids[2], list["notes"].([]any)[0].(map[string]any)["id"])
+
+	makeHTTPRequest(t, "GET", notesURL+"?viewport=0,0,0,600", nil, h, http.StatusBadRequest)
+	makeHTTPRequest(t, "PATCH", notesURL+"/"+ids[0]+"/position", map[string]any{"x": 0, "y": 0, "width": 0, "height": 10}, h, http.StatusBadRequest)
+}
@@ -0,0 +1,58 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecklistItemsE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "checklist@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	created := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes", map[string]any{
+		"title": "Trip",
+		"type":  "checklist",
+		"items": []map[string]any{{"text": "Flights"}, {"text": "Hotel"}},
+	}, h, http.StatusCreated)
+	note := created["note"].(map[string]any)
+	noteURL := env.BaseURL + "/api/v1/notes/" + note["id"].(string)
+	items := note["items"].([]any)
+	require.Len(t, items, 2)
+	flights := items[0].(map[string]any)["id"].(string)
+	hotel := items[1].(map[string]any)["id"].(string)
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	updated := makeHTTPRequest(t, "PATCH", noteURL+"/items/"+hotel, map[string]any{"checked": true}, h, http.StatusOK)
+	assert.Equal(t, true, updated["item"].(map[string]any)["checked"])
+	msg := <-messages
+	assert.Equal(t, "item_updated", msg["type"])
+	assert.Equal(t, hotel, msg["item"].(map[string]any)["id"])
+
+	added := makeHTTPRequest(t, "POST", noteURL+"/items", map[string]any{"text": "Visa", "position": 0}, h, http.StatusCreated)
+	visa := added["item"].(map[string]any)["id"].(string)
+	assert.Equal(t, float64(0), added["item"].(map[string]any)["position"])
+
+	makeHTTPRequest(t, "PUT", noteURL+"/items/order", map[string]any{
+		"item_ids": []string{flights, hotel},
+	}, h, http.StatusBadRequest)
+	makeHTTPRequest(t, "PUT", noteURL+"/items/order", map[string]any{
+		"item_ids": []string{hotel, flights, visa},
+	}, h, http.StatusOK)
+	makeHTTPRequest(t, "DELETE", noteURL+"/items/"+flights, nil, h, http.StatusNoContent)
+	makeHTTPRequest(t, "DELETE", noteURL+"/items/"+flights, nil, h, http.StatusNotFound)
+
+	list := makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/notes", nil, h, http.StatusOK)
+	listed := list["notes"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{"done": float64(1), "total": float64(2)}, listed["progress"])
+}
@@ -0,0 +1,62 @@
+//go:build e2e
+
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeysE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	// A retried sign-up gets the first status instead of a conflict, but
+	// not its tokens, which are never stored
+	signUpPayload := map[string]any{"email": "idem@example.com", "password": "Password123"}
+	signUpHeaders := map[string]string{"Idempotency-Key": "sign-up-1"}
+	first := makeHTTPRequest(t, "POST", env.BaseURL+authPath+"/sign-up", signUpPayload, signUpHeaders, http.StatusCreated)
+	signUpResp, err := httpJSON("POST", env.BaseURL+authPath+"/sign-up", signUpPayload, signUpHeaders)
+	require.NoError(t, err)
+	retriedBody, err := io.ReadAll(signUpResp.Body)
+	require.NoError(t, err)
+	require.NoError(t, signUpResp.Body.Close())
+	assert.Equal(t, http.StatusCreated, signUpResp.StatusCode)
+	assert.Equal(t, "true", signUpResp.Header.Get("Idempotency-Replayed"))
+	assert.Empty(t, retriedBody)
+
+	// Another client picking the same key signs up on its own
+	otherSignUp := map[string]any{"email": "idem-same-key@example.com", "password": "Password123"}
+	makeHTTPRequest(t, "POST", env.BaseURL+authPath+"/sign-up", otherSignUp, signUpHeaders, http.StatusCreated)
+
+	h := getAuthHeaders(t, first["token"].(string))
+	h["Idempotency-Key"] = "create-1"
+	payload := map[string]any{"title": "Flaky network", "body": "sent twice"}
+
+	created := makeHTTPRequest(t, "POST", env.BaseURL+notesPath, payload, h, http.StatusCreated)
+
+	resp, err := httpJSON("POST", env.BaseURL+notesPath, payload, h)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Idempotency-Replayed"))
+	var replayed map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&replayed))
+	assert.Equal(t, created["note"].(map[string]any)["id"], replayed["note"].(map[string]any)["id"])
+
+	// The same key with another payload is refused
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Something else"}, h, http.StatusUnprocessableEntity)
+
+	// Keys are per user
+	other := getAuthHeaders(t, setupTestUser(t, env, "idem-other@example.com", "Password123"))
+	other["Idempotency-Key"] = "create-1"
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, payload, other, http.StatusCreated)
+
+	delete(h, "Idempotency-Key")
+	list := makeHTTPRequest(t, "GET", env.BaseURL+notesPath, nil, h, http.StatusOK)
+	assert.Len(t, list["notes"].([]any), 1, "the retry must not create a second note")
+}
@@ -0,0 +1,88 @@
+//go:build e2e
+
+package test
+
+import (
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboundEmailE2E(t *testing.T) {
+	smtpPort, err := randomPort()
+	require.NoError(t, err)
+	smtpAddr := "127.0.0.1:" + smtpPort
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{
+		"INBOUND_SMTP_ENABLED":  "true",
+		"INBOUND_SMTP_ADDR":     smtpAddr,
+		"INBOUND_MAIL_DOMAIN":   "in.notepulse.test",
+		"INBOUND_MAX_BYTES":     "4096",
+		"INBOUND_RATE_PER_HOUR": "2",
+	})
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", smtpAddr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+
+	token := setupTestUser(t, env, "inbound@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	addressURL := env.BaseURL + "/api/v1/me/inbound-address"
+
+	makeHTTPRequest(t, "GET", addressURL, nil, h, http.StatusNotFound)
+	created := makeHTTPRequest(t, "POST", addressURL, nil, h, http.StatusCreated)
+	address := created["address"].(string)
+	assert.True(t, strings.HasSuffix(address, "@in.notepulse.test"))
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	mail := "From: Ann <ann@example.com>\r\n" +
+		"Subject: Printer on fire\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\nPlease <b>help</b>.\r\n" +
+		"--b\r\nContent-Type: image/jpeg\r\nContent-Disposition: attachment; filename=fire.jpg\r\n\r\nJPEG\r\n" +
+		"--b--\r\n"
+	require.NoError(t, smtp.SendMail(smtpAddr, nil, "ann@example.com", []string{address}, []byte(mail)))
+
+	select {
+	case msg := <-messages:
+		require.Equal(t, "created", msg["type"])
+		note := msg["note"].(map[string]any)
+		assert.Equal(t, "Printer on fire", note["title"])
+		assert.Equal(t, "Please help.\n\nAttachments:\n- fire.jpg (4 bytes)", note["body"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no created event for the email")
+	}
+
+	var perr *textproto.Error
+	err = smtp.SendMail(smtpAddr, nil, "ann@example.com", []string{address}, []byte("Subject: Big\r\n\r\n"+strings.Repeat("x", 5000)))
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 552, perr.Code)
+
+	err = smtp.SendMail(smtpAddr, nil, "ann@example.com", []string{address}, []byte("Subject: Again\r\n\r\nbody"))
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 450, perr.Code, "two messages an hour")
+
+	// A new address retires the old one
+	makeHTTPRequest(t, "POST", addressURL, nil, h, http.StatusCreated)
+	err = smtp.SendMail(smtpAddr, nil, "ann@example.com", []string{address}, []byte("Subject: Old\r\n\r\nbody"))
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 550, perr.Code)
+
+	makeHTTPRequest(t, "DELETE", addressURL, nil, h, http.StatusNoContent)
+	makeHTTPRequest(t, "GET", addressURL, nil, h, http.StatusNotFound)
+}
@@ -0,0 +1,67 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinksE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "links@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	notesURL := env.BaseURL + "/api/v1/notes"
+
+	create := func(title, body string) string {
+		created := makeHTTPRequest(t, "POST", notesURL, map[string]any{"title": title, "body": body}, h, http.StatusCreated)
+		return created["note"].(map[string]any)["id"].(string)
+	}
+	planID := create("Plan", "Steps")
+	indexID := create("Index", "See [[Plan]], [["+planID+"]] and [[Budget]]")
+
+	backlinks := makeHTTPRequest(t, "GET", notesURL+"/"+planID+"/backlinks", nil, h, http.StatusOK)
+	require.Len(t, backlinks["notes"], 1)
+	assert.Equal(t, indexID, backlinks["notes"].([]any)[0].(map[string]any)["id"])
+	makeHTTPRequest(t, "GET", notesURL+"/000000000000000000000000/backlinks", nil, h, http.StatusNotFound)
+
+	graph := makeHTTPRequest(t, "GET", notesURL+"/graph", nil, h, http.StatusOK)
+	assert.Len(t, graph["nodes"], 2)
+	assert.Equal(t, []any{map[string]any{"source": indexID, "target": planID}}, graph["edges"])
+	assert.Equal(t, []any{map[string]any{"source": indexID, "target": "Budget"}}, graph["broken"])
+
+	// Creating Budget heals the link; renaming Plan rewrites the one by title
+	budgetID := create("Budget", "")
+	makeHTTPRequest(t, "PATCH", notesURL+"/"+planID, map[string]any{"title": "Roadmap"}, h, http.StatusOK)
+	backlinks = makeHTTPRequest(t, "GET", notesURL+"/"+planID+"/backlinks", nil, h, http.StatusOK)
+	require.Len(t, backlinks["notes"], 1)
+	assert.Equal(t, "See [[Roadmap]], [["+planID+"]] and [[Budget]]", backlinks["notes"].([]any)[0].(map[string]any)["body"])
+	graph = makeHTTPRequest(t, "GET", notesURL+"/graph", nil, h, http.StatusOK)
+	assert.Len(t, graph["edges"], 2)
+	assert.Empty(t, graph["broken"])
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	// Deleting Budget breaks the link to it
+	makeHTTPRequest(t, "DELETE", notesURL+"/"+budgetID, nil, h, http.StatusNoContent)
+	select {
+	case msg := <-messages:
+		require.Equal(t, "links", msg["type"])
+		assert.Equal(t, indexID, msg["note"].(map[string]any)["id"])
+		links := msg["links"].([]any)
+		require.Len(t, links, 3)
+		assert.Equal(t, map[string]any{"target": "Budget"}, links[2])
+	case <-time.After(2 * time.Second):
+		t.Fatal("no links event")
+	}
+	graph = makeHTTPRequest(t, "GET", notesURL+"/graph", nil, h, http.StatusOK)
+	assert.Equal(t, []any{map[string]any{"source": indexID, "target": "Budget"}}, graph["broken"])
+}
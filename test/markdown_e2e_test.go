@@ -0,0 +1,38 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownRenderingE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "markdown@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	created := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes", map[string]any{
+		"title":  "Checklist",
+		"body":   "- [x] **done**\n- [ ] <script>alert(1)</script>open",
+		"format": "markdown",
+	}, h, http.StatusCreated)
+	note := created["note"].(map[string]any)
+	assert.Equal(t, "markdown", note["format"])
+	assert.NotContains(t, note, "rendered_html")
+
+	list := makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/notes?render=html", nil, h, http.StatusOK)
+	require.Len(t, list["notes"], 1)
+	html := list["notes"].([]any)[0].(map[string]any)["rendered_html"].(string)
+	assert.Contains(t, html, `<input checked="" disabled="" type="checkbox"> <strong>done</strong>`)
+	assert.NotContains(t, html, "<script")
+
+	makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/notes?render=pdf", nil, h, http.StatusBadRequest)
+	makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes", map[string]any{
+		"title": "Bad", "format": "rst",
+	}, h, http.StatusBadRequest)
+}
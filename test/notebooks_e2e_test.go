@@ -0,0 +1,84 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotebooksE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "notebooks@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	notesURL := env.BaseURL + "/api/v1/notes"
+	notebooksURL := env.BaseURL + "/api/v1/notebooks"
+
+	work := makeHTTPRequest(t, "POST", notebooksURL, map[string]any{"name": "Work"}, h, http.StatusCreated)
+	workID := work["id"].(string)
+	projects := makeHTTPRequest(t, "POST", notebooksURL, map[string]any{"name": "Projects", "parent_id": workID}, h, http.StatusCreated)
+	projectsID := projects["id"].(string)
+	assert.Equal(t, workID, projects["parent_id"])
+	assert.Equal(t, float64(1), projects["depth"])
+	makeHTTPRequest(t, "POST", notebooksURL, map[string]any{"name": "Work"}, h, http.StatusConflict)
+
+	noteIn := func(title, notebookID string) string {
+		body := map[string]any{"title": title}
+		if notebookID != "" {
+			body["notebook_id"] = notebookID
+		}
+		created := makeHTTPRequest(t, "POST", notesURL, body, h, http.StatusCreated)
+		return created["note"].(map[string]any)["id"].(string)
+	}
+	noteIn("Plan", workID)
+	specID := noteIn("Spec", projectsID)
+	inboxID := noteIn("Loose", "")
+
+	titles := func(query string) []string {
+		list := makeHTTPRequest(t, "GET", notesURL+query, nil, h, http.StatusOK)
+		var out []string
+		for _, n := range list["notes"].([]any) {
+			out = append(out, n.(map[string]any)["title"].(string))
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Plan"}, titles("?notebook="+workID))
+	assert.ElementsMatch(t, []string{"Plan", "Spec"}, titles("?notebook="+workID+"&recursive=true"))
+	assert.Equal(t, []string{"Loose"}, titles("?notebook=inbox"))
+
+	list := makeHTTPRequest(t, "GET", notesURL+"?notebook="+workID, nil, h, http.StatusOK)
+	counts := map[string]float64{}
+	for _, c := range list["notebook_counts"].([]any) {
+		c := c.(map[string]any)
+		key, _ := c["notebook_id"].(string)
+		counts[key] = c["count"].(float64)
+	}
+	assert.Equal(t, map[string]float64{"": 1, workID: 1, projectsID: 1}, counts)
+
+	moved := makeHTTPRequest(t, "POST", notesURL+"/"+inboxID+"/move", map[string]any{"notebook_id": projectsID}, h, http.StatusOK)
+	assert.Equal(t, projectsID, moved["note"].(map[string]any)["notebook_id"])
+	copied := makeHTTPRequest(t, "POST", notesURL+"/"+specID+"/copy", map[string]any{}, h, http.StatusCreated)
+	copyID := copied["note"].(map[string]any)["id"].(string)
+	assert.NotEqual(t, specID, copyID)
+	assert.NotContains(t, copied["note"], "notebook_id")
+	makeHTTPRequest(t, "POST", notesURL+"/"+specID+"/move", map[string]any{"notebook_id": "000000000000000000000000"}, h, http.StatusNotFound)
+
+	// Moving Work under Projects would nest it in itself
+	makeHTTPRequest(t, "PATCH", notebooksURL+"/"+workID, map[string]any{"parent_id": projectsID}, h, http.StatusBadRequest)
+	top := makeHTTPRequest(t, "PATCH", notebooksURL+"/"+projectsID, map[string]any{"parent_id": ""}, h, http.StatusOK)
+	assert.Equal(t, float64(0), top["depth"])
+
+	// Deleting Projects keeps its notes in the Inbox; deleting Work deletes Plan
+	makeHTTPRequest(t, "DELETE", notebooksURL+"/"+projectsID, nil, h, http.StatusNoContent)
+	assert.ElementsMatch(t, []string{"Loose", "Spec", "Spec"}, titles("?notebook=inbox"))
+	makeHTTPRequest(t, "DELETE", notebooksURL+"/"+workID+"?notes=delete", nil, h, http.StatusNoContent)
+	assert.ElementsMatch(t, []string{"Loose", "Spec", "Spec"}, titles(""))
+
+	remaining := makeHTTPRequest(t, "GET", notebooksURL, nil, h, http.StatusOK)
+	require.Empty(t, remaining["notebooks"])
+	makeHTTPRequest(t, "GET", notesURL+"?notebook="+workID, nil, h, http.StatusNotFound)
+}
@@ -0,0 +1,44 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotasE2E(t *testing.T) {
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{
+		"MAX_NOTES_PER_USER": "2",
+		"MAX_TITLE_CHARS":    "10",
+		"MAX_BODY_BYTES":     "100",
+	})
+
+	h := getAuthHeaders(t, setupTestUser(t, env, "quota@example.com", "Password123"))
+	usageURL := env.BaseURL + "/api/v1/me/usage"
+
+	resp := makeHTTPRequest(t, "GET", usageURL, nil, h, http.StatusOK)
+	assert.Equal(t, map[string]any{"note_count": float64(0), "storage_bytes": float64(0)}, resp["usage"])
+	assert.Equal(t, float64(2), resp["limits"].(map[string]any)["max_notes"])
+
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Far too long a title"}, h, http.StatusRequestEntityTooLarge)
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Big", "body": strings.Repeat("x", 101)}, h, http.StatusRequestEntityTooLarge)
+
+	first := makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "One", "body": "abc"}, h, http.StatusCreated)
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Two"}, h, http.StatusCreated)
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Three"}, h, http.StatusUnprocessableEntity)
+
+	resp = makeHTTPRequest(t, "GET", usageURL, nil, h, http.StatusOK)
+	assert.Equal(t, map[string]any{"note_count": float64(2), "storage_bytes": float64(9)}, resp["usage"])
+
+	// Deleting a note makes room again
+	noteID := first["note"].(map[string]any)["id"].(string)
+	makeHTTPRequest(t, "DELETE", env.BaseURL+notesPath+"/"+noteID, nil, h, http.StatusNoContent)
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "Three"}, h, http.StatusCreated)
+
+	resp = makeHTTPRequest(t, "GET", usageURL, nil, h, http.StatusOK)
+	assert.Equal(t, map[string]any{"note_count": float64(2), "storage_bytes": float64(8)}, resp["usage"])
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -81,3 +84,35 @@ func TestV1RateLimitEnabledE2E(t *testing.T) {
 
 	makeHTTPRequest(t, "GET", getTestingUrl(env), nil, h, http.StatusTooManyRequests)
 }
+
+func TestRateLimitPerUserE2E(t *testing.T) {
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{
+		appRateLimitEnv:      fmt.Sprint(maxPerMinute),
+		"WRITE_RATE_PER_MIN": "1",
+		"RATE_LIMIT_STORE":   "mongo",
+	})
+
+	alice := getAuthHeaders(t, setupTestUser(t, env, "rl-alice@example.com", "Password123"))
+	bob := getAuthHeaders(t, setupTestUser(t, env, "rl-bob@example.com", "Password123"))
+
+	resp, err := httpJSON("GET", getTestingUrl(env), nil, alice)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fmt.Sprint(maxPerMinute), resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, fmt.Sprint(maxPerMinute-1), resp.Header.Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, resp.Header.Get("RateLimit-Reset"))
+
+	// Writes have a stricter budget of their own
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "one"}, alice, http.StatusCreated)
+	resp, err = httpJSON("POST", env.BaseURL+notesPath, map[string]any{"title": "two"}, alice)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	// Both users share an IP but not a bucket
+	makeHTTPRequest(t, "GET", getTestingUrl(env), nil, alice, http.StatusTooManyRequests)
+	makeHTTPRequest(t, "GET", getTestingUrl(env), nil, bob, http.StatusOK)
+	makeHTTPRequest(t, "POST", env.BaseURL+notesPath, map[string]any{"title": "bob"}, bob, http.StatusCreated)
+}
@@ -0,0 +1,74 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDueDatesAndRemindersE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "reminders@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	notesURL := env.BaseURL + "/api/v1/notes"
+	now := time.Now().UTC()
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	created := makeHTTPRequest(t, "POST", notesURL, map[string]any{
+		"title":     "Pay rent",
+		"due_at":    now.Add(-time.Hour).Format(time.RFC3339),
+		"remind_at": now.Add(-time.Minute).Format(time.RFC3339),
+	}, h, http.StatusCreated)
+	rent := created["note"].(map[string]any)["id"].(string)
+	makeHTTPRequest(t, "POST", notesURL, map[string]any{
+		"title":  "Renew passport",
+		"due_at": now.Add(30 * 24 * time.Hour).Format(time.RFC3339),
+	}, h, http.StatusCreated)
+	makeHTTPRequest(t, "POST", notesURL, map[string]any{"title": "Someday"}, h, http.StatusCreated)
+
+	// The scheduler sweeps every 15 seconds
+	deadline := time.After(20 * time.Second)
+	for reminded := false; !reminded; {
+		select {
+		case msg := <-messages:
+			if msg["type"] == "reminder" {
+				reminded = true
+				note := msg["note"].(map[string]any)
+				assert.Equal(t, rent, note["id"])
+				assert.NotEmpty(t, note["reminded_at"])
+			}
+		case <-deadline:
+			t.Fatal("no reminder event")
+		}
+	}
+
+	list := makeHTTPRequest(t, "GET", notesURL+"?sort=due_at&order=asc", nil, h, http.StatusOK)
+	require.Len(t, list["notes"], 2, "notes without a due date are left out")
+	assert.Equal(t, "Pay rent", list["notes"].([]any)[0].(map[string]any)["title"])
+
+	list = makeHTTPRequest(t, "GET", notesURL+"?overdue=true", nil, h, http.StatusOK)
+	require.Len(t, list["notes"], 1)
+	assert.Equal(t, rent, list["notes"].([]any)[0].(map[string]any)["id"])
+
+	before := url.QueryEscape(now.Add(60 * 24 * time.Hour).Format(time.RFC3339))
+	list = makeHTTPRequest(t, "GET", notesURL+"?due_before="+before, nil, h, http.StatusOK)
+	assert.Len(t, list["notes"], 2)
+	makeHTTPRequest(t, "GET", notesURL+"?due_before=tomorrow", nil, h, http.StatusBadRequest)
+
+	updated := makeHTTPRequest(t, "PATCH", notesURL+"/"+rent, map[string]any{"due_at": "", "remind_at": ""}, h, http.StatusOK)
+	note := updated["note"].(map[string]any)
+	assert.NotContains(t, note, "due_at")
+	assert.NotContains(t, note, "remind_at")
+	makeHTTPRequest(t, "PATCH", notesURL+"/"+rent, map[string]any{"due_at": "next week"}, h, http.StatusBadRequest)
+}
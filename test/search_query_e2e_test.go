@@ -0,0 +1,58 @@
+//go:build e2e
+
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredSearchQueryE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "querysearch@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	for i := range 5 {
+		createAndVerifyNote(t, env, h, NoteParams{
+			Title: fmt.Sprintf("Standup %d", i),
+			Body:  "action item for the team",
+			Color: testColor,
+		})
+	}
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Standup draft", Body: "action item", Color: testColor})
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Standup blue", Body: "action item", Color: "#0000FF"})
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Retro", Body: "action item", Color: testColor})
+
+	q := url.QueryEscape(`title:"standup" -draft color:#ff0000 updated:>2000-01-01 body:"action item"`)
+	list := func(params string) map[string]any {
+		return makeHTTPRequest(t, "GET", env.BaseURL+notesPath+"?q="+q+"&"+params, nil, h, http.StatusOK)
+	}
+
+	// cursor pagination
+	first := list("limit=3")
+	assert.Equal(t, float64(5), first["total_count"])
+	assert.Equal(t, float64(8), first["total_count_unfiltered"])
+	require.Len(t, first["notes"], 3)
+	second := list("limit=3&cursor=" + url.QueryEscape(first["next_cursor"].(string)))
+	require.Len(t, second["notes"], 2)
+
+	// offset pagination
+	page := list("limit=2&offset=4&sort=title&order=asc")
+	require.Len(t, page["notes"], 1)
+	assert.Equal(t, "Standup 4", page["notes"].([]any)[0].(map[string]any)["title"])
+
+	// anchor pagination
+	anchorID := first["notes"].([]any)[1].(map[string]any)["id"].(string)
+	window := list("anchor=" + anchorID + "&span=3")
+	assert.Equal(t, float64(1), window["anchor_index"])
+	require.Len(t, window["notes"], 3)
+
+	resp := makeHTTPRequest(t, "GET", env.BaseURL+notesPath+"?q="+url.QueryEscape("standup color:red"), nil, h, http.StatusBadRequest)
+	assert.Contains(t, resp["error"], "color:red")
+}
@@ -0,0 +1,102 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionsEndpoint = "/api/v1/sessions"
+
+func TestRevokedSessionAccessTokenE2E(t *testing.T) {
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{
+		"AUTH_RATE_PER_MIN": "1000",
+	})
+
+	creds := map[string]string{
+		"email":    "revoked-session@test.com",
+		"password": "Password123",
+	}
+	results := ExecuteHTTPJSONSteps(t, []HTTPJSONStep{
+		{
+			Name:           "sign up on the first device",
+			Method:         "POST",
+			URL:            signUpEndpoint,
+			Body:           creds,
+			ExpectedStatus: http.StatusCreated,
+			Validator:      AuthTokenValidator("token"),
+		},
+		{
+			Name:           "sign in on the second device",
+			Method:         "POST",
+			URL:            signInEndpoint,
+			Body:           creds,
+			ExpectedStatus: http.StatusOK,
+			Validator:      AuthTokenValidator("token"),
+		},
+	}, env.BaseURL)
+	keptToken := GetTokenFromResponse(t, results[0], "token")
+	revokedToken := GetTokenFromResponse(t, results[1], "token")
+
+	wsURL := "ws://localhost" + env.BaseURL[len("http://localhost"):] + "/ws/notes/stream?token="
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+revokedToken, nil)
+	require.NoError(t, err, "the second device connects before revocation")
+	require.NoError(t, conn.Close())
+
+	// the second device's session is the one that is not current for the first
+	listed := ExecuteHTTPJSONStep(t, HTTPJSONStep{
+		Name:           "list sessions from the first device",
+		Method:         "GET",
+		URL:            sessionsEndpoint,
+		Headers:        map[string]string{"Authorization": "Bearer " + keptToken},
+		ExpectedStatus: http.StatusOK,
+	}, env.BaseURL)
+	sessions, ok := listed["sessions"].([]any)
+	require.True(t, ok)
+	require.Len(t, sessions, 2)
+	var otherID string
+	for _, s := range sessions {
+		session := s.(map[string]any)
+		if current, _ := session["current"].(bool); !current {
+			otherID, _ = session["id"].(string)
+		}
+	}
+	require.NotEmpty(t, otherID)
+
+	resp, err := httpJSON("DELETE", env.BaseURL+sessionsEndpoint+"/"+otherID, nil,
+		map[string]string{"Authorization": "Bearer " + keptToken})
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode, "revoke the second device's session")
+
+	ExecuteHTTPJSONSteps(t, []HTTPJSONStep{
+		{
+			Name:           "the revoked session's access token is refused",
+			Method:         "GET",
+			URL:            meEndpoint,
+			Headers:        map[string]string{"Authorization": "Bearer " + revokedToken},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "the other session keeps working",
+			Method:         "GET",
+			URL:            meEndpoint,
+			Headers:        map[string]string{"Authorization": "Bearer " + keptToken},
+			ExpectedStatus: http.StatusOK,
+		},
+	}, env.BaseURL)
+
+	_, resp, err = websocket.DefaultDialer.Dial(wsURL+revokedToken, nil)
+	require.Error(t, err, "the revoked session cannot reconnect")
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn, _, err = websocket.DefaultDialer.Dial(wsURL+keptToken, nil)
+	require.NoError(t, err, "the other session can still connect")
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,69 @@
+//go:build e2e
+
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatesE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "templates@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	templatesURL := env.BaseURL + "/api/v1/templates"
+
+	created := makeHTTPRequest(t, "POST", templatesURL, map[string]any{
+		"name":  "Weekly retro",
+		"title": "Retro {{week}}",
+		"body":  "Held on {{date}}",
+		"color": "#FFD700",
+		"recurrence": map[string]any{
+			"rrule":    "FREQ=WEEKLY;BYDAY=MO",
+			"start":    "2026-01-05T09:00:00+01:00",
+			"timezone": "Europe/Berlin",
+		},
+	}, h, http.StatusCreated)
+	id := created["id"].(string)
+	recurrence := created["recurrence"].(map[string]any)
+	next, err := time.Parse(time.RFC3339, recurrence["next_at"].(string))
+	require.NoError(t, err)
+	assert.True(t, next.After(time.Now()))
+
+	makeHTTPRequest(t, "POST", templatesURL, map[string]any{
+		"name": "Hourly", "title": "x", "recurrence": map[string]any{"rrule": "FREQ=HOURLY"},
+	}, h, http.StatusBadRequest)
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	note := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes/from-template/"+id, nil, h, http.StatusCreated)["note"].(map[string]any)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	year, week := time.Now().In(berlin).ISOWeek()
+	assert.Equal(t, fmt.Sprintf("Retro %d-W%02d", year, week), note["title"])
+	assert.Equal(t, "#FFD700", note["color"])
+
+	msg := <-messages
+	assert.Equal(t, "created", msg["type"], "notes from templates are broadcast like any other")
+	assert.Equal(t, note["id"], msg["note"].(map[string]any)["id"])
+
+	updated := makeHTTPRequest(t, "PATCH", templatesURL+"/"+id, map[string]any{"recurrence": map[string]any{"rrule": ""}}, h, http.StatusOK)
+	assert.NotContains(t, updated, "recurrence")
+
+	other := setupTestUser(t, env, "templates-other@example.com", "Password123")
+	makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes/from-template/"+id, nil, getAuthHeaders(t, other), http.StatusNotFound)
+
+	list := makeHTTPRequest(t, "GET", templatesURL, nil, h, http.StatusOK)
+	assert.Len(t, list["templates"], 1)
+	makeHTTPRequest(t, "DELETE", templatesURL+"/"+id, nil, h, http.StatusNoContent)
+	makeHTTPRequest(t, "GET", templatesURL+"/"+id, nil, h, http.StatusNotFound)
+}
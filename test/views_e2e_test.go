@@ -0,0 +1,73 @@
+//go:build e2e
+
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedViewsE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "views@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Urgent: fix login", Body: "asap", Color: testColor})
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Someday", Body: "maybe", Color: "#00FF00"})
+
+	view := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/views", map[string]any{
+		"name":    "Red",
+		"request": map[string]any{"q": "color:#FF0000", "sort": "title", "order": "asc", "limit": 5},
+	}, h, http.StatusCreated)
+	viewID := view["id"].(string)
+	assert.Equal(t, float64(1), view["count"])
+	assert.NotContains(t, view["request"], "limit", "pagination is not stored")
+
+	page := makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/views/"+viewID+"/notes?limit=10", nil, h, http.StatusOK)
+	require.Len(t, page["notes"], 1)
+	assert.Equal(t, "Urgent: fix login", page["notes"].([]any)[0].(map[string]any)["title"])
+
+	ws := setupWebSocket(t, env, token)
+	defer ws.Close()
+	messages := make(chan map[string]any, 10)
+	startWebSocketListener(ws, messages)
+
+	createAndVerifyNote(t, env, h, NoteParams{Title: "Urgent: pay invoice", Body: "today", Color: testColor})
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-messages:
+			if msg["type"] != "view_counts" {
+				continue
+			}
+			counts := msg["views"].([]any)
+			require.Len(t, counts, 1)
+			assert.Equal(t, viewID, counts[0].(map[string]any)["view_id"])
+			assert.Equal(t, float64(2), counts[0].(map[string]any)["count"])
+			return
+		case <-deadline:
+			t.Fatal("no view_counts event received")
+		}
+	}
+}
+
+func TestSavedViewsRejectBadQueryE2E(t *testing.T) {
+	env := SetupTestEnvironment(t)
+
+	token := setupTestUser(t, env, "badview@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+
+	resp := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/views", map[string]any{
+		"name":    "Broken",
+		"request": map[string]any{"q": "updated:>soon"},
+	}, h, http.StatusBadRequest)
+	assert.Contains(t, resp["error"], "updated:>soon")
+
+	makeHTTPRequest(t, "GET", env.BaseURL+"/api/v1/views/"+"683cdb8aa96ad71e8e075bd7"+"/notes", nil, h, http.StatusNotFound)
+}
@@ -0,0 +1,87 @@
+//go:build e2e
+
+package test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooksE2E(t *testing.T) {
+	env := SetupTestEnvironmentWithEnv(t, map[string]string{"WEBHOOK_ALLOW_PRIVATE": "true"})
+
+	const secret = "e2e-webhook-secret"
+	received := make(chan map[string]any, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get("X-NotePulse-Timestamp") + "." + string(body)))
+		if r.Header.Get("X-NotePulse-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event map[string]any
+		_ = json.Unmarshal(body, &event)
+		received <- event
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	token := setupTestUser(t, env, "webhooks@example.com", "Password123")
+	h := getAuthHeaders(t, token)
+	hooksURL := env.BaseURL + "/api/v1/webhooks"
+
+	makeHTTPRequest(t, "POST", hooksURL, map[string]any{"url": "ftp://example.com", "secret": secret}, h, http.StatusBadRequest)
+	hook := makeHTTPRequest(t, "POST", hooksURL, map[string]any{"url": receiver.URL, "secret": secret, "events": []string{"created"}}, h, http.StatusCreated)
+	hookID := hook["id"].(string)
+	assert.NotContains(t, hook, "secret")
+
+	created := makeHTTPRequest(t, "POST", env.BaseURL+"/api/v1/notes", map[string]any{"title": "Hooked"}, h, http.StatusCreated)
+	noteID := created["note"].(map[string]any)["id"].(string)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "created", event["type"])
+		assert.Equal(t, noteID, event["note"].(map[string]any)["id"])
+	case <-time.After(10 * time.Second):
+		t.Fatal("no webhook delivery")
+	}
+
+	var deliveries []any
+	require.Eventually(t, func() bool {
+		log := makeHTTPRequest(t, "GET", hooksURL+"/"+hookID+"/deliveries", nil, h, http.StatusOK)
+		deliveries = log["deliveries"].([]any)
+		return len(deliveries) == 1 && deliveries[0].(map[string]any)["status"] == "delivered"
+	}, 5*time.Second, 100*time.Millisecond)
+	deliveryID := deliveries[0].(map[string]any)["id"].(string)
+
+	// Updates are filtered out
+	makeHTTPRequest(t, "PATCH", env.BaseURL+"/api/v1/notes/"+noteID, map[string]any{"title": "Renamed"}, h, http.StatusOK)
+
+	again := makeHTTPRequest(t, "POST", hooksURL+"/"+hookID+"/deliveries/"+deliveryID+"/redeliver", nil, h, http.StatusAccepted)
+	assert.Equal(t, deliveryID, again["redelivery_of"])
+	select {
+	case event := <-received:
+		assert.Equal(t, "created", event["type"], "the redelivery repeats the original event")
+	case <-time.After(10 * time.Second):
+		t.Fatal("no redelivery")
+	}
+	makeHTTPRequest(t, "POST", hooksURL+"/"+hookID+"/deliveries/000000000000000000000000/redeliver", nil, h, http.StatusNotFound)
+
+	other := getAuthHeaders(t, setupTestUser(t, env, "webhooks-other@example.com", "Password123"))
+	makeHTTPRequest(t, "GET", hooksURL+"/"+hookID, nil, other, http.StatusNotFound)
+
+	makeHTTPRequest(t, "DELETE", hooksURL+"/"+hookID, nil, h, http.StatusNoContent)
+	list := makeHTTPRequest(t, "GET", hooksURL, nil, h, http.StatusOK)
+	assert.Empty(t, list["webhooks"])
+}